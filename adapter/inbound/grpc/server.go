@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -9,6 +10,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	proto "github.com/ajkula/GoRTMS/adapter/inbound/grpc/proto/generated"
@@ -351,9 +353,30 @@ func (s *Server) PublishMessage(
 		}
 	}
 
+	// gRPC calls aren't authenticated, only the peer address is known
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			ip = p.Addr.String()
+		}
+		message.SetPublisher(model.Publisher{ClientIP: ip})
+	}
+
 	// Publier le message
 	if err := s.messageService.PublishMessage(req.DomainName, req.QueueName, message); err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to publish message: %v", err)
+		switch {
+		case errors.Is(err, model.ErrDuplicatePublish):
+			// retried producer sequence, reply with the original ID
+		case errors.Is(err, model.ErrInvalidProducerSequence), errors.Is(err, model.ErrInvalidMessage),
+			errors.Is(err, model.ErrEnrichmentPayload):
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to publish message: %v", err)
+		case errors.Is(err, model.ErrStaleProducerSequence), errors.Is(err, model.ErrQueueReadOnly):
+			return nil, status.Errorf(codes.FailedPrecondition, "Failed to publish message: %v", err)
+		case errors.Is(err, model.ErrInjectedFault), errors.Is(err, model.ErrInjectedCircuitOpen), errors.Is(err, model.ErrStandbyReadOnly):
+			return nil, status.Errorf(codes.Unavailable, "Failed to publish message: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "Failed to publish message: %v", err)
+		}
 	}
 
 	return &proto.PublishMessageResponse{
@@ -366,17 +389,33 @@ func (s *Server) ConsumeMessages(
 	ctx context.Context,
 	req *proto.ConsumeMessagesRequest,
 ) (*proto.ConsumeMessagesResponse, error) {
-	// Créer un contexte avec timeout si nécessaire
+	// Créer un contexte avec timeout si nécessaire, l'attente s'arrêtant
+	// aussi dès que le client annule l'appel
+	options := &inbound.ConsumeOptions{MaxCount: int(req.MaxMessages)}
 	if req.TimeoutSeconds > 0 {
 		var cancel context.CancelFunc
-		_, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
 		defer cancel()
+		options.Timeout = time.Duration(req.TimeoutSeconds) * time.Second
 	}
 
-	// Récupérer les messages
+	// Récupérer les messages, max_messages bornant aussi le prefetch demandé
+	// au groupe pour ne pas bufferiser plus que le lot attendu
 	var messages []*model.Message
 	for i := 0; i < int(req.MaxMessages); i++ {
-		message, err := s.messageService.ConsumeMessageWithGroup(ctx, req.DomainName, req.QueueName, "", &inbound.ConsumeOptions{})
+		message, err := s.messageService.ConsumeMessageWithGroup(ctx, req.DomainName, req.QueueName, "", options)
+		if errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		if errors.Is(err, context.Canceled) {
+			return nil, status.Error(codes.Canceled, "consumer left")
+		}
+		if errors.Is(err, model.ErrStandbyReadOnly) {
+			return nil, status.Errorf(codes.Unavailable, "Failed to consume message: %v", err)
+		}
+		if errors.Is(err, model.ErrQueueWriteOnly) || errors.Is(err, model.ErrImplicitGroupDenied) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Failed to consume message: %v", err)
+		}
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "Failed to consume message: %v", err)
 		}
@@ -391,19 +430,7 @@ func (s *Server) ConsumeMessages(
 	// Convertir les messages
 	protoMessages := make([]*proto.Message, len(messages))
 	for i, message := range messages {
-		// Convertir les métadonnées
-		metadata := make(map[string]string)
-		for key, value := range message.Metadata {
-			metadata[key] = fmt.Sprintf("%v", value)
-		}
-
-		protoMessages[i] = &proto.Message{
-			Id:        message.ID,
-			Payload:   message.Payload,
-			Headers:   message.Headers,
-			Metadata:  metadata,
-			Timestamp: message.Timestamp.UnixNano(),
-		}
+		protoMessages[i] = toProtoMessage(message)
 	}
 
 	return &proto.ConsumeMessagesResponse{
@@ -417,12 +444,13 @@ func (s *Server) SubscribeToQueue(
 	stream proto.GoRTMS_SubscribeToQueueServer,
 ) error {
 	// Créer un canal pour recevoir les messages
-	messageChan := make(chan *model.Message)
+	messageChan := make(chan *proto.Message)
 
-	// Créer un handler qui enverra les messages au canal
+	// Créer un handler qui convertit le message avant de l'envoyer au canal,
+	// l'enveloppe étant recyclée dès le retour du handler
 	handler := func(message *model.Message) error {
 		select {
-		case messageChan <- message:
+		case messageChan <- toProtoMessage(message):
 			return nil
 		case <-stream.Context().Done():
 			return stream.Context().Err()
@@ -452,22 +480,7 @@ func (s *Server) SubscribeToQueue(
 		select {
 		case <-stream.Context().Done():
 			return stream.Context().Err()
-		case message := <-messageChan:
-			// Convertir les métadonnées
-			metadata := make(map[string]string)
-			for key, value := range message.Metadata {
-				metadata[key] = fmt.Sprintf("%v", value)
-			}
-
-			// Convertir le message
-			protoMessage := &proto.Message{
-				Id:        message.ID,
-				Payload:   message.Payload,
-				Headers:   message.Headers,
-				Metadata:  metadata,
-				Timestamp: message.Timestamp.UnixNano(),
-			}
-
+		case protoMessage := <-messageChan:
 			// Envoyer le message au client
 			if err := stream.Send(&proto.MessageResponse{
 				Message: protoMessage,
@@ -567,3 +580,20 @@ func (s *Server) ListRoutingRules(
 		Rules: protoRules,
 	}, nil
 }
+
+// toProtoMessage convertit un message du domaine en message protobuf
+func toProtoMessage(message *model.Message) *proto.Message {
+	// Convertir les métadonnées
+	metadata := make(map[string]string)
+	for key, value := range message.Metadata {
+		metadata[key] = fmt.Sprintf("%v", value)
+	}
+
+	return &proto.Message{
+		Id:        message.ID,
+		Payload:   message.Payload,
+		Headers:   message.Headers,
+		Metadata:  metadata,
+		Timestamp: message.Timestamp.UnixNano(),
+	}
+}
@@ -0,0 +1,340 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Types de paquets MQTT 3.1.1, dans les 4 bits de poids fort de l'en-tête fixe
+const (
+	packetConnect     byte = 1
+	packetConnack     byte = 2
+	packetPublish     byte = 3
+	packetPuback      byte = 4
+	packetSubscribe   byte = 8
+	packetSuback      byte = 9
+	packetUnsubscribe byte = 10
+	packetUnsuback    byte = 11
+	packetPingreq     byte = 12
+	packetPingresp    byte = 13
+	packetDisconnect  byte = 14
+)
+
+// Codes de retour du CONNACK
+const (
+	connackAccepted           byte = 0x00
+	connackBadProtocol        byte = 0x01
+	connackIdentifierRejected byte = 0x02
+	connackBadCredentials     byte = 0x04
+	connackNotAuthorized      byte = 0x05
+)
+
+// subackFailure refuse un filtre dans un SUBACK
+const subackFailure byte = 0x80
+
+// protocolLevel est le niveau de protocole de MQTT 3.1.1
+const protocolLevel byte = 4
+
+// MaxPacketSize borne la taille restante d'un paquet reçu
+const MaxPacketSize = 1 << 20
+
+var (
+	errMalformedPacket = errors.New("malformed MQTT packet")
+	errPacketTooLarge  = errors.New("MQTT packet too large")
+)
+
+// readPacket lit un paquet et renvoie son type, ses drapeaux et son corps
+func readPacket(r *bufio.Reader) (byte, byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	// Longueur restante, encodée sur 1 à 4 octets de 7 bits
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, 0, nil, errMalformedPacket
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > MaxPacketSize {
+		return 0, 0, nil, errPacketTooLarge
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return header >> 4, header & 0x0f, body, nil
+}
+
+// encodePacket construit un paquet à partir de son en-tête et de son corps
+func encodePacket(header byte, body []byte) []byte {
+	packet := make([]byte, 0, len(body)+5)
+	packet = append(packet, header)
+	length := len(body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if length == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// decoder lit les champs du corps d'un paquet
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.buf) < 1 {
+		d.err = errMalformedPacket
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) uint16() uint16 {
+	if d.err != nil || len(d.buf) < 2 {
+		d.err = errMalformedPacket
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.buf)
+	d.buf = d.buf[2:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.uint16())
+	if d.err != nil || len(d.buf) < n {
+		d.err = errMalformedPacket
+		return nil
+	}
+	b := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+// appendString ajoute une chaîne préfixée par sa longueur
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// connectPacket est le CONNECT d'un client
+type connectPacket struct {
+	protocolName string
+	level        byte
+	cleanSession bool
+	keepAlive    uint16
+	clientID     string
+	will         *publishPacket
+	username     string
+	hasUsername  bool
+	password     string
+	hasPassword  bool
+}
+
+// parseConnect décode un CONNECT, le niveau de protocole est vérifié par l'appelant
+func parseConnect(body []byte) (*connectPacket, error) {
+	d := &decoder{buf: body}
+	p := &connectPacket{
+		protocolName: d.string(),
+		level:        d.byte(),
+	}
+	flags := d.byte()
+	p.keepAlive = d.uint16()
+	if d.err != nil {
+		return nil, d.err
+	}
+	// MQIsdp est le nom de MQTT 3.1, refusé ensuite par son niveau
+	if (p.protocolName != "MQTT" && p.protocolName != "MQIsdp") || flags&0x01 != 0 {
+		return nil, errMalformedPacket
+	}
+	if p.level != protocolLevel {
+		return p, nil
+	}
+
+	p.cleanSession = flags&0x02 != 0
+	p.clientID = d.string()
+	if flags&0x04 != 0 {
+		p.will = &publishPacket{
+			topic:  d.string(),
+			qos:    (flags >> 3) & 0x03,
+			retain: flags&0x20 != 0,
+		}
+		p.will.payload = d.bytes()
+		if p.will.qos == 3 {
+			return nil, errMalformedPacket
+		}
+	} else if flags&0x38 != 0 {
+		return nil, errMalformedPacket
+	}
+	if p.hasUsername = flags&0x80 != 0; p.hasUsername {
+		p.username = d.string()
+	}
+	if p.hasPassword = flags&0x40 != 0; p.hasPassword {
+		if !p.hasUsername {
+			return nil, errMalformedPacket
+		}
+		p.password = d.string()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return p, nil
+}
+
+// encodeConnack construit un CONNACK, sans session conservée
+func encodeConnack(code byte) []byte {
+	return encodePacket(packetConnack<<4, []byte{0, code})
+}
+
+// publishPacket est un PUBLISH reçu ou envoyé
+type publishPacket struct {
+	topic    string
+	packetID uint16
+	qos      byte
+	retain   bool
+	dup      bool
+	payload  []byte
+}
+
+// parsePublish décode un PUBLISH à partir des drapeaux de l'en-tête fixe
+func parsePublish(flags byte, body []byte) (*publishPacket, error) {
+	d := &decoder{buf: body}
+	p := &publishPacket{
+		dup:    flags&0x08 != 0,
+		qos:    (flags >> 1) & 0x03,
+		retain: flags&0x01 != 0,
+		topic:  d.string(),
+	}
+	if p.qos > 0 {
+		p.packetID = d.uint16()
+		if d.err == nil && p.packetID == 0 {
+			return nil, errMalformedPacket
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if p.qos == 3 {
+		return nil, errMalformedPacket
+	}
+	p.payload = d.buf
+	return p, nil
+}
+
+// encodePublish construit un PUBLISH
+func encodePublish(p *publishPacket) []byte {
+	header := packetPublish<<4 | p.qos<<1
+	if p.dup {
+		header |= 0x08
+	}
+	if p.retain {
+		header |= 0x01
+	}
+	body := appendString(make([]byte, 0, len(p.topic)+len(p.payload)+4), p.topic)
+	if p.qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, p.packetID)
+	}
+	return encodePacket(header, append(body, p.payload...))
+}
+
+// encodePuback construit un PUBACK
+func encodePuback(packetID uint16) []byte {
+	return encodePacket(packetPuback<<4, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// topicRequest est un filtre demandé dans un SUBSCRIBE
+type topicRequest struct {
+	filter string
+	qos    byte
+}
+
+// parseSubscribe décode un SUBSCRIBE, qui contient au moins un filtre
+func parseSubscribe(body []byte) (uint16, []topicRequest, error) {
+	d := &decoder{buf: body}
+	packetID := d.uint16()
+	var requests []topicRequest
+	for d.err == nil && len(d.buf) > 0 {
+		request := topicRequest{filter: d.string(), qos: d.byte()}
+		if request.qos > 2 {
+			return 0, nil, errMalformedPacket
+		}
+		requests = append(requests, request)
+	}
+	if d.err != nil || len(requests) == 0 {
+		return 0, nil, errMalformedPacket
+	}
+	return packetID, requests, nil
+}
+
+// encodeSuback construit un SUBACK avec un code par filtre
+func encodeSuback(packetID uint16, codes []byte) []byte {
+	body := binary.BigEndian.AppendUint16(make([]byte, 0, len(codes)+2), packetID)
+	return encodePacket(packetSuback<<4, append(body, codes...))
+}
+
+// parseUnsubscribe décode un UNSUBSCRIBE, qui contient au moins un filtre
+func parseUnsubscribe(body []byte) (uint16, []string, error) {
+	d := &decoder{buf: body}
+	packetID := d.uint16()
+	var filters []string
+	for d.err == nil && len(d.buf) > 0 {
+		filters = append(filters, d.string())
+	}
+	if d.err != nil || len(filters) == 0 {
+		return 0, nil, errMalformedPacket
+	}
+	return packetID, filters, nil
+}
+
+// encodeUnsuback construit un UNSUBACK
+func encodeUnsuback(packetID uint16) []byte {
+	return encodePacket(packetUnsuback<<4, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// encodePingresp construit un PINGRESP
+func encodePingresp() []byte {
+	return encodePacket(packetPingresp<<4, nil)
+}
+
+// packetName nomme un type de paquet dans les journaux
+func packetName(packetType byte) string {
+	names := map[byte]string{
+		packetConnect: "CONNECT", packetConnack: "CONNACK", packetPublish: "PUBLISH",
+		packetPuback: "PUBACK", packetSubscribe: "SUBSCRIBE", packetSuback: "SUBACK",
+		packetUnsubscribe: "UNSUBSCRIBE", packetUnsuback: "UNSUBACK", packetPingreq: "PINGREQ",
+		packetPingresp: "PINGRESP", packetDisconnect: "DISCONNECT",
+	}
+	if name, ok := names[packetType]; ok {
+		return name
+	}
+	return fmt.Sprintf("packet type %d", packetType)
+}
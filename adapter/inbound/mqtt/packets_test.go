@@ -0,0 +1,146 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketRemainingLength(t *testing.T) {
+	for _, size := range []int{0, 127, 128, 16383, 16384, 200000} {
+		body := bytes.Repeat([]byte{'x'}, size)
+		packet := encodePacket(packetPublish<<4, body)
+
+		packetType, flags, decoded, err := readPacket(bufio.NewReader(bytes.NewReader(packet)))
+		require.NoError(t, err)
+		assert.Equal(t, packetPublish, packetType)
+		assert.Zero(t, flags)
+		assert.Equal(t, size, len(decoded))
+	}
+
+	// five length bytes are malformed
+	_, _, _, err := readPacket(bufio.NewReader(bytes.NewReader([]byte{0x30, 0xff, 0xff, 0xff, 0xff, 0x01})))
+	assert.ErrorIs(t, err, errMalformedPacket)
+
+	// oversized packets are refused before reading their body
+	oversized := encodePacket(packetPublish<<4, nil)[:1]
+	oversized = append(oversized, 0x80, 0x80, 0x80, 0x01)
+	_, _, _, err = readPacket(bufio.NewReader(bytes.NewReader(oversized)))
+	assert.ErrorIs(t, err, errPacketTooLarge)
+}
+
+func TestParseConnect(t *testing.T) {
+	packet := encodeConnect(connectPacket{
+		clientID:     "sensor-1",
+		cleanSession: true,
+		keepAlive:    30,
+		will:         &publishPacket{topic: "sensors/status", payload: []byte("offline"), qos: 1},
+		username:     "svc",
+		hasUsername:  true,
+		password:     "secret",
+		hasPassword:  true,
+	})
+	_, _, body, err := readPacket(bufio.NewReader(bytes.NewReader(packet)))
+	require.NoError(t, err)
+
+	connect, err := parseConnect(body)
+	require.NoError(t, err)
+	assert.Equal(t, protocolLevel, connect.level)
+	assert.Equal(t, "sensor-1", connect.clientID)
+	assert.True(t, connect.cleanSession)
+	assert.Equal(t, uint16(30), connect.keepAlive)
+	assert.Equal(t, "sensors/status", connect.will.topic)
+	assert.Equal(t, []byte("offline"), connect.will.payload)
+	assert.Equal(t, byte(1), connect.will.qos)
+	assert.Equal(t, "svc", connect.username)
+	assert.Equal(t, "secret", connect.password)
+
+	// a password needs a username
+	_, err = parseConnect(append(appendString(nil, "MQTT"), protocolLevel, 0x40, 0, 0, 0, 0))
+	assert.ErrorIs(t, err, errMalformedPacket)
+
+	// truncated packets are malformed
+	_, err = parseConnect(body[:len(body)-3])
+	assert.ErrorIs(t, err, errMalformedPacket)
+}
+
+func TestPublishRoundTrip(t *testing.T) {
+	packet := encodePublish(&publishPacket{topic: "shop/orders", packetID: 7, qos: 1, payload: []byte(`{"id":1}`)})
+
+	packetType, flags, body, err := readPacket(bufio.NewReader(bytes.NewReader(packet)))
+	require.NoError(t, err)
+	require.Equal(t, packetPublish, packetType)
+
+	publish, err := parsePublish(flags, body)
+	require.NoError(t, err)
+	assert.Equal(t, "shop/orders", publish.topic)
+	assert.Equal(t, uint16(7), publish.packetID)
+	assert.Equal(t, byte(1), publish.qos)
+	assert.Equal(t, []byte(`{"id":1}`), publish.payload)
+
+	// QoS 1 publishes need a packet identifier
+	_, err = parsePublish(0x02, append(appendString(nil, "a/b"), 0, 0))
+	assert.ErrorIs(t, err, errMalformedPacket)
+}
+
+func TestParseSubscribe(t *testing.T) {
+	body := []byte{0, 3}
+	body = append(appendString(body, "shop/+"), 1)
+	body = append(appendString(body, "shop/orders"), 0)
+
+	packetID, requests, err := parseSubscribe(body)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(3), packetID)
+	assert.Equal(t, []topicRequest{{filter: "shop/+", qos: 1}, {filter: "shop/orders", qos: 0}}, requests)
+
+	_, _, err = parseSubscribe([]byte{0, 3})
+	assert.ErrorIs(t, err, errMalformedPacket)
+
+	_, filters, err := parseUnsubscribe(appendString([]byte{0, 4}, "shop/orders"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop/orders"}, filters)
+}
+
+// encodeConnect builds the CONNECT of a client
+func encodeConnect(p connectPacket) []byte {
+	flags := byte(0)
+	if p.cleanSession {
+		flags |= 0x02
+	}
+	body := appendString(nil, "MQTT")
+	level := p.level
+	if level == 0 {
+		level = protocolLevel
+	}
+	if p.will != nil {
+		flags |= 0x04 | p.will.qos<<3
+	}
+	if p.hasUsername {
+		flags |= 0x80
+	}
+	if p.hasPassword {
+		flags |= 0x40
+	}
+	body = append(body, level, flags, byte(p.keepAlive>>8), byte(p.keepAlive))
+	body = appendString(body, p.clientID)
+	if p.will != nil {
+		body = appendString(body, p.will.topic)
+		body = appendString(body, string(p.will.payload))
+	}
+	if p.hasUsername {
+		body = appendString(body, p.username)
+	}
+	if p.hasPassword {
+		body = appendString(body, p.password)
+	}
+	return encodePacket(packetConnect<<4, body)
+}
+
+func TestPacketName(t *testing.T) {
+	assert.Equal(t, "PINGREQ", packetName(packetPingreq))
+	assert.True(t, strings.HasPrefix(packetName(15), "packet type"))
+}
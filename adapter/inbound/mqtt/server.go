@@ -0,0 +1,599 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+const (
+	// connectTimeout borne l'attente du CONNECT d'une nouvelle connexion
+	connectTimeout = 10 * time.Second
+
+	// writeTimeout borne l'écriture d'un paquet vers un client lent
+	writeTimeout = 10 * time.Second
+
+	// OutboxSize borne les messages en attente d'envoi d'une session, les
+	// suivants étant écartés
+	OutboxSize = 256
+)
+
+// Server est un broker MQTT 3.1.1 qui publie et consomme à travers le
+// MessageService, les topics étant résolus en files
+type Server struct {
+	messageService inbound.MessageService
+	serviceRepo    outbound.ServiceRepository
+	mapper         *topicMapper
+	rootCtx        context.Context
+
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	sessions map[string]*session
+	wg       sync.WaitGroup
+	seq      atomic.Uint64
+}
+
+// NewServer crée un nouveau serveur MQTT, les topics suivant le découpage
+// domaine/file tant qu'aucune correspondance n'est déclarée
+func NewServer(messageService inbound.MessageService, rootCtx context.Context) *Server {
+	return &Server{
+		messageService: messageService,
+		mapper:         &topicMapper{},
+		rootCtx:        rootCtx,
+		conns:          make(map[net.Conn]struct{}),
+		sessions:       make(map[string]*session),
+	}
+}
+
+// SetTopics déclare les correspondances entre filtres de topics et files
+func (s *Server) SetTopics(mappings []TopicMapping) error {
+	mapper, err := newTopicMapper(mappings)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.mapper = mapper
+	s.mu.Unlock()
+	return nil
+}
+
+// SetServiceRepository active l'authentification : le nom d'utilisateur du
+// CONNECT est l'ID d'un compte de service et le mot de passe son secret
+func (s *Server) SetServiceRepository(repo outbound.ServiceRepository) {
+	s.serviceRepo = repo
+}
+
+// Start démarre le serveur MQTT
+func (s *Server) Start(address string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+	s.listener = lis
+
+	s.wg.Add(1)
+	go s.serve(lis)
+
+	log.Printf("MQTT server started on %s", address)
+	return nil
+}
+
+// serve accepte les connexions jusqu'à la fermeture du listener
+func (s *Server) serve(lis net.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("MQTT accept failed: %v", err)
+			}
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Stop arrête le serveur MQTT et ferme les sessions
+func (s *Server) Stop() {
+	log.Println("Stopping MQTT server...")
+
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+	for _, sess := range sessions {
+		sess.close(true)
+	}
+
+	// Fermer aussi les connexions qui n'ont pas encore envoyé leur CONNECT
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	// Attendre avec timeout
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		log.Println("MQTT server stop timed out")
+	}
+
+	log.Println("MQTT server shutdown complete")
+}
+
+// topics renvoie les correspondances courantes
+func (s *Server) topics() *topicMapper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mapper
+}
+
+// handleConn traite une connexion, du CONNECT à sa fermeture
+func (s *Server) handleConn(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+
+	// Le premier paquet doit être un CONNECT
+	conn.SetReadDeadline(time.Now().Add(connectTimeout))
+	packetType, _, body, err := readPacket(reader)
+	if err != nil || packetType != packetConnect {
+		conn.Close()
+		return
+	}
+	connect, err := parseConnect(body)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	refuse := func(code byte) {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		conn.Write(encodeConnack(code))
+		conn.Close()
+	}
+	if connect.level != protocolLevel {
+		refuse(connackBadProtocol)
+		return
+	}
+
+	// Seules les sessions propres sont tenues, sans état entre deux connexions
+	if connect.clientID == "" {
+		if !connect.cleanSession {
+			refuse(connackIdentifierRejected)
+			return
+		}
+		connect.clientID = fmt.Sprintf("gortms-%d", s.seq.Add(1))
+	}
+	if connect.will != nil && (connect.will.qos > 1 || !validTopicName(connect.will.topic)) {
+		refuse(connackNotAuthorized)
+		return
+	}
+
+	account, code := s.authenticate(connect, conn.RemoteAddr().String())
+	if code != connackAccepted {
+		refuse(code)
+		return
+	}
+
+	sess := &session{
+		server:   s,
+		conn:     conn,
+		reader:   reader,
+		clientID: connect.clientID,
+		account:  account,
+		will:     connect.will,
+		outbox:   make(chan *publishPacket, OutboxSize),
+		subs:     make(map[string]*subscription),
+		done:     make(chan struct{}),
+	}
+	if connect.keepAlive > 0 {
+		// La spécification accorde une fois et demie l'intervalle annoncé
+		sess.keepAlive = time.Duration(connect.keepAlive) * time.Second * 3 / 2
+	}
+
+	// Un client qui se reconnecte avec le même ID remplace sa session
+	s.mu.Lock()
+	previous := s.sessions[sess.clientID]
+	s.sessions[sess.clientID] = sess
+	s.mu.Unlock()
+	if previous != nil {
+		previous.close(false)
+	}
+
+	if err := sess.write(encodeConnack(connackAccepted)); err != nil {
+		sess.close(false)
+		return
+	}
+
+	go sess.writeLoop()
+	sess.readLoop()
+}
+
+// authenticate vérifie les identifiants du CONNECT quand l'authentification
+// est active, et renvoie le compte de service du client
+func (s *Server) authenticate(connect *connectPacket, remoteAddr string) (*model.ServiceAccount, byte) {
+	if s.serviceRepo == nil {
+		return nil, connackAccepted
+	}
+	if !connect.hasUsername || !connect.hasPassword {
+		return nil, connackNotAuthorized
+	}
+
+	ctx, cancel := context.WithTimeout(s.rootCtx, 5*time.Second)
+	defer cancel()
+	account, err := s.serviceRepo.GetByID(ctx, connect.username)
+	if err != nil || account == nil ||
+		subtle.ConstantTimeCompare([]byte(account.Secret), []byte(connect.password)) != 1 {
+		return nil, connackBadCredentials
+	}
+	if !account.Enabled {
+		return nil, connackNotAuthorized
+	}
+	if len(account.IPWhitelist) > 0 && !model.IPAllowed(remoteAddr, account.IPWhitelist) {
+		return nil, connackNotAuthorized
+	}
+
+	s.serviceRepo.UpdateLastUsed(ctx, account.ID)
+	return account, connackAccepted
+}
+
+// subscription est un abonnement d'une session à une file
+type subscription struct {
+	filter string
+	domain string
+	queue  string
+	id     string
+}
+
+// session est la connexion d'un client MQTT
+type session struct {
+	server    *Server
+	conn      net.Conn
+	reader    *bufio.Reader
+	clientID  string
+	account   *model.ServiceAccount
+	keepAlive time.Duration
+
+	// will est publié si la connexion se termine sans DISCONNECT
+	will *publishPacket
+
+	writeMu sync.Mutex
+	outbox  chan *publishPacket
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// permitted vérifie une permission du compte de la session, tout étant
+// permis sans authentification
+func (sess *session) permitted(action, domain string) bool {
+	return sess.account == nil || sess.account.HasPermission(action+":"+domain)
+}
+
+// write envoie un paquet encodé
+func (sess *session) write(packet []byte) error {
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+	sess.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := sess.conn.Write(packet)
+	return err
+}
+
+// readLoop traite les paquets du client jusqu'à la fermeture
+func (sess *session) readLoop() {
+	graceful := false
+	defer func() {
+		sess.close(graceful)
+	}()
+
+	for {
+		if sess.keepAlive > 0 {
+			sess.conn.SetReadDeadline(time.Now().Add(sess.keepAlive))
+		} else {
+			sess.conn.SetReadDeadline(time.Time{})
+		}
+
+		packetType, flags, body, err := readPacket(sess.reader)
+		if err != nil {
+			return
+		}
+
+		switch packetType {
+		case packetPublish:
+			packet, err := parsePublish(flags, body)
+			if err != nil {
+				return
+			}
+			if err := sess.handlePublish(packet); err != nil {
+				log.Printf("MQTT client %s disconnected: %v", sess.clientID, err)
+				return
+			}
+
+		case packetSubscribe:
+			if flags != 0x02 {
+				return
+			}
+			packetID, requests, err := parseSubscribe(body)
+			if err != nil {
+				return
+			}
+			codes := make([]byte, len(requests))
+			for i, request := range requests {
+				codes[i] = sess.subscribe(request)
+			}
+			if err := sess.write(encodeSuback(packetID, codes)); err != nil {
+				return
+			}
+
+		case packetUnsubscribe:
+			if flags != 0x02 {
+				return
+			}
+			packetID, filters, err := parseUnsubscribe(body)
+			if err != nil {
+				return
+			}
+			for _, filter := range filters {
+				sess.unsubscribe(filter)
+			}
+			if err := sess.write(encodeUnsuback(packetID)); err != nil {
+				return
+			}
+
+		case packetPingreq:
+			if err := sess.write(encodePingresp()); err != nil {
+				return
+			}
+
+		case packetDisconnect:
+			graceful = true
+			return
+
+		default:
+			// Un second CONNECT ou un paquet inconnu est une violation du protocole
+			log.Printf("MQTT client %s sent an unexpected %s", sess.clientID, packetName(packetType))
+			return
+		}
+	}
+}
+
+// handlePublish publie un message reçu dans la file de son topic. Une erreur
+// ferme la connexion, le client renvoyant alors ses messages QoS 1
+func (sess *session) handlePublish(packet *publishPacket) error {
+	if packet.qos > 1 {
+		return errors.New("QoS 2 is not supported")
+	}
+	if !validTopicName(packet.topic) {
+		return fmt.Errorf("invalid topic name: %q", packet.topic)
+	}
+
+	if err := sess.publish(packet); err != nil {
+		return err
+	}
+	if packet.qos == 1 {
+		return sess.write(encodePuback(packet.packetID))
+	}
+	return nil
+}
+
+// publish transmet un PUBLISH au MessageService. Comme MQTT 3.1.1 n'a pas
+// d'acquittement négatif, un message refusé est écarté et seules les
+// indisponibilités passagères remontent une erreur
+func (sess *session) publish(packet *publishPacket) error {
+	domain, queue, ok := sess.server.topics().resolve(packet.topic)
+	if !ok {
+		log.Printf("MQTT client %s published to unmapped topic %s, message dropped", sess.clientID, packet.topic)
+		return nil
+	}
+	if !sess.permitted("publish", domain) {
+		log.Printf("MQTT client %s may not publish to %s, message dropped", sess.clientID, domain)
+		return nil
+	}
+
+	message := &model.Message{
+		ID:        fmt.Sprintf("mqtt-%d-%d", time.Now().UnixNano(), sess.server.seq.Add(1)),
+		Topic:     packet.topic,
+		Payload:   bytes.Clone(packet.payload),
+		Headers:   map[string]string{"mqtt-client-id": sess.clientID},
+		Timestamp: time.Now(),
+	}
+	publisher := model.Publisher{ClientIP: remoteIP(sess.conn.RemoteAddr())}
+	if sess.account != nil {
+		publisher.Principal = "service:" + sess.account.ID
+	}
+	message.SetPublisher(publisher)
+
+	err := sess.server.messageService.PublishMessage(domain, queue, message)
+	switch {
+	case err == nil, errors.Is(err, model.ErrDuplicatePublish):
+		return nil
+	case errors.Is(err, model.ErrInjectedFault), errors.Is(err, model.ErrInjectedCircuitOpen),
+		errors.Is(err, model.ErrStandbyReadOnly):
+		return fmt.Errorf("failed to publish to %s.%s: %w", domain, queue, err)
+	default:
+		log.Printf("MQTT client %s: failed to publish to %s.%s, message dropped: %v", sess.clientID, domain, queue, err)
+		return nil
+	}
+}
+
+// subscribe abonne la session à la file d'un filtre et renvoie le code du
+// SUBACK. Seule la QoS 0 est accordée : les abonnés reçoivent les messages au
+// fil des publications, sans reprise de ceux écartés, et ne peuvent donc pas
+// promettre une livraison au moins une fois
+func (sess *session) subscribe(request topicRequest) byte {
+	if !validFilter(request.filter) {
+		return subackFailure
+	}
+	domain, queue, ok := sess.server.topics().resolveFilter(request.filter)
+	if !ok || !sess.permitted("consume", domain) {
+		return subackFailure
+	}
+	// Un nouvel abonnement au même filtre remplace le précédent
+	sess.unsubscribe(request.filter)
+
+	sub := &subscription{filter: request.filter, domain: domain, queue: queue}
+	id, err := sess.server.messageService.SubscribeToQueue(domain, queue, func(msg *model.Message) error {
+		sess.deliver(sub, msg)
+		return nil
+	})
+	if err != nil {
+		log.Printf("MQTT client %s: failed to subscribe to %s.%s: %v", sess.clientID, domain, queue, err)
+		return subackFailure
+	}
+	sub.id = id
+
+	sess.mu.Lock()
+	closed := sess.closed()
+	if !closed {
+		sess.subs[request.filter] = sub
+	}
+	sess.mu.Unlock()
+	if closed {
+		sess.server.messageService.UnsubscribeFromQueue(domain, queue, id)
+		return subackFailure
+	}
+	return 0
+}
+
+// unsubscribe retire l'abonnement d'un filtre
+func (sess *session) unsubscribe(filter string) {
+	sess.mu.Lock()
+	sub, ok := sess.subs[filter]
+	delete(sess.subs, filter)
+	sess.mu.Unlock()
+	if ok {
+		sess.server.messageService.UnsubscribeFromQueue(sub.domain, sub.queue, sub.id)
+	}
+}
+
+// deliver met en file un message d'un abonnement, écarté si la file d'envoi
+// est pleine. Le message n'est valable que pendant l'appel, son contenu est
+// donc copié
+func (sess *session) deliver(sub *subscription, msg *model.Message) {
+	// Les messages publiés en MQTT gardent leur topic, que le filtre doit couvrir
+	topic := msg.Topic
+	switch {
+	case topic != "" && validTopicName(topic):
+		if !matchTopic(sub.filter, topic) {
+			return
+		}
+	case !hasWildcard(sub.filter):
+		topic = sub.filter
+	default:
+		topic = sub.domain + "/" + sub.queue
+	}
+
+	packet := &publishPacket{topic: topic, payload: bytes.Clone(msg.Payload)}
+	select {
+	case sess.outbox <- packet:
+	case <-sess.done:
+	default:
+	}
+}
+
+// writeLoop envoie les messages des abonnements
+func (sess *session) writeLoop() {
+	for {
+		select {
+		case <-sess.done:
+			return
+		case packet := <-sess.outbox:
+			if err := sess.write(encodePublish(packet)); err != nil {
+				sess.close(false)
+				return
+			}
+		}
+	}
+}
+
+// closed indique si la session est fermée
+func (sess *session) closed() bool {
+	select {
+	case <-sess.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close ferme la session, publie son testament si elle se termine sans
+// DISCONNECT et retire ses abonnements
+func (sess *session) close(graceful bool) {
+	sess.closeOnce.Do(func() {
+		close(sess.done)
+		sess.conn.Close()
+
+		sess.mu.Lock()
+		subs := sess.subs
+		sess.subs = make(map[string]*subscription)
+		sess.mu.Unlock()
+		for _, sub := range subs {
+			sess.server.messageService.UnsubscribeFromQueue(sub.domain, sub.queue, sub.id)
+		}
+
+		s := sess.server
+		s.mu.Lock()
+		if s.sessions[sess.clientID] == sess {
+			delete(s.sessions, sess.clientID)
+		}
+		s.mu.Unlock()
+
+		if !graceful && sess.will != nil {
+			if err := sess.publish(sess.will); err != nil {
+				log.Printf("MQTT client %s: %v", sess.clientID, err)
+			}
+		}
+	})
+}
+
+// remoteIP renvoie l'IP d'une adresse distante, l'adresse entière si elle
+// n'a pas de port
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	ip, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return ip
+}
@@ -0,0 +1,302 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// brokerService records publishes and keeps the handler of each queue subscribed to
+type brokerService struct {
+	inbound.MessageService
+
+	mu        sync.Mutex
+	published []string // domain:queue:topic
+	handlers  map[string]model.MessageHandler
+	publishFn func() error
+}
+
+func (s *brokerService) PublishMessage(domainName, queueName string, message *model.Message) error {
+	if s.publishFn != nil {
+		if err := s.publishFn(); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.published = append(s.published, domainName+":"+queueName+":"+message.Topic+":"+string(message.Payload))
+	return nil
+}
+
+func (s *brokerService) SubscribeToQueue(domainName, queueName string, handler model.MessageHandler) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[domainName+":"+queueName] = handler
+	return "sub-" + queueName, nil
+}
+
+func (s *brokerService) UnsubscribeFromQueue(domainName, queueName, subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.handlers, domainName+":"+queueName)
+	return nil
+}
+
+func (s *brokerService) publishes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.published...)
+}
+
+func (s *brokerService) deliver(queueKey string, msg *model.Message) bool {
+	s.mu.Lock()
+	handler := s.handlers[queueKey]
+	s.mu.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(msg)
+	return true
+}
+
+// accountRepository serves service accounts from a map
+type accountRepository struct {
+	outbound.ServiceRepository
+	accounts map[string]*model.ServiceAccount
+}
+
+func (r *accountRepository) GetByID(ctx context.Context, serviceID string) (*model.ServiceAccount, error) {
+	if account, ok := r.accounts[serviceID]; ok {
+		return account, nil
+	}
+	return nil, errors.New("service not found")
+}
+
+func (r *accountRepository) UpdateLastUsed(ctx context.Context, serviceID string) error {
+	return nil
+}
+
+// testClient is the client end of a pipe served by the broker
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, s *Server) *testClient {
+	client, server := net.Pipe()
+	go s.handleConn(server)
+	t.Cleanup(func() { client.Close() })
+	return &testClient{t: t, conn: client, reader: bufio.NewReader(client)}
+}
+
+func (c *testClient) send(packet []byte) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetWriteDeadline(time.Now().Add(time.Second)))
+	_, err := c.conn.Write(packet)
+	require.NoError(c.t, err)
+}
+
+func (c *testClient) read() (byte, byte, []byte) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(time.Second)))
+	packetType, flags, body, err := readPacket(c.reader)
+	require.NoError(c.t, err)
+	return packetType, flags, body
+}
+
+func (c *testClient) connect(p connectPacket) byte {
+	c.t.Helper()
+	c.send(encodeConnect(p))
+	packetType, _, body := c.read()
+	require.Equal(c.t, packetConnack, packetType)
+	require.Len(c.t, body, 2)
+	return body[1]
+}
+
+func (c *testClient) subscribe(packetID uint16, filter string, qos byte) byte {
+	c.t.Helper()
+	body := append(appendString([]byte{byte(packetID >> 8), byte(packetID)}, filter), qos)
+	c.send(encodePacket(packetSubscribe<<4|0x02, body))
+	packetType, _, suback := c.read()
+	require.Equal(c.t, packetSuback, packetType)
+	require.Len(c.t, suback, 3)
+	return suback[2]
+}
+
+func TestServerPublish(t *testing.T) {
+	service := &brokerService{handlers: make(map[string]model.MessageHandler)}
+	s := NewServer(service, context.Background())
+	require.NoError(t, s.SetTopics([]TopicMapping{{Filter: "sensors/+/temperature", Domain: "iot", Queue: "temperatures"}}))
+
+	client := dial(t, s)
+	assert.Equal(t, connackAccepted, client.connect(connectPacket{clientID: "sensor-1", cleanSession: true}))
+
+	// QoS 1 publishes are acknowledged once published
+	client.send(encodePublish(&publishPacket{topic: "sensors/kitchen/temperature", packetID: 1, qos: 1, payload: []byte("21.5")}))
+	packetType, _, body := client.read()
+	assert.Equal(t, packetPuback, packetType)
+	assert.Equal(t, []byte{0, 1}, body)
+
+	// QoS 0 publishes to the default domain/queue layout
+	client.send(encodePublish(&publishPacket{topic: "shop/orders", payload: []byte("{}")}))
+
+	// unmapped topics are dropped but still acknowledged
+	client.send(encodePublish(&publishPacket{topic: "a/b/c", packetID: 2, qos: 1, payload: []byte("lost")}))
+	packetType, _, _ = client.read()
+	assert.Equal(t, packetPuback, packetType)
+
+	assert.Equal(t, []string{
+		"iot:temperatures:sensors/kitchen/temperature:21.5",
+		"shop:orders:shop/orders:{}",
+	}, service.publishes())
+
+	// QoS 2 isn't supported and closes the connection
+	client.send(encodePublish(&publishPacket{topic: "shop/orders", packetID: 3, qos: 2}))
+	require.NoError(t, client.conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, _, err := readPacket(client.reader)
+	assert.Error(t, err)
+}
+
+func TestServerPublishUnavailable(t *testing.T) {
+	service := &brokerService{
+		handlers:  make(map[string]model.MessageHandler),
+		publishFn: func() error { return model.ErrStandbyReadOnly },
+	}
+	s := NewServer(service, context.Background())
+
+	client := dial(t, s)
+	require.Equal(t, connackAccepted, client.connect(connectPacket{clientID: "c", cleanSession: true}))
+
+	// no PUBACK, the client publishes again once reconnected
+	client.send(encodePublish(&publishPacket{topic: "shop/orders", packetID: 1, qos: 1}))
+	require.NoError(t, client.conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, _, err := readPacket(client.reader)
+	assert.Error(t, err)
+}
+
+func TestServerSubscribe(t *testing.T) {
+	service := &brokerService{handlers: make(map[string]model.MessageHandler)}
+	s := NewServer(service, context.Background())
+	require.NoError(t, s.SetTopics([]TopicMapping{{Filter: "sensors/+/temperature", Domain: "iot", Queue: "temperatures"}}))
+
+	client := dial(t, s)
+	require.Equal(t, connackAccepted, client.connect(connectPacket{clientID: "dashboard", cleanSession: true}))
+
+	assert.Equal(t, byte(0), client.subscribe(1, "sensors/+/temperature", 1), "subscriptions are granted QoS 0")
+	assert.Equal(t, byte(0), client.subscribe(2, "shop/orders", 0))
+	assert.Equal(t, subackFailure, client.subscribe(3, "#", 0), "a filter must resolve to one queue")
+
+	// messages keep the topic they were published on
+	require.True(t, service.deliver("iot:temperatures", &model.Message{Topic: "sensors/attic/temperature", Payload: []byte("19")}))
+	packetType, flags, body := client.read()
+	require.Equal(t, packetPublish, packetType)
+	publish, err := parsePublish(flags, body)
+	require.NoError(t, err)
+	assert.Equal(t, "sensors/attic/temperature", publish.topic)
+	assert.Equal(t, byte(0), publish.qos)
+	assert.Equal(t, []byte("19"), publish.payload)
+
+	// messages published through other adapters use the subscribed topic
+	require.True(t, service.deliver("shop:orders", &model.Message{Payload: []byte("{}")}))
+	packetType, flags, body = client.read()
+	require.Equal(t, packetPublish, packetType)
+	publish, err = parsePublish(flags, body)
+	require.NoError(t, err)
+	assert.Equal(t, "shop/orders", publish.topic)
+	assert.Equal(t, byte(0), publish.qos)
+
+	client.send(encodePacket(packetUnsubscribe<<4|0x02, appendString([]byte{0, 4}, "shop/orders")))
+	packetType, _, _ = client.read()
+	assert.Equal(t, packetUnsuback, packetType)
+	assert.False(t, service.deliver("shop:orders", &model.Message{Payload: []byte("{}")}))
+
+	client.send(encodePacket(packetPingreq<<4, nil))
+	packetType, _, _ = client.read()
+	assert.Equal(t, packetPingresp, packetType)
+
+	// subscriptions end with the connection
+	client.send(encodePacket(packetDisconnect<<4, nil))
+	assert.Eventually(t, func() bool {
+		return !service.deliver("iot:temperatures", &model.Message{})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServerWill(t *testing.T) {
+	service := &brokerService{handlers: make(map[string]model.MessageHandler)}
+	s := NewServer(service, context.Background())
+
+	client := dial(t, s)
+	require.Equal(t, connackAccepted, client.connect(connectPacket{
+		clientID:     "sensor-1",
+		cleanSession: true,
+		will:         &publishPacket{topic: "sensors/status", payload: []byte("offline")},
+	}))
+
+	// the will is published when the connection drops without DISCONNECT
+	client.conn.Close()
+	assert.Eventually(t, func() bool {
+		return len(service.publishes()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"sensors:status:sensors/status:offline"}, service.publishes())
+}
+
+func TestServerAuthentication(t *testing.T) {
+	service := &brokerService{handlers: make(map[string]model.MessageHandler)}
+	s := NewServer(service, context.Background())
+	s.SetServiceRepository(&accountRepository{accounts: map[string]*model.ServiceAccount{
+		"ingest":   {ID: "ingest", Secret: "s3cret", Enabled: true, Permissions: []string{"publish:iot"}},
+		"disabled": {ID: "disabled", Secret: "s3cret"},
+	}})
+
+	login := func(username, password string) byte {
+		client := dial(t, s)
+		code := client.connect(connectPacket{clientID: "c-" + username, cleanSession: true,
+			username: username, hasUsername: true, password: password, hasPassword: true})
+		client.conn.Close()
+		return code
+	}
+	assert.Equal(t, connackBadCredentials, login("ingest", "wrong"))
+	assert.Equal(t, connackBadCredentials, login("unknown", "s3cret"))
+	assert.Equal(t, connackNotAuthorized, login("disabled", "s3cret"))
+
+	anonymous := dial(t, s)
+	assert.Equal(t, connackNotAuthorized, anonymous.connect(connectPacket{clientID: "anonymous", cleanSession: true}))
+
+	client := dial(t, s)
+	require.Equal(t, connackAccepted, client.connect(connectPacket{clientID: "ingest", cleanSession: true,
+		username: "ingest", hasUsername: true, password: "s3cret", hasPassword: true}))
+
+	// permissions are checked per domain
+	client.send(encodePublish(&publishPacket{topic: "iot/readings", packetID: 1, qos: 1, payload: []byte("1")}))
+	packetType, _, _ := client.read()
+	assert.Equal(t, packetPuback, packetType)
+	client.send(encodePublish(&publishPacket{topic: "billing/invoices", packetID: 2, qos: 1, payload: []byte("2")}))
+	packetType, _, _ = client.read()
+	assert.Equal(t, packetPuback, packetType)
+	assert.Equal(t, subackFailure, client.subscribe(3, "iot/readings", 1))
+
+	assert.Equal(t, []string{"iot:readings:iot/readings:1"}, service.publishes())
+}
+
+func TestServerRejectsProtocolLevel(t *testing.T) {
+	s := NewServer(&brokerService{}, context.Background())
+
+	client := dial(t, s)
+	assert.Equal(t, connackBadProtocol, client.connect(connectPacket{clientID: "old", cleanSession: true, level: 3}))
+
+	// a persistent session needs a client identifier
+	client = dial(t, s)
+	assert.Equal(t, connackIdentifierRejected, client.connect(connectPacket{}))
+}
@@ -0,0 +1,115 @@
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TopicMapping associe les topics correspondant à un filtre à une file
+type TopicMapping struct {
+	Filter string
+	Domain string
+	Queue  string
+}
+
+// validTopicName vérifie un nom de topic de PUBLISH, sans joker
+func validTopicName(topic string) bool {
+	return topic != "" && len(topic) <= 0xffff && !strings.ContainsAny(topic, "+#\x00")
+}
+
+// validFilter vérifie un filtre, + occupant un niveau entier et # terminant le filtre
+func validFilter(filter string) bool {
+	if filter == "" || len(filter) > 0xffff || strings.ContainsRune(filter, 0) {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if level == "#" && i < len(levels)-1 {
+			return false
+		}
+		if level != "+" && level != "#" && strings.ContainsAny(level, "+#") {
+			return false
+		}
+	}
+	return true
+}
+
+// hasWildcard indique si un filtre contient un joker
+func hasWildcard(filter string) bool {
+	return strings.ContainsAny(filter, "+#")
+}
+
+// matchTopic indique si un topic correspond à un filtre valide. Les jokers
+// du premier niveau ne couvrent pas les topics réservés commençant par $
+func matchTopic(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			// sport/# couvre aussi sport
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// topicMapper résout les topics en files, les correspondances étant essayées
+// dans l'ordre avant le découpage par défaut domaine/file
+type topicMapper struct {
+	mappings []TopicMapping
+}
+
+// newTopicMapper vérifie les correspondances et les copie
+func newTopicMapper(mappings []TopicMapping) (*topicMapper, error) {
+	for _, m := range mappings {
+		if !validFilter(m.Filter) {
+			return nil, fmt.Errorf("invalid MQTT topic filter: %q", m.Filter)
+		}
+		if m.Domain == "" || m.Queue == "" {
+			return nil, fmt.Errorf("MQTT topic filter %s: domain and queue are required", m.Filter)
+		}
+	}
+	return &topicMapper{mappings: append([]TopicMapping(nil), mappings...)}, nil
+}
+
+// resolve renvoie la file d'un topic de PUBLISH
+func (m *topicMapper) resolve(topic string) (string, string, bool) {
+	for _, mapping := range m.mappings {
+		if matchTopic(mapping.Filter, topic) {
+			return mapping.Domain, mapping.Queue, true
+		}
+	}
+	return defaultQueue(topic)
+}
+
+// resolveFilter renvoie la file d'un filtre d'abonnement. Un filtre avec
+// joker doit être déclaré tel quel, un abonnement ne lisant qu'une seule file
+func (m *topicMapper) resolveFilter(filter string) (string, string, bool) {
+	if !hasWildcard(filter) {
+		return m.resolve(filter)
+	}
+	for _, mapping := range m.mappings {
+		if mapping.Filter == filter {
+			return mapping.Domain, mapping.Queue, true
+		}
+	}
+	return "", "", false
+}
+
+// defaultQueue découpe un topic domaine/file
+func defaultQueue(topic string) (string, string, bool) {
+	domain, queue, ok := strings.Cut(topic, "/")
+	if !ok || domain == "" || queue == "" || strings.Contains(queue, "/") || strings.HasPrefix(domain, "$") {
+		return "", "", false
+	}
+	return domain, queue, true
+}
@@ -0,0 +1,80 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		match  bool
+	}{
+		{"sport/tennis/player1", "sport/tennis/player1", true},
+		{"sport/tennis/+", "sport/tennis/player1", true},
+		{"sport/tennis/+", "sport/tennis/player1/ranking", false},
+		{"sport/#", "sport", true},
+		{"sport/#", "sport/tennis/player1", true},
+		{"+/+", "/finance", true},
+		{"+", "/finance", false},
+		{"#", "$SYS/broker", false},
+		{"$SYS/#", "$SYS/broker", true},
+		{"sport/+/player1", "sport/tennis/player2", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchTopic(tt.filter, tt.topic), "%s ~ %s", tt.filter, tt.topic)
+	}
+}
+
+func TestValidFilter(t *testing.T) {
+	for _, filter := range []string{"#", "+", "sport/#", "sport/+/player1", "+/+"} {
+		assert.True(t, validFilter(filter), filter)
+	}
+	for _, filter := range []string{"", "sport/#/ranking", "sport+", "sport/ten#"} {
+		assert.False(t, validFilter(filter), filter)
+	}
+	assert.False(t, validTopicName("sport/+"))
+	assert.True(t, validTopicName("sport/tennis"))
+}
+
+func TestTopicMapper(t *testing.T) {
+	mapper, err := newTopicMapper([]TopicMapping{
+		{Filter: "sensors/+/temperature", Domain: "iot", Queue: "temperatures"},
+		{Filter: "alerts/#", Domain: "iot", Queue: "alerts"},
+	})
+	require.NoError(t, err)
+
+	resolve := func(topic string) []string {
+		domain, queue, ok := mapper.resolve(topic)
+		if !ok {
+			return nil
+		}
+		return []string{domain, queue}
+	}
+	assert.Equal(t, []string{"iot", "temperatures"}, resolve("sensors/kitchen/temperature"))
+	assert.Equal(t, []string{"iot", "alerts"}, resolve("alerts/fire/level1"))
+
+	// unmapped topics fall back to domain/queue
+	assert.Equal(t, []string{"shop", "orders"}, resolve("shop/orders"))
+	assert.Nil(t, resolve("shop/orders/eu"))
+	assert.Nil(t, resolve("orders"))
+	assert.Nil(t, resolve("$SYS/uptime"))
+
+	// wildcard subscriptions must be declared as they are
+	domain, queue, ok := mapper.resolveFilter("alerts/#")
+	assert.True(t, ok)
+	assert.Equal(t, "iot/alerts", domain+"/"+queue)
+	_, _, ok = mapper.resolveFilter("sensors/#")
+	assert.False(t, ok)
+	domain, queue, ok = mapper.resolveFilter("sensors/kitchen/temperature")
+	assert.True(t, ok)
+	assert.Equal(t, "iot/temperatures", domain+"/"+queue)
+
+	_, err = newTopicMapper([]TopicMapping{{Filter: "a/#/b", Domain: "d", Queue: "q"}})
+	assert.Error(t, err)
+	_, err = newTopicMapper([]TopicMapping{{Filter: "a/b"}})
+	assert.Error(t, err)
+}
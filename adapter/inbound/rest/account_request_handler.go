@@ -21,6 +21,7 @@ type CreateAccountRequestRequest struct {
 	Username      string         `json:"username"`
 	Password      string         `json:"password"`
 	RequestedRole model.UserRole `json:"requestedRole"`
+	Email         string         `json:"email,omitempty"`
 }
 
 type ReviewAccountRequestRequest struct {
@@ -80,6 +81,7 @@ func (h *AccountRequestHandler) CreateAccountRequest(w http.ResponseWriter, r *h
 		Username:      req.Username,
 		Password:      req.Password,
 		RequestedRole: req.RequestedRole,
+		Email:         req.Email,
 	}
 
 	request, err := h.accountRequestService.CreateAccountRequest(r.Context(), options)
@@ -93,6 +95,8 @@ func (h *AccountRequestHandler) CreateAccountRequest(w http.ResponseWriter, r *h
 			http.Error(w, "Account request already exists for this username", http.StatusConflict)
 		case model.ErrInvalidRequestedRole:
 			http.Error(w, "Invalid role requested", http.StatusBadRequest)
+		case model.ErrInvalidEmail:
+			http.Error(w, "Invalid email address", http.StatusBadRequest)
 		default:
 			http.Error(w, "Failed to create account request", http.StatusInternalServerError)
 		}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// REST API versions. v2 carries the breaking response changes, v1 keeps the
+// original responses for existing clients.
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
+)
+
+// apiPrefixes lists the prefixes the API is mounted on, the unversioned
+// /api prefix serving v1. Versioned prefixes come first, see SetupListenerRoutes.
+var apiPrefixes = []struct {
+	prefix  string
+	version string
+}{
+	{"/api/" + APIVersion1, APIVersion1},
+	{"/api/" + APIVersion2, APIVersion2},
+	{"/api", APIVersion1},
+}
+
+// APIPrefixes returns the path prefixes the REST API is served on
+func APIPrefixes() []string {
+	prefixes := make([]string, 0, len(apiPrefixes))
+	for _, api := range apiPrefixes {
+		prefixes = append(prefixes, api.prefix)
+	}
+	return prefixes
+}
+
+// unversionedAPIPath maps /api/v1/... and /api/v2/... to /api/...
+func unversionedAPIPath(path string) string {
+	for _, api := range apiPrefixes[:2] {
+		if rest, ok := strings.CutPrefix(path, api.prefix); ok && (rest == "" || rest[0] == '/') {
+			return "/api" + rest
+		}
+	}
+	return path
+}
+
+// apiVersionMiddleware tags responses with the version serving them. v1
+// responses announce its deprecation once configured, v2 error responses
+// are wrapped in the JSON error envelope.
+func (h *Handler) apiVersionMiddleware(prefix, version string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", version)
+
+			if version == APIVersion1 {
+				h.setDeprecationHeaders(w, prefix, r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &errorEnvelopeWriter{ResponseWriter: w}
+			next.ServeHTTP(ew, r)
+			ew.finish()
+		})
+	}
+}
+
+// setDeprecationHeaders adds the Deprecation (RFC 9745), Sunset (RFC 8594)
+// and successor Link headers to v1 responses
+func (h *Handler) setDeprecationHeaders(w http.ResponseWriter, prefix, path string) {
+	if h.config == nil {
+		return
+	}
+	versioning := h.config.HTTP.APIVersioning
+
+	if deprecation, err := time.Parse(time.DateOnly, versioning.V1Deprecation); err == nil {
+		setDeprecation(w, deprecation)
+		successor := "/api/" + APIVersion2 + strings.TrimPrefix(path, prefix)
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+	}
+	if sunset, err := time.Parse(time.DateOnly, versioning.V1Sunset); err == nil {
+		w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// setDeprecation sets the Deprecation header in its RFC 9745 form, the
+// deprecation date as @<unix seconds>
+func setDeprecation(w http.ResponseWriter, deprecation time.Time) {
+	w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecation.Unix()))
+}
+
+// APIError is the v2 error envelope
+type APIError struct {
+	Error APIErrorBody `json:"error"`
+}
+
+type APIErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"` // snake_case status text, e.g. not_found
+	Message string `json:"message"`
+}
+
+// errorEnvelopeWriter turns the plain text errors written by http.Error into
+// the v2 JSON envelope, other responses pass through untouched
+type errorEnvelopeWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	enveloping  bool
+	message     bytes.Buffer
+}
+
+func (w *errorEnvelopeWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		// held back until the message is known
+		w.enveloping = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *errorEnvelopeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enveloping {
+		return w.message.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// finish writes the envelope of a held back error
+func (w *errorEnvelopeWriter) finish() {
+	if !w.enveloping {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	json.NewEncoder(w.ResponseWriter).Encode(APIError{Error: APIErrorBody{
+		Status:  w.status,
+		Code:    strings.ReplaceAll(strings.ToLower(http.StatusText(w.status)), " ", "_"),
+		Message: strings.TrimSpace(w.message.String()),
+	}})
+}
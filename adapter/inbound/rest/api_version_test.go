@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnversionedAPIPath(t *testing.T) {
+	assert.Equal(t, "/api/domains", unversionedAPIPath("/api/v1/domains"))
+	assert.Equal(t, "/api/auth/login", unversionedAPIPath("/api/v2/auth/login"))
+	assert.Equal(t, "/api", unversionedAPIPath("/api/v2"))
+	assert.Equal(t, "/api/domains", unversionedAPIPath("/api/domains"))
+	assert.Equal(t, "/api/v10/domains", unversionedAPIPath("/api/v10/domains"))
+}
+
+func TestAPIVersionMiddleware(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.HTTP.APIVersioning.V1Deprecation = "2026-01-01"
+	cfg.HTTP.APIVersioning.V1Sunset = "2026-12-31"
+	h := &Handler{config: cfg}
+
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Domain not found", http.StatusNotFound)
+	})
+
+	t.Run("v1 keeps plain errors and announces deprecation", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.apiVersionMiddleware("/api", APIVersion1)(notFound).ServeHTTP(w, httptest.NewRequest("GET", "/api/domains/shop", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "Domain not found\n", w.Body.String())
+		assert.Equal(t, APIVersion1, w.Header().Get("API-Version"))
+		assert.Equal(t, "@1767225600", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v2/domains/shop>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("v2 wraps errors in the envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.apiVersionMiddleware("/api/v2", APIVersion2)(notFound).ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/domains/shop", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("Deprecation"))
+
+		var body APIError
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, APIErrorBody{Status: 404, Code: "not_found", Message: "Domain not found"}, body.Error)
+	})
+
+	t.Run("v2 passes successful responses through", func(t *testing.T) {
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		h.apiVersionMiddleware("/api/v2", APIVersion2)(ok).ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/domains", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	for _, path := range []string{"/api/domains", "/api/v1/domains", "/api/v2/domains"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
@@ -187,6 +187,31 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode("{status: OK}")
 }
 
+// ResetPassword sets a temporary password on a user (admin only). The
+// password is emailed to the user when possible, returned otherwise.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetBy string
+	if admin := GetUserFromContext(r.Context()); admin != nil {
+		resetBy = admin.Username
+	}
+
+	user, password, err := h.authService.ResetPassword(mux.Vars(r)["id"], resetBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := map[string]any{
+		"user":     user.ToResponse(),
+		"notified": password == "",
+	}
+	if password != "" {
+		response["temporaryPassword"] = password
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := h.authService.ListUsers()
 
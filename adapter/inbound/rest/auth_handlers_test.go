@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/ajkula/GoRTMS/config"
 	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -279,3 +281,37 @@ func TestAuthHandler_GetProfile_UserNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	handler, authService, _ := setupAuthHandler()
+	testUser := createTestUserModel()
+	admin := createTestAdminModel()
+
+	reset := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/admin/users/"+userID+"/reset-password", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": userID})
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, admin))
+		w := httptest.NewRecorder()
+		handler.ResetPassword(w, req)
+		return w
+	}
+
+	authService.On("ResetPassword", testUser.ID, "admin").Return(testUser, "Temp0rary!", nil).Once()
+	w := reset(testUser.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]any
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "Temp0rary!", response["temporaryPassword"])
+	assert.Equal(t, false, response["notified"])
+
+	// emailed passwords are not returned
+	authService.On("ResetPassword", testUser.ID, "admin").Return(testUser, "", nil).Once()
+	w = reset(testUser.ID)
+	response = nil
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.NotContains(t, response, "temporaryPassword")
+	assert.Equal(t, true, response["notified"])
+
+	authService.On("ResetPassword", "unknown", "admin").Return(nil, "", errors.New("user not found")).Once()
+	assert.Equal(t, http.StatusNotFound, reset("unknown").Code)
+}
@@ -97,6 +97,7 @@ func (m *AuthMiddleware) GetUserFromContext(ctx context.Context) *model.User {
 }
 
 func (m *AuthMiddleware) isPublicRoute(path string) bool {
+	path = unversionedAPIPath(path)
 	publicRoutes := []string{
 		"/api/auth/login",
 		"/api/auth/bootstrap",
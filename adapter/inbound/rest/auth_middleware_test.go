@@ -27,6 +27,14 @@ func (s *MockAuthService) UpdatePassword(user *model.User, old, new string) erro
 	return nil
 }
 
+func (m *MockAuthService) ResetPassword(userID, resetBy string) (*model.User, string, error) {
+	args := m.Called(userID, resetBy)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*model.User), args.String(1), args.Error(2)
+}
+
 func (s *MockAuthService) GenerateToken(user *model.User, issuedAt time.Time) (string, error) {
 	return "testuser", nil
 }
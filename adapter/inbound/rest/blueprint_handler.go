@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/gorilla/mux"
+)
+
+// SetBlueprintService lets queue creations reference blueprints by name
+func (h *Handler) SetBlueprintService(blueprints inbound.BlueprintService) {
+	h.blueprints = blueprints
+}
+
+// lookupBlueprint returns the blueprint a queue creation references
+func (h *Handler) lookupBlueprint(name string) (*model.QueueBlueprint, error) {
+	if h.blueprints == nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrBlueprintNotFound, name)
+	}
+	return h.blueprints.GetBlueprint(name)
+}
+
+// blueprintQueueConfig applies the config of a create request over the one
+// of the blueprint, the request may leave its config out
+func blueprintQueueConfig(blueprint *model.QueueBlueprint, raw json.RawMessage) (*model.QueueConfig, error) {
+	if len(raw) == 0 {
+		config := blueprint.QueueConfig()
+		return &config, nil
+	}
+	return overlayQueueConfig(blueprint.QueueConfig(), raw)
+}
+
+func blueprintStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrBlueprintNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrInvalidBlueprint):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrBlueprintReadOnly):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) listQueueBlueprints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"blueprints": h.blueprints.ListBlueprints(),
+	})
+}
+
+func (h *Handler) getQueueBlueprint(w http.ResponseWriter, r *http.Request) {
+	blueprint, err := h.blueprints.GetBlueprint(mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, err.Error(), blueprintStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blueprint)
+}
+
+// creates or replaces a blueprint, its config takes the format of the
+// config of queue creations
+func (h *Handler) putQueueBlueprint(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Description string          `json:"description"`
+		Config      json.RawMessage `json:"config"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	config, err := parseQueueConfig(request.Config)
+	if err != nil {
+		http.Error(w, "Invalid config format", http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	blueprint := &model.QueueBlueprint{
+		Name:        name,
+		Description: request.Description,
+		Config:      *config,
+	}
+	if err := h.blueprints.SaveBlueprint(blueprint); err != nil {
+		http.Error(w, err.Error(), blueprintStatus(err))
+		return
+	}
+
+	saved, err := h.blueprints.GetBlueprint(name)
+	if err != nil {
+		http.Error(w, err.Error(), blueprintStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+func (h *Handler) deleteQueueBlueprint(w http.ResponseWriter, r *http.Request) {
+	if err := h.blueprints.DeleteBlueprint(mux.Vars(r)["name"]); err != nil {
+		http.Error(w, err.Error(), blueprintStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
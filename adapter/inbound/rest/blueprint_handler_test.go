@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/storage/memory"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateQueueFromBlueprint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger := &mockLogger{}
+	domainRepo := memory.NewDomainRepository(logger)
+	queueService := service.NewQueueService(ctx, logger, domainRepo, nil)
+	domainService := service.NewDomainService(domainRepo, queueService, ctx)
+	require.NoError(t, domainService.CreateDomain(ctx, &model.DomainConfig{Name: "shop"}))
+
+	blueprints := service.NewBlueprintService(logger, []model.QueueBlueprint{{
+		Name: "critical-with-dlq",
+		Config: model.QueueConfig{
+			IsPersistent: true,
+			TTL:          time.Hour,
+			RetryEnabled: true,
+			RetryConfig:  &model.RetryConfig{MaxRetries: 5, Factor: 2},
+			Labels:       map[string]string{"tier": "critical"},
+		},
+	}})
+	h := &Handler{logger: logger, queueService: queueService, blueprints: blueprints}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/domains/{domain}/queues", h.createQueue).Methods("POST")
+	router.HandleFunc("/api/blueprints/{name}", h.putQueueBlueprint).Methods("PUT")
+	router.HandleFunc("/api/blueprints/{name}", h.deleteQueueBlueprint).Methods("DELETE")
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	// The blueprint alone
+	w := send("POST", "/api/domains/shop/queues", `{"name":"orders","blueprint":"critical-with-dlq"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	queue, err := queueService.GetQueue(ctx, "shop", "orders")
+	require.NoError(t, err)
+	assert.True(t, queue.Config.IsPersistent)
+	assert.Equal(t, 5, queue.Config.RetryConfig.MaxRetries)
+
+	// The request overrides some settings and adds labels
+	w = send("POST", "/api/domains/shop/queues", `{"name":"refunds","blueprint":"critical-with-dlq",
+		"config":{"ttl":"10m","retryConfig":{"maxRetries":1},"labels":{"team":"payments"}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	queue, err = queueService.GetQueue(ctx, "shop", "refunds")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, queue.Config.TTL)
+	assert.Equal(t, 1, queue.Config.RetryConfig.MaxRetries)
+	assert.Equal(t, 2.0, queue.Config.RetryConfig.Factor)
+	assert.Equal(t, map[string]string{"tier": "critical", "team": "payments"}, queue.Config.Labels)
+
+	// The blueprint is left untouched
+	blueprint, err := blueprints.GetBlueprint("critical-with-dlq")
+	require.NoError(t, err)
+	assert.Equal(t, 5, blueprint.Config.RetryConfig.MaxRetries)
+	assert.Len(t, blueprint.Config.Labels, 1)
+
+	w = send("POST", "/api/domains/shop/queues", `{"name":"misc","blueprint":"nope"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Blueprints of the API can be replaced and deleted, not the configured ones
+	w = send("PUT", "/api/blueprints/ephemeral", `{"config":{"ttl":"1m","partitions":4}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = send("POST", "/api/domains/shop/queues", `{"name":"clicks","blueprint":"ephemeral","config":{"circuitBreakerEnabled":false}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	queue, err = queueService.GetQueue(ctx, "shop", "clicks")
+	require.NoError(t, err)
+	assert.Equal(t, 4, queue.Config.Partitions)
+
+	assert.Equal(t, http.StatusNoContent, send("DELETE", "/api/blueprints/ephemeral", "").Code)
+	assert.Equal(t, http.StatusConflict, send("PUT", "/api/blueprints/critical-with-dlq", `{"config":{}}`).Code)
+	assert.Equal(t, http.StatusConflict, send("DELETE", "/api/blueprints/critical-with-dlq", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send("PUT", "/api/blueprints/bad", `{"config":{"orderingKey":"id"}}`).Code)
+}
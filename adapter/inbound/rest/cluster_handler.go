@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/gorilla/mux"
+)
+
+// standalonePlacement is the placement of a node running alone, it owns
+// every queue
+type standalonePlacement struct {
+	node model.ClusterNode
+}
+
+func (p standalonePlacement) Self() model.ClusterNode                { return p.node }
+func (p standalonePlacement) Nodes() []model.ClusterNode             { return []model.ClusterNode{p.node} }
+func (p standalonePlacement) Owner(string, string) model.ClusterNode { return p.node }
+
+// SetClusterPlacement replaces the standalone placement once the queues are
+// spread over several nodes
+func (h *Handler) SetClusterPlacement(placement outbound.ClusterPlacement) {
+	h.placement = placement
+}
+
+// returns the nodes of the cluster and the node owning each queue
+func (h *Handler) getClusterMetadata(w http.ResponseWriter, r *http.Request) {
+	domains, err := h.domainService.ListDomains(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	nodes := h.placement.Nodes()
+	metadata := model.ClusterMetadata{
+		Clustered: len(nodes) > 1,
+		Node:      h.placement.Self().ID,
+		Nodes:     nodes,
+		Queues:    make([]model.QueueOwner, 0),
+	}
+	for _, domain := range domains {
+		queues, err := h.queueService.ListQueues(r.Context(), domain.Name)
+		if err != nil {
+			// deleted meanwhile
+			continue
+		}
+		for _, queue := range queues {
+			metadata.Queues = append(metadata.Queues, model.QueueOwner{
+				Domain: domain.Name,
+				Queue:  queue.Name,
+				Node:   h.placement.Owner(domain.Name, queue.Name).ID,
+			})
+		}
+	}
+	sort.Slice(metadata.Queues, func(i, j int) bool {
+		a, b := metadata.Queues[i], metadata.Queues[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.Queue < b.Queue
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metadata)
+}
+
+// ownedQueue redirects the data plane calls of a queue held by another node
+// to it, with a 307 so that the method and body are kept
+func (h *Handler) ownedQueue(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		owner := h.placement.Owner(vars["domain"], vars["queue"])
+		if owner.ID == h.placement.Self().ID || owner.Address == "" {
+			next(w, r)
+			return
+		}
+		w.Header().Set("X-GoRTMS-Owner", owner.ID)
+		http.Redirect(w, r, strings.TrimRight(owner.Address, "/")+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}
+}
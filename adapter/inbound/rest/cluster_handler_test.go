@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardedPlacement gives the queues of a domain to another node
+type shardedPlacement struct {
+	self, other model.ClusterNode
+	domain      string
+}
+
+func (p shardedPlacement) Self() model.ClusterNode    { return p.self }
+func (p shardedPlacement) Nodes() []model.ClusterNode { return []model.ClusterNode{p.self, p.other} }
+func (p shardedPlacement) Owner(domainName, queueName string) model.ClusterNode {
+	if domainName == p.domain {
+		return p.other
+	}
+	return p.self
+}
+
+func TestClusterMetadataAndRedirects(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	ctx := context.Background()
+	for _, domain := range []string{"shop", "billing"} {
+		require.NoError(t, server.handler.domainService.CreateDomain(ctx, &model.DomainConfig{Name: domain}))
+		require.NoError(t, server.handler.queueService.CreateQueue(ctx, domain, "orders", &model.QueueConfig{}))
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// alone, the node owns every queue
+	w := do("GET", "/api/cluster/metadata", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var metadata model.ClusterMetadata
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&metadata))
+	assert.False(t, metadata.Clustered)
+	require.Len(t, metadata.Queues, 2)
+	assert.Equal(t, "billing", metadata.Queues[0].Domain)
+	assert.Equal(t, metadata.Node, metadata.Queues[0].Node)
+
+	server.handler.SetClusterPlacement(shardedPlacement{
+		self:   model.ClusterNode{ID: "node-a", Address: "http://node-a:8080"},
+		other:  model.ClusterNode{ID: "node-b", Address: "http://node-b:8080/"},
+		domain: "billing",
+	})
+
+	w = do("GET", "/api/cluster/metadata", "")
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&metadata))
+	assert.True(t, metadata.Clustered)
+	assert.Equal(t, "node-a", metadata.Node)
+	assert.Equal(t, []model.QueueOwner{
+		{Domain: "billing", Queue: "orders", Node: "node-b"},
+		{Domain: "shop", Queue: "orders", Node: "node-a"},
+	}, metadata.Queues)
+
+	w = do("POST", "/api/domains/billing/queues/orders/messages?key=1", `{"id": 1}`)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "http://node-b:8080/api/domains/billing/queues/orders/messages?key=1", w.Header().Get("Location"))
+	assert.Equal(t, "node-b", w.Header().Get("X-GoRTMS-Owner"))
+
+	w = do("POST", "/api/domains/shop/queues/orders/messages", `{"id": 1}`)
+	assert.NotEqual(t, http.StatusTemporaryRedirect, w.Code)
+}
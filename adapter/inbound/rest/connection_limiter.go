@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/config"
+)
+
+// Connection limit scopes, also reported as the reason of a rejection
+const (
+	connScopeIP      = "ip"
+	connScopeService = "service"
+	connScopeUser    = "user"
+
+	// connScopeRequest rejects the requests of a service account with too
+	// many in flight
+	connScopeRequest = "request"
+)
+
+// ConnectionLimiter counts the open long-lived connections per client address,
+// service account and user, and refuses those above the configured limits.
+// It also bounds the requests each service account has in flight.
+type ConnectionLimiter struct {
+	limits   config.ConnectionLimits
+	mu       sync.Mutex
+	active   map[string]int // "scope:key" -> open connections
+	open     int
+	inFlight map[string]int    // service -> requests being served
+	rejected map[string]uint64 // scope -> refused connections
+}
+
+// ConnectionStats is served by GET /api/stats/connections
+type ConnectionStats struct {
+	Open     int               `json:"open"`
+	InFlight int               `json:"inFlight"` // HMAC requests being served
+	Rejected map[string]uint64 `json:"rejected"` // by limit scope: ip, service, user or request
+}
+
+func NewConnectionLimiter(limits config.ConnectionLimits) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		limits:   limits,
+		active:   make(map[string]int),
+		inFlight: make(map[string]int),
+		rejected: make(map[string]uint64),
+	}
+}
+
+// Acquire admits a connection and returns the function releasing it once
+// closed, or the scope whose limit the connection would exceed.
+// A nil limiter admits every connection.
+func (l *ConnectionLimiter) Acquire(ip, service, user string) (release func(), rejectedBy string) {
+	return l.acquire(ip, service, 0, user)
+}
+
+// acquire is Acquire with the connection limit of the service account,
+// 0 keeping the configured one
+func (l *ConnectionLimiter) acquire(ip, service string, serviceLimit int, user string) (release func(), rejectedBy string) {
+	if l == nil {
+		return func() {}, ""
+	}
+	if serviceLimit <= 0 {
+		serviceLimit = l.limits.MaxPerService
+	}
+
+	type slot struct {
+		scope, key string
+		limit      int
+	}
+	slots := []slot{{connScopeIP, ip, l.limits.MaxPerIP}}
+	if service != "" {
+		slots = append(slots, slot{connScopeService, service, serviceLimit})
+	}
+	if user != "" {
+		slots = append(slots, slot{connScopeUser, user, l.limits.MaxPerUser})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, s := range slots {
+		if s.limit > 0 && l.active[s.scope+":"+s.key] >= s.limit {
+			l.rejected[s.scope]++
+			return nil, s.scope
+		}
+	}
+
+	for _, s := range slots {
+		l.active[s.scope+":"+s.key]++
+	}
+	l.open++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			for _, s := range slots {
+				key := s.scope + ":" + s.key
+				if l.active[key]--; l.active[key] <= 0 {
+					delete(l.active, key)
+				}
+			}
+			l.open--
+		})
+	}, ""
+}
+
+// AcquireRequest admits a request of a service account and returns the
+// function releasing it once served, or false when the account already has
+// its limit in flight. limit is the account's own, 0 keeping the configured
+// one. A nil limiter admits every request.
+func (l *ConnectionLimiter) AcquireRequest(service string, limit int) (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	if limit <= 0 {
+		limit = l.limits.MaxInFlightPerService
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit > 0 && l.inFlight[service] >= limit {
+		l.rejected[connScopeRequest]++
+		return nil, false
+	}
+	l.inFlight[service]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if l.inFlight[service]--; l.inFlight[service] <= 0 {
+				delete(l.inFlight, service)
+			}
+		})
+	}, true
+}
+
+func (l *ConnectionLimiter) Stats() ConnectionStats {
+	stats := ConnectionStats{Rejected: make(map[string]uint64)}
+	if l == nil {
+		return stats
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats.Open = l.open
+	for _, count := range l.inFlight {
+		stats.InFlight += count
+	}
+	for scope, count := range l.rejected {
+		stats.Rejected[scope] = count
+	}
+	return stats
+}
+
+// limitConnection serves next while holding a connection slot, next must
+// only return once the connection is closed
+func (h *Handler) limitConnection(w http.ResponseWriter, r *http.Request, service, user string, next http.Handler) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	serviceLimit := 0
+	if service != "" && h.serviceRepo != nil {
+		if account, err := h.serviceRepo.GetByID(r.Context(), service); err == nil {
+			serviceLimit = account.Limits.MaxConnections
+		}
+	}
+
+	release, rejectedBy := h.connLimiter.acquire(ip, service, serviceLimit, user)
+	if rejectedBy != "" {
+		h.logger.Warn("Connection rejected",
+			"limit", rejectedBy,
+			"ip", ip,
+			"service", service,
+			"user", user)
+		http.Error(w, "Too many connections per "+rejectedBy, http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	next.ServeHTTP(w, r)
+}
+
+func (h *Handler) getConnectionStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.connLimiter.Stats())
+}
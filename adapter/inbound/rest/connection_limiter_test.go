@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLimiter(t *testing.T) {
+	limiter := NewConnectionLimiter(config.ConnectionLimits{MaxPerIP: 3, MaxPerService: 1, MaxPerUser: 2})
+
+	releaseService, rejectedBy := limiter.Acquire("10.0.0.1", "billing", "")
+	require.Empty(t, rejectedBy)
+
+	_, rejectedBy = limiter.Acquire("10.0.0.2", "billing", "")
+	assert.Equal(t, connScopeService, rejectedBy, "the service limit spans addresses")
+
+	releaseAlice, rejectedBy := limiter.Acquire("10.0.0.1", "", "alice")
+	require.Empty(t, rejectedBy)
+	_, rejectedBy = limiter.Acquire("10.0.0.1", "", "bob")
+	require.Empty(t, rejectedBy)
+
+	_, rejectedBy = limiter.Acquire("10.0.0.1", "", "carol")
+	assert.Equal(t, connScopeIP, rejectedBy)
+
+	stats := limiter.Stats()
+	assert.Equal(t, 3, stats.Open)
+	assert.Equal(t, map[string]uint64{connScopeService: 1, connScopeIP: 1}, stats.Rejected)
+
+	releaseService()
+	releaseService() // releasing twice frees a single slot
+	releaseAlice()
+	assert.Equal(t, 1, limiter.Stats().Open)
+
+	_, rejectedBy = limiter.Acquire("10.0.0.2", "billing", "")
+	assert.Empty(t, rejectedBy)
+}
+
+func TestWebSocketAuthAppliesConnectionLimits(t *testing.T) {
+	middleware, _, logger := setupAuthMiddleware(false)
+
+	h := &Handler{
+		logger:      logger,
+		config:      middleware.config,
+		connLimiter: NewConnectionLimiter(config.ConnectionLimits{MaxPerIP: 1}),
+	}
+
+	// the first connection stays open until its handler returns
+	release := make(chan struct{})
+	defer close(release)
+	opened := make(chan struct{})
+	wsRoute := h.WebSocketAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(opened)
+		<-release
+	}))
+
+	go wsRoute.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/ws/domains/orders/queues/incoming", nil))
+	<-opened
+
+	w := httptest.NewRecorder()
+	wsRoute.ServeHTTP(w, httptest.NewRequest("GET", "/api/ws/domains/orders/queues/incoming", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, uint64(1), h.connLimiter.Stats().Rejected[connScopeIP])
+}
+
+func TestConnectionLimiterServiceOverrides(t *testing.T) {
+	limiter := NewConnectionLimiter(config.ConnectionLimits{MaxPerService: 1, MaxInFlightPerService: 1})
+
+	// an account limit replaces the configured one
+	_, rejectedBy := limiter.acquire("10.0.0.1", "billing", 2, "")
+	require.Empty(t, rejectedBy)
+	_, rejectedBy = limiter.acquire("10.0.0.2", "billing", 2, "")
+	require.Empty(t, rejectedBy)
+	_, rejectedBy = limiter.acquire("10.0.0.3", "billing", 2, "")
+	assert.Equal(t, connScopeService, rejectedBy)
+
+	release, ok := limiter.AcquireRequest("billing", 0)
+	require.True(t, ok)
+	_, ok = limiter.AcquireRequest("billing", 0)
+	assert.False(t, ok)
+	_, ok = limiter.AcquireRequest("orders", 0)
+	assert.True(t, ok, "the in-flight limit is per service")
+	_, ok = limiter.AcquireRequest("billing", 2)
+	assert.True(t, ok)
+
+	release()
+	stats := limiter.Stats()
+	assert.Equal(t, 2, stats.InFlight)
+	assert.Equal(t, uint64(1), stats.Rejected[connScopeRequest])
+
+	var nilLimiter *ConnectionLimiter
+	_, ok = nilLimiter.AcquireRequest("billing", 1)
+	assert.True(t, ok)
+}
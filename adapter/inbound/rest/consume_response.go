@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// consumedMessage is the entry of a message in a consume response. The
+// payload is under "payload" as stored: JSON embedded as is, text as a
+// string, and binary base64-encoded with "encoding": "base64"; raw returns
+// it base64-encoded whatever it holds. Without raw the fields of JSON
+// object payloads are also merged at the top level, where they override id,
+// timestamp and headers, and other payloads are copied as a "data" string,
+// as consumers written before "payload" expect. "payload", "contentType"
+// and "encoding" are never overridden.
+func consumedMessage(msg *model.Message, raw bool) map[string]any {
+	entry := map[string]any{
+		"id":        msg.ID,
+		"timestamp": msg.Timestamp,
+		"headers":   msg.Headers,
+	}
+
+	if !raw {
+		var fields map[string]any
+		if err := hotJSON.Unmarshal(msg.Payload, &fields); err != nil {
+			fields = map[string]any{"data": string(msg.Payload)}
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+	}
+
+	entry["contentType"] = msg.ContentType()
+	delete(entry, "encoding")
+	switch {
+	case raw || !utf8.Valid(msg.Payload):
+		entry["payload"] = base64.StdEncoding.EncodeToString(msg.Payload)
+		entry["encoding"] = "base64"
+	case json.Valid(msg.Payload):
+		entry["payload"] = json.RawMessage(msg.Payload)
+	default:
+		entry["payload"] = string(msg.Payload)
+	}
+	return entry
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeEntry returns the consume response entry as a client decodes it
+func encodeEntry(t *testing.T, msg *model.Message, raw bool) map[string]json.RawMessage {
+	data, err := json.Marshal(consumedMessage(msg, raw))
+	require.NoError(t, err)
+	var entry map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &entry))
+	return entry
+}
+
+func TestConsumedMessage(t *testing.T) {
+	now := time.Now()
+
+	t.Run("json object", func(t *testing.T) {
+		entry := encodeEntry(t, &model.Message{
+			ID:        "m1",
+			Payload:   []byte(`{"amount":12345678901234567890,"z":1,"a":2,"payload":"mine"}`),
+			Headers:   map[string]string{"Content-Type": "application/json"},
+			Timestamp: now,
+		}, false)
+
+		// verbatim: precision and key order kept
+		assert.JSONEq(t, `"application/json"`, string(entry["contentType"]))
+		assert.Equal(t, `{"amount":12345678901234567890,"z":1,"a":2,"payload":"mine"}`, string(entry["payload"]))
+		assert.Contains(t, entry, "z", "fields are still merged")
+		assert.NotContains(t, entry, "encoding")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		entry := encodeEntry(t, &model.Message{
+			Payload: []byte("event=push&ref=main"),
+			Headers: map[string]string{"content-type": "application/x-www-form-urlencoded"},
+		}, false)
+
+		assert.JSONEq(t, `"application/x-www-form-urlencoded"`, string(entry["contentType"]))
+		assert.JSONEq(t, `"event=push&ref=main"`, string(entry["payload"]))
+		assert.JSONEq(t, `"event=push&ref=main"`, string(entry["data"]))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		entry := encodeEntry(t, &model.Message{Payload: []byte{0xff, 0x00, 0xfe}}, false)
+
+		assert.JSONEq(t, `"application/octet-stream"`, string(entry["contentType"]))
+		assert.JSONEq(t, `"/wD+"`, string(entry["payload"]))
+		assert.JSONEq(t, `"base64"`, string(entry["encoding"]))
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		entry := encodeEntry(t, &model.Message{ID: "m1", Payload: []byte(`{"a":1}`)}, true)
+
+		assert.JSONEq(t, `"eyJhIjoxfQ=="`, string(entry["payload"]))
+		assert.JSONEq(t, `"base64"`, string(entry["encoding"]))
+		assert.JSONEq(t, `"application/json"`, string(entry["contentType"]))
+		assert.NotContains(t, entry, "a", "raw payloads aren't merged")
+	})
+}
@@ -3,10 +3,13 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/gorilla/mux"
 )
 
@@ -89,8 +92,13 @@ func (h *Handler) createConsumerGroup(w http.ResponseWriter, r *http.Request) {
 	queueName := vars["queue"]
 
 	var request struct {
-		GroupID string `json:"groupID"`
-		TTL     string `json:"ttl,omitempty"`
+		GroupID       string `json:"groupID"`
+		TTL           string `json:"ttl,omitempty"`
+		DeliveryMode  string `json:"deliveryMode,omitempty"`
+		Priority      int    `json:"priority,omitempty"`
+		Prefetch      int    `json:"prefetch,omitempty"`
+		CleanupAction string `json:"cleanupAction,omitempty"`
+		Ephemeral     bool   `json:"ephemeral,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -114,17 +122,309 @@ func (h *Handler) createConsumerGroup(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	mode, err := model.ParseGroupDeliveryMode(request.DeliveryMode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Prefetch < 0 {
+		http.Error(w, model.ErrInvalidPrefetch.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cleanupAction, err := model.ParseGroupCleanupAction(request.CleanupAction)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Ephemeral && cleanupAction == model.GroupCleanupArchive {
+		http.Error(w, "ephemeral groups are deleted, not archived", http.StatusBadRequest)
+		return
+	}
+
 	// create
-	if err := h.consumerGroupService.CreateConsumerGroup(r.Context(), domainName, queueName, request.GroupID, ttl); err != nil {
+	create := h.consumerGroupService.CreateConsumerGroup
+	if request.Ephemeral {
+		create = h.consumerGroupService.CreateEphemeralConsumerGroup
+	}
+	if err := create(r.Context(), domainName, queueName, request.GroupID, ttl); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if mode != model.GroupDeliveryShared {
+		if err := h.consumerGroupService.UpdateConsumerGroupDeliveryMode(r.Context(), domainName, queueName, request.GroupID, mode); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if request.Priority != 0 {
+		if err := h.consumerGroupService.UpdateConsumerGroupPriority(r.Context(), domainName, queueName, request.GroupID, request.Priority); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if request.Prefetch != 0 {
+		if err := h.consumerGroupService.UpdateConsumerGroupPrefetch(r.Context(), domainName, queueName, request.GroupID, request.Prefetch); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if cleanupAction != "" {
+		if err := h.consumerGroupService.UpdateConsumerGroupCleanupAction(r.Context(), domainName, queueName, request.GroupID, cleanupAction); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":        "success",
+		"groupID":       request.GroupID,
+		"deliveryMode":  string(mode),
+		"priority":      request.Priority,
+		"prefetch":      request.Prefetch,
+		"cleanupAction": string(cleanupAction),
+		"ephemeral":     request.Ephemeral,
+	})
+}
+
+func (h *Handler) updateConsumerGroupDeliveryMode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	groupID := vars["group"]
+
+	var request struct {
+		DeliveryMode string `json:"deliveryMode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := model.ParseGroupDeliveryMode(request.DeliveryMode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// group check
+	if _, err := h.consumerGroupService.GetGroupDetails(r.Context(), domainName, queueName, groupID); err != nil {
+		http.Error(w, "Consumer group not found or error: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := h.consumerGroupService.UpdateConsumerGroupDeliveryMode(r.Context(), domainName, queueName, groupID, mode); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "success",
-		"groupID": request.GroupID,
+		"status":       "success",
+		"groupID":      groupID,
+		"deliveryMode": string(mode),
+	})
+}
+
+// overrides the action of the stale group cleanup for a group, an empty
+// action following the policy again
+func (h *Handler) updateConsumerGroupCleanupAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	groupID := vars["group"]
+
+	var request struct {
+		CleanupAction string `json:"cleanupAction"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	action, err := model.ParseGroupCleanupAction(request.CleanupAction)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// group check
+	if _, err := h.consumerGroupService.GetGroupDetails(r.Context(), domainName, queueName, groupID); err != nil {
+		http.Error(w, "Consumer group not found or error: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := h.consumerGroupService.UpdateConsumerGroupCleanupAction(r.Context(), domainName, queueName, groupID, action); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":        "success",
+		"groupID":       groupID,
+		"cleanupAction": string(action),
+	})
+}
+
+// exports the offsets of the groups of a queue, for an import on another instance
+func (h *Handler) exportGroupOffsets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	offsets, err := h.consumerGroupService.ExportOffsets(r.Context(), vars["domain"], vars["queue"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(offsets)
+}
+
+// creates the groups of an export on the queue, positioned where they were
+func (h *Handler) importGroupOffsets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var offsets model.GroupOffsets
+	if err := json.NewDecoder(r.Body).Decode(&offsets); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.consumerGroupService.ImportOffsets(r.Context(), vars["domain"], vars["queue"], &offsets)
+	if errors.Is(err, model.ErrInvalidOffsets) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"results": results,
+	})
+}
+
+// lists the groups the stale group cleanup removes within the `within`
+// duration (default 24h), soonest first
+func (h *Handler) listUpcomingCleanups(w http.ResponseWriter, r *http.Request) {
+	var within time.Duration
+	if value := r.URL.Query().Get("within"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid within duration", http.StatusBadRequest)
+			return
+		}
+		within = parsed
+	}
+
+	cleanups, err := h.consumerGroupService.UpcomingCleanups(r.Context(), within)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"cleanups": cleanups,
+	})
+}
+
+// lists the positions kept by archiving cleanups
+func (h *Handler) listArchivedGroups(w http.ResponseWriter, r *http.Request) {
+	archived, err := h.consumerGroupService.ArchivedGroups(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"archived": archived,
+	})
+}
+
+func (h *Handler) updateConsumerGroupPriority(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	groupID := vars["group"]
+
+	var request struct {
+		Priority *int `json:"priority"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Priority == nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// group check
+	if _, err := h.consumerGroupService.GetGroupDetails(r.Context(), domainName, queueName, groupID); err != nil {
+		http.Error(w, "Consumer group not found or error: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := h.consumerGroupService.UpdateConsumerGroupPriority(r.Context(), domainName, queueName, groupID, *request.Priority); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":   "success",
+		"groupID":  groupID,
+		"priority": *request.Priority,
+	})
+}
+
+func (h *Handler) updateConsumerGroupPrefetch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	groupID := vars["group"]
+
+	var request struct {
+		Prefetch *int `json:"prefetch"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Prefetch == nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if *request.Prefetch < 0 {
+		http.Error(w, model.ErrInvalidPrefetch.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// group check
+	if _, err := h.consumerGroupService.GetGroupDetails(r.Context(), domainName, queueName, groupID); err != nil {
+		http.Error(w, "Consumer group not found or error: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := h.consumerGroupService.UpdateConsumerGroupPrefetch(r.Context(), domainName, queueName, groupID, *request.Prefetch); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":   "success",
+		"groupID":  groupID,
+		"prefetch": *request.Prefetch,
 	})
 }
 
@@ -229,6 +529,42 @@ func (h *Handler) getPendingMessages(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// keeps a group polling between deliveries alive, the body naming the
+// polling consumer being optional
+func (h *Handler) heartbeatConsumerGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	groupID := vars["group"]
+
+	var request struct {
+		ConsumerID string `json:"consumerID"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.consumerGroupService.Heartbeat(r.Context(), domainName, queueName, groupID, request.ConsumerID)
+	switch {
+	case errors.Is(err, model.ErrConsumerNotInGroup):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil && err.Error() == "consumer group not found":
+		http.Error(w, "Consumer group not found or expired", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+	})
+}
+
 func (h *Handler) addConsumerToGroup(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	domainName := vars["domain"]
@@ -0,0 +1,279 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DelegationContextKey contextKey = "delegation"
+
+	// delegationTokenHeader carries the delegated token in place of the
+	// HMAC headers
+	delegationTokenHeader = "X-Delegation-Token"
+
+	// delegationAudience keeps delegated tokens and user JWTs apart
+	delegationAudience = "gortms-delegation"
+)
+
+// delegationClaims are the claims of a delegated token, the issuer being
+// the service account delegating
+type delegationClaims struct {
+	Scope []string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// DelegationMiddleware authenticates the requests of workers holding a token
+// minted by a service account on its behalf. Tokens are signed with the
+// secret of the account: rotating the secret or disabling the account
+// revokes them all, and they only grant the permissions the account still
+// holds. Requests then go through the same checks as HMAC ones, acting as
+// the account restricted to the scope of the token.
+type DelegationMiddleware struct {
+	serviceRepo outbound.ServiceRepository
+	logger      outbound.Logger
+	hmac        *HMACMiddleware
+}
+
+func NewDelegationMiddleware(serviceRepo outbound.ServiceRepository, logger outbound.Logger, hmac *HMACMiddleware) *DelegationMiddleware {
+	return &DelegationMiddleware{
+		serviceRepo: serviceRepo,
+		logger:      logger,
+		hmac:        hmac,
+	}
+}
+
+// Issue mints a token granting part of the permissions of the service
+func (m *DelegationMiddleware) Issue(service *model.ServiceAccount, request model.DelegationRequest) (string, *model.Delegation, error) {
+	ttl, err := request.Validate(service)
+	if err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate delegation ID: %w", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	delegation := &model.Delegation{
+		ID:          hex.EncodeToString(buf),
+		ServiceID:   service.ID,
+		Subject:     request.Subject,
+		Permissions: request.Permissions,
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, delegationClaims{
+		Scope: delegation.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        delegation.ID,
+			Issuer:    service.ID,
+			Subject:   delegation.Subject,
+			Audience:  jwt.ClaimStrings{delegationAudience},
+			IssuedAt:  jwt.NewNumericDate(delegation.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(delegation.ExpiresAt),
+		},
+	})
+	signed, err := token.SignedString([]byte(service.Secret))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign delegation: %w", err)
+	}
+	return signed, delegation, nil
+}
+
+// validate checks the token and returns the account it acts for, scoped
+func (m *DelegationMiddleware) validate(ctx context.Context, tokenString string) (*model.ServiceAccount, *model.Delegation, error) {
+	var claims delegationClaims
+	var service *model.ServiceAccount
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
+		// the claims are parsed but not verified yet, the issuer only picks the key
+		found, err := m.serviceRepo.GetByID(ctx, claims.Issuer)
+		if err != nil {
+			return nil, errors.New("invalid service")
+		}
+		if !found.Enabled {
+			return nil, errors.New("service disabled")
+		}
+		service = found
+		return []byte(found.Secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(delegationAudience),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delegation := &model.Delegation{
+		ID:          claims.ID,
+		ServiceID:   service.ID,
+		Subject:     claims.Subject,
+		Permissions: claims.Scope,
+		IssuedAt:    claims.IssuedAt.Time,
+		ExpiresAt:   claims.ExpiresAt.Time,
+	}
+	return service.Scoped(claims.Scope), delegation, nil
+}
+
+// validates delegated tokens, with the checks of HMAC requests
+func (m *DelegationMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := m.hmac.config
+		if !config.Security.EnableAuthentication {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if config.Security.HMAC.RequireTLS && r.TLS == nil {
+			m.logger.Warn("Delegated request rejected: RequireTLS enabled but request over HTTP",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"remoteAddr", r.RemoteAddr)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 page not found"))
+			return
+		}
+
+		service, delegation, err := m.validate(r.Context(), r.Header.Get(delegationTokenHeader))
+		if err != nil {
+			m.logger.Warn("Delegated token rejected", "path", r.URL.Path, "ERROR", err)
+			m.hmac.unauthorized(w, "invalid or expired delegation token")
+			return
+		}
+
+		// workers act from the networks of the account
+		if len(service.IPWhitelist) > 0 && !m.hmac.isIPAllowed(r.RemoteAddr, service.IPWhitelist) {
+			m.hmac.forbidden(w, "IP not whitelisted")
+			return
+		}
+
+		if check := checkDelegatedAccess(service, r.Method, r.URL.Path); !check.Allowed {
+			m.hmac.forbidden(w, check.Reason)
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			m.serviceRepo.UpdateLastUsed(ctx, service.ID)
+		}()
+
+		release, ok := m.hmac.limiter.AcquireRequest(service.ID, service.Limits.MaxInFlight)
+		if !ok {
+			m.logger.Warn("Request rejected: too many in flight", "serviceID", service.ID, "path", r.URL.Path)
+			http.Error(w, "Too many requests in flight for this service", http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		m.logger.Debug("Delegated request",
+			"serviceID", service.ID,
+			"subject", delegation.Subject,
+			"delegation", delegation.ID,
+			"path", r.URL.Path)
+
+		ctx := context.WithValue(r.Context(), ServiceContextKey, service)
+		ctx = context.WithValue(ctx, DelegationContextKey, delegation)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// checkDelegatedAccess checks a delegated request like a signed one, except
+// that routes needing no permission are refused: a token only reaches what
+// its scope names, workers' own routes being mapped by workerPermission
+func checkDelegatedAccess(service *model.ServiceAccount, method, path string) model.AccessCheck {
+	if extractPermission(method, path) == "" {
+		permission := workerPermission(method, path)
+		if permission == "" {
+			return model.AccessCheck{Reason: "route not available to delegated tokens"}
+		}
+		if _, ok := service.MatchPermission(permission); !ok {
+			return model.AccessCheck{
+				Permission: permission,
+				Reason:     fmt.Sprintf("insufficient permissions for %s", permission),
+			}
+		}
+	}
+	return checkServiceAccess(service, method, path)
+}
+
+// workerPermission maps the worker routes that need no permission from a
+// signed service: group heartbeats and producer sessions
+func workerPermission(method, path string) string {
+	parts := strings.Split(strings.Trim(unversionedAPIPath(path), "/"), "/")
+	if len(parts) < 7 || parts[0] != "api" || parts[1] != "domains" || parts[3] != "queues" {
+		return ""
+	}
+	domain := parts[2]
+
+	switch {
+	case method == "POST" && len(parts) == 8 && parts[5] == "consumer-groups" && parts[7] == "heartbeat":
+		return "consume:" + domain
+	case method == "GET" && len(parts) == 7 && parts[5] == "producers":
+		return "publish:" + domain
+	}
+	return ""
+}
+
+// isDelegatedRequest tells whether the request carries a delegated token
+func isDelegatedRequest(r *http.Request) bool {
+	return r.Header.Get(delegationTokenHeader) != ""
+}
+
+// createDelegation mints a delegated token for the calling service account.
+// Delegated tokens can't mint tokens of their own.
+func (h *Handler) createDelegation(w http.ResponseWriter, r *http.Request) {
+	service := h.hmacMiddleware.GetServiceFromContext(r.Context())
+	if service == nil {
+		http.Error(w, "delegations are minted by service accounts", http.StatusForbidden)
+		return
+	}
+	if _, delegated := r.Context().Value(DelegationContextKey).(*model.Delegation); delegated {
+		http.Error(w, "delegated tokens can't mint delegations", http.StatusForbidden)
+		return
+	}
+
+	var request model.DelegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, delegation, err := h.delegations.Issue(service, request)
+	if errors.Is(err, model.ErrInvalidDelegation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to issue delegation", "serviceID", service.ID, "ERROR", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Delegation issued",
+		"serviceID", service.ID,
+		"subject", delegation.Subject,
+		"delegation", delegation.ID,
+		"permissions", delegation.Permissions,
+		"expiresAt", delegation.ExpiresAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Token string `json:"token"`
+		*model.Delegation
+	}{token, delegation})
+}
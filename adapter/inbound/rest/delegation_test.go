@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegatedTokens(t *testing.T) {
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	hmacMiddleware := NewHMACMiddleware(repo, logger, cfg)
+	delegations := NewDelegationMiddleware(repo, logger, hmacMiddleware)
+	hmacMiddleware.AcceptDelegations(delegations)
+	h := &Handler{logger: logger, config: cfg, hmacMiddleware: hmacMiddleware, delegations: delegations}
+
+	service := createTestService()
+	service.Permissions = append(service.Permissions, "publish:billing")
+	require.NoError(t, repo.Create(context.Background(), service))
+
+	router := mux.NewRouter()
+	router.Use(hmacMiddleware.Middleware)
+	router.HandleFunc("/api/services/delegations", h.createDelegation).Methods("POST")
+	router.PathPrefix("/api/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// the service mints a token for its workers, signing the request with HMAC
+	mint := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, createTestRequest("POST", "/api/services/delegations", body, service))
+		return w
+	}
+	w := mint(`{"subject": "worker-7", "permissions": ["publish:orders"], "ttl": "10m"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var minted struct {
+		Token string `json:"token"`
+		model.Delegation
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &minted))
+	assert.Equal(t, service.ID, minted.ServiceID)
+	assert.Equal(t, "worker-7", minted.Subject)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), minted.ExpiresAt, 2*time.Second)
+
+	assert.Equal(t, http.StatusBadRequest, mint(`{"permissions": ["publish:payments"]}`).Code, "permissions the service lacks")
+	assert.Equal(t, http.StatusBadRequest, mint(`{"permissions": ["publish:orders"], "ttl": "48h"}`).Code)
+
+	serve := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("X-Delegation-Token", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	publishOrders := "/api/domains/orders/queues/incoming/messages"
+
+	assert.Equal(t, http.StatusOK, serve("POST", publishOrders, minted.Token))
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/domains/billing/queues/invoices/messages", minted.Token),
+		"the token only grants its scope")
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/services/delegations", minted.Token),
+		"delegated tokens can't mint tokens")
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", publishOrders, minted.Token+"x"))
+
+	// a token naming another scope than the one signed is rejected
+	parts := strings.Split(minted.Token, ".")
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, delegationClaims{
+		Scope: []string{"*"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    service.ID,
+			Audience:  jwt.ClaimStrings{delegationAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	forgedToken, err := forged.SignedString([]byte("not-the-secret"))
+	require.NoError(t, err)
+	forgedParts := strings.Split(forgedToken, ".")
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", publishOrders, forgedParts[0]+"."+forgedParts[1]+"."+parts[2]))
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, delegationClaims{
+		Scope: []string{"publish:orders"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    service.ID,
+			Audience:  jwt.ClaimStrings{delegationAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	expiredToken, err := expired.SignedString([]byte(service.Secret))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", publishOrders, expiredToken))
+
+	// revoking the permission of the service revokes it from its tokens
+	stored, err := repo.GetByID(context.Background(), service.ID)
+	require.NoError(t, err)
+	stored.Permissions = []string{"consume:payments"}
+	require.NoError(t, repo.Update(context.Background(), stored))
+	assert.Equal(t, http.StatusForbidden, serve("POST", publishOrders, minted.Token))
+
+	// and rotating its secret revokes every token
+	stored.Permissions = []string{"publish:orders"}
+	stored.Secret = "rotated-secret"
+	require.NoError(t, repo.Update(context.Background(), stored))
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", publishOrders, minted.Token))
+}
+
+func TestDelegatedTokensDenyUnmappedRoutes(t *testing.T) {
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	hmacMiddleware := NewHMACMiddleware(repo, logger, cfg)
+	delegations := NewDelegationMiddleware(repo, logger, hmacMiddleware)
+	hmacMiddleware.AcceptDelegations(delegations)
+
+	service := createTestService()
+	service.Permissions = []string{"*"}
+	require.NoError(t, repo.Create(context.Background(), service))
+	token, _, err := delegations.Issue(service, model.DelegationRequest{Subject: "worker-7", Permissions: []string{"consume:orders"}})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(hmacMiddleware.Middleware)
+	router.PathPrefix("/api/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(method, path string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("X-Delegation-Token", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/domains/orders/queues/incoming/messages"))
+	assert.Equal(t, http.StatusOK, serve("POST", "/api/domains/orders/queues/incoming/consumer-groups/billing/heartbeat"))
+
+	// routes the scope doesn't name are closed, even those a signed service reaches freely
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/domains"))
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/domains/orders/queues"))
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/ws/tickets"))
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/domains/orders/queues/incoming/producers/checkout"))
+}
+
+func TestHybridMiddlewareRoutesDelegatedTokens(t *testing.T) {
+	h := &HybridMiddleware{logger: &mockLogger2{}}
+
+	req := httptest.NewRequest("POST", "/api/ws/tickets", nil)
+	assert.False(t, h.isHMACRequest(req))
+
+	req.Header.Set("X-Delegation-Token", "token")
+	assert.True(t, h.isHMACRequest(req))
+}
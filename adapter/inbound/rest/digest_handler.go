@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// queueDigester fingerprints queues so that two copies of them can be compared
+type queueDigester interface {
+	GetQueueDigest(ctx context.Context, domainName, queueName string, bucketSize int) (*model.QueueDigest, error)
+	CompareQueueDigest(ctx context.Context, domainName, queueName string, remote *model.QueueDigest) ([]model.DigestDivergence, error)
+}
+
+// returns the digest of the messages stored in a queue
+func (h *Handler) getQueueDigest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	digester, ok := h.messageService.(queueDigester)
+	if !ok {
+		http.Error(w, "Queue digests not supported", http.StatusNotImplemented)
+		return
+	}
+
+	bucketSize := 0
+	if sizeStr := r.URL.Query().Get("bucketSize"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			http.Error(w, "Invalid bucketSize", http.StatusBadRequest)
+			return
+		}
+		bucketSize = size
+	}
+
+	digest, err := digester.GetQueueDigest(r.Context(), vars["domain"], vars["queue"], bucketSize)
+	if err != nil {
+		writeDigestError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(digest)
+}
+
+// compares a queue with the digest of another copy of it, and reports the
+// buckets of messages that differ
+func (h *Handler) compareQueueDigest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	digester, ok := h.messageService.(queueDigester)
+	if !ok {
+		http.Error(w, "Queue digests not supported", http.StatusNotImplemented)
+		return
+	}
+
+	var remote model.QueueDigest
+	if err := json.NewDecoder(r.Body).Decode(&remote); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	divergences, err := digester.CompareQueueDigest(r.Context(), domainName, queueName, &remote)
+	if err != nil {
+		writeDigestError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"domain":      domainName,
+		"queue":       queueName,
+		"consistent":  len(divergences) == 0,
+		"divergences": divergences,
+	})
+}
+
+func writeDigestError(w http.ResponseWriter, err error) {
+	if errors.Is(err, model.ErrInvalidDigest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusNotFound)
+}
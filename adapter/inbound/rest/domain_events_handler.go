@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// getDomainEvents pages through the events of a domain: since is the next
+// of the previous page, 0 or absent for the first one
+func (h *Handler) getDomainEvents(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["domain"]
+	query := r.URL.Query()
+
+	var since uint64
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		var err error
+		if since, err = strconv.ParseUint(sinceStr, 10, 64); err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.statsService.GetDomainEvents(r.Context(), domainName, since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/adapter/outbound/machineid"
 	"github.com/ajkula/GoRTMS/adapter/outbound/storage"
 	"github.com/ajkula/GoRTMS/config"
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -635,7 +636,7 @@ func setupCompleteTestServer(t *testing.T) *completeTestServer {
 	logger := &mockLogger{}
 
 	// Create repositories
-	serviceRepo, err := storage.NewSecureServiceRepository(filepath.Join(tempDir, "services.db"), logger)
+	serviceRepo, err := storage.NewSecureServiceRepository(filepath.Join(tempDir, "services.db"), machineid.NewMachineID("test-machine-id", "", logger), logger)
 	if err != nil {
 		t.Fatalf("Failed to create service repository: %v", err)
 	}
@@ -671,6 +672,9 @@ func setupCompleteTestServer(t *testing.T) *completeTestServer {
 		consumerGroupRepo,
 		serviceRepo,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	// Setup routes
@@ -1050,6 +1054,11 @@ func (m *mockAuthService) UpdatePassword(user *model.User, old, new string) erro
 	return nil
 }
 
+// ResetPassword implements inbound.AuthService.
+func (m *mockAuthService) ResetPassword(userID, resetBy string) (*model.User, string, error) {
+	return nil, "", fmt.Errorf("user not found")
+}
+
 // UpdateUser implements inbound.AuthService.
 func (m *mockAuthService) UpdateUser(userID string, updates inbound.UpdateUserRequest, isAdmin bool) (*model.User, error) {
 	return &model.User{}, nil
@@ -1260,6 +1269,30 @@ func (m *mockDomainService) ListDomains(ctx context.Context) ([]*model.Domain, e
 	return domains, nil
 }
 
+func (m *mockDomainService) UpdateAllowedRouteSources(ctx context.Context, name string, sources []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	domain, exists := m.domains[name]
+	if !exists {
+		return fmt.Errorf("domain not found")
+	}
+	domain.AllowedRouteSources = sources
+	return nil
+}
+
+func (m *mockDomainService) UpdateDomainLabels(ctx context.Context, name string, labels map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	domain, exists := m.domains[name]
+	if !exists {
+		return fmt.Errorf("domain not found")
+	}
+	domain.Labels = labels
+	return nil
+}
+
 // mockQueueService implements inbound.QueueService
 type mockQueueService struct {
 	queues map[string]map[string]*model.Queue // domain -> queue -> Queue
@@ -1299,6 +1332,80 @@ func (m *mockQueueService) GetQueue(ctx context.Context, domainName, queueName s
 	return queue, nil
 }
 
+func (m *mockQueueService) UpdateQueueLabels(ctx context.Context, domainName, queueName string, labels map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue, exists := m.queues[domainName][queueName]
+	if !exists {
+		return fmt.Errorf("queue not found")
+	}
+	queue.Config.Labels = labels
+	return nil
+}
+
+func (m *mockQueueService) UpdateQueueMirror(ctx context.Context, domainName, queueName string, mirror *model.MirrorConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue, exists := m.queues[domainName][queueName]
+	if !exists {
+		return fmt.Errorf("queue not found")
+	}
+	if mirror != nil {
+		if err := mirror.Validate(queueName); err != nil {
+			return err
+		}
+	}
+	queue.Config.Mirror = mirror
+	return nil
+}
+
+func (m *mockQueueService) UpdateQueueEnrichment(ctx context.Context, domainName, queueName string, rules []model.EnrichmentRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue, exists := m.queues[domainName][queueName]
+	if !exists {
+		return fmt.Errorf("queue not found")
+	}
+	if err := model.ValidateEnrichment(rules); err != nil {
+		return err
+	}
+	queue.Config.Enrichment = rules
+	return nil
+}
+
+func (m *mockQueueService) UpdateQueueMode(ctx context.Context, domainName, queueName string, mode model.QueueMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue, exists := m.queues[domainName][queueName]
+	if !exists {
+		return fmt.Errorf("queue not found")
+	}
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	queue.Config.Mode = mode
+	return nil
+}
+
+func (m *mockQueueService) UpdateQueueImplicitGroups(ctx context.Context, domainName, queueName string, policy model.ImplicitGroupPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue, exists := m.queues[domainName][queueName]
+	if !exists {
+		return fmt.Errorf("queue not found")
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	queue.Config.ImplicitGroups = policy
+	return nil
+}
+
 func (m *mockQueueService) DeleteQueue(ctx context.Context, domainName, queueName string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -1338,16 +1445,18 @@ func (m *mockQueueService) Cleanup() {}
 // mockQueueHandler implements model.QueueHandler
 type mockQueueHandler struct{}
 
-func (m *mockQueueHandler) Stop()                                           {}
-func (m *mockQueueHandler) Start(ctx context.Context)                       {}
-func (m *mockQueueHandler) RemoveConsumerGroup(groupID string)              {}
-func (m *mockQueueHandler) RequestMessages(groupID string, count int) error { return nil }
-func (m *mockQueueHandler) GetQueue() *model.Queue                          { return &model.Queue{} }
+func (m *mockQueueHandler) Stop()                              {}
+func (m *mockQueueHandler) Start(ctx context.Context)          {}
+func (m *mockQueueHandler) RemoveConsumerGroup(groupID string) {}
+func (m *mockQueueHandler) RequestMessages(ctx context.Context, groupID string, count int) error {
+	return nil
+}
+func (m *mockQueueHandler) GetQueue() *model.Queue { return &model.Queue{} }
 func (m *mockQueueHandler) Dequeue(ctx context.Context) (*model.Message, error) {
 	return &model.Message{}, nil
 }
 func (m *mockQueueHandler) Enqueue(context.Context, *model.Message) error { return nil }
-func (m *mockQueueHandler) ConsumeMessage(groupID string, timeout time.Duration) (*model.Message, error) {
+func (m *mockQueueHandler) ConsumeMessage(ctx context.Context, groupID string, timeout time.Duration) (*model.Message, error) {
 	return &model.Message{}, nil
 }
 func (m *mockQueueHandler) AddConsumerGroup(groupID string, lastIndex int64) error { return nil }
@@ -1373,6 +1482,18 @@ func (m *mockRoutingService) ListRoutingRules(ctx context.Context, domainName st
 	return []*model.RoutingRule{}, nil
 }
 
+func (m *mockRoutingService) UpdateRoutingRule(ctx context.Context, domainName, sourceQueue, destKey string, update model.RoutingRuleUpdate) (*model.RoutingRule, error) {
+	return &model.RoutingRule{SourceQueue: sourceQueue, DestinationQueue: destKey}, nil
+}
+
+func (m *mockRoutingService) ReorderRoutingRules(ctx context.Context, domainName, sourceQueue string, order []string) error {
+	return nil
+}
+
+func (m *mockRoutingService) GetRoutingRuleStats(ctx context.Context, domainName string) ([]model.RoutingRuleStats, error) {
+	return []model.RoutingRuleStats{}, nil
+}
+
 // mockStatsService implements inbound.StatsService
 type mockStatsService struct{}
 
@@ -1392,6 +1513,24 @@ func (m *mockStatsService) TrackMessageConsumed(domainName, queueName string)  {
 func (m *mockStatsService) GetStatsWithAggregation(ctx context.Context, period, granularity string) (any, error) {
 	return m.GetStats(ctx)
 }
+func (m *mockStatsService) GetStatsByLabel(ctx context.Context, labelKey string) (any, error) {
+	return map[string]any{"label": labelKey, "groups": []any{}}, nil
+}
+func (m *mockStatsService) GetTopN(ctx context.Context, ranking string, limit int) (any, error) {
+	return []any{}, nil
+}
+func (m *mockStatsService) GetScalingHint(ctx context.Context, domainName, queueName, groupID string, policy model.ScalingPolicy) (*model.ScalingHint, error) {
+	return nil, model.ErrNoScalingMetrics
+}
+func (m *mockStatsService) GetBacklogs(ctx context.Context) ([]model.QueueBacklog, error) {
+	return nil, nil
+}
+func (m *mockStatsService) GetQueueWaits(ctx context.Context) ([]model.QueueWaits, error) {
+	return []model.QueueWaits{}, nil
+}
+func (m *mockStatsService) GetDomainEvents(ctx context.Context, domainName string, since uint64, limit int) (*model.EventPage, error) {
+	return &model.EventPage{Domain: domainName, Events: []model.SystemEvent{}, Next: since}, nil
+}
 func (m *mockStatsService) RecordDomainCreated(name string)                      {}
 func (m *mockStatsService) RecordDomainDeleted(name string)                      {}
 func (m *mockStatsService) RecordQueueCreated(domain, queue string)              {}
@@ -1479,6 +1618,88 @@ func (m *mockConsumerGroupService) UpdateConsumerGroupTTL(ctx context.Context, d
 	return nil
 }
 
+func (m *mockConsumerGroupService) UpdateConsumerGroupPriority(ctx context.Context, domainName, queueName, groupID string, priority int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)
+	if group, exists := m.groups[key]; exists {
+		group.Priority = priority
+	}
+	return nil
+}
+
+func (m *mockConsumerGroupService) UpdateConsumerGroupPrefetch(ctx context.Context, domainName, queueName, groupID string, prefetch int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)
+	if group, exists := m.groups[key]; exists {
+		group.Prefetch = prefetch
+	}
+	return nil
+}
+
+func (m *mockConsumerGroupService) UpdateConsumerGroupDeliveryMode(ctx context.Context, domainName, queueName, groupID string, mode model.GroupDeliveryMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)
+	if group, exists := m.groups[key]; exists {
+		group.DeliveryMode = mode
+	}
+	return nil
+}
+
+func (m *mockConsumerGroupService) CreateEphemeralConsumerGroup(ctx context.Context, domainName, queueName, groupID string, ttl time.Duration) error {
+	if err := m.CreateConsumerGroup(ctx, domainName, queueName, groupID, ttl); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups[fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)].Ephemeral = true
+	return nil
+}
+
+func (m *mockConsumerGroupService) UpdateConsumerGroupCleanupAction(ctx context.Context, domainName, queueName, groupID string, action model.GroupCleanupAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)
+	if group, exists := m.groups[key]; exists {
+		group.CleanupAction = action
+	}
+	return nil
+}
+
+func (m *mockConsumerGroupService) UpcomingCleanups(ctx context.Context, within time.Duration) ([]model.ScheduledCleanup, error) {
+	return []model.ScheduledCleanup{}, nil
+}
+
+func (m *mockConsumerGroupService) ArchivedGroups(ctx context.Context) ([]*model.ArchivedGroup, error) {
+	return []*model.ArchivedGroup{}, nil
+}
+
+func (m *mockConsumerGroupService) ExportOffsets(ctx context.Context, domainName, queueName string) (*model.GroupOffsets, error) {
+	return &model.GroupOffsets{Domain: domainName, Queue: queueName, Groups: []model.GroupOffset{}}, nil
+}
+
+func (m *mockConsumerGroupService) ImportOffsets(ctx context.Context, domainName, queueName string, offsets *model.GroupOffsets) ([]model.OffsetImportResult, error) {
+	return []model.OffsetImportResult{}, nil
+}
+
+func (m *mockConsumerGroupService) Heartbeat(ctx context.Context, domainName, queueName, groupID, consumerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", domainName, queueName, groupID)
+	if group, exists := m.groups[key]; exists {
+		group.UpdateActivity()
+	}
+	return nil
+}
+
 func (m *mockConsumerGroupService) GetPendingMessages(ctx context.Context, domainName, queueName, groupID string) ([]*model.Message, error) {
 	return []*model.Message{}, nil
 }
@@ -1592,3 +1813,58 @@ func (m *mockConsumerGroupRepo) SetGroupTTL(ctx context.Context, domainName, que
 func (m *mockConsumerGroupRepo) UpdateLastActivity(ctx context.Context, domainName, queueName, groupID string) error {
 	return nil
 }
+
+func TestSetupListenerRoutes_Roles(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	routes := func(role string) *mux.Router {
+		router := mux.NewRouter()
+		server.handler.SetupListenerRoutes(router, config.HTTPListener{Name: role, Role: role})
+		return router
+	}
+	matches := func(router *mux.Router, method, path string) bool {
+		var match mux.RouteMatch
+		return router.Match(httptest.NewRequest(method, path, nil), &match) && match.MatchErr == nil
+	}
+
+	management := routes(config.ListenerRoleManagement)
+	data := routes(config.ListenerRoleData)
+
+	if !matches(management, "GET", "/api/domains") {
+		t.Errorf("GET /api/domains should be served by the management listener")
+	}
+	if !matches(management, "GET", "/api/admin/settings") {
+		t.Errorf("GET /api/admin/settings should be served by the management listener")
+	}
+	if !matches(management, "GET", "/ui/index.html") {
+		t.Errorf("GET /ui/index.html should be served by the management listener")
+	}
+	if matches(management, "POST", "/api/domains/shop/queues/orders/messages") {
+		t.Errorf("POST /api/domains/shop/queues/orders/messages should not be served by the management listener")
+	}
+
+	if !matches(data, "POST", "/api/domains/shop/queues/orders/messages") {
+		t.Errorf("POST /api/domains/shop/queues/orders/messages should be served by the data listener")
+	}
+	if !matches(data, "GET", "/api/domains/shop/queues/orders/messages") {
+		t.Errorf("GET /api/domains/shop/queues/orders/messages should be served by the data listener")
+	}
+	if !matches(data, "POST", "/api/ws/tickets") {
+		t.Errorf("POST /api/ws/tickets should be served by the data listener")
+	}
+	if matches(data, "GET", "/api/domains") {
+		t.Errorf("GET /api/domains should not be served by the data listener")
+	}
+	if matches(data, "GET", "/ui/index.html") {
+		t.Errorf("GET /ui/index.html should not be served by the data listener")
+	}
+
+	// health checks are served on every listener
+	if !matches(management, "GET", "/health") {
+		t.Errorf("GET /health should be served by the management listener")
+	}
+	if !matches(data, "GET", "/health") {
+		t.Errorf("GET /health should be served by the data listener")
+	}
+}
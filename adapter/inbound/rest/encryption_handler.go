@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// getQueueEncryptionKey returns the AES-256-GCM key sealing the encrypted
+// fields of a queue, consumers holding it decrypt the fields themselves
+func (h *Handler) getQueueEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	keys, ok := h.messageService.(interface {
+		QueueEncryptionKey(ctx context.Context, domainName, queueName string) (string, [32]byte, error)
+	})
+	if !ok {
+		http.Error(w, "Field encryption not supported", http.StatusNotImplemented)
+		return
+	}
+
+	keyID, key, err := keys.QueueEncryptionKey(r.Context(), domainName, queueName)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrFieldEncryptionDisabled):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		default:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	disclosedTo := "anonymous"
+	if user, ok := r.Context().Value(UserContextKey).(*model.User); ok && user != nil {
+		disclosedTo = user.Username
+	}
+	h.logger.Info("Queue encryption key disclosed",
+		"domain", domainName,
+		"queue", queueName,
+		"user", disclosedTo)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{
+		"keyId":     keyID,
+		"algorithm": "AES-256-GCM",
+		"key":       base64.StdEncoding.EncodeToString(key[:]),
+	})
+}
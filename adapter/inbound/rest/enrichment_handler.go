@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// requestPublisher is who published through the request: the user of the
+// JWT or the service account of the HMAC signature, and the peer address.
+// X-Forwarded-For is ignored, producers could set it to anything.
+func requestPublisher(r *http.Request) model.Publisher {
+	var publisher model.Publisher
+	if user := GetUserFromContext(r.Context()); user != nil {
+		publisher.Principal = "user:" + user.Username
+	} else if service, ok := r.Context().Value(ServiceContextKey).(*model.ServiceAccount); ok && service != nil {
+		publisher.Principal = "service:" + service.ID
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	publisher.ClientIP = ip
+	return publisher
+}
+
+// updateQueueEnrichment replaces the enrichment rules of a queue
+func (h *Handler) updateQueueEnrichment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var request struct {
+		Rules []model.EnrichmentRule `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueEnrichment(r.Context(), domainName, queueName, request.Rules); err != nil {
+		if errors.Is(err, model.ErrInvalidEnrichment) {
+			domainWriteError(w, err, http.StatusBadRequest)
+			return
+		}
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"domain": domainName,
+		"queue":  queueName,
+		"rules":  request.Rules,
+	})
+}
+
+// deleteQueueEnrichment stops enriching the messages of a queue
+func (h *Handler) deleteQueueEnrichment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueEnrichment(r.Context(), domainName, vars["queue"], nil); err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
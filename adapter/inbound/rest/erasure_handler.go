@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// startErasure purges the messages of a data subject in the background,
+// the job is answered with 202 and polled through its report
+func (h *Handler) startErasure(w http.ResponseWriter, r *http.Request) {
+	var request model.ErasureRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.erasureService.StartErasure(r.Context(), request)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidErasure) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", r.URL.Path+"/"+report.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *Handler) listErasures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"erasures": h.erasureService.ListErasures(),
+	})
+}
+
+func (h *Handler) getErasure(w http.ResponseWriter, r *http.Request) {
+	report, err := h.erasureService.GetErasure(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
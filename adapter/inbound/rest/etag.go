@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// resourceETag hashes the state a response is rendered from, so polls can be
+// answered without building the response body
+func resourceETag(parts ...any) string {
+	h := fnv.New64a()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v\x00", part)
+	}
+	return fmt.Sprintf("%q", fmt.Sprintf("%016x", h.Sum64()))
+}
+
+// notModified sets the ETag of the response and answers 304 Not Modified when
+// the If-None-Match header of the request already holds it
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison If-None-Match uses (RFC 9110)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// versionETag quotes a resource version the way If-Match carries it
+func versionETag(version uint64) string {
+	return strconv.Quote(strconv.FormatUint(version, 10))
+}
+
+// ifMatchFails reports whether the If-Match header of a write rules it out,
+// using the strong comparison: weak tags and missing resources never match
+func ifMatchFails(ifMatch string, version uint64, exists bool) bool {
+	if ifMatch == "" {
+		return false
+	}
+	if !exists {
+		return true
+	}
+	current := versionETag(version)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == current {
+			return false
+		}
+	}
+	return true
+}
+
+// domainPrecondition answers 412 Precondition Failed when the If-Match header
+// of a write doesn't hold the current version of the domain. The request it
+// returns carries the version matched, so the repository refuses the write if
+// the domain is stored again before it
+func (h *Handler) domainPrecondition(w http.ResponseWriter, r *http.Request, domainName string) (*http.Request, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return r, true
+	}
+
+	domain, err := h.domainService.GetDomain(r.Context(), domainName)
+	exists := err == nil && domain != nil
+	var version uint64
+	if exists {
+		version = domain.Version
+	}
+
+	if ifMatchFails(ifMatch, version, exists) {
+		http.Error(w, fmt.Sprintf("Domain %s does not match If-Match %s", domainName, ifMatch), http.StatusPreconditionFailed)
+		return r, false
+	}
+	// "*" only asks for the domain to exist
+	if strings.TrimSpace(ifMatch) == "*" {
+		return r, true
+	}
+	return r.WithContext(model.WithDomainPrecondition(r.Context(), domainName, version)), true
+}
+
+// domainWriteError answers a failed domain write with the given status, or
+// 412 Precondition Failed when the domain moved past its If-Match version
+func domainWriteError(w http.ResponseWriter, err error, status int) {
+	if errors.Is(err, model.ErrDomainVersionMismatch) {
+		status = http.StatusPreconditionFailed
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// queueCounts lists the message counts of queues in a stable order, they
+// change without the domain being stored
+func queueCounts(queues map[string]*model.Queue) []string {
+	counts := make([]string, 0, len(queues))
+	for name, queue := range queues {
+		counts = append(counts, fmt.Sprintf("%s=%d", name, queue.MessageCount))
+	}
+	sort.Strings(counts)
+	return counts
+}
+
+// ruleCounts lists the match counts of routing rules in a stable order, they
+// change without the domain being stored
+func ruleCounts(stats []model.RoutingRuleStats) []string {
+	counts := make([]string, 0, len(stats))
+	for _, rule := range stats {
+		counts = append(counts, fmt.Sprintf("%s=%d/%d", rule.Rule, rule.Matched, rule.Routed))
+	}
+	sort.Strings(counts)
+	return counts
+}
+
+// domainVersion returns the stored version of a domain, 0 when it can't be found
+func (h *Handler) domainVersion(ctx context.Context, name string) uint64 {
+	domain, err := h.domainService.GetDomain(ctx, name)
+	if err != nil || domain == nil {
+		return 0
+	}
+	return domain.Version
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := resourceETag("shop", uint64(3))
+
+	assert.False(t, etagMatches("", etag))
+	assert.True(t, etagMatches(etag, etag))
+	assert.True(t, etagMatches(`"other", W/`+etag, etag), "weak comparison")
+	assert.True(t, etagMatches("*", etag))
+	assert.False(t, etagMatches(resourceETag("shop", uint64(4)), etag))
+}
+
+func TestDomainGetsAnswerNotModified(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	domains := server.handler.domainService
+	require.NoError(t, domains.CreateDomain(context.Background(), &model.DomainConfig{Name: "shop"}))
+	shop, _ := domains.GetDomain(context.Background(), "shop")
+	shop.Version = 1
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/domains", "/api/domains/shop", "/api/domains/shop/routes"} {
+		first := get(path, "")
+		require.Equal(t, http.StatusOK, first.Code, path)
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag, path)
+
+		unchanged := get(path, etag)
+		assert.Equal(t, http.StatusNotModified, unchanged.Code, path)
+		assert.Empty(t, unchanged.Body.String(), path)
+	}
+
+	etag := get("/api/domains/shop", "").Header().Get("ETag")
+	shop.Version = 2 // stored again
+	assert.Equal(t, http.StatusOK, get("/api/domains/shop", etag).Code)
+
+	etag = get("/api/domains", "").Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, get("/api/domains?label=team", etag).Code, "filters are part of the tag")
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// externalMetricsPrefix is where the Kubernetes external metrics API is served,
+// the path an APIService or a metrics adapter forwards HPA queries to
+const externalMetricsPrefix = "/apis/external.metrics.k8s.io/v1beta1"
+
+// External metrics served per queue or consumer group
+const (
+	metricQueueDepth = "gortms_queue_depth"        // labels: domain, queue
+	metricGroupLag   = "gortms_consumer_group_lag" // labels: domain, queue, group
+)
+
+type externalMetricValue struct {
+	MetricName   string            `json:"metricName"`
+	MetricLabels map[string]string `json:"metricLabels"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Value        string            `json:"value"` // Kubernetes quantity
+}
+
+type externalMetricValueList struct {
+	Kind       string                `json:"kind"`
+	APIVersion string                `json:"apiVersion"`
+	Metadata   struct{}              `json:"metadata"`
+	Items      []externalMetricValue `json:"items"`
+}
+
+func newExternalMetricValueList(capacity int) externalMetricValueList {
+	return externalMetricValueList{
+		Kind:       "ExternalMetricValueList",
+		APIVersion: "external.metrics.k8s.io/v1beta1",
+		Items:      make([]externalMetricValue, 0, capacity),
+	}
+}
+
+type apiResource struct {
+	Name         string   `json:"name"`
+	SingularName string   `json:"singularName"`
+	Namespaced   bool     `json:"namespaced"`
+	Kind         string   `json:"kind"`
+	Verbs        []string `json:"verbs"`
+}
+
+type apiResourceList struct {
+	Kind         string        `json:"kind"`
+	APIVersion   string        `json:"apiVersion"`
+	GroupVersion string        `json:"groupVersion"`
+	Resources    []apiResource `json:"resources"`
+}
+
+// setupExternalMetricsRoutes serves queue depths and consumer group lags the
+// way the Kubernetes external metrics API does, so HPA and KEDA can scale
+// consumers on them
+func (h *Handler) setupExternalMetricsRoutes(router *mux.Router) {
+	router.Handle(externalMetricsPrefix, h.authMiddleware.Middleware(http.HandlerFunc(h.listExternalMetrics))).Methods("GET")
+
+	metricsRouter := router.PathPrefix(externalMetricsPrefix).Subrouter()
+	metricsRouter.Use(h.authMiddleware.Middleware)
+	metricsRouter.HandleFunc("/namespaces/{namespace}/{metric}", h.getExternalMetric).Methods("GET")
+}
+
+// listExternalMetrics answers the discovery request of the API group
+func (h *Handler) listExternalMetrics(w http.ResponseWriter, r *http.Request) {
+	list := apiResourceList{
+		Kind:         "APIResourceList",
+		APIVersion:   "v1",
+		GroupVersion: "external.metrics.k8s.io/v1beta1",
+	}
+	for _, name := range []string{metricQueueDepth, metricGroupLag} {
+		list.Resources = append(list.Resources, apiResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      []string{"get"},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// getExternalMetric lists the values of a metric matching the labelSelector
+// (domain, queue and group). GoRTMS has no namespaces, any is accepted.
+func (h *Handler) getExternalMetric(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+	if metric != metricQueueDepth && metric != metricGroupLag {
+		http.Error(w, "Unknown metric "+metric, http.StatusNotFound)
+		return
+	}
+
+	selector, err := model.ParseLabelSelector([]string{r.URL.Query().Get("labelSelector")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	backlogs, err := h.statsService.GetBacklogs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	list := newExternalMetricValueList(len(backlogs))
+	add := func(labels map[string]string, value int) {
+		if model.MatchLabels(labels, selector) {
+			list.Items = append(list.Items, externalMetricValue{
+				MetricName:   metric,
+				MetricLabels: labels,
+				Timestamp:    now,
+				Value:        strconv.Itoa(value),
+			})
+		}
+	}
+
+	for _, backlog := range backlogs {
+		if metric == metricQueueDepth {
+			add(map[string]string{"domain": backlog.Domain, "queue": backlog.Queue}, backlog.Depth)
+			continue
+		}
+		groups := make([]string, 0, len(backlog.Groups))
+		for group := range backlog.Groups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			add(map[string]string{"domain": backlog.Domain, "queue": backlog.Queue, "group": group}, backlog.Groups[group])
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
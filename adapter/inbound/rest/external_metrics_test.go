@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backlogStatsService serves fixed backlogs
+type backlogStatsService struct {
+	mockStatsService
+	backlogs []model.QueueBacklog
+}
+
+func (s *backlogStatsService) GetBacklogs(ctx context.Context) ([]model.QueueBacklog, error) {
+	return s.backlogs, nil
+}
+
+func TestExternalMetricsAPI(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	server.handler.statsService = &backlogStatsService{backlogs: []model.QueueBacklog{
+		{Domain: "shop", Queue: "orders", Depth: 120, Groups: map[string]int{"billing": 40, "search": 5}},
+		{Domain: "shop", Queue: "mails", Depth: 3, Groups: map[string]int{}},
+	}}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+	values := func(w *httptest.ResponseRecorder) map[string]string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list externalMetricValueList
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+		assert.Equal(t, "ExternalMetricValueList", list.Kind)
+
+		values := make(map[string]string)
+		for _, item := range list.Items {
+			values[item.MetricLabels["queue"]+"/"+item.MetricLabels["group"]] = item.Value
+		}
+		return values
+	}
+
+	w := get(externalMetricsPrefix)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), metricGroupLag)
+
+	assert.Equal(t, map[string]string{"orders/": "120", "mails/": "3"},
+		values(get(externalMetricsPrefix+"/namespaces/default/gortms_queue_depth")))
+	assert.Equal(t, map[string]string{"orders/": "120"},
+		values(get(externalMetricsPrefix+"/namespaces/default/gortms_queue_depth?labelSelector=domain=shop,queue=orders")))
+	assert.Equal(t, map[string]string{"orders/billing": "40"},
+		values(get(externalMetricsPrefix+"/namespaces/workers/gortms_consumer_group_lag?labelSelector=group=billing")))
+
+	assert.Equal(t, http.StatusNotFound, get(externalMetricsPrefix+"/namespaces/default/cpu").Code)
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// returns the sampled handler failures of a queue, newest first
+func (h *Handler) getQueueErrors(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	channelQueue, err := h.queueService.GetChannelQueue(r.Context(), domainName, queueName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	sampler, ok := channelQueue.(interface {
+		GetDeliveryFailures() ([]model.DeliveryFailure, int64)
+	})
+	if !ok {
+		http.Error(w, "Failure sampling not supported for this queue", http.StatusNotImplemented)
+		return
+	}
+
+	failures, total := sampler.GetDeliveryFailures()
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit >= 0 && limit < len(failures) {
+			failures = failures[:limit]
+		}
+	}
+
+	// truncated payloads aren't valid JSON anymore, redaction withholds them
+	if h.config.Redaction.Enabled() {
+		for i := range failures {
+			failures[i].Payload = h.config.Redaction.Payload(failures[i].Payload)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"domain":        domainName,
+		"queue":         queueName,
+		"totalFailures": total,
+		"errors":        failures,
+	})
+}
+
+// returns the handler latency, failures and quarantine of the subscribers of a queue
+func (h *Handler) getSubscriberStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	reporter, ok := h.messageService.(interface {
+		GetSubscriberStats(ctx context.Context, domainName, queueName string) ([]model.SubscriberStats, error)
+	})
+	if !ok {
+		http.Error(w, "Subscriber stats not supported", http.StatusNotImplemented)
+		return
+	}
+
+	subscribers, err := reporter.GetSubscriberStats(r.Context(), domainName, queueName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"domain":      domainName,
+		"queue":       queueName,
+		"subscribers": subscribers,
+	})
+}
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// faultInjector is implemented by message services supporting fault injection
+type faultInjector interface {
+	InjectFault(ctx context.Context, fault model.QueueFault) error
+	ClearFault(domainName, queueName string) bool
+	ListFaults() []model.QueueFault
+}
+
+type faultRequest struct {
+	PublishFailureRate float64 `json:"publishFailureRate"`
+	ConsumeLatency     string  `json:"consumeLatency"` // e.g. "250ms"
+	CircuitOpen        bool    `json:"circuitOpen"`
+	TTL                string  `json:"ttl"` // defaults to 10m, at most 24h
+}
+
+type faultResponse struct {
+	Domain             string    `json:"domain"`
+	Queue              string    `json:"queue"`
+	PublishFailureRate float64   `json:"publishFailureRate,omitempty"`
+	ConsumeLatency     string    `json:"consumeLatency,omitempty"`
+	CircuitOpen        bool      `json:"circuitOpen,omitempty"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+}
+
+func newFaultResponse(fault model.QueueFault) faultResponse {
+	response := faultResponse{
+		Domain:             fault.Domain,
+		Queue:              fault.Queue,
+		PublishFailureRate: fault.PublishFailureRate,
+		CircuitOpen:        fault.CircuitOpen,
+		ExpiresAt:          fault.ExpiresAt,
+	}
+	if fault.ConsumeLatency > 0 {
+		response.ConsumeLatency = fault.ConsumeLatency.String()
+	}
+	return response
+}
+
+func (h *Handler) faultInjector(w http.ResponseWriter) (faultInjector, bool) {
+	injector, ok := h.messageService.(faultInjector)
+	if !ok {
+		http.Error(w, "Fault injection not supported", http.StatusNotImplemented)
+	}
+	return injector, ok
+}
+
+func (h *Handler) listFaults(w http.ResponseWriter, r *http.Request) {
+	injector, ok := h.faultInjector(w)
+	if !ok {
+		return
+	}
+
+	faults := injector.ListFaults()
+	response := make([]faultResponse, 0, len(faults))
+	for _, fault := range faults {
+		response = append(response, newFaultResponse(fault))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"faults": response})
+}
+
+// injectFault replaces the fault of a queue, it lifts itself after its TTL
+func (h *Handler) injectFault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	injector, ok := h.faultInjector(w)
+	if !ok {
+		return
+	}
+
+	var request faultRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	fault := model.QueueFault{
+		Domain:             vars["domain"],
+		Queue:              vars["queue"],
+		PublishFailureRate: request.PublishFailureRate,
+		CircuitOpen:        request.CircuitOpen,
+	}
+	if request.ConsumeLatency != "" {
+		latency, err := time.ParseDuration(request.ConsumeLatency)
+		if err != nil {
+			http.Error(w, "Invalid consumeLatency", http.StatusBadRequest)
+			return
+		}
+		fault.ConsumeLatency = latency
+	}
+
+	ttl := model.DefaultFaultTTL
+	if request.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(request.TTL); err != nil || ttl <= 0 || ttl > model.MaxFaultTTL {
+			http.Error(w, "ttl must be a duration up to "+model.MaxFaultTTL.String(), http.StatusBadRequest)
+			return
+		}
+	}
+	fault.ExpiresAt = time.Now().Add(ttl).UTC()
+
+	if err := injector.InjectFault(r.Context(), fault); err != nil {
+		if errors.Is(err, model.ErrInvalidFault) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newFaultResponse(fault))
+}
+
+func (h *Handler) clearFault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	injector, ok := h.faultInjector(w)
+	if !ok {
+		return
+	}
+
+	if !injector.ClearFault(vars["domain"], vars["queue"]) {
+		http.Error(w, "No fault injected into this queue", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
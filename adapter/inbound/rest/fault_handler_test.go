@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faultyMessageService records injected faults and fails publishes to faulted queues
+type faultyMessageService struct {
+	*mockMessageService
+	faults map[string]model.QueueFault
+}
+
+func (s *faultyMessageService) InjectFault(ctx context.Context, fault model.QueueFault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+	s.faults[fault.Domain+"/"+fault.Queue] = fault
+	return nil
+}
+
+func (s *faultyMessageService) ClearFault(domainName, queueName string) bool {
+	_, exists := s.faults[domainName+"/"+queueName]
+	delete(s.faults, domainName+"/"+queueName)
+	return exists
+}
+
+func (s *faultyMessageService) ListFaults() []model.QueueFault {
+	faults := make([]model.QueueFault, 0, len(s.faults))
+	for _, fault := range s.faults {
+		faults = append(faults, fault)
+	}
+	return faults
+}
+
+func (s *faultyMessageService) PublishMessage(domainName, queueName string, message *model.Message) error {
+	if s.faults[domainName+"/"+queueName].CircuitOpen {
+		return model.ErrInjectedCircuitOpen
+	}
+	return s.mockMessageService.PublishMessage(domainName, queueName, message)
+}
+
+func TestFaultInjectionAPI(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	ctx := context.Background()
+	require.NoError(t, server.handler.domainService.CreateDomain(ctx, &model.DomainConfig{Name: "shop"}))
+	require.NoError(t, server.handler.queueService.CreateQueue(ctx, "shop", "orders", &model.QueueConfig{}))
+
+	messages := server.handler.messageService.(*mockMessageService)
+	server.handler.messageService = &faultyMessageService{mockMessageService: messages, faults: make(map[string]model.QueueFault)}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/admin/domains/shop/queues/orders/faults", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/admin/domains/shop/queues/orders/faults", `{"circuitOpen": true, "ttl": "48h"}`).Code)
+
+	w := do("PUT", "/api/admin/domains/shop/queues/orders/faults", `{"circuitOpen": true, "consumeLatency": "250ms", "ttl": "1m"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var fault faultResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&fault))
+	assert.Equal(t, "250ms", fault.ConsumeLatency)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), fault.ExpiresAt, 5*time.Second)
+
+	w = do("POST", "/api/domains/shop/queues/orders/messages", `{"id": 1}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	w = do("GET", "/api/admin/faults", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"circuitOpen":true`)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/api/admin/domains/shop/queues/orders/faults", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/admin/domains/shop/queues/orders/faults", "").Code)
+}
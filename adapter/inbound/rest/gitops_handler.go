@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// getGitOpsStatus returns the drift report of the last reconciliation pass
+func (h *Handler) getGitOpsStatus(w http.ResponseWriter, r *http.Request) {
+	gitops := h.getCurrentConfig().GitOps
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"directory": gitops.Directory,
+		"interval":  gitops.Interval.String(),
+		"prune":     gitops.Prune,
+		"report":    h.reconciler.LastReport(),
+	})
+}
+
+// reconcileGitOps runs a pass now, ?dryRun=true only reports the drift.
+// Manifests that can't be loaded are answered with 422 and the report.
+func (h *Handler) reconcileGitOps(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if raw := r.URL.Query().Get("dryRun"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid dryRun parameter", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, err := h.reconciler.Reconcile(r.Context(), dryRun)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		h.logger.Error("GitOps reconciliation failed", "error", err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
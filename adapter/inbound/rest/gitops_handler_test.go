@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockReconciler struct {
+	last   *model.ReconcileReport
+	dryRun bool
+	err    error
+}
+
+func (m *mockReconciler) Reconcile(ctx context.Context, dryRun bool) (*model.ReconcileReport, error) {
+	m.dryRun = dryRun
+	report := &model.ReconcileReport{DryRun: dryRun, Drift: []model.Drift{}}
+	if m.err != nil {
+		report.Error = m.err.Error()
+	}
+	return report, m.err
+}
+
+func (m *mockReconciler) LastReport() *model.ReconcileReport {
+	return m.last
+}
+
+func TestGitOpsHandlers(t *testing.T) {
+	reconciler := &mockReconciler{last: &model.ReconcileReport{InSync: true, Drift: []model.Drift{}}}
+	h := &Handler{logger: &mockLogger{}, config: config.DefaultConfig(), reconciler: reconciler}
+
+	t.Run("status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.getGitOpsStatus(w, httptest.NewRequest("GET", "/api/admin/gitops/status", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Directory string                 `json:"directory"`
+			Report    *model.ReconcileReport `json:"report"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "./manifests", body.Directory)
+		require.NotNil(t, body.Report)
+		assert.True(t, body.Report.InSync)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.reconcileGitOps(w, httptest.NewRequest("POST", "/api/admin/gitops/reconcile?dryRun=true", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, reconciler.dryRun)
+	})
+
+	t.Run("invalid dryRun", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.reconcileGitOps(w, httptest.NewRequest("POST", "/api/admin/gitops/reconcile?dryRun=maybe", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid manifests", func(t *testing.T) {
+		reconciler.err = errors.New("shop.yaml: unknown kind")
+		w := httptest.NewRecorder()
+		h.reconcileGitOps(w, httptest.NewRequest("POST", "/api/admin/gitops/reconcile", nil))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "shop.yaml: unknown kind")
+		assert.False(t, reconciler.dryRun)
+	})
+}
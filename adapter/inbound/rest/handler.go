@@ -1,16 +1,17 @@
 package rest
 
 import (
-	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajkula/GoRTMS/config"
@@ -41,6 +42,19 @@ type Handler struct {
 	serviceRepo           outbound.ServiceRepository
 	accountRequestHandler *AccountRequestHandler
 	accountRequestService inbound.AccountRequestService
+	snapshotService       inbound.SnapshotService
+	reconciler            inbound.ReconcilerService
+	erasureService        inbound.ErasureService
+	wsTickets             *WSTicketStore
+	delegations           *DelegationMiddleware
+	connLimiter           *ConnectionLimiter
+	placement             outbound.ClusterPlacement
+	standby               StandbyReplica
+	preferences           outbound.PreferencesRepository
+	blueprints            inbound.BlueprintService
+	errorReporter         outbound.ErrorReporter
+	panics                panicCounter
+	configVersion         atomic.Uint64 // bumped each time the runtime config is replaced
 }
 
 func NewHandler(
@@ -58,10 +72,17 @@ func NewHandler(
 	consumerGroupRepo outbound.ConsumerGroupRepository,
 	repoService outbound.ServiceRepository,
 	accountRequestService inbound.AccountRequestService,
+	snapshotService inbound.SnapshotService,
+	reconciler inbound.ReconcilerService,
+	erasureService inbound.ErasureService,
 ) *Handler {
 	authMiddleware := NewAuthMiddleware(authService, logger, config)
 	authHandler := NewAuthHandler(authService, logger)
+	connLimiter := NewConnectionLimiter(config.HTTP.Connections)
 	hmacMiddleware := NewHMACMiddleware(repoService, logger, config)
+	hmacMiddleware.LimitInFlight(connLimiter)
+	delegations := NewDelegationMiddleware(repoService, logger, hmacMiddleware)
+	hmacMiddleware.AcceptDelegations(delegations)
 	hybridMiddleware := NewHybridMiddleware(config, hmacMiddleware, authMiddleware, logger)
 	accountRequestHandler := NewAccountRequestHandler(accountRequestService, authService, logger)
 
@@ -85,112 +106,289 @@ func NewHandler(
 		serviceRepo:           repoService,
 		accountRequestHandler: accountRequestHandler,
 		accountRequestService: accountRequestService,
+		snapshotService:       snapshotService,
+		reconciler:            reconciler,
+		erasureService:        erasureService,
+		wsTickets:             NewWSTicketStore(wsTicketTTL),
+		delegations:           delegations,
+		connLimiter:           connLimiter,
+		placement:             standalonePlacement{node: model.ClusterNode{ID: config.General.NodeID}},
 	}
 }
 
 // SetupRoutes REST API config
 func (h *Handler) SetupRoutes(router *mux.Router) {
+	h.SetupListenerRoutes(router, config.HTTPListener{Role: config.ListenerRoleAll})
+}
+
+// SetupListenerRoutes mounts the routes matching the listener role: management
+// (UI, auth, admin, resource management) and/or data plane (publish, consume)
+func (h *Handler) SetupListenerRoutes(router *mux.Router, listener config.HTTPListener) {
+	// outermost, so the other middleware see the request ID and are covered
+	router.Use(h.recoveryMiddleware(listener.Name))
+	if h.config != nil && h.config.Security.Hardened {
+		router.Use(securityHeadersMiddleware(listener.TLS))
+	}
+
+	// The same API is mounted under each version prefix, versioned prefixes
+	// first so that /api does not shadow them
+	for _, api := range apiPrefixes {
+		apiRouter := router.PathPrefix(api.prefix).Subrouter()
+		apiRouter.Use(h.apiVersionMiddleware(api.prefix, api.version))
+		h.setupAPIRoutes(apiRouter, listener)
+	}
+
+	// health check routes
+	router.HandleFunc("/health", h.healthCheck).Methods("GET")
+
+	if listener.ServesData() {
+		// third-party webhooks, authenticated by their signature
+		router.HandleFunc("/ingest/{name}", h.ingestWebhook).Methods("POST")
+	}
+
+	if listener.ServesManagement() {
+		// Kubernetes external metrics API, for consumer autoscalers
+		h.setupExternalMetricsRoutes(router)
+
+		// UI routes
+		router.PathPrefix("/ui/").Handler(h.serveEmbeddedUI())
+	}
+}
+
+// setupAPIRoutes mounts the API routes of the listener on a version prefix
+func (h *Handler) setupAPIRoutes(apiRouter *mux.Router, listener config.HTTPListener) {
 	serviceHandler := NewServiceHandler(h.serviceRepo, h.logger)
+	if usage, ok := h.messageService.(serviceUsageSource); ok {
+		serviceHandler.SetUsageSource(usage)
+	}
+	management := listener.ServesManagement()
+	data := listener.ServesData()
 
 	// CRITICAL: Router order matters in Gorilla Mux!
 	// Subrouters with same PathPrefix are tested in CREATION ORDER.
 	// More specific routes (hmacRouter) must be created BEFORE general ones
 	// /consumers/{consumer} would match /consumers/self before hmacRouter is tested.
-	hmacRouter := router.PathPrefix("/api").Subrouter()
+	hmacRouter := apiRouter.NewRoute().Subrouter()
 	hmacRouter.Use(h.hmacMiddleware.Middleware)
 
-	jwtRouter := router.PathPrefix("/api").Subrouter()
+	jwtRouter := apiRouter.NewRoute().Subrouter()
 	jwtRouter.Use(h.authMiddleware.Middleware)
 
 	adminRouter := jwtRouter.PathPrefix("/admin").Subrouter()
 	adminRouter.Use(h.authMiddleware.RequireRole(model.RoleAdmin))
 
-	hybridRouter := router.PathPrefix("/api").Subrouter()
+	hybridRouter := apiRouter.NewRoute().Subrouter()
 	hybridRouter.Use(h.hybridMiddleware.Middleware)
 
-	// Auth routes
-	router.HandleFunc("/api/auth/login", h.authHandler.Login).Methods("POST")
-	router.HandleFunc("/api/auth/bootstrap", h.authHandler.Bootstrap).Methods("POST")
-	jwtRouter.HandleFunc("/auth/profile", h.authHandler.GetProfile).Methods("GET")
-	adminRouter.HandleFunc("/users", h.authHandler.CreateUser).Methods("POST")
-	adminRouter.HandleFunc("/users", h.authHandler.ListUsers).Methods("GET")
-	jwtRouter.HandleFunc("/users/{id}", h.authHandler.UpdateUser).Methods("PATCH")
-	jwtRouter.HandleFunc("/auth/change-password", h.authHandler.ChangePassword).Methods("PUT")
-
-	// Account request routes
-	router.HandleFunc("/api/account-requests", h.accountRequestHandler.CreateAccountRequest).Methods("POST")
-	adminRouter.HandleFunc("/account-requests", h.accountRequestHandler.ListAccountRequests).Methods("GET")
-	adminRouter.HandleFunc("/account-requests/{requestId}", h.accountRequestHandler.GetAccountRequest).Methods("GET")
-	adminRouter.HandleFunc("/account-requests/{requestId}/review", h.accountRequestHandler.ReviewAccountRequest).Methods("POST")
-	adminRouter.HandleFunc("/account-requests/{requestId}", h.accountRequestHandler.DeleteAccountRequest).Methods("DELETE")
-
-	// Service rutes
-	jwtRouter.HandleFunc("/services", serviceHandler.CreateService).Methods("POST")
-	jwtRouter.HandleFunc("/services", serviceHandler.ListServices).Methods("GET")
-	jwtRouter.HandleFunc("/services/{id}", serviceHandler.GetService).Methods("GET")
-	jwtRouter.HandleFunc("/services/{id}", serviceHandler.DeleteService).Methods("DELETE")
-	jwtRouter.HandleFunc("/services/{id}/rotate-secret", serviceHandler.RotateSecret).Methods("POST")
-	jwtRouter.HandleFunc("/services/{id}/permissions", serviceHandler.UpdatePermissions).Methods("PUT")
-
-	// Domains routes
-	jwtRouter.HandleFunc("/domains", h.listDomains).Methods("GET")
-	hybridRouter.HandleFunc("/domains", h.createDomain).Methods("POST")
-	jwtRouter.HandleFunc("/domains/{domain}", h.getDomain).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}", h.deleteDomain).Methods("DELETE")
-
-	// Queues routes
-	jwtRouter.HandleFunc("/domains/{domain}/queues", h.listQueues).Methods("GET")
-	hybridRouter.HandleFunc("/domains/{domain}/queues", h.createQueue).Methods("POST")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}", h.getQueue).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}", h.deleteQueue).Methods("DELETE")
-
-	// Messages routes
-	hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages", h.publishMessage).Methods("POST")
-	hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages", h.consumeMessages).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/subscribe", h.subscribeToQueue).Methods("POST")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/unsubscribe", h.unsubscribeFromQueue).Methods("POST")
-
-	// Routing rules routes
-	jwtRouter.HandleFunc("/domains/{domain}/routes", h.listRoutingRules).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/routes", h.addRoutingRule).Methods("POST")
-	jwtRouter.HandleFunc("/domains/{domain}/routes/{source}/{destination}", h.removeRoutingRule).Methods("DELETE")
-
-	// Simulation routes
-	jwtRouter.HandleFunc("/domains/{domain}/routes/test", h.testRoutingRules).Methods("POST")
-
-	// ConsumerGroup routes
-	jwtRouter.HandleFunc("/consumer-groups", h.listAllConsumerGroups).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups", h.listConsumerGroups).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups", h.createConsumerGroup).Methods("POST")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}", h.getConsumerGroup).Methods("GET")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}", h.deleteConsumerGroup).Methods("DELETE")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/ttl", h.updateConsumerGroupTTL).Methods("PUT")
-	hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/messages", h.getPendingMessages).Methods("GET")
-	hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers", h.addConsumerToGroup).Methods("POST")
-	hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers/self", h.removeSelfFromGroup).Methods("DELETE")
-	jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers/{consumer}", h.removeConsumerFromGroup).Methods("DELETE")
-
-	// Stats routes
-	jwtRouter.HandleFunc("/stats", h.getStats).Methods("GET")
-
-	// system ressources routes
-	if h.resourceMonitor != nil {
-		h.logger.Info("Setting up resource monitoring routes")
-		jwtRouter.HandleFunc("/resources/current", h.getCurrentResourceStats).Methods("GET")
-		jwtRouter.HandleFunc("/resources/history", h.getResourceStatsHistory).Methods("GET")
-		jwtRouter.HandleFunc("/resources/domains/{domain}", h.getDomainResourceStats).Methods("GET")
-	}
-
-	// settings routes
-	adminRouter.HandleFunc("/settings", h.getSettings).Methods("GET")
-	adminRouter.HandleFunc("/settings", h.updateSettings).Methods("PUT")
-	adminRouter.HandleFunc("/settings/reset", h.resetSettings).Methods("POST")
+	// unauthenticated, HMAC clients sign their timestamps against it
+	apiRouter.HandleFunc("/time", h.serverTime).Methods("GET")
+
+	if management {
+		// Auth routes
+		apiRouter.HandleFunc("/auth/login", h.authHandler.Login).Methods("POST")
+		apiRouter.HandleFunc("/auth/bootstrap", h.authHandler.Bootstrap).Methods("POST")
+		jwtRouter.HandleFunc("/auth/profile", h.authHandler.GetProfile).Methods("GET")
+		adminRouter.HandleFunc("/users", h.authHandler.CreateUser).Methods("POST")
+		adminRouter.HandleFunc("/users", h.authHandler.ListUsers).Methods("GET")
+		jwtRouter.HandleFunc("/users/{id}", h.authHandler.UpdateUser).Methods("PATCH")
+		adminRouter.HandleFunc("/users/{id}/reset-password", h.authHandler.ResetPassword).Methods("POST")
+		jwtRouter.HandleFunc("/auth/change-password", h.authHandler.ChangePassword).Methods("PUT")
+
+		// Account request routes
+		apiRouter.HandleFunc("/account-requests", h.accountRequestHandler.CreateAccountRequest).Methods("POST")
+		adminRouter.HandleFunc("/account-requests", h.accountRequestHandler.ListAccountRequests).Methods("GET")
+		adminRouter.HandleFunc("/account-requests/{requestId}", h.accountRequestHandler.GetAccountRequest).Methods("GET")
+		adminRouter.HandleFunc("/account-requests/{requestId}/review", h.accountRequestHandler.ReviewAccountRequest).Methods("POST")
+		adminRouter.HandleFunc("/account-requests/{requestId}", h.accountRequestHandler.DeleteAccountRequest).Methods("DELETE")
+
+		// Service rutes
+		jwtRouter.HandleFunc("/services", serviceHandler.CreateService).Methods("POST")
+		jwtRouter.HandleFunc("/services", serviceHandler.ListServices).Methods("GET")
+		jwtRouter.HandleFunc("/services/usage", serviceHandler.ListServiceUsage).Methods("GET")
+		jwtRouter.HandleFunc("/services/{id}/usage", serviceHandler.GetServiceUsage).Methods("GET")
+		jwtRouter.HandleFunc("/services/{id}", serviceHandler.GetService).Methods("GET")
+		jwtRouter.HandleFunc("/services/{id}", serviceHandler.PutService).Methods("PUT")
+		jwtRouter.HandleFunc("/services/{id}", serviceHandler.DeleteService).Methods("DELETE")
+		jwtRouter.HandleFunc("/services/{id}/rotate-secret", serviceHandler.RotateSecret).Methods("POST")
+		jwtRouter.HandleFunc("/services/{id}/permissions", serviceHandler.UpdatePermissions).Methods("PUT")
+		jwtRouter.HandleFunc("/services/{id}/can", serviceHandler.CanService).Methods("POST")
+
+		// Domains routes
+		jwtRouter.HandleFunc("/domains", h.listDomains).Methods("GET")
+		hybridRouter.HandleFunc("/domains", h.createDomain).Methods("POST")
+		hybridRouter.HandleFunc("/domains/{domain}", h.putDomain).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}", h.getDomain).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}", h.deleteDomain).Methods("DELETE")
+		adminRouter.HandleFunc("/domains/{domain}/route-sources", h.updateAllowedRouteSources).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/labels", h.updateDomainLabels).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/schema/types", h.getSchemaTypes).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/events", h.getDomainEvents).Methods("GET")
+
+		// Message search over the indexed schema fields
+		jwtRouter.HandleFunc("/search", h.searchMessages).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/search", h.searchMessages).Methods("GET")
+
+		// Queues routes
+		jwtRouter.HandleFunc("/domains/{domain}/queues", h.listQueues).Methods("GET")
+		hybridRouter.HandleFunc("/domains/{domain}/queues", h.createQueue).Methods("POST")
+		hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}", h.putQueue).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}", h.getQueue).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}", h.deleteQueue).Methods("DELETE")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/labels", h.updateQueueLabels).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/errors", h.getQueueErrors).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/subscribers", h.getSubscriberStats).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/rejections", h.getSchemaRejections).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/mirror", h.updateQueueMirror).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/mirror", h.deleteQueueMirror).Methods("DELETE")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/enrichment", h.updateQueueEnrichment).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/enrichment", h.deleteQueueEnrichment).Methods("DELETE")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/mode", h.updateQueueMode).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/infer-schema", h.inferSchema).Methods("POST")
+
+		// Queue digests, to compare two copies of a queue
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/digest", h.getQueueDigest).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/digest/compare", h.compareQueueDigest).Methods("POST")
+
+		// Field encryption key, handed to the consumers allowed to read sealed fields
+		adminRouter.HandleFunc("/domains/{domain}/queues/{queue}/encryption-key", h.getQueueEncryptionKey).Methods("GET")
+
+		// Fault injection, for resilience testing of consumers
+		adminRouter.HandleFunc("/faults", h.listFaults).Methods("GET")
+		adminRouter.HandleFunc("/domains/{domain}/queues/{queue}/faults", h.injectFault).Methods("PUT")
+		adminRouter.HandleFunc("/domains/{domain}/queues/{queue}/faults", h.clearFault).Methods("DELETE")
+
+		// Snapshot routes
+		if h.snapshotService != nil {
+			jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/snapshot", h.readReplica(h.snapshotQueue)).Methods("POST")
+			jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/restore", h.restoreQueue).Methods("POST")
+		}
+	}
 
-	// health check routes
-	router.HandleFunc("/health", h.healthCheck).Methods("GET")
+	// Queue owners, for clients connecting to the right node
+	hybridRouter.HandleFunc("/cluster/metadata", h.getClusterMetadata).Methods("GET")
+
+	if data {
+		// Messages routes
+		// calls for queues held by another node are redirected to it
+		hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages", h.ownedQueue(h.publishMessage)).Methods("POST")
+		hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages", h.ownedQueue(h.consumeMessages)).Methods("GET")
+		hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/producers/{producer}", h.ownedQueue(h.getProducerSession)).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages/{id}/move", h.ownedQueue(h.moveMessage)).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/messages/{id}/trace", h.ownedQueue(h.traceMessage)).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/drain", h.ownedQueue(h.drainQueue)).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/subscribe", h.ownedQueue(h.subscribeToQueue)).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/unsubscribe", h.ownedQueue(h.unsubscribeFromQueue)).Methods("POST")
+	}
+
+	if management {
+		// Routing rules routes
+		jwtRouter.HandleFunc("/domains/{domain}/routes", h.listRoutingRules).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/routes", h.addRoutingRule).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/routes/stats", h.getRoutingRuleStats).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/routes/{source}", h.reorderRoutingRules).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/routes/{source}/{destination}", h.putRoutingRule).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/routes/{source}/{destination}", h.updateRoutingRule).Methods("PATCH")
+		jwtRouter.HandleFunc("/domains/{domain}/routes/{source}/{destination}", h.removeRoutingRule).Methods("DELETE")
+
+		// Simulation routes
+		jwtRouter.HandleFunc("/domains/{domain}/routes/test", h.testRoutingRules).Methods("POST")
+
+		// ConsumerGroup routes
+		jwtRouter.HandleFunc("/consumer-groups", h.listAllConsumerGroups).Methods("GET")
+		jwtRouter.HandleFunc("/consumer-groups/cleanups", h.listUpcomingCleanups).Methods("GET")
+		jwtRouter.HandleFunc("/consumer-groups/archived", h.listArchivedGroups).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups", h.listConsumerGroups).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups", h.createConsumerGroup).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/offsets", h.exportGroupOffsets).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/offsets", h.importGroupOffsets).Methods("POST")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}", h.getConsumerGroup).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}", h.deleteConsumerGroup).Methods("DELETE")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/ttl", h.updateConsumerGroupTTL).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/delivery-mode", h.updateConsumerGroupDeliveryMode).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/priority", h.updateConsumerGroupPriority).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/prefetch", h.updateConsumerGroupPrefetch).Methods("PUT")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/cleanup-action", h.updateConsumerGroupCleanupAction).Methods("PUT")
+	}
+
+	if data {
+		hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/messages", h.ownedQueue(h.getPendingMessages)).Methods("GET")
+		hybridRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/heartbeat", h.ownedQueue(h.heartbeatConsumerGroup)).Methods("POST")
+		hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers", h.ownedQueue(h.addConsumerToGroup)).Methods("POST")
+		hmacRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers/self", h.ownedQueue(h.removeSelfFromGroup)).Methods("DELETE")
+	}
+
+	if management {
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/consumer-groups/{group}/consumers/{consumer}", h.removeConsumerFromGroup).Methods("DELETE")
+	}
+
+	if data {
+		// WebSocket tickets, redeemed during the upgrade handshake
+		hybridRouter.HandleFunc("/ws/tickets", h.createWSTicket).Methods("POST")
+
+		// Delegated tokens, minted by a service account for its workers
+		hmacRouter.HandleFunc("/services/delegations", h.createDelegation).Methods("POST")
+
+		// Replication feed, pulled by standby replicas
+		hmacRouter.HandleFunc("/replication/domains", h.getReplicatedDomains).Methods("GET")
+		hmacRouter.HandleFunc("/replication/domains/{domain}/queues/{queue}/messages", h.getReplicationBatch).Methods("GET")
+	}
+
+	if management {
+		// Stats routes
+		jwtRouter.HandleFunc("/stats", h.getStats).Methods("GET")
+		jwtRouter.HandleFunc("/stats/labels/{label}", h.getStatsByLabel).Methods("GET")
+		jwtRouter.HandleFunc("/stats/compaction", h.getIndexCompactionStats).Methods("GET")
+		jwtRouter.HandleFunc("/stats/top/{ranking}", h.getTopN).Methods("GET")
+		jwtRouter.HandleFunc("/stats/waits", h.getQueueWaits).Methods("GET")
+		jwtRouter.HandleFunc("/stats/connections", h.getConnectionStats).Methods("GET")
+		jwtRouter.HandleFunc("/stats/panics", h.getPanicStats).Methods("GET")
+		jwtRouter.HandleFunc("/domains/{domain}/queues/{queue}/scaling", h.getScalingHint).Methods("GET")
+
+		// system ressources routes
+		if h.resourceMonitor != nil {
+			h.logger.Info("Setting up resource monitoring routes")
+			jwtRouter.HandleFunc("/resources/current", h.getCurrentResourceStats).Methods("GET")
+			jwtRouter.HandleFunc("/resources/history", h.getResourceStatsHistory).Methods("GET")
+			jwtRouter.HandleFunc("/resources/domains/{domain}", h.getDomainResourceStats).Methods("GET")
+		}
 
-	// UI routes
-	router.PathPrefix("/ui/").Handler(h.serveEmbeddedUI())
+		// settings routes
+		adminRouter.HandleFunc("/settings", h.getSettings).Methods("GET")
+		adminRouter.HandleFunc("/settings", h.updateSettings).Methods("PUT")
+		adminRouter.HandleFunc("/settings/reset", h.resetSettings).Methods("POST")
+
+		// Standby replication
+		adminRouter.HandleFunc("/replication/status", h.getReplicationStatus).Methods("GET")
+		adminRouter.HandleFunc("/replication/promote", h.promoteStandby).Methods("POST")
+
+		// Preferences of the calling user, for the UI
+		if h.preferences != nil {
+			jwtRouter.HandleFunc("/preferences", h.getPreferences).Methods("GET")
+			jwtRouter.HandleFunc("/preferences", h.putPreferences).Methods("PUT")
+			jwtRouter.HandleFunc("/preferences", h.deletePreferences).Methods("DELETE")
+		}
+
+		// Queue blueprints, referenced by name when creating queues
+		if h.blueprints != nil {
+			jwtRouter.HandleFunc("/blueprints", h.listQueueBlueprints).Methods("GET")
+			jwtRouter.HandleFunc("/blueprints/{name}", h.getQueueBlueprint).Methods("GET")
+			adminRouter.HandleFunc("/blueprints/{name}", h.putQueueBlueprint).Methods("PUT")
+			adminRouter.HandleFunc("/blueprints/{name}", h.deleteQueueBlueprint).Methods("DELETE")
+		}
+
+		// GitOps routes
+		if h.reconciler != nil {
+			adminRouter.HandleFunc("/gitops/status", h.getGitOpsStatus).Methods("GET")
+			adminRouter.HandleFunc("/gitops/reconcile", h.reconcileGitOps).Methods("POST")
+		}
+
+		// Data subject erasure jobs
+		if h.erasureService != nil {
+			adminRouter.HandleFunc("/erasures", h.startErasure).Methods("POST")
+			adminRouter.HandleFunc("/erasures", h.listErasures).Methods("GET")
+			adminRouter.HandleFunc("/erasures/{id}", h.getErasure).Methods("GET")
+		}
+	}
 }
 
 // serves UI files from embedded filesystem
@@ -245,21 +443,53 @@ func (h *Handler) healthCheck(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
+// serverTime returns the clock of the server, for HMAC clients to measure
+// their skew before signing
+func (h *Handler) serverTime(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]any{
+		"time":            now.Format(time.RFC3339Nano),
+		"unixMillis":      now.UnixMilli(),
+		"timestampWindow": h.hmacMiddleware.timestampWindow.String(),
+	})
+}
+
 func (h *Handler) listDomains(w http.ResponseWriter, r *http.Request) {
+	selector, err := labelSelectorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	domains, err := h.domainService.ListDomains(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	versions := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		versions = append(versions, fmt.Sprintf("%s@%d", domain.Name, domain.Version))
+	}
+	sort.Strings(versions)
+	if notModified(w, r, resourceETag(r.URL.RawQuery, versions)) {
+		return
+	}
+
 	// simple JSON response structure
 	type domainResponse struct {
-		Name string `json:"name"`
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels,omitempty"`
 	}
 
-	response := make([]domainResponse, len(domains))
-	for i, domain := range domains {
-		response[i] = domainResponse{Name: domain.Name}
+	response := make([]domainResponse, 0, len(domains))
+	for _, domain := range domains {
+		if !model.MatchLabels(domain.Labels, selector) {
+			continue
+		}
+		response = append(response, domainResponse{Name: domain.Name, Labels: domain.Labels})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -275,14 +505,17 @@ func (h *Handler) createDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the domain is named in the body, out of reach of the middleware scope check
+	if service := h.hmacMiddleware.GetServiceFromContext(r.Context()); service != nil && !service.InScope(config.Name) {
+		http.Error(w, fmt.Sprintf("domain %s is outside the service scope", config.Name), http.StatusForbidden)
+		return
+	}
+
 	if err := h.domainService.CreateDomain(r.Context(), &config); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Register event
-	h.statsService.RecordDomainCreated(config.Name)
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -301,6 +534,10 @@ func (h *Handler) getDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if notModified(w, r, resourceETag(domain.Name, domain.Version, queueCounts(domain.Queues))) {
+		return
+	}
+
 	// Simple structure circular reference-less
 	type QueueInfo struct {
 		Name         string            `json:"name"`
@@ -309,23 +546,33 @@ func (h *Handler) getDomain(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type RouteInfo struct {
-		SourceQueue      string `json:"sourceQueue"`
-		DestinationQueue string `json:"destinationQueue"`
-		Predicate        any    `json:"predicate"`
+		SourceQueue       string `json:"sourceQueue"`
+		DestinationQueue  string `json:"destinationQueue"`
+		DestinationDomain string `json:"destinationDomain,omitempty"`
+		Predicate         any    `json:"predicate"`
+		Enabled           bool   `json:"enabled"`
+		Priority          int    `json:"priority,omitempty"`
+		StopOnMatch       bool   `json:"stopOnMatch,omitempty"`
 	}
 
 	type DomainResponse struct {
-		Name   string           `json:"name"`
-		Schema model.SchemaInfo `json:"schema,omitempty"`
-		Queues []QueueInfo      `json:"queues"`
-		Routes []RouteInfo      `json:"routes"`
+		Name                string            `json:"name"`
+		Schema              model.SchemaInfo  `json:"schema,omitempty"`
+		Queues              []QueueInfo       `json:"queues"`
+		Routes              []RouteInfo       `json:"routes"`
+		AllowedRouteSources []string          `json:"allowedRouteSources,omitempty"`
+		Labels              map[string]string `json:"labels,omitempty"`
+		Version             uint64            `json:"version"`
 	}
 
 	// assign response
 	response := DomainResponse{
-		Name:   domain.Name,
-		Queues: make([]QueueInfo, 0, len(domain.Queues)),
-		Routes: make([]RouteInfo, 0),
+		Name:                domain.Name,
+		Queues:              make([]QueueInfo, 0, len(domain.Queues)),
+		Routes:              make([]RouteInfo, 0),
+		AllowedRouteSources: domain.AllowedRouteSources,
+		Labels:              domain.Labels,
+		Version:             domain.Version,
 	}
 
 	// Convert schema to serializable type
@@ -393,11 +640,20 @@ func (h *Handler) getDomain(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			response.Routes = append(response.Routes, RouteInfo{
+			routeInfo := RouteInfo{
 				SourceQueue:      srcQueue,
 				DestinationQueue: dstQueue,
 				Predicate:        predicateInfo,
-			})
+				Enabled:          rule.IsEnabled(),
+				Priority:         rule.Priority,
+				StopOnMatch:      rule.StopOnMatch,
+			}
+			if rule.IsCrossDomain(domain.Name) {
+				routeInfo.DestinationQueue = rule.DestinationQueue
+				routeInfo.DestinationDomain = rule.DestinationDomain
+			}
+
+			response.Routes = append(response.Routes, routeInfo)
 		}
 	}
 
@@ -418,8 +674,13 @@ func (h *Handler) deleteDomain(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	domainName := vars["domain"]
 
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
 	if err := h.domainService.DeleteDomain(r.Context(), domainName); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		domainWriteError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -429,16 +690,59 @@ func (h *Handler) deleteDomain(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) updateAllowedRouteSources(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	var request struct {
+		AllowedRouteSources []string `json:"allowedRouteSources"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.UpdateAllowedRouteSources(r.Context(), domainName, request.AllowedRouteSources); err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":              "success",
+		"domain":              domainName,
+		"allowedRouteSources": request.AllowedRouteSources,
+	})
+}
+
 func (h *Handler) listQueues(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	domainName := vars["domain"]
 
+	selector, err := labelSelectorFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	queues, err := h.queueService.ListQueues(r.Context(), domainName)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// queues inherit their domain labels when filtering
+	domain, _ := h.domainService.GetDomain(r.Context(), domainName)
+	if domain != nil && notModified(w, r, resourceETag(domain.Name, domain.Version, queueCounts(domain.Queues), r.URL.RawQuery)) {
+		return
+	}
+
 	// simple JSON response structure
 	type queueResponse struct {
 		Name         string             `json:"name"`
@@ -446,13 +750,16 @@ func (h *Handler) listQueues(w http.ResponseWriter, r *http.Request) {
 		Config       *model.QueueConfig `json:"config"`
 	}
 
-	response := make([]queueResponse, len(queues))
-	for i, queue := range queues {
-		response[i] = queueResponse{
+	response := make([]queueResponse, 0, len(queues))
+	for _, queue := range queues {
+		if !model.MatchLabels(queue.EffectiveLabels(domain), selector) {
+			continue
+		}
+		response = append(response, queueResponse{
 			Name:         queue.Name,
 			MessageCount: queue.MessageCount,
 			Config:       &queue.Config,
-		}
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -466,39 +773,88 @@ func (h *Handler) createQueue(w http.ResponseWriter, r *http.Request) {
 	domainName := vars["domain"]
 
 	// Read raw req body
-	bodyBytes, err := io.ReadAll(r.Body)
+	bodyBytes, release, err := requestBody(r)
 	if err != nil {
 		h.logger.Error("Error reading request body", "ERROR", err)
 		http.Error(w, "Failed to read request", http.StatusBadRequest)
 		return
 	}
-	// Reset body for JSON decoder
-	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	defer release()
 
 	// Log req body
 	h.logger.Debug("Queue creation request", "body", string(bodyBytes))
 
 	var request struct {
-		Name   string          `json:"name"`
-		Config json.RawMessage `json:"config"` // RawMessage to avoid decoding pblms
+		Name      string          `json:"name"`
+		Blueprint string          `json:"blueprint,omitempty"`
+		Config    json.RawMessage `json:"config"` // RawMessage to avoid decoding pblms
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if err := json.Unmarshal(bodyBytes, &request); err != nil {
 		h.logger.Error("Error decoding request JSON", "ERROR", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// decode manually
-	var configMap map[string]any
-	if err := json.Unmarshal(request.Config, &configMap); err != nil {
+	var config *model.QueueConfig
+	if request.Blueprint != "" {
+		blueprint, blueprintErr := h.lookupBlueprint(request.Blueprint)
+		if blueprintErr != nil {
+			http.Error(w, blueprintErr.Error(), http.StatusBadRequest)
+			return
+		}
+		config, err = blueprintQueueConfig(blueprint, request.Config)
+	} else {
+		config, err = parseQueueConfig(request.Config)
+	}
+	if err != nil {
 		h.logger.Error("Error decoding config", "ERROR", err)
 		http.Error(w, "Invalid config format", http.StatusBadRequest)
 		return
 	}
 
-	// Base config
-	config := &model.QueueConfig{}
+	h.logger.Debug("Creating queue", "config", config)
+
+	if err := h.queueService.CreateQueue(r.Context(), domainName, request.Name, config); err != nil {
+		h.logger.Error("Error from service", "ERROR", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type CreateQueueResponse struct {
+		Status    string             `json:"status"`
+		Queue     string             `json:"queue"`
+		Blueprint string             `json:"blueprint,omitempty"`
+		Config    *model.QueueConfig `json:"config"`
+	}
+
+	response := CreateQueueResponse{
+		Status:    "success",
+		Queue:     request.Name,
+		Blueprint: request.Blueprint,
+		Config:    config,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseQueueConfig decodes the queue config of create requests
+func parseQueueConfig(raw json.RawMessage) (*model.QueueConfig, error) {
+	return overlayQueueConfig(model.QueueConfig{}, raw)
+}
+
+// overlayQueueConfig decodes a queue config over a base config, such as the
+// one of a blueprint: only the keys present in raw replace the base values
+func overlayQueueConfig(base model.QueueConfig, raw json.RawMessage) (*model.QueueConfig, error) {
+	// decode manually
+	var configMap map[string]any
+	if err := json.Unmarshal(raw, &configMap); err != nil {
+		return nil, err
+	}
+
+	config := &base
 
 	// Apply req values
 	if isPersistent, ok := configMap["isPersistent"].(bool); ok {
@@ -512,21 +868,21 @@ func (h *Handler) createQueue(w http.ResponseWriter, r *http.Request) {
 
 	if ttlStr, ok := configMap["ttl"].(string); ok {
 		ttl, err := time.ParseDuration(ttlStr)
-		if err != nil {
-			h.logger.Error("Error parsing TTL duration", "ERROR", err)
-			// use default instead
-		} else {
+		if err == nil {
 			config.TTL = ttl
-		}
+		} // invalid durations keep the default
 	}
 
-	h.logger.Debug("Creating queue", "config", config)
-
 	// Process retry config
-	if retryEnabled, ok := configMap["retryEnabled"].(bool); ok && retryEnabled {
-		config.RetryEnabled = true
+	if retryEnabled, ok := configMap["retryEnabled"].(bool); ok {
+		config.RetryEnabled = retryEnabled
+	}
+	if config.RetryEnabled {
 		if retryConfigMap, ok := configMap["retryConfig"].(map[string]interface{}); ok {
 			retryConfig := &model.RetryConfig{}
+			if config.RetryConfig != nil {
+				*retryConfig = *config.RetryConfig
+			}
 
 			if v, ok := retryConfigMap["maxRetries"].(float64); ok {
 				retryConfig.MaxRetries = int(v)
@@ -553,10 +909,15 @@ func (h *Handler) createQueue(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Process circuit breaker config
-	if cbEnabled, ok := configMap["circuitBreakerEnabled"].(bool); ok && cbEnabled {
-		config.CircuitBreakerEnabled = true
+	if cbEnabled, ok := configMap["circuitBreakerEnabled"].(bool); ok {
+		config.CircuitBreakerEnabled = cbEnabled
+	}
+	if config.CircuitBreakerEnabled {
 		if cbConfigMap, ok := configMap["circuitBreakerConfig"].(map[string]interface{}); ok {
 			cbConfig := &model.CircuitBreakerConfig{}
+			if config.CircuitBreakerConfig != nil {
+				*cbConfig = *config.CircuitBreakerConfig
+			}
 
 			if v, ok := cbConfigMap["errorThreshold"].(float64); ok {
 				cbConfig.ErrorThreshold = v
@@ -576,31 +937,108 @@ func (h *Handler) createQueue(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			if v, ok := cbConfigMap["fallbackQueue"].(string); ok {
+				cbConfig.FallbackQueue = v
+			}
+
 			config.CircuitBreakerConfig = cbConfig
 		}
 	}
 
-	if err := h.queueService.CreateQueue(r.Context(), domainName, request.Name, config); err != nil {
-		h.logger.Error("Error from service", "ERROR", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	// Process labels
+	// the labels of the request are added to the ones of the base
+	if labelsMap, ok := configMap["labels"].(map[string]any); ok {
+		if config.Labels == nil {
+			config.Labels = make(map[string]string, len(labelsMap))
+		}
+		for k, v := range labelsMap {
+			config.Labels[k] = fmt.Sprint(v)
+		}
 	}
 
-	type CreateQueueResponse struct {
-		Status string             `json:"status"`
-		Queue  string             `json:"queue"`
-		Config *model.QueueConfig `json:"config"`
+	// Process the sensitive fields
+	if fields, ok := configMap["encryptedFields"].([]any); ok {
+		config.EncryptedFields = nil
+		for _, field := range fields {
+			name, ok := field.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("invalid encrypted field: %v", field)
+			}
+			config.EncryptedFields = append(config.EncryptedFields, name)
+		}
 	}
 
-	response := CreateQueueResponse{
-		Status: "success",
-		Queue:  request.Name,
-		Config: config,
+	// Process partitioning
+	if v, ok := configMap["partitions"].(float64); ok {
+		config.Partitions = int(v)
+	}
+	if v, ok := configMap["orderingKey"].(string); ok {
+		config.OrderingKey = v
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	// Process key compaction
+	if raw, ok := configMap["compaction"].(map[string]any); ok {
+		config.Compaction = &model.CompactionConfig{}
+		if key, ok := raw["key"].(string); ok {
+			config.Compaction.Key = key
+		}
+	}
+
+	// Process the read-only and write-only modes
+	if v, ok := configMap["mode"].(string); ok {
+		mode, err := model.ParseQueueMode(v)
+		if err != nil {
+			return nil, err
+		}
+		config.Mode = mode
+	}
+
+	// Process the implicit consumer group policy
+	if v, ok := configMap["implicitGroups"].(string); ok {
+		policy, err := model.ParseImplicitGroupPolicy(v)
+		if err != nil {
+			return nil, err
+		}
+		config.ImplicitGroups = policy
+	}
+
+	// Process the publish-time enrichment
+	if raw, ok := configMap["enrichment"].([]any); ok {
+		encoded, _ := json.Marshal(raw)
+		config.Enrichment = nil
+		if err := json.Unmarshal(encoded, &config.Enrichment); err != nil {
+			return nil, fmt.Errorf("invalid enrichment: %w", err)
+		}
+	}
+
+	// Process the shadow queue mirror
+	if raw, ok := configMap["mirror"].(map[string]any); ok {
+		encoded, _ := json.Marshal(raw)
+		config.Mirror = &model.MirrorConfig{}
+		if err := json.Unmarshal(encoded, config.Mirror); err != nil {
+			return nil, fmt.Errorf("invalid mirror: %w", err)
+		}
+	}
+
+	// Process the dead-letter queue
+	if raw, ok := configMap["deadLetter"].(map[string]any); ok {
+		encoded, _ := json.Marshal(raw)
+		config.DeadLetter = &model.DeadLetterConfig{}
+		if err := json.Unmarshal(encoded, config.DeadLetter); err != nil {
+			return nil, fmt.Errorf("invalid dead-letter config: %w", err)
+		}
+	}
+
+	// Process the read replica
+	if raw, ok := configMap["readReplica"].(map[string]any); ok {
+		encoded, _ := json.Marshal(raw)
+		config.ReadReplica = &model.ReadReplicaConfig{}
+		if err := json.Unmarshal(encoded, config.ReadReplica); err != nil {
+			return nil, fmt.Errorf("invalid read replica: %w", err)
+		}
+	}
+
+	return config, nil
 }
 
 func (h *Handler) getQueue(w http.ResponseWriter, r *http.Request) {
@@ -614,11 +1052,17 @@ func (h *Handler) getQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version := h.domainVersion(r.Context(), domainName)
+	if notModified(w, r, resourceETag(domainName, version, queue.Name, queue.MessageCount)) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"name":         queue.Name,
 		"messageCount": queue.MessageCount,
 		"config":       queue.Config,
+		"version":      version,
 	})
 }
 
@@ -627,8 +1071,13 @@ func (h *Handler) deleteQueue(w http.ResponseWriter, r *http.Request) {
 	domainName := vars["domain"]
 	queueName := vars["queue"]
 
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
 	if err := h.queueService.DeleteQueue(r.Context(), domainName, queueName); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		domainWriteError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -643,20 +1092,17 @@ func (h *Handler) publishMessage(w http.ResponseWriter, r *http.Request) {
 	domainName := vars["domain"]
 	queueName := vars["queue"]
 
-	var payload map[string]any
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		h.logger.Error("Error decoding request body", "ERROR", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	body, release, err := requestBody(r)
+	if err != nil {
+		h.logger.Error("Error reading request body", "ERROR", err)
+		http.Error(w, "Failed to read request", http.StatusBadRequest)
 		return
 	}
-
-	h.logger.Debug("Message payload", "payload", fmt.Sprintf("%+v", payload))
-
-	// Convert to JSON
-	payloadBytes, err := json.Marshal(payload)
+	payloadBytes, payloadID, err := parsePublishPayload(body)
+	release()
 	if err != nil {
-		h.logger.Error("Error marshalling payload", "ERROR", err)
-		http.Error(w, "Failed to encode payload", http.StatusInternalServerError)
+		h.logger.Error("Error decoding request body", "ERROR", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -669,10 +1115,9 @@ func (h *Handler) publishMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := GenerateID()
-	ID, exists := payload["id"].(string)
-	if exists {
-		id = ID
+	id := payloadID
+	if id == "" {
+		id = GenerateID()
 	}
 
 	// Create message
@@ -682,19 +1127,35 @@ func (h *Handler) publishMessage(w http.ResponseWriter, r *http.Request) {
 		Headers:   extractHeaders(r),
 		Timestamp: time.Now(),
 	}
+	message.SetPublisher(requestPublisher(r))
 
 	// Publish message
 	if err := h.messageService.PublishMessage(domainName, queueName, message); err != nil {
-		h.logger.Error("Error publishing message", "ERROR", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		var validationErr *model.SchemaValidationError
+		switch {
+		case errors.Is(err, model.ErrDuplicatePublish):
+			// the producer retried, answer as the first publish did
+			writePublishResponse(w, message.ID, true)
+		case errors.Is(err, model.ErrInvalidProducerSequence), errors.Is(err, model.ErrEncryptedFieldsPayload),
+			errors.Is(err, model.ErrEnrichmentPayload):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, model.ErrStaleProducerSequence), errors.Is(err, model.ErrQueueReadOnly):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, model.ErrInjectedFault), errors.Is(err, model.ErrInjectedCircuitOpen):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, model.ErrStandbyReadOnly):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.As(err, &validationErr):
+			writeSchemaViolations(w, validationErr)
+		default:
+			h.logger.Error("Error publishing message", "ERROR", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":    "success",
-		"messageId": message.ID,
-	})
+	writePublishResponse(w, message.ID, false)
 }
 
 func (h *Handler) consumeMessages(w http.ResponseWriter, r *http.Request) {
@@ -724,28 +1185,56 @@ func (h *Handler) consumeMessages(w http.ResponseWriter, r *http.Request) {
 		"consumer", consumerID,
 		"maxCount", maxCount)
 
-	// long polling if timeout is set TODO: check this part
+	// long polling if timeout is set, the whole batch waiting at most the
+	// timeout; the request context stops the wait once the client leaves
 	ctx := r.Context()
 	if timeout > 0 {
 		var cancel context.CancelFunc
-		_, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 		defer cancel()
 	}
 
 	var messages []*model.Message
 
 	if groupID == "" {
+		// expires once idle instead of staying in the registry
 		groupID = "temp-" + time.Now().Format("20060102-150405.999999999")
+		if err := h.consumerGroupService.CreateEphemeralConsumerGroup(r.Context(), domainName, queueName, groupID, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 	options := &inbound.ConsumeOptions{
 		StartFromID: startFromID,
 		ConsumerID:  consumerID,
 		Timeout:     time.Duration(timeout) * time.Second,
 	}
+	if service := h.hmacMiddleware.GetServiceFromContext(r.Context()); service != nil {
+		options.ServiceID = service.ID
+	}
 
 	for range maxCount {
 		message, err := h.messageService.ConsumeMessageWithGroup(ctx, domainName, queueName, groupID, options)
+		if errors.Is(err, context.DeadlineExceeded) {
+			break // long poll over, answer with what was consumed
+		}
+		if errors.Is(err, context.Canceled) {
+			h.logger.Debug("Consumer left during the long poll", "group", groupID, "consumer", consumerID)
+			return
+		}
 		if err != nil {
+			if errors.Is(err, model.ErrConsumerIDRequired) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, model.ErrStandbyReadOnly) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			if errors.Is(err, model.ErrQueueWriteOnly) || errors.Is(err, model.ErrImplicitGroupDenied) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -757,30 +1246,15 @@ func (h *Handler) consumeMessages(w http.ResponseWriter, r *http.Request) {
 		messages = append(messages, message)
 	}
 
+	// ?raw=true hands the payloads untouched, base64-encoded
+	raw, _ := strconv.ParseBool(query.Get("raw"))
 	responseMessages := make([]map[string]any, len(messages))
 	for i, msg := range messages {
-		var payload map[string]any
-		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-			payload = map[string]any{"data": string(msg.Payload)}
-		}
-
-		// Add metadata
-		responseMsg := map[string]any{
-			"id":        msg.ID,
-			"timestamp": msg.Timestamp,
-			"headers":   msg.Headers,
-		}
-
-		// Fusion with payload
-		for k, v := range payload {
-			responseMsg[k] = v
-		}
-
-		responseMessages[i] = responseMsg
+		responseMessages[i] = consumedMessage(msg, raw)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
+	encodeJSON(w, map[string]any{
 		"messages": responseMessages,
 		"count":    len(messages),
 	})
@@ -845,9 +1319,46 @@ func (h *Handler) listRoutingRules(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// each rule comes with its match counts, so dead rules stand out
+	type ruleWithStats struct {
+		*model.RoutingRule
+		Stats model.RoutingRuleStats `json:"stats"`
+	}
+	views := make([]ruleWithStats, 0, len(rules))
+	stats := make([]model.RoutingRuleStats, 0, len(rules))
+	for _, rule := range rules {
+		ruleStats := rule.Stats(domainName)
+		views = append(views, ruleWithStats{RoutingRule: rule, Stats: ruleStats})
+		stats = append(stats, ruleStats)
+	}
+
+	version := h.domainVersion(r.Context(), domainName)
+	if notModified(w, r, resourceETag(domainName, version, ruleCounts(stats))) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"rules": rules,
+		"rules":   views,
+		"version": version,
+	})
+}
+
+// getRoutingRuleStats reports the messages routed by each rule, with the
+// observed split of canary rules
+func (h *Handler) getRoutingRuleStats(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["domain"]
+
+	stats, err := h.routingService.GetRoutingRuleStats(r.Context(), domainName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"domain": domainName,
+		"rules":  stats,
 	})
 }
 
@@ -862,6 +1373,14 @@ func (h *Handler) addRoutingRule(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.routingService.AddRoutingRule(r.Context(), domainName, &rule); err != nil {
+		if errors.Is(err, model.ErrCrossDomainRouteDenied) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidCanary) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -873,14 +1392,89 @@ func (h *Handler) addRoutingRule(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// updateRoutingRule enables, disables or reprioritizes a rule, which keeps
+// its stats unlike a delete and recreate
+func (h *Handler) updateRoutingRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	var update model.RoutingRuleUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	rule, err := h.routingService.UpdateRoutingRule(r.Context(), domainName, vars["source"], vars["destination"], update)
+	if err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      "success",
+		"rule":        rule.ID(domainName),
+		"enabled":     rule.IsEnabled(),
+		"priority":    rule.Priority,
+		"stopOnMatch": rule.StopOnMatch,
+		"version":     h.domainVersion(r.Context(), domainName),
+	})
+}
+
+// reorderRoutingRules sets the evaluation order of the rules of a source
+// queue from the list of their destination keys, first evaluated first
+func (h *Handler) reorderRoutingRules(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	var request struct {
+		Order []string `json:"order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.routingService.ReorderRoutingRules(r.Context(), domainName, vars["source"], request.Order); err != nil {
+		if errors.Is(err, model.ErrInvalidRuleOrder) {
+			domainWriteError(w, err, http.StatusBadRequest)
+			return
+		}
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "success",
+		"order":   request.Order,
+		"version": h.domainVersion(r.Context(), domainName),
+	})
+}
+
 func (h *Handler) removeRoutingRule(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	domainName := vars["domain"]
 	sourceQueue := vars["source"]
 	destQueue := vars["destination"]
 
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
 	if err := h.routingService.RemoveRoutingRule(r.Context(), domainName, sourceQueue, destQueue); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		domainWriteError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -912,25 +1506,110 @@ func (h *Handler) getStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// CSV and NDJSON exports hold the message rate series
+	if mediaType := exportMediaType(r); mediaType != mediaTypeJSON {
+		rates, err := statsSeries(stats, "messageRates")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeExport(w, mediaType, rates)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(stats); err != nil {
+	if err := encodeJSON(w, stats); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-// extracts meaningful headers from req
-func extractHeaders(r *http.Request) map[string]string {
-	headers := make(map[string]string)
+// reports the background message index compaction
+func (h *Handler) getIndexCompactionStats(w http.ResponseWriter, r *http.Request) {
+	compaction, ok := h.messageService.(interface {
+		GetIndexCompactionStats() model.IndexCompactionStats
+	})
+	if !ok {
+		http.Error(w, "Index compaction stats not supported", http.StatusNotImplemented)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(compaction.GetIndexCompactionStats())
+}
 
-	relevantHeaders := []string{
-		"Content-Type",
-		"X-Request-ID",
-		"User-Agent",
+// getTopN serves the triage rankings: slow-consumers, hot-queues and large-queues
+func (h *Handler) getTopN(w http.ResponseWriter, r *http.Request) {
+	ranking := mux.Vars(r)["ranking"]
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.statsService.GetTopN(r.Context(), ranking, limit)
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownRanking) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if mediaType := exportMediaType(r); mediaType != mediaTypeJSON {
+		writeExport(w, mediaType, entries)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"ranking": ranking,
+		"entries": entries,
+	})
+}
+
+// getQueueWaits serves the per-queue histograms of the time messages waited
+// for their first delivery and for their full ack
+func (h *Handler) getQueueWaits(w http.ResponseWriter, r *http.Request) {
+	waits, err := h.statsService.GetQueueWaits(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"buckets": model.WaitBuckets,
+		"queues":  waits,
+	})
+}
+
+// extracts meaningful headers from req
+// relevantHeaders are the request headers kept on published messages
+var relevantHeaders = []string{
+	"Content-Type",
+	"X-Request-ID",
+	"User-Agent",
+	model.HeaderProducerID,
+	model.HeaderProducerSeq,
+	model.HeaderOrderingKey,
+	model.HeaderCompactionKey,
+}
+
+// extractHeaders returns the relevant headers of a request, nil when it has
+// none so that bare publishes don't allocate a map
+func extractHeaders(r *http.Request) map[string]string {
+	var headers map[string]string
 	for _, header := range relevantHeaders {
 		if value := r.Header.Get(header); value != "" {
+			if headers == nil {
+				headers = make(map[string]string, len(relevantHeaders))
+			}
 			headers[header] = value
 		}
 	}
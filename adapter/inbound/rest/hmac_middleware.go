@@ -1,11 +1,9 @@
 package rest
 
 import (
-	"bytes"
 	"context"
 	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,6 +22,8 @@ type HMACMiddleware struct {
 	logger          outbound.Logger
 	config          *config.Config
 	timestampWindow time.Duration
+	limiter         *ConnectionLimiter    // bounds the requests in flight per service
+	delegation      *DelegationMiddleware // nil when delegated tokens aren't accepted
 }
 
 func NewHMACMiddleware(serviceRepo outbound.ServiceRepository, logger outbound.Logger, config *config.Config) *HMACMiddleware {
@@ -43,6 +43,17 @@ func NewHMACMiddleware(serviceRepo outbound.ServiceRepository, logger outbound.L
 	}
 }
 
+// LimitInFlight bounds the requests each service account has in flight
+func (m *HMACMiddleware) LimitInFlight(limiter *ConnectionLimiter) {
+	m.limiter = limiter
+}
+
+// AcceptDelegations hands the requests carrying a delegated token, instead
+// of the HMAC headers, to the delegation middleware
+func (m *HMACMiddleware) AcceptDelegations(delegation *DelegationMiddleware) {
+	m.delegation = delegation
+}
+
 // updates the enabled status from config
 func (m *HMACMiddleware) UpdateConfig(config *config.Config) {
 	m.config = config
@@ -61,6 +72,11 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if m.delegation != nil && isDelegatedRequest(r) {
+			m.delegation.Middleware(next).ServeHTTP(w, r)
+			return
+		}
+
 		// Extract HMAC headers
 		serviceID := r.Header.Get("X-Service-ID")
 		timestamp := r.Header.Get("X-Timestamp")
@@ -86,9 +102,10 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Validate timestamp window
-		if !m.isTimestampValid(timestamp) {
-			m.unauthorized(w, "timestamp outside valid window")
+		signedAt, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			m.logger.Warn("Invalid timestamp format", "timestamp", timestamp, "error", err)
+			m.timestampRejected(w, "invalid timestamp format", m.timestampWindow)
 			return
 		}
 
@@ -105,6 +122,13 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Validate timestamp window, some services run with looser clocks
+		window := service.SkewWindow(m.timestampWindow)
+		if !m.isTimestampValid(signedAt, window) {
+			m.timestampRejected(w, "timestamp outside valid window", window)
+			return
+		}
+
 		// Read request body for signature validation
 		body, err := m.readBody(r)
 		if err != nil {
@@ -113,8 +137,13 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Validate HMAC signature
-		if !m.validateSignature(r.Method, r.URL.Path, body, timestamp, service.Secret, signature) {
+		// Validate HMAC signature, with the version the client signed with
+		version, err := m.negotiateVersion(w, r)
+		if err != nil {
+			m.unauthorized(w, err.Error())
+			return
+		}
+		if !m.validateSignature(version, r, body, timestamp, service.Secret, signature) {
 			m.unauthorized(w, "invalid signature")
 			return
 		}
@@ -126,9 +155,8 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 		}
 
 		// Check permissions for the specific action
-		permission := m.extractPermission(r.Method, r.URL.Path)
-		if permission != "" && !service.HasPermission(permission) {
-			m.forbidden(w, fmt.Sprintf("insufficient permissions for %s", permission))
+		if check := checkServiceAccess(service, r.Method, r.URL.Path); !check.Allowed {
+			m.forbidden(w, check.Reason)
 			return
 		}
 
@@ -139,6 +167,15 @@ func (m *HMACMiddleware) Middleware(next http.Handler) http.Handler {
 			m.serviceRepo.UpdateLastUsed(ctx, serviceID)
 		}()
 
+		// Keep a misbehaving integration from starving the others
+		release, ok := m.limiter.AcquireRequest(service.ID, service.Limits.MaxInFlight)
+		if !ok {
+			m.logger.Warn("Request rejected: too many in flight", "serviceID", serviceID, "path", r.URL.Path)
+			http.Error(w, "Too many requests in flight for this service", http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
 		// Add service to context
 		ctx := context.WithValue(r.Context(), ServiceContextKey, service)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -156,28 +193,22 @@ func (m *HMACMiddleware) readBody(r *http.Request) ([]byte, error) {
 		return nil, err
 	}
 
-	// Restore body for next handlers
-	r.Body = io.NopCloser(bytes.NewBuffer(body))
+	// Restore body for next handlers, which can reuse the bytes as is
+	r.Body = newBufferedBody(body)
 	return body, nil
 }
 
 // checks if timestamp is within the allowed window
-func (m *HMACMiddleware) isTimestampValid(timestampStr string) bool {
-	timestamp, err := time.Parse(time.RFC3339, timestampStr)
-	if err != nil {
-		m.logger.Warn("Invalid timestamp format", "timestamp", timestampStr, "error", err)
-		return false
-	}
-
+func (m *HMACMiddleware) isTimestampValid(timestamp time.Time, window time.Duration) bool {
 	now := time.Now()
 	diff := now.Sub(timestamp).Abs()
 
-	if diff > m.timestampWindow {
+	if diff > window {
 		m.logger.Warn("Timestamp outside window",
 			"timestamp", timestamp,
 			"now", now,
 			"diff", diff,
-			"window", m.timestampWindow)
+			"window", window)
 		return false
 	}
 
@@ -185,28 +216,55 @@ func (m *HMACMiddleware) isTimestampValid(timestampStr string) bool {
 }
 
 // validates the HMAC signature
-func (m *HMACMiddleware) validateSignature(method, path string, body []byte, timestamp, secret, providedSignature string) bool {
-	expectedSignature := m.generateSignature(method, path, body, timestamp, secret)
+func (m *HMACMiddleware) validateSignature(version string, r *http.Request, body []byte, timestamp, secret, providedSignature string) bool {
+	canonical, err := canonicalRequest(version, r, body, timestamp)
+	if err != nil {
+		return false
+	}
+	expectedSignature := signCanonical(canonical, secret)
 
 	// Use constant-time comparison to prevent timing attacks
 	return hmac.Equal([]byte(expectedSignature), []byte(providedSignature))
 }
 
-// creates HMAC-SHA256 signature
-func (m *HMACMiddleware) generateSignature(method, path string, body []byte, timestamp, secret string) string {
-	// Create canonical request string
-	canonicalRequest := fmt.Sprintf("%s\n%s\n%s\n%s", method, path, string(body), timestamp)
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(canonicalRequest))
-	signature := hex.EncodeToString(h.Sum(nil))
+// checkServiceAccess decides whether a service may send a request: the
+// permission of the action and the domain scope of the service. The HMAC
+// and delegated token middlewares and the permission dry-run share it.
+func checkServiceAccess(service *model.ServiceAccount, method, path string) model.AccessCheck {
+	check := model.AccessCheck{Permission: extractPermission(method, path)}
+	if check.Permission != "" {
+		matched, ok := service.MatchPermission(check.Permission)
+		if !ok {
+			check.Reason = fmt.Sprintf("insufficient permissions for %s", check.Permission)
+			return check
+		}
+		check.MatchedBy = matched
+	}
+	if domain := pathDomain(path); domain != "" && !service.InScope(domain) {
+		check.Reason = fmt.Sprintf("domain %s is outside the service scope", domain)
+		return check
+	}
+	check.Allowed = true
+	return check
+}
 
-	return fmt.Sprintf("sha256=%s", signature)
+// pathDomain returns the domain a request path acts on, so that scoped
+// services stay out of the other domains even on routes needing no permission
+func pathDomain(path string) string {
+	parts := strings.Split(strings.Trim(unversionedAPIPath(path), "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api" && parts[1] == "domains":
+		return parts[2]
+	case len(parts) >= 4 && parts[0] == "api" && parts[1] == "replication" && parts[2] == "domains":
+		return parts[3]
+	}
+	return ""
 }
 
 // determines required permission based on HTTP method and path
-func (m *HMACMiddleware) extractPermission(method, path string) string {
+func extractPermission(method, path string) string {
+	path = unversionedAPIPath(path)
+
 	// Parse path to extract domain and operation
 	parts := strings.Split(strings.Trim(path, "/"), "/")
 
@@ -233,36 +291,25 @@ func (m *HMACMiddleware) extractPermission(method, path string) string {
 		}
 	}
 
+	// Replication feed: api/replication/domains[/{domain}/queues/{queue}/messages]
+	if len(parts) >= 2 && parts[0] == "api" && parts[1] == "replication" {
+		if len(parts) >= 4 && parts[2] == "domains" {
+			return fmt.Sprintf("replicate:%s", parts[3])
+		}
+		return "replicate:*"
+	}
+
+	// Idempotent management writes: api/domains/{domain}[/queues/{queue}]
+	if method == "PUT" && len(parts) >= 3 && parts[0] == "api" && parts[1] == "domains" {
+		return fmt.Sprintf("manage:%s", parts[2])
+	}
+
 	return ""
 }
 
 // checks if the client IP is in the whitelist
 func (m *HMACMiddleware) isIPAllowed(remoteAddr string, whitelist []string) bool {
-	// Extract IP from "IP:port" format
-	clientIP := remoteAddr
-	if idx := strings.LastIndex(remoteAddr, ":"); idx != -1 {
-		clientIP = remoteAddr[:idx]
-	}
-
-	// Remove brackets from IPv6 addresses
-	clientIP = strings.Trim(clientIP, "[]")
-
-	for _, allowedIP := range whitelist {
-		// Simple IP matching (could be enhanced with CIDR support)
-		if clientIP == allowedIP || allowedIP == "*" {
-			return true
-		}
-
-		// Basic wildcard support
-		if strings.HasSuffix(allowedIP, "*") {
-			prefix := strings.TrimSuffix(allowedIP, "*")
-			if strings.HasPrefix(clientIP, prefix) {
-				return true
-			}
-		}
-	}
-
-	return false
+	return model.IPAllowed(remoteAddr, whitelist)
 }
 
 // extracts service from request context
@@ -282,6 +329,20 @@ func (m *HMACMiddleware) unauthorized(w http.ResponseWriter, message string) {
 	w.Write([]byte(fmt.Sprintf(`{"error":"unauthorized","message":"%s"}`, message)))
 }
 
+// sends 401 response for a timestamp refused, with the server time for
+// skewed clients to see how far off they are
+func (m *HMACMiddleware) timestampRejected(w http.ResponseWriter, message string, window time.Duration) {
+	m.logger.Warn("HMAC authentication failed", "message", message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":      "unauthorized",
+		"message":    message,
+		"serverTime": time.Now().UTC().Format(time.RFC3339Nano),
+		"window":     window.String(),
+	})
+}
+
 // sends 403 response
 func (m *HMACMiddleware) forbidden(w http.ResponseWriter, message string) {
 	m.logger.Warn("HMAC authorization failed", "message", message)
@@ -5,6 +5,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ajkula/GoRTMS/adapter/outbound/machineid"
 	"github.com/ajkula/GoRTMS/adapter/outbound/storage"
 	"github.com/ajkula/GoRTMS/config"
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -38,7 +40,7 @@ func createTempFilePath(t *testing.T) string {
 // Test helper to create repository with test data
 func createTestRepository(t *testing.T, logger outbound.Logger) outbound.ServiceRepository {
 	filePath := createTempFilePath(t)
-	repo, err := storage.NewSecureServiceRepository(filePath, logger)
+	repo, err := storage.NewSecureServiceRepository(filePath, machineid.NewMachineID("test-machine-id", "", logger), logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -291,6 +293,62 @@ func TestHMACMiddleware_InvalidTimestamp(t *testing.T) {
 	}
 }
 
+func TestHMACMiddleware_ServiceTimestampWindow(t *testing.T) {
+	// Setup
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	middleware := NewHMACMiddleware(repo, logger, cfg)
+
+	service := createTestService()
+	service.TimestampWindow = "15m"
+	repo.Create(context.Background(), service)
+
+	send := func(skew time.Duration) *httptest.ResponseRecorder {
+		body := `{"message":"test"}`
+		path := "/api/domains/orders/queues/payments/messages"
+		timestamp := time.Now().Add(skew).Format(time.RFC3339)
+
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("X-Service-ID", service.ID)
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Signature", generateTestSignature("POST", path, body, timestamp, service.Secret))
+		w := httptest.NewRecorder()
+
+		middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(w, req)
+		return w
+	}
+
+	// past the server default, within the window of the service
+	if w := send(-10 * time.Minute); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	w := send(20 * time.Minute)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	var response struct {
+		Message    string `json:"message"`
+		ServerTime string `json:"serverTime"`
+		Window     string `json:"window"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	serverTime, err := time.Parse(time.RFC3339Nano, response.ServerTime)
+	if err != nil || time.Since(serverTime).Abs() > time.Minute {
+		t.Errorf("Expected the server time, got %q", response.ServerTime)
+	}
+	if response.Message != "timestamp outside valid window" || response.Window != "15m0s" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
 func TestHMACMiddleware_ServiceNotFound(t *testing.T) {
 	// Setup
 	logger := &mockLogger2{}
@@ -532,6 +590,112 @@ func TestHMACMiddleware_WildcardPermissions(t *testing.T) {
 	}
 }
 
+func TestHMACMiddleware_DomainScope(t *testing.T) {
+	// Setup
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	middleware := NewHMACMiddleware(repo, logger, cfg)
+
+	service := createTestService()
+	service.Permissions = []string{"*"}
+	service.Domains = []string{"orders"}
+	repo.Create(context.Background(), service)
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "Publish within the scope",
+			method:         "POST",
+			path:           "/api/domains/orders/queues/payments/messages",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Wildcard permission outside the scope",
+			method:         "POST",
+			path:           "/api/domains/inventory/queues/items/messages",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Route without permission outside the scope",
+			method:         "GET",
+			path:           "/api/domains/inventory/queues/items/producers/p1",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Replication feed of every domain",
+			method:         "GET",
+			path:           "/api/replication/domains",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerCalled := false
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := createTestRequest(tc.method, tc.path, `{"message":"test"}`, service)
+			w := httptest.NewRecorder()
+
+			middleware.Middleware(testHandler).ServeHTTP(w, req)
+
+			if tc.expectedStatus == http.StatusOK && !handlerCalled {
+				t.Error("Expected handler to be called")
+			}
+			if tc.expectedStatus != http.StatusOK && handlerCalled {
+				t.Error("Expected handler NOT to be called")
+			}
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestCreateDomainOutsideScope(t *testing.T) {
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	middleware := NewHMACMiddleware(repo, logger, cfg)
+	domains := &mockDomainService{domains: make(map[string]*model.Domain)}
+	h := &Handler{logger: logger, hmacMiddleware: middleware, domainService: domains}
+
+	service := createTestService()
+	service.Permissions = []string{"*"}
+	service.Domains = []string{"orders"}
+	repo.Create(context.Background(), service)
+
+	create := func(name string) int {
+		req := createTestRequest("POST", "/api/domains", fmt.Sprintf(`{"name":%q}`, name), service)
+		w := httptest.NewRecorder()
+		middleware.Middleware(http.HandlerFunc(h.createDomain)).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// the domain is named in the body, not in the path
+	if code := create("inventory"); code != http.StatusForbidden {
+		t.Errorf("Expected status %d outside the scope, got %d", http.StatusForbidden, code)
+	}
+	if _, exists := domains.domains["inventory"]; exists {
+		t.Error("Expected the domain outside the scope not to be created")
+	}
+	if code := create("orders"); code != http.StatusCreated {
+		t.Errorf("Expected status %d within the scope, got %d", http.StatusCreated, code)
+	}
+}
+
 func TestHMACMiddleware_IPWhitelist(t *testing.T) {
 	// Setup
 	logger := &mockLogger2{}
@@ -673,3 +837,155 @@ func TestHMACMiddleware_LastUsedUpdate(t *testing.T) {
 			retrievedService.LastUsed.Format(time.RFC3339Nano))
 	}
 }
+
+func TestHMACMiddleware_InFlightLimit(t *testing.T) {
+	logger := &mockLogger{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	limiter := NewConnectionLimiter(config.ConnectionLimits{MaxInFlightPerService: 2})
+	middleware := NewHMACMiddleware(repo, logger, cfg)
+	middleware.LimitInFlight(limiter)
+
+	noisy := createTestService()
+	noisy.Limits = model.ServiceLimits{MaxInFlight: 1}
+	repo.Create(context.Background(), noisy)
+	quiet := createTestService()
+	quiet.ID = "test-service-002"
+	repo.Create(context.Background(), quiet)
+
+	// the first request of the noisy service stays in flight until released
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	blocking := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		blocking.ServeHTTP(httptest.NewRecorder(), createTestRequest("POST", "/api/domains/orders/queues/payments/messages", "{}", noisy))
+	}()
+	<-started
+
+	ok := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(service *model.ServiceAccount) int {
+		w := httptest.NewRecorder()
+		ok.ServeHTTP(w, createTestRequest("POST", "/api/domains/orders/queues/payments/messages", "{}", service))
+		return w.Code
+	}
+
+	if code := serve(noisy); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 above the account limit, got %d", code)
+	}
+	if code := serve(quiet); code != http.StatusOK {
+		t.Errorf("Expected other accounts to be served, got %d", code)
+	}
+	if stats := limiter.Stats(); stats.Rejected[connScopeRequest] != 1 || stats.InFlight != 1 {
+		t.Errorf("Unexpected limiter stats: %+v", stats)
+	}
+
+	close(release)
+	<-done
+	if code := serve(noisy); code != http.StatusOK {
+		t.Errorf("Expected status 200 once the request is served, got %d", code)
+	}
+	if inFlight := limiter.Stats().InFlight; inFlight != 0 {
+		t.Errorf("Expected no request in flight, got %d", inFlight)
+	}
+}
+
+// Test helper to sign a request with version 2, query string and host included
+func signTestRequestV2(req *http.Request, body string, service *model.ServiceAccount) {
+	timestamp := time.Now().Format(time.RFC3339)
+	bodyHash := sha256.Sum256([]byte(body))
+	canonical := fmt.Sprintf("v2\n%s\n%s\n%s\n%s\n%s\n%s",
+		req.Method, req.Host, req.URL.Path, req.URL.Query().Encode(), hex.EncodeToString(bodyHash[:]), timestamp)
+	h := hmac.New(sha256.New, []byte(service.Secret))
+	h.Write([]byte(canonical))
+
+	req.Header.Set("X-Service-ID", service.ID)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Version", "v2")
+	req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(h.Sum(nil)))
+}
+
+func TestHMACMiddleware_SignatureVersions(t *testing.T) {
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	cfg := config.DefaultConfig()
+	cfg.Security.EnableAuthentication = true
+
+	middleware := NewHMACMiddleware(repo, logger, cfg)
+	service := createTestService()
+	repo.Create(context.Background(), service)
+
+	handler := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	path := "/api/domains/orders/queues/payments/messages"
+	body := `{"message":"test"}`
+
+	// v2 signs the query string and the host
+	req := httptest.NewRequest("POST", path+"?wait=5s&priority=high", strings.NewReader(body))
+	signTestRequestV2(req, body, service)
+	if w := serve(req); w.Code != http.StatusOK {
+		t.Errorf("Expected v2 request to pass, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tampered := httptest.NewRequest("POST", path+"?wait=5s&priority=high", strings.NewReader(body))
+	signTestRequestV2(tampered, body, service)
+	tampered.URL.RawQuery = "wait=5s&priority=low"
+	if w := serve(tampered); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a tampered query to be rejected, got %d", w.Code)
+	}
+
+	otherHost := httptest.NewRequest("POST", path, strings.NewReader(body))
+	signTestRequestV2(otherHost, body, service)
+	otherHost.Host = "replica.internal"
+	if w := serve(otherHost); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a request replayed to another host to be rejected, got %d", w.Code)
+	}
+
+	unknown := createTestRequest("POST", path, body, service)
+	unknown.Header.Set("X-Signature-Version", "v9")
+	if w := serve(unknown); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "unsupported signature version") {
+		t.Errorf("Expected an unknown version to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// v1 stays accepted without the header, deprecated during the window
+	if w := serve(createTestRequest("POST", path, body, service)); w.Code != http.StatusOK || w.Header().Get("Deprecation") != "" {
+		t.Errorf("Expected v1 request to pass silently, got %d, deprecation %q", w.Code, w.Header().Get("Deprecation"))
+	}
+
+	sunset := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	deprecation := sunset.Add(-30 * 24 * time.Hour)
+	cfg.Security.HMAC.V1Sunset = sunset.Format(time.RFC3339)
+	cfg.Security.HMAC.V1Deprecation = deprecation.Format(time.RFC3339)
+	w := serve(createTestRequest("POST", path, body, service))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected v1 request to pass before the sunset, got %d", w.Code)
+	}
+	// same RFC 9745 form as the deprecated v1 API
+	if w.Header().Get("Deprecation") != fmt.Sprintf("@%d", deprecation.Unix()) || w.Header().Get("Sunset") != sunset.Format(http.TimeFormat) {
+		t.Errorf("Expected deprecation headers, got %v", w.Header())
+	}
+
+	cfg.Security.HMAC.V1Sunset = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	if w := serve(createTestRequest("POST", path, body, service)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected v1 request to be rejected after the sunset, got %d", w.Code)
+	}
+	req = httptest.NewRequest("POST", path, strings.NewReader(body))
+	signTestRequestV2(req, body, service)
+	if w := serve(req); w.Code != http.StatusOK || w.Header().Get("Deprecation") != "" {
+		t.Errorf("Expected v2 request to pass after the sunset, got %d", w.Code)
+	}
+}
@@ -56,8 +56,12 @@ func (h *HybridMiddleware) isHMACRequest(r *http.Request) bool {
 	timestamp := r.Header.Get("X-Timestamp")
 	signature := r.Header.Get("X-Signature")
 
-	// All three headers must be present for HMAC authentication
+	// All three headers must be present for HMAC authentication, unless
+	// a delegated token stands for them
 	hasAllHeaders := serviceID != "" && timestamp != "" && signature != ""
+	if !hasAllHeaders && isDelegatedRequest(r) {
+		return true
+	}
 
 	if hasAllHeaders {
 		h.logger.Debug("HMAC headers detected",
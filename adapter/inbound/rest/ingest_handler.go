@@ -0,0 +1,223 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// headerIngestRoute names the ingestion route a message came through
+	headerIngestRoute = "X-Ingest-Route"
+
+	// defaultIngestBodyKB bounds a webhook body when the route doesn't
+	defaultIngestBodyKB = 1024
+
+	// stripeTolerance is how old a Stripe signature may be, as in Stripe's SDKs
+	stripeTolerance = 5 * time.Minute
+)
+
+// ingestForwardedHeaders are the webhook headers kept on the message, they
+// tell consumers what the provider sent
+var ingestForwardedHeaders = []string{
+	"Content-Type",
+	"User-Agent",
+	"X-Request-ID",
+	"X-GitHub-Event",
+	"X-GitHub-Delivery",
+	"X-GitHub-Hook-ID",
+}
+
+var errInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ingestRoute returns the configured ingestion route called name
+func (h *Handler) ingestRoute(name string) (config.IngestRoute, bool) {
+	for _, route := range h.config.Ingest {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return config.IngestRoute{}, false
+}
+
+// ingestWebhook publishes a third-party webhook to the queue of its route,
+// once its signature is verified. The sender is answered as soon as the
+// message is stored, so the broker buffers webhooks for slow consumers.
+func (h *Handler) ingestWebhook(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	route, exists := h.ingestRoute(name)
+	if !exists {
+		http.Error(w, "Ingest route not found", http.StatusNotFound)
+		return
+	}
+
+	maxBodyKB := route.MaxBodyKB
+	if maxBodyKB == 0 {
+		maxBodyKB = defaultIngestBodyKB
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBodyKB)*1024))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Webhook body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifyWebhookSignature(route, r.Header, body, time.Now()); err != nil {
+		h.logger.Warn("Webhook refused",
+			"route", route.Name,
+			"remoteAddr", r.RemoteAddr,
+			"ERROR", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := h.queueService.GetQueue(r.Context(), route.Domain, route.Queue); err != nil {
+		h.logger.Error("Ingest route queue not found",
+			"route", route.Name,
+			"queue", route.Domain+"."+route.Queue,
+			"ERROR", err)
+		http.Error(w, fmt.Sprintf("Queue not found: %s", err), http.StatusNotFound)
+		return
+	}
+
+	headers := make(map[string]string)
+	for _, header := range ingestForwardedHeaders {
+		if value := r.Header.Get(header); value != "" {
+			headers[header] = value
+		}
+	}
+	headers[headerIngestRoute] = route.Name
+
+	metadata := make(map[string]any, len(route.Metadata))
+	for key, value := range route.Metadata {
+		metadata[key] = value
+	}
+
+	message := &model.Message{
+		ID:        GenerateID(),
+		Payload:   body,
+		Headers:   headers,
+		Metadata:  metadata,
+		Timestamp: time.Now(),
+	}
+
+	if err := h.messageService.PublishMessage(route.Domain, route.Queue, message); err != nil {
+		var validationErr *model.SchemaValidationError
+		switch {
+		case errors.Is(err, model.ErrInjectedFault), errors.Is(err, model.ErrInjectedCircuitOpen):
+			// providers retry deliveries answered with a 5xx
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, model.ErrStandbyReadOnly):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, model.ErrQueueReadOnly):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &validationErr):
+			writeSchemaViolations(w, validationErr)
+		default:
+			h.logger.Error("Error publishing webhook", "route", route.Name, "ERROR", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":    "accepted",
+		"messageId": message.ID,
+	})
+}
+
+// verifyWebhookSignature checks the signature the provider of the route
+// put on the body, routes without a provider accept every webhook
+func verifyWebhookSignature(route config.IngestRoute, header http.Header, body []byte, now time.Time) error {
+	switch route.Provider {
+	case config.IngestProviderGitHub:
+		signature, found := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		if !found {
+			return fmt.Errorf("%w: missing X-Hub-Signature-256", errInvalidWebhookSignature)
+		}
+		return checkHMAC(route.Secret, signature, body)
+	case config.IngestProviderStripe:
+		return verifyStripeSignature(route.Secret, header.Get("Stripe-Signature"), body, now)
+	case config.IngestProviderHMAC:
+		name := route.SignatureHeader
+		if name == "" {
+			name = "X-Signature"
+		}
+		signature := strings.TrimPrefix(header.Get(name), "sha256=")
+		if signature == "" {
+			return fmt.Errorf("%w: missing %s", errInvalidWebhookSignature, name)
+		}
+		return checkHMAC(route.Secret, signature, body)
+	}
+	return nil
+}
+
+// verifyStripeSignature checks a Stripe-Signature header: a timestamp and
+// one or more HMACs of "timestamp.body", t=...,v1=...,v1=...
+func verifyStripeSignature(secret, header string, body []byte, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed Stripe-Signature", errInvalidWebhookSignature)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed Stripe-Signature", errInvalidWebhookSignature)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > stripeTolerance || age < -stripeTolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", errInvalidWebhookSignature)
+	}
+
+	for _, signature := range signatures {
+		if checkHMAC(secret, signature, []byte(timestamp), []byte("."), body) == nil {
+			return nil
+		}
+	}
+	return errInvalidWebhookSignature
+}
+
+// checkHMAC compares a hex HMAC-SHA256 with the one of parts, in constant time
+func checkHMAC(secret, signature string, parts ...[]byte) error {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: not hex encoded", errInvalidWebhookSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errInvalidWebhookSignature
+	}
+	return nil
+}
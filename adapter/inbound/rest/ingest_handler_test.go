@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hexHMAC(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	now := time.Unix(1_750_000_000, 0)
+
+	github := config.IngestRoute{Provider: config.IngestProviderGitHub, Secret: "gh"}
+	header := http.Header{"X-Hub-Signature-256": {"sha256=" + hexHMAC("gh", string(body))}}
+	assert.NoError(t, verifyWebhookSignature(github, header, body, now))
+	assert.ErrorIs(t, verifyWebhookSignature(github, header, []byte(`{}`), now), errInvalidWebhookSignature)
+	assert.ErrorIs(t, verifyWebhookSignature(github, http.Header{}, body, now), errInvalidWebhookSignature)
+
+	stripe := config.IngestRoute{Provider: config.IngestProviderStripe, Secret: "whsec"}
+	signed := fmt.Sprintf("t=%d,v1=%s,v1=%s", now.Unix(), hexHMAC("old", "x"), hexHMAC("whsec", fmt.Sprintf("%d.%s", now.Unix(), body)))
+	header = http.Header{"Stripe-Signature": {signed}}
+	assert.NoError(t, verifyWebhookSignature(stripe, header, body, now), "any v1 signature may match")
+	assert.ErrorIs(t, verifyWebhookSignature(stripe, header, body, now.Add(10*time.Minute)), errInvalidWebhookSignature, "replayed too late")
+	header = http.Header{"Stripe-Signature": {"v1=abc"}}
+	assert.ErrorIs(t, verifyWebhookSignature(stripe, header, body, now), errInvalidWebhookSignature)
+
+	generic := config.IngestRoute{Provider: config.IngestProviderHMAC, Secret: "k", SignatureHeader: "X-Webhook-Signature"}
+	header = http.Header{"X-Webhook-Signature": {hexHMAC("k", string(body))}}
+	assert.NoError(t, verifyWebhookSignature(generic, header, body, now))
+	header = http.Header{"X-Webhook-Signature": {"not-hex"}}
+	assert.ErrorIs(t, verifyWebhookSignature(generic, header, body, now), errInvalidWebhookSignature)
+
+	assert.NoError(t, verifyWebhookSignature(config.IngestRoute{}, http.Header{}, body, now), "unsigned routes accept everything")
+}
+
+func TestIngestWebhook(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	ctx := context.Background()
+	require.NoError(t, server.handler.domainService.CreateDomain(ctx, &model.DomainConfig{Name: "ci"}))
+	require.NoError(t, server.handler.queueService.CreateQueue(ctx, "ci", "events", &model.QueueConfig{}))
+	server.handler.config.Ingest = []config.IngestRoute{{
+		Name:      "github",
+		Domain:    "ci",
+		Queue:     "events",
+		Provider:  config.IngestProviderGitHub,
+		Secret:    "gh",
+		Metadata:  map[string]string{"source": "github"},
+		MaxBodyKB: 1,
+	}}
+
+	post := func(path, body, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "push")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature-256", "sha256="+signature)
+		}
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"ref":"refs/heads/main"}`
+	w := post("/ingest/github", body, hexHMAC("gh", body))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	messages := server.handler.messageService.(*mockMessageService).messages["ci/events"]
+	require.Len(t, messages, 1)
+	assert.Equal(t, body, string(messages[0].Payload))
+	assert.Equal(t, "push", messages[0].Headers["X-GitHub-Event"])
+	assert.Equal(t, "github", messages[0].Headers[headerIngestRoute])
+	assert.Equal(t, "github", messages[0].Metadata["source"])
+
+	assert.Equal(t, http.StatusUnauthorized, post("/ingest/github", body, hexHMAC("wrong", body)).Code)
+	assert.Equal(t, http.StatusNotFound, post("/ingest/stripe", body, "").Code)
+
+	large := `{"data":"` + strings.Repeat("x", 2048) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("/ingest/github", large, hexHMAC("gh", large)).Code)
+}
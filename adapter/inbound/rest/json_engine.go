@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// jsonEngine marshals the JSON of the hot paths: publish bodies, consumed
+// messages and stats. The engines behave as encoding/json does, they only
+// differ in speed.
+type jsonEngine interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type stdJSON struct{}
+
+func (stdJSON) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (stdJSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// jsonEngines are the engines built in, the faster ones register themselves
+// from files behind their build tag
+var jsonEngines = map[string]jsonEngine{"std": stdJSON{}}
+
+// hotJSON is the engine of the hot paths, set once at startup
+var hotJSON jsonEngine = stdJSON{}
+
+// SetJSONEngine selects the engine of the hot paths. An engine left out of
+// the build is an error, encoding/json is kept.
+func SetJSONEngine(name string) error {
+	engine, ok := jsonEngines[name]
+	if !ok {
+		return fmt.Errorf("json engine %s is not built in, available: %v", name, JSONEngines())
+	}
+	hotJSON = engine
+	return nil
+}
+
+// JSONEngines lists the engines built in
+func JSONEngines() []string {
+	names := make([]string, 0, len(jsonEngines))
+	for name := range jsonEngines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodeJSON writes v followed by a newline, as json.Encoder does
+func encodeJSON(w io.Writer, v any) error {
+	data, err := hotJSON.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
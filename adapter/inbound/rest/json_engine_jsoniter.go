@@ -0,0 +1,9 @@
+//go:build jsoniter
+
+package rest
+
+import jsoniter "github.com/json-iterator/go"
+
+func init() {
+	jsonEngines["jsoniter"] = jsoniter.ConfigCompatibleWithStandardLibrary
+}
@@ -0,0 +1,9 @@
+//go:build sonic
+
+package rest
+
+import "github.com/bytedance/sonic"
+
+func init() {
+	jsonEngines["sonic"] = sonic.ConfigStd
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonSamples are shaped like the bodies of the hot paths
+var jsonSamples = map[string]any{
+	"consume": map[string]any{
+		"count": 2,
+		"messages": []map[string]any{
+			{"id": "m-1", "timestamp": time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC), "headers": map[string]string{"Content-Type": "application/json"}, "amount": 12.5, "note": "<b>&</b>"},
+			{"id": "m-2", "timestamp": time.Date(2026, 1, 2, 3, 4, 6, 0, time.UTC), "headers": nil, "items": []any{"a", 1.0, true, nil}},
+		},
+	},
+	"stats": map[string]any{
+		"domains":  3,
+		"queues":   12,
+		"messages": 4200,
+		"messageRates": []map[string]any{
+			{"timestamp": 1767322800, "published": 10.5, "consumed": 9.25},
+			{"timestamp": 1767322860, "published": 11, "consumed": 12},
+		},
+	},
+}
+
+func TestSetJSONEngine(t *testing.T) {
+	defer func() { hotJSON = stdJSON{} }()
+
+	assert.Contains(t, JSONEngines(), "std")
+	require.NoError(t, SetJSONEngine("std"))
+	assert.Error(t, SetJSONEngine("gojay"))
+	assert.Equal(t, stdJSON{}, hotJSON, "unknown engines keep encoding/json")
+}
+
+// every engine built in writes what encoding/json writes
+func TestJSONEnginesMatchStd(t *testing.T) {
+	defer func() { hotJSON = stdJSON{} }()
+
+	for _, name := range JSONEngines() {
+		require.NoError(t, SetJSONEngine(name))
+		for sample, v := range jsonSamples {
+			expected := new(bytes.Buffer)
+			require.NoError(t, json.NewEncoder(expected).Encode(v))
+
+			got := new(bytes.Buffer)
+			require.NoError(t, encodeJSON(got, v))
+			assert.Equal(t, expected.String(), got.String(), "%s: %s", name, sample)
+		}
+
+		payload, id, err := parsePublishPayload([]byte(`{"id": "order-1", "total": 3}`))
+		require.NoError(t, err, name)
+		assert.Equal(t, "order-1", id, name)
+		assert.Equal(t, `{"id":"order-1","total":3}`, string(payload), name)
+	}
+}
+
+// go test -tags jsoniter,sonic -bench JSONEngines ./adapter/inbound/rest/
+func BenchmarkJSONEngines(b *testing.B) {
+	defer func() { hotJSON = stdJSON{} }()
+
+	body := `{"id":"order-1","customer":{"name":"Alice","tier":"gold"},"items":[` +
+		strings.Repeat(`{"sku":"sku-1","qty":2,"price":19.99},`, 20) + `{"sku":"last","qty":1}]}`
+
+	for _, name := range JSONEngines() {
+		if err := SetJSONEngine(name); err != nil {
+			b.Fatal(err)
+		}
+		b.Run(name+"/encode-stats", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				encodeJSON(&bytes.Buffer{}, jsonSamples["stats"])
+			}
+		})
+		b.Run(name+"/decode-payload", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var payload map[string]any
+				if err := hotJSON.Unmarshal([]byte(body), &payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+type labelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// parses the repeated ?label=key=value query parameters
+func labelSelectorFromRequest(r *http.Request) (map[string]string, error) {
+	return model.ParseLabelSelector(r.URL.Query()["label"])
+}
+
+func (h *Handler) updateDomainLabels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	var request labelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.UpdateDomainLabels(r.Context(), domainName, request.Labels); err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"domain": domainName,
+		"labels": request.Labels,
+	})
+}
+
+func (h *Handler) updateQueueLabels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var request labelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueLabels(r.Context(), domainName, queueName, request.Labels); err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"domain": domainName,
+		"queue":  queueName,
+		"labels": request.Labels,
+	})
+}
+
+func (h *Handler) getStatsByLabel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	labelKey := vars["label"]
+
+	stats, err := h.statsService.GetStatsByLabel(r.Context(), labelKey)
+	if err != nil {
+		h.logger.Error("getStatsByLabel", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if mediaType := exportMediaType(r); mediaType != mediaTypeJSON {
+		groups, err := statsSeries(stats, "groups")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeExport(w, mediaType, groups)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
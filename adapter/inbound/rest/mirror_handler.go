@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// updateQueueMirror starts, or replaces, the shadow copies of a queue
+func (h *Handler) updateQueueMirror(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var mirror model.MirrorConfig
+	if err := json.NewDecoder(r.Body).Decode(&mirror); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueMirror(r.Context(), domainName, queueName, &mirror); err != nil {
+		if errors.Is(err, model.ErrInvalidMirror) {
+			domainWriteError(w, err, http.StatusBadRequest)
+			return
+		}
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"domain": domainName,
+		"queue":  queueName,
+		"mirror": mirror,
+	})
+}
+
+// deleteQueueMirror stops the shadow copies of a queue
+func (h *Handler) deleteQueueMirror(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueMirror(r.Context(), domainName, vars["queue"], nil); err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// messageMover is implemented by message services supporting manual moves
+type messageMover interface {
+	MoveMessage(ctx context.Context, domainName, queueName, messageID, destinationQueue string) (*model.Message, error)
+}
+
+// queueDrainer is implemented by message services supporting queue drains
+type queueDrainer interface {
+	DrainQueue(ctx context.Context, domainName, queueName, destinationQueue string) (*model.DrainReport, error)
+}
+
+// moveMessage takes a message out of a queue into another queue of the
+// domain, for manual triage
+func (h *Handler) moveMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	messageID := vars["id"]
+
+	mover, ok := h.messageService.(messageMover)
+	if !ok {
+		http.Error(w, "Message moves not supported", http.StatusNotImplemented)
+		return
+	}
+
+	var request struct {
+		Destination string `json:"destination"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	message, err := mover.MoveMessage(r.Context(), domainName, queueName, messageID, request.Destination)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidMove):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, model.ErrMoveFailed):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      "success",
+		"id":          message.ID,
+		"domain":      domainName,
+		"source":      queueName,
+		"destination": request.Destination,
+	})
+}
+
+// drainQueue moves the backlog of a queue to the queue named by the `to`
+// parameter, answering once done with the drain report
+func (h *Handler) drainQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	drainer, ok := h.messageService.(queueDrainer)
+	if !ok {
+		http.Error(w, "Queue drains not supported", http.StatusNotImplemented)
+		return
+	}
+
+	report, err := drainer.DrainQueue(r.Context(), domainName, queueName, r.URL.Query().Get("to"))
+	if report == nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidMove):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, model.ErrMoveFailed):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	// a drain stopped midway still tells what was moved
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(report)
+}
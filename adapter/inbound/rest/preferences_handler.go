@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// anonymousPreferences keys the preferences shared by the callers of a
+// server running without authentication
+const anonymousPreferences = "anonymous"
+
+// SetPreferencesRepository lets users keep their UI preferences server-side
+func (h *Handler) SetPreferencesRepository(repo outbound.PreferencesRepository) {
+	h.preferences = repo
+}
+
+// preferencesOwner is the user the preferences of the request belong to
+func preferencesOwner(r *http.Request) string {
+	if user := GetUserFromContext(r.Context()); user != nil {
+		return user.Username
+	}
+	return anonymousPreferences
+}
+
+// returns the preferences of the calling user, the defaults if none saved
+func (h *Handler) getPreferences(w http.ResponseWriter, r *http.Request) {
+	username := preferencesOwner(r)
+	preferences, err := h.preferences.Get(r.Context(), username)
+	if errors.Is(err, model.ErrPreferencesNotFound) {
+		preferences, err = model.NewUserPreferences(username), nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences)
+}
+
+// replaces the preferences of the calling user
+func (h *Handler) putPreferences(w http.ResponseWriter, r *http.Request) {
+	preferences := model.NewUserPreferences("")
+	if err := json.NewDecoder(r.Body).Decode(preferences); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := preferences.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	preferences.Username = preferencesOwner(r)
+	preferences.UpdatedAt = time.Now()
+	if preferences.PinnedQueues == nil {
+		preferences.PinnedQueues = []model.PinnedQueue{}
+	}
+	if preferences.SavedSearches == nil {
+		preferences.SavedSearches = []model.SavedSearch{}
+	}
+
+	if err := h.preferences.Store(r.Context(), preferences); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences)
+}
+
+// resets the preferences of the calling user to the defaults
+func (h *Handler) deletePreferences(w http.ResponseWriter, r *http.Request) {
+	err := h.preferences.Delete(r.Context(), preferencesOwner(r))
+	if err != nil && !errors.Is(err, model.ErrPreferencesNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,34 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// returns the last sequence accepted for a producer so it can resume after a restart
+func (h *Handler) getProducerSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+	producerID := vars["producer"]
+
+	sessions, ok := h.messageService.(interface {
+		GetProducerSession(domainName, queueName, producerID string) (model.ProducerSession, bool)
+	})
+	if !ok {
+		http.Error(w, "Producer sessions not supported", http.StatusNotImplemented)
+		return
+	}
+
+	session, exists := sessions.GetProducerSession(domainName, queueName, producerID)
+	if !exists {
+		http.Error(w, "Producer session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
@@ -0,0 +1,258 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// The PUT endpoints below create resources under client chosen names and are
+// safe to replay: an identical request is a no-op answered with 200, a request
+// changing what can't change after creation is a 409 Conflict. All of them
+// honour If-Match against the domain version.
+
+// putDomain creates the domain of the path or converges its labels and
+// allowed route sources, the schema is fixed at creation
+func (h *Handler) putDomain(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["domain"]
+
+	var config model.DomainConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if config.Name != "" && config.Name != domainName {
+		http.Error(w, "Domain name does not match the path", http.StatusBadRequest)
+		return
+	}
+	if len(config.QueueConfigs) > 0 || len(config.RoutingRules) > 0 {
+		http.Error(w, "Queues and routing rules are managed with their own PUT endpoints", http.StatusBadRequest)
+		return
+	}
+	config.Name = domainName
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	status := http.StatusOK
+
+	domain, err := h.domainService.GetDomain(ctx, domainName)
+	if err != nil {
+		if err := h.domainService.CreateDomain(ctx, &config); err != nil {
+			domainWriteError(w, err, http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusCreated
+	} else {
+		if !domain.Schema.SameFields(config.Schema) {
+			http.Error(w, "Domain exists with a different schema", http.StatusConflict)
+			return
+		}
+		if !slices.Equal(domain.AllowedRouteSources, config.AllowedRouteSources) {
+			if err := h.domainService.UpdateAllowedRouteSources(ctx, domainName, config.AllowedRouteSources); err != nil {
+				domainWriteError(w, err, http.StatusInternalServerError)
+				return
+			}
+		}
+		if !maps.Equal(domain.Labels, config.Labels) {
+			if err := h.domainService.UpdateDomainLabels(ctx, domainName, config.Labels); err != nil {
+				domainWriteError(w, err, http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "success",
+		"domain":  domainName,
+		"created": status == http.StatusCreated,
+		"version": h.domainVersion(ctx, domainName),
+	})
+}
+
+// putQueue creates the queue of the path or converges its labels,
+// the rest of its config is fixed at creation
+func (h *Handler) putQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var request struct {
+		Name   string          `json:"name"`
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Name != "" && request.Name != queueName {
+		http.Error(w, "Queue name does not match the path", http.StatusBadRequest)
+		return
+	}
+	if len(request.Config) == 0 {
+		request.Config = json.RawMessage("{}")
+	}
+
+	config, err := parseQueueConfig(request.Config)
+	if err != nil {
+		http.Error(w, "Invalid config format", http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	domain, err := h.domainService.GetDomain(ctx, domainName)
+	if err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	status := http.StatusOK
+	if queue, exists := domain.Queues[queueName]; !exists {
+		if err := h.queueService.CreateQueue(ctx, domainName, queueName, config); err != nil {
+			domainWriteError(w, err, http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusCreated
+	} else {
+		if !queue.Config.SameSettings(*config) {
+			http.Error(w, "Queue exists with a different configuration", http.StatusConflict)
+			return
+		}
+		if !maps.Equal(queue.Config.Labels, config.Labels) {
+			if err := h.queueService.UpdateQueueLabels(ctx, domainName, queueName, config.Labels); err != nil {
+				domainWriteError(w, err, http.StatusInternalServerError)
+				return
+			}
+		}
+		if !reflect.DeepEqual(queue.Config.Mirror, config.Mirror) {
+			if err := h.queueService.UpdateQueueMirror(ctx, domainName, queueName, config.Mirror); err != nil {
+				domainWriteError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+		if !reflect.DeepEqual(queue.Config.Enrichment, config.Enrichment) {
+			if err := h.queueService.UpdateQueueEnrichment(ctx, domainName, queueName, config.Enrichment); err != nil {
+				domainWriteError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+		if !queue.Config.Mode.Equal(config.Mode) {
+			if err := h.queueService.UpdateQueueMode(ctx, domainName, queueName, config.Mode); err != nil {
+				domainWriteError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+		if !queue.Config.ImplicitGroups.Equal(config.ImplicitGroups) {
+			if err := h.queueService.UpdateQueueImplicitGroups(ctx, domainName, queueName, config.ImplicitGroups); err != nil {
+				domainWriteError(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "success",
+		"domain":  domainName,
+		"queue":   queueName,
+		"config":  config,
+		"created": status == http.StatusCreated,
+		"version": h.domainVersion(ctx, domainName),
+	})
+}
+
+// putRoutingRule creates the rule of the path, {destination} being the
+// destination key ("queue" or "domain:queue"). Rules can't be edited,
+// replacing one takes a DELETE first.
+func (h *Handler) putRoutingRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	sourceQueue := vars["source"]
+
+	var rule model.RoutingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	destination := vars["destination"]
+	destDomain, destQueue, crossDomain := strings.Cut(destination, ":")
+	if !crossDomain {
+		destDomain, destQueue = "", destination
+	} else if destDomain == domainName {
+		destDomain = ""
+	}
+
+	if (rule.SourceQueue != "" && rule.SourceQueue != sourceQueue) ||
+		(rule.DestinationQueue != "" && rule.DestinationQueue != destQueue) ||
+		(rule.DestinationDomain != "" && rule.DestinationDomain != domainName && rule.DestinationDomain != destDomain) {
+		http.Error(w, "Routing rule does not match the path", http.StatusBadRequest)
+		return
+	}
+	rule.SourceQueue = sourceQueue
+	rule.DestinationQueue = destQueue
+	rule.DestinationDomain = destDomain
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	domain, err := h.domainService.GetDomain(ctx, domainName)
+	if err != nil {
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	status := http.StatusOK
+	if existing := domain.Routes[sourceQueue][rule.DestinationKey(domainName)]; existing != nil {
+		if !existing.SameRouting(&rule) {
+			http.Error(w, "Routing rule exists with a different predicate, header policy or canary split", http.StatusConflict)
+			return
+		}
+	} else {
+		if err := h.routingService.AddRoutingRule(ctx, domainName, &rule); err != nil {
+			if errors.Is(err, model.ErrCrossDomainRouteDenied) {
+				domainWriteError(w, err, http.StatusForbidden)
+				return
+			}
+			if errors.Is(err, model.ErrInvalidCanary) {
+				domainWriteError(w, err, http.StatusBadRequest)
+				return
+			}
+			domainWriteError(w, err, http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "success",
+		"domain":  domainName,
+		"rule":    rule.ID(domainName),
+		"created": status == http.StatusCreated,
+		"version": h.domainVersion(ctx, domainName),
+	})
+}
@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/storage/memory"
+	"github.com/ajkula/GoRTMS/domain/service"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPutRouter(t *testing.T) *mux.Router {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger := &mockLogger{}
+	domainRepo := memory.NewDomainRepository(logger)
+	queueService := service.NewQueueService(ctx, logger, domainRepo, nil)
+
+	h := &Handler{
+		logger:         logger,
+		domainService:  service.NewDomainService(domainRepo, queueService, ctx),
+		queueService:   queueService,
+		routingService: service.NewRoutingService(domainRepo, ctx),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/domains/{domain}", h.putDomain).Methods("PUT")
+	router.HandleFunc("/api/domains/{domain}", h.getDomain).Methods("GET")
+	router.HandleFunc("/api/domains/{domain}/labels", h.updateDomainLabels).Methods("PUT")
+	router.HandleFunc("/api/domains/{domain}/queues/{queue}", h.putQueue).Methods("PUT")
+	router.HandleFunc("/api/domains/{domain}/routes/{source}/{destination}", h.putRoutingRule).Methods("PUT")
+	return router
+}
+
+func put(router *mux.Router, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPutDomainIsIdempotent(t *testing.T) {
+	router := setupPutRouter(t)
+
+	w := put(router, "/api/domains/shop", `{"labels":{"team":"payments"}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"version":1`)
+
+	w = put(router, "/api/domains/shop", `{"labels":{"team":"payments"}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":1`, "identical requests store nothing")
+
+	w = put(router, "/api/domains/shop", `{"labels":{"team":"billing"}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":2`)
+
+	w = put(router, "/api/domains/shop", `{"schema":{"fields":{"id":"string"}}}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "the schema is fixed at creation")
+
+	assert.Equal(t, http.StatusBadRequest, put(router, "/api/domains/shop", `{"name":"other"}`).Code)
+}
+
+func TestPutDomainIfMatch(t *testing.T) {
+	router := setupPutRouter(t)
+	require.Equal(t, http.StatusCreated, put(router, "/api/domains/shop", `{}`).Code)
+
+	w := put(router, "/api/domains/shop/labels", `{"labels":{"env":"prod"}}`, "If-Match", `"7"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = put(router, "/api/domains/shop/labels", `{"labels":{"env":"prod"}}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the version moved on, the stale tag is refused
+	w = put(router, "/api/domains/shop/labels", `{"labels":{"env":"dev"}}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = put(router, "/api/domains/missing", `{}`, "If-Match", "*")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "If-Match never matches a missing resource")
+}
+
+func TestPutQueueIsIdempotent(t *testing.T) {
+	router := setupPutRouter(t)
+	require.Equal(t, http.StatusCreated, put(router, "/api/domains/shop", `{}`).Code)
+
+	config := `{"config":{"isPersistent":true,"maxSize":100,"ttl":"1h","labels":{"team":"payments"}}}`
+	w := put(router, "/api/domains/shop/queues/orders", config)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	assert.Equal(t, http.StatusOK, put(router, "/api/domains/shop/queues/orders", config).Code)
+
+	w = put(router, "/api/domains/shop/queues/orders", `{"config":{"isPersistent":true,"maxSize":100,"ttl":"1h"}}`)
+	assert.Equal(t, http.StatusOK, w.Code, "labels converge in place")
+
+	w = put(router, "/api/domains/shop/queues/orders", `{"config":{"isPersistent":true,"maxSize":500,"ttl":"1h"}}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, http.StatusNotFound, put(router, "/api/domains/missing/queues/orders", config).Code)
+}
+
+func TestPutRoutingRuleIsIdempotent(t *testing.T) {
+	router := setupPutRouter(t)
+	require.Equal(t, http.StatusCreated, put(router, "/api/domains/shop", `{}`).Code)
+	require.Equal(t, http.StatusCreated, put(router, "/api/domains/shop/queues/orders", `{}`).Code)
+	require.Equal(t, http.StatusCreated, put(router, "/api/domains/shop/queues/invoices", `{}`).Code)
+
+	rule := `{"predicate":{"type":"eq","field":"type","value":"invoice"}}`
+	w := put(router, "/api/domains/shop/routes/orders/invoices", rule)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, "orders->invoices", created["rule"])
+
+	assert.Equal(t, http.StatusOK, put(router, "/api/domains/shop/routes/orders/invoices", rule).Code)
+	assert.Equal(t, http.StatusOK, put(router, "/api/domains/shop/routes/orders/shop:invoices", rule).Code,
+		"same domain destination keys resolve to the local rule")
+
+	w = put(router, "/api/domains/shop/routes/orders/invoices", `{"predicate":{"type":"eq","field":"type","value":"refund"}}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = put(router, "/api/domains/shop/routes/orders/invoices", `{"sourceQueue":"audit"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// updateQueueMode makes a queue read-only to drain it down, write-only to
+// collect messages for later or read-write again
+func (h *Handler) updateQueueMode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var request struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	mode, err := model.ParseQueueMode(request.Mode)
+	if err != nil {
+		domainWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	r, ok := h.domainPrecondition(w, r, domainName)
+	if !ok {
+		return
+	}
+
+	if err := h.queueService.UpdateQueueMode(r.Context(), domainName, queueName, mode); err != nil {
+		if errors.Is(err, model.ErrInvalidQueueMode) {
+			domainWriteError(w, err, http.StatusBadRequest)
+			return
+		}
+		domainWriteError(w, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"domain": domainName,
+		"queue":  queueName,
+		"mode":   mode,
+	})
+}
@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// readReplica redirects the scans of a queue with a remote read replica to
+// the node holding it, with a 307 like ownedQueue. A standby serves them
+// itself: it is the replica, its copy of the queue config points at it.
+func (h *Handler) readReplica(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.standby != nil && h.standby.Status().Role == model.ReplicaRoleStandby {
+			next(w, r)
+			return
+		}
+
+		vars := mux.Vars(r)
+		queue, err := h.queueService.GetQueue(r.Context(), vars["domain"], vars["queue"])
+		if err != nil || !queue.Config.ReadReplica.Remote() {
+			next(w, r)
+			return
+		}
+		w.Header().Set("X-GoRTMS-Read-Replica", queue.Config.ReadReplica.URL)
+		http.Redirect(w, r, strings.TrimRight(queue.Config.ReadReplica.URL, "/")+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedStandby struct{ role string }
+
+func (s fixedStandby) Status() model.ReplicationStatus { return model.ReplicationStatus{Role: s.role} }
+func (s fixedStandby) Promote() error                  { return nil }
+
+func TestReadReplicaRedirect(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	ctx := context.Background()
+	require.NoError(t, server.handler.domainService.CreateDomain(ctx, &model.DomainConfig{Name: "shop"}))
+	require.NoError(t, server.handler.queueService.CreateQueue(ctx, "shop", "orders", &model.QueueConfig{
+		ReadReplica: &model.ReadReplicaConfig{Mode: model.ReadReplicaRemote, URL: "http://replica:8080/"},
+	}))
+	require.NoError(t, server.handler.queueService.CreateQueue(ctx, "shop", "invoices", &model.QueueConfig{
+		ReadReplica: &model.ReadReplicaConfig{Mode: model.ReadReplicaLocal},
+	}))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/domains/{domain}/queues/{queue}/snapshot", server.handler.readReplica(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).Methods("POST")
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+		return w
+	}
+
+	w := do("/api/domains/shop/queues/orders/snapshot?format=json")
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "http://replica:8080/api/domains/shop/queues/orders/snapshot?format=json", w.Header().Get("Location"))
+	assert.Equal(t, "http://replica:8080/", w.Header().Get("X-GoRTMS-Read-Replica"))
+
+	// local replicas are read by the services
+	assert.Equal(t, http.StatusOK, do("/api/domains/shop/queues/invoices/snapshot").Code)
+
+	// the standby holding the replica serves the scans
+	server.handler.SetStandbyReplica(fixedStandby{role: model.ReplicaRoleStandby})
+	assert.Equal(t, http.StatusOK, do("/api/domains/shop/queues/orders/snapshot").Code)
+	server.handler.SetStandbyReplica(fixedStandby{role: model.ReplicaRolePrimary})
+	assert.Equal(t, http.StatusTemporaryRedirect, do("/api/domains/shop/queues/orders/snapshot").Code)
+}
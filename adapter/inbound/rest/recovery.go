@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/gorilla/mux"
+)
+
+// requestIDHeader carries the ID of a request, the client's own is kept
+// when it sends a usable one
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from clients
+const maxRequestIDLength = 128
+
+const RequestIDContextKey contextKey = "requestId"
+
+// PanicStats counts the panics recovered from the handlers
+type PanicStats struct {
+	Total uint64             `json:"total"`
+	Last  *model.ErrorReport `json:"last,omitempty"`
+}
+
+type panicCounter struct {
+	total atomic.Uint64
+	last  atomic.Pointer[model.ErrorReport]
+}
+
+// SetErrorReporter forwards the recovered panics to an error tracker
+func (h *Handler) SetErrorReporter(reporter outbound.ErrorReporter) {
+	h.errorReporter = reporter
+}
+
+// RequestID returns the ID of the request being handled, empty outside of
+// the routes of a listener
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDContextKey).(string)
+	return id
+}
+
+// validRequestID keeps client IDs out of the logs when they could forge
+// log lines or bloat them
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// recoveryMiddleware gives each request an ID, echoed in the response, and
+// turns a panic of the handlers into a logged and reported 500 instead of
+// a dropped connection
+func (h *Handler) recoveryMiddleware(listener string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(requestIDHeader, id)
+			r = r.WithContext(context.WithValue(r.Context(), RequestIDContextKey, id))
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// net/http aborts the response quietly on this one
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				h.recoverPanic(w, r, listener, recovered, debug.Stack())
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// recoverPanic records the panic and answers the request. A handler that
+// panicked after writing its status keeps it, the error is appended.
+func (h *Handler) recoverPanic(w http.ResponseWriter, r *http.Request, listener string, recovered any, stack []byte) {
+	vars := mux.Vars(r)
+	report := &model.ErrorReport{
+		Kind:      model.ErrorKindPanic,
+		RequestID: RequestID(r.Context()),
+		Listener:  listener,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Domain:    vars["domain"],
+		Queue:     vars["queue"],
+		ServiceID: r.Header.Get("X-Service-ID"), // unverified, the auth middleware context is gone
+		Message:   fmt.Sprint(recovered),
+		Stack:     string(stack),
+		Time:      time.Now(),
+	}
+	h.panics.total.Add(1)
+	h.panics.last.Store(report)
+
+	h.logger.Error("Panic recovered",
+		"requestId", report.RequestID,
+		"listener", listener,
+		"method", report.Method,
+		"path", report.Path,
+		"panic", report.Message,
+		"stack", report.Stack)
+	if h.errorReporter != nil {
+		h.errorReporter.Report(context.WithoutCancel(r.Context()), *report)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":     "Internal server error",
+		"requestId": report.RequestID,
+	})
+}
+
+// PanicStats returns the number of panics recovered and the last one
+func (h *Handler) PanicStats() PanicStats {
+	return PanicStats{Total: h.panics.total.Load(), Last: h.panics.last.Load()}
+}
+
+func (h *Handler) getPanicStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.PanicStats())
+}
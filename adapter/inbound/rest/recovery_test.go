@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingReporter struct {
+	reports []model.ErrorReport
+}
+
+func (r *recordingReporter) Report(ctx context.Context, report model.ErrorReport) {
+	r.reports = append(r.reports, report)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	reporter := &recordingReporter{}
+	h := &Handler{logger: &mockLogger{t: t}}
+	h.SetErrorReporter(reporter)
+
+	router := mux.NewRouter()
+	router.Use(h.recoveryMiddleware("public"))
+	router.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestID(r.Context())))
+	})
+	router.HandleFunc("/boom", func(w http.ResponseWriter, r *http.Request) {
+		var queues map[string]*model.Queue
+		queues["pending"].Name = "boom"
+	})
+
+	t.Run("request IDs are generated or kept", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+		assert.Len(t, w.Header().Get(requestIDHeader), 32)
+		assert.Equal(t, w.Header().Get(requestIDHeader), w.Body.String())
+
+		req := httptest.NewRequest("GET", "/ok", nil)
+		req.Header.Set(requestIDHeader, "trace-42")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "trace-42", w.Body.String())
+
+		req.Header.Set(requestIDHeader, "forged\nline")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.NotEqual(t, "forged\nline", w.Body.String())
+		assert.Len(t, w.Body.String(), 32)
+	})
+
+	t.Run("panics answer 500 and are counted and reported", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/boom", nil)
+		req.Header.Set(requestIDHeader, "trace-43")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "trace-43", body["requestId"])
+
+		stats := h.PanicStats()
+		assert.EqualValues(t, 1, stats.Total)
+		require.NotNil(t, stats.Last)
+		assert.Equal(t, "/boom", stats.Last.Path)
+
+		require.Len(t, reporter.reports, 1)
+		report := reporter.reports[0]
+		assert.Equal(t, "trace-43", report.RequestID)
+		assert.Equal(t, "public", report.Listener)
+		assert.Contains(t, report.Message, "nil pointer dereference")
+		assert.True(t, strings.Contains(report.Stack, "recovery_test.go"))
+	})
+
+	t.Run("aborted handlers are left to net/http", func(t *testing.T) {
+		router.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+		})
+		assert.EqualValues(t, 1, h.PanicStats().Total)
+	})
+}
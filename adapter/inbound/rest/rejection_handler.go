@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// returns the sampled schema rejections of a queue, newest first, so
+// producers can see why their publishes were refused
+func (h *Handler) getSchemaRejections(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	if _, err := h.queueService.GetQueue(r.Context(), domainName, queueName); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	sampler, ok := h.messageService.(interface {
+		GetSchemaRejections(domainName, queueName string) ([]model.SchemaRejection, int64)
+	})
+	if !ok {
+		http.Error(w, "Schema rejection sampling not supported", http.StatusNotImplemented)
+		return
+	}
+
+	rejections, total := sampler.GetSchemaRejections(domainName, queueName)
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit >= 0 && limit < len(rejections) {
+			rejections = rejections[:limit]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"domain":          domainName,
+		"queue":           queueName,
+		"totalRejections": total,
+		"rejections":      rejections,
+	})
+}
+
+// writeSchemaViolations answers a publish refused by the domain schema with
+// the violations
+func writeSchemaViolations(w http.ResponseWriter, err *model.SchemaValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":      "invalid_message",
+		"message":    err.Error(),
+		"violations": err.Violations,
+	})
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// replicationFeed hands the metadata and messages of this node to its standby
+type replicationFeed interface {
+	ReplicatedDomains(ctx context.Context) ([]model.ReplicatedDomain, error)
+	ReplicationBatch(ctx context.Context, domainName, storageQueue string, after int64, limit int) (*model.ReplicationBatch, error)
+}
+
+// StandbyReplica follows a primary until promoted
+type StandbyReplica interface {
+	Status() model.ReplicationStatus
+	Promote() error
+}
+
+// SetStandbyReplica reports the standby replication of the node and lets
+// admins promote it
+func (h *Handler) SetStandbyReplica(standby StandbyReplica) {
+	h.standby = standby
+}
+
+// returns the domains and queues a standby copies
+func (h *Handler) getReplicatedDomains(w http.ResponseWriter, r *http.Request) {
+	feed, ok := h.messageService.(replicationFeed)
+	if !ok {
+		http.Error(w, "Replication not supported", http.StatusNotImplemented)
+		return
+	}
+
+	domains, err := feed.ReplicatedDomains(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domains)
+}
+
+// returns the messages stored in a queue, or a partition, from index after on
+func (h *Handler) getReplicationBatch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	feed, ok := h.messageService.(replicationFeed)
+	if !ok {
+		http.Error(w, "Replication not supported", http.StatusNotImplemented)
+		return
+	}
+
+	query := r.URL.Query()
+	after, err := strconv.ParseInt(query.Get("after"), 10, 64)
+	if err != nil && query.Get("after") != "" {
+		http.Error(w, "Invalid after index", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil && query.Get("limit") != "" {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := feed.ReplicationBatch(r.Context(), vars["domain"], vars["queue"], after, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+// returns the role of the node and the progress of its standby replication
+func (h *Handler) getReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	status := model.ReplicationStatus{Role: model.ReplicaRolePrimary, Queues: []model.ReplicatedQueue{}}
+	if h.standby != nil {
+		status = h.standby.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// stops following the primary and opens the node to publishes and consumes
+func (h *Handler) promoteStandby(w http.ResponseWriter, r *http.Request) {
+	if h.standby == nil {
+		http.Error(w, model.ErrNotStandby.Error(), http.StatusConflict)
+		return
+	}
+	if err := h.standby.Promote(); err != nil {
+		if errors.Is(err, model.ErrNotStandby) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.standby.Status())
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// bodyBufferPool recycles request body buffers on the hot write paths
+var bodyBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBodyBuffer keeps buffers grown by oversized bodies out of the pool
+const maxPooledBodyBuffer = 1 << 20
+
+var errPayloadNotObject = errors.New("payload must be a JSON object")
+
+// bufferedBody is a request body already held in memory, so handlers
+// behind a middleware that read it can reuse the bytes without copying
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferedBody(data []byte) *bufferedBody {
+	return &bufferedBody{Reader: bytes.NewReader(data), data: data}
+}
+
+func (b *bufferedBody) Close() error { return nil }
+
+// requestBody returns the request body and a release func handing its
+// buffer back to the pool; the bytes must not be used after release
+func requestBody(r *http.Request) ([]byte, func(), error) {
+	noop := func() {}
+	if r.Body == nil {
+		return nil, noop, nil
+	}
+	if buffered, ok := r.Body.(*bufferedBody); ok {
+		return buffered.data, noop, nil
+	}
+
+	buf := bodyBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if r.ContentLength > 0 && r.ContentLength <= maxPooledBodyBuffer {
+		buf.Grow(int(r.ContentLength))
+	}
+	release := func() {
+		if buf.Cap() <= maxPooledBodyBuffer {
+			bodyBufferPool.Put(buf)
+		}
+	}
+
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		release()
+		return nil, noop, err
+	}
+	return buf.Bytes(), release, nil
+}
+
+// parsePublishPayload validates a publish body in one pass and returns a
+// compacted copy of it along with its "id" field when that is a string.
+// The payload keeps its original key order and number precision.
+func parsePublishPayload(body []byte) ([]byte, string, error) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, "", errPayloadNotObject
+	}
+
+	// only the id is decoded, the rest of the document is validated and skipped
+	var probe struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := hotJSON.Unmarshal(trimmed, &probe); err != nil {
+		return nil, "", err
+	}
+
+	var id string
+	if len(probe.ID) > 0 && probe.ID[0] == '"' {
+		if err := json.Unmarshal(probe.ID, &id); err != nil {
+			return nil, "", err
+		}
+	}
+
+	payload := bytes.NewBuffer(make([]byte, 0, len(trimmed)))
+	if err := json.Compact(payload, trimmed); err != nil {
+		return nil, "", err
+	}
+	return payload.Bytes(), id, nil
+}
+
+// writePublishResponse answers a publish with the body json.Encoder would
+// write for it, without building a map or going through reflection
+func writePublishResponse(w http.ResponseWriter, messageID string, duplicate bool) {
+	buf := make([]byte, 0, 64+len(messageID))
+	if duplicate {
+		buf = append(buf, `{"duplicate":true,"messageId":`...)
+	} else {
+		buf = append(buf, `{"messageId":`...)
+	}
+	buf = appendJSONString(buf, messageID)
+	buf = append(buf, ",\"status\":\"success\"}\n"...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf)
+}
+
+// appendJSONString appends s as a JSON string. IDs are usually plain ASCII
+// and copied as is, the others go through the encoder for their escapes.
+func appendJSONString(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			encoded, _ := json.Marshal(s)
+			return append(buf, encoded...)
+		}
+	}
+	buf = append(buf, '"')
+	buf = append(buf, s...)
+	return append(buf, '"')
+}
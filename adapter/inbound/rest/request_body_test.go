@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublishPayload(t *testing.T) {
+	payload, id, err := parsePublishPayload([]byte(` { "id": "order-1", "amount": 12345678901234567890,
+		"items": [ {"sku": "a"} ] }`))
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", id)
+	// key order and number precision survive, whitespace does not
+	assert.Equal(t, `{"id":"order-1","amount":12345678901234567890,"items":[{"sku":"a"}]}`, string(payload))
+
+	_, id, err = parsePublishPayload([]byte(`{"id": 42}`))
+	require.NoError(t, err)
+	assert.Empty(t, id, "only string ids are used as message IDs")
+
+	for _, body := range []string{``, `[1, 2]`, `"text"`, `{"id": "x"`, `{"a": 1} trailing`} {
+		_, _, err := parsePublishPayload([]byte(body))
+		assert.Error(t, err, body)
+	}
+}
+
+func TestRequestBodyReusesBufferedBody(t *testing.T) {
+	data := []byte(`{"id":"a"}`)
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Body = newBufferedBody(data)
+
+	body, release, err := requestBody(req)
+	require.NoError(t, err)
+	defer release()
+	assert.Same(t, &data[0], &body[0], "a body buffered by a middleware is not copied again")
+}
+
+func TestWritePublishResponse(t *testing.T) {
+	for _, id := range []string{"order-1", "3f9a0c", `quote"d`, "caf\u00e9", "<tag>&", "tab\t"} {
+		for _, duplicate := range []bool{false, true} {
+			expected := new(strings.Builder)
+			response := map[string]any{"status": "success", "messageId": id}
+			if duplicate {
+				response["duplicate"] = true
+			}
+			require.NoError(t, json.NewEncoder(expected).Encode(response))
+
+			w := httptest.NewRecorder()
+			writePublishResponse(w, id, duplicate)
+			assert.Equal(t, expected.String(), w.Body.String(), id)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestExtractHeadersWithoutRelevantHeaders(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Del("User-Agent")
+	assert.Nil(t, extractHeaders(req))
+
+	req.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, extractHeaders(req))
+}
+
+func BenchmarkPublishResponse(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writePublishResponse(httptest.NewRecorder(), "01HZX3K5Q8N2V7R4T6Y9W1M3B5", false)
+	}
+}
+
+func BenchmarkPublishBody(b *testing.B) {
+	body := `{"id":"order-1","customer":{"name":"Alice","tier":"gold"},"items":[` +
+		strings.Repeat(`{"sku":"sku-1","qty":2,"price":19.99},`, 20) + `{"sku":"last","qty":1}]}`
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		data, release, err := requestBody(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := parsePublishPayload(data); err != nil {
+			b.Fatal(err)
+		}
+		release()
+	}
+}
@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// Response formats of the scaling hint endpoint
+const (
+	scalingFormatJSON            = "json"
+	scalingFormatKEDA            = "keda"             // flat numbers for the KEDA metrics-api scaler
+	scalingFormatExternalMetrics = "external-metrics" // external.metrics.k8s.io value list for HPA adapters
+)
+
+// kedaScalingMetrics is polled by the KEDA metrics-api scaler, each field
+// being usable as valueLocation
+type kedaScalingMetrics struct {
+	DesiredConsumers int     `json:"desiredConsumers"`
+	Backlog          int     `json:"backlog"`
+	ArrivalRate      float64 `json:"arrivalRate"`
+	ProcessingRate   float64 `json:"processingRate"`
+	Consumers        int     `json:"consumers"`
+}
+
+// getScalingHint serves the consumer count autoscalers should run for a queue,
+// or for one of its consumer groups with ?group=
+func (h *Handler) getScalingHint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = scalingFormatJSON
+	}
+	if format != scalingFormatJSON && format != scalingFormatKEDA && format != scalingFormatExternalMetrics {
+		http.Error(w, "format must be json, keda or external-metrics", http.StatusBadRequest)
+		return
+	}
+
+	var policy model.ScalingPolicy
+	if drainTime := query.Get("drainTime"); drainTime != "" {
+		d, err := time.ParseDuration(drainTime)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid drainTime", http.StatusBadRequest)
+			return
+		}
+		policy.DrainTime = d
+	}
+	for param, target := range map[string]*int{"min": &policy.MinConsumers, "max": &policy.MaxConsumers} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid "+param, http.StatusBadRequest)
+				return
+			}
+			*target = n
+		}
+	}
+	if policy.MaxConsumers > 0 && policy.MinConsumers > policy.MaxConsumers {
+		http.Error(w, "min must not exceed max", http.StatusBadRequest)
+		return
+	}
+
+	hint, err := h.statsService.GetScalingHint(r.Context(), vars["domain"], vars["queue"], query.Get("group"), policy)
+	if err != nil {
+		if errors.Is(err, model.ErrNoScalingMetrics) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch format {
+	case scalingFormatKEDA:
+		json.NewEncoder(w).Encode(kedaScalingMetrics{
+			DesiredConsumers: hint.DesiredConsumers,
+			Backlog:          hint.Backlog,
+			ArrivalRate:      hint.ArrivalRate,
+			ProcessingRate:   hint.ProcessingRate,
+			Consumers:        hint.Consumers,
+		})
+	case scalingFormatExternalMetrics:
+		json.NewEncoder(w).Encode(externalMetrics(hint, time.Now().UTC()))
+	default:
+		json.NewEncoder(w).Encode(hint)
+	}
+}
+
+// externalMetrics lists the hint the way the Kubernetes external metrics API
+// serves values, rates as milli-quantities
+func externalMetrics(hint *model.ScalingHint, now time.Time) externalMetricValueList {
+	labels := map[string]string{"domain": hint.Domain, "queue": hint.Queue}
+	if hint.GroupID != "" {
+		labels["group"] = hint.GroupID
+	}
+
+	milli := func(rate float64) string {
+		return strconv.FormatInt(int64(rate*1000+0.5), 10) + "m"
+	}
+	values := []struct{ name, value string }{
+		{"gortms_desired_consumers", strconv.Itoa(hint.DesiredConsumers)},
+		{"gortms_backlog", strconv.Itoa(hint.Backlog)},
+		{"gortms_arrival_rate", milli(hint.ArrivalRate)},
+		{"gortms_processing_rate", milli(hint.ProcessingRate)},
+	}
+
+	list := newExternalMetricValueList(len(values))
+	for _, v := range values {
+		list.Items = append(list.Items, externalMetricValue{
+			MetricName:   v.name,
+			MetricLabels: labels,
+			Timestamp:    now,
+			Value:        v.value,
+		})
+	}
+	return list
+}
@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalMetrics(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	list := externalMetrics(&model.ScalingHint{
+		Domain: "shop", Queue: "orders", GroupID: "billing",
+		Backlog: 1200, ArrivalRate: 40.5, ProcessingRate: 0.0004, DesiredConsumers: 3,
+	}, now)
+
+	assert.Equal(t, "ExternalMetricValueList", list.Kind)
+	require.Len(t, list.Items, 4)
+	values := make(map[string]string)
+	for _, item := range list.Items {
+		values[item.MetricName] = item.Value
+		assert.Equal(t, map[string]string{"domain": "shop", "queue": "orders", "group": "billing"}, item.MetricLabels)
+		assert.Equal(t, now, item.Timestamp)
+	}
+	assert.Equal(t, map[string]string{
+		"gortms_desired_consumers": "3",
+		"gortms_backlog":           "1200",
+		"gortms_arrival_rate":      "40500m",
+		"gortms_processing_rate":   "0m",
+	}, values)
+}
+
+func TestGetScalingHintValidation(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	get := func(query string) int {
+		req := httptest.NewRequest("GET", "/api/domains/shop/queues/orders/scaling"+query, nil)
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=prometheus"))
+	assert.Equal(t, http.StatusBadRequest, get("?drainTime=soon"))
+	assert.Equal(t, http.StatusBadRequest, get("?min=-1"))
+	assert.Equal(t, http.StatusBadRequest, get("?min=5&max=2"))
+	assert.Equal(t, http.StatusNotFound, get("?format=keda"), "no metrics collected for the queue")
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// Formats served by POST /api/domains/{domain}/queues/{queue}/infer-schema
+const (
+	inferenceFormatFields     = "fields"
+	inferenceFormatJSONSchema = "jsonschema"
+)
+
+// messageSampler is implemented by message services reading stored messages
+// without consuming them
+type messageSampler interface {
+	SampleMessages(ctx context.Context, domainName, queueName string, count int) ([]*model.Message, error)
+}
+
+type inferSchemaRequest struct {
+	SampleSize int    `json:"sampleSize"` // defaults to 100, at most 10000
+	Format     string `json:"format"`     // "fields" (default) or "jsonschema"
+}
+
+// inferSchema proposes a schema from the recent messages of a queue, to
+// formalize the validation of queues carrying free-form payloads. Nothing is
+// consumed, and the domain schema is left as is.
+func (h *Handler) inferSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	request := inferSchemaRequest{SampleSize: model.DefaultInferenceSample, Format: inferenceFormatFields}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.SampleSize <= 0 || request.SampleSize > model.MaxInferenceSample {
+		http.Error(w, "sampleSize must be between 1 and 10000", http.StatusBadRequest)
+		return
+	}
+	if request.Format != inferenceFormatFields && request.Format != inferenceFormatJSONSchema {
+		http.Error(w, "format must be fields or jsonschema", http.StatusBadRequest)
+		return
+	}
+
+	sampler, ok := h.messageService.(messageSampler)
+	if !ok {
+		http.Error(w, "Message sampling not supported", http.StatusNotImplemented)
+		return
+	}
+
+	messages, err := sampler.SampleMessages(r.Context(), domainName, queueName, request.SampleSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	payloads := make([][]byte, len(messages))
+	for i, message := range messages {
+		payloads[i] = message.Payload
+	}
+	schema := model.InferSchema(payloads)
+
+	response := map[string]any{
+		"domain":  domainName,
+		"queue":   queueName,
+		"sampled": schema.Sampled,
+		"skipped": schema.Skipped,
+	}
+	if request.Format == inferenceFormatJSONSchema {
+		response["schema"] = schema.JSONSchema()
+	} else {
+		response["fields"] = schema.Fields
+		response["proposed"] = schema.Proposed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// samplingMessageService serves fixed samples
+type samplingMessageService struct {
+	*mockMessageService
+	samples []*model.Message
+}
+
+func (s *samplingMessageService) SampleMessages(ctx context.Context, domainName, queueName string, count int) ([]*model.Message, error) {
+	return s.samples[max(0, len(s.samples)-count):], nil
+}
+
+func TestInferSchemaAPI(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	server.handler.messageService = &samplingMessageService{
+		mockMessageService: server.handler.messageService.(*mockMessageService),
+		samples: []*model.Message{
+			{ID: "1", Payload: []byte(`{"id":"a","total":10}`)},
+			{ID: "2", Payload: []byte(`{"id":"b","total":12.5,"coupon":"X"}`)},
+		},
+	}
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/domains/shop/queues/orders/infer-schema", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Sampled  int                        `json:"sampled"`
+		Proposed map[string]model.FieldType `json:"proposed"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 2, response.Sampled)
+	assert.Equal(t, map[string]model.FieldType{"id": model.StringType, "total": model.NumberType}, response.Proposed)
+
+	w = do(`{"sampleSize": 1, "format": "jsonschema"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"required":["coupon","id","total"]`)
+
+	assert.Equal(t, http.StatusBadRequest, do(`{"format": "avro"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(`{"sampleSize": 20000}`).Code)
+}
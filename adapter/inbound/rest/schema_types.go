@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// Languages served by GET /api/domains/{domain}/schema/types
+const (
+	schemaLangGo = "go"
+	schemaLangTS = "ts"
+)
+
+var (
+	goFieldTypes = map[model.FieldType]string{
+		model.StringType:  "string",
+		model.NumberType:  "float64",
+		model.BooleanType: "bool",
+		model.ObjectType:  "map[string]any",
+		model.ArrayType:   "[]any",
+	}
+	tsFieldTypes = map[model.FieldType]string{
+		model.StringType:  "string",
+		model.NumberType:  "number",
+		model.BooleanType: "boolean",
+		model.ObjectType:  "Record<string, unknown>",
+		model.ArrayType:   "unknown[]",
+	}
+
+	tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// getSchemaTypes renders the schema of a domain as a Go struct or a
+// TypeScript interface, so producers and consumers build the payloads the
+// broker validates
+func (h *Handler) getSchemaTypes(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["domain"]
+	lang := r.URL.Query().Get("lang")
+	pkg := r.URL.Query().Get("package")
+	if pkg == "" {
+		pkg = "messages"
+	}
+
+	if lang != schemaLangGo && lang != schemaLangTS {
+		http.Error(w, "lang must be go or ts", http.StatusBadRequest)
+		return
+	}
+	if lang == schemaLangGo && (!token.IsIdentifier(pkg) || token.IsKeyword(pkg)) {
+		http.Error(w, "Invalid package name", http.StatusBadRequest)
+		return
+	}
+
+	domain, err := h.domainService.GetDomain(r.Context(), domainName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if notModified(w, r, resourceETag(domain.Name, domain.Version, lang, pkg)) {
+		return
+	}
+
+	var fields map[string]model.FieldType
+	customValidation := false
+	if domain.Schema != nil {
+		fields = domain.Schema.Fields
+		customValidation = domain.Schema.Validation != nil
+	}
+
+	var source []byte
+	if lang == schemaLangGo {
+		source, err = generateGoTypes(pkg, domain.Name, fields, customValidation)
+		if err != nil {
+			h.logger.Error("Failed to generate schema types", "domain", domain.Name, "error", err)
+			http.Error(w, "Failed to generate types", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		source = generateTSTypes(domain.Name, fields, customValidation)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(source)
+}
+
+// generateGoTypes writes a formatted Go file holding the message struct of a domain
+func generateGoTypes(pkg, domainName string, fields map[string]model.FieldType, customValidation bool) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by GoRTMS from the schema of domain %s. DO NOT EDIT.\n\n", domainName)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	typeName := messageTypeName(domainName)
+	fmt.Fprintf(&b, "// %s is the payload published to the queues of domain %s\n", typeName, domainName)
+	if customValidation {
+		b.WriteString("// The domain also applies a custom validation these types can't express\n")
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", typeName)
+
+	used := make(map[string]bool)
+	for _, name := range sortedFieldNames(fields) {
+		goType, ok := goFieldTypes[fields[name]]
+		if !ok {
+			goType = "any"
+		}
+
+		fieldName := pascalCase(name)
+		if fieldName == "" || !unicode.IsLetter([]rune(fieldName)[0]) {
+			fieldName = "Field" + fieldName
+		}
+		// "user_id" and "userId" both give UserId
+		for i := 2; used[fieldName]; i++ {
+			fieldName = strings.TrimRight(fieldName, "0123456789") + strconv.Itoa(i)
+		}
+		used[fieldName] = true
+
+		fmt.Fprintf(&b, "\t%s %s `json:%s`\n", fieldName, goType, strconv.Quote(name))
+	}
+	b.WriteString("}\n")
+
+	return format.Source([]byte(b.String()))
+}
+
+// generateTSTypes writes a TypeScript interface for the message of a domain,
+// every schema field being required
+func generateTSTypes(domainName string, fields map[string]model.FieldType, customValidation bool) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated by GoRTMS from the schema of domain %s. Do not edit.\n\n", domainName)
+
+	if customValidation {
+		b.WriteString("// The domain also applies a custom validation these types can't express\n")
+	}
+	fmt.Fprintf(&b, "export interface %s {\n", messageTypeName(domainName))
+	for _, name := range sortedFieldNames(fields) {
+		tsType, ok := tsFieldTypes[fields[name]]
+		if !ok {
+			tsType = "unknown"
+		}
+
+		key := name
+		if !tsIdentifier.MatchString(name) {
+			key = strconv.Quote(name)
+		}
+		fmt.Fprintf(&b, "  %s: %s;\n", key, tsType)
+	}
+	b.WriteString("}\n")
+
+	return []byte(b.String())
+}
+
+func sortedFieldNames(fields map[string]model.FieldType) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// messageTypeName names the message type of a domain, "orders" gives OrdersMessage
+func messageTypeName(domainName string) string {
+	name := pascalCase(domainName)
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Domain" + name
+	}
+	return name + "Message"
+}
+
+// pascalCase joins the letters and digits of s, upper casing the first
+// letter of every word: "order-events" gives OrderEvents
+func pascalCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGoTypes(t *testing.T) {
+	source, err := generateGoTypes("messages", "order-events", map[string]model.FieldType{
+		"user_id": model.StringType,
+		"userId":  model.NumberType,
+		"2fa":     model.BooleanType,
+		"items":   model.ArrayType,
+		"address": model.ObjectType,
+	}, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, `// Code generated by GoRTMS from the schema of domain order-events. DO NOT EDIT.
+
+package messages
+
+// OrderEventsMessage is the payload published to the queues of domain order-events
+type OrderEventsMessage struct {
+	Field2fa bool           `+"`json:\"2fa\"`"+`
+	Address  map[string]any `+"`json:\"address\"`"+`
+	Items    []any          `+"`json:\"items\"`"+`
+	UserId   float64        `+"`json:\"userId\"`"+`
+	UserId2  string         `+"`json:\"user_id\"`"+`
+}
+`, string(source))
+}
+
+func TestGenerateTSTypes(t *testing.T) {
+	source := generateTSTypes("orders", map[string]model.FieldType{
+		"id":         model.StringType,
+		"total":      model.NumberType,
+		"x-trace-id": model.StringType,
+	}, true)
+
+	assert.Equal(t, `// Generated by GoRTMS from the schema of domain orders. Do not edit.
+
+// The domain also applies a custom validation these types can't express
+export interface OrdersMessage {
+  id: string;
+  total: number;
+  "x-trace-id": string;
+}
+`, string(source))
+}
+
+func TestGetSchemaTypes(t *testing.T) {
+	server := setupCompleteTestServer(t)
+	defer server.cleanup()
+
+	require.NoError(t, server.handler.domainService.CreateDomain(context.Background(), &model.DomainConfig{
+		Name:   "shop",
+		Schema: &model.Schema{Fields: map[string]model.FieldType{"id": model.StringType}},
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer mock-jwt-token")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/domains/shop/schema/types?lang=go&package=shop")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "package shop")
+	assert.Contains(t, w.Body.String(), "type ShopMessage struct")
+
+	w = get("/api/domains/shop/schema/types?lang=ts")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "export interface ShopMessage")
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/domains/shop/schema/types?lang=rust").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/domains/shop/schema/types?lang=go&package=func").Code)
+	assert.Equal(t, http.StatusNotFound, get("/api/domains/unknown/schema/types?lang=go").Code)
+}
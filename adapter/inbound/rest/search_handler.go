@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// messageSearcher is implemented by message services indexing fields for search
+type messageSearcher interface {
+	SearchMessages(ctx context.Context, domainName string, query map[string]string, limit int) ([]*model.Message, error)
+}
+
+// searchMessages finds the stored messages holding indexed field values,
+// every query parameter but limit is a field: ?customerId=42&status=paid.
+// Without a domain in the path every domain indexing the fields is searched.
+func (h *Handler) searchMessages(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["domain"]
+
+	searcher, ok := h.messageService.(messageSearcher)
+	if !ok {
+		http.Error(w, "Message search not supported", http.StatusNotImplemented)
+		return
+	}
+
+	limit := 0
+	query := make(map[string]string)
+	for name, values := range r.URL.Query() {
+		if name == "limit" {
+			var err error
+			if limit, err = strconv.Atoi(values[0]); err != nil || limit < 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			continue
+		}
+		query[name] = values[0]
+	}
+
+	messages, err := searcher.SearchMessages(r.Context(), domainName, query, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidSearch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, model.ErrSearchDisabled):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		default:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+		return
+	}
+
+	results := make([]map[string]any, len(messages))
+	for i, msg := range messages {
+		payload := msg.Payload
+		if h.config.Redaction.Enabled() {
+			payload = h.config.Redaction.Payload(payload)
+		}
+		result := map[string]any{
+			"id":        msg.ID,
+			"domain":    msg.Metadata["domain"],
+			"queue":     msg.Metadata["queue"],
+			"timestamp": msg.Timestamp,
+			"headers":   msg.Headers,
+		}
+		if json.Valid(payload) {
+			result["payload"] = json.RawMessage(payload)
+		} else {
+			result["payload"] = string(payload)
+		}
+		results[i] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"messages": results,
+		"count":    len(results),
+	})
+}
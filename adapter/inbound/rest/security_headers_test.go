@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("TLS listener sends HSTS and the UI its policy", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		securityHeadersMiddleware(true)(ok).ServeHTTP(w, httptest.NewRequest("GET", "/ui/index.html", nil))
+
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, hstsPolicy, w.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, uiContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("plaintext listener and API leave out HSTS and CSP", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		securityHeadersMiddleware(false)(ok).ServeHTTP(w, httptest.NewRequest("GET", "/api/domains", nil))
+
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+		assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	})
+}
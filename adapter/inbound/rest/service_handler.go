@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,10 +15,14 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// validServiceID restricts client chosen IDs to the shape of generated ones
+var validServiceID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
 // ServiceHandler handles service account management operations
 type ServiceHandler struct {
 	serviceRepo outbound.ServiceRepository
 	logger      outbound.Logger
+	usage       serviceUsageSource
 }
 
 // NewServiceHandler creates a new service handler
@@ -42,19 +48,25 @@ func (h *ServiceHandler) CreateService(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate unique service ID
-	serviceID := h.generateServiceID(req.Name)
+	h.createService(w, r, h.generateServiceID(req.Name), &req)
+}
 
+// createService stores a new service account and discloses its secret
+func (h *ServiceHandler) createService(w http.ResponseWriter, r *http.Request, serviceID string, req *model.ServiceAccountCreateRequest) {
 	// Create service account
 	service := &model.ServiceAccount{
-		ID:          serviceID,
-		Name:        req.Name,
-		Secret:      storage.GenerateServiceSecret(),
-		IsDisclosed: false, // Initially not disclosed
-		Permissions: req.Permissions,
-		IPWhitelist: req.IPWhitelist,
-		CreatedAt:   time.Now(),
-		LastUsed:    time.Time{}, // Never used yet
-		Enabled:     true,
+		ID:              serviceID,
+		Name:            req.Name,
+		Secret:          storage.GenerateServiceSecret(),
+		IsDisclosed:     false, // Initially not disclosed
+		Permissions:     req.Permissions,
+		IPWhitelist:     req.IPWhitelist,
+		Limits:          req.Limits,
+		Domains:         req.Domains,
+		CreatedAt:       time.Now(),
+		LastUsed:        time.Time{}, // Never used yet
+		Enabled:         true,
+		TimestampWindow: req.TimestampWindow,
 	}
 
 	// Save to repository
@@ -79,15 +91,19 @@ func (h *ServiceHandler) CreateService(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}{
 		ServiceAccountView: &model.ServiceAccountView{
-			ID:          service.ID,
-			Name:        service.Name,
-			Secret:      service.Secret, // ✅ VISIBLE ONLY HERE
-			IsDisclosed: true,
-			Permissions: service.Permissions,
-			IPWhitelist: service.IPWhitelist,
-			CreatedAt:   service.CreatedAt,
-			LastUsed:    service.LastUsed,
-			Enabled:     service.Enabled,
+			ID:              service.ID,
+			Name:            service.Name,
+			Secret:          service.Secret, // ✅ VISIBLE ONLY HERE
+			IsDisclosed:     true,
+			Permissions:     service.Permissions,
+			IPWhitelist:     service.IPWhitelist,
+			CreatedAt:       service.CreatedAt,
+			LastUsed:        service.LastUsed,
+			Enabled:         service.Enabled,
+			Version:         service.Version,
+			Limits:          service.Limits,
+			Domains:         service.Domains,
+			TimestampWindow: service.TimestampWindow,
 		},
 		Message: "SAVE THIS SECRET NOW - It will never be shown again!",
 	}
@@ -97,6 +113,70 @@ func (h *ServiceHandler) CreateService(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// PutService creates the service account with the id of the path, or updates
+// the name, permissions, IP whitelist, limits, domain scope and timestamp
+// window of an existing one. Replaying an
+// identical request changes nothing; the secret is only disclosed on creation.
+func (h *ServiceHandler) PutService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serviceID := vars["id"]
+
+	if !validServiceID.MatchString(serviceID) {
+		http.Error(w, "Service ID must be 3 to 64 lowercase letters, digits or hyphens", http.StatusBadRequest)
+		return
+	}
+
+	var req model.ServiceAccountCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateCreateRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service, err := h.serviceRepo.GetByID(r.Context(), serviceID)
+	if err != nil {
+		if ifMatchFails(r.Header.Get("If-Match"), 0, false) {
+			http.Error(w, "Service not found", http.StatusPreconditionFailed)
+			return
+		}
+		h.createService(w, r, serviceID, &req)
+		return
+	}
+
+	if ifMatchFails(r.Header.Get("If-Match"), service.Version, true) {
+		http.Error(w, "Service account changed since it was read", http.StatusPreconditionFailed)
+		return
+	}
+
+	if service.Name != req.Name ||
+		!slices.Equal(service.Permissions, req.Permissions) ||
+		!slices.Equal(service.IPWhitelist, req.IPWhitelist) ||
+		service.Limits != req.Limits ||
+		!slices.Equal(service.Domains, req.Domains) ||
+		service.TimestampWindow != req.TimestampWindow {
+		service.Name = req.Name
+		service.Permissions = req.Permissions
+		service.IPWhitelist = req.IPWhitelist
+		service.Limits = req.Limits
+		service.Domains = req.Domains
+		service.TimestampWindow = req.TimestampWindow
+
+		if err := h.serviceRepo.Update(r.Context(), service); err != nil {
+			h.logger.Error("Failed to update service account", "error", err, "serviceID", serviceID)
+			http.Error(w, "Failed to update service", http.StatusInternalServerError)
+			return
+		}
+		h.logger.Info("Service account updated", "serviceID", serviceID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.ToPublicView())
+}
+
 // ListServices returns all service accounts (with secrets masked)
 func (h *ServiceHandler) ListServices(w http.ResponseWriter, r *http.Request) {
 	services, err := h.serviceRepo.List(r.Context())
@@ -143,19 +223,61 @@ func (h *ServiceHandler) GetService(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(view)
 }
 
+// CanService tells whether the service account would be allowed to send a
+// request, the permission granting it or why it would be refused, without
+// the request being signed or sent
+func (h *ServiceHandler) CanService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serviceID := vars["id"]
+
+	var req struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Method == "" || !strings.HasPrefix(req.Path, "/") {
+		http.Error(w, "method and an absolute path are required", http.StatusBadRequest)
+		return
+	}
+
+	service, err := h.serviceRepo.GetByID(r.Context(), serviceID)
+	if err != nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	// the query string plays no part in permissions
+	path, _, _ := strings.Cut(req.Path, "?")
+	check := checkServiceAccess(service, strings.ToUpper(req.Method), path)
+	if !service.Enabled {
+		check.Allowed, check.Reason = false, "service disabled"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
 // DeleteService removes a service account
 func (h *ServiceHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serviceID := vars["id"]
 
 	// Check if service exists
-	_, err := h.serviceRepo.GetByID(r.Context(), serviceID)
+	service, err := h.serviceRepo.GetByID(r.Context(), serviceID)
 	if err != nil {
 		h.logger.Warn("Service not found for deletion", "serviceID", serviceID, "error", err)
 		http.Error(w, "Service not found", http.StatusNotFound)
 		return
 	}
 
+	if ifMatchFails(r.Header.Get("If-Match"), service.Version, true) {
+		http.Error(w, "Service account changed since it was read", http.StatusPreconditionFailed)
+		return
+	}
+
 	// Delete service
 	if err := h.serviceRepo.Delete(r.Context(), serviceID); err != nil {
 		h.logger.Error("Failed to delete service account", "error", err, "serviceID", serviceID)
@@ -217,15 +339,19 @@ func (h *ServiceHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
 		Rotated bool   `json:"rotated"`
 	}{
 		ServiceAccountView: &model.ServiceAccountView{
-			ID:          service.ID,
-			Name:        service.Name,
-			Secret:      service.Secret, // ✅ NEW SECRET VISIBLE ONLY HERE
-			IsDisclosed: true,
-			Permissions: service.Permissions,
-			IPWhitelist: service.IPWhitelist,
-			CreatedAt:   service.CreatedAt,
-			LastUsed:    service.LastUsed,
-			Enabled:     service.Enabled,
+			ID:              service.ID,
+			Name:            service.Name,
+			Secret:          service.Secret, // ✅ NEW SECRET VISIBLE ONLY HERE
+			IsDisclosed:     true,
+			Permissions:     service.Permissions,
+			IPWhitelist:     service.IPWhitelist,
+			CreatedAt:       service.CreatedAt,
+			LastUsed:        service.LastUsed,
+			Enabled:         service.Enabled,
+			Version:         service.Version,
+			Limits:          service.Limits,
+			Domains:         service.Domains,
+			TimestampWindow: service.TimestampWindow,
 		},
 		Message: "NEW SECRET GENERATED - Save it now! Old secret is invalid.",
 		Rotated: true,
@@ -245,6 +371,24 @@ func (h *ServiceHandler) UpdatePermissions(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if req.Limits != nil {
+		if err := req.Limits.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Domains != nil {
+		if err := model.ValidateServiceScope(*req.Domains); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.TimestampWindow != nil {
+		if err := model.ValidateTimestampWindow(*req.TimestampWindow); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Get existing service
 	service, err := h.serviceRepo.GetByID(r.Context(), serviceID)
@@ -254,12 +398,26 @@ func (h *ServiceHandler) UpdatePermissions(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if ifMatchFails(r.Header.Get("If-Match"), service.Version, true) {
+		http.Error(w, "Service account changed since it was read", http.StatusPreconditionFailed)
+		return
+	}
+
 	// Update fields
 	service.Permissions = req.Permissions
 	service.IPWhitelist = req.IPWhitelist
 	if req.Enabled != nil {
 		service.Enabled = *req.Enabled
 	}
+	if req.Limits != nil {
+		service.Limits = *req.Limits
+	}
+	if req.Domains != nil {
+		service.Domains = *req.Domains
+	}
+	if req.TimestampWindow != nil {
+		service.TimestampWindow = *req.TimestampWindow
+	}
 
 	// Save changes
 	if err := h.serviceRepo.Update(r.Context(), service); err != nil {
@@ -298,7 +456,14 @@ func (h *ServiceHandler) validateCreateRequest(req *model.ServiceAccountCreateRe
 		}
 	}
 
-	return nil
+	if err := model.ValidateServiceScope(req.Domains); err != nil {
+		return err
+	}
+	if err := model.ValidateTimestampWindow(req.TimestampWindow); err != nil {
+		return err
+	}
+
+	return req.Limits.Validate()
 }
 
 // isValidPermission validates permission format
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/gorilla/mux"
@@ -316,6 +318,154 @@ func TestServiceHandler_DeleteService(t *testing.T) {
 	}
 }
 
+func TestServiceHandler_CanService(t *testing.T) {
+	// Setup
+	logger := &mockLogger{}
+	repo := createTestRepository(t, logger)
+	handler := NewServiceHandler(repo, logger)
+
+	service := &model.ServiceAccount{
+		ID:          "tenant-worker",
+		Name:        "Tenant Worker",
+		Secret:      "secret-key",
+		Permissions: []string{"publish:*", "consume:orders"},
+		Domains:     []string{"orders", "billing"},
+		Enabled:     true,
+	}
+	if err := repo.Create(context.Background(), service); err != nil {
+		t.Fatalf("Failed to create test service: %v", err)
+	}
+
+	can := func(serviceID, body string) (*httptest.ResponseRecorder, model.AccessCheck) {
+		req := httptest.NewRequest("POST", "/api/services/"+serviceID+"/can", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": serviceID})
+		w := httptest.NewRecorder()
+		handler.CanService(w, req)
+
+		var check model.AccessCheck
+		json.Unmarshal(w.Body.Bytes(), &check)
+		return w, check
+	}
+
+	testCases := []struct {
+		name      string
+		body      string
+		allowed   bool
+		matchedBy string
+		reason    string
+	}{
+		{
+			name:      "Wildcard grants the publish",
+			body:      `{"method":"post","path":"/api/v2/domains/billing/queues/invoices/messages"}`,
+			allowed:   true,
+			matchedBy: "publish:*",
+		},
+		{
+			name:   "Permission missing",
+			body:   `{"method":"GET","path":"/api/domains/billing/queues/invoices/messages?count=5"}`,
+			reason: "insufficient permissions for consume:billing",
+		},
+		{
+			name:   "Domain out of scope",
+			body:   `{"method":"POST","path":"/api/domains/inventory/queues/items/messages"}`,
+			reason: "insufficient permissions for publish:inventory",
+		},
+		{
+			name:   "Route without permission out of scope",
+			body:   `{"method":"GET","path":"/api/domains/inventory/queues/items/producers/p1"}`,
+			reason: "domain inventory is outside the service scope",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, check := can("tenant-worker", tc.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if check.Allowed != tc.allowed || check.MatchedBy != tc.matchedBy || check.Reason != tc.reason {
+				t.Errorf("Unexpected check: %+v", check)
+			}
+		})
+	}
+
+	if w, _ := can("missing", `{"method":"GET","path":"/api/domains"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	if w, _ := can("tenant-worker", `{"method":"GET"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestServiceHandler_PutService(t *testing.T) {
+	// Setup
+	logger := &mockLogger{}
+	repo := createTestRepository(t, logger)
+	handler := NewServiceHandler(repo, logger)
+
+	putService := func(body string, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/services/billing-worker", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": "billing-worker"})
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.PutService(w, req)
+		return w
+	}
+
+	body := `{"name":"Billing Worker","permissions":["consume:billing"]}`
+
+	// First call creates the account and discloses its secret
+	w := putService(body, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var created model.ServiceAccountView
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Secret == "" || created.Secret == "••••••••••••••••" {
+		t.Error("Expected secret to be visible on creation")
+	}
+
+	// Replaying the request changes nothing
+	w = putService(body, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var replayed model.ServiceAccountView
+	json.NewDecoder(w.Body).Decode(&replayed)
+	if replayed.Version != created.Version {
+		t.Errorf("Expected version %d to be kept, got %d", created.Version, replayed.Version)
+	}
+	if replayed.Secret != "••••••••••••••••" {
+		t.Error("Expected secret to be masked on replay")
+	}
+
+	// Stale versions are refused
+	update := `{"name":"Billing Worker","permissions":["consume:billing","publish:audit"]}`
+	if w = putService(update, `"999"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412, got %d", w.Code)
+	}
+
+	w = putService(update, fmt.Sprintf(`"%d"`, created.Version))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	stored, _ := repo.GetByID(context.Background(), "billing-worker")
+	if len(stored.Permissions) != 2 || stored.Version != created.Version+1 {
+		t.Errorf("Expected updated permissions at version %d, got %v at %d", created.Version+1, stored.Permissions, stored.Version)
+	}
+
+	// Client chosen IDs keep the shape of generated ones
+	req := httptest.NewRequest("PUT", "/api/services/Bad_ID", strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "Bad_ID"})
+	w = httptest.NewRecorder()
+	handler.PutService(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
 func TestServiceHandler_ValidateCreateRequest(t *testing.T) {
 	handler := &ServiceHandler{}
 
@@ -372,6 +522,24 @@ func TestServiceHandler_ValidateCreateRequest(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "Scoped to a domain",
+			request: model.ServiceAccountCreateRequest{
+				Name:        "Tenant Service",
+				Permissions: []string{"*"},
+				Domains:     []string{"orders"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Scoped to every domain",
+			request: model.ServiceAccountCreateRequest{
+				Name:        "Tenant Service",
+				Permissions: []string{"*"},
+				Domains:     []string{"*"},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -385,3 +553,74 @@ func TestServiceHandler_ValidateCreateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceHandler_GetServiceUsage(t *testing.T) {
+	logger := &mockLogger{}
+	repo := createTestRepository(t, logger)
+	handler := NewServiceHandler(repo, logger)
+
+	service := &model.ServiceAccount{ID: "billing", Name: "Billing", Secret: "secret", Enabled: true}
+	if err := repo.Create(context.Background(), service); err != nil {
+		t.Fatalf("Failed to create test service: %v", err)
+	}
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/services/"+id+"/usage", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler.GetServiceUsage(w, req)
+		return w
+	}
+
+	if w := get("billing"); w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a usage source, got %d", w.Code)
+	}
+
+	tracker := model.NewServiceUsageTracker()
+	tracker.RecordPublished("billing", 42, time.Now())
+	handler.SetUsageSource(trackerUsage{tracker})
+
+	w := get("billing")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var usage model.ServiceUsage
+	if err := json.NewDecoder(w.Body).Decode(&usage); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if usage.TotalPublished != (model.UsageCounters{Messages: 1, Bytes: 42}) {
+		t.Errorf("Unexpected published total: %+v", usage.TotalPublished)
+	}
+	if window, ok := usage.Window(time.Minute); !ok || window.Published.Bytes != 42 {
+		t.Errorf("Expected 42 bytes in the 1m window, got %+v", usage.Windows)
+	}
+
+	if w := get("unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ListServiceUsage(w, httptest.NewRequest("GET", "/api/services/usage", nil))
+	var list struct {
+		Services []model.ServiceUsage `json:"services"`
+		Count    int                  `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if list.Count != 1 || list.Services[0].ServiceID != "billing" {
+		t.Errorf("Unexpected usage list: %+v", list)
+	}
+}
+
+type trackerUsage struct {
+	tracker *model.ServiceUsageTracker
+}
+
+func (u trackerUsage) GetServiceUsage(serviceID string) model.ServiceUsage {
+	return u.tracker.Usage(serviceID, time.Now())
+}
+
+func (u trackerUsage) ListServiceUsage() []model.ServiceUsage {
+	return u.tracker.All(time.Now())
+}
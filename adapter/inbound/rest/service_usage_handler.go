@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// serviceUsageSource counts the messages and bytes of each service account
+type serviceUsageSource interface {
+	GetServiceUsage(serviceID string) model.ServiceUsage
+	ListServiceUsage() []model.ServiceUsage
+}
+
+// SetUsageSource reports the traffic of the service accounts
+func (h *ServiceHandler) SetUsageSource(usage serviceUsageSource) {
+	h.usage = usage
+}
+
+// GetServiceUsage returns what a service account published and consumed
+// over the rolling windows and since startup
+func (h *ServiceHandler) GetServiceUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Service usage not supported", http.StatusNotImplemented)
+		return
+	}
+
+	serviceID := mux.Vars(r)["id"]
+	if _, err := h.serviceRepo.GetByID(r.Context(), serviceID); err != nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.usage.GetServiceUsage(serviceID))
+}
+
+// ListServiceUsage returns the traffic of every service account seen since
+// startup, deleted ones included, for chargeback reports
+func (h *ServiceHandler) ListServiceUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Service usage not supported", http.StatusNotImplemented)
+		return
+	}
+
+	usages := h.usage.ListServiceUsage()
+	response := struct {
+		Services []model.ServiceUsage `json:"services"`
+		Count    int                  `json:"count"`
+	}{
+		Services: usages,
+		Count:    len(usages),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
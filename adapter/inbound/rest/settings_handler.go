@@ -32,6 +32,10 @@ var (
 func (h *Handler) getSettings(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("Getting current settings")
 
+	if notModified(w, r, resourceETag(h.configVersion.Load(), h.getConfigFilePath())) {
+		return
+	}
+
 	// Get current config from global instance
 	currentConfig := h.getCurrentConfig()
 	publicConfig := currentConfig.ToPublic()
@@ -248,6 +252,7 @@ func (h *Handler) getConfigFilePath() string {
 // Updates the configuration instance
 func (h *Handler) setCurrentConfig(cfg *config.Config) {
 	h.config = cfg
+	h.configVersion.Add(1)
 
 	h.logger.Info("Configuration updated in handler")
 }
@@ -65,30 +65,41 @@ func (h *Handler) testRoutingRules(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Filter the rules that have the specified queue as the source
-	sourceRules := make([]*model.RoutingRule, 0)
+	sourceRules := make(map[string]*model.RoutingRule)
 	for _, rule := range rules {
 		if rule.SourceQueue == request.Queue {
-			sourceRules = append(sourceRules, rule)
+			sourceRules[rule.DestinationKey(domainName)] = rule
 		}
 	}
 
-	// Test each rule
+	// Test each rule, in the order publishing evaluates them
 	type MatchResult struct {
 		Rule             *model.RoutingRule `json:"rule"`
 		Matches          bool               `json:"matches"`
 		DestinationQueue string             `json:"destinationQueue"`
+		Skipped          string             `json:"skipped,omitempty"` // "disabled" or "stopped"
 	}
 
 	matches := make([]MatchResult, 0, len(sourceRules))
-	for _, rule := range sourceRules {
-		// Evaluate the predicate to see if the rule applies
-		isMatch := evaluatePredicate(h.logger, rule.Predicate, testMessage)
-
-		matches = append(matches, MatchResult{
+	stopped := false
+	for _, rule := range model.AppendRulesInOrder(nil, sourceRules) {
+		result := MatchResult{
 			Rule:             rule,
-			Matches:          isMatch,
 			DestinationQueue: rule.DestinationQueue,
-		})
+		}
+
+		switch {
+		case stopped:
+			result.Skipped = "stopped"
+		case !rule.IsEnabled():
+			result.Skipped = "disabled"
+		default:
+			// Evaluate the predicate to see if the rule applies
+			result.Matches = evaluatePredicate(h.logger, rule.Predicate, testMessage)
+			stopped = result.Matches && rule.StopOnMatch
+		}
+
+		matches = append(matches, result)
 	}
 
 	response := map[string]interface{}{
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) snapshotQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	archive, err := h.snapshotService.SnapshotQueue(r.Context(), domainName, queueName)
+	if err != nil {
+		h.logger.Error("Error creating queue snapshot",
+			"domain", domainName,
+			"queue", queueName,
+			"ERROR", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, model.ErrDomainNotFound) || errors.Is(err, model.ErrQueueNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s-%s.json", domainName, queueName, archive.CreatedAt.Format("20060102-150405"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	json.NewEncoder(w).Encode(archive)
+}
+
+func (h *Handler) restoreQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domainName := vars["domain"]
+	queueName := vars["queue"]
+
+	var archive model.QueueArchive
+	if err := json.NewDecoder(r.Body).Decode(&archive); err != nil {
+		http.Error(w, "Invalid archive format", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	result, err := h.snapshotService.RestoreQueue(r.Context(), domainName, queueName, &archive)
+	if err != nil {
+		h.logger.Error("Error restoring queue snapshot",
+			"domain", domainName,
+			"queue", queueName,
+			"ERROR", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Debug("Queue restore finished", "duration", time.Since(start).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "success",
+		"result": result,
+	})
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSnapshots fails every snapshot with the same error
+type failingSnapshots struct{ err error }
+
+func (s failingSnapshots) SnapshotQueue(ctx context.Context, domainName, queueName string) (*model.QueueArchive, error) {
+	return nil, s.err
+}
+
+func (s failingSnapshots) RestoreQueue(ctx context.Context, domainName, queueName string, archive *model.QueueArchive) (*model.QueueRestoreResult, error) {
+	return nil, s.err
+}
+
+func TestSnapshotQueueErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("lookup: %w", model.ErrDomainNotFound), http.StatusNotFound},
+		{model.ErrQueueNotFound, http.StatusNotFound},
+		{errors.New("blob store unavailable"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		h := &Handler{logger: &mockLogger2{}, snapshotService: failingSnapshots{err: tt.err}}
+		router := mux.NewRouter()
+		router.HandleFunc("/api/domains/{domain}/queues/{queue}/snapshot", h.snapshotQueue)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/domains/shop/queues/orders/snapshot", nil))
+		assert.Equal(t, tt.code, w.Code, tt.err.Error())
+	}
+}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errNotObject = errors.New("stats row is not a JSON object")
+
+// Media types of the stats exports, JSON stays the default
+const (
+	mediaTypeJSON   = "application/json"
+	mediaTypeCSV    = "text/csv"
+	mediaTypeNDJSON = "application/x-ndjson"
+)
+
+// exportMediaType picks the stats format from the Accept header: the
+// supported type with the highest quality, the first listed on a tie
+func exportMediaType(r *http.Request) string {
+	best, bestQ := mediaTypeJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case mediaTypeJSON, mediaTypeCSV, mediaTypeNDJSON:
+		case "*/*", "application/*":
+			mediaType = mediaTypeJSON
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	return best
+}
+
+// writeExport writes rows, anything marshaling to a JSON array of objects,
+// as CSV with a header line or as one JSON object per line. CSV columns are
+// the object keys in order of appearance; nested values are kept as JSON.
+func writeExport(w http.ResponseWriter, mediaType string, rows any) {
+	content, err := json.Marshal(rows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(content, &records); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if mediaType == mediaTypeNDJSON {
+		w.Header().Set("Content-Type", mediaTypeNDJSON)
+		for _, record := range records {
+			w.Write(record)
+			w.Write([]byte("\n"))
+		}
+		return
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	values := make([]map[string]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		keys, err := objectKeys(record)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+		var fields map[string]json.RawMessage
+		json.Unmarshal(record, &fields)
+		values = append(values, fields)
+	}
+
+	w.Header().Set("Content-Type", mediaTypeCSV+"; charset=utf-8")
+	out := csv.NewWriter(w)
+	out.Write(columns)
+	for _, fields := range values {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = csvValue(fields[column])
+		}
+		out.Write(row)
+	}
+	out.Flush()
+}
+
+// objectKeys lists the keys of a JSON object in their order
+func objectKeys(object json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(object))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, errNotObject
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// csvValue renders a JSON value as a cell: strings unquoted, null empty
+func csvValue(value json.RawMessage) string {
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	return string(value)
+}
+
+// statsSeries extracts an array field of a stats response for its export
+func statsSeries(stats any, field string) (json.RawMessage, error) {
+	content, err := json.Marshal(stats)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	if series, ok := fields[field]; ok && string(series) != "null" {
+		return series, nil
+	}
+	return json.RawMessage("[]"), nil
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportMediaType(t *testing.T) {
+	accept := func(value string) string {
+		r := httptest.NewRequest("GET", "/api/stats", nil)
+		if value != "" {
+			r.Header.Set("Accept", value)
+		}
+		return exportMediaType(r)
+	}
+
+	assert.Equal(t, mediaTypeJSON, accept(""))
+	assert.Equal(t, mediaTypeJSON, accept("*/*"))
+	assert.Equal(t, mediaTypeCSV, accept("text/csv"))
+	assert.Equal(t, mediaTypeNDJSON, accept("application/x-ndjson, application/json;q=0.5"))
+	assert.Equal(t, mediaTypeJSON, accept("text/csv;q=0.2, application/json"))
+	assert.Equal(t, mediaTypeJSON, accept("text/html"))
+}
+
+func TestWriteExport(t *testing.T) {
+	type row struct {
+		Domain  string   `json:"domain"`
+		Queue   string   `json:"queue"`
+		Rate    float64  `json:"rate"`
+		Domains []string `json:"domains,omitempty"`
+	}
+	rows := []row{
+		{Domain: "shop", Queue: "orders", Rate: 1.5},
+		{Domain: "shop", Queue: "a,b", Rate: 0, Domains: []string{"x"}},
+	}
+
+	t.Run("CSV keeps the field order and quotes cells", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		writeExport(w, mediaTypeCSV, rows)
+
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "domain,queue,rate,domains\n"+
+			"shop,orders,1.5,\n"+
+			`shop,"a,b",0,"[""x""]"`+"\n", w.Body.String())
+	})
+
+	t.Run("NDJSON writes one object per line", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		writeExport(w, mediaTypeNDJSON, rows)
+
+		assert.Equal(t, mediaTypeNDJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"domain":"shop","queue":"orders","rate":1.5}`+"\n"+
+			`{"domain":"shop","queue":"a,b","rate":0,"domains":["x"]}`+"\n", w.Body.String())
+	})
+}
+
+func TestStatsSeries(t *testing.T) {
+	series, err := statsSeries(map[string]any{"domains": 2, "messageRates": []map[string]int{{"rate": 3}}}, "messageRates")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"rate":3}]`, string(series))
+
+	series, err = statsSeries(map[string]any{"domains": 2}, "messageRates")
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(series))
+}
@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// messageTracer is implemented by message services tracing routed copies
+type messageTracer interface {
+	TraceMessage(ctx context.Context, domainName, queueName, messageID string) (*model.MessageTrace, error)
+}
+
+// traceMessage returns the tree of the copies routing made of a message
+func (h *Handler) traceMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	tracer, ok := h.messageService.(messageTracer)
+	if !ok {
+		http.Error(w, "Message traces not supported", http.StatusNotImplemented)
+		return
+	}
+
+	trace, err := tracer.TraceMessage(r.Context(), vars["domain"], vars["queue"], vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"stored": trace.Count(),
+		"trace":  trace,
+	})
+}
@@ -0,0 +1,211 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/mux"
+)
+
+// wsTicketTTL bounds how long a ticket can wait before the upgrade handshake
+const wsTicketTTL = 30 * time.Second
+
+// wsTicketContextKey holds the ticket a WebSocket connection was admitted with
+const wsTicketContextKey contextKey = "wsTicket"
+
+// WSTicket is a single-use credential for a WebSocket upgrade
+type WSTicket struct {
+	ID        string
+	Subject   string // username or service ID
+	Method    string // "JWT" or "HMAC"
+	Domain    string // empty with Queue for the multiplexed endpoint
+	Queue     string
+	ExpiresAt time.Time
+}
+
+// WSTicketStore keeps short-lived tickets in memory until redeemed
+type WSTicketStore struct {
+	tickets map[string]*WSTicket
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+func NewWSTicketStore(ttl time.Duration) *WSTicketStore {
+	return &WSTicketStore{
+		tickets: make(map[string]*WSTicket),
+		ttl:     ttl,
+	}
+}
+
+// Issue creates a ticket bound to a subject and a queue
+func (s *WSTicketStore) Issue(subject, method, domain, queue string) (*WSTicket, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate ticket: %w", err)
+	}
+
+	now := time.Now()
+	ticket := &WSTicket{
+		ID:        hex.EncodeToString(buf),
+		Subject:   subject,
+		Method:    method,
+		Domain:    domain,
+		Queue:     queue,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// purge expired tickets lazily
+	for id, t := range s.tickets {
+		if now.After(t.ExpiresAt) {
+			delete(s.tickets, id)
+		}
+	}
+	s.tickets[ticket.ID] = ticket
+
+	return ticket, nil
+}
+
+// Redeem consumes a ticket, it is valid only once and for its own queue
+func (s *WSTicketStore) Redeem(id, domain, queue string) (*WSTicket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket, exists := s.tickets[id]
+	if !exists {
+		return nil, false
+	}
+	delete(s.tickets, id)
+
+	if time.Now().After(ticket.ExpiresAt) || ticket.Domain != domain || ticket.Queue != queue {
+		return nil, false
+	}
+
+	return ticket, true
+}
+
+func (h *Handler) createWSTicket(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Domain string `json:"domain"`
+		Queue  string `json:"queue"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// neither for the multiplexed endpoint, its subscriptions are checked one by one
+	if (request.Domain == "") != (request.Queue == "") {
+		http.Error(w, "domain and queue are required, or neither for a multiplexed connection", http.StatusBadRequest)
+		return
+	}
+
+	subject, method := "anonymous", "NONE"
+	if service := h.hmacMiddleware.GetServiceFromContext(r.Context()); service != nil {
+		// same permission as a REST consumer of this domain
+		if request.Domain != "" && !service.HasPermission("consume:"+request.Domain) {
+			http.Error(w, "insufficient permissions for consume:"+request.Domain, http.StatusForbidden)
+			return
+		}
+		subject, method = service.ID, "HMAC"
+	} else if user, ok := r.Context().Value(UserContextKey).(*model.User); ok && user != nil {
+		subject, method = user.Username, "JWT"
+	}
+
+	ticket, err := h.wsTickets.Issue(subject, method, request.Domain, request.Queue)
+	if err != nil {
+		h.logger.Error("Failed to issue WebSocket ticket", "ERROR", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"ticket":    ticket.ID,
+		"expiresAt": ticket.ExpiresAt,
+	})
+}
+
+// WebSocketAuth guards the WebSocket upgrade with either a ticket
+// (?ticket=) issued by POST /api/ws/tickets or a JWT (Authorization header
+// or ?token=), so the handshake is rejected before any upgrade happens.
+// Admitted connections then count against the connection limits.
+func (h *Handler) WebSocketAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.config.Security.EnableAuthentication {
+			h.limitConnection(w, r, "", "", next)
+			return
+		}
+
+		vars := mux.Vars(r)
+
+		if ticketID := r.URL.Query().Get("ticket"); ticketID != "" {
+			ticket, ok := h.wsTickets.Redeem(ticketID, vars["domain"], vars["queue"])
+			if !ok {
+				h.authMiddleware.unauthorized(w, "invalid or expired ticket")
+				return
+			}
+
+			h.logger.Debug("WebSocket ticket redeemed", "subject", ticket.Subject, "method", ticket.Method)
+			r = r.WithContext(context.WithValue(r.Context(), wsTicketContextKey, ticket))
+			switch ticket.Method {
+			case "HMAC":
+				h.limitConnection(w, r, ticket.Subject, "", next)
+			case "JWT":
+				h.limitConnection(w, r, "", ticket.Subject, next)
+			default:
+				h.limitConnection(w, r, "", "", next)
+			}
+			return
+		}
+
+		token := h.authMiddleware.extractToken(r)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+
+		if token != "" {
+			if user, err := h.authService.ValidateToken(token); err == nil && user != nil {
+				ctx := context.WithValue(r.Context(), UserContextKey, user)
+				h.limitConnection(w, r.WithContext(ctx), "", user.Username, next)
+				return
+			}
+		}
+
+		h.authMiddleware.unauthorized(w, "unauthorized")
+	})
+}
+
+// AuthorizeWebSocketSubscription checks a subscription opened on the
+// multiplexed endpoint. Services admitted with a ticket need the consume
+// permission of the domain, as for a per-queue ticket; users may subscribe
+// to any queue.
+func (h *Handler) AuthorizeWebSocketSubscription(r *http.Request, domainName, queueName string) error {
+	ticket, ok := r.Context().Value(wsTicketContextKey).(*WSTicket)
+	if !ok || ticket.Method != "HMAC" {
+		return nil
+	}
+
+	var service *model.ServiceAccount
+	if h.serviceRepo != nil {
+		service, _ = h.serviceRepo.GetByID(r.Context(), ticket.Subject)
+	}
+	if service == nil || !service.Enabled {
+		return fmt.Errorf("service %s not found or disabled", ticket.Subject)
+	}
+	if !service.HasPermission("consume:" + domainName) {
+		return fmt.Errorf("insufficient permissions for consume:%s", domainName)
+	}
+	return nil
+}
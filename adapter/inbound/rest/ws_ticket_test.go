@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSTicketStore(t *testing.T) {
+	store := NewWSTicketStore(time.Minute)
+
+	ticket, err := store.Issue("alice", "JWT", "orders", "incoming")
+	require.NoError(t, err)
+
+	_, ok := store.Redeem(ticket.ID, "orders", "other")
+	assert.False(t, ok, "ticket is bound to its queue")
+
+	ticket, _ = store.Issue("alice", "JWT", "orders", "incoming")
+	redeemed, ok := store.Redeem(ticket.ID, "orders", "incoming")
+	require.True(t, ok)
+	assert.Equal(t, "alice", redeemed.Subject)
+
+	_, ok = store.Redeem(ticket.ID, "orders", "incoming")
+	assert.False(t, ok, "ticket is single-use")
+
+	expired := NewWSTicketStore(-time.Second)
+	ticket, _ = expired.Issue("alice", "JWT", "orders", "incoming")
+	_, ok = expired.Redeem(ticket.ID, "orders", "incoming")
+	assert.False(t, ok)
+}
+
+func TestWebSocketAuth(t *testing.T) {
+	middleware, authService, logger := setupAuthMiddleware(true)
+	logger.On("Debug", mock.Anything, mock.Anything).Return()
+	authService.On("ValidateToken", "valid-token").Return(createTestUserModel(), nil)
+	authService.On("ValidateToken", "bad-token").Return(nil, errors.New("invalid token"))
+
+	h := &Handler{
+		logger:         logger,
+		config:         middleware.config,
+		authService:    authService,
+		authMiddleware: middleware,
+		wsTickets:      NewWSTicketStore(time.Minute),
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/api/ws/domains/{domain}/queues/{queue}", h.WebSocketAuth(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	))
+
+	ticket, err := h.wsTickets.Issue("alice", "JWT", "orders", "incoming")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		expected int
+	}{
+		{"no credentials", "/api/ws/domains/orders/queues/incoming", "", http.StatusUnauthorized},
+		{"token query param", "/api/ws/domains/orders/queues/incoming?token=valid-token", "", http.StatusOK},
+		{"bearer header", "/api/ws/domains/orders/queues/incoming", "Bearer valid-token", http.StatusOK},
+		{"invalid token", "/api/ws/domains/orders/queues/incoming?token=bad-token", "", http.StatusUnauthorized},
+		{"ticket", "/api/ws/domains/orders/queues/incoming?ticket=" + ticket.ID, "", http.StatusOK},
+		{"ticket replay", "/api/ws/domains/orders/queues/incoming?ticket=" + ticket.ID, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestAuthorizeWebSocketSubscription(t *testing.T) {
+	logger := &mockLogger2{}
+	repo := createTestRepository(t, logger)
+	service := createTestService()
+	require.NoError(t, repo.Create(context.Background(), service))
+	h := &Handler{serviceRepo: repo, wsTickets: NewWSTicketStore(time.Minute)}
+
+	// a multiplexed ticket is bound to no queue
+	ticket, err := h.wsTickets.Issue(service.ID, "HMAC", "", "")
+	require.NoError(t, err)
+	redeemed, ok := h.wsTickets.Redeem(ticket.ID, "", "")
+	require.True(t, ok)
+
+	r := httptest.NewRequest("GET", "/api/ws", nil)
+	assert.NoError(t, h.AuthorizeWebSocketSubscription(r, "payments", "incoming"), "users may subscribe to any queue")
+
+	r = r.WithContext(context.WithValue(r.Context(), wsTicketContextKey, redeemed))
+	assert.NoError(t, h.AuthorizeWebSocketSubscription(r, "payments", "incoming"))
+	assert.Error(t, h.AuthorizeWebSocketSubscription(r, "orders", "incoming"), "publish only")
+}
@@ -1,13 +1,16 @@
 package websocket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -22,6 +25,17 @@ type Handler struct {
 	connections    map[string][]*websocketConnection
 	mu             sync.RWMutex
 	rootCtx        context.Context
+	idleTimeout    time.Duration // 0 désactive la fermeture des connexions inactives
+	redaction      *model.Redaction
+	qos            QoS // limites des abonnements, resserrables par le client
+
+	// Reprise des abonnements après une reconnexion, 0 la désactive
+	resumeWindow time.Duration
+	parked       map[string]*parkedFrames // ID d'abonnement -> trames non envoyées
+
+	// Connexions multiplexées, et contrôle des abonnements qu'elles ouvrent
+	sessions  map[*multiplexSession]struct{}
+	authorize func(r *http.Request, domainName, queueName string) error
 }
 
 // websocketConnection représente une connexion WebSocket active
@@ -30,6 +44,76 @@ type websocketConnection struct {
 	domainName     string
 	queueName      string
 	subscriptionID string
+
+	// Canal d'une connexion multiplexée, qui partage alors sa connexion
+	channel string
+	session *multiplexSession
+
+	// Dernier échange avec le client (UnixNano), dans un sens ou dans l'autre
+	lastActivity atomic.Int64
+
+	// Contrôle de flux par crédits, actif dès que le client en accorde
+	flowControl atomic.Bool
+	credit      atomic.Int64 // messages que le client accepte encore
+	dropped     atomic.Int64 // messages écartés faute de crédit ou de place
+
+	// File d'envoi bornée, vidée par une seule goroutine d'écriture
+	outbox  *outbox
+	writeMu sync.Mutex // gorilla/websocket n'accepte qu'un écrivain à la fois
+}
+
+// writeJSON envoie une trame au client, en exclusion des autres écritures
+func (c *websocketConnection) writeJSON(v any) error {
+	if c.session != nil {
+		return c.session.writeJSON(v)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// reply répond au client, en précisant le canal sur une connexion multiplexée
+func (c *websocketConnection) reply(frame map[string]any) error {
+	if c.channel != "" {
+		frame["channel"] = c.channel
+	}
+	return c.writeJSON(frame)
+}
+
+// touch enregistre un échange avec le client
+func (c *websocketConnection) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+	if c.session != nil {
+		c.session.touch()
+	}
+}
+
+// idleSince indique si la connexion n'a rien échangé depuis la date donnée
+func (c *websocketConnection) idleSince(t time.Time) bool {
+	return c.lastActivity.Load() < t.UnixNano()
+}
+
+// grantCredit ajoute des crédits et active le contrôle de flux
+func (c *websocketConnection) grantCredit(count int64) {
+	c.credit.Add(count)
+	c.flowControl.Store(true)
+}
+
+// takeCredit consomme un crédit, toujours accordé sans contrôle de flux
+func (c *websocketConnection) takeCredit() bool {
+	if !c.flowControl.Load() {
+		return true
+	}
+	for {
+		credit := c.credit.Load()
+		if credit <= 0 {
+			c.dropped.Add(1)
+			return false
+		}
+		if c.credit.CompareAndSwap(credit, credit-1) {
+			return true
+		}
+	}
 }
 
 // NewHandler crée un nouveau gestionnaire WebSocket
@@ -44,11 +128,95 @@ func NewHandler(messageService inbound.MessageService, rootCtx context.Context)
 			},
 		},
 		connections: make(map[string][]*websocketConnection),
+		parked:      make(map[string]*parkedFrames),
+		sessions:    make(map[*multiplexSession]struct{}),
 		rootCtx:     rootCtx,
+		qos:         QoS{}.withDefaults(),
+	}
+}
+
+// SetQoS fixe les limites des abonnements : débit, messages non acquittés,
+// taille de la file d'envoi et politique de débordement. Les clients peuvent
+// demander des limites plus strictes, jamais plus larges.
+func (h *Handler) SetQoS(qos QoS) {
+	h.qos = qos.withDefaults()
+}
+
+// SetTimeouts borne la durée de la poignée de main et ferme les connexions
+// restées inactives plus de idleTimeout (0 désactive la fermeture)
+func (h *Handler) SetTimeouts(handshakeTimeout, idleTimeout time.Duration) {
+	h.upgrader.HandshakeTimeout = handshakeTimeout
+	h.idleTimeout = idleTimeout
+
+	if idleTimeout > 0 {
+		go h.reapIdleConnections(idleTimeout)
+	}
+}
+
+// SetRedaction masque les champs sensibles des payloads diffusés,
+// l'interface web observant les files par WebSocket
+func (h *Handler) SetRedaction(redaction *model.Redaction) {
+	h.redaction = redaction
+}
+
+// reapIdleConnections ferme périodiquement les connexions inactives
+func (h *Handler) reapIdleConnections(idleTimeout time.Duration) {
+	ticker := time.NewTicker(idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.rootCtx.Done():
+			return
+		case <-ticker.C:
+			h.closeIdleConnections(time.Now().Add(-idleTimeout))
+		}
+	}
+}
+
+// closeIdleConnections ferme les connexions sans échange depuis cutoff, la
+// boucle de lecture de chaque session se charge ensuite du nettoyage
+func (h *Handler) closeIdleConnections(cutoff time.Time) int {
+	var idle []*websocketConnection
+	var idleSessions []*multiplexSession
+	h.mu.RLock()
+	for _, connections := range h.connections {
+		for _, conn := range connections {
+			if conn.idleSince(cutoff) {
+				idle = append(idle, conn)
+			}
+		}
+	}
+	for session := range h.sessions {
+		if session.idleSince(cutoff) {
+			idleSessions = append(idleSessions, session)
+		}
 	}
+	h.mu.RUnlock()
+
+	for _, conn := range idle {
+		closeGoingAway(conn.conn)
+	}
+	for _, session := range idleSessions {
+		closeGoingAway(session.conn)
+	}
+
+	if closed := len(idle) + len(idleSessions); closed > 0 {
+		log.Printf("Closed %d idle WebSocket connections", closed)
+	}
+	return len(idle) + len(idleSessions)
+}
+
+// closeGoingAway ferme une connexion inactive. WriteControl et Close peuvent
+// être appelés en parallèle des autres écritures.
+func closeGoingAway(conn *websocket.Conn) {
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseGoingAway, "idle timeout"),
+		time.Now().Add(time.Second))
+	conn.Close()
 }
 
-// HandleConnection gère une connexion WebSocket entrante
+// HandleConnection gère une connexion WebSocket entrante, jusqu'à sa fermeture
 func (h *Handler) HandleConnection(w http.ResponseWriter, r *http.Request, domainName, queueName string) {
 	// Établir la connexion WebSocket
 	conn, err := h.upgrader.Upgrade(w, r, nil)
@@ -66,6 +234,37 @@ func (h *Handler) HandleConnection(w http.ResponseWriter, r *http.Request, domai
 		domainName: domainName,
 		queueName:  queueName,
 	}
+	wsConn.touch()
+	conn.SetPongHandler(func(string) error {
+		wsConn.touch()
+		return nil
+	})
+
+	// Crédit initial optionnel, aucun message n'est poussé avant d'en accorder
+	if raw := r.URL.Query().Get("credit"); raw != "" {
+		credit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || credit < 0 {
+			conn.WriteJSON(map[string]string{
+				"type":  "error",
+				"error": "invalid credit",
+			})
+			conn.Close()
+			return
+		}
+		wsConn.grantCredit(credit)
+	}
+
+	// Limites de l'abonnement, un client lent n'accumule pas de messages sans fin
+	qos, err := h.qos.forRequest(r.URL.Query())
+	if err != nil {
+		conn.WriteJSON(map[string]string{
+			"type":  "error",
+			"error": err.Error(),
+		})
+		conn.Close()
+		return
+	}
+	wsConn.outbox = newOutbox(qos)
 
 	// Enregistrer la connexion
 	h.mu.Lock()
@@ -75,50 +274,75 @@ func (h *Handler) HandleConnection(w http.ResponseWriter, r *http.Request, domai
 	h.connections[queueKey] = append(h.connections[queueKey], wsConn)
 	h.mu.Unlock()
 
-	// Configurer l'abonnement à la file d'attente
-	subID, err := h.messageService.SubscribeToQueue(
-		domainName,
-		queueName,
-		func(msg *model.Message) error {
-			return h.sendMessageToClient(wsConn, msg)
-		},
-	)
+	handler := func(msg *model.Message) error {
+		return h.sendMessageToClient(wsConn, msg)
+	}
 
-	if err != nil {
-		log.Printf("Error subscribing to queue: %v", err)
-		conn.Close()
-		return
+	// Reprendre l'abonnement d'une connexion précédente, sinon en créer un
+	var subID string
+	var missed int64
+	resumed := false
+	if resumeID := r.URL.Query().Get("resume"); resumeID != "" {
+		if missed, err = h.resumeSubscription(wsConn, resumeID, handler); err == nil {
+			subID, resumed = resumeID, true
+		} else {
+			log.Printf("Subscription %s not resumed: %v", resumeID, err)
+		}
+	}
+	if !resumed {
+		subID, err = h.messageService.SubscribeToQueue(domainName, queueName, handler)
+		if err != nil {
+			log.Printf("Error subscribing to queue: %v", err)
+			wsConn.outbox.close()
+			conn.Close()
+			return
+		}
 	}
 
 	// Stocker l'ID d'abonnement
 	wsConn.subscriptionID = subID
 
-	// Envoyer un message de confirmation
-	conn.WriteJSON(map[string]string{
+	// Envoyer un message de confirmation, avant les messages de l'abonnement
+	_, resumable := h.resumer()
+	wsConn.writeJSON(map[string]any{
 		"type":           "connected",
 		"subscriptionId": subID,
 		"domain":         domainName,
 		"queue":          queueName,
+		"resumable":      resumable,
+		"resumed":        resumed,
+		"missed":         missed,
+		"qos": map[string]any{
+			"rate":       wsConn.outbox.qos.Rate,
+			"maxUnacked": wsConn.outbox.qos.MaxUnacked,
+			"buffer":     wsConn.outbox.qos.Buffer,
+			"overflow":   wsConn.outbox.qos.Overflow,
+		},
 	})
+	go h.writeLoop(wsConn)
 
-	// Gérer la fermeture de la connexion
-	go h.handleWebSocketSession(wsConn)
+	// La session occupe la requête jusqu'à la fermeture, afin que les limites
+	// de connexions appliquées en amont restent tenues pendant toute sa durée
+	h.handleWebSocketSession(wsConn)
 }
 
 // handleWebSocketSession gère une session WebSocket active
 func (h *Handler) handleWebSocketSession(wsConn *websocketConnection) {
 	defer func() {
-		// Se désinscrire de la file d'attente
-		err := h.messageService.UnsubscribeFromQueue(
-			wsConn.domainName,
-			wsConn.queueName,
-			wsConn.subscriptionID,
-		)
-		if err != nil {
-			log.Printf("Error unsubscribing: %v", err)
+		// Garder l'abonnement pour une reprise, sinon se désinscrire
+		if !h.detachSubscription(wsConn) {
+			err := h.messageService.UnsubscribeFromQueue(
+				wsConn.domainName,
+				wsConn.queueName,
+				wsConn.subscriptionID,
+			)
+			if err != nil {
+				log.Printf("Error unsubscribing: %v", err)
+			}
 		}
 
-		// Fermer la connexion
+		// Fermer la connexion et libérer la goroutine d'écriture
+		wsConn.outbox.close()
 		wsConn.conn.Close()
 
 		// Supprimer la connexion de la liste
@@ -148,6 +372,7 @@ func (h *Handler) handleWebSocketSession(wsConn *websocketConnection) {
 			}
 			break
 		}
+		wsConn.touch()
 
 		// Traiter les messages du client
 		h.handleClientMessage(wsConn, messageType, data)
@@ -177,9 +402,40 @@ func (h *Handler) handleClientMessage(wsConn *websocketConnection, messageType i
 	switch msgType {
 	case "ping":
 		// Répondre à un ping
-		wsConn.conn.WriteJSON(map[string]string{
+		wsConn.reply(map[string]any{
 			"type": "pong",
 		})
+	case "credit":
+		// Accorder des crédits au serveur, les messages sans crédit sont écartés
+		count, ok := message["count"].(float64)
+		if !ok || count < 1 {
+			wsConn.reply(map[string]any{
+				"type":  "error",
+				"error": "credit count must be a positive number",
+			})
+			return
+		}
+		wsConn.grantCredit(int64(count))
+
+		wsConn.reply(map[string]any{
+			"type":    "credit",
+			"credit":  wsConn.credit.Load(),
+			"dropped": wsConn.dropped.Swap(0),
+		})
+	case "ack":
+		// Acquitter des messages reçus libère des places pour les suivants
+		var ids []string
+		if id, ok := message["id"].(string); ok {
+			ids = append(ids, id)
+		}
+		if list, ok := message["ids"].([]any); ok {
+			for _, id := range list {
+				if id, ok := id.(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+		wsConn.outbox.ack(ids...)
 	case "publish":
 		// Publier un message dans la file d'attente
 		payload, ok := message["payload"]
@@ -211,7 +467,7 @@ func (h *Handler) handleClientMessage(wsConn *websocketConnection, messageType i
 
 		if err != nil {
 			log.Printf("Error publishing message: %v", err)
-			wsConn.conn.WriteJSON(map[string]string{
+			wsConn.reply(map[string]any{
 				"type":  "error",
 				"error": err.Error(),
 			})
@@ -219,34 +475,95 @@ func (h *Handler) handleClientMessage(wsConn *websocketConnection, messageType i
 		}
 
 		// Confirmer la publication
-		wsConn.conn.WriteJSON(map[string]string{
+		wsConn.reply(map[string]any{
 			"type":      "published",
 			"messageId": msg.ID,
 		})
 	}
 }
 
-// sendMessageToClient envoie un message à un client WebSocket
-func (h *Handler) sendMessageToClient(wsConn *websocketConnection, msg *model.Message) error {
-	// Décoder le payload
-	var payload map[string]any
-	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-		payload = map[string]any{
-			"data": string(msg.Payload),
+// messageFrame est la trame envoyée aux clients. Le payload JSON y est
+// recopié tel quel, sans être décodé puis réencodé pour chaque abonné.
+type messageFrame struct {
+	Type      string            `json:"type"`
+	Channel   string            `json:"channel,omitempty"`
+	ID        string            `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Headers   map[string]string `json:"headers"`
+	Payload   json.RawMessage   `json:"payload"`
+}
+
+// newMessageFrame construit la trame d'un message, les payloads qui ne sont
+// pas des objets JSON étant enveloppés dans {"data": "..."}
+func newMessageFrame(msg *model.Message) (*messageFrame, error) {
+	payload := json.RawMessage(msg.Payload)
+	if trimmed := bytes.TrimLeft(msg.Payload, " \t\r\n"); len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		wrapped, err := json.Marshal(map[string]string{"data": string(msg.Payload)})
+		if err != nil {
+			return nil, err
 		}
+		payload = wrapped
 	}
 
-	// Créer le message à envoyer
-	message := map[string]any{
-		"type":      "message",
-		"id":        msg.ID,
-		"timestamp": msg.Timestamp,
-		"headers":   msg.Headers,
-		"payload":   payload,
+	return &messageFrame{
+		Type:      "message",
+		ID:        msg.ID,
+		Timestamp: msg.Timestamp,
+		Headers:   msg.Headers,
+		Payload:   payload,
+	}, nil
+}
+
+// sendMessageToClient dépose un message dans la file d'envoi d'un client
+// WebSocket, sans attendre qu'il soit écrit
+func (h *Handler) sendMessageToClient(wsConn *websocketConnection, msg *model.Message) error {
+	// Sans crédit le message est écarté plutôt que de bloquer la diffusion
+	if !wsConn.takeCredit() {
+		return nil
 	}
 
-	// Envoyer au client
-	return wsConn.conn.WriteJSON(message)
+	if h.redaction.Enabled() {
+		redacted := *msg
+		redacted.Payload = h.redaction.Payload(msg.Payload)
+		msg = &redacted
+	}
+
+	frame, err := newMessageFrame(msg)
+	if err != nil {
+		return err
+	}
+	frame.Channel = wsConn.channel
+
+	dropped, ok := wsConn.outbox.push(frame)
+	wsConn.dropped.Add(dropped)
+	if !ok {
+		// File pleine avec la politique disconnect, la boucle de lecture nettoie
+		log.Printf("Closing slow WebSocket subscriber on %s.%s", wsConn.domainName, wsConn.queueName)
+		wsConn.outbox.close()
+		wsConn.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "subscription overflow"),
+			time.Now().Add(time.Second))
+		wsConn.conn.Close()
+	}
+	return nil
+}
+
+// writeLoop écrit les messages de la file d'envoi au rythme permis par la
+// QoS de l'abonnement, jusqu'à la fermeture de la connexion
+func (h *Handler) writeLoop(wsConn *websocketConnection) {
+	var last time.Time
+	for wsConn.outbox.pace(&last) {
+		frame := wsConn.outbox.next()
+		if frame == nil {
+			return
+		}
+		if err := wsConn.writeJSON(frame); err != nil {
+			wsConn.outbox.close()
+			wsConn.conn.Close()
+			return
+		}
+		wsConn.touch()
+	}
 }
 
 // GenerateID génère un ID unique
@@ -270,6 +587,7 @@ func (h *Handler) Cleanup() {
 				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Server shutting down"))
 
 			// Fermer la connexion
+			conn.outbox.close()
 			conn.conn.Close()
 
 			// Se désabonner
@@ -284,5 +602,17 @@ func (h *Handler) Cleanup() {
 		delete(h.connections, queueKey)
 	}
 
+	// Les connexions multiplexées se désabonnent de chacun de leurs canaux
+	for session := range h.sessions {
+		session.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Server shutting down"),
+			time.Now().Add(time.Second))
+		session.conn.Close()
+		for _, channel := range session.takeChannels() {
+			h.closeChannel(channel)
+		}
+		delete(h.sessions, session)
+	}
+
 	log.Println("WebSocket handler cleanup complete")
 }
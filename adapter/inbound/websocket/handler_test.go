@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMessageFrame(t *testing.T) {
+	msg := &model.Message{
+		ID:        "msg-1",
+		Payload:   []byte(`{"order":"A-1","amount":12345678901234567890}`),
+		Headers:   map[string]string{"Content-Type": "application/json"},
+		Timestamp: time.Date(2025, 6, 29, 10, 30, 0, 0, time.UTC),
+	}
+
+	frame, err := newMessageFrame(msg)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(frame)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"type": "message",
+		"id": "msg-1",
+		"timestamp": "2025-06-29T10:30:00Z",
+		"headers": {"Content-Type": "application/json"},
+		"payload": {"order": "A-1", "amount": 12345678901234567890}
+	}`, string(encoded))
+
+	// payloads that are not JSON objects are wrapped as before
+	for _, payload := range []string{`plain text`, `[1,2]`, `{"broken":`} {
+		frame, err := newMessageFrame(&model.Message{ID: "msg-2", Payload: []byte(payload)})
+		require.NoError(t, err)
+		assert.JSONEq(t, mustJSON(t, map[string]string{"data": payload}), string(frame.Payload))
+	}
+}
+
+func TestWebsocketConnectionCredit(t *testing.T) {
+	conn := &websocketConnection{}
+	assert.True(t, conn.takeCredit(), "messages flow freely until the client grants credit")
+
+	conn.grantCredit(2)
+	assert.True(t, conn.takeCredit())
+	assert.True(t, conn.takeCredit())
+	assert.False(t, conn.takeCredit())
+	assert.False(t, conn.takeCredit())
+	assert.Equal(t, int64(2), conn.dropped.Load())
+
+	conn.grantCredit(1)
+	assert.True(t, conn.takeCredit())
+}
+
+func mustJSON(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// BenchmarkMessageFrame compares encoding one message for each subscriber
+// by decoding the payload (the previous frame) and by reusing its bytes
+func BenchmarkMessageFrame(b *testing.B) {
+	msg := &model.Message{
+		ID:        "msg-1",
+		Payload:   []byte(`{"order":"A-1","items":[` + strings.Repeat(`{"sku":"s","qty":1},`, 30) + `{"sku":"t","qty":2}]}`),
+		Headers:   map[string]string{"a": "b"},
+		Timestamp: time.Now(),
+	}
+
+	b.Run("decoded", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var payload map[string]any
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				b.Fatal(err)
+			}
+			json.Marshal(map[string]any{
+				"type":      "message",
+				"id":        msg.ID,
+				"timestamp": msg.Timestamp,
+				"headers":   msg.Headers,
+				"payload":   payload,
+			})
+		}
+	})
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			frame, err := newMessageFrame(msg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			json.Marshal(frame)
+		}
+	})
+}
+
+// subscribeOnlyService accepts subscriptions and never delivers
+type subscribeOnlyService struct {
+	inbound.MessageService
+}
+
+func (s *subscribeOnlyService) SubscribeToQueue(domainName, queueName string, handler model.MessageHandler) (string, error) {
+	return "sub-1", nil
+}
+
+func (s *subscribeOnlyService) UnsubscribeFromQueue(domainName, queueName, subscriptionID string) error {
+	return nil
+}
+
+func TestCloseIdleConnections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHandler(&subscribeOnlyService{}, ctx)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.HandleConnection(w, r, "orders", "incoming")
+	}))
+	defer server.Close()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var connected map[string]any
+	require.NoError(t, conn.ReadJSON(&connected))
+	assert.Equal(t, "connected", connected["type"])
+
+	assert.Equal(t, 0, h.closeIdleConnections(time.Now().Add(-time.Minute)), "recent traffic keeps the connection")
+	assert.Equal(t, 1, h.closeIdleConnections(time.Now().Add(time.Second)))
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *gorillaws.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, gorillaws.CloseGoingAway, closeErr.Code)
+	assert.Equal(t, "idle timeout", closeErr.Text)
+
+	// the session ends and releases the connection
+	assert.Eventually(t, func() bool {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return len(h.connections) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,322 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/gorilla/websocket"
+)
+
+// MaxChannels borne les abonnements d'une connexion multiplexée
+const MaxChannels = 100
+
+// multiplexSession est une connexion portant des abonnements à plusieurs
+// files, chacun identifié par le canal que le client lui a choisi
+type multiplexSession struct {
+	conn    *websocket.Conn
+	request *http.Request // identité du client, pour contrôler chaque abonnement
+
+	// Dernier échange avec le client (UnixNano), sur l'un ou l'autre canal
+	lastActivity atomic.Int64
+
+	writeMu sync.Mutex // les canaux écrivent sur la même connexion
+
+	mu       sync.Mutex
+	channels map[string]*websocketConnection
+}
+
+// writeJSON envoie une trame au client, en exclusion des autres canaux
+func (s *multiplexSession) writeJSON(v any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(v)
+}
+
+// touch enregistre un échange avec le client
+func (s *multiplexSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleSince indique si la connexion n'a rien échangé depuis la date donnée
+func (s *multiplexSession) idleSince(t time.Time) bool {
+	return s.lastActivity.Load() < t.UnixNano()
+}
+
+// channel renvoie l'abonnement ouvert sur un canal
+func (s *multiplexSession) channel(id string) *websocketConnection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channels[id]
+}
+
+// takeChannels retire tous les canaux de la session
+func (s *multiplexSession) takeChannels() []*websocketConnection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	channels := make([]*websocketConnection, 0, len(s.channels))
+	for id, channel := range s.channels {
+		channels = append(channels, channel)
+		delete(s.channels, id)
+	}
+	return channels
+}
+
+// SetSubscriptionAuthorizer contrôle les abonnements ouverts sur une
+// connexion multiplexée, qui n'est liée à aucune file lors de son ouverture
+func (h *Handler) SetSubscriptionAuthorizer(authorize func(r *http.Request, domainName, queueName string) error) {
+	h.authorize = authorize
+}
+
+// HandleMultiplexed gère une connexion sur laquelle le client ouvre et ferme
+// des abonnements à autant de files qu'il le souhaite, jusqu'à sa fermeture
+func (h *Handler) HandleMultiplexed(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading to WebSocket: %v", err)
+		return
+	}
+
+	session := &multiplexSession{
+		conn:     conn,
+		request:  r,
+		channels: make(map[string]*websocketConnection),
+	}
+	session.touch()
+	conn.SetPongHandler(func(string) error {
+		session.touch()
+		return nil
+	})
+
+	h.mu.Lock()
+	h.sessions[session] = struct{}{}
+	h.mu.Unlock()
+
+	session.writeJSON(map[string]any{
+		"type":        "connected",
+		"multiplexed": true,
+		"maxChannels": MaxChannels,
+	})
+
+	defer func() {
+		conn.Close()
+		for _, channel := range session.takeChannels() {
+			h.closeChannel(channel)
+		}
+
+		h.mu.Lock()
+		delete(h.sessions, session)
+		h.mu.Unlock()
+	}()
+
+	// Boucle de lecture des trames du client
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err,
+				websocket.CloseGoingAway,
+				websocket.CloseNormalClosure) {
+				log.Printf("WebSocket error: %v", err)
+			}
+			return
+		}
+		session.touch()
+
+		if messageType == websocket.TextMessage {
+			h.handleMultiplexedMessage(session, data)
+		}
+	}
+}
+
+// multiplexFrame est l'en-tête des trames d'une connexion multiplexée
+type multiplexFrame struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Domain  string `json:"domain"`
+	Queue   string `json:"queue"`
+
+	// Réglages de l'abonnement, comme les paramètres d'une connexion dédiée
+	Credit     *int64   `json:"credit"`
+	Rate       *float64 `json:"rate"`
+	MaxUnacked *int     `json:"maxUnacked"`
+	Buffer     *int     `json:"buffer"`
+	Overflow   string   `json:"overflow"`
+}
+
+// handleMultiplexedMessage ouvre et ferme les canaux, et transmet les autres
+// trames (credit, ack, publish) à l'abonnement de leur canal
+func (h *Handler) handleMultiplexedMessage(session *multiplexSession, data []byte) {
+	var frame multiplexFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		log.Printf("Error parsing client message: %v", err)
+		return
+	}
+
+	switch frame.Type {
+	case "subscribe":
+		h.openChannel(session, &frame)
+	case "unsubscribe":
+		session.mu.Lock()
+		channel := session.channels[frame.Channel]
+		delete(session.channels, frame.Channel)
+		session.mu.Unlock()
+
+		if channel == nil {
+			channelError(session, frame.Channel, "unknown channel")
+			return
+		}
+		h.closeChannel(channel)
+		session.writeJSON(map[string]any{
+			"type":    "unsubscribed",
+			"channel": frame.Channel,
+		})
+	case "ping":
+		if frame.Channel == "" {
+			session.writeJSON(map[string]string{"type": "pong"})
+			return
+		}
+		fallthrough
+	default:
+		channel := session.channel(frame.Channel)
+		if channel == nil {
+			channelError(session, frame.Channel, "unknown channel")
+			return
+		}
+		h.handleClientMessage(channel, websocket.TextMessage, data)
+	}
+}
+
+// openChannel abonne un canal à une file
+func (h *Handler) openChannel(session *multiplexSession, frame *multiplexFrame) {
+	if frame.Channel == "" {
+		channelError(session, "", "channel is required")
+		return
+	}
+	if frame.Domain == "" || frame.Queue == "" {
+		channelError(session, frame.Channel, "domain and queue are required")
+		return
+	}
+	if h.authorize != nil {
+		if err := h.authorize(session.request, frame.Domain, frame.Queue); err != nil {
+			channelError(session, frame.Channel, err.Error())
+			return
+		}
+	}
+
+	qos, err := h.qos.forRequest(frame.qosQuery())
+	if err != nil {
+		channelError(session, frame.Channel, err.Error())
+		return
+	}
+	if frame.Credit != nil && *frame.Credit < 0 {
+		channelError(session, frame.Channel, "invalid credit")
+		return
+	}
+
+	channel := &websocketConnection{
+		conn:       session.conn,
+		domainName: frame.Domain,
+		queueName:  frame.Queue,
+		channel:    frame.Channel,
+		session:    session,
+		outbox:     newOutbox(qos),
+	}
+	if frame.Credit != nil {
+		channel.grantCredit(*frame.Credit)
+	}
+
+	// Le canal est réservé avant l'abonnement, qui peut déjà pousser des messages
+	session.mu.Lock()
+	_, taken := session.channels[frame.Channel]
+	full := len(session.channels) >= MaxChannels
+	if !taken && !full {
+		session.channels[frame.Channel] = channel
+	}
+	session.mu.Unlock()
+	switch {
+	case taken:
+		channelError(session, frame.Channel, "channel already in use")
+		return
+	case full:
+		channelError(session, frame.Channel, fmt.Sprintf("at most %d channels per connection", MaxChannels))
+		return
+	}
+
+	subID, err := h.messageService.SubscribeToQueue(frame.Domain, frame.Queue, func(msg *model.Message) error {
+		return h.sendMessageToClient(channel, msg)
+	})
+	if err != nil {
+		session.mu.Lock()
+		delete(session.channels, frame.Channel)
+		session.mu.Unlock()
+		channel.outbox.close()
+		channelError(session, frame.Channel, err.Error())
+		return
+	}
+	channel.subscriptionID = subID
+
+	// Confirmer l'abonnement, avant les messages du canal
+	session.writeJSON(map[string]any{
+		"type":           "subscribed",
+		"channel":        frame.Channel,
+		"subscriptionId": subID,
+		"domain":         frame.Domain,
+		"queue":          frame.Queue,
+		"qos": map[string]any{
+			"rate":       qos.Rate,
+			"maxUnacked": qos.MaxUnacked,
+			"buffer":     qos.Buffer,
+			"overflow":   qos.Overflow,
+		},
+	})
+	go h.writeLoop(channel)
+}
+
+// closeChannel désabonne un canal retiré de sa session
+func (h *Handler) closeChannel(channel *websocketConnection) {
+	channel.outbox.close()
+	if channel.subscriptionID == "" {
+		return
+	}
+	if err := h.messageService.UnsubscribeFromQueue(channel.domainName, channel.queueName, channel.subscriptionID); err != nil {
+		log.Printf("Error unsubscribing: %v", err)
+	}
+}
+
+// channelError signale au client l'échec d'une trame de canal
+func channelError(session *multiplexSession, channel, message string) {
+	frame := map[string]any{
+		"type":  "error",
+		"error": message,
+	}
+	if channel != "" {
+		frame["channel"] = channel
+	}
+	session.writeJSON(frame)
+}
+
+// qosQuery présente les réglages de la trame comme les paramètres d'une
+// connexion dédiée, soumis aux mêmes limites du serveur
+func (f *multiplexFrame) qosQuery() url.Values {
+	query := url.Values{}
+	if f.Rate != nil {
+		query.Set("rate", strconv.FormatFloat(*f.Rate, 'f', -1, 64))
+	}
+	if f.MaxUnacked != nil {
+		query.Set("maxUnacked", strconv.Itoa(*f.MaxUnacked))
+	}
+	if f.Buffer != nil {
+		query.Set("buffer", strconv.Itoa(*f.Buffer))
+	}
+	if f.Overflow != "" {
+		query.Set("overflow", f.Overflow)
+	}
+	return query
+}
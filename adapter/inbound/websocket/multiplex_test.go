@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fanoutService keeps the handler of each queue subscribed to
+type fanoutService struct {
+	inbound.MessageService
+
+	mu       sync.Mutex
+	handlers map[string]model.MessageHandler // domain:queue -> handler
+}
+
+func (s *fanoutService) SubscribeToQueue(domainName, queueName string, handler model.MessageHandler) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[domainName+":"+queueName] = handler
+	return "sub-" + queueName, nil
+}
+
+func (s *fanoutService) UnsubscribeFromQueue(domainName, queueName, subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.handlers, domainName+":"+queueName)
+	return nil
+}
+
+func (s *fanoutService) deliver(queueKey string, msg *model.Message) bool {
+	s.mu.Lock()
+	handler := s.handlers[queueKey]
+	s.mu.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(msg)
+	return true
+}
+
+func TestHandleMultiplexed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &fanoutService{handlers: make(map[string]model.MessageHandler)}
+	h := NewHandler(service, ctx)
+	h.SetSubscriptionAuthorizer(func(r *http.Request, domainName, queueName string) error {
+		if domainName == "billing" {
+			return errors.New("insufficient permissions for consume:billing")
+		}
+		return nil
+	})
+	server := httptest.NewServer(http.HandlerFunc(h.HandleMultiplexed))
+	defer server.Close()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	read := func() map[string]any {
+		var frame map[string]any
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		require.NoError(t, conn.ReadJSON(&frame))
+		return frame
+	}
+
+	assert.Equal(t, true, read()["multiplexed"])
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "channel": "a", "domain": "shop", "queue": "orders"}))
+	subscribed := read()
+	assert.Equal(t, "subscribed", subscribed["type"])
+	assert.Equal(t, "a", subscribed["channel"])
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "channel": "b", "domain": "shop", "queue": "refunds"}))
+	assert.Equal(t, "subscribed", read()["type"])
+
+	// each message tells the channel it belongs to
+	require.True(t, service.deliver("shop:refunds", &model.Message{ID: "r-1", Payload: []byte(`{}`)}))
+	message := read()
+	assert.Equal(t, "message", message["type"])
+	assert.Equal(t, "b", message["channel"])
+	assert.Equal(t, "r-1", message["id"])
+
+	// channel frames are answered on their channel
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "credit", "channel": "a", "count": 5}))
+	credit := read()
+	assert.Equal(t, "credit", credit["type"])
+	assert.Equal(t, "a", credit["channel"])
+
+	for _, refused := range []map[string]any{
+		{"type": "subscribe", "channel": "a", "domain": "shop", "queue": "invoices"},
+		{"type": "subscribe", "channel": "c", "domain": "billing", "queue": "invoices"},
+		{"type": "ack", "channel": "z", "id": "r-1"},
+	} {
+		require.NoError(t, conn.WriteJSON(refused))
+		frame := read()
+		assert.Equal(t, "error", frame["type"])
+		assert.Equal(t, refused["channel"], frame["channel"])
+	}
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "unsubscribe", "channel": "b"}))
+	assert.Equal(t, "unsubscribed", read()["type"])
+	assert.False(t, service.deliver("shop:refunds", &model.Message{ID: "r-2"}))
+
+	// closing the connection ends every subscription
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		service.mu.Lock()
+		defer service.mu.Unlock()
+		return len(service.handlers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
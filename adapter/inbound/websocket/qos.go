@@ -0,0 +1,249 @@
+package websocket
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy décide du sort d'un message quand la file d'envoi d'un
+// abonnement est pleine
+type OverflowPolicy string
+
+const (
+	// OverflowDropOld écarte le plus ancien message en attente
+	OverflowDropOld OverflowPolicy = "drop-old"
+
+	// OverflowDropNew écarte le message qui arrive
+	OverflowDropNew OverflowPolicy = "drop-new"
+
+	// OverflowDisconnect ferme la connexion du client trop lent
+	OverflowDisconnect OverflowPolicy = "disconnect"
+
+	// DefaultBuffer borne la file d'envoi quand rien n'est configuré
+	DefaultBuffer = 256
+)
+
+// ParseOverflowPolicy valide une politique de débordement, drop-old par défaut
+func ParseOverflowPolicy(raw string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(raw); policy {
+	case "":
+		return OverflowDropOld, nil
+	case OverflowDropOld, OverflowDropNew, OverflowDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy: %s", raw)
+	}
+}
+
+// QoS borne ce qu'un abonnement WebSocket garde en mémoire côté serveur.
+// Les messages attendent dans une file d'envoi de Buffer places, d'où ils
+// partent au plus Rate fois par seconde tant que le client a moins de
+// MaxUnacked messages non acquittés ; 0 désactive la limite correspondante.
+type QoS struct {
+	Rate       float64
+	MaxUnacked int
+	Buffer     int
+	Overflow   OverflowPolicy
+}
+
+// withDefaults complète les valeurs absentes
+func (q QoS) withDefaults() QoS {
+	if q.Buffer <= 0 {
+		q.Buffer = DefaultBuffer
+	}
+	if q.Overflow == "" {
+		q.Overflow = OverflowDropOld
+	}
+	return q
+}
+
+// forRequest applique les réglages demandés par le client (?rate=,
+// ?maxUnacked=, ?buffer=, ?overflow=), qui ne peuvent que resserrer les
+// limites du serveur
+func (q QoS) forRequest(query url.Values) (QoS, error) {
+	if raw := query.Get("rate"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate <= 0 {
+			return q, fmt.Errorf("invalid rate")
+		}
+		q.Rate = capLimit(q.Rate, rate)
+	}
+	if raw := query.Get("maxUnacked"); raw != "" {
+		maxUnacked, err := strconv.Atoi(raw)
+		if err != nil || maxUnacked <= 0 {
+			return q, fmt.Errorf("invalid maxUnacked")
+		}
+		q.MaxUnacked = int(capLimit(float64(q.MaxUnacked), float64(maxUnacked)))
+	}
+	if raw := query.Get("buffer"); raw != "" {
+		buffer, err := strconv.Atoi(raw)
+		if err != nil || buffer <= 0 {
+			return q, fmt.Errorf("invalid buffer")
+		}
+		q.Buffer = min(q.Buffer, buffer)
+	}
+	if raw := query.Get("overflow"); raw != "" {
+		policy, err := ParseOverflowPolicy(raw)
+		if err != nil {
+			return q, err
+		}
+		q.Overflow = policy
+	}
+	return q, nil
+}
+
+// capLimit retient la plus stricte de deux limites, 0 valant l'absence de limite
+func capLimit(limit, requested float64) float64 {
+	if limit <= 0 {
+		return requested
+	}
+	return min(limit, requested)
+}
+
+// outbox est la file d'envoi bornée d'un abonnement. La diffusion y dépose
+// les trames sans jamais attendre le client, une goroutine d'écriture les
+// en retire au rythme permis.
+type outbox struct {
+	qos QoS
+
+	mu      sync.Mutex
+	pending []*messageFrame
+	unacked map[string]struct{}
+
+	wake chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// newOutbox crée la file d'envoi d'un abonnement
+func newOutbox(qos QoS) *outbox {
+	return &outbox{
+		qos:     qos.withDefaults(),
+		unacked: make(map[string]struct{}),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// push dépose une trame. Une file pleine applique la politique de
+// débordement : dropped compte les trames écartées, ok est faux quand le
+// client doit être déconnecté.
+func (o *outbox) push(frame *messageFrame) (dropped int64, ok bool) {
+	o.mu.Lock()
+	if len(o.pending) >= o.qos.Buffer {
+		switch o.qos.Overflow {
+		case OverflowDisconnect:
+			o.mu.Unlock()
+			return 0, false
+		case OverflowDropNew:
+			o.mu.Unlock()
+			return 1, true
+		default:
+			o.pending[0] = nil
+			o.pending = o.pending[1:]
+			dropped = 1
+		}
+	}
+	o.pending = append(o.pending, frame)
+	o.mu.Unlock()
+
+	o.signal()
+	return dropped, true
+}
+
+// next attend une trame envoyable, nil une fois la file fermée
+func (o *outbox) next() *messageFrame {
+	for {
+		o.mu.Lock()
+		if len(o.pending) > 0 && (o.qos.MaxUnacked == 0 || len(o.unacked) < o.qos.MaxUnacked) {
+			frame := o.pending[0]
+			o.pending[0] = nil
+			o.pending = o.pending[1:]
+			if o.qos.MaxUnacked > 0 {
+				o.unacked[frame.ID] = struct{}{}
+			}
+			o.mu.Unlock()
+			return frame
+		}
+		o.mu.Unlock()
+
+		select {
+		case <-o.wake:
+		case <-o.done:
+			return nil
+		}
+	}
+}
+
+// ack acquitte des messages envoyés et renvoie le nombre encore en attente
+// d'acquittement, les identifiants inconnus sont ignorés
+func (o *outbox) ack(ids ...string) int {
+	o.mu.Lock()
+	for _, id := range ids {
+		delete(o.unacked, id)
+	}
+	outstanding := len(o.unacked)
+	o.mu.Unlock()
+
+	o.signal()
+	return outstanding
+}
+
+// drain retire les trames qui n'ont pas encore été envoyées
+func (o *outbox) drain() []*messageFrame {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	frames := o.pending
+	o.pending = nil
+	return frames
+}
+
+// prepend place des trames devant celles en attente, sans tenir compte de
+// la taille de la file : elles tenaient déjà dans celle d'une connexion
+func (o *outbox) prepend(frames []*messageFrame) {
+	if len(frames) == 0 {
+		return
+	}
+	o.mu.Lock()
+	o.pending = append(append([]*messageFrame(nil), frames...), o.pending...)
+	o.mu.Unlock()
+
+	o.signal()
+}
+
+// signal réveille la goroutine d'écriture
+func (o *outbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close libère la goroutine d'écriture, les trames en attente sont perdues
+func (o *outbox) close() {
+	o.once.Do(func() { close(o.done) })
+}
+
+// pace attend le prochain envoi permis par le débit, faux une fois la file
+// fermée. Le débit est lissé : un envoi au plus tous les 1/Rate secondes.
+func (o *outbox) pace(last *time.Time) bool {
+	if o.qos.Rate <= 0 {
+		return true
+	}
+	interval := time.Duration(float64(time.Second) / o.qos.Rate)
+	wait := time.Until(last.Add(interval))
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-o.done:
+			return false
+		}
+	}
+	*last = time.Now()
+	return true
+}
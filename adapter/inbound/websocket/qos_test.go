@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQoSForRequest(t *testing.T) {
+	server := QoS{Rate: 100, MaxUnacked: 50, Buffer: 200}.withDefaults()
+
+	qos, err := server.forRequest(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, QoS{Rate: 100, MaxUnacked: 50, Buffer: 200, Overflow: OverflowDropOld}, qos)
+
+	qos, err = server.forRequest(url.Values{
+		"rate":       {"10"},
+		"maxUnacked": {"500"},
+		"buffer":     {"20"},
+		"overflow":   {"disconnect"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, QoS{Rate: 10, MaxUnacked: 50, Buffer: 20, Overflow: OverflowDisconnect}, qos, "clients only tighten the limits")
+
+	qos, err = QoS{}.withDefaults().forRequest(url.Values{"maxUnacked": {"5"}})
+	require.NoError(t, err)
+	assert.Equal(t, 5, qos.MaxUnacked)
+
+	for _, query := range []url.Values{
+		{"rate": {"-1"}},
+		{"maxUnacked": {"many"}},
+		{"buffer": {"0"}},
+		{"overflow": {"block"}},
+	} {
+		_, err := server.forRequest(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
+
+func TestOutboxOverflow(t *testing.T) {
+	frames := func(o *outbox) []string {
+		var ids []string
+		for _, frame := range o.pending {
+			ids = append(ids, frame.ID)
+		}
+		return ids
+	}
+
+	dropOld := newOutbox(QoS{Buffer: 2, Overflow: OverflowDropOld})
+	for _, id := range []string{"1", "2", "3"} {
+		dropOld.push(&messageFrame{ID: id})
+	}
+	assert.Equal(t, []string{"2", "3"}, frames(dropOld))
+
+	dropNew := newOutbox(QoS{Buffer: 2, Overflow: OverflowDropNew})
+	var dropped int64
+	for _, id := range []string{"1", "2", "3"} {
+		n, ok := dropNew.push(&messageFrame{ID: id})
+		assert.True(t, ok)
+		dropped += n
+	}
+	assert.Equal(t, []string{"1", "2"}, frames(dropNew))
+	assert.Equal(t, int64(1), dropped)
+
+	disconnect := newOutbox(QoS{Buffer: 1, Overflow: OverflowDisconnect})
+	_, ok := disconnect.push(&messageFrame{ID: "1"})
+	assert.True(t, ok)
+	_, ok = disconnect.push(&messageFrame{ID: "2"})
+	assert.False(t, ok)
+}
+
+func TestOutboxMaxUnacked(t *testing.T) {
+	o := newOutbox(QoS{MaxUnacked: 1})
+	defer o.close()
+	o.push(&messageFrame{ID: "1"})
+	o.push(&messageFrame{ID: "2"})
+
+	assert.Equal(t, "1", o.next().ID)
+
+	next := make(chan *messageFrame, 1)
+	go func() { next <- o.next() }()
+	select {
+	case <-next:
+		t.Fatal("a message was sent beyond the unacked limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, 0, o.ack("1"))
+	select {
+	case frame := <-next:
+		assert.Equal(t, "2", frame.ID)
+	case <-time.After(time.Second):
+		t.Fatal("the ack didn't release the next message")
+	}
+}
+
+// deliveringService hands the subscription handler to the test
+type deliveringService struct {
+	subscribeOnlyService
+	handler chan model.MessageHandler
+}
+
+func (s *deliveringService) SubscribeToQueue(domainName, queueName string, handler model.MessageHandler) (string, error) {
+	s.handler <- handler
+	return "sub-1", nil
+}
+
+func TestSlowSubscriberDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &deliveringService{handler: make(chan model.MessageHandler, 1)}
+	h := NewHandler(service, ctx)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.HandleConnection(w, r, "orders", "incoming")
+	}))
+	defer server.Close()
+
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http") + "?maxUnacked=1&buffer=2&overflow=disconnect"
+	conn, _, err := gorillaws.DefaultDialer.Dial(endpoint, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	handler := <-service.handler
+
+	// the client never acks: one message in flight, two waiting, then the overflow
+	for i := range 4 {
+		require.NoError(t, handler(&model.Message{ID: string(rune('a' + i)), Payload: []byte(`{}`)}))
+	}
+
+	var closeErr *gorillaws.CloseError
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, gorillaws.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, "subscription overflow", closeErr.Text)
+}
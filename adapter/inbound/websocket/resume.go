@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// subscriptionResumer est implémenté par les services de messages gardant
+// les abonnements des clients déconnectés pour qu'ils les reprennent
+type subscriptionResumer interface {
+	DetachFromQueue(domainName, queueName, subscriptionID string) error
+	ResumeSubscription(domainName, queueName, subscriptionID string, handler model.MessageHandler) (int64, error)
+}
+
+// parkedFrames sont les trames qu'une connexion fermée n'a pas envoyées,
+// gardées pour la reprise de son abonnement
+type parkedFrames struct {
+	domainName, queueName string
+	frames                []*messageFrame
+	since                 time.Time
+}
+
+// SetResumeWindow garde l'abonnement d'un client déconnecté pendant window,
+// le temps qu'il se reconnecte avec ?resume=<subscriptionId> (0 désactive
+// la reprise). La fenêtre doit être celle du registre des abonnements.
+func (h *Handler) SetResumeWindow(window time.Duration) {
+	h.resumeWindow = window
+}
+
+// resumer renvoie le service de messages quand il sait reprendre les
+// abonnements et que la reprise est activée
+func (h *Handler) resumer() (subscriptionResumer, bool) {
+	if h.resumeWindow <= 0 {
+		return nil, false
+	}
+	resumer, ok := h.messageService.(subscriptionResumer)
+	return resumer, ok
+}
+
+// detachSubscription garde l'abonnement d'une connexion qui se ferme, et
+// les trames qu'elle n'a pas envoyées. Faux quand la reprise n'est pas
+// possible, l'abonnement restant alors à supprimer.
+func (h *Handler) detachSubscription(wsConn *websocketConnection) bool {
+	resumer, ok := h.resumer()
+	if !ok {
+		return false
+	}
+
+	// les messages suivants sont gardés par le registre
+	if err := resumer.DetachFromQueue(wsConn.domainName, wsConn.queueName, wsConn.subscriptionID); err != nil {
+		return false
+	}
+	wsConn.outbox.close()
+	frames := wsConn.outbox.drain()
+
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, parked := range h.parked {
+		if now.Sub(parked.since) > h.resumeWindow {
+			delete(h.parked, id)
+		}
+	}
+	if len(frames) > 0 {
+		h.parked[wsConn.subscriptionID] = &parkedFrames{
+			domainName: wsConn.domainName,
+			queueName:  wsConn.queueName,
+			frames:     frames,
+			since:      now,
+		}
+	}
+	return true
+}
+
+// resumeSubscription reprend un abonnement pour une nouvelle connexion : les
+// trames non envoyées par la précédente partent d'abord, puis les messages
+// gardés par le registre. missed compte les messages perdus faute de place.
+func (h *Handler) resumeSubscription(wsConn *websocketConnection, subscriptionID string, handler model.MessageHandler) (missed int64, err error) {
+	resumer, ok := h.resumer()
+	if !ok {
+		return 0, model.ErrSubscriptionNotResumable
+	}
+
+	missed, err = resumer.ResumeSubscription(wsConn.domainName, wsConn.queueName, subscriptionID, handler)
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	parked := h.parked[subscriptionID]
+	delete(h.parked, subscriptionID)
+	h.mu.Unlock()
+
+	// le registre n'a pu garder que les messages arrivés après
+	if parked != nil && parked.domainName == wsConn.domainName && parked.queueName == wsConn.queueName {
+		wsConn.outbox.prepend(parked.frames)
+	}
+	return missed, nil
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resumingService keeps its single subscription when detached, a resume
+// handing over the messages published meanwhile
+type resumingService struct {
+	inbound.MessageService
+
+	mu       sync.Mutex
+	handler  chan model.MessageHandler
+	detached bool
+	backlog  []*model.Message
+}
+
+func (s *resumingService) SubscribeToQueue(domainName, queueName string, handler model.MessageHandler) (string, error) {
+	s.handler <- handler
+	return "sub-1", nil
+}
+
+func (s *resumingService) UnsubscribeFromQueue(domainName, queueName, subscriptionID string) error {
+	return nil
+}
+
+func (s *resumingService) DetachFromQueue(domainName, queueName, subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detached = true
+	return nil
+}
+
+func (s *resumingService) ResumeSubscription(domainName, queueName, subscriptionID string, handler model.MessageHandler) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.detached || subscriptionID != "sub-1" {
+		return 0, model.ErrSubscriptionNotResumable
+	}
+	s.detached = false
+	for _, msg := range s.backlog {
+		handler(msg)
+	}
+	return 3, nil
+}
+
+func (s *resumingService) isDetached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.detached
+}
+
+func TestResumeSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &resumingService{
+		handler: make(chan model.MessageHandler, 1),
+		backlog: []*model.Message{{ID: "d", Payload: []byte(`{}`)}},
+	}
+	h := NewHandler(service, ctx)
+	h.SetResumeWindow(time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.HandleConnection(w, r, "orders", "incoming")
+	}))
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// one message in flight, the next two still waiting when the client leaves
+	conn, _, err := gorillaws.DefaultDialer.Dial(endpoint+"?maxUnacked=1", nil)
+	require.NoError(t, err)
+	var connected map[string]any
+	require.NoError(t, conn.ReadJSON(&connected))
+	assert.Equal(t, true, connected["resumable"])
+	assert.Equal(t, false, connected["resumed"])
+
+	handler := <-service.handler
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, handler(&model.Message{ID: id, Payload: []byte(`{}`)}))
+	}
+	var frame messageFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, "a", frame.ID)
+	conn.Close()
+	require.Eventually(t, service.isDetached, time.Second, 10*time.Millisecond)
+
+	// the unsent messages come first, then those kept meanwhile
+	conn, _, err = gorillaws.DefaultDialer.Dial(endpoint+"?resume=sub-1", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	connected = nil
+	require.NoError(t, conn.ReadJSON(&connected))
+	assert.Equal(t, "sub-1", connected["subscriptionId"])
+	assert.Equal(t, true, connected["resumed"])
+	assert.Equal(t, float64(3), connected["missed"])
+
+	for _, id := range []string{"b", "c", "d"} {
+		require.NoError(t, conn.ReadJSON(&frame))
+		assert.Equal(t, id, frame.ID)
+	}
+
+	h.mu.RLock()
+	assert.Empty(t, h.parked)
+	h.mu.RUnlock()
+}
+
+func TestResumeUnknownSubscriptionSubscribes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &resumingService{handler: make(chan model.MessageHandler, 1)}
+	h := NewHandler(service, ctx)
+	h.SetResumeWindow(time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.HandleConnection(w, r, "orders", "incoming")
+	}))
+	defer server.Close()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?resume=sub-9", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var connected map[string]any
+	require.NoError(t, conn.ReadJSON(&connected))
+	assert.Equal(t, "sub-1", connected["subscriptionId"], "a fresh subscription")
+	assert.Equal(t, false, connected["resumed"])
+	<-service.handler
+}
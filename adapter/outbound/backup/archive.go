@@ -0,0 +1,203 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"golang.org/x/crypto/argon2"
+)
+
+// magic opens every backup file, followed by the format version
+const (
+	magic         = "GORTMSBK"
+	formatVersion = 1
+	saltSize      = 16
+)
+
+var (
+	ErrNotBackup         = errors.New("not a GoRTMS backup")
+	ErrUnsupportedFormat = errors.New("unsupported backup format")
+	ErrWrongPassphrase   = errors.New("wrong passphrase or corrupted backup")
+)
+
+// Write encrypts the backup with a key derived from passphrase: a gzipped
+// tar holding the manifest, the data files and one archive per queue,
+// sealed with AES-256-GCM
+func Write(w io.Writer, b *Backup, passphrase string) error {
+	if passphrase == "" {
+		return ErrNoPassphrase
+	}
+
+	var plain bytes.Buffer
+	if err := writeTar(&plain, b); err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, len(magic)+1+saltSize)
+	header = append(header, magic...)
+	header = append(header, formatVersion)
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	header = append(header, salt...)
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	// the header is authenticated along with the content
+	sealed := gcm.Seal(nil, nonce, plain.Bytes(), header)
+	for _, part := range [][]byte{header, nonce, sealed} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read decrypts a backup written by Write
+func Read(r io.Reader, passphrase string) (*Backup, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(magic) + 1 + saltSize
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, ErrNotBackup
+	}
+	if data[len(magic)] != formatVersion {
+		return nil, ErrUnsupportedFormat
+	}
+	header, salt := data[:headerSize], data[len(magic)+1:headerSize]
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[headerSize:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, ErrNotBackup
+	}
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return readTar(bytes.NewReader(plain))
+}
+
+// newGCM derives the backup key with Argon2id, as user passwords are hashed
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func writeTar(w io.Writer, b *Backup) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	add := func(name string, content []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: b.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add(manifestEntry, manifest); err != nil {
+		return err
+	}
+	for _, name := range b.Manifest.Files {
+		if err := add(path.Join(filesDir, name), b.Files[name]); err != nil {
+			return err
+		}
+	}
+	for _, archive := range b.Queues {
+		content, err := json.Marshal(archive)
+		if err != nil {
+			return err
+		}
+		if err := add(queueEntry(archive.Domain, archive.Queue), content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// queueEntry names the archive of a queue inside the backup
+func queueEntry(domainName, queueName string) string {
+	return path.Join(queuesDir, domainName, queueName+".json")
+}
+
+func readTar(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	b := &Backup{Files: make(map[string][]byte)}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case header.Name == manifestEntry:
+			if err := json.Unmarshal(content, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+		case strings.HasPrefix(header.Name, filesDir+"/"):
+			b.Files[strings.TrimPrefix(header.Name, filesDir+"/")] = content
+		case strings.HasPrefix(header.Name, queuesDir+"/"):
+			var archive model.QueueArchive
+			if err := json.Unmarshal(content, &archive); err != nil {
+				return nil, fmt.Errorf("invalid queue archive %s: %w", header.Name, err)
+			}
+			b.Queues = append(b.Queues, &archive)
+		}
+	}
+	return b, nil
+}
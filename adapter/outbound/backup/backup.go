@@ -0,0 +1,282 @@
+// Package backup snapshots a GoRTMS node into an encrypted file and
+// restores it: the files of the data directory (users, service accounts,
+// account requests, offloaded payloads) and, read from the running server,
+// its domains and the messages of every queue.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+const (
+	manifestEntry = "manifest.json"
+	filesDir      = "data"
+	queuesDir     = "queues"
+
+	// stableReadAttempts bounds the reads of a file being written meanwhile
+	stableReadAttempts = 5
+	stableReadDelay    = 50 * time.Millisecond
+
+	filePrefix = "gortms-"
+	fileSuffix = ".bak"
+	timeLayout = "20060102T150405Z"
+)
+
+var (
+	ErrNoPassphrase = errors.New("a backup passphrase is required")
+	ErrNoBackup     = errors.New("no backup at or before the requested time")
+	ErrUnstableFile = errors.New("file kept changing while being copied")
+	ErrDataDirInUse = errors.New("data directory already holds files, restore with force to overwrite them")
+	ErrUnsafeEntry  = errors.New("backup entry escapes the data directory")
+)
+
+// Manifest describes what a backup holds
+type Manifest struct {
+	CreatedAt time.Time                `json:"createdAt"`
+	NodeID    string                   `json:"nodeId,omitempty"`
+	Files     []string                 `json:"files"` // relative to the data directory
+	Domains   []model.ReplicatedDomain `json:"domains,omitempty"`
+}
+
+// Backup is the decrypted content of a backup file
+type Backup struct {
+	Manifest Manifest
+	Files    map[string][]byte
+	Queues   []*model.QueueArchive // one per queue, partitions merged
+}
+
+// CollectFiles copies the files of dataDir, skipping the paths under
+// exclude. A file rewritten during the copy is read again, so each file is
+// copied as one consistent version.
+func CollectFiles(b *Backup, dataDir string, exclude ...string) error {
+	if b.Files == nil {
+		b.Files = make(map[string][]byte)
+	}
+	excluded := make([]string, 0, len(exclude))
+	for _, dir := range exclude {
+		if abs, err := filepath.Abs(dir); err == nil {
+			excluded = append(excluded, abs)
+		}
+	}
+
+	err := filepath.WalkDir(dataDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		for _, dir := range excluded {
+			if abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator)) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := readStable(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		b.Files[name] = content
+		b.Manifest.Files = append(b.Manifest.Files, name)
+		return nil
+	})
+	sort.Strings(b.Manifest.Files)
+	return err
+}
+
+// readStable reads a file until its size and modification time are the
+// same before and after the read
+func readStable(path string) ([]byte, error) {
+	for attempt := 0; attempt < stableReadAttempts; attempt++ {
+		before, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		after, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if before.Size() == after.Size() && before.ModTime().Equal(after.ModTime()) && int64(len(content)) == after.Size() {
+			return content, nil
+		}
+		time.Sleep(stableReadDelay)
+	}
+	return nil, ErrUnstableFile
+}
+
+// CollectQueues reads the domains of a running server and the messages of
+// each queue, batchSize at a time. Messages published during the copy may
+// or may not be part of it.
+func CollectQueues(ctx context.Context, b *Backup, source outbound.ReplicationSource, batchSize int) error {
+	domains, err := source.Domains(ctx)
+	if err != nil {
+		return err
+	}
+	b.Manifest.Domains = domains
+
+	for _, domain := range domains {
+		queueNames := make([]string, 0, len(domain.Queues))
+		for name := range domain.Queues {
+			queueNames = append(queueNames, name)
+		}
+		slices.Sort(queueNames)
+
+		for _, queueName := range queueNames {
+			queue := &model.Queue{Name: queueName, DomainName: domain.Name, Config: domain.Queues[queueName]}
+			archive := &model.QueueArchive{
+				Version:   model.QueueArchiveVersion,
+				Domain:    domain.Name,
+				Queue:     queueName,
+				Config:    queue.Config,
+				CreatedAt: b.Manifest.CreatedAt,
+				Messages:  make([]*model.ArchivedMessage, 0),
+			}
+			for _, storageQueue := range queue.StorageQueues() {
+				messages, err := pullAll(ctx, source, domain.Name, storageQueue, batchSize)
+				if err != nil {
+					return fmt.Errorf("%s/%s: %w", domain.Name, storageQueue, err)
+				}
+				archive.Messages = append(archive.Messages, messages...)
+			}
+			archive.Count = len(archive.Messages)
+			b.Queues = append(b.Queues, archive)
+		}
+	}
+	return nil
+}
+
+func pullAll(ctx context.Context, source outbound.ReplicationSource, domainName, storageQueue string, batchSize int) ([]*model.ArchivedMessage, error) {
+	if batchSize <= 0 {
+		batchSize = model.DefaultReplicationBatchSize
+	}
+	var messages []*model.ArchivedMessage
+	next := int64(0)
+	for {
+		batch, err := source.Messages(ctx, domainName, storageQueue, next, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, batch.Messages...)
+		if len(batch.Messages) < batchSize || batch.Next <= next {
+			return messages, nil
+		}
+		next = batch.Next
+	}
+}
+
+// FileName names a backup after the time it was taken
+func FileName(createdAt time.Time) string {
+	return filePrefix + createdAt.UTC().Format(timeLayout) + fileSuffix
+}
+
+// List returns the backup files of dir and when they were taken, oldest first
+func List(dir string) ([]string, []time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	var times []time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		taken, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+		times = append(times, taken)
+	}
+	// names sort as their times
+	return names, times, nil
+}
+
+// Select returns the latest backup of dir taken at or before at, the
+// latest one when at is zero
+func Select(dir string, at time.Time) (string, error) {
+	names, times, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		if at.IsZero() || !times[i].After(at) {
+			return names[i], nil
+		}
+	}
+	return "", ErrNoBackup
+}
+
+// Prune deletes the oldest backups of dir, keeping the keep latest ones
+func Prune(dir string, keep int) ([]string, error) {
+	names, _, err := List(dir)
+	if err != nil || keep <= 0 || len(names) <= keep {
+		return nil, err
+	}
+	removed := names[:len(names)-keep]
+	for _, name := range removed {
+		if err := os.Remove(name); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// RestoreFiles writes the files of the backup back into dataDir. The server
+// must be stopped, it would otherwise overwrite them. Without force, a data
+// directory already holding one of the files is left untouched.
+func RestoreFiles(b *Backup, dataDir string, force bool) error {
+	for _, name := range b.Manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("%s: %w", name, ErrUnsafeEntry)
+		}
+		if _, err := os.Stat(filepath.Join(dataDir, filepath.FromSlash(name))); err == nil && !force {
+			return ErrDataDirInUse
+		}
+	}
+
+	for _, name := range b.Manifest.Files {
+		target := filepath.Join(dataDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		// written aside then renamed, a file is never left half restored
+		tmp := target + ".restore"
+		if err := os.WriteFile(tmp, b.Files[name], 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource serves a partitioned queue, one message per partition
+type fakeSource struct{}
+
+func (fakeSource) Domains(ctx context.Context) ([]model.ReplicatedDomain, error) {
+	return []model.ReplicatedDomain{{
+		Name:   "orders",
+		Queues: map[string]model.QueueConfig{"incoming": {Partitions: 2}},
+	}}, nil
+}
+
+func (fakeSource) Messages(ctx context.Context, domainName, storageQueue string, after int64, limit int) (*model.ReplicationBatch, error) {
+	if after > 0 {
+		return &model.ReplicationBatch{Next: after}, nil
+	}
+	return &model.ReplicationBatch{
+		Messages: []*model.ArchivedMessage{{ID: storageQueue, Payload: []byte(`{}`)}},
+		Next:     1,
+	}, nil
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "users.db"), []byte("users"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "blobs", "orders"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "blobs", "orders", "b1"), []byte("blob"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "backups"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "backups", FileName(time.Now())), []byte("old"), 0600))
+
+	b := &Backup{Manifest: Manifest{CreatedAt: time.Now().UTC()}}
+	require.NoError(t, CollectFiles(b, dataDir, filepath.Join(dataDir, "backups")))
+	require.NoError(t, CollectQueues(context.Background(), b, fakeSource{}, 10))
+	assert.Equal(t, []string{"blobs/orders/b1", "users.db"}, b.Manifest.Files)
+	require.Len(t, b.Queues, 1)
+	assert.Equal(t, 2, b.Queues[0].Count)
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, b, "correct horse"))
+	assert.NotContains(t, out.String(), "users")
+
+	_, err := Read(bytes.NewReader(out.Bytes()), "wrong")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	restored, err := Read(bytes.NewReader(out.Bytes()), "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blob"), restored.Files["blobs/orders/b1"])
+	assert.Equal(t, "orders", restored.Manifest.Domains[0].Name)
+	assert.Equal(t, "incoming", restored.Queues[0].Queue)
+
+	// Files go back into an empty data directory, or over it with force
+	target := t.TempDir()
+	require.NoError(t, RestoreFiles(restored, target, false))
+	content, err := os.ReadFile(filepath.Join(target, "blobs", "orders", "b1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blob"), content)
+	assert.ErrorIs(t, RestoreFiles(restored, target, false), ErrDataDirInUse)
+	assert.NoError(t, RestoreFiles(restored, target, true))
+}
+
+func TestSelectBackup(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for hour := 0; hour < 3; hour++ {
+		name := filepath.Join(dir, FileName(base.Add(time.Duration(hour)*time.Hour)))
+		require.NoError(t, os.WriteFile(name, nil, 0600))
+	}
+
+	latest, err := Select(dir, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, FileName(base.Add(2*time.Hour)), filepath.Base(latest))
+
+	// Point in time: the last backup taken before it
+	atTime, err := Select(dir, base.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, FileName(base.Add(time.Hour)), filepath.Base(atTime))
+
+	_, err = Select(dir, base.Add(-time.Minute))
+	assert.ErrorIs(t, err, ErrNoBackup)
+
+	removed, err := Prune(dir, 1)
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+	names, _, err := List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{latest}, names)
+}
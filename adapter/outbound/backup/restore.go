@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// RESTRestorer puts the domains and messages of a backup back into a
+// running server, through its REST API with an admin token
+type RESTRestorer struct {
+	BaseURL string
+	Token   string
+
+	Client *http.Client
+}
+
+// Restore creates the missing domains of the backup, then restores each
+// queue. Messages already in a queue are skipped, a restore can be repeated.
+func (r *RESTRestorer) Restore(ctx context.Context, b *Backup) ([]*model.QueueRestoreResult, error) {
+	for _, domain := range b.Manifest.Domains {
+		exists, err := r.domainExists(ctx, domain.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+
+		// DomainConfig carries a validation func, it is sent field by field
+		config := map[string]any{
+			"Name":                domain.Name,
+			"QueueConfigs":        domain.Queues,
+			"AllowedRouteSources": domain.AllowedRouteSources,
+			"Labels":              domain.Labels,
+		}
+		if len(domain.Fields) > 0 {
+			config["Schema"] = map[string]any{"Fields": domain.Fields}
+		}
+		if err := r.do(ctx, http.MethodPost, "/api/domains", config, nil); err != nil {
+			return nil, fmt.Errorf("domain %s: %w", domain.Name, err)
+		}
+	}
+
+	results := make([]*model.QueueRestoreResult, 0, len(b.Queues))
+	for _, archive := range b.Queues {
+		path := "/api/domains/" + url.PathEscape(archive.Domain) + "/queues/" + url.PathEscape(archive.Queue) + "/restore"
+		var response struct {
+			Result *model.QueueRestoreResult `json:"result"`
+		}
+		if err := r.do(ctx, http.MethodPost, path, archive, &response); err != nil {
+			return results, fmt.Errorf("queue %s/%s: %w", archive.Domain, archive.Queue, err)
+		}
+		results = append(results, response.Result)
+	}
+	return results, nil
+}
+
+func (r *RESTRestorer) domainExists(ctx context.Context, domainName string) (bool, error) {
+	err := r.do(ctx, http.MethodGet, "/api/domains/"+url.PathEscape(domainName), nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// statusError is an answer of the server other than a success
+type statusError struct {
+	code    int
+	status  string
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("gortms: %s %s", e.status, e.message)
+}
+
+func (r *RESTRestorer) do(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.BaseURL, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &statusError{code: resp.StatusCode, status: resp.Status, message: strings.TrimSpace(string(message))}
+	}
+	if result == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
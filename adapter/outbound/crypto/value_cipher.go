@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// Keys encrypting the configuration values, named in the ciphertext
+const (
+	ValueKeyMaster  = "master"
+	ValueKeyMachine = "machine"
+)
+
+var ErrValueKeyUnavailable = errors.New("key unavailable")
+
+// ValueCipher encrypts the secrets of the configuration file, under the
+// master key shared by the nodes of a deployment or under the key of the
+// machine, the ciphertext naming the key: master:<base64> or
+// machine:<base64>, nonce then AES-GCM sealed value.
+type ValueCipher struct {
+	crypto    outbound.CryptoService
+	masterKey string
+	machineID outbound.MachineIDService
+}
+
+// NewValueCipher builds the cipher of the configuration values, masterKey
+// being empty and machineID nil when the key isn't available
+func NewValueCipher(crypto outbound.CryptoService, masterKey string, machineID outbound.MachineIDService) *ValueCipher {
+	return &ValueCipher{
+		crypto:    crypto,
+		masterKey: masterKey,
+		machineID: machineID,
+	}
+}
+
+// key derives the named key, apart from the keys of the storage and of the
+// encrypted fields
+func (c *ValueCipher) key(name string) ([32]byte, error) {
+	switch name {
+	case ValueKeyMaster:
+		if c.masterKey == "" {
+			return [32]byte{}, fmt.Errorf("%w: master key not set", ErrValueKeyUnavailable)
+		}
+		return c.crypto.DeriveKey(c.masterKey + "/config"), nil
+	case ValueKeyMachine:
+		if c.machineID == nil {
+			return [32]byte{}, fmt.Errorf("%w: machine key", ErrValueKeyUnavailable)
+		}
+		id, err := c.machineID.GetMachineID()
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("%w: %w", ErrValueKeyUnavailable, err)
+		}
+		return c.crypto.DeriveKey(id + "/config"), nil
+	}
+	return [32]byte{}, fmt.Errorf("unknown key %q", name)
+}
+
+// Encrypt seals value under the named key
+func (c *ValueCipher) Encrypt(keyName, value string) (string, error) {
+	key, err := c.key(keyName)
+	if err != nil {
+		return "", err
+	}
+	encrypted, nonce, err := c.crypto.Encrypt([]byte(value), key)
+	if err != nil {
+		return "", err
+	}
+	return keyName + ":" + base64.StdEncoding.EncodeToString(append(nonce, encrypted...)), nil
+}
+
+// Decrypt opens a ciphertext built by Encrypt
+func (c *ValueCipher) Decrypt(ciphertext string) (string, error) {
+	keyName, encoded, found := strings.Cut(ciphertext, ":")
+	if !found {
+		return "", errors.New("malformed encrypted value, expected <key>:<base64>")
+	}
+	key, err := c.key(keyName)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	// nonces are the size of the AES-GCM standard ones
+	const nonceSize = 12
+	if len(sealed) < nonceSize {
+		return "", errors.New("malformed encrypted value, too short")
+	}
+	plaintext, err := c.crypto.Decrypt(sealed[nonceSize:], sealed[:nonceSize], key)
+	if err != nil {
+		return "", fmt.Errorf("wrong %s key or tampered value", keyName)
+	}
+	return string(plaintext), nil
+}
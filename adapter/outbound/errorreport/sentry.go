@@ -0,0 +1,234 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// queueSize bounds the reports waiting for Sentry, a panic storm drops the
+// reports past it rather than piling them up
+const queueSize = 100
+
+const sentryClient = "gortms/1.0"
+
+// SentryReporter sends error reports to the store endpoint of a Sentry
+// project, from a background worker. With a sample rate below 1, reports
+// are dropped at random before being queued.
+type SentryReporter struct {
+	logger      outbound.Logger
+	endpoint    string
+	auth        string
+	environment string
+	serverName  string
+	sampleRate  float64
+	sample      func() float64
+	client      *http.Client
+
+	queue chan model.ErrorReport
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// sentryDSN is the parsed https://<key>@<host>[/<path>]/<project> DSN
+type sentryDSN struct {
+	key      string
+	endpoint string
+}
+
+// parseSentryDSN checks a DSN and returns its store endpoint
+func parseSentryDSN(dsn string) (sentryDSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return sentryDSN{}, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return sentryDSN{}, fmt.Errorf("invalid sentry dsn: an http(s) url is required")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return sentryDSN{}, fmt.Errorf("invalid sentry dsn: the public key is missing")
+	}
+	path, project := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		path, project = "/"+project[:i], project[i+1:]
+	}
+	if project == "" {
+		return sentryDSN{}, fmt.Errorf("invalid sentry dsn: the project id is missing")
+	}
+
+	return sentryDSN{
+		key:      u.User.Username(),
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path, project),
+	}, nil
+}
+
+// NewSentryReporter starts the delivery worker of a Sentry project
+func NewSentryReporter(cfg config.ErrorReportingConfig, serverName string, logger outbound.Logger) (*SentryReporter, error) {
+	dsn, err := parseSentryDSN(cfg.SentryDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &SentryReporter{
+		logger:      logger,
+		endpoint:    dsn.endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, dsn.key),
+		environment: cfg.Environment,
+		serverName:  serverName,
+		sampleRate:  cfg.SampleRate,
+		sample:      mathrand.Float64,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan model.ErrorReport, queueSize),
+	}
+	r.wg.Add(1)
+	go r.deliver()
+	return r, nil
+}
+
+// Report queues a sampled report, it is dropped when the queue is full
+func (r *SentryReporter) Report(ctx context.Context, report model.ErrorReport) {
+	if r.sampleRate > 0 && r.sampleRate < 1 && r.sample() >= r.sampleRate {
+		return
+	}
+	select {
+	case r.queue <- report:
+	default:
+		r.logger.Warn("Error report dropped, queue full", "kind", report.Kind)
+	}
+}
+
+// Close sends the queued reports and stops the worker
+func (r *SentryReporter) Close() {
+	r.once.Do(func() {
+		close(r.queue)
+	})
+	r.wg.Wait()
+}
+
+func (r *SentryReporter) deliver() {
+	defer r.wg.Done()
+
+	for report := range r.queue {
+		if err := r.send(report); err != nil {
+			r.logger.Error("Error report not sent",
+				"kind", report.Kind,
+				"ERROR", err)
+		}
+	}
+}
+
+// sentryEvent is the subset of the Sentry event payload the reports fill
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message"`
+	Exception   sentryExceptions  `json:"exception"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]string `json:"extra"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+func (r *SentryReporter) event(report model.ErrorReport) sentryEvent {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   report.Time.UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      "gortms",
+		ServerName:  r.serverName,
+		Environment: r.environment,
+		Message:     report.Kind + ": " + report.Message,
+		Exception: sentryExceptions{Values: []sentryException{
+			{Type: report.Kind, Value: report.Message},
+		}},
+		Tags:  map[string]string{"kind": report.Kind},
+		Extra: map[string]string{},
+	}
+	if report.Method != "" {
+		event.Request = &sentryRequest{Method: report.Method, URL: report.Path}
+	}
+
+	// empty values are left out, Sentry would index them
+	for tag, value := range map[string]string{
+		"request_id": report.RequestID,
+		"listener":   report.Listener,
+		"domain":     report.Domain,
+		"queue":      report.Queue,
+		"service_id": report.ServiceID,
+	} {
+		if value != "" {
+			event.Tags[tag] = value
+		}
+	}
+	if report.MessageID != "" {
+		event.Extra["message_id"] = report.MessageID
+	}
+	if report.Stack != "" {
+		event.Extra["stack"] = report.Stack
+	}
+	return event
+}
+
+// send posts a report once, the worker doesn't retry: the failure is
+// already in the logs
+func (r *SentryReporter) send(report model.ErrorReport) error {
+	body, err := json.Marshal(r.event(report))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry refused the report: %s", resp.Status)
+	}
+	return nil
+}
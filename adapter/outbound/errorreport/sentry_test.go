@@ -0,0 +1,99 @@
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(logLvl string)     {}
+func (nopLogger) Shutdown()                     {}
+
+func TestParseSentryDSN(t *testing.T) {
+	dsn, err := parseSentryDSN("https://abc@o1.ingest.sentry.io/42")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", dsn.key)
+	assert.Equal(t, "https://o1.ingest.sentry.io/api/42/store/", dsn.endpoint)
+
+	dsn, err = parseSentryDSN("http://abc@sentry.internal:9000/prefix/7")
+	require.NoError(t, err)
+	assert.Equal(t, "http://sentry.internal:9000/prefix/api/7/store/", dsn.endpoint)
+
+	for _, invalid := range []string{"", "https://o1.ingest.sentry.io/42", "https://abc@o1.ingest.sentry.io/", "ftp://abc@host/1"} {
+		_, err := parseSentryDSN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	events := make(chan map[string]any, 1)
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/42/store/", r.URL.Path)
+		auth = r.Header.Get("X-Sentry-Auth")
+
+		var event map[string]any
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "://", "://abc@", 1) + "/42"
+	reporter, err := NewSentryReporter(config.ErrorReportingConfig{SentryDSN: dsn, Environment: "staging"}, "node-1", nopLogger{})
+	require.NoError(t, err)
+
+	reporter.Report(context.Background(), model.ErrorReport{
+		Kind:      model.ErrorKindPanic,
+		RequestID: "req-1",
+		Listener:  "public",
+		Method:    "POST",
+		Path:      "/api/domains/orders/queues/pending/messages",
+		Message:   "runtime error: index out of range [3] with length 3",
+		Stack:     "goroutine 1 [running]:",
+		Time:      time.Now(),
+	})
+	reporter.Close()
+
+	event := <-events
+	assert.Contains(t, auth, "sentry_key=abc")
+	assert.Equal(t, "staging", event["environment"])
+	assert.Equal(t, "node-1", event["server_name"])
+	assert.Equal(t, "req-1", event["tags"].(map[string]any)["request_id"])
+	assert.Equal(t, "goroutine 1 [running]:", event["extra"].(map[string]any)["stack"])
+	assert.Equal(t, "panic", event["tags"].(map[string]any)["kind"])
+	assert.NotContains(t, event["tags"], "service_id")
+	assert.Len(t, event["event_id"], 32)
+}
+
+func TestSentryReporterSampling(t *testing.T) {
+	draws := []float64{0.1, 0.25, 0.9, 0.2}
+	reporter := &SentryReporter{
+		logger:     nopLogger{},
+		sampleRate: 0.25,
+		sample: func() float64 {
+			draw := draws[0]
+			draws = draws[1:]
+			return draw
+		},
+		queue: make(chan model.ErrorReport, 10),
+	}
+	for range 4 {
+		reporter.Report(context.Background(), model.ErrorReport{Kind: model.ErrorKindDelivery})
+	}
+	assert.Len(t, reporter.queue, 2)
+}
@@ -0,0 +1,153 @@
+package eventbus
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// DefaultSubscriberBuffer is the number of events a subscriber can lag behind
+const DefaultSubscriberBuffer = 1024
+
+type subscriber struct {
+	name    string
+	kinds   []model.EventKind
+	handler model.EventHandler
+	events  chan model.DomainEvent
+	dropped atomic.Int64
+	done    chan struct{}
+}
+
+func (s *subscriber) wants(kind model.EventKind) bool {
+	return len(s.kinds) == 0 || slices.Contains(s.kinds, kind)
+}
+
+// MemoryEventBus delivers events in process, each subscriber on its own
+// goroutine so a slow one never delays the publisher or the others
+type MemoryEventBus struct {
+	logger      outbound.Logger
+	bufferSize  int
+	subscribers map[*subscriber]struct{}
+	closed      bool
+	mu          sync.RWMutex
+}
+
+func NewMemoryEventBus(logger outbound.Logger, bufferSize int) outbound.EventBus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultSubscriberBuffer
+	}
+	return &MemoryEventBus{
+		logger:      logger,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (b *MemoryEventBus) Publish(event model.DomainEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	for sub := range b.subscribers {
+		if !sub.wants(event.Kind) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			if dropped := sub.dropped.Add(1); dropped%1000 == 1 {
+				b.logger.Warn("Event subscriber lagging, events dropped",
+					"subscriber", sub.name,
+					"kind", string(event.Kind),
+					"dropped", dropped)
+			}
+		}
+	}
+}
+
+func (b *MemoryEventBus) Subscribe(name string, handler model.EventHandler, kinds ...model.EventKind) func() {
+	sub := &subscriber{
+		name:    name,
+		kinds:   kinds,
+		handler: handler,
+		events:  make(chan model.DomainEvent, b.bufferSize),
+		done:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go b.run(sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			if _, exists := b.subscribers[sub]; exists {
+				delete(b.subscribers, sub)
+				close(sub.events)
+			}
+			b.mu.Unlock()
+			<-sub.done
+		})
+	}
+}
+
+func (b *MemoryEventBus) run(sub *subscriber) {
+	defer close(sub.done)
+
+	for event := range sub.events {
+		b.handle(sub, event)
+	}
+}
+
+// a failing subscriber must not take the others down
+func (b *MemoryEventBus) handle(sub *subscriber, event model.DomainEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Event subscriber panicked",
+				"subscriber", sub.name,
+				"kind", string(event.Kind),
+				"ERROR", r)
+		}
+	}()
+	sub.handler(event)
+}
+
+func (b *MemoryEventBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+
+	subs := make([]*subscriber, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		close(sub.events)
+		subs = append(subs, sub)
+	}
+	b.subscribers = make(map[*subscriber]struct{})
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		<-sub.done
+	}
+}
@@ -0,0 +1,97 @@
+package eventbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(logLvl string)     {}
+func (nopLogger) Shutdown()                     {}
+
+// recorder collects the events handed to a subscriber
+type recorder struct {
+	mu     sync.Mutex
+	events []model.DomainEvent
+}
+
+func (r *recorder) handle(event model.DomainEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) kinds() []model.EventKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]model.EventKind, 0, len(r.events))
+	for _, e := range r.events {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+func TestMemoryEventBusFanOut(t *testing.T) {
+	bus := NewMemoryEventBus(nopLogger{}, 16)
+
+	all, queues := &recorder{}, &recorder{}
+	bus.Subscribe("all", all.handle)
+	bus.Subscribe("queues", queues.handle, model.EventQueueCreated, model.EventQueueDeleted)
+
+	bus.Publish(model.DomainEvent{Kind: model.EventMessagePublished, Domain: "shop", Queue: "orders"})
+	bus.Publish(model.DomainEvent{Kind: model.EventQueueCreated, Domain: "shop", Queue: "orders"})
+	bus.Close()
+
+	assert.Equal(t, []model.EventKind{model.EventMessagePublished, model.EventQueueCreated}, all.kinds())
+	assert.Equal(t, []model.EventKind{model.EventQueueCreated}, queues.kinds())
+	assert.False(t, all.events[0].Time.IsZero(), "publish stamps the event")
+
+	// publishing after close is a no-op
+	bus.Publish(model.DomainEvent{Kind: model.EventQueueDeleted})
+	assert.Len(t, queues.kinds(), 1)
+}
+
+func TestMemoryEventBusIsolatesSubscribers(t *testing.T) {
+	bus := NewMemoryEventBus(nopLogger{}, 1)
+
+	block := make(chan struct{})
+	bus.Subscribe("slow", func(model.DomainEvent) { <-block })
+	bus.Subscribe("panics", func(model.DomainEvent) { panic("boom") })
+	fast := &recorder{}
+	unsubscribe := bus.Subscribe("fast", fast.handle)
+
+	// a blocked subscriber drops events instead of stalling the publisher
+	done := make(chan struct{})
+	go func() {
+		for range 10 {
+			bus.Publish(model.DomainEvent{Kind: model.EventMessageConsumed})
+			time.Sleep(time.Millisecond)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher blocked by a slow subscriber")
+	}
+
+	assert.Eventually(t, func() bool { return len(fast.kinds()) > 0 }, time.Second, 5*time.Millisecond)
+
+	unsubscribe()
+	count := len(fast.kinds())
+	bus.Publish(model.DomainEvent{Kind: model.EventMessageConsumed})
+
+	close(block)
+	bus.Close()
+	assert.Len(t, fast.kinds(), count, "no events after unsubscribe")
+}
@@ -2,14 +2,19 @@ package logging
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/ajkula/GoRTMS/domain/port/outbound"
 )
 
@@ -38,6 +43,8 @@ type SlogAdapter struct {
 	logChan   chan LogMessage
 	ctx       context.Context
 	cancel    context.CancelFunc
+	done      chan struct{} // closed once the pending logs are written
+	output    io.Closer     // log file, nil for stdout/stderr
 	levelMu   sync.RWMutex
 	slogLevel *slog.LevelVar
 	// atomic level for fast shouldLog() checks
@@ -55,13 +62,20 @@ func NewSlogAdapter(config *config.Config) outbound.Logger {
 	handlerOpts := &slog.HandlerOptions{
 		Level: levelVar,
 	}
+	if config.Redaction.Enabled() {
+		handlerOpts.ReplaceAttr = redactAttr(&config.Redaction)
+	}
+
+	out, closer := logOutput(config)
 
 	adapter := &SlogAdapter{
-		logger:    slog.New(slog.NewJSONHandler(os.Stdout, handlerOpts)),
+		logger:    slog.New(slog.NewJSONHandler(out, handlerOpts)),
+		output:    closer,
 		config:    config,
 		logChan:   make(chan LogMessage, config.Logging.ChannelSize),
 		ctx:       ctx,
 		cancel:    cancel,
+		done:      make(chan struct{}),
 		slogLevel: levelVar,
 	}
 
@@ -73,6 +87,46 @@ func NewSlogAdapter(config *config.Config) outbound.Logger {
 	return adapter
 }
 
+// picks the log destination, services without a console log to a file
+func logOutput(config *config.Config) (io.Writer, io.Closer) {
+	switch strings.ToLower(config.Logging.Output) {
+	case "stderr":
+		return os.Stderr, nil
+	case "file":
+		if config.Logging.FilePath == "" {
+			break
+		}
+		if err := os.MkdirAll(filepath.Dir(config.Logging.FilePath), 0755); err == nil {
+			file, err := os.OpenFile(config.Logging.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err == nil {
+				return file, file
+			}
+		}
+		fmt.Fprintf(os.Stderr, "cannot open log file %s, logging to stdout\n", config.Logging.FilePath)
+	}
+	return os.Stdout, nil
+}
+
+// redactAttr hides the fields named by the redaction rules, and applies
+// them to the payloads logged as raw bytes
+func redactAttr(redaction *model.Redaction) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, attr slog.Attr) slog.Attr {
+		if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.MessageKey) {
+			return attr
+		}
+		if redacted, ok := redaction.Field(attr.Key, attr.Value.Any()); ok {
+			return slog.String(attr.Key, redacted)
+		}
+		switch value := attr.Value.Any().(type) {
+		case []byte:
+			return slog.String(attr.Key, string(redaction.Payload(value)))
+		case json.RawMessage:
+			return slog.Any(attr.Key, json.RawMessage(redaction.Payload(value)))
+		}
+		return attr
+	}
+}
+
 // updates both config and slog level dynamically
 func (s *SlogAdapter) UpdateLevel(logLvl string) {
 	s.levelMu.Lock()
@@ -91,8 +145,9 @@ func (s *SlogAdapter) UpdateLevel(logLvl string) {
 }
 
 // hadles messages asynchronously
+// the channel is left open, late logs are dropped once it fills up
 func (s *SlogAdapter) processLogs() {
-	defer close(s.logChan)
+	defer close(s.done)
 
 	for {
 		select {
@@ -103,6 +158,9 @@ func (s *SlogAdapter) processLogs() {
 				msg := <-s.logChan
 				s.writeLog(msg)
 			}
+			if s.output != nil {
+				s.output.Close()
+			}
 			return
 		}
 	}
@@ -201,6 +259,8 @@ func (s *SlogAdapter) Debug(msg string, args ...any) {
 	s.sendLog(LevelDebug, msg, args...)
 }
 
+// Shutdown writes the pending logs and returns once they are written
 func (s *SlogAdapter) Shutdown() {
 	s.cancel()
+	<-s.done
 }
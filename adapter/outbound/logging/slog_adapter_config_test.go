@@ -1,9 +1,11 @@
 package logging
 
 import (
+	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -270,3 +272,15 @@ func TestLogger_DynamicLevelChange_InvalidLevels(t *testing.T) {
 	// Restore original level
 	adapter.UpdateLevel(originalLevel)
 }
+
+func TestRedactAttr(t *testing.T) {
+	redact := redactAttr(&model.Redaction{Rules: []model.ScrubRule{
+		{Field: "email", Action: model.ScrubMask},
+		{Field: "card", Action: model.ScrubRemove},
+	}})
+
+	assert.Equal(t, "***", redact(nil, slog.String("email", "a@b.c")).Value.String())
+	assert.Equal(t, `{"id":1}`, redact(nil, slog.Any("payload", []byte(`{"id":1,"card":"4111"}`))).Value.String())
+	assert.Equal(t, "orders", redact(nil, slog.String("queue", "orders")).Value.String())
+	assert.Equal(t, "email", redact(nil, slog.String(slog.MessageKey, "email")).Value.String())
+}
@@ -1,10 +1,14 @@
 package logging
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ajkula/GoRTMS/config"
+	"github.com/stretchr/testify/assert"
 )
 
 // Helper to create test config
@@ -282,6 +286,21 @@ func TestLogger_DifferentOutputs(t *testing.T) {
 	}
 }
 
+func TestLogger_FileOutput(t *testing.T) {
+	cfg := createTestConfig("INFO")
+	cfg.Logging.Output = "file"
+	cfg.Logging.FilePath = filepath.Join(t.TempDir(), "logs", "gortms.log")
+
+	logger := NewSlogAdapter(cfg)
+	logger.Info("written to file", "key", "value")
+	logger.Shutdown()
+
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(cfg.Logging.FilePath)
+		return err == nil && strings.Contains(string(data), "written to file")
+	}, time.Second, 10*time.Millisecond)
+}
+
 // Benchmark tests to ensure performance
 func BenchmarkLogger_Debug(b *testing.B) {
 	cfg := createTestConfig("ERROR") // Debug disabled for performance
@@ -0,0 +1,29 @@
+//go:build darwin
+
+package machineid
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var platformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID" = "([0-9A-Fa-f-]+)"`)
+
+// dmiUUID reads the platform UUID from the I/O Registry
+func dmiUUID() (string, error) {
+	out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+	match := platformUUIDPattern.FindSubmatch(out)
+	if match == nil {
+		return "", errors.New("no IOPlatformUUID")
+	}
+	uuid := strings.ToLower(string(match[1]))
+	if !validDMIUUID(uuid) {
+		return "", errors.New("placeholder DMI UUID")
+	}
+	return uuid, nil
+}
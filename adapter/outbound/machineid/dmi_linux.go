@@ -0,0 +1,23 @@
+//go:build linux
+
+package machineid
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// dmiUUID reads the SMBIOS system UUID, readable by root only on most
+// distributions
+func dmiUUID() (string, error) {
+	data, err := os.ReadFile("/sys/class/dmi/id/product_uuid")
+	if err != nil {
+		return "", err
+	}
+	uuid := strings.ToLower(strings.TrimSpace(string(data)))
+	if !validDMIUUID(uuid) {
+		return "", errors.New("placeholder DMI UUID")
+	}
+	return uuid, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows && !darwin
+
+package machineid
+
+import "errors"
+
+func dmiUUID() (string, error) {
+	return "", errors.New("no DMI UUID on this platform")
+}
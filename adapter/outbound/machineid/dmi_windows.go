@@ -0,0 +1,23 @@
+//go:build windows
+
+package machineid
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// dmiUUID reads the SMBIOS system UUID through CIM
+func dmiUUID() (string, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"(Get-CimInstance -ClassName Win32_ComputerSystemProduct).UUID").Output()
+	if err != nil {
+		return "", err
+	}
+	uuid := strings.ToLower(strings.TrimSpace(string(out)))
+	if !validDMIUUID(uuid) {
+		return "", errors.New("placeholder DMI UUID")
+	}
+	return uuid, nil
+}
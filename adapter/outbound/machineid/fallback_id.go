@@ -0,0 +1,192 @@
+package machineid
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/denisbrodbeck/machineid"
+)
+
+// Sources of the machine ID, in the order they are tried
+const (
+	SourceConfig    = "config"    // security.machineId
+	SourceOS        = "os"        // /etc/machine-id, MachineGuid, IOPlatformUUID
+	SourceDMI       = "dmi"       // SMBIOS system UUID
+	SourceMAC       = "mac"       // hardware address of a network interface
+	SourceGenerated = "generated" // random ID kept in the data directory
+)
+
+// GeneratedIDFile holds the generated machine ID, in the data directory
+const GeneratedIDFile = "machine-id"
+
+type idSource struct {
+	name  string
+	rawID func() (string, error)
+}
+
+// FallbackMachineID resolves the machine ID from the first source that
+// provides one: the configured override, the OS machine ID, the DMI system
+// UUID, the hardware address of a network interface and, last, an ID
+// generated once and stored in the data directory. Hosts with an OS machine
+// ID keep the ID they had before the fallbacks existed.
+type FallbackMachineID struct {
+	sources []idSource
+	logger  outbound.Logger
+
+	once   sync.Once
+	id     string
+	source string
+	err    error
+}
+
+var _ outbound.MachineIDService = (*FallbackMachineID)(nil)
+
+// NewMachineID builds the chain, override being security.machineId and
+// dataDir the directory keeping the generated ID
+func NewMachineID(override, dataDir string, logger outbound.Logger) *FallbackMachineID {
+	var sources []idSource
+	if override != "" {
+		sources = append(sources, idSource{SourceConfig, func() (string, error) { return override, nil }})
+	}
+	sources = append(sources,
+		idSource{SourceOS, machineid.ID},
+		idSource{SourceDMI, dmiUUID},
+		idSource{SourceMAC, hardwareAddress},
+	)
+	if dataDir != "" {
+		sources = append(sources, idSource{SourceGenerated, func() (string, error) {
+			return generatedID(filepath.Join(dataDir, GeneratedIDFile))
+		}})
+	}
+	return &FallbackMachineID{sources: sources, logger: logger}
+}
+
+// GetMachineID returns the hashed ID of the first source providing one,
+// resolved once
+func (m *FallbackMachineID) GetMachineID() (string, error) {
+	m.once.Do(m.resolve)
+	return m.id, m.err
+}
+
+// Source names the source the machine ID comes from
+func (m *FallbackMachineID) Source() string {
+	m.once.Do(m.resolve)
+	return m.source
+}
+
+func (m *FallbackMachineID) resolve() {
+	var failures []string
+	for _, source := range m.sources {
+		rawID, err := source.rawID()
+		if err == nil && strings.TrimSpace(rawID) == "" {
+			err = errors.New("empty ID")
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", source.name, err))
+			continue
+		}
+
+		hash := sha256.Sum256([]byte(strings.TrimSpace(rawID)))
+		m.id, m.source = hex.EncodeToString(hash[:]), source.name
+
+		if len(failures) > 0 {
+			m.logger.Warn("Machine ID sources unavailable, using a fallback", "source", source.name, "unavailable", failures)
+		}
+		if source.name == SourceGenerated {
+			m.logger.Warn("Machine ID generated and stored in the data directory, next to the data it protects: set security.machineId to keep it elsewhere")
+		}
+		m.logger.Info("Machine ID resolved", "source", source.name)
+		return
+	}
+	m.err = fmt.Errorf("no machine ID source available (%s)", strings.Join(failures, "; "))
+}
+
+// validDMIUUID rejects the placeholders firmwares ship instead of a UUID
+func validDMIUUID(uuid string) bool {
+	digits := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(uuid), "-", ""))
+	if len(digits) != 32 {
+		return false
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return false
+	}
+	if strings.Count(digits, digits[:1]) == len(digits) {
+		return false // all zeros or all Fs
+	}
+	return digits != "03000200040005000006000700080009"
+}
+
+// hardwareAddress returns the lowest universally administered address of
+// the network interfaces. Locally administered addresses, those of
+// containers and most VMs, change when they are recreated and are skipped.
+func hardwareAddress() (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	var addresses []string
+	for _, iface := range interfaces {
+		mac := iface.HardwareAddr
+		if iface.Flags&net.FlagLoopback != 0 || len(mac) < 6 || mac[0]&0x02 != 0 {
+			continue
+		}
+		if strings.Count(mac.String(), "00") == len(mac) {
+			continue
+		}
+		addresses = append(addresses, mac.String())
+	}
+	if len(addresses) == 0 {
+		return "", errors.New("no universally administered hardware address")
+	}
+	sort.Strings(addresses)
+	return "mac:" + addresses[0], nil
+}
+
+// generatedID reads the ID stored at path, generating it on first use
+func generatedID(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// created meanwhile by another process
+		return generatedID(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(id + "\n"); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,102 @@
+package machineid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(logLvl string)     {}
+func (nopLogger) Shutdown()                     {}
+
+func hashed(rawID string) string {
+	hash := sha256.Sum256([]byte(rawID))
+	return hex.EncodeToString(hash[:])
+}
+
+func failing(name string) idSource {
+	return idSource{name, func() (string, error) { return "", errors.New(name + " unavailable") }}
+}
+
+func TestMachineIDOverride(t *testing.T) {
+	m := NewMachineID("0123456789abcdef-node", t.TempDir(), nopLogger{})
+
+	id, err := m.GetMachineID()
+	require.NoError(t, err)
+	assert.Equal(t, hashed("0123456789abcdef-node"), id)
+	assert.Equal(t, SourceConfig, m.Source())
+}
+
+func TestMachineIDFallsBack(t *testing.T) {
+	m := &FallbackMachineID{logger: nopLogger{}, sources: []idSource{
+		failing(SourceOS),
+		{SourceDMI, func() (string, error) { return "  ", nil }},
+		{SourceMAC, func() (string, error) { return "mac:00:1b:21:3a:4f:10", nil }},
+		failing(SourceGenerated),
+	}}
+
+	id, err := m.GetMachineID()
+	require.NoError(t, err)
+	assert.Equal(t, hashed("mac:00:1b:21:3a:4f:10"), id)
+	assert.Equal(t, SourceMAC, m.Source())
+}
+
+func TestMachineIDGeneratedIsPersisted(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	newChain := func() *FallbackMachineID {
+		return &FallbackMachineID{logger: nopLogger{}, sources: []idSource{
+			failing(SourceOS),
+			failing(SourceDMI),
+			failing(SourceMAC),
+			{SourceGenerated, func() (string, error) { return generatedID(filepath.Join(dataDir, GeneratedIDFile)) }},
+		}}
+	}
+
+	first, err := newChain().GetMachineID()
+	require.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(dataDir, GeneratedIDFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a restart finds the same ID
+	second, err := newChain().GetMachineID()
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestMachineIDNoSource(t *testing.T) {
+	m := &FallbackMachineID{logger: nopLogger{}, sources: []idSource{failing(SourceOS), failing(SourceMAC)}}
+
+	_, err := m.GetMachineID()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "os unavailable")
+	assert.Contains(t, err.Error(), "mac unavailable")
+}
+
+func TestValidDMIUUID(t *testing.T) {
+	assert.True(t, validDMIUUID("4c4c4544-0037-3410-8051-b3c04f4a4e32"))
+	assert.True(t, validDMIUUID("4C4C4544-0037-3410-8051-B3C04F4A4E32\n"))
+
+	for _, placeholder := range []string{
+		"",
+		"Not Settable",
+		"00000000-0000-0000-0000-000000000000",
+		"FFFFFFFF-FFFF-FFFF-FFFF-FFFFFFFFFFFF",
+		"03000200-0400-0500-0006-000700080009",
+	} {
+		assert.False(t, validDMIUUID(placeholder), placeholder)
+	}
+}
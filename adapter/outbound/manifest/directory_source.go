@@ -0,0 +1,182 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// Manifest kinds
+const (
+	KindDomain         = "Domain"
+	KindServiceAccount = "ServiceAccount"
+)
+
+// domainManifest is a domain described like in the config file
+type domainManifest struct {
+	Kind                string `yaml:"kind"`
+	config.DomainConfig `yaml:",inline"`
+}
+
+type serviceAccountManifest struct {
+	Kind            string   `yaml:"kind"`
+	ID              string   `yaml:"id"`
+	Name            string   `yaml:"name"`
+	Permissions     []string `yaml:"permissions"`
+	IPWhitelist     []string `yaml:"ipWhitelist,omitempty"`
+	Domains         []string `yaml:"domains,omitempty"`
+	Enabled         *bool    `yaml:"enabled,omitempty"` // default true
+	TimestampWindow string   `yaml:"timestampWindow,omitempty"`
+}
+
+// DirectorySource reads the *.yaml and *.yml files of a directory, each file
+// holding any number of documents separated by ---
+type DirectorySource struct {
+	dir string
+}
+
+func NewDirectorySource(dir string) outbound.ManifestSource {
+	return &DirectorySource{dir: dir}
+}
+
+func (s *DirectorySource) Load(ctx context.Context) (*model.DesiredState, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	var domains []config.DomainConfig
+	state := &model.DesiredState{}
+	accounts := make(map[string]string) // ID -> file
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fileDomains, fileAccounts, err := readManifestFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		domains = append(domains, fileDomains...)
+		for _, account := range fileAccounts {
+			if previous, exists := accounts[account.ID]; exists {
+				return nil, fmt.Errorf("%s: service account %s is already defined in %s", file, account.ID, previous)
+			}
+			accounts[account.ID] = file
+			state.ServiceAccounts = append(state.ServiceAccounts, account)
+		}
+	}
+
+	if err := config.ValidateDomains(domains); err != nil {
+		return nil, err
+	}
+	for _, domain := range domains {
+		state.Domains = append(state.Domains, domain.Desired())
+	}
+
+	return state, nil
+}
+
+func readManifestFile(file string) ([]config.DomainConfig, []model.DesiredServiceAccount, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var domains []config.DomainConfig
+	var accounts []model.DesiredServiceAccount
+
+	decoder := yaml.NewDecoder(f)
+	for i := 0; ; i++ {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		var header struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := document.Decode(&header); err != nil {
+			return nil, nil, fmt.Errorf("%s: document %d: %w", file, i, err)
+		}
+
+		switch header.Kind {
+		case KindDomain:
+			var manifest domainManifest
+			if err := document.Decode(&manifest); err != nil {
+				return nil, nil, fmt.Errorf("%s: document %d: %w", file, i, err)
+			}
+			domains = append(domains, manifest.DomainConfig)
+
+		case KindServiceAccount:
+			var manifest serviceAccountManifest
+			if err := document.Decode(&manifest); err != nil {
+				return nil, nil, fmt.Errorf("%s: document %d: %w", file, i, err)
+			}
+			account, err := manifest.desired()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: document %d: %w", file, i, err)
+			}
+			accounts = append(accounts, account)
+
+		default:
+			return nil, nil, fmt.Errorf("%s: document %d: unknown kind %q, expected %s or %s",
+				file, i, header.Kind, KindDomain, KindServiceAccount)
+		}
+	}
+
+	return domains, accounts, nil
+}
+
+func (m serviceAccountManifest) desired() (model.DesiredServiceAccount, error) {
+	switch {
+	case strings.TrimSpace(m.ID) == "":
+		return model.DesiredServiceAccount{}, errors.New("service account id is required")
+	case strings.TrimSpace(m.Name) == "":
+		return model.DesiredServiceAccount{}, fmt.Errorf("service account %s: name is required", m.ID)
+	case len(m.Permissions) == 0:
+		return model.DesiredServiceAccount{}, fmt.Errorf("service account %s: at least one permission is required", m.ID)
+	}
+	if err := model.ValidateServiceScope(m.Domains); err != nil {
+		return model.DesiredServiceAccount{}, fmt.Errorf("service account %s: %w", m.ID, err)
+	}
+	if err := model.ValidateTimestampWindow(m.TimestampWindow); err != nil {
+		return model.DesiredServiceAccount{}, fmt.Errorf("service account %s: %w", m.ID, err)
+	}
+
+	return model.DesiredServiceAccount{
+		ID:              m.ID,
+		Name:            m.Name,
+		Permissions:     m.Permissions,
+		IPWhitelist:     m.IPWhitelist,
+		Domains:         m.Domains,
+		Enabled:         m.Enabled == nil || *m.Enabled,
+		TimestampWindow: m.TimestampWindow,
+	}, nil
+}
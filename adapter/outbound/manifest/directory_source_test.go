@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestDirectorySourceLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "shop.yaml", `
+kind: Domain
+name: shop
+schema:
+  fields:
+    id: string
+labels:
+  team: payments
+queues:
+  - name: orders
+    config:
+      isPersistent: true
+      maxSize: 100
+  - name: invoices
+routes:
+  - sourceQueue: orders
+    destinationQueue: invoices
+    predicate:
+      type: eq
+      field: type
+      value: invoice
+`)
+	writeManifest(t, dir, "accounts.yml", `
+kind: ServiceAccount
+id: billing
+name: Billing
+permissions: ["publish:shop"]
+---
+kind: ServiceAccount
+id: audit
+name: Audit
+permissions: ["consume:*"]
+enabled: false
+`)
+	writeManifest(t, dir, "README.md", "not a manifest")
+
+	state, err := NewDirectorySource(dir).Load(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, state.Domains, 1)
+	shop := state.Domains[0]
+	assert.Equal(t, "shop", shop.Name)
+	assert.Equal(t, model.StringType, shop.Schema.Fields["id"])
+	assert.Equal(t, "payments", shop.Labels["team"])
+	require.Len(t, shop.Queues, 2)
+	assert.Equal(t, 100, shop.Queues[0].Config.MaxSize)
+	require.Len(t, shop.Routes, 1)
+	assert.Equal(t, "orders->invoices", shop.Routes[0].ID("shop"))
+
+	require.Len(t, state.ServiceAccounts, 2)
+	assert.Equal(t, "billing", state.ServiceAccounts[0].ID)
+	assert.True(t, state.ServiceAccounts[0].Enabled, "accounts are enabled by default")
+	assert.False(t, state.ServiceAccounts[1].Enabled)
+}
+
+func TestDirectorySourceRejectsInvalidManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown kind",
+			files:   map[string]string{"a.yaml": "kind: Topic\nname: x\n"},
+			wantErr: `a.yaml: document 0: unknown kind "Topic"`,
+		},
+		{
+			name:    "malformed yaml",
+			files:   map[string]string{"a.yaml": "kind: Domain\nname: [shop\n"},
+			wantErr: "a.yaml",
+		},
+		{
+			name: "duplicate service account",
+			files: map[string]string{
+				"a.yaml": "kind: ServiceAccount\nid: billing\nname: Billing\npermissions: [\"*\"]\n",
+				"b.yaml": "kind: ServiceAccount\nid: billing\nname: Billing\npermissions: [\"*\"]\n",
+			},
+			wantErr: "service account billing is already defined",
+		},
+		{
+			name:    "account without permissions",
+			files:   map[string]string{"a.yaml": "kind: ServiceAccount\nid: billing\nname: Billing\n"},
+			wantErr: "at least one permission is required",
+		},
+		{
+			name: "duplicate domain",
+			files: map[string]string{
+				"a.yaml": "kind: Domain\nname: shop\n",
+				"b.yaml": "kind: Domain\nname: shop\n",
+			},
+			wantErr: "shop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeManifest(t, dir, name, content)
+			}
+
+			_, err := NewDirectorySource(dir).Load(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestDirectorySourceMissingDirectory(t *testing.T) {
+	_, err := NewDirectorySource(filepath.Join(t.TempDir(), "missing")).Load(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const remoteWriteVersion = "0.1.0"
+
+// RemoteWriter pushes metric samples to a Prometheus remote-write endpoint
+// (Prometheus, Mimir, Cortex, VictoriaMetrics, Grafana Cloud...): a
+// snappy-compressed protobuf WriteRequest per push.
+type RemoteWriter struct {
+	url     string
+	headers map[string]string
+	labels  map[string]string // added to every series
+	client  *http.Client
+}
+
+var _ outbound.MetricsWriter = (*RemoteWriter)(nil)
+
+// NewRemoteWriter builds the writer of an endpoint, series being labelled
+// with instance=nodeID unless the configuration sets an instance label
+func NewRemoteWriter(cfg config.RemoteWriteConfig, nodeID string) *RemoteWriter {
+	labels := make(map[string]string, len(cfg.Labels)+1)
+	if nodeID != "" {
+		labels["instance"] = nodeID
+	}
+	for name, value := range cfg.Labels {
+		labels[name] = value
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = model.DefaultRemoteWriteTimeout
+	}
+
+	return &RemoteWriter{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		labels:  labels,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Write pushes the samples. Endpoints answering 4xx, 429 aside, refuse
+// them for good and the error wraps model.ErrMetricsRejected.
+func (w *RemoteWriter) Write(ctx context.Context, samples []model.MetricSample) error {
+	body := snappyEncode(w.encode(samples))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	req.Header.Set("User-Agent", "gortms")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("remote write: %s %s", resp.Status, strings.TrimSpace(string(message)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", model.ErrMetricsRejected, err)
+	}
+	return err
+}
+
+// encode builds the WriteRequest protobuf:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; } // milliseconds
+//
+// Samples of the same series are grouped, in time order.
+func (w *RemoteWriter) encode(samples []model.MetricSample) []byte {
+	type series struct {
+		labels  []string // name, value pairs sorted by name
+		samples []model.MetricSample
+	}
+	var keys []string
+	bySeries := make(map[string]*series)
+	for _, sample := range samples {
+		key := sample.SeriesKey()
+		s, exists := bySeries[key]
+		if !exists {
+			s = &series{labels: w.seriesLabels(sample)}
+			bySeries[key] = s
+			keys = append(keys, key)
+		}
+		s.samples = append(s.samples, sample)
+	}
+	sort.Strings(keys)
+
+	var request []byte
+	for _, key := range keys {
+		s := bySeries[key]
+		sort.SliceStable(s.samples, func(i, j int) bool { return s.samples[i].Time.Before(s.samples[j].Time) })
+
+		var ts []byte
+		for i := 0; i < len(s.labels); i += 2 {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, s.labels[i])
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, s.labels[i+1])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		for _, sample := range s.samples {
+			var point []byte
+			point = protowire.AppendTag(point, 1, protowire.Fixed64Type)
+			point = protowire.AppendFixed64(point, math.Float64bits(sample.Value))
+			point = protowire.AppendTag(point, 2, protowire.VarintType)
+			point = protowire.AppendVarint(point, uint64(sample.Time.UnixMilli()))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, point)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+	return request
+}
+
+// seriesLabels are the labels of the series of the sample, __name__ and the
+// labels of the writer included, sorted by name as remote write requires.
+// Labels of the sample win over the labels of the writer.
+func (w *RemoteWriter) seriesLabels(sample model.MetricSample) []string {
+	merged := make(map[string]string, len(w.labels)+len(sample.Labels)+1)
+	for name, value := range w.labels {
+		merged[name] = value
+	}
+	for name, value := range sample.Labels {
+		merged[name] = value
+	}
+	merged["__name__"] = sample.Name
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		labels = append(labels, name, merged[name])
+	}
+	return labels
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// snappyDecodeLiterals decodes the literal-only blocks of snappyEncode
+func snappyDecodeLiterals(t *testing.T, src []byte) []byte {
+	length, n := binary.Uvarint(src)
+	require.Positive(t, n)
+	src = src[n:]
+
+	var dst []byte
+	for len(src) > 0 {
+		tag := src[0]
+		require.Zero(t, tag&3, "literal elements only")
+		size, src2 := int(tag>>2), src[1:]
+		switch size {
+		case 60:
+			size, src2 = int(src2[0]), src2[1:]
+		case 61:
+			size, src2 = int(binary.LittleEndian.Uint16(src2)), src2[2:]
+		}
+		dst = append(dst, src2[:size+1]...)
+		src = src2[size+1:]
+	}
+	require.Len(t, dst, int(length))
+	return dst
+}
+
+type decodedSeries struct {
+	labels map[string]string
+	names  []string // in the order sent
+	values []float64
+	millis []int64
+}
+
+// fields splits a protobuf message in its length-delimited and scalar fields
+func fields(t *testing.T, b []byte, visit func(num protowire.Number, typ protowire.Type, value []byte, scalar uint64)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.Positive(t, n)
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.Positive(t, n)
+			visit(num, typ, v, 0)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			require.Positive(t, n)
+			visit(num, typ, nil, v)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.Positive(t, n)
+			visit(num, typ, nil, v)
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+}
+
+func decodeWriteRequest(t *testing.T, body []byte) []decodedSeries {
+	var all []decodedSeries
+	fields(t, snappyDecodeLiterals(t, body), func(_ protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		series := decodedSeries{labels: map[string]string{}}
+		fields(t, ts, func(num protowire.Number, _ protowire.Type, value []byte, _ uint64) {
+			switch num {
+			case 1:
+				var name, val string
+				fields(t, value, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						val = string(v)
+					}
+				})
+				series.labels[name] = val
+				series.names = append(series.names, name)
+			case 2:
+				fields(t, value, func(num protowire.Number, _ protowire.Type, _ []byte, scalar uint64) {
+					if num == 1 {
+						series.values = append(series.values, math.Float64frombits(scalar))
+					} else {
+						series.millis = append(series.millis, int64(scalar))
+					}
+				})
+			}
+		})
+		all = append(all, series)
+	})
+	return all
+}
+
+func TestRemoteWriter(t *testing.T) {
+	var body []byte
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writer := NewRemoteWriter(config.RemoteWriteConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Scope-OrgID": "tenant-1"},
+		Labels:  map[string]string{"env": "edge"},
+	}, "node-1")
+
+	t0 := time.UnixMilli(1_700_000_000_000)
+	labels := map[string]string{"domain": "shop", "queue": "orders"}
+	require.NoError(t, writer.Write(context.Background(), []model.MetricSample{
+		{Name: "gortms_publish_rate", Value: 4, Time: t0.Add(time.Second)},
+		{Name: "gortms_queue_messages", Labels: labels, Value: 12, Time: t0},
+		{Name: "gortms_publish_rate", Value: 2.5, Time: t0},
+	}))
+
+	assert.Equal(t, "snappy", headers.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", headers.Get("Content-Type"))
+	assert.Equal(t, "0.1.0", headers.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "tenant-1", headers.Get("X-Scope-OrgID"))
+
+	series := decodeWriteRequest(t, body)
+	require.Len(t, series, 2)
+
+	rates := series[0]
+	assert.Equal(t, map[string]string{"__name__": "gortms_publish_rate", "env": "edge", "instance": "node-1"}, rates.labels)
+	assert.Equal(t, []string{"__name__", "env", "instance"}, rates.names, "labels are sorted by name")
+	assert.Equal(t, []float64{2.5, 4}, rates.values, "samples are sent in time order")
+	assert.Equal(t, []int64{t0.UnixMilli(), t0.Add(time.Second).UnixMilli()}, rates.millis)
+
+	queue := series[1]
+	assert.Equal(t, "shop", queue.labels["domain"])
+	assert.Equal(t, "orders", queue.labels["queue"])
+	assert.Equal(t, []float64{12}, queue.values)
+}
+
+func TestRemoteWriterErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", status)
+	}))
+	defer server.Close()
+
+	writer := NewRemoteWriter(config.RemoteWriteConfig{URL: server.URL}, "")
+	samples := []model.MetricSample{{Name: "gortms_publish_rate", Value: 1, Time: time.Now()}}
+
+	err := writer.Write(context.Background(), samples)
+	assert.ErrorIs(t, err, model.ErrMetricsRejected)
+	assert.ErrorContains(t, err, "out of order sample")
+
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		err = writer.Write(context.Background(), samples)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, model.ErrMetricsRejected, "%d is retried", status)
+	}
+}
+
+func TestSnappyEncodeLongLiterals(t *testing.T) {
+	for _, size := range []int{0, 1, 60, 61, 256, 257, 70_000} {
+		src := make([]byte, size)
+		for i := range src {
+			src[i] = byte(i)
+		}
+		assert.Equal(t, src, append([]byte{}, snappyDecodeLiterals(t, snappyEncode(src))...), "size %d", size)
+	}
+}
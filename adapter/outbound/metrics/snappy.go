@@ -0,0 +1,37 @@
+package metrics
+
+import "encoding/binary"
+
+// snappyMaxLiteral bounds the literal chunks, the encoder being free to
+// split them
+const snappyMaxLiteral = 1 << 16
+
+// snappyEncode frames src as a snappy block made of literals only. Remote
+// write requires the snappy block format, not its compression: pushes are
+// small and seldom, so a decoder-compatible block without back-references
+// saves a dependency for a few kilobytes on the wire.
+//
+// A block is the uncompressed length as a varint, then elements. A literal
+// element is a tag byte, with the length minus one in its upper six bits up
+// to 59, or 60 to 63 saying the length minus one follows on 1 to 4 bytes,
+// little endian; then the bytes themselves.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)+len(src)/snappyMaxLiteral*3+16), uint64(len(src)))
+
+	for len(src) > 0 {
+		chunk := src[:min(len(src), snappyMaxLiteral)]
+		src = src[len(chunk):]
+
+		n := uint32(len(chunk) - 1)
+		switch {
+		case n < 60:
+			dst = append(dst, byte(n)<<2)
+		case n < 1<<8:
+			dst = append(dst, 60<<2, byte(n))
+		default:
+			dst = append(dst, 61<<2, byte(n), byte(n>>8))
+		}
+		dst = append(dst, chunk...)
+	}
+	return dst
+}
@@ -0,0 +1,284 @@
+package migration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+const (
+	// DefaultBatchSize is the number of records read from the source at once
+	DefaultBatchSize = 100
+
+	// DefaultPollInterval is the wait between empty reads when following the source
+	DefaultPollInterval = time.Second
+)
+
+// Record is a message read from the source broker
+type Record struct {
+	// Offset locates the record in the source, the next read starts after it
+	Offset string `json:"offset"`
+
+	// Seq is the producer sequence the record is republished with
+	Seq uint64 `json:"seq"`
+
+	// Payload is a JSON object, sources wrap the bodies that aren't
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Source is the broker messages are migrated from
+type Source interface {
+	// Name identifies the source in checkpoints and producer sessions
+	Name() string
+
+	// Read returns up to limit records after the offset, the whole source
+	// when the offset is empty
+	Read(ctx context.Context, after string, limit int) ([]Record, error)
+
+	// Pending counts the records still to migrate after the offset
+	Pending(ctx context.Context, after string) (int64, error)
+
+	// Drains is set when reading removes the records from the source, which
+	// then can't be read again after a failed publish
+	Drains() bool
+
+	Close() error
+}
+
+// Publisher republishes the records into GoRTMS
+type Publisher interface {
+	Publish(ctx context.Context, producerID string, record Record) error
+}
+
+// Checkpoint is the migration progress, saved after every batch
+type Checkpoint struct {
+	Source   string `json:"source"`
+	Offset   string `json:"offset"`
+	Migrated uint64 `json:"migrated"`
+
+	// InFlight holds the records drained from the source but not published
+	// yet, they are published first on resume
+	InFlight []Record `json:"inFlight,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadCheckpoint reads the checkpoint of the source, a missing file starts
+// the migration from the beginning
+func LoadCheckpoint(path, source string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Checkpoint{Source: source}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Source != source {
+		return nil, fmt.Errorf("checkpoint %s belongs to %s, not %s", path, checkpoint.Source, source)
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint through a temporary file so that a crash
+// never leaves it half written
+func (c *Checkpoint) Save(path string) error {
+	c.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Report tells whether producers can be cut over to GoRTMS
+type Report struct {
+	Source   string `json:"source"`
+	Offset   string `json:"offset"`
+	Migrated uint64 `json:"migrated"`
+	Pending  int64  `json:"pending"`
+	InFlight int    `json:"inFlight"`
+
+	// Ready is set once every record of the source is in GoRTMS
+	Ready bool `json:"ready"`
+}
+
+// Migrator copies a source into a GoRTMS queue. Records are republished
+// with a producer session, so the batch replayed after a crash is
+// deduplicated by the server instead of published twice.
+type Migrator struct {
+	Source         Source
+	Publisher      Publisher
+	CheckpointPath string
+
+	// BatchSize is capped by the producer session window, the window must
+	// cover a whole replayed batch
+	BatchSize int
+
+	// Follow keeps polling the source once caught up, for the dual-write
+	// period before cutover
+	Follow       bool
+	PollInterval time.Duration
+
+	// OnBatch is called after every saved batch
+	OnBatch func(Checkpoint)
+}
+
+func (m *Migrator) producerID() string {
+	return "migrate-" + m.Source.Name()
+}
+
+func (m *Migrator) batchSize() int {
+	if m.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return min(m.BatchSize, model.ProducerSessionWindow)
+}
+
+// Run migrates until the source is exhausted, or until ctx is done when
+// following it
+func (m *Migrator) Run(ctx context.Context) (*Checkpoint, error) {
+	checkpoint, err := LoadCheckpoint(m.CheckpointPath, m.Source.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(checkpoint.InFlight) > 0 {
+		if err := m.publish(ctx, checkpoint, checkpoint.InFlight); err != nil {
+			return checkpoint, err
+		}
+	}
+
+	poll := m.PollInterval
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+
+	for {
+		records, err := m.Source.Read(ctx, checkpoint.Offset, m.batchSize())
+		if err != nil {
+			if ctx.Err() != nil {
+				return checkpoint, nil
+			}
+			return checkpoint, fmt.Errorf("failed to read %s: %w", m.Source.Name(), err)
+		}
+
+		if len(records) == 0 {
+			if !m.Follow {
+				return checkpoint, nil
+			}
+			select {
+			case <-ctx.Done():
+				return checkpoint, nil
+			case <-time.After(poll):
+				continue
+			}
+		}
+
+		for i := range records {
+			records[i].Seq = checkpoint.Migrated + uint64(i) + 1
+		}
+		if m.Source.Drains() {
+			checkpoint.InFlight = records
+			if err := checkpoint.Save(m.CheckpointPath); err != nil {
+				return checkpoint, err
+			}
+		}
+
+		if err := m.publish(ctx, checkpoint, records); err != nil {
+			return checkpoint, err
+		}
+	}
+}
+
+// publish republishes the batch and saves the progress, on failure the
+// checkpoint keeps what is left of a drained batch
+func (m *Migrator) publish(ctx context.Context, checkpoint *Checkpoint, records []Record) error {
+	for i, record := range records {
+		if err := m.Publisher.Publish(ctx, m.producerID(), record); err != nil {
+			if saveErr := checkpoint.Save(m.CheckpointPath); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
+			return fmt.Errorf("failed to publish %s offset %s: %w", m.Source.Name(), record.Offset, err)
+		}
+
+		checkpoint.Offset = record.Offset
+		checkpoint.Migrated = record.Seq
+		if m.Source.Drains() {
+			checkpoint.InFlight = records[i+1:]
+		}
+	}
+
+	checkpoint.InFlight = nil
+	if err := checkpoint.Save(m.CheckpointPath); err != nil {
+		return err
+	}
+	if m.OnBatch != nil {
+		m.OnBatch(*checkpoint)
+	}
+	return nil
+}
+
+// Verify compares the checkpoint with the source without migrating
+func (m *Migrator) Verify(ctx context.Context) (*Report, error) {
+	checkpoint, err := LoadCheckpoint(m.CheckpointPath, m.Source.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.Source.Pending(ctx, checkpoint.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", m.Source.Name(), err)
+	}
+
+	return &Report{
+		Source:   checkpoint.Source,
+		Offset:   checkpoint.Offset,
+		Migrated: checkpoint.Migrated,
+		Pending:  pending,
+		InFlight: len(checkpoint.InFlight),
+		Ready:    pending == 0 && len(checkpoint.InFlight) == 0,
+	}, nil
+}
+
+// objectPayload keeps JSON object bodies as they are and wraps the others,
+// GoRTMS only accepts JSON object payloads
+func objectPayload(body []byte) json.RawMessage {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(body, &object) == nil && object != nil {
+		return json.RawMessage(body)
+	}
+
+	wrapped := map[string]string{"body": string(body)}
+	if !utf8.Valid(body) {
+		wrapped = map[string]string{"bodyBase64": base64.StdEncoding.EncodeToString(body)}
+	}
+	payload, _ := json.Marshal(wrapped)
+	return payload
+}
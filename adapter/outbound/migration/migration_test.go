@@ -0,0 +1,285 @@
+package migration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource serves numbered records, optionally draining them
+type sliceSource struct {
+	payloads []string
+	drains   bool
+	read     int
+}
+
+func (s *sliceSource) Name() string { return "test:orders" }
+func (s *sliceSource) Drains() bool { return s.drains }
+func (s *sliceSource) Close() error { return nil }
+
+func (s *sliceSource) Read(_ context.Context, after string, limit int) ([]Record, error) {
+	start := 0
+	if after != "" && !s.drains {
+		start, _ = strconv.Atoi(after)
+	}
+	if s.drains {
+		start = s.read
+	}
+	end := min(start+limit, len(s.payloads))
+
+	var records []Record
+	for i := start; i < end; i++ {
+		records = append(records, Record{Offset: strconv.Itoa(i + 1), Payload: json.RawMessage(s.payloads[i])})
+	}
+	if s.drains {
+		s.read = end
+	}
+	return records, nil
+}
+
+func (s *sliceSource) Pending(_ context.Context, after string) (int64, error) {
+	position, _ := strconv.Atoi(after)
+	if s.drains {
+		position = s.read
+	}
+	return int64(len(s.payloads) - position), nil
+}
+
+// recordingPublisher fails once after failAfter publishes
+type recordingPublisher struct {
+	published []Record
+	failAfter int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, producerID string, record Record) error {
+	if p.failAfter > 0 && len(p.published) == p.failAfter {
+		p.failAfter = 0
+		return errors.New("server unavailable")
+	}
+	p.published = append(p.published, record)
+	return nil
+}
+
+func TestMigratorResumesFromCheckpoint(t *testing.T) {
+	source := &sliceSource{payloads: []string{`{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`, `{"n":5}`}}
+	publisher := &recordingPublisher{failAfter: 3}
+	migrator := &Migrator{
+		Source:         source,
+		Publisher:      publisher,
+		CheckpointPath: filepath.Join(t.TempDir(), "checkpoint.json"),
+		BatchSize:      2,
+	}
+
+	_, err := migrator.Run(context.Background())
+	require.Error(t, err)
+
+	checkpoint, err := LoadCheckpoint(migrator.CheckpointPath, source.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "3", checkpoint.Offset)
+	assert.Equal(t, uint64(3), checkpoint.Migrated)
+
+	report, err := migrator.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, report.Ready)
+	assert.Equal(t, int64(2), report.Pending)
+
+	checkpoint, err = migrator.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), checkpoint.Migrated)
+	require.Len(t, publisher.published, 5)
+	for i, record := range publisher.published {
+		assert.Equal(t, uint64(i+1), record.Seq)
+	}
+
+	report, err = migrator.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Ready)
+}
+
+func TestMigratorKeepsDrainedRecordsInFlight(t *testing.T) {
+	source := &sliceSource{payloads: []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, drains: true}
+	publisher := &recordingPublisher{failAfter: 1}
+	migrator := &Migrator{
+		Source:         source,
+		Publisher:      publisher,
+		CheckpointPath: filepath.Join(t.TempDir(), "checkpoint.json"),
+		BatchSize:      3,
+	}
+
+	_, err := migrator.Run(context.Background())
+	require.Error(t, err)
+
+	// the records are gone from the source, only the checkpoint has them
+	report, err := migrator.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Pending)
+	assert.Equal(t, 2, report.InFlight)
+	assert.False(t, report.Ready)
+
+	_, err = migrator.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, publisher.published, 3)
+	assert.JSONEq(t, `{"n":3}`, string(publisher.published[2].Payload))
+	assert.Equal(t, uint64(3), publisher.published[2].Seq)
+
+	report, err = migrator.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Ready)
+}
+
+func TestLoadCheckpointRejectsOtherSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, (&Checkpoint{Source: "redis:orders", Offset: "1-0"}).Save(path))
+
+	_, err := LoadCheckpoint(path, "redis:payments")
+	assert.Error(t, err)
+}
+
+func TestRESTPublisherSignsAndSendsSequence(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		assert.Equal(t, "/api/domains/shop/queues/orders/messages", r.URL.Path)
+		if r.Header.Get("X-Producer-Seq") == "2" {
+			http.Error(w, "stale producer sequence", http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	publisher := &RESTPublisher{BaseURL: server.URL, Domain: "shop", Queue: "orders", ServiceID: "svc", ServiceSecret: "secret"}
+	record := Record{Offset: "1-0", Seq: 1, Payload: json.RawMessage(`{"n":1}`)}
+	require.NoError(t, publisher.Publish(context.Background(), "migrate-redis:orders", record))
+
+	assert.Equal(t, "migrate-redis:orders", headers.Get("X-Producer-ID"))
+	assert.Equal(t, "1", headers.Get("X-Producer-Seq"))
+	assert.Equal(t, "svc", headers.Get("X-Service-ID"))
+	assert.Equal(t, sign("POST", "/api/domains/shop/queues/orders/messages", body, headers.Get("X-Timestamp"), "secret"), headers.Get("X-Signature"))
+
+	// already published before the session window
+	record.Seq = 2
+	assert.NoError(t, publisher.Publish(context.Background(), "migrate-redis:orders", record))
+}
+
+func TestRabbitMQSourceNumbersDrainedMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "guest", user)
+		assert.Equal(t, "secret", password)
+
+		switch r.URL.EscapedPath() {
+		case "/api/queues/%2F/orders/get":
+			var request map[string]any
+			json.NewDecoder(r.Body).Decode(&request)
+			assert.Equal(t, "ack_requeue_false", request["ackmode"])
+			w.Write([]byte(`[
+				{"payload":"{\"n\":1}","payload_encoding":"string"},
+				{"payload":"plain text","payload_encoding":"string"},
+				{"payload":"/w==","payload_encoding":"base64"}
+			]`))
+		case "/api/queues/%2F/orders":
+			w.Write([]byte(`{"messages":7}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewRabbitMQSource(strings.Replace(server.URL, "http://", "http://guest:secret@", 1), "", "orders")
+	require.NoError(t, err)
+
+	records, err := source.Read(context.Background(), "10", 3)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "11", records[0].Offset)
+	assert.Equal(t, "13", records[2].Offset)
+	assert.JSONEq(t, `{"n":1}`, string(records[0].Payload))
+	assert.JSONEq(t, `{"body":"plain text"}`, string(records[1].Payload))
+	assert.JSONEq(t, `{"bodyBase64":"/w=="}`, string(records[2].Payload))
+
+	pending, err := source.Pending(context.Background(), "13")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), pending)
+}
+
+// fakeRedis answers XRANGE from a fixed stream
+func fakeRedis(t *testing.T, ids []string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var wg sync.WaitGroup
+	t.Cleanup(wg.Wait)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			command, err := readRESP(reader)
+			if err != nil {
+				return
+			}
+			args := command.([]any)
+			start := args[2].(string)
+			count, _ := strconv.Atoi(args[5].(string))
+
+			var entries []string
+			for _, id := range ids {
+				if len(entries) == count {
+					break
+				}
+				if start == "-" || id > strings.TrimPrefix(start, "(") {
+					entries = append(entries, "*2\r\n$"+strconv.Itoa(len(id))+"\r\n"+id+"\r\n*2\r\n$7\r\npayload\r\n$7\r\n{\"n\":1}\r\n")
+				}
+			}
+			io.WriteString(conn, "*"+strconv.Itoa(len(entries))+"\r\n"+strings.Join(entries, ""))
+		}
+	}()
+
+	return "redis://" + listener.Addr().String()
+}
+
+func TestRedisStreamSourceReadsAfterOffset(t *testing.T) {
+	source, err := NewRedisStreamSource(fakeRedis(t, []string{"1-0", "2-0", "3-0"}), "orders")
+	require.NoError(t, err)
+	defer source.Close()
+
+	records, err := source.Read(context.Background(), "1-0", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "2-0", records[0].Offset)
+	assert.JSONEq(t, `{"n":1}`, string(records[0].Payload))
+
+	pending, err := source.Pending(context.Background(), "2-0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+}
+
+func TestStreamEntryPayload(t *testing.T) {
+	entry := streamEntry{id: "1-0", fields: []string{"user", "42", "action", "login"}}
+	assert.JSONEq(t, `{"user":"42","action":"login"}`, string(entry.payload()))
+
+	entry = streamEntry{id: "1-0", fields: []string{"data", `{"user":42}`}}
+	assert.JSONEq(t, `{"user":42}`, string(entry.payload()))
+}
@@ -0,0 +1,84 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// RESTPublisher publishes into a GoRTMS queue through the REST API, with a
+// user token or a service account signing its requests
+type RESTPublisher struct {
+	BaseURL string
+	Domain  string
+	Queue   string
+
+	// Token is a JWT, used when no service account is set
+	Token string
+
+	ServiceID     string
+	ServiceSecret string
+
+	Client *http.Client
+}
+
+func (p *RESTPublisher) path() string {
+	return "/api/domains/" + url.PathEscape(p.Domain) + "/queues/" + url.PathEscape(p.Queue) + "/messages"
+}
+
+// Publish sends the record with its producer sequence. A conflict means the
+// sequence is older than the session window, so already published.
+func (p *RESTPublisher) Publish(ctx context.Context, producerID string, record Record) error {
+	path := p.path()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.BaseURL, "/")+path, bytes.NewReader(record.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.HeaderProducerID, producerID)
+	req.Header.Set(model.HeaderProducerSeq, strconv.FormatUint(record.Seq, 10))
+
+	if p.ServiceID != "" {
+		timestamp := time.Now().UTC().Format(time.RFC3339)
+		req.Header.Set("X-Service-ID", p.ServiceID)
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Signature", sign(http.MethodPost, path, record.Payload, timestamp, p.ServiceSecret))
+	} else if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusConflict {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("gortms: %s %s", resp.Status, strings.TrimSpace(string(message)))
+}
+
+// sign builds the signature the HMAC middleware expects
+func sign(method, path string, body []byte, timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s", method, path, body, timestamp)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
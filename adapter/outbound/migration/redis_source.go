@@ -0,0 +1,254 @@
+package migration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisTimeout = 10 * time.Second
+
+	// redisCountPage is the page size used to count the pending entries
+	redisCountPage = 1000
+)
+
+// RedisStreamSource reads a Redis stream with XRANGE. Reading doesn't
+// consume the entries, the stream can be migrated again from any offset.
+type RedisStreamSource struct {
+	stream string
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+// NewRedisStreamSource connects to redis://[user:password@]host:port[/db]
+func NewRedisStreamSource(rawURL, stream string) (*RedisStreamSource, error) {
+	if stream == "" {
+		return nil, errors.New("redis stream name is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis url %q, expected redis://host:port/db", rawURL)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	conn, err := net.DialTimeout("tcp", address, redisTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	source := &RedisStreamSource{
+		stream: stream,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+
+	if password, ok := u.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if user := u.User.Username(); user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if _, err := source.do(context.Background(), args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" && db != "0" {
+		if _, err := source.do(context.Background(), "SELECT", db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return source, nil
+}
+
+func (s *RedisStreamSource) Name() string {
+	return "redis:" + s.stream
+}
+
+func (s *RedisStreamSource) Drains() bool {
+	return false
+}
+
+func (s *RedisStreamSource) Close() error {
+	return s.conn.Close()
+}
+
+func (s *RedisStreamSource) Read(ctx context.Context, after string, limit int) ([]Record, error) {
+	entries, err := s.xrange(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, Record{
+			Offset:  entry.id,
+			Payload: entry.payload(),
+		})
+	}
+	return records, nil
+}
+
+// Pending pages through the stream, Redis has no count of the entries
+// after an ID
+func (s *RedisStreamSource) Pending(ctx context.Context, after string) (int64, error) {
+	var pending int64
+	for {
+		entries, err := s.xrange(ctx, after, redisCountPage)
+		if err != nil {
+			return 0, err
+		}
+		pending += int64(len(entries))
+		if len(entries) < redisCountPage {
+			return pending, nil
+		}
+		after = entries[len(entries)-1].id
+	}
+}
+
+type streamEntry struct {
+	id     string
+	fields []string // field, value, field, value...
+}
+
+// payload keeps the value of an entry made of a single JSON object field
+// (XADD orders * payload '{...}'), the fields become the object otherwise
+func (e streamEntry) payload() json.RawMessage {
+	if len(e.fields) == 2 {
+		var object map[string]json.RawMessage
+		if json.Unmarshal([]byte(e.fields[1]), &object) == nil && object != nil {
+			return json.RawMessage(e.fields[1])
+		}
+	}
+
+	object := make(map[string]string, len(e.fields)/2)
+	for i := 0; i+1 < len(e.fields); i += 2 {
+		object[e.fields[i]] = e.fields[i+1]
+	}
+	payload, _ := json.Marshal(object)
+	return payload
+}
+
+// xrange reads the entries after the ID, exclusive ranges need Redis 6.2
+func (s *RedisStreamSource) xrange(ctx context.Context, after string, count int) ([]streamEntry, error) {
+	start := "-"
+	if after != "" {
+		start = "(" + after
+	}
+
+	reply, err := s.do(ctx, "XRANGE", s.stream, start, "+", "COUNT", strconv.Itoa(count))
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected XRANGE reply %T", reply)
+	}
+
+	entries := make([]streamEntry, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, errors.New("malformed stream entry")
+		}
+		id, _ := pair[0].(string)
+		rawFields, _ := pair[1].([]any)
+
+		entry := streamEntry{id: id, fields: make([]string, 0, len(rawFields))}
+		for _, field := range rawFields {
+			value, _ := field.(string)
+			entry.fields = append(entry.fields, value)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// do sends a command and reads its reply with the RESP protocol
+func (s *RedisStreamSource) do(ctx context.Context, args ...string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(redisTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	s.conn.SetDeadline(deadline)
+
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, command.String()); err != nil {
+		return nil, err
+	}
+
+	return readRESP(s.reader)
+}
+
+// readRESP reads one reply, bulk strings come back as strings and arrays
+// as []any
+func readRESP(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		items := make([]any, size)
+		for i := range items {
+			if items[i], err = readRESP(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply %q", line)
+}
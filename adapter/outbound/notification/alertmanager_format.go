@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+const (
+	// alertmanagerAlertName names the queue capacity alerts
+	alertmanagerAlertName = "GoRTMSQueueCapacity"
+
+	// alertmanagerReceiver is the receiver the webhooks claim to come from
+	alertmanagerReceiver = "gortms"
+)
+
+// alertmanagerWebhook is the body Alertmanager posts to its webhook
+// receivers (version 4), each post holding a single alert
+type alertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []alertmanagerAlert `json:"alerts"`
+}
+
+type alertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"` // zero while firing
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// alertmanagerMessage formats a capacity alert or its resolution. The
+// labels of a resolution are those of the alert it resolves, so receivers
+// match them by fingerprint.
+func alertmanagerMessage(notification model.Notification) any {
+	data := notification.Data
+	queue := fmt.Sprintf("%v.%v", data["Domain"], data["Queue"])
+	usage, _ := data["Usage"].(float64)
+
+	labels := map[string]string{
+		"alertname": alertmanagerAlertName,
+		"domain":    fmt.Sprint(data["Domain"]),
+		"queue":     fmt.Sprint(data["Queue"]),
+		"severity":  fmt.Sprint(data["Severity"]),
+	}
+	annotations := map[string]string{
+		"summary":     fmt.Sprintf("Queue %s is %.0f%% full", queue, usage),
+		"description": fmt.Sprintf("Queue %s reached the %s capacity level.", queue, labels["severity"]),
+	}
+
+	alert := alertmanagerAlert{
+		Status:      "firing",
+		Labels:      labels,
+		Annotations: annotations,
+		Fingerprint: alertFingerprint(labels),
+	}
+	alert.StartsAt, _ = data["Since"].(time.Time)
+	if notification.Type == model.NotificationAlertResolved {
+		alert.Status = "resolved"
+		alert.EndsAt, _ = data["ResolvedAt"].(time.Time)
+	}
+
+	return alertmanagerWebhook{
+		Version:           "4",
+		GroupKey:          fmt.Sprintf("{}:{alertname=%q, domain=%q, queue=%q}", alertmanagerAlertName, labels["domain"], labels["queue"]),
+		Status:            alert.Status,
+		Receiver:          alertmanagerReceiver,
+		GroupLabels:       map[string]string{"alertname": alertmanagerAlertName},
+		CommonLabels:      labels,
+		CommonAnnotations: annotations,
+		Alerts:            []alertmanagerAlert{alert},
+	}
+}
+
+// alertFingerprint identifies an alert by its labels, like Alertmanager
+func alertFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0xff})
+		h.Write([]byte(labels[name]))
+		h.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// severityColors are the accent colors of the alert severities
+var severityColors = map[string]string{
+	"critical": "#d32f2f",
+	"warning":  "#f9a825",
+}
+
+// defaultColor accents the messages other than alerts
+const defaultColor = "#1976d2"
+
+// resolvedColor accents the resolved alerts
+const resolvedColor = "#388e3c"
+
+// chatFact is a labelled value shown under a chat message
+type chatFact struct {
+	Name  string
+	Value string
+}
+
+// chatMessage is a notification formatted independently of the chat
+type chatMessage struct {
+	Title string
+	Text  string
+	Color string
+	Facts []chatFact
+}
+
+// newChatMessage formats an alert or an account request
+func newChatMessage(notification model.Notification) chatMessage {
+	data := notification.Data
+	switch notification.Type {
+	case model.NotificationAlert:
+		severity := fmt.Sprint(data["Severity"])
+		queue := fmt.Sprintf("%v.%v", data["Domain"], data["Queue"])
+		usage, _ := data["Usage"].(float64)
+		message := chatMessage{
+			Title: fmt.Sprintf("GoRTMS %s alert on %s", severity, queue),
+			Text:  fmt.Sprintf("Queue %s is %.0f%% full.", queue, usage),
+			Color: severityColors[severity],
+			Facts: []chatFact{
+				{Name: "Queue", Value: queue},
+				{Name: "Severity", Value: severity},
+				{Name: "Usage", Value: fmt.Sprintf("%.0f%%", usage)},
+			},
+		}
+		if since, ok := data["Since"].(time.Time); ok {
+			message.Facts = append(message.Facts, chatFact{Name: "Since", Value: since.Format("2006-01-02 15:04:05 MST")})
+		}
+		if message.Color == "" {
+			message.Color = defaultColor
+		}
+		return message
+	case model.NotificationAlertResolved:
+		severity := fmt.Sprint(data["Severity"])
+		queue := fmt.Sprintf("%v.%v", data["Domain"], data["Queue"])
+		usage, _ := data["Usage"].(float64)
+		message := chatMessage{
+			Title: fmt.Sprintf("GoRTMS %s alert resolved on %s", severity, queue),
+			Text:  fmt.Sprintf("Queue %s is %.0f%% full.", queue, usage),
+			Color: resolvedColor,
+			Facts: []chatFact{
+				{Name: "Queue", Value: queue},
+				{Name: "Severity", Value: severity},
+				{Name: "Usage", Value: fmt.Sprintf("%.0f%%", usage)},
+			},
+		}
+		if since, ok := data["Since"].(time.Time); ok {
+			message.Facts = append(message.Facts, chatFact{Name: "Since", Value: since.Format("2006-01-02 15:04:05 MST")})
+		}
+		return message
+	case model.NotificationAccountRequest:
+		return chatMessage{
+			Title: fmt.Sprintf("GoRTMS account request from %v", data["Username"]),
+			Text:  "Review it from the administration page.",
+			Color: defaultColor,
+			Facts: []chatFact{
+				{Name: "Username", Value: fmt.Sprint(data["Username"])},
+				{Name: "Role", Value: fmt.Sprint(data["Role"])},
+				{Name: "Request", Value: fmt.Sprint(data["RequestID"])},
+			},
+		}
+	default:
+		return chatMessage{
+			Title: fmt.Sprintf("GoRTMS %s notification", notification.Type),
+			Color: defaultColor,
+		}
+	}
+}
+
+// slackMessage is the body of a Slack incoming webhook, an attachment
+// carries the severity color
+func slackMessage(message chatMessage) any {
+	fields := make([]map[string]any, 0, len(message.Facts))
+	for _, fact := range message.Facts {
+		fields = append(fields, map[string]any{
+			"title": fact.Name,
+			"value": fact.Value,
+			"short": true,
+		})
+	}
+	return map[string]any{
+		"text": message.Title,
+		"attachments": []map[string]any{{
+			"color":    message.Color,
+			"title":    message.Title,
+			"text":     message.Text,
+			"fields":   fields,
+			"fallback": message.Title + ": " + message.Text,
+		}},
+	}
+}
+
+// teamsMessage is the body of a Teams incoming webhook, a MessageCard
+func teamsMessage(message chatMessage) any {
+	facts := make([]map[string]string, 0, len(message.Facts))
+	for _, fact := range message.Facts {
+		facts = append(facts, map[string]string{
+			"name":  fact.Name,
+			"value": fact.Value,
+		})
+	}
+	return map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": message.Color[1:],
+		"summary":    message.Title,
+		"title":      message.Title,
+		"text":       message.Text,
+		"sections":   []map[string]any{{"facts": facts}},
+	}
+}
@@ -0,0 +1,225 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+const (
+	defaultRatePerMinute = 20
+	defaultMaxRetries    = 3
+
+	// retryBackoff is the wait before the first retry, doubled for each one
+	retryBackoff = time.Second
+)
+
+// chatFormats build the JSON body of each webhook kind
+var chatFormats = map[string]func(model.Notification) any{
+	config.ChatWebhookSlack: func(notification model.Notification) any {
+		return slackMessage(newChatMessage(notification))
+	},
+	config.ChatWebhookTeams: func(notification model.Notification) any {
+		return teamsMessage(newChatMessage(notification))
+	},
+	config.ChatWebhookAlertmanager: alertmanagerMessage,
+}
+
+// defaultChatTypes are the notifications posted by each webhook kind when
+// its types are left empty
+var defaultChatTypes = map[string][]model.NotificationType{
+	config.ChatWebhookSlack:        {model.NotificationAlert, model.NotificationAccountRequest},
+	config.ChatWebhookTeams:        {model.NotificationAlert, model.NotificationAccountRequest},
+	config.ChatWebhookAlertmanager: {model.NotificationAlert, model.NotificationAlertResolved},
+}
+
+// ChatNotifier posts alerts and account requests to a Slack or Teams
+// incoming webhook, or alerts to an Alertmanager webhook receiver. Posts are
+// paced to the configured rate and failed ones retried with a growing
+// backoff, from a background worker.
+type ChatNotifier struct {
+	logger     outbound.Logger
+	name       string
+	url        string
+	format     func(model.Notification) any
+	types      []model.NotificationType
+	severities []string
+	interval   time.Duration
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+
+	queue chan model.Notification
+	wg    sync.WaitGroup
+	once  sync.Once
+	done  chan struct{}
+}
+
+// NewChatNotifier starts the delivery worker of a webhook
+func NewChatNotifier(cfg config.ChatWebhook, logger outbound.Logger) (*ChatNotifier, error) {
+	format, known := chatFormats[cfg.Kind]
+	if !known {
+		return nil, fmt.Errorf("unknown webhook kind: %s", cfg.Kind)
+	}
+
+	n := &ChatNotifier{
+		logger:     logger,
+		name:       cfg.Name,
+		url:        cfg.URL,
+		format:     format,
+		types:      defaultChatTypes[cfg.Kind],
+		severities: cfg.Severities,
+		interval:   time.Minute / defaultRatePerMinute,
+		maxRetries: defaultMaxRetries,
+		backoff:    retryBackoff,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan model.Notification, queueSize),
+		done:       make(chan struct{}),
+	}
+	if n.name == "" {
+		n.name = cfg.Kind
+	}
+	if len(cfg.Types) > 0 {
+		n.types = nil
+		for _, kind := range cfg.Types {
+			n.types = append(n.types, model.NotificationType(kind))
+		}
+	}
+	if cfg.RatePerMinute > 0 {
+		n.interval = time.Minute / time.Duration(cfg.RatePerMinute)
+	}
+	if cfg.MaxRetries > 0 {
+		n.maxRetries = cfg.MaxRetries
+	}
+
+	n.wg.Add(1)
+	go n.deliver()
+	return n, nil
+}
+
+// Enabled reports whether the webhook posts notifications of the type
+func (n *ChatNotifier) Enabled(kind model.NotificationType) bool {
+	return slices.Contains(n.types, kind)
+}
+
+// Notify queues a notification, alerts of other severities than the
+// configured ones are dropped, along with their resolutions
+func (n *ChatNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	if !n.Enabled(notification.Type) {
+		return nil
+	}
+	isAlert := notification.Type == model.NotificationAlert || notification.Type == model.NotificationAlertResolved
+	if isAlert && len(n.severities) > 0 {
+		severity, _ := notification.Data["Severity"].(string)
+		if !slices.Contains(n.severities, severity) {
+			return nil
+		}
+	}
+
+	select {
+	case n.queue <- notification:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// Close posts the queued notifications and stops the worker, retries
+// still waiting are abandoned
+func (n *ChatNotifier) Close() {
+	n.once.Do(func() {
+		close(n.queue)
+		close(n.done)
+	})
+	n.wg.Wait()
+}
+
+// deliver posts the queued notifications, one per interval at most
+func (n *ChatNotifier) deliver() {
+	defer n.wg.Done()
+
+	var last time.Time
+	for notification := range n.queue {
+		if wait := time.Until(last.Add(n.interval)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-n.done:
+			}
+		}
+		last = time.Now()
+
+		if err := n.post(n.format(notification)); err != nil {
+			n.logger.Error("Webhook notification not sent",
+				"webhook", n.name,
+				"type", notification.Type,
+				"ERROR", err)
+		}
+	}
+}
+
+// post sends a message, retrying network errors, 429 and 5xx answers
+func (n *ChatNotifier) post(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := n.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= n.maxRetries {
+			return err
+		}
+
+		wait := max(backoff, retryAfter)
+		backoff *= 2
+		select {
+		case <-time.After(wait):
+		case <-n.done:
+			return err
+		}
+	}
+}
+
+// postOnce sends a message once, retryAfter is negative when retrying
+// wouldn't help
+func (n *ChatNotifier) postOnce(body []byte) (retryAfter time.Duration, err error) {
+	return postJSON(n.client, n.url, body)
+}
+
+// postJSON sends a JSON body once, retryAfter is negative when retrying
+// wouldn't help
+func postJSON(client *http.Client, url string, body []byte) (retryAfter time.Duration, err error) {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("webhook rate limited: %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("webhook failed: %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("webhook refused: %s", resp.Status)
+	}
+}
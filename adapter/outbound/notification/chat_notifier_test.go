@@ -0,0 +1,211 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer records the bodies posted to it, answering with statuses
+// in turn and 200 once they run out
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	bodies   []map[string]any
+	times    []time.Time
+	received chan struct{}
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.times = append(s.times, time.Now())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		s.received <- struct{}{}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// wait blocks until count posts were received
+func (s *webhookServer) wait(t *testing.T, count int) {
+	for range count {
+		select {
+		case <-s.received:
+		case <-time.After(2 * time.Second):
+			t.Fatal("webhook not posted")
+		}
+	}
+}
+
+func newTestChatNotifier(t *testing.T, cfg config.ChatWebhook) *ChatNotifier {
+	n, err := NewChatNotifier(cfg, nopLogger{})
+	require.NoError(t, err)
+	n.backoff = time.Millisecond
+	t.Cleanup(n.Close)
+	return n
+}
+
+func alertNotification(severity string) model.Notification {
+	return model.Notification{
+		Type: model.NotificationAlert,
+		Data: map[string]any{
+			"Domain":   "orders",
+			"Queue":    "pending",
+			"Severity": severity,
+			"Usage":    92.4,
+			"Since":    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestChatNotifierSlackFormatAndSeverities(t *testing.T) {
+	server := newWebhookServer(t)
+	n := newTestChatNotifier(t, config.ChatWebhook{
+		Kind:       config.ChatWebhookSlack,
+		URL:        server.URL,
+		Severities: []string{"critical"},
+	})
+
+	assert.True(t, n.Enabled(model.NotificationAlert))
+	assert.True(t, n.Enabled(model.NotificationAccountRequest))
+	assert.False(t, n.Enabled(model.NotificationPasswordReset))
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, alertNotification("warning")))
+	require.NoError(t, n.Notify(ctx, alertNotification("critical")))
+	server.wait(t, 1)
+	n.Close()
+
+	require.Len(t, server.bodies, 1, "warning alerts are filtered out")
+	body := server.bodies[0]
+	assert.Equal(t, "GoRTMS critical alert on orders.pending", body["text"])
+	attachment := body["attachments"].([]any)[0].(map[string]any)
+	assert.Equal(t, "#d32f2f", attachment["color"])
+	assert.Equal(t, "Queue orders.pending is 92% full.", attachment["text"])
+	assert.Len(t, attachment["fields"], 4)
+}
+
+func TestChatNotifierTeamsFormat(t *testing.T) {
+	server := newWebhookServer(t)
+	n := newTestChatNotifier(t, config.ChatWebhook{
+		Kind:  config.ChatWebhookTeams,
+		URL:   server.URL,
+		Types: []string{"account-request"},
+	})
+	assert.False(t, n.Enabled(model.NotificationAlert))
+
+	require.NoError(t, n.Notify(context.Background(), model.Notification{
+		Type: model.NotificationAccountRequest,
+		Data: map[string]any{"RequestID": "req-1", "Username": "alice", "Role": model.RoleUser},
+	}))
+	server.wait(t, 1)
+	n.Close()
+
+	body := server.bodies[0]
+	assert.Equal(t, "MessageCard", body["@type"])
+	assert.Equal(t, "1976d2", body["themeColor"])
+	assert.Equal(t, "GoRTMS account request from alice", body["title"])
+	facts := body["sections"].([]any)[0].(map[string]any)["facts"].([]any)
+	assert.Equal(t, map[string]any{"name": "Role", "value": "user"}, facts[1])
+}
+
+func TestChatNotifierAlertmanagerFormat(t *testing.T) {
+	server := newWebhookServer(t)
+	n := newTestChatNotifier(t, config.ChatWebhook{Kind: config.ChatWebhookAlertmanager, URL: server.URL, RatePerMinute: 6000})
+	assert.True(t, n.Enabled(model.NotificationAlertResolved))
+	assert.False(t, n.Enabled(model.NotificationAccountRequest))
+
+	firing := alertNotification("critical")
+	resolved := alertNotification("critical")
+	resolved.Type = model.NotificationAlertResolved
+	resolved.Data["ResolvedAt"] = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, firing))
+	require.NoError(t, n.Notify(ctx, resolved))
+	server.wait(t, 2)
+	n.Close()
+
+	body := server.bodies[0]
+	assert.Equal(t, "4", body["version"])
+	assert.Equal(t, "firing", body["status"])
+	alert := body["alerts"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"alertname": "GoRTMSQueueCapacity",
+		"domain":    "orders",
+		"queue":     "pending",
+		"severity":  "critical",
+	}, alert["labels"])
+	assert.Equal(t, "2024-05-01T10:00:00Z", alert["startsAt"])
+	assert.Equal(t, "0001-01-01T00:00:00Z", alert["endsAt"])
+
+	resolvedAlert := server.bodies[1]["alerts"].([]any)[0].(map[string]any)
+	assert.Equal(t, "resolved", resolvedAlert["status"])
+	assert.Equal(t, "2024-05-01T10:30:00Z", resolvedAlert["endsAt"])
+	assert.Equal(t, alert["fingerprint"], resolvedAlert["fingerprint"])
+}
+
+func TestChatNotifierRetries(t *testing.T) {
+	server := newWebhookServer(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	n := newTestChatNotifier(t, config.ChatWebhook{Kind: config.ChatWebhookSlack, URL: server.URL, MaxRetries: 2})
+
+	require.NoError(t, n.Notify(context.Background(), alertNotification("warning")))
+	server.wait(t, 3)
+	n.Close()
+	assert.Len(t, server.bodies, 3)
+}
+
+func TestChatNotifierDoesNotRetryRefusals(t *testing.T) {
+	server := newWebhookServer(t, http.StatusBadRequest)
+	n := newTestChatNotifier(t, config.ChatWebhook{Kind: config.ChatWebhookSlack, URL: server.URL})
+
+	_, err := n.postOnce([]byte(`{}`))
+	assert.Error(t, err)
+	require.NoError(t, n.Notify(context.Background(), alertNotification("warning")))
+	server.wait(t, 2)
+	n.Close()
+	assert.Len(t, server.bodies, 2, "the refused post is not retried")
+}
+
+func TestChatNotifierRateLimit(t *testing.T) {
+	server := newWebhookServer(t)
+	n := newTestChatNotifier(t, config.ChatWebhook{Kind: config.ChatWebhookSlack, URL: server.URL, RatePerMinute: 1200})
+
+	for range 3 {
+		require.NoError(t, n.Notify(context.Background(), alertNotification("warning")))
+	}
+	server.wait(t, 3)
+	n.Close()
+
+	for i := 1; i < len(server.times); i++ {
+		assert.GreaterOrEqual(t, server.times[i].Sub(server.times[i-1]), 40*time.Millisecond)
+	}
+}
+
+func TestNewChatNotifierRejectsUnknownKind(t *testing.T) {
+	_, err := NewChatNotifier(config.ChatWebhook{Kind: "irc", URL: "http://example.com"}, nopLogger{})
+	assert.Error(t, err)
+}
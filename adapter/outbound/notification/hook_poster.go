@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+type hookPost struct {
+	url   string
+	event model.QueueControlEvent
+}
+
+// HookPoster posts the control events of queue hooks to their webhooks from
+// a background worker, retrying failed posts with a growing backoff like
+// the chat webhooks
+type HookPoster struct {
+	logger     outbound.Logger
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+
+	queue chan hookPost
+	wg    sync.WaitGroup
+	once  sync.Once
+	done  chan struct{}
+}
+
+// NewHookPoster starts the delivery worker of the queue hooks
+func NewHookPoster(logger outbound.Logger) *HookPoster {
+	p := &HookPoster{
+		logger:     logger,
+		maxRetries: defaultMaxRetries,
+		backoff:    retryBackoff,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan hookPost, queueSize),
+		done:       make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.deliver()
+	return p
+}
+
+// Post queues an event, it is dropped when the queue is full
+func (p *HookPoster) Post(url string, event model.QueueControlEvent) {
+	select {
+	case p.queue <- hookPost{url: url, event: event}:
+	default:
+		p.logger.Warn("Queue hook dropped, queue full",
+			"queue", event.Domain+"."+event.Queue,
+			"event", event.Event)
+	}
+}
+
+// Close posts the queued events and stops the worker, retries still
+// waiting are abandoned
+func (p *HookPoster) Close() {
+	p.once.Do(func() {
+		close(p.queue)
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+func (p *HookPoster) deliver() {
+	defer p.wg.Done()
+
+	for post := range p.queue {
+		if err := p.post(post); err != nil {
+			p.logger.Error("Queue hook not sent",
+				"queue", post.event.Domain+"."+post.event.Queue,
+				"event", post.event.Event,
+				"ERROR", err)
+		}
+	}
+}
+
+// post sends an event, retrying network errors, 429 and 5xx answers
+func (p *HookPoster) post(post hookPost) error {
+	body, err := json.Marshal(post.event)
+	if err != nil {
+		return err
+	}
+
+	backoff := p.backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := postJSON(p.client, post.url, body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= p.maxRetries {
+			return err
+		}
+
+		wait := max(backoff, retryAfter)
+		backoff *= 2
+		select {
+		case <-time.After(wait):
+		case <-p.done:
+			return err
+		}
+	}
+}
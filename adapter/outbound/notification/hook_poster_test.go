@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookPoster(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable)
+	p := NewHookPoster(nopLogger{})
+	p.backoff = time.Millisecond
+
+	p.Post(server.URL, model.QueueControlEvent{Event: model.QueueHookFull, Domain: "shop", Queue: "orders", MessageCount: 10})
+	server.wait(t, 2)
+	p.Close()
+
+	assert.Len(t, server.bodies, 2, "the failed post is retried")
+	assert.Equal(t, "full", server.bodies[1]["event"])
+	assert.Equal(t, "orders", server.bodies[1]["queue"])
+	assert.Equal(t, float64(10), server.bodies[1]["messageCount"])
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// Notifiers hands notifications to every notifier sending their type
+type Notifiers []interface {
+	Enabled(kind model.NotificationType) bool
+	Notify(ctx context.Context, notification model.Notification) error
+	Close()
+}
+
+// Enabled reports whether one of the notifiers sends the type
+func (n Notifiers) Enabled(kind model.NotificationType) bool {
+	for _, notifier := range n {
+		if notifier.Enabled(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify hands a notification to the notifiers sending its type
+func (n Notifiers) Notify(ctx context.Context, notification model.Notification) error {
+	var errs []error
+	for _, notifier := range n {
+		if notifier.Enabled(notification.Type) {
+			errs = append(errs, notifier.Notify(ctx, notification))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops every notifier
+func (n Notifiers) Close() {
+	for _, notifier := range n {
+		notifier.Close()
+	}
+}
@@ -0,0 +1,204 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// queueSize bounds the notifications waiting for the SMTP server, the
+// services raising them never wait for it
+const queueSize = 100
+
+var errQueueFull = errors.New("notification queue full")
+
+// defaultTemplates are used for the subjects and bodies left empty in config
+var defaultTemplates = map[model.NotificationType][2]string{
+	model.NotificationAlert: {
+		`[GoRTMS] {{.Severity}} alert on {{.Domain}}.{{.Queue}}`,
+		`Queue {{.Domain}}.{{.Queue}} is {{printf "%.0f" .Usage}}% full since {{.Since.Format "2006-01-02 15:04:05 MST"}}.`,
+	},
+	model.NotificationAlertResolved: {
+		`[GoRTMS] {{.Severity}} alert resolved on {{.Domain}}.{{.Queue}}`,
+		`Queue {{.Domain}}.{{.Queue}} is {{printf "%.0f" .Usage}}% full, the {{.Severity}} alert raised {{.Since.Format "2006-01-02 15:04:05 MST"}} is resolved.`,
+	},
+	model.NotificationAccountRequest: {
+		`[GoRTMS] Account request from {{.Username}}`,
+		`{{.Username}} requested a {{.Role}} account (request {{.RequestID}}). Review it from the administration page.`,
+	},
+	model.NotificationAccountReviewed: {
+		`[GoRTMS] Your account request was {{.Status}}`,
+		`{{if eq (print .Status) "approved"}}Your {{.Role}} account {{.Username}} is ready, sign in with the password you chose.{{else}}Your request for the account {{.Username}} was rejected.{{with .Reason}} Reason: {{.}}{{end}}{{end}}`,
+	},
+	model.NotificationPasswordReset: {
+		`[GoRTMS] Your password was reset`,
+		`{{.ResetBy}} reset the password of {{.Username}}. Your temporary password is {{.Password}}, change it after signing in.`,
+	},
+}
+
+// sendFunc sends an email, smtp.SendMail outside of tests
+type sendFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// notificationType is a parsed, enabled notification type
+type notificationType struct {
+	recipients []string
+	subject    *template.Template
+	body       *template.Template
+}
+
+// SMTPNotifier emails notifications from a background worker
+type SMTPNotifier struct {
+	logger outbound.Logger
+	addr   string
+	auth   smtp.Auth
+	from   string
+	types  map[model.NotificationType]notificationType
+	send   sendFunc
+
+	queue chan model.Notification
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewSMTPNotifier parses the templates of the enabled types and starts the
+// delivery worker
+func NewSMTPNotifier(cfg config.NotificationConfig, logger outbound.Logger) (*SMTPNotifier, error) {
+	n := &SMTPNotifier{
+		logger: logger,
+		addr:   net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)),
+		from:   cfg.SMTP.From,
+		types:  make(map[model.NotificationType]notificationType),
+		send:   smtp.SendMail,
+		queue:  make(chan model.Notification, queueSize),
+	}
+	if cfg.SMTP.Username != "" {
+		n.auth = smtp.PlainAuth("", cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.Host)
+	}
+
+	for name, settings := range cfg.Types {
+		kind := model.NotificationType(name)
+		defaults, known := defaultTemplates[kind]
+		if !known {
+			return nil, fmt.Errorf("unknown notification type: %s", name)
+		}
+		if !settings.Enabled {
+			continue
+		}
+
+		subject, body := settings.Subject, settings.Body
+		if subject == "" {
+			subject = defaults[0]
+		}
+		if body == "" {
+			body = defaults[1]
+		}
+		parsed := notificationType{recipients: settings.Recipients}
+		var err error
+		if parsed.subject, err = template.New(name).Option("missingkey=zero").Parse(subject); err != nil {
+			return nil, fmt.Errorf("notification %s subject: %w", name, err)
+		}
+		if parsed.body, err = template.New(name).Option("missingkey=zero").Parse(body); err != nil {
+			return nil, fmt.Errorf("notification %s body: %w", name, err)
+		}
+		n.types[kind] = parsed
+	}
+
+	n.wg.Add(1)
+	go n.deliver()
+	return n, nil
+}
+
+// Enabled reports whether notifications of the type are sent
+func (n *SMTPNotifier) Enabled(kind model.NotificationType) bool {
+	_, enabled := n.types[kind]
+	return enabled
+}
+
+// Notify queues a notification, it fails only when the queue is full
+func (n *SMTPNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	if !n.Enabled(notification.Type) {
+		return nil
+	}
+	select {
+	case n.queue <- notification:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// Close sends the queued notifications and stops the worker
+func (n *SMTPNotifier) Close() {
+	n.once.Do(func() { close(n.queue) })
+	n.wg.Wait()
+}
+
+// deliver sends the queued notifications one at a time
+func (n *SMTPNotifier) deliver() {
+	defer n.wg.Done()
+	for notification := range n.queue {
+		if err := n.sendNotification(notification); err != nil {
+			n.logger.Error("Notification not sent",
+				"type", notification.Type,
+				"ERROR", err)
+		}
+	}
+}
+
+// sendNotification renders a notification and sends it to its recipients
+func (n *SMTPNotifier) sendNotification(notification model.Notification) error {
+	settings := n.types[notification.Type]
+
+	var recipients []string
+	for _, to := range slices.Concat(settings.recipients, notification.To) {
+		if to != "" && !slices.Contains(recipients, to) {
+			recipients = append(recipients, to)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	message, err := n.render(settings, notification, recipients)
+	if err != nil {
+		return err
+	}
+	return n.send(n.addr, n.auth, n.from, recipients, message)
+}
+
+// render builds the email of a notification
+func (n *SMTPNotifier) render(settings notificationType, notification model.Notification, recipients []string) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := settings.subject.Execute(&subject, notification.Data); err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	if err := settings.body.Execute(&body, notification.Data); err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", n.from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	message.WriteString("\r\n")
+	return message.Bytes(), nil
+}
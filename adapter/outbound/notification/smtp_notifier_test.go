@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(level string)      {}
+func (nopLogger) Shutdown()                     {}
+
+// sentMail is an email captured instead of being sent
+type sentMail struct {
+	addr    string
+	from    string
+	to      []string
+	message string
+}
+
+func newTestNotifier(t *testing.T, types map[string]config.NotificationTemplate) (*SMTPNotifier, *[]sentMail) {
+	cfg := config.NotificationConfig{
+		Enabled: true,
+		SMTP:    config.SMTPConfig{Host: "smtp.example.com", Port: 587, From: "gortms@example.com"},
+		Types:   types,
+	}
+	n, err := NewSMTPNotifier(cfg, nopLogger{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var sent []sentMail
+	n.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, sentMail{addr: addr, from: from, to: to, message: string(msg)})
+		return nil
+	}
+	return n, &sent
+}
+
+func TestSMTPNotifierDefaultTemplates(t *testing.T) {
+	n, sent := newTestNotifier(t, map[string]config.NotificationTemplate{
+		"alert":          {Enabled: true, Recipients: []string{"ops@example.com"}},
+		"password-reset": {Enabled: false},
+	})
+
+	assert.True(t, n.Enabled(model.NotificationAlert))
+	assert.False(t, n.Enabled(model.NotificationPasswordReset))
+	assert.False(t, n.Enabled(model.NotificationAccountRequest))
+
+	require.NoError(t, n.Notify(context.Background(), model.Notification{
+		Type: model.NotificationAlert,
+		To:   []string{"ops@example.com", "oncall@example.com"},
+		Data: map[string]any{
+			"Domain":   "shop",
+			"Queue":    "orders",
+			"Severity": "critical",
+			"Usage":    92.4,
+			"Since":    time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC),
+		},
+	}))
+	require.NoError(t, n.Notify(context.Background(), model.Notification{Type: model.NotificationPasswordReset}))
+	n.Close()
+
+	require.Len(t, *sent, 1, "disabled types are dropped")
+	mail := (*sent)[0]
+	assert.Equal(t, "smtp.example.com:587", mail.addr)
+	assert.Equal(t, "gortms@example.com", mail.from)
+	assert.Equal(t, []string{"ops@example.com", "oncall@example.com"}, mail.to)
+	assert.Contains(t, mail.message, "Subject: [GoRTMS] critical alert on shop.orders\r\n")
+	assert.Contains(t, mail.message, "To: ops@example.com, oncall@example.com\r\n")
+	assert.True(t, strings.HasSuffix(mail.message, "\r\n\r\nQueue shop.orders is 92% full since 2026-03-01 08:30:00 UTC.\r\n"), mail.message)
+}
+
+func TestSMTPNotifierCustomTemplates(t *testing.T) {
+	n, sent := newTestNotifier(t, map[string]config.NotificationTemplate{
+		"account-reviewed": {
+			Enabled: true,
+			Subject: "Request {{.Status}}",
+			Body:    "Hello {{.Username}},\n{{.Reason}}",
+		},
+	})
+
+	require.NoError(t, n.Notify(context.Background(), model.Notification{
+		Type: model.NotificationAccountReviewed,
+		To:   []string{"alice@example.com"},
+		Data: map[string]any{"Username": "alice", "Status": model.AccountRequestRejected, "Reason": "No"},
+	}))
+	// nobody to send to
+	require.NoError(t, n.Notify(context.Background(), model.Notification{Type: model.NotificationAccountReviewed}))
+	n.Close()
+
+	require.Len(t, *sent, 1)
+	assert.Contains(t, (*sent)[0].message, "Subject: Request rejected\r\n")
+	assert.Contains(t, (*sent)[0].message, "\r\n\r\nHello alice,\r\nNo\r\n")
+}
+
+func TestNewSMTPNotifierRejectsBadTemplates(t *testing.T) {
+	_, err := NewSMTPNotifier(config.NotificationConfig{Types: map[string]config.NotificationTemplate{
+		"alert": {Enabled: true, Body: "{{.Queue"},
+	}}, nopLogger{})
+	assert.Error(t, err)
+
+	_, err = NewSMTPNotifier(config.NotificationConfig{Types: map[string]config.NotificationTemplate{
+		"digest": {Enabled: true},
+	}}, nopLogger{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// implements BlobStore with one file per key
+type FileBlobStore struct {
+	dir string
+}
+
+func NewFileBlobStore(dir string) (outbound.BlobStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+// keys are hashed so they never escape the blob directory
+func (s *FileBlobStore) pathFor(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+func (s *FileBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	path := s.pathFor(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// write then rename so readers never see a partial blob
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+func (s *FileBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.pathFor(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+func (s *FileBlobStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.pathFor(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
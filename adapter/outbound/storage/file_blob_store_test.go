@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	key := "orders/incoming/../../msg-1"
+	require.NoError(t, store.Put(ctx, key, []byte("large payload")))
+
+	data, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("large payload"), data)
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	// deleting twice is fine
+	assert.NoError(t, store.Delete(ctx, key))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,6 +16,7 @@ type ConsumerGroupRepository struct {
 	logger outbound.Logger
 	// Map Domain -> Queue -> GroupID -> Consumer Group
 	groups      map[string]map[string]map[string]*model.ConsumerGroup
+	archived    map[string]*model.ArchivedGroup // "domain:queue:group" -> archived position
 	messageRepo outbound.MessageRepository
 	mu          sync.RWMutex
 }
@@ -27,6 +29,7 @@ func NewConsumerGroupRepository(
 	return &ConsumerGroupRepository{
 		logger:      logger,
 		groups:      make(map[string]map[string]map[string]*model.ConsumerGroup),
+		archived:    make(map[string]*model.ArchivedGroup),
 		messageRepo: messageRepo,
 	}
 }
@@ -113,9 +116,19 @@ func (r *ConsumerGroupRepository) RegisterConsumer(
 			TTL:          0,
 			LastActivity: now,
 			MessageCount: 0,
+			DeliveryMode: model.GroupDeliveryShared,
 		}
 		r.groups[domainName][queueName][groupID] = group
 
+		// A group coming back after an archiving cleanup resumes where it was
+		archiveKey := domainName + ":" + queueName + ":" + groupID
+		if archived, exists := r.archived[archiveKey]; exists {
+			group.Position = archived.Position
+			delete(r.archived, archiveKey)
+			r.logger.Info("Restored archived consumer group position",
+				"group", archiveKey, "position", archived.Position)
+		}
+
 		// Add group to ackMatrix
 		matrix := r.messageRepo.GetOrCreateAckMatrix(domainName, queueName)
 		matrix.RegisterGroup(groupID)
@@ -206,7 +219,15 @@ func (r *ConsumerGroupRepository) DeleteGroup(
 	return nil
 }
 
+// CleanupStaleGroups deletes the groups idle for longer than their TTL,
+// olderThan for the groups without one
 func (r *ConsumerGroupRepository) CleanupStaleGroups(ctx context.Context, olderThan time.Duration) error {
+	return r.CleanupGroups(ctx, model.GroupCleanupPolicy{StaleAfter: olderThan, Action: model.GroupCleanupDelete})
+}
+
+// CleanupGroups removes the groups the policy finds stale, archiving the
+// position of those whose action is archive
+func (r *ConsumerGroupRepository) CleanupGroups(ctx context.Context, policy model.GroupCleanupPolicy) error {
 	cleanupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
@@ -229,15 +250,24 @@ func (r *ConsumerGroupRepository) CleanupStaleGroups(ctx context.Context, olderT
 		return warning
 	}
 
-	r.logger.Debug("Starting cleanup of stale consumer groups", "olderThan", olderThan.String())
+	policy = policy.WithDefaults()
+	r.logger.Debug("Starting cleanup of stale consumer groups",
+		"staleAfter", policy.StaleAfter.String(), "action", policy.Action)
 
+	now := time.Now()
 	cleanupCount := 0
 
 	for domainName, domainGroups := range r.groups {
 		for queueName, queueGroups := range domainGroups {
 			for groupID, group := range queueGroups {
-				if group.IsExpired(olderThan) {
-					r.logger.Info("Removing stale consumer group " + domainName + "." + queueName + "." + groupID)
+				schedule := group.CleanupSchedule(policy)
+				if now.After(schedule.CleanupAt) {
+					r.logger.Info("Removing stale consumer group "+domainName+"."+queueName+"."+groupID,
+						"action", schedule.Action)
+
+					if schedule.Action == model.GroupCleanupArchive {
+						r.archived[domainName+":"+queueName+":"+groupID] = group.Archive(now)
+					}
 
 					// Clean AckMatrix
 					ackMatrix := r.messageRepo.GetOrCreateAckMatrix(domainName, queueName)
@@ -282,11 +312,12 @@ func (r *ConsumerGroupRepository) GetGroupDetails(
 		return nil, errors.New("consumer group not found")
 	}
 
-	// Calculate waiting messages count
+	// Calculate waiting messages count on a copy, readers share the lock
+	snapshot := group.Snapshot()
 	matrix := r.messageRepo.GetOrCreateAckMatrix(domainName, queueName)
-	group.MessageCount = matrix.GetPendingMessageCount(groupID)
+	snapshot.MessageCount = matrix.GetPendingMessageCount(groupID)
 
-	return group, nil
+	return snapshot, nil
 }
 
 func (r *ConsumerGroupRepository) GetAllGroups(ctx context.Context) ([]*model.ConsumerGroup, error) {
@@ -299,10 +330,11 @@ func (r *ConsumerGroupRepository) GetAllGroups(ctx context.Context) ([]*model.Co
 		for queueName, queueGroups := range domainGroups {
 			for _, group := range queueGroups {
 				// Update message count
+				snapshot := group.Snapshot()
 				matrix := r.messageRepo.GetOrCreateAckMatrix(domainName, queueName)
-				group.MessageCount = matrix.GetPendingMessageCount(group.GroupID)
+				snapshot.MessageCount = matrix.GetPendingMessageCount(group.GroupID)
 
-				allGroups = append(allGroups, group)
+				allGroups = append(allGroups, snapshot)
 			}
 		}
 	}
@@ -334,6 +366,166 @@ func (r *ConsumerGroupRepository) UpdateLastActivity(
 	return nil
 }
 
+// Heartbeat refreshes the activity of a group, and of one of its consumers
+// when consumerID is given
+func (r *ConsumerGroupRepository) Heartbeat(
+	ctx context.Context,
+	domainName, queueName, groupID, consumerID string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	return group.Heartbeat(consumerID)
+}
+
+// SetGroupCleanupAction overrides the cleanup policy action for a group
+func (r *ConsumerGroupRepository) SetGroupCleanupAction(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	action model.GroupCleanupAction,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.CleanupAction = action
+	return nil
+}
+
+// SetGroupEphemeral makes a group expire once idle, see model.DefaultEphemeralGroupTTL
+func (r *ConsumerGroupRepository) SetGroupEphemeral(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.Ephemeral = true
+	return nil
+}
+
+// ArchivedGroups lists the positions kept by archiving cleanups
+func (r *ConsumerGroupRepository) ArchivedGroups(ctx context.Context) ([]*model.ArchivedGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	archived := make([]*model.ArchivedGroup, 0, len(r.archived))
+	for _, group := range r.archived {
+		copied := *group
+		archived = append(archived, &copied)
+	}
+	sort.Slice(archived, func(i, j int) bool {
+		return archived[i].ArchivedAt.After(archived[j].ArchivedAt)
+	})
+
+	return archived, nil
+}
+
+// SetGroupDeliveryMode changes how a group spreads messages among its consumers
+func (r *ConsumerGroupRepository) SetGroupDeliveryMode(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	mode model.GroupDeliveryMode,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.DeliveryMode = mode
+	group.LastActivity = time.Now()
+	return nil
+}
+
+// SetGroupPriority changes how early a group is served when groups compete
+func (r *ConsumerGroupRepository) SetGroupPriority(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	priority int,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.Priority = priority
+	group.LastActivity = time.Now()
+	return nil
+}
+
+// SetGroupPrefetch changes how many messages are buffered ahead of the group consumers
+func (r *ConsumerGroupRepository) SetGroupPrefetch(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	prefetch int,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.Prefetch = prefetch
+	group.LastActivity = time.Now()
+	return nil
+}
+
+// RecordConsumerDelivery counts a message handed to a consumer of the group
+func (r *ConsumerGroupRepository) RecordConsumerDelivery(
+	ctx context.Context,
+	domainName, queueName, groupID, consumerID string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.RecordDelivery(consumerID)
+	return nil
+}
+
+// RecordConsumerAck counts a message acknowledged by a consumer of the group
+func (r *ConsumerGroupRepository) RecordConsumerAck(
+	ctx context.Context,
+	domainName, queueName, groupID, consumerID string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, exists := r.groups[domainName][queueName][groupID]
+	if !exists {
+		return errors.New("consumer group not found")
+	}
+
+	group.RecordAck(consumerID)
+	return nil
+}
+
 func (r *ConsumerGroupRepository) SetGroupTTL(
 	ctx context.Context,
 	domainName, queueName, groupID string,
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupGroupsArchivesPositions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewConsumerGroupRepository(nopLogger{}, NewMessageRepository(nopLogger{})).(*ConsumerGroupRepository)
+
+	for _, groupID := range []string{"reports", "workers", "audit"} {
+		require.NoError(t, repo.RegisterConsumer(ctx, "shop", "orders", groupID, groupID+"-1"))
+		require.NoError(t, repo.StorePosition(ctx, "shop", "orders", groupID, 42))
+	}
+	require.NoError(t, repo.SetGroupCleanupAction(ctx, "shop", "orders", "workers", model.GroupCleanupDelete))
+	require.NoError(t, repo.SetGroupTTL(ctx, "shop", "orders", "audit", 48*time.Hour))
+
+	stale := time.Now().Add(-5 * time.Hour)
+	for _, groupID := range []string{"reports", "workers", "audit"} {
+		repo.groups["shop"]["orders"][groupID].LastActivity = stale
+	}
+
+	policy := model.GroupCleanupPolicy{StaleAfter: 4 * time.Hour, Action: model.GroupCleanupArchive}
+	require.NoError(t, repo.CleanupGroups(ctx, policy))
+
+	groups, err := repo.ListGroups(ctx, "shop", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"audit"}, groups, "the TTL of audit outlives the policy")
+
+	archived, err := repo.ArchivedGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, archived, 1, "workers overrides the archive action")
+	assert.Equal(t, "reports", archived[0].GroupID)
+	assert.Equal(t, int64(42), archived[0].Position)
+	assert.Equal(t, []string{"reports-1"}, archived[0].ConsumerIDs)
+
+	// The group resumes from its archived position when it comes back
+	require.NoError(t, repo.RegisterConsumer(ctx, "shop", "orders", "reports", "reports-2"))
+	position, err := repo.GetPosition(ctx, "shop", "orders", "reports")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), position)
+	archived, _ = repo.ArchivedGroups(ctx)
+	assert.Empty(t, archived)
+
+	// Deleted groups start over
+	require.NoError(t, repo.RegisterConsumer(ctx, "shop", "orders", "workers", "workers-1"))
+	position, err = repo.GetPosition(ctx, "shop", "orders", "workers")
+	require.NoError(t, err)
+	assert.Zero(t, position)
+}
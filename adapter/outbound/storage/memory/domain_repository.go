@@ -3,46 +3,90 @@ package memory
 import (
 	"context"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/ajkula/GoRTMS/domain/port/outbound"
 )
 
-type DomainRepository struct {
+const (
+	// domainShards spreads the domains over independently locked maps, so
+	// lookups and listings on large installations don't serialize on one lock
+	domainShards = 32
+
+	// defaultDomainPageSize is the page size of ListDomainsPage without a limit
+	defaultDomainPageSize = 500
+)
+
+var errInvalidDomainCursor = errors.New("invalid domain cursor")
+
+type domainShard struct {
+	mutex   sync.RWMutex
 	domains map[string]*model.Domain
+}
+
+type DomainRepository struct {
+	shards  [domainShards]*domainShard
 	logger  outbound.Logger
-	mutex   sync.RWMutex
+	version atomic.Uint64 // last version handed out
 }
 
 func NewDomainRepository(logger outbound.Logger) outbound.DomainRepository {
-	return &DomainRepository{
-		domains: make(map[string]*model.Domain),
-		logger:  logger,
+	r := &DomainRepository{logger: logger}
+	for i := range r.shards {
+		r.shards[i] = &domainShard{domains: make(map[string]*model.Domain)}
 	}
+	return r
+}
+
+func shardIndex(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32() % domainShards)
+}
+
+func (r *DomainRepository) shard(name string) *domainShard {
+	return r.shards[shardIndex(name)]
 }
 
 func (r *DomainRepository) StoreDomain(ctx context.Context, domain *model.Domain) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	shard := r.shard(domain.Name)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
 
 	// Check if it's an update or a creation
-	_, exists := r.domains[domain.Name]
+	stored, exists := shard.domains[domain.Name]
+	precondition := model.DomainPreconditionFor(ctx, domain.Name)
+	if precondition != nil && (!exists || stored.Version != precondition.Version) {
+		return fmt.Errorf("%w: %s", model.ErrDomainVersionMismatch, domain.Name)
+	}
 	if exists {
 		r.logger.Debug("Updating", "domain", domain.Name)
 	} else {
 		r.logger.Debug("Creating", "domain", domain.Name)
-		r.domains[domain.Name] = domain
+		shard.domains[domain.Name] = domain
+	}
+
+	domain.Version = r.version.Add(1)
+	if precondition != nil {
+		precondition.Version = domain.Version
 	}
 
 	return nil
 }
 
 func (r *DomainRepository) GetDomain(ctx context.Context, name string) (*model.Domain, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	shard := r.shard(name)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
 
-	domain, ok := r.domains[name]
+	domain, ok := shard.domains[name]
 	if !ok || domain.System {
 		return nil, errors.New("domain not found")
 	}
@@ -51,41 +95,49 @@ func (r *DomainRepository) GetDomain(ctx context.Context, name string) (*model.D
 }
 
 func (r *DomainRepository) DeleteDomain(ctx context.Context, name string) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	shard := r.shard(name)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
 
-	if _, ok := r.domains[name]; !ok {
+	stored, ok := shard.domains[name]
+	if !ok {
 		return errors.New("domain not found")
 	}
+	if precondition := model.DomainPreconditionFor(ctx, name); precondition != nil && stored.Version != precondition.Version {
+		return fmt.Errorf("%w: %s", model.ErrDomainVersionMismatch, name)
+	}
 
-	delete(r.domains, name)
+	delete(shard.domains, name)
 	return nil
 }
 
-func (r *DomainRepository) ListDomains(ctx context.Context) ([]*model.Domain, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+// collect appends the domains of the shard that are (or aren't) system
+// domains, holding the shard lock only
+func (s *domainShard) collect(domains []*model.Domain, system bool) []*model.Domain {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 
-	domains := make([]*model.Domain, 0, len(r.domains))
-	for _, domain := range r.domains {
-		if domain.System {
-			continue
+	for _, domain := range s.domains {
+		if domain.System == system {
+			domains = append(domains, domain)
 		}
-		domains = append(domains, domain)
+	}
+	return domains
+}
+
+func (r *DomainRepository) ListDomains(ctx context.Context) ([]*model.Domain, error) {
+	domains := make([]*model.Domain, 0)
+	for _, shard := range r.shards {
+		domains = shard.collect(domains, false)
 	}
 
 	return domains, nil
 }
 
 func (r *DomainRepository) SystemDomains(ctx context.Context) ([]*model.Domain, error) {
-	r.mutex.RLock()
-	defer r.mutex.Unlock()
-
 	domains := make([]*model.Domain, 0)
-	for _, domain := range r.domains {
-		if domain.System {
-			domains = append(domains, domain)
-		}
+	for _, shard := range r.shards {
+		domains = shard.collect(domains, true)
 	}
 
 	if len(domains) == 0 {
@@ -94,3 +146,70 @@ func (r *DomainRepository) SystemDomains(ctx context.Context) ([]*model.Domain,
 
 	return domains, nil
 }
+
+// ListDomainsPage walks the domains shard by shard, in name order within a
+// shard. The cursor is the one returned by the previous page, empty for the
+// first page; the next cursor is empty once every shard has been walked.
+// Domains created or deleted during the walk may or may not be returned.
+func (r *DomainRepository) ListDomainsPage(ctx context.Context, cursor string, limit int) ([]*model.Domain, string, error) {
+	index, after, err := parseDomainCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultDomainPageSize
+	}
+
+	domains := make([]*model.Domain, 0, limit)
+	for ; index < domainShards; index, after = index+1, "" {
+		page := r.shards[index].page(after, limit-len(domains))
+		domains = append(domains, page...)
+		if len(domains) == limit {
+			last := domains[len(domains)-1].Name
+			return domains, formatDomainCursor(index, last), nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return domains, "", nil
+}
+
+// page returns up to limit non-system domains named after the given name
+func (s *domainShard) page(after string, limit int) []*model.Domain {
+	s.mutex.RLock()
+	names := make([]string, 0, len(s.domains))
+	for name, domain := range s.domains {
+		if !domain.System && name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	domains := make([]*model.Domain, 0, len(names))
+	for _, name := range names {
+		domains = append(domains, s.domains[name])
+	}
+	s.mutex.RUnlock()
+
+	return domains
+}
+
+func formatDomainCursor(shard int, last string) string {
+	return strconv.Itoa(shard) + ":" + last
+}
+
+func parseDomainCursor(cursor string) (int, string, error) {
+	if cursor == "" {
+		return 0, "", nil
+	}
+	shard, last, found := strings.Cut(cursor, ":")
+	index, err := strconv.Atoi(shard)
+	if !found || err != nil || index < 0 || index >= domainShards {
+		return 0, "", fmt.Errorf("%w: %q", errInvalidDomainCursor, cursor)
+	}
+	return index, last, nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(logLvl string)     {}
+func (nopLogger) Shutdown()                     {}
+
+func TestDomainRepositoryShards(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDomainRepository(nopLogger{}).(*DomainRepository)
+
+	var wg sync.WaitGroup
+	for i := range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.StoreDomain(ctx, &model.Domain{Name: fmt.Sprintf("domain-%d", i)})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, repo.StoreDomain(ctx, &model.Domain{Name: "_system", System: true}))
+
+	domains, err := repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Len(t, domains, 200)
+
+	versions := make(map[uint64]bool)
+	for _, domain := range domains {
+		versions[domain.Version] = true
+	}
+	assert.Len(t, versions, 200, "versions stay unique across shards")
+
+	system, err := repo.SystemDomains(ctx)
+	require.NoError(t, err)
+	assert.Len(t, system, 1)
+	_, err = repo.GetDomain(ctx, "_system")
+	assert.Error(t, err)
+
+	require.NoError(t, repo.DeleteDomain(ctx, "domain-7"))
+	_, err = repo.GetDomain(ctx, "domain-7")
+	assert.Error(t, err)
+	assert.Error(t, repo.DeleteDomain(ctx, "domain-7"))
+}
+
+func TestListDomainsPage(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDomainRepository(nopLogger{}).(*DomainRepository)
+	for i := range 100 {
+		require.NoError(t, repo.StoreDomain(ctx, &model.Domain{Name: fmt.Sprintf("domain-%d", i)}))
+	}
+
+	seen := make(map[string]bool)
+	cursor, pages := "", 0
+	for {
+		domains, next, err := repo.ListDomainsPage(ctx, cursor, 7)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(domains), 7)
+		for _, domain := range domains {
+			assert.False(t, seen[domain.Name], "%s returned twice", domain.Name)
+			seen[domain.Name] = true
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+
+		// Deleting a domain already walked doesn't disturb the walk
+		if pages == 3 {
+			require.NoError(t, repo.DeleteDomain(ctx, domains[0].Name))
+		}
+	}
+	assert.Len(t, seen, 100)
+	assert.Equal(t, 15, pages)
+
+	_, _, err := repo.ListDomainsPage(ctx, "nope", 10)
+	assert.ErrorIs(t, err, errInvalidDomainCursor)
+	_, _, err = repo.ListDomainsPage(ctx, "99:domain-1", 10)
+	assert.ErrorIs(t, err, errInvalidDomainCursor)
+}
+
+func TestDomainPrecondition(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDomainRepository(nopLogger{})
+
+	orders := &model.Domain{Name: "orders"}
+	require.NoError(t, repo.StoreDomain(ctx, orders))
+	matched := orders.Version
+
+	// another write lands between the If-Match check and the store
+	require.NoError(t, repo.StoreDomain(ctx, orders))
+	stale := model.WithDomainPrecondition(ctx, "orders", matched)
+	assert.ErrorIs(t, repo.StoreDomain(stale, orders), model.ErrDomainVersionMismatch)
+	assert.ErrorIs(t, repo.DeleteDomain(stale, "orders"), model.ErrDomainVersionMismatch)
+	assert.NoError(t, repo.StoreDomain(stale, &model.Domain{Name: "payments"}), "other domains are unconditional")
+
+	// the stores of one request follow each other
+	current := model.WithDomainPrecondition(ctx, "orders", orders.Version)
+	require.NoError(t, repo.StoreDomain(current, orders))
+	require.NoError(t, repo.StoreDomain(current, orders))
+	require.NoError(t, repo.DeleteDomain(current, "orders"))
+
+	// a precondition never matches a missing domain
+	assert.ErrorIs(t, repo.StoreDomain(current, &model.Domain{Name: "orders"}), model.ErrDomainVersionMismatch)
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+)
+
+// indexCacheSize bounds the message IDs whose index is cached per queue.
+// Consumers read close to the tail, so the recent stores are what gets
+// looked up.
+const indexCacheSize = 4096
+
+type queueKey struct {
+	domain string
+	queue  string
+}
+
+// indexCache keeps the index of the recently stored or looked up message
+// IDs of each queue, least recently used first out, sparing
+// GetIndexByMessageID a scan of the queue indexes on every consume. Entries
+// go with the indexes they point to.
+type indexCache struct {
+	mu     sync.Mutex
+	size   int
+	queues map[queueKey]*queueIndexCache
+}
+
+type queueIndexCache struct {
+	entries map[string]*list.Element
+	order   *list.List // of *indexEntry, most recently used first
+}
+
+type indexEntry struct {
+	id    string
+	index int64
+}
+
+func newIndexCache(size int) *indexCache {
+	return &indexCache{size: size, queues: make(map[queueKey]*queueIndexCache)}
+}
+
+func (c *indexCache) get(domainName, queueName, messageID string) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, exists := c.queues[queueKey{domainName, queueName}]
+	if !exists {
+		return 0, false
+	}
+	element, exists := queue.entries[messageID]
+	if !exists {
+		return 0, false
+	}
+	queue.order.MoveToFront(element)
+	return element.Value.(*indexEntry).index, true
+}
+
+func (c *indexCache) put(domainName, queueName, messageID string, index int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := queueKey{domainName, queueName}
+	queue, exists := c.queues[key]
+	if !exists {
+		queue = &queueIndexCache{entries: make(map[string]*list.Element), order: list.New()}
+		c.queues[key] = queue
+	}
+
+	if element, exists := queue.entries[messageID]; exists {
+		element.Value.(*indexEntry).index = index
+		queue.order.MoveToFront(element)
+		return
+	}
+	queue.entries[messageID] = queue.order.PushFront(&indexEntry{id: messageID, index: index})
+
+	if queue.order.Len() > c.size {
+		oldest := queue.order.Back()
+		queue.order.Remove(oldest)
+		delete(queue.entries, oldest.Value.(*indexEntry).id)
+	}
+}
+
+// remove drops the entry of a message if it still points to index
+func (c *indexCache) remove(domainName, queueName, messageID string, index int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, exists := c.queues[queueKey{domainName, queueName}]
+	if !exists {
+		return
+	}
+	if element, exists := queue.entries[messageID]; exists && element.Value.(*indexEntry).index == index {
+		queue.order.Remove(element)
+		delete(queue.entries, messageID)
+	}
+}
+
+// clear drops the entries of a queue
+func (c *indexCache) clear(domainName, queueName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.queues, queueKey{domainName, queueName})
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newIndexCache(2)
+	cache.put("shop", "orders", "a", 0)
+	cache.put("shop", "orders", "b", 1)
+	cache.put("shop", "payments", "a", 7)
+
+	_, ok := cache.get("shop", "orders", "a")
+	require.True(t, ok)
+	cache.put("shop", "orders", "c", 2)
+
+	_, ok = cache.get("shop", "orders", "b")
+	assert.False(t, ok, "least recently used")
+	index, ok := cache.get("shop", "orders", "a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), index)
+	index, ok = cache.get("shop", "payments", "a")
+	assert.True(t, ok, "queues are cached apart")
+	assert.Equal(t, int64(7), index)
+}
+
+func TestGetIndexByMessageIDCache(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMessageRepository(nopLogger{}).(*MessageRepository)
+	for i := range 5 {
+		require.NoError(t, repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: fmt.Sprintf("m%d", i)}))
+	}
+
+	index, err := repo.GetIndexByMessageID(ctx, "shop", "orders", "m3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), index)
+
+	// evicted entries are found by the scan
+	repo.indexes.clear("shop", "orders")
+	index, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), index)
+	_, cached := repo.indexes.get("shop", "orders", "m3")
+	assert.True(t, cached)
+
+	// cleaned up indexes aren't served from the cache
+	repo.CleanupMessageIndices(ctx, "shop", "orders", 2, 0)
+	_, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m1")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	index, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), index)
+
+	// nor the obsolete indexes of deleted messages
+	require.NoError(t, repo.DeleteMessage(ctx, "shop", "orders", "m2"))
+	_, err = repo.GetMessagesAfterIndex(ctx, "shop", "orders", 0, 10)
+	require.NoError(t, err)
+	_, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m2")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	// a message stored again is found at its new index
+	require.NoError(t, repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: "m4"}))
+	index, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), index)
+
+	repo.ClearQueueIndices(ctx, "shop", "orders")
+	_, err = repo.GetIndexByMessageID(ctx, "shop", "orders", "m4")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+}
+
+// BenchmarkConsumeIndexLookup stores a message and looks up the index of
+// the one consumed, lagging behind the producers, as each consume does, in
+// a queue holding a backlog, with and without the cache
+func BenchmarkConsumeIndexLookup(b *testing.B) {
+	ctx := context.Background()
+	const lag = 100
+	for _, backlog := range []int{1000, 10000} {
+		for _, cached := range []bool{true, false} {
+			b.Run(fmt.Sprintf("backlog=%d/cached=%t", backlog, cached), func(b *testing.B) {
+				repo := NewMessageRepository(nopLogger{}).(*MessageRepository)
+				if !cached {
+					repo.indexes = newIndexCache(0)
+				}
+				for i := range backlog {
+					if err := repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: fmt.Sprintf("m%d", i)}); err != nil {
+						b.Fatal(err)
+					}
+				}
+				ids := make([]string, b.N+backlog)
+				for i := range ids {
+					ids[i] = fmt.Sprintf("m%d", i)
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					next := backlog + i
+					if err := repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: ids[next]}); err != nil {
+						b.Fatal(err)
+					}
+					if _, err := repo.GetIndexByMessageID(ctx, "shop", "orders", ids[next-lag]); err != nil {
+						b.Fatal(err)
+					}
+					// the consumed messages are cleaned up, the backlog stays the same
+					repo.CleanupMessageIndices(ctx, "shop", "orders", int64(i+1), 0)
+				}
+			})
+		}
+	}
+}
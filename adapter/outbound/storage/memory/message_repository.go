@@ -22,12 +22,18 @@ type MessageRepository struct {
 	messages         map[string]map[string]map[string]*model.Message
 	indexToID        map[string]map[string]map[int64]string
 	nextIndexCounter map[string]map[string]int64
+	indexFloor       map[string]map[string]int64 // lowest index compaction has not reached yet
+	queueBytes       map[string]map[string]int64 // payload and header bytes held per queue
+	indexes          *indexCache                 // message ID -> index of the recent messages
 	mu               sync.RWMutex
 
 	// Map of acknowledgment matrices per queue
 	ackMatrices map[string]*model.AckMatrix
 	ackMu       sync.RWMutex
 
+	// local read replicas of the queues configured with one
+	replicas readReplicas
+
 	logger outbound.Logger
 }
 
@@ -36,7 +42,11 @@ func NewMessageRepository(logger outbound.Logger) outbound.MessageRepository {
 		messages:         make(map[string]map[string]map[string]*model.Message),
 		indexToID:        make(map[string]map[string]map[int64]string),
 		nextIndexCounter: make(map[string]map[string]int64),
+		indexFloor:       make(map[string]map[string]int64),
+		queueBytes:       make(map[string]map[string]int64),
+		indexes:          newIndexCache(indexCacheSize),
 		ackMatrices:      make(map[string]*model.AckMatrix),
+		replicas:         newReadReplicas(),
 		logger:           logger,
 	}
 }
@@ -76,11 +86,15 @@ func (r *MessageRepository) StoreMessage(
 		r.messages[domainName] = make(map[string]map[string]*model.Message)
 		r.indexToID[domainName] = make(map[string]map[int64]string)
 		r.nextIndexCounter[domainName] = make(map[string]int64)
+		r.indexFloor[domainName] = make(map[string]int64)
+		r.queueBytes[domainName] = make(map[string]int64)
 	}
 	if _, exists := r.messages[domainName][queueName]; !exists {
 		r.messages[domainName][queueName] = make(map[string]*model.Message)
 		r.indexToID[domainName][queueName] = make(map[int64]string)
 		r.nextIndexCounter[domainName][queueName] = 0
+		r.indexFloor[domainName][queueName] = 0
+		r.queueBytes[domainName][queueName] = 0
 	}
 
 	// Use and increment the atomic counter
@@ -88,10 +102,22 @@ func (r *MessageRepository) StoreMessage(
 	r.nextIndexCounter[domainName][queueName]++
 
 	// Store the message
+	if previous, exists := r.messages[domainName][queueName][message.ID]; exists {
+		r.queueBytes[domainName][queueName] -= messageSize(previous)
+	}
 	r.messages[domainName][queueName][message.ID] = message
+	r.queueBytes[domainName][queueName] += messageSize(message)
 
 	// Associate the index with the message ID
 	r.indexToID[domainName][queueName][nextIndex] = message.ID
+	r.indexes.put(domainName, queueName, message.ID, nextIndex)
+
+	r.replicateChange(replicaOp{
+		kind:    replicaStore,
+		key:     replicaKey{domainName, queueName},
+		index:   nextIndex,
+		message: message,
+	})
 
 	return nil
 }
@@ -164,6 +190,7 @@ func (r *MessageRepository) GetMessagesAfterIndex(
 	// Delete obsolete indexes after the iteration
 	for _, idx := range obsoleteIndexes {
 		r.logger.Debug("Suppression de l'index obsolète", "index", idx)
+		r.indexes.remove(domainName, queueName, r.indexToID[domainName][queueName][idx], idx)
 		delete(r.indexToID[domainName][queueName], idx)
 	}
 
@@ -184,9 +211,14 @@ func (r *MessageRepository) GetIndexByMessageID(
 		return 0, ErrQueueNotFound
 	}
 
+	if index, cached := r.indexes.get(domainName, queueName, messageID); cached {
+		return index, nil
+	}
+
 	// Iterate over the indexes to find the one pointing to our messageID
 	for index, id := range r.indexToID[domainName][queueName] {
 		if id == messageID {
+			r.indexes.put(domainName, queueName, messageID, index)
 			return index, nil
 		}
 	}
@@ -210,11 +242,38 @@ func (r *MessageRepository) DeleteMessage(
 	}
 
 	// delete message
-	if _, exists := r.messages[domainName][queueName][messageID]; !exists {
+	message, exists := r.messages[domainName][queueName][messageID]
+	if !exists {
 		return ErrMessageNotFound
 	}
 
 	delete(r.messages[domainName][queueName], messageID)
+	r.queueBytes[domainName][queueName] -= messageSize(message)
+	r.replicateChange(replicaOp{kind: replicaDelete, key: replicaKey{domainName, queueName}, id: messageID})
+	return nil
+}
+
+// ReplaceMessage swaps a stored message for a new version under the same ID,
+// keeping its index
+func (r *MessageRepository) ReplaceMessage(
+	ctx context.Context,
+	domainName, queueName string,
+	message *model.Message,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.messages[domainName][queueName]; !exists {
+		return ErrQueueNotFound
+	}
+	previous, exists := r.messages[domainName][queueName][message.ID]
+	if !exists {
+		return ErrMessageNotFound
+	}
+
+	r.messages[domainName][queueName][message.ID] = message
+	r.queueBytes[domainName][queueName] += messageSize(message) - messageSize(previous)
+	r.replicateChange(replicaOp{kind: replicaReplace, key: replicaKey{domainName, queueName}, message: message})
 	return nil
 }
 
@@ -232,6 +291,24 @@ func (r *MessageRepository) GetQueueMessageCount(domainName string, queueName st
 	return 0
 }
 
+// GetQueueMessageBytes returns the payload and header bytes stored for a queue
+func (r *MessageRepository) GetQueueMessageBytes(domainName string, queueName string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.queueBytes[domainName][queueName]
+}
+
+// messageSize approximates the memory held by a message through its payload and
+// headers, a payload moved to the blob store counting as if it were held
+func messageSize(message *model.Message) int64 {
+	size := int64(message.PayloadSize())
+	for name, value := range message.Headers {
+		size += int64(len(name) + len(value))
+	}
+	return size
+}
+
 func (r *MessageRepository) ClearQueueIndices(
 	ctx context.Context,
 	domainName, queueName string,
@@ -251,46 +328,64 @@ func (r *MessageRepository) ClearQueueIndices(
 	if domainIndices, exists := r.indexToID[domainName]; exists {
 		// Reset the map for this queue
 		domainIndices[queueName] = make(map[int64]string)
+		r.indexes.clear(domainName, queueName)
+		r.indexFloor[domainName][queueName] = r.nextIndexCounter[domainName][queueName]
+		r.replicateChange(replicaOp{kind: replicaClear, key: replicaKey{domainName, queueName}})
 		r.logger.Debug("Indices réinitialisés",
 			"domain", domainName,
 			"queue", queueName)
 	}
 }
 
+// CleanupMessageIndices removes index entries below minPosition, oldest
+// first and at most budget of them (no limit when budget <= 0). Indices are
+// sequential so each call only walks the range it removes; more reports
+// that entries below minPosition are left for a later call.
 func (r *MessageRepository) CleanupMessageIndices(
 	ctx context.Context,
 	domainName, queueName string,
 	minPosition int64,
-) {
+	budget int,
+) (removed int, more bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// check if maps exist
 	if _, exists := r.indexToID[domainName]; !exists {
-		return
+		return 0, false
 	}
 
 	indexMap, exists := r.indexToID[domainName][queueName]
 	if !exists {
-		return
+		return 0, false
 	}
 
-	initialSize := len(indexMap)
+	floor := r.indexFloor[domainName][queueName]
+	end := minPosition
+	if budget > 0 && floor+int64(budget) < end {
+		end = floor + int64(budget)
+	}
 
-	// Delete all indexes lower than minPosition
-	for idx := range indexMap {
-		if idx < minPosition {
+	// Delete the indexes between the floor and the end of this batch
+	for idx := floor; idx < end; idx++ {
+		if id, ok := indexMap[idx]; ok {
+			r.indexes.remove(domainName, queueName, id, idx)
 			delete(indexMap, idx)
+			removed++
 		}
 	}
+	if end > floor {
+		r.indexFloor[domainName][queueName] = end
+		r.replicateChange(replicaOp{kind: replicaCleanup, key: replicaKey{domainName, queueName}, index: end})
+	}
 
-	removedCount := initialSize - len(indexMap)
-	if removedCount > 0 {
+	if removed > 0 {
 		r.logger.Debug("Nettoyage incrémental des indices",
 			"domain", domainName,
 			"queue", queueName,
-			"removedCount", removedCount,
+			"removedCount", removed,
 			"minPosition", minPosition,
 			"remaining", len(indexMap))
 	}
+	return removed, end < minPosition
 }
@@ -0,0 +1,296 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// replicaBacklog bounds the changes waiting to be applied to the replicas,
+// a replica falling further behind is copied again from the primary
+const replicaBacklog = 10000
+
+type replicaKey struct {
+	domain, queue string
+}
+
+type replicaOpKind int
+
+const (
+	replicaSeed replicaOpKind = iota
+	replicaStore
+	replicaReplace
+	replicaDelete
+	replicaClear
+	replicaCleanup
+)
+
+// replicaOp is a change of a storage queue, applied to its replica
+type replicaOp struct {
+	kind    replicaOpKind
+	key     replicaKey
+	index   int64 // index of the stored message, or floor of the cleanup
+	message *model.Message
+	id      string
+}
+
+// replicaQueue is the copy of a storage queue, its messages keyed by their
+// index in the primary storage so that scans see the same indexes
+type replicaQueue struct {
+	messages map[int64]*model.Message
+	indexes  map[string]int64 // message ID -> index
+	ready    bool             // copied from the primary, false while it's copied again
+}
+
+// readReplicas are the local read replicas of a MessageRepository: copies
+// of some storage queues under a lock of their own, fed by a background
+// worker. Scans of those queues read the copies and don't contend with the
+// publishers and consumers on the lock of the primary storage.
+type readReplicas struct {
+	trackMu sync.RWMutex
+	tracked map[replicaKey]bool // storage queues whose changes are copied
+
+	mu     sync.RWMutex
+	queues map[replicaKey]*replicaQueue
+
+	ops   chan replicaOp
+	start sync.Once
+}
+
+func newReadReplicas() readReplicas {
+	return readReplicas{
+		tracked: make(map[replicaKey]bool),
+		queues:  make(map[replicaKey]*replicaQueue),
+		ops:     make(chan replicaOp, replicaBacklog),
+	}
+}
+
+// ReplicaReader returns the replica of a storage queue. The first call
+// starts copying it and reports false, as do the calls until the copy has
+// caught up.
+func (r *MessageRepository) ReplicaReader(domainName, queueName string) (outbound.MessageReader, bool) {
+	rr := &r.replicas
+	rr.start.Do(func() { go r.replicate() })
+
+	key := replicaKey{domainName, queueName}
+	rr.trackMu.Lock()
+	tracked := rr.tracked[key]
+	rr.tracked[key] = true
+	rr.trackMu.Unlock()
+
+	if !tracked {
+		// a replica left behind is stale until copied again
+		rr.mu.Lock()
+		if queue, exists := rr.queues[key]; exists {
+			queue.ready = false
+		}
+		rr.mu.Unlock()
+
+		select {
+		case rr.ops <- replicaOp{kind: replicaSeed, key: key}:
+		default:
+			rr.untrack(key)
+		}
+		return nil, false
+	}
+
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+	if queue, exists := rr.queues[key]; !exists || !queue.ready {
+		return nil, false
+	}
+	return rr, true
+}
+
+// DropReplicas forgets the replicas of a queue and its partitions, or of
+// every queue of the domain when queueName is empty
+func (r *MessageRepository) DropReplicas(domainName, queueName string) {
+	rr := &r.replicas
+	matches := func(key replicaKey) bool {
+		if key.domain != domainName {
+			return false
+		}
+		parent, _, partitioned := model.ParsePartitionQueueName(key.queue)
+		return queueName == "" || key.queue == queueName || (partitioned && parent == queueName)
+	}
+
+	rr.trackMu.Lock()
+	for key := range rr.tracked {
+		if matches(key) {
+			delete(rr.tracked, key)
+		}
+	}
+	rr.trackMu.Unlock()
+
+	rr.mu.Lock()
+	for key := range rr.queues {
+		if matches(key) {
+			delete(rr.queues, key)
+		}
+	}
+	rr.mu.Unlock()
+}
+
+// replicateChange queues a change of a storage queue for its replica. It is
+// called with r.mu held, so that changes are applied in the order they were
+// made, and never blocks: a replica too far behind is dropped and copied
+// again on its next use.
+func (r *MessageRepository) replicateChange(op replicaOp) {
+	rr := &r.replicas
+	rr.trackMu.RLock()
+	tracked := rr.tracked[op.key]
+	rr.trackMu.RUnlock()
+	if !tracked {
+		return
+	}
+
+	select {
+	case rr.ops <- op:
+	default:
+		r.logger.Warn("Read replica too far behind, it will be copied again",
+			"domain", op.key.domain,
+			"queue", op.key.queue)
+		rr.untrack(op.key)
+	}
+}
+
+func (rr *readReplicas) untrack(key replicaKey) {
+	rr.trackMu.Lock()
+	delete(rr.tracked, key)
+	rr.trackMu.Unlock()
+}
+
+// replicate applies the changes to the replicas, for the life of the process
+func (r *MessageRepository) replicate() {
+	for op := range r.replicas.ops {
+		if op.kind == replicaSeed {
+			r.seedReplica(op.key)
+			continue
+		}
+		r.replicas.apply(op)
+	}
+}
+
+// seedReplica copies a storage queue into its replica, the changes queued
+// after the seed are applied over it
+func (r *MessageRepository) seedReplica(key replicaKey) {
+	queue := &replicaQueue{
+		messages: make(map[int64]*model.Message),
+		indexes:  make(map[string]int64),
+		ready:    true,
+	}
+
+	r.mu.RLock()
+	for index, id := range r.indexToID[key.domain][key.queue] {
+		if message, exists := r.messages[key.domain][key.queue][id]; exists {
+			queue.messages[index] = message
+			queue.indexes[id] = index
+		}
+	}
+	r.mu.RUnlock()
+
+	r.replicas.mu.Lock()
+	r.replicas.queues[key] = queue
+	r.replicas.mu.Unlock()
+}
+
+func (rr *readReplicas) apply(op replicaOp) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	queue, exists := rr.queues[op.key]
+	if !exists {
+		return
+	}
+
+	switch op.kind {
+	case replicaStore:
+		if previous, exists := queue.indexes[op.message.ID]; exists {
+			delete(queue.messages, previous)
+		}
+		queue.messages[op.index] = op.message
+		queue.indexes[op.message.ID] = op.index
+	case replicaReplace:
+		if index, exists := queue.indexes[op.message.ID]; exists {
+			queue.messages[index] = op.message
+		}
+	case replicaDelete:
+		if index, exists := queue.indexes[op.id]; exists {
+			delete(queue.messages, index)
+			delete(queue.indexes, op.id)
+		}
+	case replicaClear:
+		clear(queue.messages)
+		clear(queue.indexes)
+	case replicaCleanup:
+		for index, message := range queue.messages {
+			if index < op.index {
+				delete(queue.messages, index)
+				delete(queue.indexes, message.ID)
+			}
+		}
+	}
+}
+
+func (rr *readReplicas) GetMessage(
+	ctx context.Context,
+	domainName, queueName, messageID string,
+) (*model.Message, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	queue, exists := rr.queues[replicaKey{domainName, queueName}]
+	if !exists {
+		return nil, ErrQueueNotFound
+	}
+	index, exists := queue.indexes[messageID]
+	if !exists {
+		return nil, ErrMessageNotFound
+	}
+	return queue.messages[index], nil
+}
+
+func (rr *readReplicas) GetMessagesAfterIndex(
+	ctx context.Context,
+	domainName, queueName string,
+	startIndex int64,
+	limit int,
+) ([]*model.Message, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	queue, exists := rr.queues[replicaKey{domainName, queueName}]
+	if !exists {
+		return []*model.Message{}, nil
+	}
+
+	var indexes []int64
+	for index := range queue.messages {
+		if index >= startIndex {
+			indexes = append(indexes, index)
+		}
+	}
+	slices.Sort(indexes)
+	if len(indexes) > limit {
+		indexes = indexes[:limit]
+	}
+
+	messages := make([]*model.Message, 0, len(indexes))
+	for _, index := range indexes {
+		messages = append(messages, queue.messages[index])
+	}
+	return messages, nil
+}
+
+func (rr *readReplicas) GetQueueMessageCount(domainName, queueName string) int {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	if queue, exists := rr.queues[replicaKey{domainName, queueName}]; exists {
+		return len(queue.messages)
+	}
+	return 0
+}
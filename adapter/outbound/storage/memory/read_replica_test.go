@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadReplica(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMessageRepository(nopLogger{}).(*MessageRepository)
+	for i := range 5 {
+		require.NoError(t, repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: fmt.Sprintf("m%d", i)}))
+	}
+
+	caughtUp := func() outbound.MessageReader {
+		var reader outbound.MessageReader
+		require.Eventually(t, func() bool {
+			var ok bool
+			reader, ok = repo.ReplicaReader("shop", "orders")
+			return ok
+		}, time.Second, 5*time.Millisecond)
+		return reader
+	}
+	ids := func(messages []*model.Message) []string {
+		var ids []string
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+		return ids
+	}
+
+	// the first use starts the copy
+	_, ok := repo.ReplicaReader("shop", "orders")
+	assert.False(t, ok)
+	reader := caughtUp()
+	assert.Equal(t, 5, reader.GetQueueMessageCount("shop", "orders"))
+
+	// changes of the primary follow, with the indexes of the primary
+	require.NoError(t, repo.StoreMessage(ctx, "shop", "orders", &model.Message{ID: "m5"}))
+	require.NoError(t, repo.DeleteMessage(ctx, "shop", "orders", "m1"))
+	require.NoError(t, repo.ReplaceMessage(ctx, "shop", "orders", &model.Message{ID: "m2", Payload: []byte("redacted")}))
+	repo.CleanupMessageIndices(ctx, "shop", "orders", 1, 0)
+	require.Eventually(t, func() bool {
+		return reader.GetQueueMessageCount("shop", "orders") == 4
+	}, time.Second, 5*time.Millisecond)
+
+	messages, err := reader.GetMessagesAfterIndex(ctx, "shop", "orders", 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m3", "m4", "m5"}, ids(messages))
+	primary, err := repo.GetMessagesAfterIndex(ctx, "shop", "orders", 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, ids(primary), ids(messages))
+
+	message, err := reader.GetMessage(ctx, "shop", "orders", "m2")
+	require.NoError(t, err)
+	assert.Equal(t, "redacted", string(message.Payload))
+	_, err = reader.GetMessage(ctx, "shop", "orders", "m0")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	// other queues aren't copied
+	require.NoError(t, repo.StoreMessage(ctx, "shop", "invoices", &model.Message{ID: "i0"}))
+	assert.Zero(t, reader.GetQueueMessageCount("shop", "invoices"))
+
+	// a dropped replica is copied again on its next use
+	repo.DropReplicas("shop", "")
+	assert.Zero(t, reader.GetQueueMessageCount("shop", "orders"))
+	_, ok = repo.ReplicaReader("shop", "orders")
+	assert.False(t, ok)
+	reader = caughtUp()
+	assert.Equal(t, 4, reader.GetQueueMessageCount("shop", "orders"))
+}
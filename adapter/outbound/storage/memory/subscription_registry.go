@@ -20,6 +20,14 @@ type Subscription struct {
 	DomainName string
 	QueueName  string
 	Handler    model.MessageHandler
+
+	// Detached subscriptions buffer their messages until resumed or expired
+	mu       sync.Mutex
+	detached bool
+	backlog  []*model.Message
+	missed   int64 // messages dropped from a full backlog
+	expiry   *time.Timer
+	detaches int // tells the expiry of a former detach apart
 }
 
 type SubscriptionRegistry struct {
@@ -29,6 +37,12 @@ type SubscriptionRegistry struct {
 	// Map of subscriptions by queue
 	queueSubscriptions map[string]map[string]*Subscription
 
+	// Keeps slow or panicking handlers from holding the publishers
+	guard *model.SubscriberGuard
+
+	// How long the subscriptions of the clients gone away are kept
+	retention model.SubscriptionRetention
+
 	mu sync.RWMutex
 }
 
@@ -36,9 +50,35 @@ func NewSubscriptionRegistry() outbound.SubscriptionRegistry {
 	return &SubscriptionRegistry{
 		subscriptions:      make(map[string]*Subscription),
 		queueSubscriptions: make(map[string]map[string]*Subscription),
+		guard:              model.NewSubscriberGuard(model.SubscriberPolicy{}, nil),
 	}
 }
 
+// SetSubscriberPolicy bounds the time handlers take and when they are
+// quarantined, quarantines are logged to logger
+func (r *SubscriptionRegistry) SetSubscriberPolicy(policy model.SubscriberPolicy, logger model.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.guard = model.NewSubscriberGuard(policy, logger)
+}
+
+// SetRetention keeps the subscriptions detached by their client for the
+// retention window, a zero window dropping them right away
+func (r *SubscriptionRegistry) SetRetention(retention model.SubscriptionRetention) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = model.NewSubscriptionRetention(retention.Window, retention.Buffer)
+}
+
+// SubscriberStats reports the handler latency, failures and quarantine of
+// the subscribers of a queue
+func (r *SubscriptionRegistry) SubscriberStats(domainName, queueName string) []model.SubscriberStats {
+	r.mu.RLock()
+	guard := r.guard
+	r.mu.RUnlock()
+	return guard.Stats(domainName, queueName)
+}
+
 func (r *SubscriptionRegistry) RegisterSubscription(
 	domainName, queueName string,
 	handler model.MessageHandler,
@@ -88,6 +128,15 @@ func (r *SubscriptionRegistry) UnregisterSubscription(
 
 	// Remove the subscription
 	delete(r.subscriptions, subscriptionID)
+	r.guard.Forget(subscriptionID)
+
+	subscription.mu.Lock()
+	if subscription.expiry != nil {
+		subscription.expiry.Stop()
+	}
+	releaseBacklog(subscription.backlog)
+	subscription.backlog = nil
+	subscription.mu.Unlock()
 
 	// Remove from the queue map
 	queueKey := fmt.Sprintf("%s:%s", subscription.DomainName, subscription.QueueName)
@@ -107,43 +156,155 @@ func (r *SubscriptionRegistry) NotifySubscribers(
 	domainName, queueName string,
 	message *model.Message,
 ) error {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	// Create the queue key
 	queueKey := fmt.Sprintf("%s:%s", domainName, queueName)
 
-	// Retrieve the subscriptions
-	queueSubs, exists := r.queueSubscriptions[queueKey]
-	if !exists {
-		return nil // No subs
+	// Retrieve the subscriptions, handlers run without the lock
+	r.mu.RLock()
+	queueSubs := make([]*Subscription, 0, len(r.queueSubscriptions[queueKey]))
+	for _, subscription := range r.queueSubscriptions[queueKey] {
+		queueSubs = append(queueSubs, subscription)
 	}
+	guard := r.guard
+	r.mu.RUnlock()
 
-	// Notify each subscriber in a goroutine
+	// Notify each subscriber in a goroutine, the guard bounds the wait
 	var wg sync.WaitGroup
 	for _, subscription := range queueSubs {
+		// Detached subscriptions keep the message for their client
+		handler, attached := subscription.handlerOrBuffer(message, r.retention.Buffer)
+		if !attached {
+			continue
+		}
+
 		wg.Add(1)
 		go func(sub *Subscription, msg *model.Message) {
 			defer wg.Done()
 
-			// Clone the message to avoid concurrency issues
-			messageCopy := *msg
-
-			// Call the handler
-			if err := sub.Handler(&messageCopy); err != nil {
-				// Log the error but continue
-				fmt.Printf("Error notifying subscriber %s: %v\n", sub.ID, err)
-			}
+			// Each subscriber gets its own envelope over the shared payload
+			deliver(guard, sub.ID, domainName, queueName, handler, msg.Envelope())
 		}(subscription, message)
 	}
 
 	// Wait for all notifications to finish
-	// Note: In a production system, we might use a timeout
 	wg.Wait()
 
 	return nil
 }
 
+// deliver hands an envelope to the handler of a subscription
+func deliver(
+	guard *model.SubscriberGuard,
+	subscriptionID, domainName, queueName string,
+	handler model.MessageHandler,
+	env *model.Message,
+) {
+	err := guard.Deliver(subscriptionID, domainName, queueName, handler, env)
+	if errors.Is(err, model.ErrHandlerTimeout) {
+		// still in use by the handler
+		return
+	}
+	model.ReleaseEnvelope(env)
+	if err != nil && !errors.Is(err, model.ErrSubscriberQuarantined) && !errors.Is(err, model.ErrSubscriberBusy) {
+		// Log the error but continue
+		fmt.Printf("Error notifying subscriber %s: %v\n", subscriptionID, err)
+	}
+}
+
+// handlerOrBuffer returns the handler of an attached subscription, a
+// detached one keeping the message instead, the oldest going past size
+func (s *Subscription) handlerOrBuffer(message *model.Message, size int) (model.MessageHandler, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.detached {
+		return s.Handler, true
+	}
+	if len(s.backlog) >= size {
+		model.ReleaseEnvelope(s.backlog[0])
+		s.backlog[0] = nil
+		s.backlog = s.backlog[1:]
+		s.missed++
+	}
+	s.backlog = append(s.backlog, message.Envelope())
+	return nil, false
+}
+
+// DetachSubscription keeps the subscription of a client gone away for the
+// retention window, buffering its messages until it is resumed. Without
+// retention the subscription is removed.
+func (r *SubscriptionRegistry) DetachSubscription(subscriptionID string) error {
+	r.mu.RLock()
+	subscription, exists := r.subscriptions[subscriptionID]
+	retention := r.retention
+	r.mu.RUnlock()
+	if !exists {
+		return ErrSubscriptionNotFound
+	}
+	if !retention.Enabled() {
+		return r.UnregisterSubscription(subscriptionID)
+	}
+
+	subscription.mu.Lock()
+	defer subscription.mu.Unlock()
+	subscription.detached = true
+	subscription.detaches++
+	detach := subscription.detaches
+	subscription.expiry = time.AfterFunc(retention.Window, func() {
+		r.expire(subscription, detach)
+	})
+	return nil
+}
+
+// expire removes a subscription still detached at the end of its window
+func (r *SubscriptionRegistry) expire(subscription *Subscription, detach int) {
+	subscription.mu.Lock()
+	expired := subscription.detached && subscription.detaches == detach
+	subscription.mu.Unlock()
+	if expired {
+		r.UnregisterSubscription(subscription.ID)
+	}
+}
+
+// ResumeSubscription attaches a new handler to a detached subscription of
+// the queue. The buffered messages are handed to it first, in order, and
+// missed tells how many were dropped from a full buffer.
+func (r *SubscriptionRegistry) ResumeSubscription(
+	subscriptionID, domainName, queueName string,
+	handler model.MessageHandler,
+) (missed int64, err error) {
+	r.mu.RLock()
+	subscription, exists := r.subscriptions[subscriptionID]
+	guard := r.guard
+	r.mu.RUnlock()
+	if !exists || subscription.DomainName != domainName || subscription.QueueName != queueName {
+		return 0, model.ErrSubscriptionNotResumable
+	}
+
+	// new messages wait for the backlog to be handed over
+	subscription.mu.Lock()
+	defer subscription.mu.Unlock()
+	if !subscription.detached {
+		return 0, model.ErrSubscriptionNotResumable
+	}
+	subscription.expiry.Stop()
+
+	subscription.Handler = handler
+	for _, env := range subscription.backlog {
+		deliver(guard, subscriptionID, domainName, queueName, handler, env)
+	}
+	missed = subscription.missed
+	subscription.backlog, subscription.missed = nil, 0
+	subscription.detached = false
+	return missed, nil
+}
+
+// releaseBacklog returns the envelopes of a backlog to the pool
+func releaseBacklog(backlog []*model.Message) {
+	for _, env := range backlog {
+		model.ReleaseEnvelope(env)
+	}
+}
+
 func generateSubscriptionID() string {
 	// Generate a random ID
 	return fmt.Sprintf("sub-%d-%d", time.Now().UnixNano(), rand.Intn(10000))
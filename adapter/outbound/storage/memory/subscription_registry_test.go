@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector records the IDs of the messages handed to it
+type collector struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (c *collector) handle(msg *model.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids = append(c.ids, msg.ID)
+	return nil
+}
+
+func (c *collector) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.ids...)
+}
+
+func TestSubscriptionDetachAndResume(t *testing.T) {
+	registry := NewSubscriptionRegistry().(*SubscriptionRegistry)
+	registry.SetRetention(model.SubscriptionRetention{Window: time.Minute, Buffer: 2})
+
+	first := &collector{}
+	id, err := registry.RegisterSubscription("shop", "orders", first.handle)
+	require.NoError(t, err)
+	require.NoError(t, registry.NotifySubscribers("shop", "orders", &model.Message{ID: "a"}))
+
+	require.NoError(t, registry.DetachSubscription(id))
+	for _, msgID := range []string{"b", "c", "d"} {
+		require.NoError(t, registry.NotifySubscribers("shop", "orders", &model.Message{ID: msgID}))
+	}
+	assert.Equal(t, []string{"a"}, first.received())
+
+	_, err = registry.ResumeSubscription(id, "shop", "payments", first.handle)
+	assert.ErrorIs(t, err, model.ErrSubscriptionNotResumable, "another queue")
+
+	second := &collector{}
+	missed, err := registry.ResumeSubscription(id, "shop", "orders", second.handle)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), missed, "the buffer keeps the last two")
+	require.NoError(t, registry.NotifySubscribers("shop", "orders", &model.Message{ID: "e"}))
+	assert.Equal(t, []string{"c", "d", "e"}, second.received())
+
+	_, err = registry.ResumeSubscription(id, "shop", "orders", second.handle)
+	assert.ErrorIs(t, err, model.ErrSubscriptionNotResumable, "already attached")
+}
+
+func TestSubscriptionDetachExpires(t *testing.T) {
+	registry := NewSubscriptionRegistry().(*SubscriptionRegistry)
+	registry.SetRetention(model.SubscriptionRetention{Window: 20 * time.Millisecond})
+
+	c := &collector{}
+	id, err := registry.RegisterSubscription("shop", "orders", c.handle)
+	require.NoError(t, err)
+	require.NoError(t, registry.DetachSubscription(id))
+
+	assert.Eventually(t, func() bool {
+		registry.mu.RLock()
+		defer registry.mu.RUnlock()
+		return len(registry.subscriptions) == 0 && len(registry.queueSubscriptions) == 0
+	}, time.Second, 5*time.Millisecond)
+	_, err = registry.ResumeSubscription(id, "shop", "orders", c.handle)
+	assert.ErrorIs(t, err, model.ErrSubscriptionNotResumable)
+}
+
+func TestSubscriptionDetachWithoutRetention(t *testing.T) {
+	registry := NewSubscriptionRegistry().(*SubscriptionRegistry)
+
+	c := &collector{}
+	id, err := registry.RegisterSubscription("shop", "orders", c.handle)
+	require.NoError(t, err)
+	require.NoError(t, registry.DetachSubscription(id))
+	assert.ErrorIs(t, registry.UnregisterSubscription(id), ErrSubscriptionNotFound, "removed right away")
+}
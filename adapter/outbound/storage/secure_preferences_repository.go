@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// represents the structure of the encrypted preferences file
+type EncryptedPreferencesFile struct {
+	Version  uint32   `json:"version"`
+	Nonce    []byte   `json:"nonce"`
+	Data     []byte   `json:"data"`
+	Checksum [32]byte `json:"checksum"`
+}
+
+type securePreferencesRepository struct {
+	filePath string
+	crypto   outbound.CryptoService
+	logger   outbound.Logger
+	key      [32]byte
+
+	mu       sync.Mutex
+	database *model.PreferencesDatabase
+}
+
+func NewSecurePreferencesRepository(
+	filePath string,
+	crypto outbound.CryptoService,
+	machineID outbound.MachineIDService,
+	logger outbound.Logger,
+) (outbound.PreferencesRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create preferences database directory: %w", err)
+	}
+
+	id, err := machineID.GetMachineID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &securePreferencesRepository{
+		filePath: filePath,
+		crypto:   crypto,
+		logger:   logger,
+		key:      crypto.DeriveKey(id),
+	}, nil
+}
+
+func (r *securePreferencesRepository) Get(ctx context.Context, username string) (*model.UserPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	preferences, exists := r.database.Preferences[username]
+	if !exists {
+		return nil, model.ErrPreferencesNotFound
+	}
+	copied := *preferences
+	return &copied, nil
+}
+
+func (r *securePreferencesRepository) Store(ctx context.Context, preferences *model.UserPreferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	stored := *preferences
+	r.database.Preferences[preferences.Username] = &stored
+	return r.save()
+}
+
+func (r *securePreferencesRepository) Delete(ctx context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	if _, exists := r.database.Preferences[username]; !exists {
+		return model.ErrPreferencesNotFound
+	}
+	delete(r.database.Preferences, username)
+	return r.save()
+}
+
+// load reads the database once, a missing file is an empty database
+func (r *securePreferencesRepository) load() error {
+	if r.database != nil {
+		return nil
+	}
+
+	fileData, err := os.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		r.database = &model.PreferencesDatabase{Preferences: make(map[string]*model.UserPreferences)}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var encFile EncryptedPreferencesFile
+	if err := json.Unmarshal(fileData, &encFile); err != nil {
+		return model.ErrPreferencesDatabaseCorrupted
+	}
+	if sha256.Sum256(encFile.Data) != encFile.Checksum {
+		return model.ErrInvalidChecksum
+	}
+
+	decrypted, err := r.crypto.Decrypt(encFile.Data, encFile.Nonce, r.key)
+	if err != nil {
+		return err
+	}
+
+	var db model.PreferencesDatabase
+	if err := json.Unmarshal(decrypted, &db); err != nil {
+		return model.ErrPreferencesDatabaseCorrupted
+	}
+	if db.Preferences == nil {
+		db.Preferences = make(map[string]*model.UserPreferences)
+	}
+	r.database = &db
+
+	r.logger.Info("Preferences database loaded successfully", "user_count", len(db.Preferences))
+	return nil
+}
+
+func (r *securePreferencesRepository) save() error {
+	jsonData, err := json.Marshal(r.database)
+	if err != nil {
+		return err
+	}
+
+	encrypted, nonce, err := r.crypto.Encrypt(jsonData, r.key)
+	if err != nil {
+		return err
+	}
+
+	fileJSON, err := json.Marshal(EncryptedPreferencesFile{
+		Version:  1,
+		Nonce:    nonce,
+		Data:     encrypted,
+		Checksum: sha256.Sum256(encrypted),
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.filePath, fileJSON, 0600)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurePreferencesRepository(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "preferences.db")
+	newRepo := func() *securePreferencesRepository {
+		repo, err := NewSecurePreferencesRepository(filePath, &mockCryptoService{}, &mockMachineIDService{}, &mockLogger{})
+		require.NoError(t, err)
+		return repo.(*securePreferencesRepository)
+	}
+	ctx := context.Background()
+
+	repo := newRepo()
+	_, err := repo.Get(ctx, "alice")
+	assert.ErrorIs(t, err, model.ErrPreferencesNotFound)
+
+	preferences := model.NewUserPreferences("alice")
+	preferences.DefaultPeriod = "6h"
+	preferences.PinnedQueues = []model.PinnedQueue{{Domain: "shop", Queue: "orders"}}
+	require.NoError(t, repo.Store(ctx, preferences))
+
+	// Read back from the file by a fresh repository
+	stored, err := newRepo().Get(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "6h", stored.DefaultPeriod)
+	assert.Equal(t, preferences.PinnedQueues, stored.PinnedQueues)
+
+	require.NoError(t, repo.Delete(ctx, "alice"))
+	assert.ErrorIs(t, repo.Delete(ctx, "alice"), model.ErrPreferencesNotFound)
+	_, err = newRepo().Get(ctx, "alice")
+	assert.ErrorIs(t, err, model.ErrPreferencesNotFound)
+}
@@ -13,7 +13,6 @@ import (
 	"time"
 
 	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
-	"github.com/ajkula/GoRTMS/adapter/outbound/machineid"
 	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/ajkula/GoRTMS/domain/port/outbound"
 )
@@ -39,23 +38,26 @@ type serviceStorageData struct {
 
 // represents a service account with encrypted secret
 type encryptedServiceAccount struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	EncryptedSecret string    `json:"encrypted_secret"`
-	SecretNonce     string    `json:"secret_nonce"`
-	Permissions     []string  `json:"permissions"`
-	IPWhitelist     []string  `json:"ip_whitelist,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
-	LastUsed        time.Time `json:"last_used"`
-	Enabled         bool      `json:"enabled"`
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	EncryptedSecret string              `json:"encrypted_secret"`
+	SecretNonce     string              `json:"secret_nonce"`
+	Permissions     []string            `json:"permissions"`
+	IPWhitelist     []string            `json:"ip_whitelist,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	LastUsed        time.Time           `json:"last_used"`
+	Enabled         bool                `json:"enabled"`
+	Limits          model.ServiceLimits `json:"limits"`
+	Domains         []string            `json:"domains,omitempty"`
+	TimestampWindow string              `json:"timestamp_window,omitempty"`
 }
 
 // creates a new secure service repository
-func NewSecureServiceRepository(filePath string, logger outbound.Logger) (*SecureServiceRepository, error) {
+func NewSecureServiceRepository(filePath string, machineIDService outbound.MachineIDService, logger outbound.Logger) (*SecureServiceRepository, error) {
 	cryptoService := crypto.NewAESCryptoService()
 
 	// Get machine ID for key derivation
-	machineID, err := machineid.NewHardwareMachineID().GetMachineID()
+	machineID, err := machineIDService.GetMachineID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get machine ID: %w", err)
 	}
@@ -106,6 +108,7 @@ func (r *SecureServiceRepository) Create(ctx context.Context, service *model.Ser
 	}
 
 	// Store the service
+	service.Version = 1
 	serviceCopy := *service
 	r.services[service.ID] = &serviceCopy
 
@@ -126,11 +129,13 @@ func (r *SecureServiceRepository) Update(ctx context.Context, service *model.Ser
 	defer r.mutex.Unlock()
 
 	// Check if service exists
-	if _, exists := r.services[service.ID]; !exists {
+	existing, exists := r.services[service.ID]
+	if !exists {
 		return fmt.Errorf("service account not found: %s", service.ID)
 	}
 
 	// Store the updated service
+	service.Version = existing.Version + 1
 	serviceCopy := *service
 	r.services[service.ID] = &serviceCopy
 
@@ -251,6 +256,9 @@ func (r *SecureServiceRepository) saveServices(services map[string]*model.Servic
 			CreatedAt:       service.CreatedAt,
 			LastUsed:        service.LastUsed,
 			Enabled:         service.Enabled,
+			Limits:          service.Limits,
+			Domains:         service.Domains,
+			TimestampWindow: service.TimestampWindow,
 		}
 
 		encryptedServices[id] = encryptedService
@@ -375,14 +383,17 @@ func (r *SecureServiceRepository) load() error {
 		}
 
 		service := &model.ServiceAccount{
-			ID:          encryptedService.ID,
-			Name:        encryptedService.Name,
-			Secret:      string(secretBytes),
-			Permissions: encryptedService.Permissions,
-			IPWhitelist: encryptedService.IPWhitelist,
-			CreatedAt:   encryptedService.CreatedAt,
-			LastUsed:    encryptedService.LastUsed,
-			Enabled:     encryptedService.Enabled,
+			ID:              encryptedService.ID,
+			Name:            encryptedService.Name,
+			Secret:          string(secretBytes),
+			Permissions:     encryptedService.Permissions,
+			IPWhitelist:     encryptedService.IPWhitelist,
+			CreatedAt:       encryptedService.CreatedAt,
+			LastUsed:        encryptedService.LastUsed,
+			Enabled:         encryptedService.Enabled,
+			Limits:          encryptedService.Limits,
+			Domains:         encryptedService.Domains,
+			TimestampWindow: encryptedService.TimestampWindow,
 		}
 
 		r.services[id] = service
@@ -69,7 +69,7 @@ func TestSecureServiceRepository_Create(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -112,7 +112,7 @@ func TestSecureServiceRepository_GetByID(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -150,7 +150,7 @@ func TestSecureServiceRepository_Update(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -197,7 +197,7 @@ func TestSecureServiceRepository_Delete(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -234,7 +234,7 @@ func TestSecureServiceRepository_List(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -294,7 +294,7 @@ func TestSecureServiceRepository_UpdateLastUsed(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -343,7 +343,7 @@ func TestSecureServiceRepository_Persistence(t *testing.T) {
 	filePath := createTempFilePath(t)
 
 	// Create first repository instance
-	repo1, err := NewSecureServiceRepository(filePath, logger)
+	repo1, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -358,7 +358,7 @@ func TestSecureServiceRepository_Persistence(t *testing.T) {
 	}
 
 	// Create second repository instance (should load from file)
-	repo2, err := NewSecureServiceRepository(filePath, logger)
+	repo2, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create second repository: %v", err)
 	}
@@ -387,7 +387,7 @@ func TestSecureServiceRepository_EncryptionSecurity(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
@@ -433,7 +433,7 @@ func TestSecureServiceRepository_ConcurrentAccess(t *testing.T) {
 	logger := &mockLogger{}
 	filePath := createTempFilePath(t)
 
-	repo, err := NewSecureServiceRepository(filePath, logger)
+	repo, err := NewSecureServiceRepository(filePath, &mockMachineIDService{}, logger)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
 	}
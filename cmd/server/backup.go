@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/backup"
+	"github.com/ajkula/GoRTMS/adapter/outbound/replication"
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// runBackupCommand handles `gortms backup`, writing an encrypted snapshot
+// of the data directory and, with --server, of the domains and messages of
+// the running server
+func runBackupCommand(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	dir := fs.String("dir", "", "Directory the backup is written to (default <dataDir>/backups)")
+	passphraseFile := fs.String("passphrase-file", "", "File holding the backup passphrase (default $GORTMS_BACKUP_PASSPHRASE)")
+	server := fs.String("server", "", "GoRTMS server URL to back up domains and messages from")
+	serviceID := fs.String("service-id", "", "Service account of the server holding replicate:*")
+	serviceSecret := fs.String("service-secret", os.Getenv("GORTMS_SERVICE_SECRET"), "Service account secret (default $GORTMS_SERVICE_SECRET)")
+	batchSize := fs.Int("batch", model.DefaultReplicationBatchSize, "Messages read at once")
+	keep := fs.Int("keep", 0, "Delete the oldest backups of the directory beyond this count, 0 keeps them all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *server != "" && (*serviceID == "" || *serviceSecret == "") {
+		return errors.New("--server needs --service-id and --service-secret")
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	if *dir == "" {
+		*dir = filepath.Join(cfg.General.DataDir, "backups")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	b := &backup.Backup{Manifest: backup.Manifest{CreatedAt: time.Now().UTC(), NodeID: cfg.General.NodeID}}
+	if err := backup.CollectFiles(b, cfg.General.DataDir, *dir); err != nil {
+		return err
+	}
+	if *server != "" {
+		source := replication.NewHTTPSource(*server, *serviceID, *serviceSecret)
+		if err := backup.CollectQueues(ctx, b, source, *batchSize); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	if err := backup.Write(&out, b, passphrase); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		return err
+	}
+	path := filepath.Join(*dir, backup.FileName(b.Manifest.CreatedAt))
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Backup %s written: %d files, %d queues\n", path, len(b.Manifest.Files), len(b.Queues))
+
+	removed, err := backup.Prune(*dir, *keep)
+	if err != nil {
+		return err
+	}
+	for _, name := range removed {
+		fmt.Printf("Removed old backup %s\n", name)
+	}
+	return nil
+}
+
+// runRestoreCommand handles `gortms restore`, putting back the backup taken
+// at or before --at: the data files while the server is stopped, then with
+// --server the domains and messages into the running server
+func runRestoreCommand(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	dir := fs.String("dir", "", "Directory holding the backups (default <dataDir>/backups)")
+	file := fs.String("file", "", "Backup file to restore, instead of picking one in --dir")
+	at := fs.String("at", "", "Restore the latest backup taken at or before this RFC 3339 time (default the latest)")
+	list := fs.Bool("list", false, "List the backups of --dir and exit")
+	passphraseFile := fs.String("passphrase-file", "", "File holding the backup passphrase (default $GORTMS_BACKUP_PASSPHRASE)")
+	force := fs.Bool("force", false, "Overwrite the files already in the data directory")
+	skipFiles := fs.Bool("skip-files", false, "Leave the data directory untouched")
+	server := fs.String("server", "", "GoRTMS server URL to restore domains and messages into")
+	token := fs.String("token", os.Getenv("GORTMS_TOKEN"), "Admin JWT used with --server (default $GORTMS_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *dir == "" {
+		*dir = filepath.Join(cfg.General.DataDir, "backups")
+	}
+
+	if *list {
+		names, times, err := backup.List(*dir)
+		if err != nil {
+			return err
+		}
+		for i, name := range names {
+			fmt.Printf("%s  %s\n", times[i].Format(time.RFC3339), name)
+		}
+		return nil
+	}
+
+	path := *file
+	if path == "" {
+		var pointInTime time.Time
+		if *at != "" {
+			if pointInTime, err = time.Parse(time.RFC3339, *at); err != nil {
+				return fmt.Errorf("--at: %w", err)
+			}
+		}
+		if path, err = backup.Select(*dir, pointInTime); err != nil {
+			return err
+		}
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := backup.Read(f, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restoring backup %s taken at %s\n", path, b.Manifest.CreatedAt.Format(time.RFC3339))
+
+	if !*skipFiles {
+		if err := backup.RestoreFiles(b, cfg.General.DataDir, *force); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %d files into %s\n", len(b.Manifest.Files), cfg.General.DataDir)
+	}
+
+	if *server != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		restorer := &backup.RESTRestorer{BaseURL: *server, Token: *token}
+		results, err := restorer.Restore(ctx, b)
+		for _, result := range results {
+			fmt.Printf("Restored %s/%s: %d messages, %d skipped\n", result.Domain, result.Queue, result.Restored, result.Skipped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPassphrase reads the backup passphrase from a file, or the environment
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		if passphrase := os.Getenv("GORTMS_BACKUP_PASSPHRASE"); passphrase != "" {
+			return passphrase, nil
+		}
+		return "", backup.ErrNoPassphrase
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	passphrase := strings.TrimSpace(string(content))
+	if passphrase == "" {
+		return "", backup.ErrNoPassphrase
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Build information, set when building a release:
+//
+//	go build -ldflags "-X main.version=1.1.0 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+//
+// Without them, the commit and date come from the VCS stamp of the Go build.
+var (
+	version   = "1.0.0"
+	commit    = ""
+	buildDate = ""
+)
+
+// cliTimeout bounds the requests of the commands run against a server
+const cliTimeout = 30 * time.Second
+
+// VersionInfo describes the binary, printed by `gortms version`
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a working tree with changes
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func currentVersion() VersionInfo {
+	info := VersionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+func (v VersionInfo) String() string {
+	s := "GoRTMS Version " + v.Version
+	if v.Commit != "" {
+		s += fmt.Sprintf(" (commit %.12s", v.Commit)
+		if v.Modified {
+			s += "+dirty"
+		}
+		if v.BuildDate != "" {
+			s += ", built " + v.BuildDate
+		}
+		s += ")"
+	}
+	return s + fmt.Sprintf(" %s %s", v.GoVersion, v.Platform)
+}
+
+// isCLICommand tells whether the argument is one of the scripting commands
+func isCLICommand(arg string) bool {
+	switch arg {
+	case "version", "config", "routes", "encrypt-value":
+		return true
+	}
+	return false
+}
+
+// runCLICommand handles `gortms version`, `gortms config print`,
+// `gortms routes list` and `gortms encrypt-value`, writing to out
+func runCLICommand(command string, args []string, out io.Writer) error {
+	switch command {
+	case "version":
+		return runVersionCommand(args, out)
+	case "config":
+		if len(args) == 0 || args[0] != "print" {
+			return errors.New("usage: gortms config print [--resolved] [--format yaml|json] [--config path]")
+		}
+		return runConfigPrintCommand(args[1:], out)
+	case "routes":
+		if len(args) == 0 || args[0] != "list" {
+			return errors.New("usage: gortms routes list [--server url] [--domain name] [--json]")
+		}
+		return runRoutesListCommand(args[1:], out)
+	case "encrypt-value":
+		return runEncryptValueCommand(args, os.Stdin, out)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// runVersionCommand handles `gortms version [--json]`
+func runVersionCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the version as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	info := currentVersion()
+	if *asJSON {
+		return writeJSON(out, info)
+	}
+	_, err := fmt.Fprintln(out, info)
+	return err
+}
+
+// runConfigPrintCommand handles `gortms config print`. The file is printed
+// over the defaults; --resolved prints what the server would run with,
+// environment overrides applied, paths made absolute and the file
+// validated. Secrets are masked unless --show-secrets is given.
+func runConfigPrintCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	resolved := fs.Bool("resolved", false, "Apply the "+config.EnvPrefix+"* environment overrides, resolve the paths and validate")
+	format := fs.String("format", "yaml", "Output format: yaml or json")
+	showSecrets := fs.Bool("show-secrets", false, "Print the secrets instead of masking them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "yaml" && *format != "json" {
+		return fmt.Errorf("--format must be yaml or json, got %q", *format)
+	}
+
+	var cfg *config.Config
+	if *resolved {
+		var err error
+		if cfg, err = config.LoadConfig(*configPath); err != nil {
+			return err
+		}
+	} else {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		cfg = config.DefaultConfig()
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	cfg.ConfigPath = *configPath
+	if !*showSecrets {
+		cfg = cfg.Redacted()
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if *format == "yaml" {
+		_, err = out.Write(data)
+		return err
+	}
+
+	// the configuration only carries YAML names, go through them for JSON
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	return writeJSON(out, tree)
+}
+
+// cliRoute is a routing rule as listed by the API
+type cliRoute struct {
+	SourceQueue       string
+	DestinationQueue  string
+	DestinationDomain string
+	Enabled           *bool
+	Priority          int
+	StopOnMatch       bool
+	Stats             model.RoutingRuleStats `json:"stats"`
+}
+
+// runRoutesListCommand handles `gortms routes list`, listing the routing
+// rules of a running server, of one domain or of all of them
+func runRoutesListCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("routes list", flag.ContinueOnError)
+	server := fs.String("server", "http://localhost:8080", "GoRTMS server URL")
+	token := fs.String("token", os.Getenv("GORTMS_TOKEN"), "JWT used to read the routes (default $GORTMS_TOKEN)")
+	domain := fs.String("domain", "", "Domain to list (default all of them)")
+	asJSON := fs.Bool("json", false, "Print the rules as JSON, as returned by the API")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+	client := &apiClient{baseURL: strings.TrimSuffix(*server, "/"), token: *token}
+
+	domains := []string{*domain}
+	if *domain == "" {
+		var list struct {
+			Domains []struct {
+				Name string `json:"name"`
+			} `json:"domains"`
+		}
+		if err := client.get(ctx, "/api/domains", &list); err != nil {
+			return err
+		}
+		domains = domains[:0]
+		for _, d := range list.Domains {
+			domains = append(domains, d.Name)
+		}
+	}
+
+	type domainRoutes struct {
+		Domain string            `json:"domain"`
+		Rules  []json.RawMessage `json:"rules"`
+	}
+	all := make([]domainRoutes, 0, len(domains))
+	for _, name := range domains {
+		var routes domainRoutes
+		if err := client.get(ctx, "/api/domains/"+url.PathEscape(name)+"/routes", &routes); err != nil {
+			return fmt.Errorf("domain %s: %w", name, err)
+		}
+		routes.Domain = name
+		all = append(all, routes)
+	}
+
+	if *asJSON {
+		return writeJSON(out, all)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tSOURCE\tDESTINATION\tPRIORITY\tENABLED\tMATCHED\tROUTED\tLAST MATCH")
+	for _, routes := range all {
+		for _, raw := range routes.Rules {
+			var rule cliRoute
+			if err := json.Unmarshal(raw, &rule); err != nil {
+				return err
+			}
+			destination := rule.DestinationQueue
+			if rule.DestinationDomain != "" {
+				destination = rule.DestinationDomain + ":" + destination
+			}
+			if rule.StopOnMatch {
+				destination += " (stop)"
+			}
+			lastMatch := "-"
+			if rule.Stats.LastMatch != nil {
+				lastMatch = rule.Stats.LastMatch.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%t\t%d\t%d\t%s\n",
+				routes.Domain, rule.SourceQueue, destination, rule.Priority,
+				rule.Enabled == nil || *rule.Enabled, rule.Stats.Matched, rule.Stats.Routed, lastMatch)
+		}
+	}
+	return tw.Flush()
+}
+
+// apiClient reads the REST API of a running server
+type apiClient struct {
+	baseURL string
+	token   string
+	client  http.Client
+}
+
+func (c *apiClient) get(ctx context.Context, path string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", req.Method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func writeJSON(out io.Writer, v any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersionCommandJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := runCLICommand("version", []string{"--json"}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var info VersionInfo
+	if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if info.Version != version || info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("unexpected version info: %+v", info)
+	}
+}
+
+func TestRoutesListCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/domains":
+			w.Write([]byte(`{"domains":[{"name":"orders"},{"name":"billing"}]}`))
+		case "/api/domains/orders/routes":
+			w.Write([]byte(`{"rules":[{"SourceQueue":"incoming","DestinationQueue":"vip","Priority":10,"StopOnMatch":true,
+				"stats":{"matched":7,"routed":6,"lastMatch":"2026-01-02T03:04:05Z"}},
+				{"SourceQueue":"incoming","DestinationQueue":"invoices","DestinationDomain":"billing","Enabled":false,"stats":{}}],"version":3}`))
+		case "/api/domains/billing/routes":
+			w.Write([]byte(`{"rules":[],"version":1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	if err := runCLICommand("routes", []string{"list", "--server", server.URL, "--token", "token"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rules, got:\n%s", out.String())
+	}
+	for i, want := range [][]string{
+		{"orders", "incoming", "vip (stop)", "10", "true", "7", "6", "2026-01-02T03:04:05Z"},
+		{"orders", "incoming", "billing:invoices", "0", "false", "0", "0", "-"},
+	} {
+		if got := strings.Fields(lines[i+1]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("rule %d: got %q, want %q", i, got, want)
+		}
+	}
+
+	out.Reset()
+	err := runCLICommand("routes", []string{"list", "--server", server.URL, "--token", "wrong", "--domain", "orders"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected the 401 to be reported, got %v", err)
+	}
+}
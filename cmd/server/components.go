@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// component is a part of the server started and stopped by the components
+// manager, after and before the components it depends on
+type component struct {
+	name      string
+	dependsOn []string
+	start     func(ctx context.Context) error
+	stop      func(ctx context.Context) error
+}
+
+// components starts the server parts in dependency order and stops them in
+// the reverse order, so nothing is stopped while a part using it still runs
+type components struct {
+	logger     outbound.Logger
+	registered []*component
+	started    []*component
+}
+
+func newComponents(logger outbound.Logger) *components {
+	return &components{logger: logger}
+}
+
+// Register adds a component, start and stop may be nil for components that
+// only need one of them
+func (c *components) Register(name string, start, stop func(ctx context.Context) error, dependsOn ...string) {
+	c.registered = append(c.registered, &component{
+		name:      name,
+		dependsOn: dependsOn,
+		start:     start,
+		stop:      stop,
+	})
+}
+
+// order sorts the components so each comes after its dependencies, in the
+// order of registration otherwise
+func (c *components) order() ([]*component, error) {
+	byName := make(map[string]*component, len(c.registered))
+	for _, comp := range c.registered {
+		if _, exists := byName[comp.name]; exists {
+			return nil, fmt.Errorf("component %s registered twice", comp.name)
+		}
+		byName[comp.name] = comp
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(c.registered))
+	ordered := make([]*component, 0, len(c.registered))
+
+	var visit func(comp *component, path []string) error
+	visit = func(comp *component, path []string) error {
+		switch state[comp.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), comp.name)
+		}
+		state[comp.name] = visiting
+		for _, name := range comp.dependsOn {
+			dependency, exists := byName[name]
+			if !exists {
+				return fmt.Errorf("component %s depends on unknown component %s", comp.name, name)
+			}
+			if err := visit(dependency, append(path, comp.name)); err != nil {
+				return err
+			}
+		}
+		state[comp.name] = visited
+		ordered = append(ordered, comp)
+		return nil
+	}
+
+	for _, comp := range c.registered {
+		if err := visit(comp, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Start starts the components in dependency order. When one fails to start,
+// the ones already started are stopped in the reverse order.
+func (c *components) Start(ctx context.Context) error {
+	ordered, err := c.order()
+	if err != nil {
+		return err
+	}
+
+	for _, comp := range ordered {
+		if comp.start != nil {
+			c.logger.Debug("Starting component", "component", comp.name)
+			if err := comp.start(ctx); err != nil {
+				err = fmt.Errorf("failed to start %s: %w", comp.name, err)
+				if stopErr := c.Stop(ctx); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return err
+			}
+		}
+		c.started = append(c.started, comp)
+	}
+	return nil
+}
+
+// Stop stops the started components in the reverse order of their start.
+// A component failing to stop doesn't keep the others from stopping, the
+// errors are returned together.
+func (c *components) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(c.started) - 1; i >= 0; i-- {
+		comp := c.started[i]
+		if comp.stop == nil {
+			continue
+		}
+		c.logger.Info("Stopping component", "component", comp.name)
+		if err := comp.stop(ctx); err != nil {
+			c.logger.Error("Failed to stop component", "component", comp.name, "ERROR", err)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", comp.name, err))
+		}
+	}
+	c.started = nil
+	return errors.Join(errs...)
+}
+
+// cleanup stops a service through its Cleanup method, if it has one
+func cleanup(svc any) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if cleanable, ok := svc.(interface{ Cleanup() }); ok {
+			cleanable.Cleanup()
+		}
+		return nil
+	}
+}
+
+// background runs fn in a goroutine from the start of the component until
+// its stop, which waits for fn to return
+func background(fn func(ctx context.Context)) (start, stop func(ctx context.Context) error) {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	start = func(ctx context.Context) error {
+		ctx, cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(done)
+			fn(ctx)
+		}()
+		return nil
+	}
+	stop = func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return start, stop
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Error(msg string, args ...any) {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) UpdateLevel(logLvl string)     {}
+func (nopLogger) Shutdown()                     {}
+
+// recordComponent registers a component logging its start and stop
+func recordComponent(c *components, log *[]string, name string, startErr error, dependsOn ...string) {
+	c.Register(name, func(context.Context) error {
+		*log = append(*log, "start "+name)
+		return startErr
+	}, func(context.Context) error {
+		*log = append(*log, "stop "+name)
+		return nil
+	}, dependsOn...)
+}
+
+func TestComponentsOrder(t *testing.T) {
+	var log []string
+	c := newComponents(nopLogger{})
+	recordComponent(c, &log, "http", nil, "messages", "domains")
+	recordComponent(c, &log, "messages", nil, "queues", "events")
+	recordComponent(c, &log, "events", nil, "stats")
+	recordComponent(c, &log, "queues", nil, "domains", "events")
+	recordComponent(c, &log, "stats", nil, "domains")
+	recordComponent(c, &log, "domains", nil)
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"start domains", "start stats", "start events", "start queues", "start messages", "start http",
+		"stop http", "stop messages", "stop queues", "stop events", "stop stats", "stop domains",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got %v, want %v", log, want)
+	}
+}
+
+func TestComponentsStartRollback(t *testing.T) {
+	var log []string
+	c := newComponents(nopLogger{})
+	recordComponent(c, &log, "domains", nil)
+	recordComponent(c, &log, "queues", nil, "domains")
+	recordComponent(c, &log, "grpc", errors.New("address in use"), "queues")
+	recordComponent(c, &log, "http", nil, "grpc")
+
+	err := c.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed to start grpc: address in use") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"start domains", "start queues", "start grpc", "stop queues", "stop domains"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got %v, want %v", log, want)
+	}
+	if err := c.Stop(context.Background()); err != nil || len(log) != len(want) {
+		t.Errorf("components stopped twice: %v", log)
+	}
+}
+
+func TestComponentsInvalidGraph(t *testing.T) {
+	for name, register := range map[string]func(c *components){
+		"cycle": func(c *components) {
+			c.Register("a", nil, nil, "b")
+			c.Register("b", nil, nil, "c")
+			c.Register("c", nil, nil, "a")
+		},
+		"unknown dependency": func(c *components) {
+			c.Register("a", nil, nil, "missing")
+		},
+		"duplicate": func(c *components) {
+			c.Register("a", nil, nil)
+			c.Register("a", nil, nil)
+		},
+	} {
+		c := newComponents(nopLogger{})
+		register(c)
+		if err := c.Start(context.Background()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBackgroundComponent(t *testing.T) {
+	exited := false
+	start, stop := background(func(ctx context.Context) {
+		<-ctx.Done()
+		exited = true
+	})
+	if err := start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !exited {
+		t.Error("stop returned before the goroutine exited")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ajkula/GoRTMS/adapter/inbound/rest"
+	"github.com/ajkula/GoRTMS/adapter/inbound/websocket"
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// newListenerRouter builds the route set and middleware stack of one HTTP listener
+func newListenerRouter(
+	listener config.HTTPListener,
+	restHandler *rest.Handler,
+	wsHandler *websocket.Handler,
+	logger outbound.Logger,
+) *mux.Router {
+	router := mux.NewRouter()
+	restHandler.SetupListenerRoutes(router, listener)
+
+	// WebSocket adapter, on every API version prefix
+	if listener.ServesData() {
+		wsRoute := restHandler.WebSocketAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			wsHandler.HandleConnection(w, r, vars["domain"], vars["queue"])
+		}))
+		// many queues over one connection, subscribed to through frames
+		multiplexed := restHandler.WebSocketAuth(http.HandlerFunc(wsHandler.HandleMultiplexed))
+		for _, prefix := range rest.APIPrefixes() {
+			router.Handle(prefix+"/ws/domains/{domain}/queues/{queue}", wsRoute)
+			router.Handle(prefix+"/ws", multiplexed)
+		}
+	}
+
+	// Middleware for debugging requests
+	if listener.RequestLog {
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logger.Info("Request", "LISTENER", listener.Name, "METHOD", r.Method, "PATH", r.URL.Path, "REQUEST_ID", rest.RequestID(r.Context()))
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			logger.Error("ROUTE ERROR", "ERROR", err)
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"ANY"}
+		}
+		logger.Info("ROUTE", "LISTENER", listener.Name, "PATH", pathTemplate, "METHOD", methods)
+		return nil
+	})
+
+	return router
+}
+
+// newTLSConfig returns the TLS settings of the listeners. The hardened
+// profile keeps TLS 1.2 for older clients but only with forward secret AEAD
+// suites and modern curves, TLS 1.3 being negotiated whenever possible.
+func newTLSConfig(hardened bool) *tls.Config {
+	if !hardened {
+		return &tls.Config{
+			MinVersion: tls.VersionTLS12, // Minimum TLS 1.2
+			CipherSuites: []uint16{
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			},
+		}
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+}
+
+// startHTTPListener serves the handler on the listener bind in the background,
+// handshakeTimeout bounds the TLS handshake and the request headers. The
+// address is bound before returning, so a port in use fails the startup.
+func startHTTPListener(
+	listener config.HTTPListener,
+	handler http.Handler,
+	handshakeTimeout time.Duration,
+	hardened bool,
+	logger outbound.Logger,
+) (*http.Server, error) {
+	httpAddr := fmt.Sprintf("%s:%d", listener.Address, listener.Port)
+	server := &http.Server{
+		Addr:              httpAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: handshakeTimeout,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	// Configure TLS if enabled
+	if listener.TLS {
+		server.TLSConfig = newTLSConfig(hardened)
+	}
+
+	ln, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listener %s: %w", listener.Name, err)
+	}
+
+	// Start HTTP/HTTPS server
+	go func() {
+		if listener.TLS {
+			logger.Info("HTTPS server listening",
+				"listener", listener.Name,
+				"role", listener.Role,
+				"URL", fmt.Sprintf("https://%s", httpAddr),
+				"certFile", listener.CertFile,
+				"keyFile", listener.KeyFile)
+
+			if err := server.ServeTLS(ln, listener.CertFile, listener.KeyFile); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTPS server error", "listener", listener.Name, "error", err)
+			}
+		} else {
+			logger.Info("HTTP server listening",
+				"listener", listener.Name,
+				"role", listener.Role,
+				"URL", fmt.Sprintf("http://%s", httpAddr))
+
+			if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTP server error", "listener", listener.Name, "error", err)
+			}
+		}
+	}()
+
+	return server, nil
+}
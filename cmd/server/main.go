@@ -2,29 +2,32 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/sha256"
 	"embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
 	"time"
 
 	_ "net/http/pprof"
 
-	"github.com/gorilla/mux"
-
 	"github.com/ajkula/GoRTMS/adapter/inbound/grpc"
+	"github.com/ajkula/GoRTMS/adapter/inbound/mqtt"
 	"github.com/ajkula/GoRTMS/adapter/inbound/rest"
 	"github.com/ajkula/GoRTMS/adapter/inbound/websocket"
 	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/adapter/outbound/errorreport"
+	"github.com/ajkula/GoRTMS/adapter/outbound/eventbus"
 	"github.com/ajkula/GoRTMS/adapter/outbound/filewatcher"
 	"github.com/ajkula/GoRTMS/adapter/outbound/logging"
-	"github.com/ajkula/GoRTMS/adapter/outbound/machineid"
+	"github.com/ajkula/GoRTMS/adapter/outbound/manifest"
+	"github.com/ajkula/GoRTMS/adapter/outbound/metrics"
+	"github.com/ajkula/GoRTMS/adapter/outbound/notification"
+	"github.com/ajkula/GoRTMS/adapter/outbound/replication"
 	"github.com/ajkula/GoRTMS/adapter/outbound/storage"
 	"github.com/ajkula/GoRTMS/adapter/outbound/storage/memory"
 	"github.com/ajkula/GoRTMS/config"
@@ -41,20 +44,72 @@ import (
 //go:embed favicon.ico
 var uiFiles embed.FS
 
+const (
+	// shutdownTimeout bounds the stop of all the components
+	shutdownTimeout = 30 * time.Second
+
+	// httpShutdownTimeout bounds the wait for the in-flight HTTP requests
+	httpShutdownTimeout = 5 * time.Second
+)
+
 func main() {
+	// Service management subcommands (install-service, service-status...)
+	if len(os.Args) > 1 && isServiceCommand(os.Args[1]) {
+		if err := runServiceCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Migration from another broker, runs against a live server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Backup and point-in-time restore of the data directory
+	if len(os.Args) > 1 && (os.Args[1] == "backup" || os.Args[1] == "restore") {
+		run := runBackupCommand
+		if os.Args[1] == "restore" {
+			run = runRestoreCommand
+		}
+		if err := run(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Scripting commands: version, config print, routes list, encrypt-value
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		if err := runCLICommand(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Handle command-line arguments
 	var configPath string
+	var logFile string
 	var generateConfig bool
 	var showVersion bool
+	var validateConfig bool
 
 	flag.StringVar(&configPath, "config", "config.yaml", "Path to configuration file")
 	flag.BoolVar(&generateConfig, "generate-config", false, "Generate default configuration file")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
+	flag.StringVar(&logFile, "log-file", "", "Write logs to this file instead of the configured output")
+	flag.BoolVar(&validateConfig, "validate-config", false, "Validate the configuration file and exit (non-zero on error)")
 	flag.Parse()
 
 	// Display version information
 	if showVersion {
-		fmt.Println("GoRTMS Version 1.0.0")
+		fmt.Println(currentVersion())
 		os.Exit(0)
 	}
 
@@ -78,34 +133,73 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Only check the configuration, for CI pipelines
+	if validateConfig {
+		for _, warning := range config.Diagnostics(cfg) {
+			fmt.Printf("warning: %s\n", warning)
+		}
+		fmt.Printf("Configuration %s is valid\n", configPath)
+		os.Exit(0)
+	}
+
+	if logFile != "" {
+		cfg.Logging.Output = "file"
+		cfg.Logging.FilePath = logFile
+	}
+
+	// Hook into systemd or the Windows service manager when started by them,
+	// registered first so the manager is told last that the shutdown is over
+	lifecycle := newServiceLifecycle()
+	defer lifecycle.Stopped()
+
 	// Initialize structured logger
 	logger := logging.NewSlogAdapter(cfg)
 
 	logger.Info("Starting GoRTMS...")
 	logger.Info("Node ID", "nodeID", cfg.General.NodeID)
 	logger.Info("Data directory", "dataDir", cfg.General.DataDir)
+	for _, warning := range config.Diagnostics(cfg) {
+		logger.Warn("Configuration: " + warning)
+	}
 
 	// Create the data directory if it doesn't exist
 	if err := os.MkdirAll(cfg.General.DataDir, 0755); err != nil {
 		logger.Error("Failed to create data directory", "ERROR", err)
 	}
 
+	// Initialize crypto services, and decrypt the ENC[...] settings before
+	// anything reads them
+	cryptoService := crypto.NewAESCryptoService()
+	machineIDService, err := decryptConfig(cfg, cryptoService, logger)
+	if err != nil {
+		logger.Error("Failed to decrypt the configuration", "ERROR", err)
+		os.Exit(1)
+	}
+
 	// Create a cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Dedicated pprof server on port 6060
-	go func() {
-		logger.Info("Starting pprof server on port", "port", cfg.HTTP.Port)
-		err := http.ListenAndServe("localhost:6060", nil)
-		logger.Error("Error starting pprof server", "ERROR", err)
-	}()
+	// Server parts start after what they depend on and stop before it
+	comps := newComponents(logger)
+
+	// Dedicated pprof server on port 6060, plaintext so left out when hardened
+	if !cfg.Security.Hardened {
+		go func() {
+			logger.Info("Starting pprof server on port", "port", cfg.HTTP.Port)
+			err := http.ListenAndServe("localhost:6060", nil)
+			logger.Error("Error starting pprof server", "ERROR", err)
+		}()
+	} else {
+		logger.Info("Hardened security profile enabled, pprof server disabled")
+	}
 
 	// Initialize repositories (outgoing adapters)
 	messageRepo := memory.NewMessageRepository(logger)
 	domainRepo := memory.NewDomainRepository(logger)
 	consumerGroupRepo := memory.NewConsumerGroupRepository(logger, messageRepo)
 	subscriptionReg := memory.NewSubscriptionRegistry()
+	eventBus := eventbus.NewMemoryEventBus(logger, eventbus.DefaultSubscriberBuffer)
 
 	// Create services (domain implementations)
 	statsService := service.NewStatsService(ctx, logger, domainRepo, messageRepo)
@@ -118,17 +212,121 @@ func main() {
 		consumerGroupRepo,
 		subscriptionReg,
 		queueService,
-		statsService,
 	)
 
 	// Inject messageService into queueService
 	if queueSvc, ok := queueService.(*service.QueueServiceImpl); ok {
 		queueSvc.SetMessageService(messageService)
+		queueSvc.SetSubscriberPolicy(cfg.Subscribers)
+	}
+
+	// Keep slow or panicking subscribers from holding their queue, and the
+	// subscriptions of disconnected clients for them to resume
+	if registry, ok := subscriptionReg.(*memory.SubscriptionRegistry); ok {
+		registry.SetSubscriberPolicy(cfg.Subscribers, logger)
+		registry.SetRetention(model.NewSubscriptionRetention(
+			cfg.HTTP.Connections.Subscriptions.ResumeWindow,
+			cfg.HTTP.Connections.Subscriptions.ResumeBuffer,
+		))
+	}
+
+	// Offload large payloads to the blob store
+	var blobStore outbound.BlobStore
+	if cfg.Storage.ClaimCheck.Enabled {
+		fileBlobStore, err := storage.NewFileBlobStore(cfg.Storage.ClaimCheck.Path)
+		if err != nil {
+			logger.Error("Failed to create blob store", "error", err)
+			os.Exit(1)
+		}
+		blobStore = fileBlobStore
+		if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+			msgSvc.SetBlobStore(blobStore, cfg.Storage.ClaimCheck.ThresholdKB*1024)
+		}
+		logger.Info("Claim-check storage enabled",
+			"path", cfg.Storage.ClaimCheck.Path,
+			"thresholdKB", cfg.Storage.ClaimCheck.ThresholdKB)
 	}
 
 	domainService := service.NewDomainService(domainRepo, queueService, ctx)
 	routingService := service.NewRoutingService(domainRepo, ctx)
 
+	// Services emit domain events, subsystems subscribe independently
+	for _, svc := range []any{messageService, queueService, domainService, routingService} {
+		if emitter, ok := svc.(interface{ SetEventBus(outbound.EventBus) }); ok {
+			emitter.SetEventBus(eventBus)
+		}
+	}
+	if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+		eventBus.Subscribe("messages", msgSvc.HandleEvent, model.EventQueueDeleted, model.EventDomainDeleted)
+	}
+	if statsSvc, ok := statsService.(*service.StatsServiceImpl); ok {
+		eventBus.Subscribe("stats", statsSvc.HandleEvent)
+		statsSvc.SetConsumerGroupRepository(consumerGroupRepo)
+		statsSvc.SetEventRetention(cfg.Events)
+	}
+
+	// The event bus stops after the services publishing to it and drains
+	// its pending events before its subscribers stop
+	comps.Register("domains", nil, cleanup(domainService))
+	comps.Register("routing", nil, cleanup(routingService), "domains")
+	comps.Register("stats", nil, cleanup(statsService), "domains", "notifications")
+	comps.Register("events", nil, func(context.Context) error {
+		eventBus.Close()
+		return nil
+	}, "stats")
+	comps.Register("queues", nil, cleanup(queueService), "domains", "events")
+	comps.Register("messages", nil, cleanup(messageService), "queues", "routing", "events")
+
+	snapshotService := service.NewSnapshotService(logger, domainRepo, messageRepo, queueService)
+	if blobStore != nil {
+		if snapshotSvc, ok := snapshotService.(*service.SnapshotServiceImpl); ok {
+			snapshotSvc.SetBlobStore(blobStore, cfg.Storage.ClaimCheck.ThresholdKB*1024)
+		}
+	}
+
+	// Serve the scans of the queues with a local read replica from it
+	if replicas, ok := messageRepo.(outbound.ReadReplicas); ok {
+		if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+			msgSvc.SetReadReplicas(replicas)
+		}
+		if snapshotSvc, ok := snapshotService.(*service.SnapshotServiceImpl); ok {
+			snapshotSvc.SetReadReplicas(replicas)
+		}
+		eventBus.Subscribe("read-replicas", func(event model.DomainEvent) {
+			replicas.DropReplicas(event.Domain, event.Queue)
+		}, model.EventQueueDeleted, model.EventDomainDeleted)
+	}
+
+	// Follow the primary until promoted
+	var standbyService *service.StandbyServiceImpl
+	if cfg.Replication.Standby {
+		standbyService = service.NewStandbyService(
+			replication.NewHTTPSource(cfg.Replication.Primary, cfg.Replication.ServiceID, cfg.Replication.ServiceSecret),
+			cfg.Replication.Primary,
+			domainService,
+			queueService,
+			messageRepo,
+			logger,
+			cfg.Replication.BatchSize,
+		)
+		if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+			msgSvc.SetStandby(standbyService)
+		}
+		start, stop := background(func(ctx context.Context) {
+			standbyService.Run(ctx, cfg.Replication.Interval)
+		})
+		comps.Register("standby", start, stop, "queues")
+		logger.Info("Standby replication enabled",
+			"primary", cfg.Replication.Primary,
+			"interval", cfg.Replication.Interval)
+	}
+
+	// Data subject erasure, offloaded payloads are matched and dropped too
+	erasureService := service.NewErasureService(ctx, logger, domainRepo, messageRepo, queueService)
+	if blobStore != nil {
+		erasureService.SetBlobStore(blobStore)
+	}
+
 	// Initialize the ConsumerGroupService
 	consumerGroupService := service.NewConsumerGroupService(
 		ctx,
@@ -136,6 +334,9 @@ func main() {
 		consumerGroupRepo,
 		messageRepo,
 	)
+	if groupSvc, ok := consumerGroupService.(*service.ConsumerGroupServiceImpl); ok {
+		groupSvc.SetCleanupPolicy(cfg.GroupCleanup)
+	}
 
 	// Initialize the resource monitoring service
 	resourceMonitorService := service.NewResourceMonitorService(
@@ -144,10 +345,60 @@ func main() {
 		queueService,
 		ctx,
 	)
+	comps.Register("resources", nil, cleanup(resourceMonitorService), "queues")
 
-	// Initialize crypto services
-	machineIDService := machineid.NewHardwareMachineID()
-	cryptoService := crypto.NewAESCryptoService()
+	// Queue hooks report queues filling up, draining and gaining or losing
+	// their consumers, to a queue of their domain or a webhook
+	hookPoster := notification.NewHookPoster(logger)
+	queueHookService := service.NewQueueHookService(
+		logger,
+		domainRepo,
+		messageRepo,
+		consumerGroupRepo,
+		messageService,
+		hookPoster,
+	)
+	eventBus.Subscribe("queue-hooks", queueHookService.HandleEvent,
+		model.EventMessagePublished, model.EventMessageConsumed,
+		model.EventQueueDeleted, model.EventDomainDeleted)
+	startHooks, stopHooks := background(func(ctx context.Context) {
+		queueHookService.Run(ctx, service.DefaultQueueHookInterval)
+	})
+	comps.Register("queue-hooks", startHooks, func(ctx context.Context) error {
+		err := stopHooks(ctx)
+		hookPoster.Close()
+		return err
+	}, "messages")
+
+	// Push the stats to a Prometheus remote-write endpoint, for installs
+	// that can't be scraped
+	if cfg.RemoteWrite.URL != "" {
+		if source, ok := statsService.(service.MetricSource); ok {
+			metricsExport := service.NewMetricsExportService(logger, source,
+				metrics.NewRemoteWriter(cfg.RemoteWrite, cfg.General.NodeID))
+			start, stop := background(func(ctx context.Context) {
+				metricsExport.Run(ctx, cfg.RemoteWrite.Interval)
+			})
+			comps.Register("remote-write", start, stop, "stats")
+			logger.Info("Metrics remote write enabled",
+				"url", cfg.RemoteWrite.URL,
+				"interval", cfg.RemoteWrite.Interval)
+		}
+	}
+
+	// Index the selected schema fields for message search
+	if cfg.Search.Enabled {
+		searchIndex := model.NewSearchIndex(cfg.Search.Fields, cfg.Search.Domains)
+		if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+			msgSvc.SetSearchIndex(ctx, searchIndex)
+		}
+		if monitor, ok := resourceMonitorService.(*service.ResourceMonitorServiceImpl); ok {
+			monitor.SetSearchIndex(searchIndex)
+		}
+		eventBus.Subscribe("search", func(event model.DomainEvent) {
+			searchIndex.DropDomain(event.Domain)
+		}, model.EventDomainDeleted)
+	}
 
 	// Initialize user repository with secure storage
 	userRepoPath := filepath.Join(cfg.General.DataDir, "users.db")
@@ -163,12 +414,81 @@ func main() {
 	}
 
 	serviceRepoPath := filepath.Join(cfg.General.DataDir, "service.db")
-	serviceRepo, err := storage.NewSecureServiceRepository(serviceRepoPath, logger)
+	serviceRepo, err := storage.NewSecureServiceRepository(serviceRepoPath, machineIDService, logger)
 	if err != nil {
 		logger.Error("Failed to create service repository", "error", err)
 		os.Exit(1)
 	}
 
+	// Seal the fields queues declare sensitive, every node sharing the
+	// configured key derives the same queue keys
+	if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+		if cfg.Security.FieldEncryptionKey != "" {
+			msgSvc.SetFieldEncryption(cryptoService, sha256.Sum256([]byte(cfg.Security.FieldEncryptionKey)))
+		} else if machineID, err := machineIDService.GetMachineID(); err != nil {
+			logger.Error("Failed to get machine ID, field encryption disabled", "error", err)
+		} else {
+			logger.Warn("security.fieldEncryptionKey is not set, encrypted fields use a key tied to this host: " +
+				"they can't be read after a standby promotion, a restore on another host or a machine ID change")
+			msgSvc.SetFieldEncryption(cryptoService, cryptoService.DeriveKey(machineID+"/fields"))
+		}
+	}
+
+	// Email and chat notifications for capacity alerts and account events
+	var notifier outbound.Notifier
+	var notifiers notification.Notifiers
+	comps.Register("notifications", nil, func(context.Context) error {
+		notifiers.Close()
+		return nil
+	})
+	if cfg.Notifications.Enabled {
+		if cfg.Notifications.SMTP.Host != "" {
+			smtpNotifier, err := notification.NewSMTPNotifier(cfg.Notifications, logger)
+			if err != nil {
+				logger.Error("Failed to start notifications", "error", err)
+				os.Exit(1)
+			}
+			notifiers = append(notifiers, smtpNotifier)
+		}
+		for _, webhook := range cfg.Notifications.Webhooks {
+			chatNotifier, err := notification.NewChatNotifier(webhook, logger)
+			if err != nil {
+				logger.Error("Failed to start webhook notifications", "webhook", webhook.Name, "error", err)
+				os.Exit(1)
+			}
+			notifiers = append(notifiers, chatNotifier)
+		}
+		notifier = notifiers
+
+		if statsSvc, ok := statsService.(*service.StatsServiceImpl); ok {
+			statsSvc.SetNotifier(notifier)
+		}
+	}
+
+	// Handler panics, delivery errors and storage failures are forwarded to Sentry
+	var errorReporter *errorreport.SentryReporter
+	if cfg.ErrorReporting.SentryDSN != "" {
+		reporter, err := errorreport.NewSentryReporter(cfg.ErrorReporting, cfg.General.NodeID, logger)
+		if err != nil {
+			logger.Error("Failed to start error reporting", "error", err)
+			os.Exit(1)
+		}
+		errorReporter = reporter
+
+		if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+			msgSvc.SetErrorReporter(errorReporter)
+		}
+		if queueSvc, ok := queueService.(*service.QueueServiceImpl); ok {
+			queueSvc.SetErrorReporter(errorReporter)
+		}
+	}
+	comps.Register("error-reporting", nil, func(context.Context) error {
+		if errorReporter != nil {
+			errorReporter.Close()
+		}
+		return nil
+	})
+
 	// Initialize the auth service
 	authService := service.NewAuthService(
 		userRepo,
@@ -176,6 +496,7 @@ func main() {
 		logger,
 		cfg.HTTP.JWT.Secret,
 		cfg.HTTP.JWT.ExpirationMinutes,
+		notifier,
 	)
 
 	if err := autoBootstrapAdmin(authService, logger); err != nil {
@@ -228,6 +549,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Initialize the user preferences repository
+	preferencesRepo, err := storage.NewSecurePreferencesRepository(
+		filepath.Join(cfg.General.DataDir, "preferences.db"),
+		cryptoService,
+		machineIDService,
+		logger,
+	)
+	if err != nil {
+		logger.Error("Failed to create preferences repository", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize account request service
 	accountRequestService := service.NewAccountRequestService(
 		accountRequestRepo,
@@ -235,6 +568,7 @@ func main() {
 		cryptoService,
 		messageService,
 		authService,
+		notifier,
 		logger,
 	)
 
@@ -251,19 +585,62 @@ func main() {
 		logger,
 	)
 
-	// Start file watcher service
-	if err := fileWatcherService.Start(ctx); err != nil {
-		logger.Error("Failed to start file watcher service", "error", err)
-		os.Exit(1)
-	}
+	comps.Register("account-request-watcher", func(ctx context.Context) error {
+		if err := fileWatcherService.Start(ctx); err != nil {
+			return err
+		}
 
-	// Watch account request file
-	if err := fileWatcherService.WatchAccountRequestFile(ctx, userRepoPath); err != nil {
-		logger.Error("Failed to watch account request file", "error", err)
-	}
+		// Watch account request file
+		if err := fileWatcherService.WatchAccountRequestFile(ctx, userRepoPath); err != nil {
+			logger.Error("Failed to watch account request file", "error", err)
+		}
+		return nil
+	}, func(context.Context) error {
+		return fileWatcherService.Stop()
+	}, "messages")
+
+	// Reconcile the broker with the manifest directory
+	var reconcilerService inbound.ReconcilerService
+	if cfg.GitOps.Enabled {
+		reconciler := service.NewReconcilerService(
+			manifest.NewDirectorySource(cfg.GitOps.Directory),
+			domainService,
+			queueService,
+			routingService,
+			serviceRepo,
+			logger,
+			cfg.GitOps.Prune,
+		)
+		reconcilerService = reconciler
+
+		// the watcher follows the directory holding the path it is given
+		manifestWatcher, err := filewatcher.NewFSWatcher()
+		if err != nil {
+			logger.Error("Failed to create manifest watcher", "error", err)
+			os.Exit(1)
+		}
+		var manifestChanges <-chan outbound.FileChangeEvent
+		if err := manifestWatcher.Watch(ctx, filepath.Join(cfg.GitOps.Directory, "manifests.yaml")); err != nil {
+			logger.Warn("Failed to watch manifest directory, relying on the interval", "error", err)
+		} else {
+			manifestChanges = manifestWatcher.Events()
+		}
 
-	// Create HTTP router
-	router := mux.NewRouter()
+		start, stop := background(func(ctx context.Context) {
+			reconciler.Run(ctx, cfg.GitOps.Interval, manifestChanges)
+		})
+		comps.Register("gitops", start, func(ctx context.Context) error {
+			err := stop(ctx)
+			if manifestChanges != nil {
+				manifestWatcher.Stop()
+			}
+			return err
+		}, "queues", "routing", "predefined-domains")
+		logger.Info("GitOps reconciliation enabled",
+			"directory", cfg.GitOps.Directory,
+			"interval", cfg.GitOps.Interval,
+			"prune", cfg.GitOps.Prune)
+	}
 
 	// Configure the incoming adapters
 	if cfg.HTTP.Enabled {
@@ -273,6 +650,13 @@ func main() {
 			os.Exit(1)
 		}
 
+		// JSON engine of the publish, consume and stats paths
+		if cfg.HTTP.JSONEngine != "" {
+			if err := rest.SetJSONEngine(cfg.HTTP.JSONEngine); err != nil {
+				logger.Warn("Falling back to encoding/json", "ERROR", err)
+			}
+		}
+
 		// REST adapter
 		restHandler := rest.NewHandler(
 			logger,
@@ -289,142 +673,59 @@ func main() {
 			consumerGroupRepo,
 			serviceRepo,
 			accountRequestService,
+			snapshotService,
+			reconcilerService,
+			erasureService,
 		)
-		restHandler.SetupRoutes(router)
+		if standbyService != nil {
+			restHandler.SetStandbyReplica(standbyService)
+		}
+		restHandler.SetPreferencesRepository(preferencesRepo)
+		restHandler.SetBlueprintService(service.NewBlueprintService(logger, cfg.Blueprints))
+		if errorReporter != nil {
+			restHandler.SetErrorReporter(errorReporter)
+		}
 
-		// WebSocket adapter
+		// one server per listener, each with its own routes, middleware and TLS
 		wsHandler := websocket.NewHandler(messageService, ctx)
-		router.HandleFunc(
-			"/api/ws/domains/{domain}/queues/{queue}",
-			func(w http.ResponseWriter, r *http.Request) {
-				vars := mux.Vars(r)
-				wsHandler.HandleConnection(w, r, vars["domain"], vars["queue"])
-			},
-		)
-
-		router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-			pathTemplate, err := route.GetPathTemplate()
-			if err != nil {
-				logger.Error("ROUTE ERROR", "ERROR", err)
-				return nil
-			}
-			methods, err := route.GetMethods()
-			if err != nil {
-				methods = []string{"ANY"}
-			}
-			logger.Info("ROUTE", "PATH", pathTemplate, "METHOD", methods)
-			return nil
+		wsHandler.SetTimeouts(cfg.HTTP.Connections.HandshakeTimeout, cfg.HTTP.Connections.IdleTimeout)
+		wsHandler.SetQoS(websocket.QoS{
+			Rate:       cfg.HTTP.Connections.Subscriptions.MaxRate,
+			MaxUnacked: cfg.HTTP.Connections.Subscriptions.MaxUnacked,
+			Buffer:     cfg.HTTP.Connections.Subscriptions.Buffer,
+			Overflow:   websocket.OverflowPolicy(cfg.HTTP.Connections.Subscriptions.Overflow),
 		})
-
-		// start HTTP server
-		httpAddr := fmt.Sprintf("%s:%d", cfg.HTTP.Address, cfg.HTTP.Port)
-		server := &http.Server{
-			Addr:         httpAddr,
-			Handler:      router,
-			ReadTimeout:  30 * time.Second,
-			WriteTimeout: 30 * time.Second,
-			IdleTimeout:  120 * time.Second,
-		}
-
-		// Configure TLS if enabled
-		if cfg.HTTP.TLS {
-			// Optional: Configure TLS settings for security
-			server.TLSConfig = &tls.Config{
-				MinVersion: tls.VersionTLS12, // Minimum TLS 1.2
-				CipherSuites: []uint16{
-					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-				},
-			}
+		wsHandler.SetResumeWindow(cfg.HTTP.Connections.Subscriptions.ResumeWindow)
+		wsHandler.SetSubscriptionAuthorizer(restHandler.AuthorizeWebSocketSubscription)
+		if cfg.Redaction.WebSocket {
+			wsHandler.SetRedaction(&cfg.Redaction)
 		}
-
-		// Start HTTP/HTTPS server
-		go func() {
-			if cfg.HTTP.TLS {
-				logger.Info("HTTPS server listening",
-					"URL", fmt.Sprintf("https://%s", httpAddr),
-					"certFile", cfg.HTTP.CertFile,
-					"keyFile", cfg.HTTP.KeyFile)
-
-				if err := server.ListenAndServeTLS(cfg.HTTP.CertFile, cfg.HTTP.KeyFile); err != nil && err != http.ErrServerClosed {
-					logger.Error("HTTPS server error", "error", err)
-				}
-			} else {
-				logger.Info("HTTP server listening", "URL", fmt.Sprintf("http://%s", httpAddr))
-
-				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-					logger.Error("HTTP server error", "error", err)
-				}
-			}
-		}()
-
-		// stop HTTP server
-		defer func() {
-			// Important cleanup order: start with services that depend on others
-			logger.Info("Cleaning up services...")
-
-			// Suggested cleanup order (from most dependent to least dependent)
-			if wsHandler != nil {
-				wsHandler.Cleanup()
+		var httpServers []*http.Server
+		stopHTTP := func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, httpShutdownTimeout)
+			defer cancel()
+
+			var errs []error
+			for _, server := range httpServers {
+				errs = append(errs, server.Shutdown(ctx))
 			}
-
-			// if grpcServer != nil {
-			// 	grpcServer.Stop()
-			// }
-
-			if messageService != nil {
-				if cleanable, ok := messageService.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
-				}
-			}
-
-			if queueService != nil {
-				if cleanable, ok := queueService.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
-				}
-			}
-
-			if statsService != nil {
-				if cleanable, ok := statsService.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
-				}
-			}
-
-			if routingService != nil {
-				if cleanable, ok := routingService.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
-				}
-			}
-
-			if domainService != nil {
-				if cleanable, ok := domainService.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
-				}
-			}
-
-			if slogAdapter, ok := logger.(*logging.SlogAdapter); ok {
-				slogAdapter.Shutdown()
-			}
-
-			if fileWatcher != nil {
-				if cleanable, ok := fileWatcher.(interface{ Cleanup() }); ok {
-					cleanable.Cleanup()
+			wsHandler.Cleanup()
+			return errors.Join(errs...)
+		}
+		comps.Register("http", func(ctx context.Context) error {
+			for _, listener := range cfg.HTTPListeners() {
+				router := newListenerRouter(listener, restHandler, wsHandler, logger)
+				server, err := startHTTPListener(listener, router, cfg.HTTP.Connections.HandshakeTimeout, cfg.Security.Hardened, logger)
+				if err != nil {
+					// a component failing to start isn't stopped, close the listeners already bound
+					return errors.Join(err, stopHTTP(ctx))
 				}
+				httpServers = append(httpServers, server)
 			}
-
-			logger.Info("All services cleaned up")
-		}()
+			return nil
+		}, stopHTTP, "messages", "resources", "account-request-watcher", "predefined-domains", "error-reporting")
 	}
 
-	// Middleware for debugging requests
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger.Info("Request", "METHOD", r.Method, "PATH", r.URL.Path)
-			next.ServeHTTP(w, r)
-		})
-	})
-
 	// Configure the gRPC adapter if enabled
 	if cfg.GRPC.Enabled {
 		grpcServer := grpc.NewServer(
@@ -435,41 +736,80 @@ func main() {
 			ctx,
 		)
 		grpcAddr := fmt.Sprintf("%s:%d", cfg.GRPC.Address, cfg.GRPC.Port)
-		if err := grpcServer.Start(grpcAddr); err != nil {
-			logger.Error("Failed to start gRPC server", "erroe", err)
-		}
+		comps.Register("grpc", func(context.Context) error {
+			return grpcServer.Start(grpcAddr)
+		}, func(context.Context) error {
+			grpcServer.Stop()
+			return nil
+		}, "messages", "predefined-domains")
+	}
 
-		// Stop the gRPC server at the end
-		defer grpcServer.Stop()
+	// Configure the MQTT adapter if enabled
+	if cfg.MQTT.Enabled {
+		mqttServer := mqtt.NewServer(messageService, ctx)
+		topics := make([]mqtt.TopicMapping, 0, len(cfg.MQTT.Topics))
+		for _, topic := range cfg.MQTT.Topics {
+			topics = append(topics, mqtt.TopicMapping{Filter: topic.Filter, Domain: topic.Domain, Queue: topic.Queue})
+		}
+		if err := mqttServer.SetTopics(topics); err != nil {
+			logger.Error("Invalid MQTT topics", "ERROR", err)
+			os.Exit(1)
+		}
+		// clients log in with a service account once authentication is on
+		if cfg.Security.EnableAuthentication {
+			mqttServer.SetServiceRepository(serviceRepo)
+		}
+		mqttAddr := fmt.Sprintf("%s:%d", cfg.MQTT.Address, cfg.MQTT.Port)
+		comps.Register("mqtt", func(context.Context) error {
+			return mqttServer.Start(mqttAddr)
+		}, func(context.Context) error {
+			mqttServer.Stop()
+			return nil
+		}, "messages", "predefined-domains")
 	}
 
-	// TODO: Implement adapters for AMQP and MQTT
+	// TODO: Implement an adapter for AMQP
 
-	// Create predefined domains (if configured)
-	for _, domainCfg := range cfg.Domains {
-		logger.Info("Creating predefined domain", "domainName", domainCfg.Name)
-		if err := createDomainFromConfig(ctx, domainService, queueService, routingService, domainCfg); err != nil {
-			logger.Error("Failed to create domain",
-				"domainName", domainCfg.Name,
-				"ERROR", err)
+	// Create predefined domains (if configured), before the adapters take traffic
+	comps.Register("predefined-domains", func(ctx context.Context) error {
+		for _, domainCfg := range cfg.Domains {
+			logger.Info("Creating predefined domain", "domainName", domainCfg.Name)
+			if err := createDomainFromConfig(ctx, domainService, queueService, routingService, domainCfg); err != nil {
+				logger.Error("Failed to create domain",
+					"domainName", domainCfg.Name,
+					"ERROR", err)
+			}
 		}
-	}
+		return nil
+	}, nil, "queues", "routing")
 
-	// Wait for signals for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// A component failing to start stops the ones started before it
+	if err := comps.Start(ctx); err != nil {
+		logger.Error("Failed to start GoRTMS", "ERROR", err)
+		cancel()
+		logger.Shutdown()
+		os.Exit(1)
+	}
 
 	// Display the startup message
 	logger.Info("GoRTMS started successfully")
+	lifecycle.Ready()
 
-	// Wait for shutdown signal
-	sig := <-sigChan
-	logger.Info("Received signal, shutting down gracefully...", "signal", sig)
+	// Wait for a signal or a service manager stop for graceful shutdown
+	reason := lifecycle.Wait()
+	logger.Info("Shutdown requested, shutting down gracefully...", "reason", reason)
+
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := comps.Stop(stopCtx); err != nil {
+		logger.Error("Shutdown incomplete", "ERROR", err)
+	}
+	cancelStop()
 
 	// Cancel the context to stop all goroutines
 	cancel()
 
 	logger.Info("Server shutdown complete")
+	logger.Shutdown()
 }
 
 func autoBootstrapAdmin(authService inbound.AuthService, logger outbound.Logger) error {
@@ -505,21 +845,14 @@ func createDomainFromConfig(
 	routingService inbound.RoutingService,
 	config config.DomainConfig,
 ) error {
+	desired := config.Desired()
+
 	// Create domain
 	domainConfig := &model.DomainConfig{
-		Name: config.Name,
-		Schema: &model.Schema{
-			Fields: make(map[string]model.FieldType),
-		},
-	}
-
-	// If a schema is defined, convert the fields
-	if schema, ok := config.Schema["fields"].(map[string]any); ok {
-		for field, typeVal := range schema {
-			if typeStr, ok := typeVal.(string); ok {
-				domainConfig.Schema.Fields[field] = model.FieldType(typeStr)
-			}
-		}
+		Name:                desired.Name,
+		Schema:              desired.Schema,
+		AllowedRouteSources: desired.AllowedRouteSources,
+		Labels:              desired.Labels,
 	}
 
 	if err := domainService.CreateDomain(ctx, domainConfig); err != nil {
@@ -527,59 +860,15 @@ func createDomainFromConfig(
 	}
 
 	// Create the queues
-	for _, queueCfg := range config.Queues {
-		queueConfig := queueCfg.Config
-
-		// Default values for retry configuration
-		if queueConfig.RetryEnabled && queueConfig.RetryConfig != nil {
-			if queueConfig.RetryConfig.InitialDelay == 0 {
-				queueConfig.RetryConfig.InitialDelay = 1 * time.Second
-			}
-			if queueConfig.RetryConfig.MaxDelay == 0 {
-				queueConfig.RetryConfig.MaxDelay = 30 * time.Second
-			}
-			if queueConfig.RetryConfig.Factor <= 0 {
-				queueConfig.RetryConfig.Factor = 2.0
-			}
-		}
-
-		// Default values for circuit breaker
-		if queueConfig.CircuitBreakerEnabled && queueConfig.CircuitBreakerConfig != nil {
-			if queueConfig.CircuitBreakerConfig.ErrorThreshold <= 0 {
-				queueConfig.CircuitBreakerConfig.ErrorThreshold = 0.5
-			}
-			if queueConfig.CircuitBreakerConfig.MinimumRequests <= 0 {
-				queueConfig.CircuitBreakerConfig.MinimumRequests = 10
-			}
-			if queueConfig.CircuitBreakerConfig.OpenTimeout == 0 {
-				queueConfig.CircuitBreakerConfig.OpenTimeout = 30 * time.Second
-			}
-			if queueConfig.CircuitBreakerConfig.SuccessThreshold <= 0 {
-				queueConfig.CircuitBreakerConfig.SuccessThreshold = 5
-			}
-		}
-
-		if err := queueService.CreateQueue(ctx, config.Name, queueCfg.Name, &queueConfig); err != nil {
-			return fmt.Errorf("failed to create queue %s: %w", queueCfg.Name, err)
+	for _, queue := range desired.Queues {
+		if err := queueService.CreateQueue(ctx, desired.Name, queue.Name, &queue.Config); err != nil {
+			return fmt.Errorf("failed to create queue %s: %w", queue.Name, err)
 		}
 	}
 
 	// Add routing rules
-	for _, routeCfg := range config.Routes {
-		// Create a rule with a simple JSON predicate
-		rulePredicate := model.JSONPredicate{
-			Type:  routeCfg.Predicate["type"].(string),
-			Field: routeCfg.Predicate["field"].(string),
-			Value: routeCfg.Predicate["value"],
-		}
-
-		rule := &model.RoutingRule{
-			SourceQueue:      routeCfg.SourceQueue,
-			DestinationQueue: routeCfg.DestinationQueue,
-			Predicate:        rulePredicate,
-		}
-
-		if err := routingService.AddRoutingRule(ctx, config.Name, rule); err != nil {
+	for _, rule := range desired.Routes {
+		if err := routingService.AddRoutingRule(ctx, desired.Name, rule); err != nil {
 			return fmt.Errorf("failed to add routing rule: %w", err)
 		}
 	}
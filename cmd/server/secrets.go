@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/adapter/outbound/machineid"
+	"github.com/ajkula/GoRTMS/config"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// The master key decrypting the ENC[master:...] configuration values, set
+// directly or through a file such as a Docker or Kubernetes secret
+const (
+	masterKeyEnv     = "GORTMS_MASTER_KEY"
+	masterKeyFileEnv = "GORTMS_MASTER_KEY_FILE"
+)
+
+// readMasterKey returns the master key, empty when none is set
+func readMasterKey() (string, error) {
+	if key := os.Getenv(masterKeyEnv); key != "" {
+		return key, nil
+	}
+	path := os.Getenv(masterKeyFileEnv)
+	if path == "" {
+		return "", nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", masterKeyFileEnv, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// decryptConfig decrypts the ENC[...] values of the configuration and
+// returns the machine ID of the node. security.machineId, which the machine
+// key derives from, can only be encrypted with the master key.
+func decryptConfig(cfg *config.Config, cryptoService outbound.CryptoService, logger outbound.Logger) (*machineid.FallbackMachineID, error) {
+	masterKey, err := readMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.DecryptValues(crypto.NewValueCipher(cryptoService, masterKey, nil).Decrypt, "security.machineId")
+	if err != nil {
+		return nil, err
+	}
+
+	machineIDService := machineid.NewMachineID(cfg.Security.MachineID, cfg.General.DataDir, logger)
+	err = cfg.DecryptValues(crypto.NewValueCipher(cryptoService, masterKey, machineIDService).Decrypt)
+	if err != nil {
+		return nil, err
+	}
+	return machineIDService, nil
+}
+
+// runEncryptValueCommand handles `gortms encrypt-value`, printing the
+// ENC[...] form of a secret to paste in the configuration file. The secret
+// is read from stdin unless given as argument, keeping it out of the shell
+// history.
+func runEncryptValueCommand(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("encrypt-value", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to the configuration file, for the machine ID override and data directory")
+	key := fs.String("key", "", "Key to encrypt with: master (default when "+masterKeyEnv+" or "+masterKeyFileEnv+" is set) or machine")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	masterKey, err := readMasterKey()
+	if err != nil {
+		return err
+	}
+	if *key == "" {
+		*key = crypto.ValueKeyMachine
+		if masterKey != "" {
+			*key = crypto.ValueKeyMaster
+		}
+	}
+
+	value := fs.Arg(0)
+	if fs.NArg() == 0 {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		return errors.New("usage: gortms encrypt-value [--key master|machine] [--config path] [value], the value being read from stdin when not given")
+	}
+
+	cryptoService := crypto.NewAESCryptoService()
+	var machineIDService outbound.MachineIDService
+	if *key == crypto.ValueKeyMachine {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		if machineIDService, err = decryptConfig(cfg, cryptoService, stderrLogger{}); err != nil {
+			return err
+		}
+	}
+
+	ciphertext, err := crypto.NewValueCipher(cryptoService, masterKey, machineIDService).Encrypt(*key, value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, config.EncryptedValue(ciphertext))
+	return err
+}
+
+// stderrLogger reports the warnings of the commands, on stderr
+type stderrLogger struct{}
+
+func (stderrLogger) Error(msg string, args ...any) {
+	fmt.Fprintln(os.Stderr, append([]any{"error:", msg}, args...)...)
+}
+func (stderrLogger) Warn(msg string, args ...any) {
+	fmt.Fprintln(os.Stderr, append([]any{"warning:", msg}, args...)...)
+}
+func (stderrLogger) Info(msg string, args ...any)  {}
+func (stderrLogger) Debug(msg string, args ...any) {}
+func (stderrLogger) UpdateLevel(logLvl string)     {}
+func (stderrLogger) Shutdown()                     {}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/config"
+)
+
+func TestEncryptValueCommand(t *testing.T) {
+	t.Setenv(masterKeyEnv, "correct horse battery staple")
+
+	var out bytes.Buffer
+	if err := runEncryptValueCommand(nil, strings.NewReader("smtp-password\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	encrypted := strings.TrimSpace(out.String())
+	if !config.IsEncrypted(encrypted) || !strings.HasPrefix(encrypted, "ENC[master:") {
+		t.Fatalf("unexpected value %q", encrypted)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.General.DataDir = t.TempDir()
+	cfg.Notifications.SMTP.Password = encrypted
+	if _, err := decryptConfig(cfg, crypto.NewAESCryptoService(), nopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Notifications.SMTP.Password != "smtp-password" {
+		t.Errorf("decrypted %q", cfg.Notifications.SMTP.Password)
+	}
+
+	t.Setenv(masterKeyEnv, "another key")
+	cfg.Notifications.SMTP.Password = encrypted
+	if _, err := decryptConfig(cfg, crypto.NewAESCryptoService(), nopLogger{}); err == nil {
+		t.Error("expected the wrong master key to be refused")
+	}
+}
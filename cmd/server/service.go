@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	serviceName        = "gortms"
+	serviceDisplayName = "GoRTMS"
+	serviceDescription = "GoRTMS real-time message broker"
+)
+
+var errServiceUnsupported = errors.New("service management is not supported on this platform")
+
+// serviceSpec describes how the service manager starts the server
+type serviceSpec struct {
+	Name        string
+	DisplayName string
+	Description string
+	ExecPath    string
+	Args        []string
+	WorkingDir  string
+}
+
+// serviceManager installs and drives the server through the OS service manager
+// (systemd on Linux, the Service Control Manager on Windows)
+type serviceManager interface {
+	Install(spec serviceSpec) error
+	Uninstall(name string) error
+	Start(name string) error
+	Stop(name string) error
+	Status(name string) (string, error)
+}
+
+// serviceLifecycle ties the running server to its service manager: it
+// reports readiness and turns the manager's stop requests into a shutdown
+type serviceLifecycle interface {
+	// Ready tells the manager the server accepts traffic
+	Ready()
+	// Wait blocks until a shutdown is requested and returns its cause
+	Wait() string
+	// Stopped tells the manager the shutdown is complete
+	Stopped()
+}
+
+var serviceCommands = map[string]bool{
+	"install-service":   true,
+	"uninstall-service": true,
+	"start-service":     true,
+	"stop-service":      true,
+	"service-status":    true,
+}
+
+func isServiceCommand(arg string) bool {
+	return serviceCommands[arg]
+}
+
+// runServiceCommand handles `gortms <command> [--config path] [--log-file path]`
+func runServiceCommand(command string, args []string) error {
+	manager, err := newServiceManager()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "install-service":
+		spec, err := installSpec(args)
+		if err != nil {
+			return err
+		}
+		if err := manager.Install(spec); err != nil {
+			return err
+		}
+		fmt.Printf("Service %s installed, start it with: %s start-service\n", spec.Name, filepath.Base(spec.ExecPath))
+		return nil
+
+	case "uninstall-service":
+		if err := manager.Uninstall(serviceName); err != nil {
+			return err
+		}
+		fmt.Printf("Service %s uninstalled\n", serviceName)
+		return nil
+
+	case "start-service":
+		return manager.Start(serviceName)
+
+	case "stop-service":
+		return manager.Stop(serviceName)
+
+	case "service-status":
+		status, err := manager.Status(serviceName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Service %s: %s\n", serviceName, status)
+		return nil
+	}
+
+	return fmt.Errorf("unknown service command: %s", command)
+}
+
+// builds the command line the service manager runs, with absolute paths
+// since services do not start from the install directory
+func installSpec(args []string) (serviceSpec, error) {
+	fs := flag.NewFlagSet("install-service", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	logFile := fs.String("log-file", defaultServiceLogFile(), "Log file used by the service (empty keeps the service manager's log)")
+	if err := fs.Parse(args); err != nil {
+		return serviceSpec{}, err
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	absConfig, err := filepath.Abs(*configPath)
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	if _, err := os.Stat(absConfig); err != nil {
+		return serviceSpec{}, fmt.Errorf("config file not found: %s (generate one with --generate-config)", absConfig)
+	}
+
+	spec := serviceSpec{
+		Name:        serviceName,
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		ExecPath:    execPath,
+		Args:        []string{"--config", absConfig},
+		WorkingDir:  filepath.Dir(absConfig),
+	}
+
+	if *logFile != "" {
+		absLog, err := filepath.Abs(*logFile)
+		if err != nil {
+			return serviceSpec{}, fmt.Errorf("failed to resolve log file path: %w", err)
+		}
+		spec.Args = append(spec.Args, "--log-file", absLog)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const systemdUnitDir = "/etc/systemd/system"
+
+// systemdManager installs the server as a systemd unit, logs go to the journal
+type systemdManager struct {
+	unitDir string
+}
+
+func newServiceManager() (serviceManager, error) {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return nil, fmt.Errorf("systemctl not found: %w", errServiceUnsupported)
+	}
+	return &systemdManager{unitDir: systemdUnitDir}, nil
+}
+
+// the journal already captures stdout
+func defaultServiceLogFile() string {
+	return ""
+}
+
+func (m *systemdManager) unitPath(name string) string {
+	return filepath.Join(m.unitDir, name+".service")
+}
+
+func renderSystemdUnit(spec serviceSpec) string {
+	execStart := make([]string, 0, len(spec.Args)+1)
+	for _, arg := range append([]string{spec.ExecPath}, spec.Args...) {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+
+	return fmt.Sprintf(`[Unit]
+Description=%s
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+ExecStart=%s
+WorkingDirectory=%s
+Restart=on-failure
+RestartSec=5
+KillSignal=SIGTERM
+TimeoutStopSec=30
+StandardOutput=journal
+StandardError=journal
+
+[Install]
+WantedBy=multi-user.target
+`, spec.Description, strings.Join(execStart, " "), systemdQuote(spec.WorkingDir))
+}
+
+func systemdQuote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func (m *systemdManager) Install(spec serviceSpec) error {
+	path := m.unitPath(spec.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("service %s is already installed (%s)", spec.Name, path)
+	}
+
+	if err := os.WriteFile(path, []byte(renderSystemdUnit(spec)), 0644); err != nil {
+		return fmt.Errorf("failed to write unit file (are you root?): %w", err)
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", spec.Name)
+}
+
+func (m *systemdManager) Uninstall(name string) error {
+	path := m.unitPath(name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+
+	// stopping an inactive unit is fine
+	_ = systemctl("stop", name)
+	_ = systemctl("disable", name)
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %w", err)
+	}
+	return systemctl("daemon-reload")
+}
+
+func (m *systemdManager) Start(name string) error {
+	return systemctl("start", name)
+}
+
+func (m *systemdManager) Stop(name string) error {
+	return systemctl("stop", name)
+}
+
+func (m *systemdManager) Status(name string) (string, error) {
+	// is-active exits non-zero for inactive units, the output is what matters
+	out, _ := exec.Command("systemctl", "is-active", name).Output()
+	status := strings.TrimSpace(string(out))
+	if status == "" {
+		return "", fmt.Errorf("failed to query service %s", name)
+	}
+	return status, nil
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSystemdUnit(t *testing.T) {
+	unit := renderSystemdUnit(serviceSpec{
+		Description: serviceDescription,
+		ExecPath:    "/opt/gortms/gortms",
+		Args:        []string{"--config", "/etc/gortms/my config.yaml"},
+		WorkingDir:  "/etc/gortms",
+	})
+
+	for _, want := range []string{
+		"Type=notify",
+		`ExecStart=/opt/gortms/gortms --config "/etc/gortms/my config.yaml"`,
+		"WorkingDirectory=/etc/gortms",
+		"StandardOutput=journal",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit file misses %q:\n%s", want, unit)
+		}
+	}
+}
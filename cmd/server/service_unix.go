@@ -0,0 +1,52 @@
+//go:build !windows
+
+package main
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// unixLifecycle shuts down on the signals sent by init systems and terminals,
+// and speaks the systemd notify protocol when started with Type=notify
+type unixLifecycle struct {
+	signals chan os.Signal
+}
+
+func newServiceLifecycle() serviceLifecycle {
+	l := &unixLifecycle{signals: make(chan os.Signal, 1)}
+	signal.Notify(l.signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	return l
+}
+
+func (l *unixLifecycle) Ready() {
+	notifySystemd("READY=1")
+}
+
+func (l *unixLifecycle) Wait() string {
+	sig := <-l.signals
+	notifySystemd("STOPPING=1")
+	return sig.String()
+}
+
+func (l *unixLifecycle) Stopped() {
+	signal.Stop(l.signals)
+}
+
+// notifySystemd sends a state to $NOTIFY_SOCKET, a no-op outside systemd
+func notifySystemd(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	conn.Write([]byte(state))
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+
+package main
+
+func newServiceManager() (serviceManager, error) {
+	return nil, errServiceUnsupported
+}
+
+func defaultServiceLogFile() string {
+	return ""
+}
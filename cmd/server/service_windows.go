@@ -0,0 +1,244 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// scmManager installs the server in the Windows Service Control Manager
+type scmManager struct{}
+
+func newServiceManager() (serviceManager, error) {
+	return &scmManager{}, nil
+}
+
+// services have no console, log next to the executable by default
+func defaultServiceLogFile() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(exe), "logs", "gortms.log")
+}
+
+func (m *scmManager) connect() (*mgr.Mgr, error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	return manager, nil
+}
+
+func (m *scmManager) Install(spec serviceSpec) error {
+	manager, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	if s, err := manager.OpenService(spec.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s is already installed", spec.Name)
+	}
+
+	s, err := manager.CreateService(spec.Name, spec.ExecPath, mgr.Config{
+		DisplayName: spec.DisplayName,
+		Description: spec.Description,
+		StartType:   mgr.StartAutomatic,
+	}, spec.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	defer s.Close()
+
+	// restart after a crash, like Restart=on-failure with systemd
+	return s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+	}, uint32((24 * time.Hour).Seconds()))
+}
+
+func (m *scmManager) Uninstall(name string) error {
+	manager, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	s, err := manager.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	// stopping a stopped service is fine
+	_, _ = s.Control(svc.Stop)
+
+	return s.Delete()
+}
+
+func (m *scmManager) Start(name string) error {
+	manager, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	s, err := manager.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	return s.Start()
+}
+
+func (m *scmManager) Stop(name string) error {
+	manager, err := m.connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	s, err := manager.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+
+	// wait for the graceful shutdown to complete
+	deadline := time.Now().Add(30 * time.Second)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not stop within 30s", name)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *scmManager) Status(name string) (string, error) {
+	manager, err := m.connect()
+	if err != nil {
+		return "", err
+	}
+	defer manager.Disconnect()
+
+	s, err := manager.OpenService(name)
+	if err != nil {
+		return "not installed", nil
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return "", err
+	}
+
+	switch status.State {
+	case svc.Running:
+		return "running", nil
+	case svc.Stopped:
+		return "stopped", nil
+	case svc.StartPending:
+		return "starting", nil
+	case svc.StopPending:
+		return "stopping", nil
+	default:
+		return fmt.Sprintf("state %d", status.State), nil
+	}
+}
+
+// windowsLifecycle runs the SCM handler when started as a service,
+// and falls back to console signals otherwise
+type windowsLifecycle struct {
+	isService bool
+	ready     chan struct{}
+	stop      chan string
+	stopped   chan struct{}
+	signals   chan os.Signal
+}
+
+func newServiceLifecycle() serviceLifecycle {
+	l := &windowsLifecycle{
+		ready:   make(chan struct{}),
+		stop:    make(chan string, 1),
+		stopped: make(chan struct{}),
+		signals: make(chan os.Signal, 1),
+	}
+
+	isService, err := svc.IsWindowsService()
+	if err == nil && isService {
+		l.isService = true
+		go func() {
+			if err := svc.Run(serviceName, l); err != nil {
+				l.stop <- "service manager error: " + err.Error()
+			}
+		}()
+	} else {
+		signal.Notify(l.signals, os.Interrupt, syscall.SIGTERM)
+	}
+
+	return l
+}
+
+// Execute implements svc.Handler
+func (l *windowsLifecycle) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	<-l.ready
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			changes <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			changes <- svc.Status{State: svc.StopPending, WaitHint: 30000}
+			if request.Cmd == svc.Stop {
+				l.stop <- "service stop"
+			} else {
+				l.stop <- "system shutdown"
+			}
+			// returning reports the service as stopped, wait for the cleanup
+			<-l.stopped
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+func (l *windowsLifecycle) Ready() {
+	close(l.ready)
+}
+
+func (l *windowsLifecycle) Wait() string {
+	select {
+	case reason := <-l.stop:
+		return reason
+	case sig := <-l.signals:
+		return sig.String()
+	}
+}
+
+func (l *windowsLifecycle) Stopped() {
+	if !l.isService {
+		signal.Stop(l.signals)
+	}
+	close(l.stopped)
+}
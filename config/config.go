@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -16,6 +15,10 @@ type Config struct {
 	// Config file path
 	ConfigPath string `yaml:"configPath"`
 
+	// encrypted holds the ENC[...] form of the settings decrypted by
+	// DecryptValues, by YAML path
+	encrypted map[string]encryptedSetting
+
 	// General configuration
 	General struct {
 
@@ -48,6 +51,18 @@ type Config struct {
 
 		// MaxSizeMB is the max storage size in MB
 		MaxSizeMB int `yaml:"maxSizeMB"`
+
+		// ClaimCheck offloads large payloads to a blob store
+		ClaimCheck struct {
+			// Enabled enables claim-check storage
+			Enabled bool `yaml:"enabled"`
+
+			// ThresholdKB is the payload size above which messages are offloaded
+			ThresholdKB int `yaml:"thresholdKB"`
+
+			// Path to the blob directory
+			Path string `yaml:"path"`
+		} `yaml:"claimCheck"`
 	} `yaml:"storage"`
 
 	// HTTP server configuration
@@ -70,6 +85,19 @@ type Config struct {
 		// KeyFile is the TLS private key path
 		KeyFile string `yaml:"keyFile"`
 
+		// Listeners replaces the single bind above with one server per entry
+		Listeners []HTTPListener `yaml:"listeners"`
+
+		// APIVersioning announces the retirement of the v1 REST API
+		APIVersioning APIVersioning `yaml:"apiVersioning"`
+
+		// Connections bounds the long-lived (WebSocket) connections
+		Connections ConnectionLimits `yaml:"connections"`
+
+		// JSONEngine encodes the publish, consume and stats JSON: std, or
+		// jsoniter and sonic in binaries built with their tag
+		JSONEngine string `yaml:"jsonEngine"`
+
 		// CORS configuration
 		CORS struct {
 			// Enabled enables CORS
@@ -111,6 +139,10 @@ type Config struct {
 
 		// Port to bind the MQTT server
 		Port int `yaml:"port"`
+
+		// Topics maps topic filters to queues, tried in order before the
+		// default domain/queue topic layout
+		Topics []MQTTTopic `yaml:"topics,omitempty"`
 	} `yaml:"mqtt"`
 
 	// gRPC server configuration
@@ -139,6 +171,20 @@ type Config struct {
 		// AdminPassword is the admin password
 		AdminPassword string `yaml:"adminPassword"`
 
+		// FieldEncryptionKey derives the keys of queue encrypted fields,
+		// empty derives them from the machine ID, tying them to the host.
+		// Required when configured queues declare encrypted fields.
+		FieldEncryptionKey string `yaml:"fieldEncryptionKey,omitempty"`
+
+		// MachineID replaces the machine ID the storage keys derive from, for
+		// containers and VMs without a stable hardware ID. Changing it makes
+		// the stored users and service accounts unreadable.
+		MachineID string `yaml:"machineId,omitempty"`
+
+		// Hardened restricts TLS to modern ciphers, sends HSTS and the
+		// security headers, and turns off the pprof listener
+		Hardened bool `yaml:"hardened"`
+
 		// HMAC configuration for service authentication
 		HMAC struct {
 			// Enabled enables HMAC authentication for services
@@ -149,6 +195,15 @@ type Config struct {
 
 			// RequireTLS requires TLS for HMAC authenticated requests
 			RequireTLS bool `yaml:"requireTLS"`
+
+			// V1Deprecation is when v1 signatures were deprecated (RFC 3339),
+			// sent in the Deprecation header of v1 requests
+			V1Deprecation string `yaml:"v1Deprecation,omitempty"`
+
+			// V1Sunset is when v1 signatures stop being accepted (RFC 3339).
+			// Until then v1 requests are answered with Deprecation and Sunset
+			// headers; empty keeps accepting them silently.
+			V1Sunset string `yaml:"v1Sunset"`
 		} `yaml:"hmac"`
 	} `yaml:"security"`
 
@@ -185,6 +240,45 @@ type Config struct {
 	// Predefined domain configurations
 	Domains []DomainConfig `yaml:"domains"`
 
+	// Blueprints are named queue configs that queue creations reference
+	Blueprints []model.QueueBlueprint `yaml:"blueprints"`
+
+	// GitOps reconciles the broker with a directory of manifests
+	GitOps GitOpsConfig `yaml:"gitops"`
+
+	// Redaction hides payload fields from logs and operational endpoints
+	Redaction model.Redaction `yaml:"redaction"`
+
+	// Ingest maps third-party webhooks posted to /ingest/{name} onto queues
+	Ingest []IngestRoute `yaml:"ingest"`
+
+	// Notifications emails alerts and account events
+	Notifications NotificationConfig `yaml:"notifications"`
+
+	// ErrorReporting forwards the panics recovered from the API handlers,
+	// the delivery errors and the storage failures to an error tracker
+	ErrorReporting ErrorReportingConfig `yaml:"errorReporting"`
+
+	// Search indexes schema fields for the message search endpoints
+	Search SearchConfig `yaml:"search"`
+
+	// Subscribers bounds the time a subscriber handles a message and when
+	// it is quarantined
+	Subscribers model.SubscriberPolicy `yaml:"subscribers"`
+
+	// GroupCleanup decides when idle consumer groups are removed and
+	// whether their positions are archived
+	GroupCleanup model.GroupCleanupPolicy `yaml:"groupCleanup"`
+
+	// Events bounds the system events kept per domain
+	Events model.EventRetention `yaml:"events"`
+
+	// Replication makes the node a warm standby of a primary
+	Replication ReplicationConfig `yaml:"replication"`
+
+	// RemoteWrite pushes the stats to a Prometheus remote-write endpoint
+	RemoteWrite RemoteWriteConfig `yaml:"remoteWrite"`
+
 	Logging struct {
 		Level       string `yaml:"level"` // "ERROR", "WARN", "INFO", "DEBUG"
 		ChannelSize int    `yaml:"channelSize"`
@@ -207,6 +301,12 @@ type DomainConfig struct {
 
 	// Routes is the list of routing rules
 	Routes []RoutingRule `yaml:"routes"`
+
+	// AllowedRouteSources lists the domains allowed to route into this domain
+	AllowedRouteSources []string `yaml:"allowedRouteSources,omitempty"`
+
+	// Labels are free-form key/value tags inherited by the domain's queues
+	Labels map[string]string `yaml:"labels,omitempty"`
 }
 
 // QueueConfig holds the configuration for a queue
@@ -226,8 +326,361 @@ type RoutingRule struct {
 	// DestinationQueue is the destination queue
 	DestinationQueue string `yaml:"destinationQueue"`
 
+	// DestinationDomain is the destination domain for cross-domain rules
+	DestinationDomain string `yaml:"destinationDomain,omitempty"`
+
 	// Predicate defines the routing condition
 	Predicate map[string]interface{} `yaml:"predicate"`
+
+	// Headers selects the headers copied to routed messages (default: all)
+	Headers *model.HeaderPropagation `yaml:"headers,omitempty"`
+
+	// Canary sends a share of the matching messages to another queue
+	Canary *model.CanarySplit `yaml:"canary,omitempty"`
+
+	// Enabled turns the rule off without removing it (default: true)
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	// Priority orders the rules of a source queue, higher is evaluated first
+	Priority int `yaml:"priority,omitempty"`
+
+	// StopOnMatch skips the rules evaluated after this one once it matched
+	StopOnMatch bool `yaml:"stopOnMatch,omitempty"`
+}
+
+// APIVersioning dates the deprecation of the v1 REST API (/api and /api/v1),
+// announced to clients through Deprecation and Sunset response headers
+type APIVersioning struct {
+	// V1Deprecation is the date v1 was deprecated (YYYY-MM-DD), empty keeps it current
+	V1Deprecation string `yaml:"v1Deprecation,omitempty"`
+
+	// V1Sunset is the date after which v1 may be removed (YYYY-MM-DD)
+	V1Sunset string `yaml:"v1Sunset,omitempty"`
+}
+
+// ConnectionLimits protects public deployments from clients holding too many
+// long-lived connections. Zero disables a limit.
+type ConnectionLimits struct {
+	// MaxPerIP bounds the concurrent connections of a client address
+	MaxPerIP int `yaml:"maxPerIP"`
+
+	// MaxPerService bounds the concurrent connections of an HMAC service account
+	MaxPerService int `yaml:"maxPerService"`
+
+	// MaxPerUser bounds the concurrent connections of a JWT user
+	MaxPerUser int `yaml:"maxPerUser"`
+
+	// MaxInFlightPerService bounds the concurrent HMAC requests of a service
+	// account, so one integration can't starve the others. Service accounts
+	// override it and MaxPerService with their own limits.
+	MaxInFlightPerService int `yaml:"maxInFlightPerService"`
+
+	// IdleTimeout closes connections without traffic in either direction
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+
+	// HandshakeTimeout bounds reading the request headers and the upgrade
+	HandshakeTimeout time.Duration `yaml:"handshakeTimeout"`
+
+	// Subscriptions bounds what each WebSocket subscription buffers
+	Subscriptions SubscriptionQoS `yaml:"subscriptions"`
+}
+
+// SubscriptionQoS limits the delivery to a WebSocket subscriber, clients may
+// ask for tighter limits when they connect
+type SubscriptionQoS struct {
+	// MaxRate bounds the messages pushed per second, 0 disables it
+	MaxRate float64 `yaml:"maxRate"`
+
+	// MaxUnacked bounds the messages sent and not yet acked, 0 disables it
+	MaxUnacked int `yaml:"maxUnacked"`
+
+	// Buffer bounds the messages waiting to be sent
+	Buffer int `yaml:"buffer"`
+
+	// Overflow is drop-old, drop-new or disconnect once the buffer is full
+	Overflow string `yaml:"overflow"`
+
+	// ResumeWindow keeps the subscription of a client gone away for it to
+	// reconnect with ?resume=, 0 drops it with the connection
+	ResumeWindow time.Duration `yaml:"resumeWindow"`
+
+	// ResumeBuffer bounds the messages kept meanwhile, the oldest going first
+	ResumeBuffer int `yaml:"resumeBuffer"`
+}
+
+// GitOpsConfig points the reconciler at a directory of YAML manifests
+// describing domains and service accounts
+type GitOpsConfig struct {
+	// Enabled starts the reconciliation loop
+	Enabled bool `yaml:"enabled"`
+
+	// Directory holds the *.yaml / *.yml manifests
+	Directory string `yaml:"directory"`
+
+	// Interval between reconciliation passes, manifest changes trigger one early
+	Interval time.Duration `yaml:"interval"`
+
+	// Prune deletes the managed resources removed from the manifests,
+	// otherwise they are only reported
+	Prune bool `yaml:"prune"`
+}
+
+// NotificationConfig sends notifications by email through an SMTP server
+// and to chat webhooks
+type NotificationConfig struct {
+	// Enabled turns notifications on, each type must still be enabled
+	Enabled bool `yaml:"enabled"`
+
+	// SMTP sends the emails, leave host empty to only use webhooks
+	SMTP SMTPConfig `yaml:"smtp"`
+
+	// Webhooks post alerts and account requests to Slack or Teams channels
+	Webhooks []ChatWebhook `yaml:"webhooks"`
+
+	// Types enables each notification type (alert, account-request,
+	// account-reviewed, password-reset) and overrides its templates
+	Types map[string]NotificationTemplate `yaml:"types"`
+}
+
+// ErrorReportingConfig points the error reports at a Sentry project
+type ErrorReportingConfig struct {
+	// SentryDSN is the client key URL of the project, empty disables
+	// reporting
+	SentryDSN string `yaml:"sentryDsn"`
+
+	// Environment tags the reports, e.g. production or staging
+	Environment string `yaml:"environment"`
+
+	// SampleRate is the share of the reports sent, between 0 and 1; 0
+	// sends them all
+	SampleRate float64 `yaml:"sampleRate"`
+}
+
+// SearchConfig selects the schema fields indexed for message search, a
+// field is only indexed in the domains whose schema declares it
+type SearchConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Fields are indexed in every domain
+	Fields []string `yaml:"fields"`
+
+	// Domains adds fields indexed in a single domain, by domain name
+	Domains map[string][]string `yaml:"domains"`
+}
+
+// ReplicationConfig points a standby at its primary, it copies the domains,
+// queues and messages of the primary until promoted
+type ReplicationConfig struct {
+	// Standby starts the node as a standby of Primary
+	Standby bool `yaml:"standby"`
+
+	// Primary is the base URL of the primary HTTP API
+	Primary string `yaml:"primary"`
+
+	// ServiceID and ServiceSecret are a service account of the primary
+	// holding the replicate:* permission
+	ServiceID     string `yaml:"serviceId"`
+	ServiceSecret string `yaml:"serviceSecret"`
+
+	// Interval is the wait between the pulls of a caught up standby
+	Interval time.Duration `yaml:"interval"`
+
+	// BatchSize is the number of messages pulled at once
+	BatchSize int `yaml:"batchSize"`
+}
+
+// RemoteWriteConfig points the stats at a Prometheus remote-write endpoint,
+// for installs that can't be scraped
+type RemoteWriteConfig struct {
+	// URL is the remote-write endpoint, empty disables the push
+	URL string `yaml:"url"`
+
+	// Interval spaces the pushes
+	Interval time.Duration `yaml:"interval"`
+
+	// Timeout bounds a push
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Headers are sent with every push, e.g. Authorization or X-Scope-OrgID
+	Headers map[string]string `yaml:"headers"`
+
+	// Labels are added to every series, instance defaulting to the node ID
+	Labels map[string]string `yaml:"labels"`
+}
+
+// SMTPConfig is the server notifications are sent through, STARTTLS is
+// used when the server offers it
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// From is the sender address
+	From string `yaml:"from"`
+}
+
+// Chat webhook kinds select the message format
+const (
+	ChatWebhookSlack        = "slack"
+	ChatWebhookTeams        = "teams"
+	ChatWebhookAlertmanager = "alertmanager"
+)
+
+// ChatWebhook posts notifications to a Slack or Microsoft Teams incoming
+// webhook, or alerts to a receiver of Alertmanager webhooks
+type ChatWebhook struct {
+	// Name identifies the webhook in logs
+	Name string `yaml:"name"`
+
+	// Kind is slack, teams or alertmanager
+	Kind string `yaml:"kind"`
+
+	// URL is the incoming webhook URL
+	URL string `yaml:"url"`
+
+	// Types lists the notifications posted: alert, alert-resolved and/or
+	// account-request (not for alertmanager). Empty posts alert and
+	// account-request to chats, alert and alert-resolved to alertmanager.
+	Types []string `yaml:"types,omitempty"`
+
+	// Severities limits the alerts posted: warning and/or critical, all
+	// when empty
+	Severities []string `yaml:"severities,omitempty"`
+
+	// RatePerMinute bounds the posts, 20 by default
+	RatePerMinute int `yaml:"ratePerMinute,omitempty"`
+
+	// MaxRetries bounds the retries of a failed post, 3 by default
+	MaxRetries int `yaml:"maxRetries,omitempty"`
+}
+
+// NotificationTemplate configures a notification type
+type NotificationTemplate struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Recipients always receive the type, besides the user concerned
+	Recipients []string `yaml:"recipients"`
+
+	// Subject and Body are Go text/templates, built-in ones when empty
+	Subject string `yaml:"subject,omitempty"`
+	Body    string `yaml:"body,omitempty"`
+}
+
+// Ingestion providers select how a webhook signature is verified
+const (
+	IngestProviderNone   = ""
+	IngestProviderGitHub = "github"
+	IngestProviderStripe = "stripe"
+	IngestProviderHMAC   = "hmac-sha256"
+)
+
+// IngestRoute accepts the webhooks of a third party on /ingest/{name} and
+// publishes them to a queue
+type IngestRoute struct {
+	// Name is the path segment of the route
+	Name string `yaml:"name"`
+
+	// Domain and Queue receive the webhooks
+	Domain string `yaml:"domain"`
+	Queue  string `yaml:"queue"`
+
+	// Provider verifies the signature: github, stripe, hmac-sha256, or
+	// empty to accept unsigned webhooks
+	Provider string `yaml:"provider"`
+
+	// Secret is the signing secret shared with the provider
+	Secret string `yaml:"secret"`
+
+	// SignatureHeader carries the hex HMAC-SHA256 of the body for the
+	// hmac-sha256 provider, X-Signature by default
+	SignatureHeader string `yaml:"signatureHeader,omitempty"`
+
+	// Metadata is added to every message of the route
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+
+	// MaxBodyKB bounds the webhook body, 1024 by default
+	MaxBodyKB int `yaml:"maxBodyKB,omitempty"`
+}
+
+// MQTTTopic maps the MQTT topics matching a filter to a queue
+type MQTTTopic struct {
+	// Filter is an MQTT topic filter, + matching one level and a final #
+	// any number of levels
+	Filter string `yaml:"filter"`
+
+	// Domain and Queue receive the publishes and serve the subscriptions
+	// of the matching topics
+	Domain string `yaml:"domain"`
+	Queue  string `yaml:"queue"`
+}
+
+// JSON engines of the hot HTTP paths, only std is built in by default
+const (
+	JSONEngineStd      = "std"
+	JSONEngineJsoniter = "jsoniter"
+	JSONEngineSonic    = "sonic"
+)
+
+// Listener roles select which part of the HTTP API a listener serves
+const (
+	ListenerRoleAll        = "all"
+	ListenerRoleManagement = "management"
+	ListenerRoleData       = "data"
+)
+
+// HTTPListener is one HTTP bind with its own TLS settings and route set
+type HTTPListener struct {
+	// Name identifies the listener in logs and validation messages
+	Name string `yaml:"name"`
+
+	// Address to bind the listener
+	Address string `yaml:"address"`
+
+	// Port to bind the listener
+	Port int `yaml:"port"`
+
+	// Role is all, management (UI, admin, auth) or data (publish, consume, websocket)
+	Role string `yaml:"role"`
+
+	// TLS enables TLS on this listener
+	TLS bool `yaml:"tls"`
+
+	// CertFile is the TLS certificate path
+	CertFile string `yaml:"certFile"`
+
+	// KeyFile is the TLS private key path
+	KeyFile string `yaml:"keyFile"`
+
+	// RequestLog logs every request served by this listener
+	RequestLog bool `yaml:"requestLog"`
+}
+
+// ServesManagement reports whether the listener mounts the management API
+func (l HTTPListener) ServesManagement() bool {
+	return l.Role == "" || l.Role == ListenerRoleAll || l.Role == ListenerRoleManagement
+}
+
+// ServesData reports whether the listener mounts the data-plane API
+func (l HTTPListener) ServesData() bool {
+	return l.Role == "" || l.Role == ListenerRoleAll || l.Role == ListenerRoleData
+}
+
+// HTTPListeners returns the configured listeners, or the single legacy bind
+func (c *Config) HTTPListeners() []HTTPListener {
+	if len(c.HTTP.Listeners) > 0 {
+		return c.HTTP.Listeners
+	}
+	return []HTTPListener{{
+		Name:       "http",
+		Address:    c.HTTP.Address,
+		Port:       c.HTTP.Port,
+		Role:       ListenerRoleAll,
+		TLS:        c.HTTP.TLS,
+		CertFile:   c.HTTP.CertFile,
+		KeyFile:    c.HTTP.KeyFile,
+		RequestLog: true,
+	}}
 }
 
 // DefaultConfig returns a default configuration
@@ -247,6 +700,9 @@ func DefaultConfig() *Config {
 	c.Storage.RetentionDays = 7
 	c.Storage.Sync = true
 	c.Storage.MaxSizeMB = 1024
+	c.Storage.ClaimCheck.Enabled = false
+	c.Storage.ClaimCheck.ThresholdKB = 256
+	c.Storage.ClaimCheck.Path = "blobs"
 
 	// HTTP server configuration
 	c.HTTP.Enabled = true
@@ -259,6 +715,29 @@ func DefaultConfig() *Config {
 	c.HTTP.CORS.AllowedOrigins = []string{"*"}
 	c.HTTP.JWT.Secret = "changeme"
 	c.HTTP.JWT.ExpirationMinutes = 60
+	c.HTTP.Connections.HandshakeTimeout = 10 * time.Second
+	c.HTTP.JSONEngine = JSONEngineStd
+	c.HTTP.Connections.Subscriptions.Buffer = 256
+	c.HTTP.Connections.Subscriptions.Overflow = "drop-old"
+	c.HTTP.Connections.Subscriptions.ResumeWindow = model.DefaultResumeWindow
+	c.HTTP.Connections.Subscriptions.ResumeBuffer = model.DefaultResumeBuffer
+
+	// Subscriber handlers
+	c.Subscribers.Timeout = model.DefaultHandlerTimeout
+	c.Subscribers.QuarantineAfter = model.DefaultQuarantineAfter
+	c.Subscribers.QuarantineFor = model.DefaultQuarantineFor
+
+	// Stale consumer group cleanup
+	c.GroupCleanup = model.GroupCleanupPolicy{}.WithDefaults()
+
+	// System events kept per domain
+	c.Events = model.EventRetention{}.WithDefaults()
+
+	// Standby replication
+	c.Replication.Interval = model.DefaultReplicationInterval
+	c.Replication.BatchSize = model.DefaultReplicationBatchSize
+	c.RemoteWrite.Interval = model.DefaultRemoteWriteInterval
+	c.RemoteWrite.Timeout = model.DefaultRemoteWriteTimeout
 
 	// AMQP server configuration
 	c.AMQP.Enabled = false
@@ -298,6 +777,13 @@ func DefaultConfig() *Config {
 	c.Cluster.HeartbeatInterval = 100 * time.Millisecond
 	c.Cluster.ElectionTimeout = 1000 * time.Millisecond
 
+	// Notifications configuration
+	c.Notifications.SMTP.Port = 587
+
+	// GitOps configuration
+	c.GitOps.Directory = "./manifests"
+	c.GitOps.Interval = 30 * time.Second
+
 	// Logging configuration defaults
 	c.Logging.Level = "INFO"
 	c.Logging.ChannelSize = 1000
@@ -329,6 +815,11 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Environment variables win over the file, see ApplyEnv
+	if _, err := ApplyEnv(config, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("invalid environment override: %w", err)
+	}
+
 	// Complete relative paths
 	if !filepath.IsAbs(config.General.DataDir) {
 		dir, err := filepath.Abs(filepath.Dir(path))
@@ -342,6 +833,10 @@ func LoadConfig(path string) (*Config, error) {
 		config.Storage.Path = filepath.Join(config.General.DataDir, config.Storage.Path)
 	}
 
+	if config.Storage.ClaimCheck.Path != "" && !filepath.IsAbs(config.Storage.ClaimCheck.Path) {
+		config.Storage.ClaimCheck.Path = filepath.Join(config.General.DataDir, config.Storage.ClaimCheck.Path)
+	}
+
 	// Validate the configuration
 	if err := ValidateConfig(config); err != nil {
 		return nil, err
@@ -359,6 +854,21 @@ func SaveConfig(config *Config, path string) error {
 		return fmt.Errorf("failed to encode config: %w", err)
 	}
 
+	// Settings read encrypted are written encrypted, on a copy as the
+	// configuration keeps running with the plaintext
+	if len(config.encrypted) > 0 {
+		saved := &Config{encrypted: config.encrypted}
+		if err := yaml.Unmarshal(data, saved); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		if err := saved.reencrypt(); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		if data, err = yaml.Marshal(saved); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+	}
+
 	// Create parent directory if necessary
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -373,55 +883,44 @@ func SaveConfig(config *Config, path string) error {
 	return nil
 }
 
-// ValidateConfig validates the configuration
-func ValidateConfig(config *Config) error {
-	// Check the log level
-	logLevel := strings.ToLower(config.General.LogLevel)
-	if logLevel != "debug" && logLevel != "info" && logLevel != "warn" && logLevel != "error" {
-		return fmt.Errorf("invalid log level: %s", config.General.LogLevel)
-	}
-
-	// Check the storage engine
-	engine := strings.ToLower(config.Storage.Engine)
-	if engine != "memory" && engine != "file" && engine != "sqlite" && engine != "badger" {
-		return fmt.Errorf("invalid storage engine: %s", config.Storage.Engine)
-	}
-
-	// check ports
-	if config.HTTP.Enabled && (config.HTTP.Port < 1 || config.HTTP.Port > 65535) {
-		return fmt.Errorf("invalid HTTP port: %d", config.HTTP.Port)
-	}
-
-	if config.AMQP.Enabled && (config.AMQP.Port < 1 || config.AMQP.Port > 65535) {
-		return fmt.Errorf("invalid AMQP port: %d", config.AMQP.Port)
+// redactedValue replaces the secrets of a printed configuration
+const redactedValue = "<redacted>"
+
+// Redacted is a copy of the configuration with its secrets masked: the JWT
+// secret, the admin and SMTP passwords, the field encryption key, the
+// replication secret, the Sentry DSN, the chat webhook URLs, the ingest
+// secrets and the remote-write headers
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	mask := func(value *string) {
+		if *value != "" {
+			*value = redactedValue
+		}
 	}
 
-	if config.MQTT.Enabled && (config.MQTT.Port < 1 || config.MQTT.Port > 65535) {
-		return fmt.Errorf("invalid MQTT port: %d", config.MQTT.Port)
+	mask(&redacted.HTTP.JWT.Secret)
+	mask(&redacted.Security.AdminPassword)
+	mask(&redacted.Security.FieldEncryptionKey)
+	mask(&redacted.Security.MachineID)
+	mask(&redacted.Notifications.SMTP.Password)
+	mask(&redacted.Replication.ServiceSecret)
+	mask(&redacted.ErrorReporting.SentryDSN)
+
+	redacted.Notifications.Webhooks = append([]ChatWebhook(nil), c.Notifications.Webhooks...)
+	for i := range redacted.Notifications.Webhooks {
+		mask(&redacted.Notifications.Webhooks[i].URL)
 	}
-
-	if config.GRPC.Enabled && (config.GRPC.Port < 1 || config.GRPC.Port > 65535) {
-		return fmt.Errorf("invalid gRPC port: %d", config.GRPC.Port)
+	redacted.Ingest = append([]IngestRoute(nil), c.Ingest...)
+	for i := range redacted.Ingest {
+		mask(&redacted.Ingest[i].Secret)
 	}
-
-	// Check the TLS configurations
-	if config.HTTP.TLS {
-		// Only validate if custom certificates are specified
-		if config.HTTP.CertFile != "" && config.HTTP.KeyFile != "" {
-			if _, err := os.Stat(config.HTTP.CertFile); os.IsNotExist(err) {
-				return fmt.Errorf("certificate file not found: %s", config.HTTP.CertFile)
-			}
-			if _, err := os.Stat(config.HTTP.KeyFile); os.IsNotExist(err) {
-				return fmt.Errorf("key file not found: %s", config.HTTP.KeyFile)
-			}
-		}
-		// If both empty, auto-generation will handle it in EnsureTLSCertificates()
-		if (config.HTTP.CertFile == "") != (config.HTTP.KeyFile == "") {
-			return fmt.Errorf("both certFile and keyFile must be specified together, or both empty for auto-generation")
+	if c.RemoteWrite.Headers != nil {
+		redacted.RemoteWrite.Headers = make(map[string]string, len(c.RemoteWrite.Headers))
+		for name := range c.RemoteWrite.Headers {
+			redacted.RemoteWrite.Headers[name] = redactedValue
 		}
 	}
-
-	return nil
+	return &redacted
 }
 
 func (c *Config) ToPublic() *PublicConfig {
@@ -436,6 +935,9 @@ func (c *Config) ToPublic() *PublicConfig {
 	pub.HTTP.TLS = c.HTTP.TLS
 	pub.HTTP.CertFile = c.HTTP.CertFile
 	pub.HTTP.KeyFile = c.HTTP.KeyFile
+	pub.HTTP.Listeners = c.HTTP.Listeners
+	pub.HTTP.APIVersioning = c.HTTP.APIVersioning
+	pub.HTTP.Connections = c.HTTP.Connections
 	pub.HTTP.CORS = c.HTTP.CORS
 	pub.HTTP.JWT.ExpirationMinutes = c.HTTP.JWT.ExpirationMinutes
 
@@ -472,6 +974,9 @@ func (c *Config) MergeFromPublic(pub *PublicConfig) {
 	c.HTTP.TLS = pub.HTTP.TLS
 	c.HTTP.CertFile = pub.HTTP.CertFile
 	c.HTTP.KeyFile = pub.HTTP.KeyFile
+	c.HTTP.Listeners = pub.HTTP.Listeners
+	c.HTTP.APIVersioning = pub.HTTP.APIVersioning
+	c.HTTP.Connections = pub.HTTP.Connections
 	c.HTTP.CORS = pub.HTTP.CORS
 	c.HTTP.JWT.ExpirationMinutes = pub.HTTP.JWT.ExpirationMinutes
 
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// Desired converts a domain configuration into the state the broker should
+// hold, with the queue defaults applied
+func (d DomainConfig) Desired() model.DesiredDomain {
+	desired := model.DesiredDomain{
+		Name: d.Name,
+		Schema: &model.Schema{
+			Fields: make(map[string]model.FieldType),
+		},
+		AllowedRouteSources: d.AllowedRouteSources,
+		Labels:              d.Labels,
+	}
+
+	// If a schema is defined, convert the fields
+	if schema, ok := d.Schema["fields"].(map[string]any); ok {
+		for field, typeVal := range schema {
+			if typeStr, ok := typeVal.(string); ok {
+				desired.Schema.Fields[field] = model.FieldType(typeStr)
+			}
+		}
+	}
+
+	for _, queueCfg := range d.Queues {
+		desired.Queues = append(desired.Queues, model.DesiredQueue{
+			Name:   queueCfg.Name,
+			Config: queueConfigWithDefaults(queueCfg.Config),
+		})
+	}
+
+	for _, routeCfg := range d.Routes {
+		// Create a rule with a simple JSON predicate
+		predicateType, _ := routeCfg.Predicate["type"].(string)
+		predicateField, _ := routeCfg.Predicate["field"].(string)
+
+		desired.Routes = append(desired.Routes, &model.RoutingRule{
+			SourceQueue:       routeCfg.SourceQueue,
+			DestinationQueue:  routeCfg.DestinationQueue,
+			DestinationDomain: routeCfg.DestinationDomain,
+			Predicate: model.JSONPredicate{
+				Type:  predicateType,
+				Field: predicateField,
+				Value: routeCfg.Predicate["value"],
+			},
+			Headers:     routeCfg.Headers,
+			Canary:      routeCfg.Canary,
+			Enabled:     routeCfg.Enabled,
+			Priority:    routeCfg.Priority,
+			StopOnMatch: routeCfg.StopOnMatch,
+		})
+	}
+
+	return desired
+}
+
+// queueConfigWithDefaults fills the retry and circuit breaker settings left empty
+func queueConfigWithDefaults(queueConfig model.QueueConfig) model.QueueConfig {
+	// Default values for retry configuration
+	if queueConfig.RetryEnabled && queueConfig.RetryConfig != nil {
+		retryConfig := *queueConfig.RetryConfig
+		if retryConfig.InitialDelay == 0 {
+			retryConfig.InitialDelay = 1 * time.Second
+		}
+		if retryConfig.MaxDelay == 0 {
+			retryConfig.MaxDelay = 30 * time.Second
+		}
+		if retryConfig.Factor <= 0 {
+			retryConfig.Factor = 2.0
+		}
+		queueConfig.RetryConfig = &retryConfig
+	}
+
+	// Default values for circuit breaker
+	if queueConfig.CircuitBreakerEnabled && queueConfig.CircuitBreakerConfig != nil {
+		cbConfig := *queueConfig.CircuitBreakerConfig
+		if cbConfig.ErrorThreshold <= 0 {
+			cbConfig.ErrorThreshold = 0.5
+		}
+		if cbConfig.MinimumRequests <= 0 {
+			cbConfig.MinimumRequests = 10
+		}
+		if cbConfig.OpenTimeout == 0 {
+			cbConfig.OpenTimeout = 30 * time.Second
+		}
+		if cbConfig.SuccessThreshold <= 0 {
+			cbConfig.SuccessThreshold = 5
+		}
+		queueConfig.CircuitBreakerConfig = &cbConfig
+	}
+
+	return queueConfig
+}
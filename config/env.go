@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the environment variables overriding the configuration
+const EnvPrefix = "GORTMS_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ApplyEnv overrides the scalar settings of the configuration with the
+// environment variables named after their YAML path, upper-cased: http.port
+// is GORTMS_HTTP_PORT and security.hmac.requireTLS is
+// GORTMS_SECURITY_HMAC_REQUIRETLS. Lists and maps are left to the file.
+// It returns the variables applied.
+func ApplyEnv(config *Config, lookup func(string) (string, bool)) ([]string, error) {
+	var applied []string
+	err := applyEnv(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup, &applied)
+	return applied, err
+}
+
+func applyEnv(v reflect.Value, name string, lookup func(string) (string, bool), applied *[]string) error {
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = field.Name
+			}
+			if err := applyEnv(v.Field(i), name+"_"+strings.ToUpper(key), lookup, applied); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value, ok := lookup(name)
+	if !ok {
+		return nil
+	}
+	if err := setScalar(v, value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*applied = append(*applied, name)
+	return nil
+}
+
+// setScalar parses value into a setting, named types included
+func setScalar(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+		return nil
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+		return nil
+	case v.CanInt():
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(n)
+		return nil
+	case v.CanUint():
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetUint(n)
+		return nil
+	case v.CanFloat():
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	return fmt.Errorf("%s settings can't be set from the environment", v.Kind())
+}
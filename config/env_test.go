@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	applied, err := ApplyEnv(cfg, envLookup(map[string]string{
+		"GORTMS_HTTP_PORT":                "9100",
+		"GORTMS_GENERAL_LOGLEVEL":         "debug",
+		"GORTMS_SECURITY_HMAC_REQUIRETLS": "true",
+		"GORTMS_REMOTEWRITE_INTERVAL":     "1m",
+		"GORTMS_UNKNOWN":                  "ignored",
+	}))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"GORTMS_HTTP_PORT",
+		"GORTMS_GENERAL_LOGLEVEL",
+		"GORTMS_SECURITY_HMAC_REQUIRETLS",
+		"GORTMS_REMOTEWRITE_INTERVAL",
+	}, applied)
+	assert.Equal(t, 9100, cfg.HTTP.Port)
+	assert.Equal(t, "debug", cfg.General.LogLevel)
+	assert.True(t, cfg.Security.HMAC.RequireTLS)
+	assert.Equal(t, time.Minute, cfg.RemoteWrite.Interval)
+}
+
+func TestApplyEnvInvalidValues(t *testing.T) {
+	for name, value := range map[string]string{
+		"GORTMS_HTTP_PORT":            "eighty",
+		"GORTMS_HTTP_ENABLED":         "maybe",
+		"GORTMS_REMOTEWRITE_INTERVAL": "often",
+		"GORTMS_DOMAINS":              "orders",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ApplyEnv(DefaultConfig(), envLookup(map[string]string{name: value}))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), name)
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.JWT.Secret = "jwt-secret"
+	cfg.Security.AdminPassword = ""
+	cfg.Notifications.Webhooks = []ChatWebhook{{URL: "https://hooks.example.com/T000/B000"}}
+	cfg.RemoteWrite.Headers = map[string]string{"Authorization": "Bearer abc"}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, redactedValue, redacted.HTTP.JWT.Secret)
+	assert.Empty(t, redacted.Security.AdminPassword, "unset secrets stay unset")
+	assert.Equal(t, redactedValue, redacted.Notifications.Webhooks[0].URL)
+	assert.Equal(t, redactedValue, redacted.RemoteWrite.Headers["Authorization"])
+
+	// the configuration itself is left alone
+	assert.Equal(t, "jwt-secret", cfg.HTTP.JWT.Secret)
+	assert.Equal(t, "https://hooks.example.com/T000/B000", cfg.Notifications.Webhooks[0].URL)
+	assert.Equal(t, "Bearer abc", cfg.RemoteWrite.Headers["Authorization"])
+}
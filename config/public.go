@@ -17,6 +17,12 @@ type PublicConfig struct {
 		RetentionDays int    `yaml:"retentionDays"`
 		Sync          bool   `yaml:"sync"`
 		MaxSizeMB     int    `yaml:"maxSizeMB"`
+
+		ClaimCheck struct {
+			Enabled     bool   `yaml:"enabled"`
+			ThresholdKB int    `yaml:"thresholdKB"`
+			Path        string `yaml:"path"`
+		} `yaml:"claimCheck"`
 	} `yaml:"storage"`
 
 	HTTP struct {
@@ -27,6 +33,10 @@ type PublicConfig struct {
 		CertFile string `yaml:"certFile"`
 		KeyFile  string `yaml:"keyFile"`
 
+		Listeners     []HTTPListener   `yaml:"listeners"`
+		APIVersioning APIVersioning    `yaml:"apiVersioning"`
+		Connections   ConnectionLimits `yaml:"connections"`
+
 		CORS struct {
 			Enabled        bool     `yaml:"enabled"`
 			AllowedOrigins []string `yaml:"allowedOrigins"`
@@ -45,9 +55,10 @@ type PublicConfig struct {
 	} `yaml:"amqp"`
 
 	MQTT struct {
-		Enabled bool   `yaml:"enabled"`
-		Address string `yaml:"address"`
-		Port    int    `yaml:"port"`
+		Enabled bool        `yaml:"enabled"`
+		Address string      `yaml:"address"`
+		Port    int         `yaml:"port"`
+		Topics  []MQTTTopic `yaml:"topics,omitempty"`
 	} `yaml:"mqtt"`
 
 	GRPC struct {
@@ -66,6 +77,8 @@ type PublicConfig struct {
 			Enabled         bool   `yaml:"enabled"`
 			TimestampWindow string `yaml:"timestampWindow"`
 			RequireTLS      bool   `yaml:"requireTLS"`
+			V1Deprecation   string `yaml:"v1Deprecation,omitempty"`
+			V1Sunset        string `yaml:"v1Sunset"`
 		} `yaml:"hmac"`
 	} `yaml:"security" json:"security"`
 
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Encrypted values are written ENC[<key>:<ciphertext>], see `gortms encrypt-value`
+const (
+	encryptedPrefix = "ENC["
+	encryptedSuffix = "]"
+)
+
+// IsEncrypted tells whether a setting holds an encrypted value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// EncryptedValue wraps a ciphertext as written in the configuration
+func EncryptedValue(ciphertext string) string {
+	return encryptedPrefix + ciphertext + encryptedSuffix
+}
+
+// encryptedSetting remembers the encrypted form of a decrypted setting
+type encryptedSetting struct {
+	encrypted string
+	plaintext string
+}
+
+// DecryptValues replaces the ENC[...] settings with their plaintext,
+// decrypt getting what is inside the brackets. Only the settings at the
+// given YAML paths are decrypted when paths are given. SaveConfig writes
+// the settings back encrypted unless they were changed.
+func (c *Config) DecryptValues(decrypt func(ciphertext string) (string, error), paths ...string) error {
+	if c.encrypted == nil {
+		c.encrypted = make(map[string]encryptedSetting)
+	}
+	return walkStrings(reflect.ValueOf(c).Elem(), "", func(path, value string, set func(string)) error {
+		if !IsEncrypted(value) || (len(paths) > 0 && !contains(paths, path)) {
+			return nil
+		}
+		plaintext, err := decrypt(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		set(plaintext)
+		c.encrypted[path] = encryptedSetting{encrypted: value, plaintext: plaintext}
+		return nil
+	})
+}
+
+// reencrypt puts the encrypted form back in the settings left unchanged
+// since DecryptValues
+func (c *Config) reencrypt() error {
+	return walkStrings(reflect.ValueOf(c).Elem(), "", func(path, value string, set func(string)) error {
+		if setting, ok := c.encrypted[path]; ok && setting.plaintext == value {
+			set(setting.encrypted)
+		}
+		return nil
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// walkStrings calls fn with the YAML path of every string setting, inside
+// lists and maps too: http.jwt.secret, notifications.webhooks[0].url,
+// remoteWrite.headers[Authorization]
+func walkStrings(v reflect.Value, path string, fn func(path, value string, set func(string)) error) error {
+	switch v.Kind() {
+	case reflect.String:
+		return fn(path, v.String(), func(s string) { v.SetString(s) })
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walkStrings(v.Elem(), path, fn)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = field.Name
+			}
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := walkStrings(v.Field(i), key, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), path+"["+strconv.Itoa(i)+"]", fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			key := key
+			err := fn(path+"["+key.String()+"]", v.MapIndex(key).String(), func(s string) {
+				v.SetMapIndex(key, reflect.ValueOf(s).Convert(v.Type().Elem()))
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reverse stands for a cipher in the tests
+func reverse(ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, "bad") {
+		return "", errors.New("wrong key")
+	}
+	runes := []rune(ciphertext)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func TestDecryptValues(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.JWT.Secret = "ENC[terces]"
+	cfg.Notifications.SMTP.Password = "plain"
+	cfg.Notifications.Webhooks = []ChatWebhook{{Name: "ops", URL: "ENC[loh]"}}
+	cfg.RemoteWrite.Headers = map[string]string{"Authorization": "ENC[nekot]"}
+	cfg.Security.MachineID = "ENC[di]"
+
+	require.NoError(t, cfg.DecryptValues(reverse, "security.machineId"))
+	assert.Equal(t, "id", cfg.Security.MachineID)
+	assert.Equal(t, "ENC[terces]", cfg.HTTP.JWT.Secret, "only the given paths are decrypted")
+
+	require.NoError(t, cfg.DecryptValues(reverse))
+	assert.Equal(t, "secret", cfg.HTTP.JWT.Secret)
+	assert.Equal(t, "plain", cfg.Notifications.SMTP.Password)
+	assert.Equal(t, "hol", cfg.Notifications.Webhooks[0].URL)
+	assert.Equal(t, "token", cfg.RemoteWrite.Headers["Authorization"])
+}
+
+func TestDecryptValuesReportsThePath(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.SMTP.Password = "ENC[bad]"
+
+	err := cfg.DecryptValues(reverse)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notifications.smtp.password")
+}
+
+func TestSaveConfigKeepsValuesEncrypted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.JWT.Secret = "ENC[terces]"
+	cfg.Notifications.SMTP.Password = "ENC[drowssap]"
+	require.NoError(t, cfg.DecryptValues(reverse))
+
+	// a settings update replacing the SMTP password
+	updated := *cfg
+	updated.Notifications.SMTP.Password = "new-password"
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, SaveConfig(&updated, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ENC[terces]")
+	assert.NotContains(t, string(data), "secret: secret")
+	assert.Contains(t, string(data), "new-password")
+
+	// the running configuration keeps the plaintext
+	assert.Equal(t, "secret", updated.HTTP.JWT.Secret)
+}
@@ -15,12 +15,29 @@ import (
 
 // EnsureTLSCertificates ensures TLS certificates exist, generating them if necessary
 func EnsureTLSCertificates(config *Config, cryptoService outbound.CryptoService, logger outbound.Logger) error {
-	if !config.HTTP.TLS {
-		return nil // TLS not enabled
+	if config.HTTP.TLS {
+		if err := ensureCertificatePair(config, &config.HTTP.CertFile, &config.HTTP.KeyFile, config.HTTP.Address, cryptoService, logger); err != nil {
+			return err
+		}
 	}
 
-	certPath := config.HTTP.CertFile
-	keyPath := config.HTTP.KeyFile
+	// listeners without their own pair share the default generated one
+	for i := range config.HTTP.Listeners {
+		l := &config.HTTP.Listeners[i]
+		if !l.TLS {
+			continue
+		}
+		if err := ensureCertificatePair(config, &l.CertFile, &l.KeyFile, l.Address, cryptoService, logger); err != nil {
+			return fmt.Errorf("listener %s: %w", l.Name, err)
+		}
+	}
+	return nil
+}
+
+// ensureCertificatePair fills in default paths and generates the pair when missing or expiring
+func ensureCertificatePair(config *Config, certFile, keyFile *string, address string, cryptoService outbound.CryptoService, logger outbound.Logger) error {
+	certPath := *certFile
+	keyPath := *keyFile
 
 	// If certificate paths are not specified, use default paths
 	if certPath == "" || keyPath == "" {
@@ -33,8 +50,8 @@ func EnsureTLSCertificates(config *Config, cryptoService outbound.CryptoService,
 		keyPath = filepath.Join(tlsDir, "server.key")
 
 		// Update config with generated paths
-		config.HTTP.CertFile = certPath
-		config.HTTP.KeyFile = keyPath
+		*certFile = certPath
+		*keyFile = keyPath
 	}
 
 	// Check if certificates already exist
@@ -52,7 +69,7 @@ func EnsureTLSCertificates(config *Config, cryptoService outbound.CryptoService,
 	// Generate new certificates
 	logger.Info("Generating self-signed TLS certificates...")
 
-	hostname := config.HTTP.Address
+	hostname := address
 	if hostname == "0.0.0.0" || hostname == "" {
 		hostname = "localhost"
 	}
@@ -0,0 +1,603 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// metricLabelPattern is the label name syntax of Prometheus
+var metricLabelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid configuration: " + e.Problems[0]
+	}
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s",
+		len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// listener is a network bind checked for port range and overlaps
+type listener struct {
+	name    string
+	enabled bool
+	address string
+	port    int
+}
+
+func (c *Config) listeners() []listener {
+	var binds []listener
+	if len(c.HTTP.Listeners) == 0 {
+		binds = append(binds, listener{"HTTP", c.HTTP.Enabled, c.HTTP.Address, c.HTTP.Port})
+	}
+	for _, l := range c.HTTP.Listeners {
+		binds = append(binds, listener{"HTTP listener " + l.Name, c.HTTP.Enabled, l.Address, l.Port})
+	}
+	return append(binds,
+		listener{"AMQP", c.AMQP.Enabled, c.AMQP.Address, c.AMQP.Port},
+		listener{"MQTT", c.MQTT.Enabled, c.MQTT.Address, c.MQTT.Port},
+		listener{"gRPC", c.GRPC.Enabled, c.GRPC.Address, c.GRPC.Port},
+		listener{"monitoring", c.Monitoring.Enabled, c.Monitoring.Address, c.Monitoring.Port},
+	)
+}
+
+func isWildcardAddress(address string) bool {
+	return address == "" || address == "0.0.0.0" || address == "::" || address == "[::]"
+}
+
+// two binds collide on the same port unless both use distinct specific addresses
+func addressesOverlap(a, b string) bool {
+	return a == b || isWildcardAddress(a) || isWildcardAddress(b)
+}
+
+// ValidateConfig checks the whole configuration and reports all problems at once
+func ValidateConfig(config *Config) error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Check the log level
+	logLevel := strings.ToLower(config.General.LogLevel)
+	if logLevel != "debug" && logLevel != "info" && logLevel != "warn" && logLevel != "error" {
+		addf("invalid log level: %s", config.General.LogLevel)
+	}
+
+	// Check the storage engine
+	engine := strings.ToLower(config.Storage.Engine)
+	if engine != "memory" && engine != "file" && engine != "sqlite" && engine != "badger" {
+		addf("invalid storage engine: %s", config.Storage.Engine)
+	}
+	if config.Storage.RetentionDays < 0 {
+		addf("invalid storage retention: %d days", config.Storage.RetentionDays)
+	}
+	if config.Storage.MaxSizeMB < 0 {
+		addf("invalid storage max size: %d MB", config.Storage.MaxSizeMB)
+	}
+
+	// Check claim-check storage
+	if config.Storage.ClaimCheck.Enabled && config.Storage.ClaimCheck.ThresholdKB <= 0 {
+		addf("invalid claim-check threshold: %d KB", config.Storage.ClaimCheck.ThresholdKB)
+	}
+
+	// check ports and overlapping binds
+	listeners := config.listeners()
+	for i, l := range listeners {
+		if !l.enabled {
+			continue
+		}
+		if l.port < 1 || l.port > 65535 {
+			addf("invalid %s port: %d", l.name, l.port)
+			continue
+		}
+		for _, other := range listeners[:i] {
+			if other.enabled && other.port == l.port && addressesOverlap(other.address, l.address) {
+				addf("%s and %s both bind port %d (%s, %s)", other.name, l.name, l.port, other.address, l.address)
+			}
+		}
+	}
+
+	// Check the JWT settings, tokens are useless without a signing key
+	if config.Security.EnableAuthentication && strings.TrimSpace(config.HTTP.JWT.Secret) == "" {
+		addf("http.jwt.secret is required when authentication is enabled")
+	}
+	if config.HTTP.JWT.ExpirationMinutes <= 0 {
+		addf("invalid JWT expiration: %d minutes", config.HTTP.JWT.ExpirationMinutes)
+	}
+
+	switch config.HTTP.JSONEngine {
+	case "", JSONEngineStd, JSONEngineJsoniter, JSONEngineSonic:
+	default:
+		addf("http.jsonEngine must be %s, %s or %s: %s", JSONEngineStd, JSONEngineJsoniter, JSONEngineSonic, config.HTTP.JSONEngine)
+	}
+
+	if config.Security.HMAC.Enabled && config.Security.HMAC.TimestampWindow != "" {
+		if d, err := time.ParseDuration(config.Security.HMAC.TimestampWindow); err != nil || d <= 0 {
+			addf("invalid HMAC timestamp window: %s", config.Security.HMAC.TimestampWindow)
+		}
+	}
+	var hmacDeprecation, hmacSunset time.Time
+	if config.Security.HMAC.V1Deprecation != "" {
+		parsed, err := time.Parse(time.RFC3339, config.Security.HMAC.V1Deprecation)
+		if err != nil {
+			addf("invalid HMAC v1 deprecation, expected an RFC 3339 time: %s", config.Security.HMAC.V1Deprecation)
+		}
+		hmacDeprecation = parsed
+	}
+	if config.Security.HMAC.V1Sunset != "" {
+		parsed, err := time.Parse(time.RFC3339, config.Security.HMAC.V1Sunset)
+		if err != nil {
+			addf("invalid HMAC v1 sunset, expected an RFC 3339 time: %s", config.Security.HMAC.V1Sunset)
+		}
+		hmacSunset = parsed
+	}
+	if !hmacDeprecation.IsZero() && !hmacSunset.IsZero() && hmacSunset.Before(hmacDeprecation) {
+		addf("HMAC v1 sunset %s is before its deprecation %s", config.Security.HMAC.V1Sunset, config.Security.HMAC.V1Deprecation)
+	}
+
+	if config.Logging.ChannelSize < 0 {
+		addf("invalid logging channel size: %d", config.Logging.ChannelSize)
+	}
+
+	if err := config.Redaction.Validate(); err != nil {
+		addf("%v", err)
+	}
+
+	// Check the HTTP listener list
+	names := make(map[string]bool)
+	for i, l := range config.HTTP.Listeners {
+		if l.Name == "" {
+			addf("http.listeners[%d]: name is required", i)
+		} else if names[l.Name] {
+			addf("HTTP listener %s is defined more than once", l.Name)
+		}
+		names[l.Name] = true
+		if l.Role != "" && l.Role != ListenerRoleAll && l.Role != ListenerRoleManagement && l.Role != ListenerRoleData {
+			addf("HTTP listener %s: invalid role: %s", l.Name, l.Role)
+		}
+	}
+
+	// Check the TLS configurations
+	for _, l := range config.HTTPListeners() {
+		if !l.TLS {
+			continue
+		}
+		// Only validate if custom certificates are specified
+		if l.CertFile != "" && l.KeyFile != "" {
+			if _, err := os.Stat(l.CertFile); os.IsNotExist(err) {
+				addf("certificate file not found: %s", l.CertFile)
+			}
+			if _, err := os.Stat(l.KeyFile); os.IsNotExist(err) {
+				addf("key file not found: %s", l.KeyFile)
+			}
+		}
+		// If both empty, auto-generation will handle it in EnsureTLSCertificates()
+		if (l.CertFile == "") != (l.KeyFile == "") {
+			addf("both certFile and keyFile must be specified together, or both empty for auto-generation")
+		}
+	}
+
+	// Check the connection limits
+	limits := config.HTTP.Connections
+	if limits.MaxPerIP < 0 || limits.MaxPerService < 0 || limits.MaxPerUser < 0 || limits.MaxInFlightPerService < 0 {
+		addf("connection limits must be positive, or 0 to disable them")
+	}
+	if limits.IdleTimeout != 0 && limits.IdleTimeout < time.Second {
+		addf("connection idle timeout must be at least 1s: %s", limits.IdleTimeout)
+	}
+	if limits.HandshakeTimeout < 0 {
+		addf("invalid connection handshake timeout: %s", limits.HandshakeTimeout)
+	}
+	subscriptions := limits.Subscriptions
+	if subscriptions.MaxRate < 0 || subscriptions.MaxUnacked < 0 || subscriptions.Buffer < 0 {
+		addf("subscription limits must be positive, or 0 to use the defaults")
+	}
+	if subscriptions.ResumeWindow < 0 || subscriptions.ResumeBuffer < 0 {
+		addf("subscription resume window and buffer can't be negative")
+	}
+	switch subscriptions.Overflow {
+	case "", "drop-old", "drop-new", "disconnect":
+	default:
+		addf("unknown subscription overflow policy: %s", subscriptions.Overflow)
+	}
+	subscribers := config.Subscribers
+	if subscribers.Timeout < 0 || subscribers.QuarantineAfter < 0 || subscribers.QuarantineFor < 0 {
+		addf("subscriber timeout and quarantine must be positive, or 0 to use the defaults")
+	}
+	groupCleanup := config.GroupCleanup
+	if groupCleanup.StaleAfter < 0 || groupCleanup.Interval < 0 {
+		addf("group cleanup durations must be positive, or 0 to use the defaults")
+	}
+	if _, err := model.ParseGroupCleanupAction(string(groupCleanup.Action)); err != nil {
+		addf("group cleanup: unknown action: %s", groupCleanup.Action)
+	}
+	if config.Events.PerDomain < 0 || config.Events.MaxAge < 0 {
+		addf("event retention must be positive, or 0 to use the defaults")
+	}
+
+	// Check the queue blueprints
+	blueprintNames := make(map[string]bool)
+	for _, blueprint := range config.Blueprints {
+		if err := blueprint.Validate(); err != nil {
+			addf("%v", err)
+			continue
+		}
+		if blueprintNames[blueprint.Name] {
+			addf("blueprint %s is defined more than once", blueprint.Name)
+		}
+		blueprintNames[blueprint.Name] = true
+	}
+
+	// Check the webhook ingestion routes
+	ingestNames := make(map[string]bool)
+	for i, route := range config.Ingest {
+		switch {
+		case route.Name == "" || strings.ContainsAny(route.Name, "/?#"):
+			addf("ingest[%d]: invalid name: %q", i, route.Name)
+		case ingestNames[route.Name]:
+			addf("ingest route %s is defined more than once", route.Name)
+		}
+		ingestNames[route.Name] = true
+		if route.Domain == "" || route.Queue == "" {
+			addf("ingest route %s: domain and queue are required", route.Name)
+		}
+		switch route.Provider {
+		case IngestProviderNone:
+		case IngestProviderGitHub, IngestProviderStripe, IngestProviderHMAC:
+			if route.Secret == "" {
+				addf("ingest route %s: the %s provider needs a secret", route.Name, route.Provider)
+			}
+		default:
+			addf("ingest route %s: unknown provider: %s", route.Name, route.Provider)
+		}
+		if route.MaxBodyKB < 0 {
+			addf("ingest route %s: invalid max body size: %dKB", route.Name, route.MaxBodyKB)
+		}
+	}
+
+	// Check the MQTT topic mappings, a # only ending a filter
+	for i, topic := range config.MQTT.Topics {
+		levels := strings.Split(topic.Filter, "/")
+		valid := topic.Filter != "" && !strings.ContainsRune(topic.Filter, 0)
+		for j, level := range levels {
+			if (level != "+" && level != "#" && strings.ContainsAny(level, "+#")) || (level == "#" && j < len(levels)-1) {
+				valid = false
+			}
+		}
+		if !valid {
+			addf("mqtt.topics[%d]: invalid filter: %q", i, topic.Filter)
+		}
+		if topic.Domain == "" || topic.Queue == "" {
+			addf("mqtt.topics[%d]: domain and queue are required", i)
+		}
+	}
+
+	// Check the error tracker
+	if dsn := config.ErrorReporting.SentryDSN; dsn != "" {
+		if u, err := url.Parse(dsn); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User == nil || strings.Trim(u.Path, "/") == "" {
+			addf("errorReporting: invalid sentry dsn, expected https://<key>@<host>/<project>")
+		}
+	}
+	if rate := config.ErrorReporting.SampleRate; rate < 0 || rate > 1 {
+		addf("errorReporting: sample rate must be between 0 and 1: %g", rate)
+	}
+
+	// Check the email and chat notifications
+	notifications := config.Notifications
+	if notifications.Enabled && notifications.SMTP.Host == "" && len(notifications.Webhooks) == 0 {
+		addf("notifications: an smtp host or a webhook is required")
+	}
+	if notifications.SMTP.Host != "" {
+		if notifications.SMTP.From == "" {
+			addf("notifications: smtp from is required")
+		}
+		if notifications.SMTP.Port <= 0 || notifications.SMTP.Port > 65535 {
+			addf("notifications: invalid smtp port: %d", notifications.SMTP.Port)
+		}
+	}
+	for i, webhook := range notifications.Webhooks {
+		name := webhook.Name
+		if name == "" {
+			name = fmt.Sprintf("webhooks[%d]", i)
+		}
+		if webhook.Kind != ChatWebhookSlack && webhook.Kind != ChatWebhookTeams && webhook.Kind != ChatWebhookAlertmanager {
+			addf("notifications: webhook %s: unknown kind: %s", name, webhook.Kind)
+		}
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			addf("notifications: webhook %s: invalid url", name)
+		}
+		for _, kind := range webhook.Types {
+			switch model.NotificationType(kind) {
+			case model.NotificationAlert, model.NotificationAlertResolved:
+			case model.NotificationAccountRequest:
+				if webhook.Kind == ChatWebhookAlertmanager {
+					addf("notifications: webhook %s: unsupported type: %s", name, kind)
+				}
+			default:
+				addf("notifications: webhook %s: unsupported type: %s", name, kind)
+			}
+		}
+		for _, severity := range webhook.Severities {
+			if severity != "warning" && severity != "critical" {
+				addf("notifications: webhook %s: unknown severity: %s", name, severity)
+			}
+		}
+		if webhook.RatePerMinute < 0 || webhook.MaxRetries < 0 {
+			addf("notifications: webhook %s: rate and retries must be positive", name)
+		}
+	}
+	for name, notification := range notifications.Types {
+		if !slices.Contains(model.NotificationTypes, model.NotificationType(name)) {
+			addf("notifications: unknown type: %s", name)
+			continue
+		}
+		for _, text := range []string{notification.Subject, notification.Body} {
+			if _, err := template.New(name).Parse(text); err != nil {
+				addf("notifications: %s: invalid template: %v", name, err)
+			}
+		}
+	}
+
+	// Check the indexed search fields
+	search := config.Search
+	if search.Enabled && len(search.Fields) == 0 && len(search.Domains) == 0 {
+		addf("search: no field to index")
+	}
+	for _, field := range search.Fields {
+		if field == "" {
+			addf("search: empty field name")
+		}
+	}
+	for domainName, fields := range search.Domains {
+		if len(fields) == 0 || slices.Contains(fields, "") {
+			addf("search: domain %s: invalid fields", domainName)
+		}
+	}
+
+	// Check the standby replication
+	replication := config.Replication
+	if replication.Standby {
+		if u, err := url.Parse(replication.Primary); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addf("replication: invalid primary URL: %q", replication.Primary)
+		}
+		if replication.ServiceID == "" || replication.ServiceSecret == "" {
+			addf("replication: a service account of the primary is required")
+		}
+	}
+	if replication.Interval < 0 {
+		addf("replication: invalid interval: %s", replication.Interval)
+	}
+	if replication.BatchSize < 0 || replication.BatchSize > model.MaxReplicationBatchSize {
+		addf("replication: batch size must be between 0 and %d", model.MaxReplicationBatchSize)
+	}
+
+	// Check the remote-write endpoint
+	remoteWrite := config.RemoteWrite
+	if remoteWrite.URL != "" {
+		if u, err := url.Parse(remoteWrite.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addf("remoteWrite: invalid url: %q", remoteWrite.URL)
+		}
+		if remoteWrite.Interval < time.Second {
+			addf("remoteWrite: interval must be at least 1s: %s", remoteWrite.Interval)
+		}
+	}
+	if remoteWrite.Timeout < 0 {
+		addf("remoteWrite: invalid timeout: %s", remoteWrite.Timeout)
+	}
+	for name := range remoteWrite.Labels {
+		if !metricLabelPattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			addf("remoteWrite: invalid label name: %q", name)
+		}
+	}
+
+	// Check the v1 API retirement dates
+	versioning := config.HTTP.APIVersioning
+	var deprecation, sunset time.Time
+	var err error
+	if versioning.V1Deprecation != "" {
+		if deprecation, err = time.Parse(time.DateOnly, versioning.V1Deprecation); err != nil {
+			addf("invalid v1 API deprecation date: %s", versioning.V1Deprecation)
+		}
+	}
+	if versioning.V1Sunset != "" {
+		if sunset, err = time.Parse(time.DateOnly, versioning.V1Sunset); err != nil {
+			addf("invalid v1 API sunset date: %s", versioning.V1Sunset)
+		}
+	}
+	if !deprecation.IsZero() && !sunset.IsZero() && sunset.Before(deprecation) {
+		addf("v1 API sunset %s is before its deprecation %s", versioning.V1Sunset, versioning.V1Deprecation)
+	}
+
+	problems = append(problems, validateDomains(config.Domains)...)
+
+	// Keys derived from the machine ID are lost with the host, on a promoted
+	// standby or a backup restored elsewhere sealed fields can't be read
+	if config.Security.FieldEncryptionKey == "" {
+		for _, domain := range config.Domains {
+			for _, queue := range domain.Queues {
+				if len(queue.Config.EncryptedFields) > 0 {
+					addf("domain %s: queue %s: encryptedFields requires security.fieldEncryptionKey", domain.Name, queue.Name)
+				}
+			}
+		}
+		for _, blueprint := range config.Blueprints {
+			if len(blueprint.Config.EncryptedFields) > 0 {
+				addf("blueprint %s: encryptedFields requires security.fieldEncryptionKey", blueprint.Name)
+			}
+		}
+	}
+
+	if config.GitOps.Enabled {
+		if config.GitOps.Directory == "" {
+			addf("gitops.directory is required when gitops is enabled")
+		}
+		if config.GitOps.Interval < time.Second {
+			addf("gitops.interval must be at least 1s: %s", config.GitOps.Interval)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checks predefined domains for missing names, duplicates and dangling routes
+// ValidateDomains checks domain definitions, such as the ones of manifests
+func ValidateDomains(domains []DomainConfig) error {
+	if problems := validateDomains(domains); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateDomains(domains []DomainConfig) []string {
+	var problems []string
+	seenDomains := make(map[string]bool)
+
+	for i, domain := range domains {
+		if domain.Name == "" {
+			problems = append(problems, fmt.Sprintf("domains[%d]: name is required", i))
+			continue
+		}
+		if seenDomains[domain.Name] {
+			problems = append(problems, fmt.Sprintf("domain %s is defined more than once", domain.Name))
+		}
+		seenDomains[domain.Name] = true
+
+		queues := make(map[string]bool)
+		for j, queue := range domain.Queues {
+			if queue.Name == "" {
+				problems = append(problems, fmt.Sprintf("domain %s: queues[%d]: name is required", domain.Name, j))
+				continue
+			}
+			if queues[queue.Name] {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s is defined more than once", domain.Name, queue.Name))
+			}
+			queues[queue.Name] = true
+		}
+
+		for _, queue := range domain.Queues {
+			if err := queue.Config.ValidatePartitions(queue.Name); err != nil {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+			}
+			if compaction := queue.Config.Compaction; compaction != nil {
+				if err := compaction.Validate(queue.Config); err != nil {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+				}
+			}
+			if err := model.ValidateEnrichment(queue.Config.Enrichment); err != nil {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+			}
+			if err := queue.Config.Mode.Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+			}
+			if err := queue.Config.ImplicitGroups.Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+			}
+			if cb := queue.Config.CircuitBreakerConfig; cb != nil && cb.FallbackQueue != "" {
+				if cb.FallbackQueue == queue.Name {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s can't fall back to itself", domain.Name, queue.Name))
+				} else if !queues[cb.FallbackQueue] {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: fallback queue %s does not exist", domain.Name, queue.Name, cb.FallbackQueue))
+				}
+			}
+
+			if hooks := queue.Config.Hooks; hooks != nil {
+				if err := hooks.Validate(queue.Name); err != nil {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+				} else if hooks.Queue != "" && !queues[hooks.Queue] {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: hook queue %s does not exist", domain.Name, queue.Name, hooks.Queue))
+				}
+			}
+
+			if deadLetter := queue.Config.DeadLetter; deadLetter != nil {
+				if err := deadLetter.Validate(queue.Name); err != nil {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+				} else if !queues[deadLetter.Queue] {
+					problems = append(problems, fmt.Sprintf("domain %s: queue %s: dead-letter queue %s does not exist", domain.Name, queue.Name, deadLetter.Queue))
+				}
+			}
+
+			mirror := queue.Config.Mirror
+			if mirror == nil {
+				continue
+			}
+			if err := mirror.Validate(queue.Name); err != nil {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: %v", domain.Name, queue.Name, err))
+			} else if !queues[mirror.Queue] {
+				problems = append(problems, fmt.Sprintf("domain %s: queue %s: shadow queue %s does not exist", domain.Name, queue.Name, mirror.Queue))
+			}
+		}
+
+		for _, route := range domain.Routes {
+			if !queues[route.SourceQueue] {
+				problems = append(problems, fmt.Sprintf("domain %s: route source queue %s does not exist", domain.Name, route.SourceQueue))
+			}
+			// cross-domain destinations are checked when the route is created
+			if (route.DestinationDomain == "" || route.DestinationDomain == domain.Name) && !queues[route.DestinationQueue] {
+				problems = append(problems, fmt.Sprintf("domain %s: route destination queue %s does not exist", domain.Name, route.DestinationQueue))
+			}
+		}
+	}
+
+	return problems
+}
+
+// Diagnostics returns the settings that are valid but probably not intended,
+// logged at startup and printed by --validate-config
+func Diagnostics(config *Config) []string {
+	var warnings []string
+
+	if config.Security.EnableAuthentication && config.HTTP.JWT.Secret == "changeme" {
+		warnings = append(warnings, "http.jwt.secret still has its default value, set a random secret")
+	}
+	if !config.Security.EnableAuthentication {
+		warnings = append(warnings, "authentication is disabled, every API is open")
+	}
+	if id := config.Security.MachineID; id != "" && len(id) < 16 {
+		warnings = append(warnings, "security.machineId is short, the storage keys derive from it: use at least 16 random characters")
+	}
+	var servesManagement, servesData bool
+	for _, l := range config.HTTPListeners() {
+		servesManagement = servesManagement || l.ServesManagement()
+		servesData = servesData || l.ServesData()
+		if config.Security.Hardened && !l.TLS {
+			warnings = append(warnings, fmt.Sprintf("security.hardened is set but HTTP listener %s has tls disabled, it sends no HSTS", l.Name))
+		}
+		if config.Security.HMAC.Enabled && config.Security.HMAC.RequireTLS && l.ServesData() && !l.TLS {
+			warnings = append(warnings, fmt.Sprintf("security.hmac.requireTLS is set but HTTP listener %s has tls disabled, HMAC requests will be rejected", l.Name))
+		}
+	}
+	if config.HTTP.Enabled && !servesManagement {
+		warnings = append(warnings, "no HTTP listener serves the management API")
+	}
+	if config.HTTP.Enabled && !servesData {
+		warnings = append(warnings, "no HTTP listener serves the data-plane API")
+	}
+	if config.HTTP.CORS.Enabled && config.Security.EnableAuthentication {
+		for _, origin := range config.HTTP.CORS.AllowedOrigins {
+			if origin == "*" {
+				warnings = append(warnings, "CORS allows any origin while authentication is enabled")
+				break
+			}
+		}
+	}
+	if config.Cluster.Enabled && len(config.Cluster.Peers) == 0 {
+		warnings = append(warnings, "cluster mode is enabled without peers")
+	}
+
+	return warnings
+}
@@ -0,0 +1,485 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigDefaults(t *testing.T) {
+	assert.NoError(t, ValidateConfig(DefaultConfig()))
+}
+
+func TestValidateConfigAggregatesProblems(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.Port = -1
+	cfg.GRPC.Enabled = true
+	cfg.GRPC.Port = cfg.Monitoring.Port
+	cfg.Security.EnableAuthentication = true
+	cfg.HTTP.JWT.Secret = ""
+	cfg.Domains = []DomainConfig{{
+		Name:   "orders",
+		Queues: []QueueConfig{{Name: "incoming"}},
+		Routes: []RoutingRule{{SourceQueue: "incoming", DestinationQueue: "missing"}},
+	}}
+
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []string{
+		"invalid HTTP port: -1",
+		"gRPC and monitoring both bind port 9090 (0.0.0.0, 0.0.0.0)",
+		"http.jwt.secret is required when authentication is enabled",
+		"domain orders: route destination queue missing does not exist",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigDistinctAddresses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.Address = "127.0.0.1"
+	cfg.GRPC.Enabled = true
+	cfg.GRPC.Address = "10.0.0.5"
+	cfg.GRPC.Port = cfg.HTTP.Port
+
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateConfigHTTPListeners(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.Listeners = []HTTPListener{
+		{Name: "admin", Address: "127.0.0.1", Port: 8081, Role: ListenerRoleManagement},
+		{Name: "data", Address: "0.0.0.0", Port: 8080, Role: ListenerRoleData},
+	}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.HTTP.Listeners = append(cfg.HTTP.Listeners,
+		HTTPListener{Name: "data", Address: "10.0.0.5", Port: 8080, Role: "metrics"},
+	)
+
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []string{
+		"HTTP listener data and HTTP listener data both bind port 8080 (0.0.0.0, 10.0.0.5)",
+		"HTTP listener data is defined more than once",
+		"HTTP listener data: invalid role: metrics",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigAPIVersioning(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.APIVersioning = APIVersioning{V1Deprecation: "2026-01-01", V1Sunset: "2026-12-31"}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.HTTP.APIVersioning = APIVersioning{V1Deprecation: "2026-06-01", V1Sunset: "2026-01-01"}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"v1 API sunset 2026-01-01 is before its deprecation 2026-06-01"}, validationErr.Problems)
+
+	cfg.HTTP.APIVersioning = APIVersioning{V1Sunset: "31/12/2026"}
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"invalid v1 API sunset date: 31/12/2026"}, validationErr.Problems)
+}
+
+func TestValidateConfigHMACSunset(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.HMAC.V1Sunset = "2027-01-01T00:00:00Z"
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Security.HMAC.V1Sunset = "next year"
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"invalid HMAC v1 sunset, expected an RFC 3339 time: next year"}, validationErr.Problems)
+
+	cfg.Security.HMAC.V1Sunset = "2027-01-01T00:00:00Z"
+	cfg.Security.HMAC.V1Deprecation = "2027-06-01T00:00:00Z"
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"HMAC v1 sunset 2027-01-01T00:00:00Z is before its deprecation 2027-06-01T00:00:00Z"}, validationErr.Problems)
+}
+
+func TestValidateConfigConnectionLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.Connections = ConnectionLimits{MaxPerIP: 20, MaxPerUser: 5, IdleTimeout: 5 * time.Minute}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.HTTP.Connections = ConnectionLimits{MaxPerService: -1, MaxInFlightPerService: -1, IdleTimeout: 100 * time.Millisecond}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"connection limits must be positive, or 0 to disable them",
+		"connection idle timeout must be at least 1s: 100ms",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigSubscriptionQoS(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTP.Connections.Subscriptions = SubscriptionQoS{MaxRate: 50, MaxUnacked: 100, Buffer: 500, Overflow: "disconnect"}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.HTTP.Connections.Subscriptions = SubscriptionQoS{Buffer: -1, Overflow: "block", ResumeWindow: -time.Second}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"subscription limits must be positive, or 0 to use the defaults",
+		"subscription resume window and buffer can't be negative",
+		"unknown subscription overflow policy: block",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigSubscribers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Subscribers = model.SubscriberPolicy{Timeout: 2 * time.Second}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Subscribers = model.SubscriberPolicy{QuarantineAfter: -1}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"subscriber timeout and quarantine must be positive, or 0 to use the defaults",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigGroupCleanup(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GroupCleanup = model.GroupCleanupPolicy{StaleAfter: 48 * time.Hour, Action: model.GroupCleanupArchive}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.GroupCleanup = model.GroupCleanupPolicy{Interval: -time.Minute, Action: "forget"}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"group cleanup durations must be positive, or 0 to use the defaults",
+		"group cleanup: unknown action: forget",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigEventRetention(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Events = model.EventRetention{PerDomain: 200, MaxAge: 24 * time.Hour}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Events = model.EventRetention{PerDomain: -1}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"event retention must be positive, or 0 to use the defaults"}, validationErr.Problems)
+}
+
+func TestValidateConfigBlueprints(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Blueprints = []model.QueueBlueprint{
+		{Name: "critical-with-dlq", Config: model.QueueConfig{IsPersistent: true, RetryEnabled: true}},
+		{Name: "high-throughput-ephemeral", Config: model.QueueConfig{Partitions: 8, TTL: time.Minute}},
+	}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Blueprints = append(cfg.Blueprints,
+		model.QueueBlueprint{Name: "critical-with-dlq"},
+		model.QueueBlueprint{Name: "shadowed", Config: model.QueueConfig{Mirror: &model.MirrorConfig{Queue: "shadow", Percent: 10}}},
+	)
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"blueprint critical-with-dlq is defined more than once",
+		"invalid queue blueprint: shadowed: blueprints can't mirror to a shadow queue",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigIngestRoutes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Ingest = []IngestRoute{
+		{Name: "github", Domain: "ci", Queue: "events", Provider: IngestProviderGitHub, Secret: "s3cret"},
+		{Name: "plain", Domain: "ci", Queue: "events"},
+	}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Ingest = []IngestRoute{
+		{Name: "stripe", Domain: "billing", Queue: "events", Provider: IngestProviderStripe},
+		{Name: "stripe", Queue: "events", Provider: "paypal"},
+		{Name: "a/b", Domain: "ci", Queue: "events", MaxBodyKB: -1},
+	}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"ingest route stripe: the stripe provider needs a secret",
+		"ingest route stripe is defined more than once",
+		"ingest route stripe: domain and queue are required",
+		"ingest route stripe: unknown provider: paypal",
+		`ingest[2]: invalid name: "a/b"`,
+		"ingest route a/b: invalid max body size: -1KB",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigMQTTTopics(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MQTT.Topics = []MQTTTopic{
+		{Filter: "sensors/+/temperature", Domain: "iot", Queue: "temperatures"},
+		{Filter: "alerts/#", Domain: "iot", Queue: "alerts"},
+	}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.MQTT.Topics = []MQTTTopic{
+		{Filter: "alerts/#/critical", Domain: "iot", Queue: "alerts"},
+		{Filter: "sensors/temp+", Domain: "iot", Queue: "temperatures"},
+		{Filter: "", Domain: "iot"},
+	}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		`mqtt.topics[0]: invalid filter: "alerts/#/critical"`,
+		`mqtt.topics[1]: invalid filter: "sensors/temp+"`,
+		`mqtt.topics[2]: invalid filter: ""`,
+		"mqtt.topics[2]: domain and queue are required",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigNotifications(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications = NotificationConfig{
+		Enabled: true,
+		SMTP:    SMTPConfig{Host: "smtp.example.com", Port: 587, From: "gortms@example.com"},
+		Types:   map[string]NotificationTemplate{"alert": {Enabled: true, Subject: "{{.Queue}} is full"}},
+	}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Notifications = NotificationConfig{
+		Enabled: true,
+		Types: map[string]NotificationTemplate{
+			"alert": {Enabled: true, Body: "{{.Queue"},
+		},
+	}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"notifications: an smtp host or a webhook is required",
+		`notifications: alert: invalid template: template: alert:1: unclosed action`,
+	}, validationErr.Problems)
+
+	cfg.Notifications = NotificationConfig{
+		Enabled: true,
+		SMTP:    SMTPConfig{Host: "smtp.example.com"},
+		Webhooks: []ChatWebhook{
+			{Name: "ops", Kind: ChatWebhookSlack, URL: "https://hooks.slack.com/services/T/B/X", Severities: []string{"critical"}},
+			{Kind: "discord", URL: "hooks", Types: []string{"password-reset"}, Severities: []string{"info"}, RatePerMinute: -1},
+		},
+	}
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"notifications: smtp from is required",
+		"notifications: invalid smtp port: 0",
+		"notifications: webhook webhooks[1]: unknown kind: discord",
+		"notifications: webhook webhooks[1]: invalid url",
+		"notifications: webhook webhooks[1]: unsupported type: password-reset",
+		"notifications: webhook webhooks[1]: unknown severity: info",
+		"notifications: webhook webhooks[1]: rate and retries must be positive",
+	}, validationErr.Problems)
+
+	cfg.Notifications = NotificationConfig{
+		Enabled: true,
+		Webhooks: []ChatWebhook{
+			{Name: "oncall", Kind: ChatWebhookAlertmanager, URL: "https://alerts.example.com/hook", Types: []string{"alert", "alert-resolved", "account-request"}},
+		},
+	}
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"notifications: webhook oncall: unsupported type: account-request"}, validationErr.Problems)
+
+	cfg.Notifications = NotificationConfig{Types: map[string]NotificationTemplate{"digest": {}}}
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"notifications: unknown type: digest"}, validationErr.Problems)
+}
+
+func TestValidateConfigErrorReporting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ErrorReporting = ErrorReportingConfig{SentryDSN: "https://key@o1.ingest.sentry.io/42", Environment: "production"}
+	require.NoError(t, ValidateConfig(cfg))
+
+	for _, dsn := range []string{"https://o1.ingest.sentry.io/42", "https://key@o1.ingest.sentry.io", "sentry.io/42"} {
+		cfg.ErrorReporting.SentryDSN = dsn
+		err := ValidateConfig(cfg)
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr), dsn)
+		assert.Equal(t, []string{"errorReporting: invalid sentry dsn, expected https://<key>@<host>/<project>"}, validationErr.Problems)
+	}
+
+	cfg.ErrorReporting = ErrorReportingConfig{SentryDSN: "https://key@o1.ingest.sentry.io/42", SampleRate: 1.5}
+	err := ValidateConfig(cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"errorReporting: sample rate must be between 0 and 1: 1.5"}, validationErr.Problems)
+}
+
+func TestValidateConfigSearch(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Search = SearchConfig{Enabled: true, Fields: []string{"customerId"}, Domains: map[string][]string{"shop": {"orderId"}}}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Search = SearchConfig{Enabled: true}
+	err := ValidateConfig(cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"search: no field to index"}, validationErr.Problems)
+
+	cfg.Search = SearchConfig{Enabled: true, Fields: []string{""}, Domains: map[string][]string{"shop": {}}}
+	err = ValidateConfig(cfg)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"search: empty field name",
+		"search: domain shop: invalid fields",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigReplication(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Replication.Standby = true
+	cfg.Replication.Primary = "https://primary:8080"
+	cfg.Replication.ServiceID = "standby"
+	cfg.Replication.ServiceSecret = "secret"
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.Replication.Primary = "primary:8080"
+	cfg.Replication.ServiceSecret = ""
+	cfg.Replication.BatchSize = model.MaxReplicationBatchSize + 1
+	err := ValidateConfig(cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		`replication: invalid primary URL: "primary:8080"`,
+		"replication: a service account of the primary is required",
+		"replication: batch size must be between 0 and 5000",
+	}, validationErr.Problems)
+}
+
+func TestHTTPListenersFallsBackToLegacyBind(t *testing.T) {
+	cfg := DefaultConfig()
+
+	listeners := cfg.HTTPListeners()
+	require.Len(t, listeners, 1)
+	assert.Equal(t, cfg.HTTP.Port, listeners[0].Port)
+	assert.True(t, listeners[0].ServesManagement())
+	assert.True(t, listeners[0].ServesData())
+}
+
+func TestValidateConfigQueueMirror(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Domains = []DomainConfig{{
+		Name: "orders",
+		Queues: []QueueConfig{
+			{Name: "incoming", Config: model.QueueConfig{Mirror: &model.MirrorConfig{Queue: "shadow", Percent: 10}}},
+			{Name: "shadow"},
+			{Name: "audit", Config: model.QueueConfig{Mirror: &model.MirrorConfig{Queue: "missing", Percent: 10}}},
+			{Name: "billing", Config: model.QueueConfig{Mirror: &model.MirrorConfig{Queue: "shadow", Percent: 150}}},
+			{Name: "refunds", Config: model.QueueConfig{CircuitBreakerConfig: &model.CircuitBreakerConfig{FallbackQueue: "shadow"}}},
+			{Name: "returns", Config: model.QueueConfig{CircuitBreakerConfig: &model.CircuitBreakerConfig{FallbackQueue: "missing"}}},
+			{Name: "payments", Config: model.QueueConfig{Partitions: 100}},
+			{Name: "invoices", Config: model.QueueConfig{Hooks: &model.QueueHooks{Queue: "shadow"}}},
+			{Name: "credits", Config: model.QueueConfig{Hooks: &model.QueueHooks{Queue: "missing"}}},
+			{Name: "debits", Config: model.QueueConfig{Hooks: &model.QueueHooks{Queue: "debits"}}},
+			{Name: "disputes", Config: model.QueueConfig{DeadLetter: &model.DeadLetterConfig{Queue: "shadow"}}},
+			{Name: "chargebacks", Config: model.QueueConfig{DeadLetter: &model.DeadLetterConfig{Queue: "missing"}}},
+			{Name: "payouts", Config: model.QueueConfig{DeadLetter: &model.DeadLetterConfig{Queue: "shadow", Reasons: []model.DeadLetterReason{"lost"}}}},
+		},
+	}}
+
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"domain orders: queue audit: shadow queue missing does not exist",
+		"domain orders: queue billing: invalid mirror: percent must be above 0 and at most 100",
+		"domain orders: queue returns: fallback queue missing does not exist",
+		"domain orders: queue payments: invalid partitions: partitions must be between 0 and 64",
+		"domain orders: queue credits: hook queue missing does not exist",
+		"domain orders: queue debits: invalid queue hooks: a queue can't report to itself",
+		"domain orders: queue chargebacks: dead-letter queue missing does not exist",
+		"domain orders: queue payouts: invalid dead-letter config: unknown reason \"lost\"",
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigRemoteWrite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RemoteWrite.URL = "https://prometheus.example.com/api/v1/write"
+	cfg.RemoteWrite.Labels = map[string]string{"env": "edge"}
+	require.NoError(t, ValidateConfig(cfg))
+
+	cfg.RemoteWrite.URL = "prometheus:9090"
+	cfg.RemoteWrite.Interval = 100 * time.Millisecond
+	cfg.RemoteWrite.Labels = map[string]string{"__name__": "x"}
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		`remoteWrite: invalid url: "prometheus:9090"`,
+		"remoteWrite: interval must be at least 1s: 100ms",
+		`remoteWrite: invalid label name: "__name__"`,
+	}, validationErr.Problems)
+}
+
+func TestValidateConfigJSONEngine(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, engine := range []string{"", JSONEngineStd, JSONEngineJsoniter, JSONEngineSonic} {
+		cfg.HTTP.JSONEngine = engine
+		require.NoError(t, ValidateConfig(cfg), engine)
+	}
+
+	cfg.HTTP.JSONEngine = "gojay"
+	err := ValidateConfig(cfg)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"http.jsonEngine must be std, jsoniter or sonic: gojay"}, validationErr.Problems)
+}
+
+func TestValidateConfigFieldEncryptionKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Domains = []DomainConfig{{
+		Name:   "shop",
+		Queues: []QueueConfig{{Name: "payments", Config: model.QueueConfig{EncryptedFields: []string{"card"}}}},
+	}}
+	cfg.Blueprints = []model.QueueBlueprint{{Name: "sealed", Config: model.QueueConfig{EncryptedFields: []string{"ssn"}}}}
+
+	err := ValidateConfig(cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"domain shop: queue payments: encryptedFields requires security.fieldEncryptionKey",
+		"blueprint sealed: encryptedFields requires security.fieldEncryptionKey",
+	}, validationErr.Problems)
+
+	cfg.Security.FieldEncryptionKey = "shared-across-nodes"
+	assert.NoError(t, ValidateConfig(cfg))
+}
@@ -20,17 +20,18 @@ const (
 
 // AccountRequest represents a user account creation request
 type AccountRequest struct {
-	ID            string               `json:"id"`            // Unique identifier for the request
-	Username      string               `json:"username"`      // Requested username
-	RequestedRole UserRole             `json:"requestedRole"` // Role requested by the user
-	Status        AccountRequestStatus `json:"status"`        // Current status of the request
-	CreatedAt     time.Time            `json:"createdAt"`     // Request creation timestamp
-	ReviewedAt    *time.Time           `json:"reviewedAt"`    // Review timestamp (nil if not reviewed)
-	ReviewedBy    string               `json:"reviewedBy"`    // Username of the admin who reviewed
-	ApprovedRole  *UserRole            `json:"approvedRole"`  // Role actually granted (may differ from requested)
-	RejectReason  string               `json:"rejectReason"`  // Reason for rejection (empty if not rejected)
-	PasswordHash  string               `json:"passwordHash"`  // Hashed password provided during request
-	Salt          [16]byte             `json:"salt"`          // Salt used for password hashing
+	ID            string               `json:"id"`              // Unique identifier for the request
+	Username      string               `json:"username"`        // Requested username
+	RequestedRole UserRole             `json:"requestedRole"`   // Role requested by the user
+	Status        AccountRequestStatus `json:"status"`          // Current status of the request
+	CreatedAt     time.Time            `json:"createdAt"`       // Request creation timestamp
+	ReviewedAt    *time.Time           `json:"reviewedAt"`      // Review timestamp (nil if not reviewed)
+	ReviewedBy    string               `json:"reviewedBy"`      // Username of the admin who reviewed
+	ApprovedRole  *UserRole            `json:"approvedRole"`    // Role actually granted (may differ from requested)
+	RejectReason  string               `json:"rejectReason"`    // Reason for rejection (empty if not rejected)
+	PasswordHash  string               `json:"passwordHash"`    // Hashed password provided during request
+	Salt          [16]byte             `json:"salt"`            // Salt used for password hashing
+	Email         string               `json:"email,omitempty"` // Where the outcome is notified (optional)
 }
 
 // AccountRequestDatabase represents the storage structure for account requests
@@ -50,6 +51,7 @@ type AccountRequestResponse struct {
 	ReviewedBy    string               `json:"reviewedBy,omitempty"`
 	ApprovedRole  *UserRole            `json:"approvedRole,omitempty"`
 	RejectReason  string               `json:"rejectReason,omitempty"`
+	Email         string               `json:"email,omitempty"`
 }
 
 // ToResponse converts an AccountRequest to its API response format
@@ -64,6 +66,7 @@ func (ar *AccountRequest) ToResponse() *AccountRequestResponse {
 		ReviewedBy:    ar.ReviewedBy,
 		ApprovedRole:  ar.ApprovedRole,
 		RejectReason:  ar.RejectReason,
+		Email:         ar.Email,
 	}
 }
 
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueueBlueprint is a named queue config that queue creations reference
+// instead of repeating it, the creation request overriding its settings
+type QueueBlueprint struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Config      QueueConfig `yaml:"config" json:"config"`
+
+	// FromConfig marks the blueprints of the configuration file, which the
+	// API can't replace or delete
+	FromConfig bool      `yaml:"-" json:"fromConfig"`
+	UpdatedAt  time.Time `yaml:"-" json:"updatedAt"`
+}
+
+// Validate checks the name and the settings shared by every queue created
+// from the blueprint. A shadow queue belongs to one queue, so blueprints
+// can't mirror.
+func (b *QueueBlueprint) Validate() error {
+	if b.Name == "" || strings.ContainsAny(b.Name, "/?# ") {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidBlueprint, b.Name)
+	}
+	if b.Config.Mirror != nil {
+		return fmt.Errorf("%w: %s: blueprints can't mirror to a shadow queue", ErrInvalidBlueprint, b.Name)
+	}
+	if err := b.Config.ValidatePartitions(b.Name); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBlueprint, b.Name, err)
+	}
+	if err := ValidateEnrichment(b.Config.Enrichment); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBlueprint, b.Name, err)
+	}
+	if compaction := b.Config.Compaction; compaction != nil {
+		if err := compaction.Validate(b.Config); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidBlueprint, b.Name, err)
+		}
+	}
+	if hooks := b.Config.Hooks; hooks != nil {
+		if err := hooks.Validate(""); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidBlueprint, b.Name, err)
+		}
+	}
+	return nil
+}
+
+// QueueConfig returns a copy of the config of the blueprint, which the
+// queue created from it can change without touching the blueprint
+func (b *QueueBlueprint) QueueConfig() QueueConfig {
+	config := b.Config
+	if config.RetryConfig != nil {
+		retry := *config.RetryConfig
+		config.RetryConfig = &retry
+	}
+	if config.CircuitBreakerConfig != nil {
+		cb := *config.CircuitBreakerConfig
+		config.CircuitBreakerConfig = &cb
+	}
+	if config.Compaction != nil {
+		compaction := *config.Compaction
+		config.Compaction = &compaction
+	}
+	if config.Hooks != nil {
+		hooks := *config.Hooks
+		hooks.Events = slices.Clone(hooks.Events)
+		config.Hooks = &hooks
+	}
+	config.Labels = maps.Clone(config.Labels)
+	config.EncryptedFields = slices.Clone(config.EncryptedFields)
+	config.Enrichment = slices.Clone(config.Enrichment)
+	return config
+}
@@ -3,9 +3,13 @@ package model
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"math"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +18,10 @@ var (
 	ErrQueueFull   = errors.New("queue is full")
 )
 
+// MaxConcurrentGroupFills bounds the group channel fills running at once,
+// beyond it pending groups wait their turn by priority
+const MaxConcurrentGroupFills = 8
+
 type ChannelQueue struct {
 	queue           *Queue
 	messages        chan *Message
@@ -31,21 +39,43 @@ type ChannelQueue struct {
 	// errors handling
 	retryQueue     chan *MessageWithRetry
 	circuitBreaker *CircuitBreaker
+	failures       *FailureSampler  // recent handler errors for debugging
+	guard          *SubscriberGuard // bounds slow or panicking subscribers
+	onFailure      func(msg *Message, err error)
+	onDeadLetter   func(msg *Message, reason DeadLetterReason, err error, attempts int)
 
 	consumerGroups map[string]*ConsumerGroupState
 	mu             sync.RWMutex
 	commandWorker  bool
+	wake           chan struct{} // signals the command worker that fills may be dispatched
 
 	pendingFetches map[string]bool // groupID -> isCurrentlyFetching
+	activeFills    int             // fills started by dispatchFills still running
 	fetchMu        sync.Mutex
 }
 
 type ConsumerGroupState struct {
 	GroupID  string
-	Messages chan *Message // messages chan
-	Commands chan int      // commands chan
+	Messages chan *Message    // messages chan
+	Commands chan fillRequest // commands chan
 	Position int64
 	Active   bool
+	Mode     GroupDeliveryMode
+	Priority int // higher is filled first
+	Prefetch int // credit: most messages buffered ahead of the consumers
+
+	cursors    map[string]int64               // broadcast mode: consumerID -> next index
+	inFlight   map[string]map[string]struct{} // broadcast mode: consumerID -> IDs handed out, cursor not yet moved
+	pending    int                            // requested count waiting for a fill slot
+	requesters []context.Context              // consumers waiting on the pending request
+	waited     int                            // dispatch rounds spent waiting, ages the priority
+}
+
+// fillRequest asks for count messages on behalf of a consumer, whose context
+// ends the request once it gives up
+type fillRequest struct {
+	ctx   context.Context
+	count int
 }
 
 func NewChannelQueue(
@@ -110,10 +140,13 @@ func NewChannelQueue(
 		workerSem:       make(chan struct{}, workerCount),
 		retryQueue:      retryQueue,
 		circuitBreaker:  cb,
+		failures:        NewFailureSampler(DefaultFailureSampleSize),
+		guard:           NewSubscriberGuard(SubscriberPolicy{}, logger),
 		consumerGroups:  make(map[string]*ConsumerGroupState),
 		messageProvider: provider,
 		domainName:      queue.DomainName,
 		pendingFetches:  make(map[string]bool),
+		wake:            make(chan struct{}, 1),
 		logger:          logger,
 	}
 }
@@ -148,6 +181,12 @@ func (cq *ChannelQueue) Enqueue(ctx context.Context, message *Message) error {
 	}
 }
 
+// CircuitOpen reports whether the circuit breaker refuses messages, it turns
+// half-open once the open timeout has passed
+func (cq *ChannelQueue) CircuitOpen() bool {
+	return cq.circuitBreaker != nil && !cq.circuitBreaker.Allow()
+}
+
 // helper method
 func (cq *ChannelQueue) recordSuccessInCircuitBreaker() {
 	cq.circuitBreaker.mu.Lock()
@@ -201,9 +240,11 @@ func (cq *ChannelQueue) AddConsumerGroup(groupID string, lastIndex int64) error
 	group := &ConsumerGroupState{
 		GroupID:  groupID,
 		Messages: make(chan *Message, bufSize),
-		Commands: make(chan int, 10), // commands buffer
+		Commands: make(chan fillRequest, 10), // commands buffer
 		Position: lastIndex,
 		Active:   true,
+		Mode:     GroupDeliveryShared,
+		Prefetch: DefaultGroupPrefetch,
 	}
 
 	cq.consumerGroups[groupID] = group
@@ -218,38 +259,158 @@ func (cq *ChannelQueue) AddConsumerGroup(groupID string, lastIndex int64) error
 	return nil
 }
 
+// processCommands sleeps until a group requests messages or a fill slot
+// frees up, so an idle queue costs nothing and requests are served at once
 func (cq *ChannelQueue) processCommands() {
 	defer cq.wg.Done()
 
-	ticker := time.NewTicker(5 * time.Millisecond)
-	defer ticker.Stop()
-
 	for {
 		select {
 		case <-cq.workerCtx.Done():
 			return
 
-		case <-ticker.C:
-			// Check all group commands
-			cq.mu.RLock()
-			for groupID, group := range cq.consumerGroups {
-				if !group.Active {
-					continue
-				}
+		case <-cq.wake:
+			cq.dispatchFills()
+		}
+	}
+}
 
-				select {
-				case count, ok := <-group.Commands:
-					if !ok {
-						continue
-					}
-					// Process the command outside the lock
-					go cq.fillGroupChannel(groupID, count)
-				default:
-					// noop
+// notify wakes the command worker, wakeups coalesce while one is pending
+func (cq *ChannelQueue) notify() {
+	select {
+	case cq.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchFills starts the fills requested by the groups, highest priority
+// first. When all fill slots are busy the others keep their request for the
+// next round, each round waited raising their priority so none starves.
+func (cq *ChannelQueue) dispatchFills() {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	waiting := make([]*ConsumerGroupState, 0, len(cq.consumerGroups))
+	for _, group := range cq.consumerGroups {
+		if !group.Active {
+			continue
+		}
+
+	drain:
+		for {
+			select {
+			case request, ok := <-group.Commands:
+				if !ok {
+					break drain
 				}
+				group.pending = max(group.pending, request.count)
+				group.requesters = append(group.requesters, request.ctx)
+			default:
+				break drain
 			}
-			cq.mu.RUnlock()
 		}
+
+		// the request is dropped once every consumer waiting on it has left
+		if len(group.requesters) > 0 {
+			group.requesters = slices.DeleteFunc(group.requesters, func(ctx context.Context) bool {
+				return ctx.Err() != nil
+			})
+			if len(group.requesters) == 0 {
+				group.pending = 0
+				group.waited = 0
+			}
+		}
+
+		if group.pending > 0 {
+			waiting = append(waiting, group)
+		}
+	}
+
+	if len(waiting) == 0 {
+		return
+	}
+
+	slices.SortFunc(waiting, func(a, b *ConsumerGroupState) int {
+		return (b.Priority + b.waited) - (a.Priority + a.waited)
+	})
+
+	cq.fetchMu.Lock()
+	defer cq.fetchMu.Unlock()
+
+	for _, group := range waiting {
+		if cq.activeFills >= MaxConcurrentGroupFills {
+			group.waited++
+			continue
+		}
+		cq.activeFills++
+
+		count := group.pending
+		ctx, stop := cq.fillContext(group.requesters)
+		group.pending = 0
+		group.requesters = nil
+		group.waited = 0
+
+		// Process the command outside the lock
+		go func(groupID string) {
+			defer func() {
+				stop()
+				cq.fetchMu.Lock()
+				cq.activeFills--
+				cq.fetchMu.Unlock()
+				// groups left waiting can take the freed slot
+				cq.notify()
+			}()
+			cq.fillGroupChannel(ctx, groupID, count)
+		}(group.GroupID)
+	}
+}
+
+// fillContext is done when the queue stops or once every consumer waiting on
+// the fill has given up, so abandoned fetches stop early
+func (cq *ChannelQueue) fillContext(requesters []context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(cq.workerCtx)
+
+	var left atomic.Int32
+	left.Store(int32(len(requesters)))
+	stops := make([]func() bool, 0, len(requesters))
+	for _, requester := range requesters {
+		stops = append(stops, context.AfterFunc(requester, func() {
+			if left.Add(-1) == 0 {
+				cancel()
+			}
+		}))
+	}
+
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+// SetGroupPriority sets how early a group is filled when fills compete
+func (cq *ChannelQueue) SetGroupPriority(groupID string, priority int) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if group, exists := cq.consumerGroups[groupID]; exists {
+		group.Priority = priority
+	}
+}
+
+// SetGroupPrefetch sets how many messages a group may buffer ahead of its
+// consumers, a value below 1 restoring DefaultGroupPrefetch
+func (cq *ChannelQueue) SetGroupPrefetch(groupID string, prefetch int) {
+	if prefetch < 1 {
+		prefetch = DefaultGroupPrefetch
+	}
+
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if group, exists := cq.consumerGroups[groupID]; exists {
+		group.Prefetch = prefetch
 	}
 }
 
@@ -264,7 +425,7 @@ func (q *ChannelQueue) UpdateConsumerGroupPosition(groupID string, position int6
 	}
 }
 
-func (cq *ChannelQueue) fillGroupChannel(groupID string, count int) {
+func (cq *ChannelQueue) fillGroupChannel(ctx context.Context, groupID string, count int) {
 	// Check if a fetch is already in progress to avoid concurrent calls
 	cq.fetchMu.Lock()
 	if cq.pendingFetches[groupID] {
@@ -297,9 +458,11 @@ func (cq *ChannelQueue) fillGroupChannel(groupID string, count int) {
 	}
 
 	messages, err := cq.messageProvider.GetMessagesAfterIndex(
-		cq.workerCtx, cq.domainName, cq.queue.Name, position, count)
+		ctx, cq.domainName, cq.queue.Name, position, count)
 	if err != nil {
-		log.Printf("Error getting messages: %v", err)
+		if ctx.Err() == nil {
+			log.Printf("Error getting messages: %v", err)
+		}
 		return
 	}
 
@@ -309,7 +472,7 @@ func (cq *ChannelQueue) fillGroupChannel(groupID string, count int) {
 
 	for _, msg := range messages {
 		select {
-		case <-cq.workerCtx.Done():
+		case <-ctx.Done():
 			return
 		case group.Messages <- msg:
 		case <-time.After(100 * time.Millisecond):
@@ -320,6 +483,16 @@ func (cq *ChannelQueue) fillGroupChannel(groupID string, count int) {
 	}
 }
 
+// HasConsumerGroup tells whether the group has consumed from the queue since
+// it was loaded
+func (cq *ChannelQueue) HasConsumerGroup(groupID string) bool {
+	cq.mu.RLock()
+	defer cq.mu.RUnlock()
+
+	_, exists := cq.consumerGroups[groupID]
+	return exists
+}
+
 func (cq *ChannelQueue) RemoveConsumerGroup(groupID string) {
 	cq.mu.Lock()
 	defer cq.mu.Unlock()
@@ -334,25 +507,44 @@ func (cq *ChannelQueue) RemoveConsumerGroup(groupID string) {
 	}
 }
 
-func (cq *ChannelQueue) RequestMessages(groupID string, count int) error {
+// RequestMessages asks for up to count messages to be buffered for the
+// group. The request is capped by the credit left, the group prefetch minus
+// the messages already buffered; without credit nothing is fetched until the
+// consumers catch up. The request is dropped, or its fetch stopped, once ctx
+// is done and no other consumer of the group waits on it.
+func (cq *ChannelQueue) RequestMessages(ctx context.Context, groupID string, count int) error {
 	cq.mu.RLock()
 	group, exists := cq.consumerGroups[groupID]
+	var credit int
+	if exists {
+		credit = group.Prefetch - len(group.Messages)
+	}
 	cq.mu.RUnlock()
 
 	if !exists || !group.Active {
 		return errors.New("consumer group not active")
 	}
 
+	count = min(count, credit)
+	if count <= 0 {
+		return nil
+	}
+
 	// send command
 	select {
-	case group.Commands <- count:
+	case group.Commands <- fillRequest{ctx: ctx, count: count}:
+		cq.notify()
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	case <-time.After(100 * time.Millisecond):
 		return errors.New("command channel full")
 	}
 }
 
-func (cq *ChannelQueue) ConsumeMessage(groupID string, timeout time.Duration) (*Message, error) {
+// ConsumeMessage waits up to timeout for a message of the group, returning
+// the error of ctx as soon as it is done
+func (cq *ChannelQueue) ConsumeMessage(ctx context.Context, groupID string, timeout time.Duration) (*Message, error) {
 	cq.mu.RLock()
 	group, exists := cq.consumerGroups[groupID]
 	cq.mu.RUnlock()
@@ -361,9 +553,15 @@ func (cq *ChannelQueue) ConsumeMessage(groupID string, timeout time.Duration) (*
 		return nil, errors.New("consumer group not active")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	select {
 	case <-cq.workerCtx.Done():
 		return nil, ErrQueueClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	case msg, ok := <-group.Messages:
 		if !ok {
 			return nil, ErrQueueClosed
@@ -374,6 +572,167 @@ func (cq *ChannelQueue) ConsumeMessage(groupID string, timeout time.Duration) (*
 	}
 }
 
+// SetGroupDeliveryMode switches a group between shared and broadcast delivery
+func (cq *ChannelQueue) SetGroupDeliveryMode(groupID string, mode GroupDeliveryMode) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	group, exists := cq.consumerGroups[groupID]
+	if !exists || group.Mode == mode {
+		return
+	}
+
+	group.Mode = mode
+	group.cursors, group.inFlight = nil, nil
+	if mode == GroupDeliveryBroadcast {
+		group.cursors = make(map[string]int64)
+		group.inFlight = make(map[string]map[string]struct{})
+	}
+}
+
+// SyncGroupConsumers drops the broadcast cursors of consumers that left the group
+// so they no longer hold back the group position
+func (cq *ChannelQueue) SyncGroupConsumers(groupID string, consumerIDs []string) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	group, exists := cq.consumerGroups[groupID]
+	if !exists || group.cursors == nil {
+		return
+	}
+
+	for consumerID := range group.cursors {
+		if !slices.Contains(consumerIDs, consumerID) {
+			delete(group.cursors, consumerID)
+			delete(group.inFlight, consumerID)
+		}
+	}
+}
+
+// ConsumeBroadcast returns the next message for a consumer of a broadcast group,
+// each consumer reading the queue from its own cursor. The message stays
+// reserved for the consumer until AdvanceConsumerCursor or
+// ReleaseBroadcastMessage, so concurrent consumes get different messages.
+func (cq *ChannelQueue) ConsumeBroadcast(ctx context.Context, groupID, consumerID string, timeout time.Duration) (*Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		cursor, reserved, err := cq.broadcastCursor(groupID, consumerID)
+		if err != nil {
+			return nil, err
+		}
+
+		// reserved messages are skipped, one more is enough to find a free one
+		messages, err := cq.messageProvider.GetMessagesAfterIndex(ctx, cq.domainName, cq.queue.Name, cursor, reserved+1)
+		if err != nil {
+			return nil, err
+		}
+		if msg := cq.reserveBroadcast(groupID, consumerID, cursor, messages); msg != nil {
+			return msg, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, nil // Timeout
+		}
+
+		select {
+		case <-cq.workerCtx.Done():
+			return nil, ErrQueueClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// broadcastCursor returns the cursor of a broadcast consumer and how many
+// messages it has reserved
+func (cq *ChannelQueue) broadcastCursor(groupID, consumerID string) (int64, int, error) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	group, exists := cq.consumerGroups[groupID]
+	if !exists || !group.Active || group.cursors == nil {
+		return 0, 0, errors.New("broadcast consumer group not active")
+	}
+	cursor, known := group.cursors[consumerID]
+	if !known {
+		// New consumers start from the oldest message not yet released by the group
+		cursor = group.Position
+		group.cursors[consumerID] = cursor
+	}
+	return cursor, len(group.inFlight[consumerID]), nil
+}
+
+// reserveBroadcast reserves the first message the consumer hasn't reserved
+// yet, nothing when its cursor moved since the messages were read
+func (cq *ChannelQueue) reserveBroadcast(groupID, consumerID string, cursor int64, messages []*Message) *Message {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	group, exists := cq.consumerGroups[groupID]
+	if !exists || group.inFlight == nil || group.cursors[consumerID] != cursor {
+		return nil
+	}
+
+	reserved := group.inFlight[consumerID]
+	for _, msg := range messages {
+		if _, taken := reserved[msg.ID]; taken {
+			continue
+		}
+		if reserved == nil {
+			reserved = make(map[string]struct{})
+			group.inFlight[consumerID] = reserved
+		}
+		reserved[msg.ID] = struct{}{}
+		return msg
+	}
+	return nil
+}
+
+// ReleaseBroadcastMessage gives back a message reserved by ConsumeBroadcast
+// without moving the cursor, the consumer gets it again
+func (cq *ChannelQueue) ReleaseBroadcastMessage(groupID, consumerID, messageID string) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if group, exists := cq.consumerGroups[groupID]; exists && group.inFlight != nil {
+		delete(group.inFlight[consumerID], messageID)
+	}
+}
+
+// AdvanceConsumerCursor moves a broadcast consumer past the message it
+// reserved. The group position follows the slowest consumer, messages in
+// [oldMin, newMin) having been read by every consumer of the group.
+func (cq *ChannelQueue) AdvanceConsumerCursor(groupID, consumerID, messageID string, position int64) (oldMin, newMin int64) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	group, exists := cq.consumerGroups[groupID]
+	if !exists || group.cursors == nil {
+		return 0, 0
+	}
+
+	delete(group.inFlight[consumerID], messageID)
+	if position > group.cursors[consumerID] {
+		group.cursors[consumerID] = position
+	}
+
+	oldMin = group.Position
+	newMin = int64(math.MaxInt64)
+	for _, cursor := range group.cursors {
+		if cursor < newMin {
+			newMin = cursor
+		}
+	}
+
+	if newMin <= oldMin {
+		return oldMin, oldMin
+	}
+
+	group.Position = newMin
+	return oldMin, newMin
+}
+
 func (cq *ChannelQueue) AddSubscriber(handler MessageHandler) {
 	cq.mu.Lock()
 	defer cq.mu.Unlock()
@@ -438,13 +797,22 @@ func (cq *ChannelQueue) processMessages() {
 					// Notify subscribers
 					cq.mu.RLock()
 					subscribers := cq.subscribers
+					guard := cq.guard
 					cq.mu.RUnlock()
 
-					for _, handler := range subscribers {
-						// Clone the message for each subscriber to avoid race conditions
-						msgCopy := *m
-						if err := handler(&msgCopy); err != nil {
-							cq.handleDeliveryError(&msgCopy, handler, err)
+					for i, handler := range subscribers {
+						// Each subscriber gets its own envelope over the shared payload
+						env := m.Envelope()
+						err := guard.Deliver(strconv.Itoa(i), cq.domainName, cq.queue.Name, handler, env)
+						switch {
+						case err == nil, errors.Is(err, ErrSubscriberBusy), errors.Is(err, ErrSubscriberQuarantined):
+							ReleaseEnvelope(env)
+						case errors.Is(err, ErrHandlerTimeout):
+							// the handler still holds the envelope
+							cq.handleDeliveryError(m.Envelope(), handler, err)
+						default:
+							// the retry queue may keep the envelope
+							cq.handleDeliveryError(env, handler, err)
 						}
 					}
 				}(msg)
@@ -462,6 +830,26 @@ func (cq *ChannelQueue) processMessages() {
 func (cq *ChannelQueue) handleDeliveryError(msg *Message, handler MessageHandler, err error) {
 	log.Printf("Error handling message %s: %v", msg.ID, err)
 
+	// Keep a sample of the failure, retries included
+	retryCount := 0
+	if retryInfo, ok := msg.Metadata["retry_info"].(*MessageWithRetry); ok {
+		retryCount = retryInfo.RetryCount
+	}
+	cq.failures.Record(msg, err, retryCount)
+	cq.mu.RLock()
+	onFailure := cq.onFailure
+	cq.mu.RUnlock()
+	if onFailure != nil {
+		onFailure(msg, err)
+	}
+
+	// A rejection is the consumer's call, not a failure worth retrying or
+	// opening the circuit for
+	if errors.Is(err, ErrMessageRejected) {
+		cq.deadLetter(msg, DeadLetterRejected, err, retryCount+1)
+		return
+	}
+
 	// If circuit breaker is enabled, record the failure
 	if cq.circuitBreaker != nil {
 		cq.circuitBreaker.mu.Lock()
@@ -503,7 +891,7 @@ func (cq *ChannelQueue) handleDeliveryError(msg *Message, handler MessageHandler
 		// Check if the maximum number of retries has been reached
 		if cq.queue.Config.RetryConfig.MaxRetries > 0 &&
 			retryInfo.RetryCount > cq.queue.Config.RetryConfig.MaxRetries {
-			// Log max retries reached
+			cq.deadLetter(msg, DeadLetterExhaustedRetries, err, retryInfo.RetryCount)
 			return
 		}
 
@@ -512,10 +900,7 @@ func (cq *ChannelQueue) handleDeliveryError(msg *Message, handler MessageHandler
 		retryInfo.NextRetryAt = time.Now().Add(delay)
 
 		// update metadata
-		if msg.Metadata == nil {
-			msg.Metadata = make(map[string]interface{})
-		}
-		msg.Metadata["retry_info"] = retryInfo
+		msg.WritableMetadata()["retry_info"] = retryInfo
 
 		// Add to retry queue
 		select {
@@ -524,6 +909,21 @@ func (cq *ChannelQueue) handleDeliveryError(msg *Message, handler MessageHandler
 		default:
 			// Full, should log
 		}
+		return
+	}
+
+	// Without retries the first failure is the last one
+	cq.deadLetter(msg, DeadLetterExhaustedRetries, err, 1)
+}
+
+// deadLetter hands a message the queue gives up on to the dead-letter
+// handler, if any
+func (cq *ChannelQueue) deadLetter(msg *Message, reason DeadLetterReason, err error, attempts int) {
+	cq.mu.RLock()
+	onDeadLetter := cq.onDeadLetter
+	cq.mu.RUnlock()
+	if onDeadLetter != nil {
+		onDeadLetter(msg, reason, err, attempts)
 	}
 }
 
@@ -573,7 +973,13 @@ func (cq *ChannelQueue) processRetries() {
 			for _, retry := range pendingRetries {
 				if now.After(retry.NextRetryAt) {
 					// Retry
+					// retries run aside from the workers, only panics are guarded
 					go func(r *MessageWithRetry) {
+						defer func() {
+							if p := recover(); p != nil {
+								cq.handleDeliveryError(r.Message, r.Handler, fmt.Errorf("%w: %v", ErrHandlerPanic, p))
+							}
+						}()
 						if err := r.Handler(r.Message); err != nil {
 							// Failure, requeue for retry if possible
 							cq.handleDeliveryError(r.Message, r.Handler, err)
@@ -590,6 +996,41 @@ func (cq *ChannelQueue) processRetries() {
 	}
 }
 
+// SetSubscriberPolicy bounds the time the subscribers take and when they
+// are quarantined
+func (cq *ChannelQueue) SetSubscriberPolicy(policy SubscriberPolicy) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+	cq.guard = NewSubscriberGuard(policy, cq.logger)
+}
+
+// SetFailureHandler is called with every handler failure, retries
+// included, before the message is retried
+func (cq *ChannelQueue) SetFailureHandler(handler func(msg *Message, err error)) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+	cq.onFailure = handler
+}
+
+// SetDeadLetterHandler is called with the messages given up on, rejected
+// by a subscriber or failing after their last retry
+func (cq *ChannelQueue) SetDeadLetterHandler(handler func(msg *Message, reason DeadLetterReason, err error, attempts int)) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+	cq.onDeadLetter = handler
+}
+
+// GetDeliveryFailures returns the sampled handler failures, newest first,
+// and the total number of failures seen by the queue
+func (cq *ChannelQueue) GetDeliveryFailures() ([]DeliveryFailure, int64) {
+	return cq.failures.Samples(), cq.failures.Total()
+}
+
+// PurgeDeliveryFailures drops the sampled failures matching
+func (cq *ChannelQueue) PurgeDeliveryFailures(match func(DeliveryFailure) bool) int {
+	return cq.failures.Purge(match)
+}
+
 func (cq *ChannelQueue) GetBufferStats() (currentSize int, capacity int) {
 	return len(cq.messages), cq.bufferSize
 }
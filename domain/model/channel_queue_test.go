@@ -0,0 +1,348 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceProvider serves messages whose index is their position in the slice
+type sliceProvider struct {
+	messages []*Message
+}
+
+func (p *sliceProvider) GetMessagesAfterIndex(ctx context.Context, domainName, queueName string, startIndex int64, limit int) ([]*Message, error) {
+	result := []*Message{}
+	for i := int(startIndex); i < len(p.messages) && len(result) < limit; i++ {
+		result = append(result, p.messages[i])
+	}
+	return result, nil
+}
+
+func TestChannelQueueBroadcastGroup(t *testing.T) {
+	provider := &sliceProvider{}
+	for i := 0; i < 3; i++ {
+		provider.messages = append(provider.messages, &Message{ID: fmt.Sprintf("msg-%d", i)})
+	}
+
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+
+	require.NoError(t, cq.AddConsumerGroup("audit", 0))
+	cq.SetGroupDeliveryMode("audit", GroupDeliveryBroadcast)
+
+	// both consumers see the first message
+	msgA, err := cq.ConsumeBroadcast(context.Background(), "audit", "a", 50*time.Millisecond)
+	require.NoError(t, err)
+	msgB, err := cq.ConsumeBroadcast(context.Background(), "audit", "b", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-0", msgA.ID)
+	assert.Equal(t, "msg-0", msgB.ID)
+
+	// the group only moves once the slowest consumer has read the message
+	oldMin, newMin := cq.AdvanceConsumerCursor("audit", "a", "msg-0", 1)
+	assert.Equal(t, oldMin, newMin)
+
+	oldMin, newMin = cq.AdvanceConsumerCursor("audit", "b", "msg-0", 1)
+	assert.Equal(t, int64(0), oldMin)
+	assert.Equal(t, int64(1), newMin)
+
+	msgA, _ = cq.ConsumeBroadcast(context.Background(), "audit", "a", 50*time.Millisecond)
+	assert.Equal(t, "msg-1", msgA.ID)
+
+	// a consumer leaving the group no longer holds it back
+	cq.AdvanceConsumerCursor("audit", "a", "msg-1", 3)
+	cq.SyncGroupConsumers("audit", []string{"a"})
+	_, newMin = cq.AdvanceConsumerCursor("audit", "a", "msg-2", 3)
+	assert.Equal(t, int64(3), newMin)
+
+	msgA, err = cq.ConsumeBroadcast(context.Background(), "audit", "a", 20*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Nil(t, msgA, "times out once the queue is drained")
+}
+
+func TestChannelQueueBroadcastConcurrentConsumes(t *testing.T) {
+	provider := &sliceProvider{}
+	for i := 0; i < 3; i++ {
+		provider.messages = append(provider.messages, &Message{ID: fmt.Sprintf("msg-%d", i)})
+	}
+
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+
+	require.NoError(t, cq.AddConsumerGroup("audit", 0))
+	cq.SetGroupDeliveryMode("audit", GroupDeliveryBroadcast)
+
+	// consumes racing before the cursor moves get different messages
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := cq.ConsumeBroadcast(context.Background(), "audit", "a", 200*time.Millisecond)
+			if assert.NoError(t, err) && assert.NotNil(t, msg) {
+				mu.Lock()
+				seen[msg.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 3)
+
+	// a released message is handed out again
+	cq.ReleaseBroadcastMessage("audit", "a", "msg-1")
+	msg, err := cq.ConsumeBroadcast(context.Background(), "audit", "a", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-1", msg.ID)
+}
+
+func TestParseGroupDeliveryMode(t *testing.T) {
+	mode, err := ParseGroupDeliveryMode("")
+	require.NoError(t, err)
+	assert.Equal(t, GroupDeliveryShared, mode)
+
+	mode, err = ParseGroupDeliveryMode("broadcast")
+	require.NoError(t, err)
+	assert.Equal(t, GroupDeliveryBroadcast, mode)
+
+	_, err = ParseGroupDeliveryMode("fanout")
+	assert.ErrorIs(t, err, ErrInvalidDeliveryMode)
+}
+
+// gatedProvider blocks every fetch until release is closed
+type gatedProvider struct {
+	sliceProvider
+	release chan struct{}
+}
+
+func (p *gatedProvider) GetMessagesAfterIndex(ctx context.Context, domainName, queueName string, startIndex int64, limit int) ([]*Message, error) {
+	<-p.release
+	return p.sliceProvider.GetMessagesAfterIndex(ctx, domainName, queueName, startIndex, limit)
+}
+
+func TestChannelQueueGroupPriority(t *testing.T) {
+	provider := &gatedProvider{release: make(chan struct{})}
+	provider.messages = []*Message{{ID: "msg-0"}}
+
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+
+	require.NoError(t, cq.AddConsumerGroup("batch", 0))
+	require.NoError(t, cq.AddConsumerGroup("realtime", 0))
+	cq.SetGroupPriority("realtime", 10)
+
+	// leave a single fill slot free
+	cq.fetchMu.Lock()
+	cq.activeFills = MaxConcurrentGroupFills - 1
+	cq.fetchMu.Unlock()
+
+	// both requests land in the same tick
+	cq.mu.Lock()
+	cq.consumerGroups["batch"].pending = 1
+	cq.consumerGroups["realtime"].pending = 1
+	cq.mu.Unlock()
+	cq.notify()
+
+	// the high priority group takes the slot, the other one waits
+	assert.Eventually(t, func() bool {
+		cq.fetchMu.Lock()
+		defer cq.fetchMu.Unlock()
+		return cq.pendingFetches["realtime"]
+	}, time.Second, 5*time.Millisecond)
+
+	cq.mu.RLock()
+	batch := cq.consumerGroups["batch"]
+	assert.Equal(t, 1, batch.pending)
+	cq.mu.RUnlock()
+
+	close(provider.release)
+	cq.fetchMu.Lock()
+	cq.activeFills -= MaxConcurrentGroupFills - 1
+	cq.fetchMu.Unlock()
+	cq.notify()
+
+	msg, err := cq.ConsumeMessage(context.Background(), "realtime", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-0", msg.ID)
+
+	// the waiting group is served once the slot frees up
+	msg, err = cq.ConsumeMessage(context.Background(), "batch", time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, "msg-0", msg.ID)
+}
+
+func TestChannelQueueRequestWakesCommandWorker(t *testing.T) {
+	provider := &sliceProvider{messages: []*Message{{ID: "msg-0"}, {ID: "msg-1"}}}
+
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+
+	require.NoError(t, cq.AddConsumerGroup("workers", 0))
+
+	// nothing is fetched until a group asks for messages
+	msg, err := cq.ConsumeMessage(context.Background(), "workers", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+
+	require.NoError(t, cq.RequestMessages(context.Background(), "workers", 2))
+	msg, err = cq.ConsumeMessage(context.Background(), "workers", time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, "msg-0", msg.ID)
+}
+
+func TestChannelQueueRequestCappedByPrefetch(t *testing.T) {
+	provider := &sliceProvider{}
+	for i := 0; i < 10; i++ {
+		provider.messages = append(provider.messages, &Message{ID: fmt.Sprintf("msg-%d", i)})
+	}
+
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+
+	require.NoError(t, cq.AddConsumerGroup("workers", 0))
+	cq.SetGroupPrefetch("workers", 3)
+	group := cq.consumerGroups["workers"]
+
+	// a request larger than the credit only buffers the prefetch
+	require.NoError(t, cq.RequestMessages(context.Background(), "workers", 10))
+	assert.Eventually(t, func() bool { return len(group.Messages) == 3 }, time.Second, 5*time.Millisecond)
+
+	// without credit left the request is dropped
+	require.NoError(t, cq.RequestMessages(context.Background(), "workers", 10))
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, group.Messages, 3)
+
+	cq.SetGroupPrefetch("workers", 0)
+	cq.mu.RLock()
+	assert.Equal(t, DefaultGroupPrefetch, group.Prefetch)
+	cq.mu.RUnlock()
+}
+
+// BenchmarkChannelQueueSubscriberFanOut delivers each message to many
+// subscribers, envelopes sharing the payload keep allocations per message flat
+func BenchmarkChannelQueueSubscriberFanOut(b *testing.B) {
+	for _, subscribers := range []int{16, 128} {
+		b.Run(fmt.Sprintf("subscribers=%d", subscribers), func(b *testing.B) {
+			cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, &sliceProvider{})
+			defer cq.Stop()
+
+			var delivered sync.WaitGroup
+			for range subscribers {
+				cq.AddSubscriber(func(m *Message) error {
+					delivered.Done()
+					return nil
+				})
+			}
+			cq.Start(context.Background())
+
+			msg := &Message{ID: "msg-1", Payload: make([]byte, 4096)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				delivered.Add(subscribers)
+				if err := cq.Enqueue(context.Background(), msg); err != nil {
+					b.Fatal(err)
+				}
+				delivered.Wait()
+			}
+		})
+	}
+}
+
+func TestChannelQueueCircuitOpen(t *testing.T) {
+	queue := &Queue{Name: "orders", DomainName: "shop", Config: QueueConfig{
+		CircuitBreakerEnabled: true,
+		CircuitBreakerConfig:  &CircuitBreakerConfig{OpenTimeout: 50 * time.Millisecond, FallbackQueue: "orders-fallback"},
+	}}
+	cq := NewChannelQueue(context.Background(), nil, queue, 10, &sliceProvider{})
+	defer cq.Stop()
+
+	assert.False(t, cq.CircuitOpen())
+
+	cq.circuitBreaker.State = CircuitOpen
+	cq.circuitBreaker.NextAttempt = time.Now().Add(50 * time.Millisecond)
+	assert.True(t, cq.CircuitOpen())
+
+	// half-open once the timeout has passed
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, cq.CircuitOpen())
+	assert.Equal(t, CircuitHalfOpen, cq.circuitBreaker.State)
+
+	message := &Message{ID: "1"}
+	assert.Equal(t, "orders-fallback", queue.Fallback(message))
+	message.Metadata = map[string]any{MetadataDivertedFrom: "payments"}
+	assert.Empty(t, queue.Fallback(message), "diverted messages are not diverted again")
+
+	var missing *Queue
+	assert.Empty(t, missing.Fallback(&Message{}))
+}
+
+// blockingProvider holds fetches until their context is done
+type blockingProvider struct {
+	started chan struct{}
+}
+
+func (p *blockingProvider) GetMessagesAfterIndex(ctx context.Context, domainName, queueName string, startIndex int64, limit int) ([]*Message, error) {
+	p.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestChannelQueueConsumerCancellation(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}, 1)}
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, provider)
+	defer cq.Stop()
+	require.NoError(t, cq.AddConsumerGroup("workers", 0))
+
+	// the wait ends as soon as the consumer leaves
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cq.RequestMessages(ctx, "workers", 5))
+	<-provider.started
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	msg, err := cq.ConsumeMessage(ctx, "workers", 10*time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, msg)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// and so does the fetch made on its behalf, freeing its fill slot
+	assert.Eventually(t, func() bool {
+		cq.fetchMu.Lock()
+		defer cq.fetchMu.Unlock()
+		return cq.activeFills == 0 && !cq.pendingFetches["workers"]
+	}, time.Second, 5*time.Millisecond)
+
+	// a request whose consumer left before a slot freed up is dropped
+	cq.fetchMu.Lock()
+	cq.activeFills = MaxConcurrentGroupFills
+	cq.fetchMu.Unlock()
+	gone, leave := context.WithCancel(context.Background())
+	require.NoError(t, cq.RequestMessages(gone, "workers", 5))
+	leave()
+	cq.fetchMu.Lock()
+	cq.activeFills = 0
+	cq.fetchMu.Unlock()
+	cq.notify()
+
+	select {
+	case <-provider.started:
+		t.Fatal("fetch started for a consumer that left")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cq.mu.RLock()
+	assert.Zero(t, cq.consumerGroups["workers"].pending)
+	cq.mu.RUnlock()
+}
@@ -0,0 +1,22 @@
+package model
+
+// ClusterNode is a broker node clients can connect to
+type ClusterNode struct {
+	ID      string `json:"id"`
+	Address string `json:"address,omitempty"` // base URL of its HTTP API
+}
+
+// QueueOwner tells which node holds a queue and serves its data plane
+type QueueOwner struct {
+	Domain string `json:"domain"`
+	Queue  string `json:"queue"`
+	Node   string `json:"node"`
+}
+
+// ClusterMetadata lets smart clients connect to the node owning each queue
+type ClusterMetadata struct {
+	Clustered bool          `json:"clustered"`
+	Node      string        `json:"node"` // node answering
+	Nodes     []ClusterNode `json:"nodes"`
+	Queues    []QueueOwner  `json:"queues"`
+}
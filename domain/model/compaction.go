@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HeaderCompactionKey carries the compaction key of a message, it takes
+// precedence over the payload field of the queue
+const HeaderCompactionKey = "X-Compaction-Key"
+
+// CompactionConfig keeps only the latest message per key in a queue, for
+// queues holding a current state (the last status of each device) rather
+// than a history. Messages without key are kept.
+type CompactionConfig struct {
+	// Key is the payload field holding the compaction key, dotted paths
+	// reach nested objects (device.id)
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+}
+
+// Validate checks the compaction of a queue, sealed fields can't be keys as
+// equal values are sealed differently
+func (c *CompactionConfig) Validate(config QueueConfig) error {
+	if c.Key == "" {
+		return nil
+	}
+	if strings.Contains(c.Key, "..") || strings.HasPrefix(c.Key, ".") || strings.HasSuffix(c.Key, ".") {
+		return fmt.Errorf("%w: invalid key %q", ErrInvalidCompaction, c.Key)
+	}
+	topField, _, _ := strings.Cut(c.Key, ".")
+	if slices.Contains(config.EncryptedFields, topField) {
+		return fmt.Errorf("%w: key field %s is encrypted", ErrInvalidCompaction, topField)
+	}
+	return nil
+}
+
+// CompactionKey returns the key a message published to q supersedes older
+// messages with, empty when q isn't compacted or the message has no key
+func (q *Queue) CompactionKey(message *Message) string {
+	if q == nil || q.Config.Compaction == nil {
+		return ""
+	}
+	if key := message.Headers[HeaderCompactionKey]; key != "" {
+		return key
+	}
+	if q.Config.Compaction.Key == "" {
+		return ""
+	}
+	key, _ := payloadField(message.Payload, q.Config.Compaction.Key)
+	return key
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueCompactionKey(t *testing.T) {
+	queue := &Queue{Name: "devices", Config: QueueConfig{Compaction: &CompactionConfig{Key: "device.id"}}}
+
+	assert.Equal(t, "d-1", queue.CompactionKey(&Message{Payload: []byte(`{"device":{"id":"d-1"}}`)}))
+	assert.Equal(t, "42", queue.CompactionKey(&Message{Payload: []byte(`{"device":{"id":42}}`)}))
+	assert.Equal(t, "d-2", queue.CompactionKey(&Message{
+		Payload: []byte(`{"device":{"id":"d-1"}}`),
+		Headers: map[string]string{HeaderCompactionKey: "d-2"},
+	}), "the header takes precedence")
+	assert.Empty(t, queue.CompactionKey(&Message{Payload: []byte(`{"status":"up"}`)}))
+
+	assert.Empty(t, (&Queue{Name: "orders"}).CompactionKey(&Message{Headers: map[string]string{HeaderCompactionKey: "d-1"}}),
+		"queues without compaction keep every message")
+}
+
+func TestCompactionConfigValidate(t *testing.T) {
+	assert.NoError(t, (&CompactionConfig{}).Validate(QueueConfig{}))
+	assert.NoError(t, (&CompactionConfig{Key: "device.id"}).Validate(QueueConfig{EncryptedFields: []string{"owner"}}))
+	assert.ErrorIs(t, (&CompactionConfig{Key: "device..id"}).Validate(QueueConfig{}), ErrInvalidCompaction)
+	assert.ErrorIs(t, (&CompactionConfig{Key: "device.id"}).Validate(QueueConfig{EncryptedFields: []string{"device"}}), ErrInvalidCompaction)
+}
@@ -1,10 +1,43 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"slices"
+	"sort"
 	"time"
 )
 
+// GroupDeliveryMode defines how messages are spread among the consumers of a group
+type GroupDeliveryMode string
+
+const (
+	// GroupDeliveryShared load-balances messages: each one goes to a single consumer
+	GroupDeliveryShared GroupDeliveryMode = "shared"
+
+	// GroupDeliveryBroadcast delivers every message to each consumer of the group
+	GroupDeliveryBroadcast GroupDeliveryMode = "broadcast"
+)
+
+var ErrInvalidDeliveryMode = errors.New("invalid delivery mode")
+
+var ErrInvalidPrefetch = errors.New("prefetch must not be negative")
+
+// DefaultGroupPrefetch is the number of messages buffered ahead of the
+// consumers of a group that did not set its own prefetch
+const DefaultGroupPrefetch = 5
+
+// ParseGroupDeliveryMode validates a delivery mode, empty meaning shared
+func ParseGroupDeliveryMode(mode string) (GroupDeliveryMode, error) {
+	switch GroupDeliveryMode(mode) {
+	case "", GroupDeliveryShared:
+		return GroupDeliveryShared, nil
+	case GroupDeliveryBroadcast:
+		return GroupDeliveryBroadcast, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidDeliveryMode, mode)
+}
+
 // Queue consumers group
 type ConsumerGroup struct {
 	DomainName   string
@@ -12,10 +45,33 @@ type ConsumerGroup struct {
 	GroupID      string
 	Position     int64
 	CreatedAt    time.Time
-	ConsumerIDs  []string      // Consumers
-	TTL          time.Duration // Time to live
-	LastActivity time.Time     // Last activity (any)
-	MessageCount int           // Messages waiting for acknowledgment
+	ConsumerIDs  []string          // Consumers
+	TTL          time.Duration     // Inactivity before cleanup, 0 follows the cleanup policy
+	LastActivity time.Time         // Last activity (any)
+	MessageCount int               // Messages waiting for acknowledgment
+	DeliveryMode GroupDeliveryMode // Shared (default) or broadcast among consumers
+	Priority     int               // Higher priority groups are filled first under pressure
+	Prefetch     int               // Messages buffered ahead of consumers, 0 means DefaultGroupPrefetch
+
+	// CleanupAction overrides the action of the cleanup policy, empty follows it
+	CleanupAction GroupCleanupAction
+
+	// Ephemeral groups expire after DefaultEphemeralGroupTTL of inactivity,
+	// or their TTL, and are deleted rather than archived
+	Ephemeral bool
+
+	// ConsumerStats is a snapshot of per-consumer counters, see Snapshot
+	ConsumerStats []ConsumerStats
+
+	consumerStats map[string]*ConsumerStats // live counters by consumer ID
+}
+
+// ConsumerStats tracks the deliveries of a single consumer within a group
+type ConsumerStats struct {
+	ConsumerID string
+	Delivered  int64
+	Acked      int64
+	LastSeen   time.Time // last poll or delivery
 }
 
 func (cg *ConsumerGroup) UpdatePosition(newPosition int64) {
@@ -25,6 +81,14 @@ func (cg *ConsumerGroup) UpdatePosition(newPosition int64) {
 	}
 }
 
+// EffectivePrefetch returns the group prefetch, falling back to the default
+func (cg *ConsumerGroup) EffectivePrefetch() int {
+	if cg.Prefetch > 0 {
+		return cg.Prefetch
+	}
+	return DefaultGroupPrefetch
+}
+
 func (cg *ConsumerGroup) GetPosition() int64 {
 	return cg.Position
 }
@@ -40,12 +104,14 @@ func (cg *ConsumerGroup) AddConsumer(consumerID string) {
 		cg.ConsumerIDs = append(cg.ConsumerIDs, consumerID)
 		cg.LastActivity = time.Now()
 	}
+	cg.statsFor(consumerID).LastSeen = time.Now()
 }
 
 func (cg *ConsumerGroup) RemoveConsumer(consumerID string) bool {
 	for i, id := range cg.ConsumerIDs {
 		if id == consumerID {
 			cg.ConsumerIDs = append(cg.ConsumerIDs[:i], cg.ConsumerIDs[i+1:]...)
+			delete(cg.consumerStats, consumerID)
 			cg.LastActivity = time.Now()
 			return len(cg.ConsumerIDs) == 0 // returns true if last consumer
 		}
@@ -66,3 +132,56 @@ func (cg *ConsumerGroup) IsExpired(maxAge time.Duration) bool {
 func (cg *ConsumerGroup) UpdateActivity() {
 	cg.LastActivity = time.Now()
 }
+
+// Heartbeat records the liveness signal of a consumer polling between
+// deliveries, the group only when consumerID is empty
+func (cg *ConsumerGroup) Heartbeat(consumerID string) error {
+	if consumerID != "" {
+		if !slices.Contains(cg.ConsumerIDs, consumerID) {
+			return ErrConsumerNotInGroup
+		}
+		cg.statsFor(consumerID).LastSeen = time.Now()
+	}
+	cg.UpdateActivity()
+	return nil
+}
+
+// Per-consumer statistics
+func (cg *ConsumerGroup) statsFor(consumerID string) *ConsumerStats {
+	if cg.consumerStats == nil {
+		cg.consumerStats = make(map[string]*ConsumerStats)
+	}
+	stats, exists := cg.consumerStats[consumerID]
+	if !exists {
+		stats = &ConsumerStats{ConsumerID: consumerID}
+		cg.consumerStats[consumerID] = stats
+	}
+	return stats
+}
+
+func (cg *ConsumerGroup) RecordDelivery(consumerID string) {
+	stats := cg.statsFor(consumerID)
+	stats.Delivered++
+	stats.LastSeen = time.Now()
+}
+
+func (cg *ConsumerGroup) RecordAck(consumerID string) {
+	cg.statsFor(consumerID).Acked++
+}
+
+// Snapshot returns a copy of the group with the live counters in
+// ConsumerStats, sorted by consumer ID, the group itself is left untouched
+func (cg *ConsumerGroup) Snapshot() *ConsumerGroup {
+	snapshot := *cg
+	snapshot.ConsumerIDs = slices.Clone(cg.ConsumerIDs)
+	snapshot.consumerStats = nil
+
+	snapshot.ConsumerStats = make([]ConsumerStats, 0, len(cg.consumerStats))
+	for _, stats := range cg.consumerStats {
+		snapshot.ConsumerStats = append(snapshot.ConsumerStats, *stats)
+	}
+	sort.Slice(snapshot.ConsumerStats, func(i, j int) bool {
+		return snapshot.ConsumerStats[i].ConsumerID < snapshot.ConsumerStats[j].ConsumerID
+	})
+	return &snapshot
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerGroupConsumerStats(t *testing.T) {
+	group := &ConsumerGroup{GroupID: "workers"}
+
+	group.AddConsumer("worker-b")
+	group.AddConsumer("worker-a")
+
+	group.RecordDelivery("worker-a")
+	group.RecordDelivery("worker-a")
+	group.RecordAck("worker-a")
+
+	snapshot := group.Snapshot()
+	require.Len(t, snapshot.ConsumerStats, 2)
+	assert.Empty(t, group.ConsumerStats, "the group itself is left untouched")
+
+	// sorted by consumer ID
+	assert.Equal(t, "worker-a", snapshot.ConsumerStats[0].ConsumerID)
+	assert.Equal(t, int64(2), snapshot.ConsumerStats[0].Delivered)
+	assert.Equal(t, int64(1), snapshot.ConsumerStats[0].Acked)
+
+	// registered but idle consumer is still reported with its last poll
+	assert.Equal(t, "worker-b", snapshot.ConsumerStats[1].ConsumerID)
+	assert.Zero(t, snapshot.ConsumerStats[1].Delivered)
+	assert.False(t, snapshot.ConsumerStats[1].LastSeen.IsZero())
+
+	group.RemoveConsumer("worker-b")
+	assert.Len(t, group.Snapshot().ConsumerStats, 1)
+	assert.Len(t, snapshot.ConsumerIDs, 2, "snapshots don't follow the group")
+}
+
+func TestConsumerGroupHeartbeat(t *testing.T) {
+	stale := time.Now().Add(-5 * time.Hour)
+	group := &ConsumerGroup{GroupID: "reports", LastActivity: stale}
+	group.AddConsumer("nightly")
+	group.LastActivity = stale
+	assert.True(t, group.IsExpired(4*time.Hour))
+
+	require.NoError(t, group.Heartbeat(""))
+	assert.False(t, group.IsExpired(4*time.Hour), "a heartbeat keeps the group from the stale cleanup")
+
+	seen := group.statsFor("nightly").LastSeen
+	time.Sleep(time.Millisecond)
+	require.NoError(t, group.Heartbeat("nightly"))
+	assert.True(t, group.statsFor("nightly").LastSeen.After(seen))
+
+	assert.ErrorIs(t, group.Heartbeat("intruder"), ErrConsumerNotInGroup)
+	assert.Len(t, group.Snapshot().ConsumerStats, 1, "unknown consumers are not registered by a heartbeat")
+}
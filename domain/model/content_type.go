@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// HeaderContentType is the header holding the media type of the payload
+const HeaderContentType = "Content-Type"
+
+// Media types of the payloads published without a Content-Type
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeText   = "text/plain; charset=utf-8"
+	ContentTypeBinary = "application/octet-stream"
+)
+
+var ErrPayloadNotJSON = errors.New("payload is not JSON")
+
+// ContentType returns the media type the message was published with, or
+// the one of its payload when the producer didn't tell: JSON, UTF-8 text or
+// binary
+func (m *Message) ContentType() string {
+	for name, value := range m.Headers {
+		if value != "" && strings.EqualFold(name, HeaderContentType) {
+			return value
+		}
+	}
+	switch {
+	case json.Valid(m.Payload):
+		return ContentTypeJSON
+	case utf8.Valid(m.Payload):
+		return ContentTypeText
+	}
+	return ContentTypeBinary
+}
+
+// DecodePayload unmarshals a JSON payload into v, whatever the Content-Type
+// it was published with
+func (m *Message) DecodePayload(v any) error {
+	if !json.Valid(m.Payload) {
+		return fmt.Errorf("%w: %s", ErrPayloadNotJSON, m.ContentType())
+	}
+	return json.Unmarshal(m.Payload, v)
+}
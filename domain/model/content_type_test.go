@@ -0,0 +1,34 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageContentType(t *testing.T) {
+	assert.Equal(t, "application/cloudevents+json", (&Message{
+		Payload: []byte(`{}`),
+		Headers: map[string]string{"content-type": "application/cloudevents+json"},
+	}).ContentType())
+	assert.Equal(t, ContentTypeJSON, (&Message{Payload: []byte(`[1, 2]`)}).ContentType())
+	assert.Equal(t, ContentTypeText, (&Message{Payload: []byte("héllo")}).ContentType())
+	assert.Equal(t, ContentTypeBinary, (&Message{Payload: []byte{0xff, 0xfe}}).ContentType())
+}
+
+func TestMessageDecodePayload(t *testing.T) {
+	var order struct {
+		ID     string  `json:"id"`
+		Amount float64 `json:"amount"`
+	}
+	msg := &Message{Payload: []byte(`{"id":"o1","amount":9.5}`), Headers: map[string]string{"Content-Type": "text/plain"}}
+	require.NoError(t, msg.DecodePayload(&order))
+	assert.Equal(t, "o1", order.ID)
+	assert.Equal(t, 9.5, order.Amount)
+
+	err := (&Message{Payload: []byte("not json")}).DecodePayload(&order)
+	assert.True(t, errors.Is(err, ErrPayloadNotJSON))
+	assert.Contains(t, err.Error(), ContentTypeText)
+}
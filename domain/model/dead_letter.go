@@ -0,0 +1,134 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DeadLetterReason tells why a message was dead-lettered
+type DeadLetterReason string
+
+const (
+	// DeadLetterExhaustedRetries: the subscribers kept failing, retries included
+	DeadLetterExhaustedRetries DeadLetterReason = "exhausted-retries"
+	// DeadLetterExpired: the message outlived the TTL of its queue
+	DeadLetterExpired DeadLetterReason = "expired"
+	// DeadLetterRejected: a subscriber returned ErrMessageRejected
+	DeadLetterRejected DeadLetterReason = "rejected-by-consumer"
+	// DeadLetterSchemaInvalid: the payload didn't match the domain schema
+	DeadLetterSchemaInvalid DeadLetterReason = "schema-invalid"
+)
+
+var deadLetterReasons = []DeadLetterReason{
+	DeadLetterExhaustedRetries,
+	DeadLetterExpired,
+	DeadLetterRejected,
+	DeadLetterSchemaInvalid,
+}
+
+var (
+	ErrInvalidDeadLetter = errors.New("invalid dead-letter config")
+
+	// ErrMessageRejected is returned by the subscribers refusing a message
+	// for good, it is dead-lettered without being retried
+	ErrMessageRejected = errors.New("message rejected by consumer")
+)
+
+// Headers of the dead-lettered messages, for the consumers of the
+// dead-letter queue to triage them without looking at the payload
+const (
+	HeaderDeadLetterReason     = "X-Dead-Letter-Reason"
+	HeaderDeadLetterQueue      = "X-Dead-Letter-Queue"
+	HeaderDeadLetterRoutingKey = "X-Dead-Letter-Routing-Key"
+	HeaderDeadLetterError      = "X-Dead-Letter-Error"
+	HeaderDeadLetterAttempts   = "X-Dead-Letter-Attempts"
+	HeaderDeadLetterTime       = "X-Dead-Letter-Time"
+)
+
+// MetadataDeadLetter holds the DeadLetterInfo of a dead-lettered message,
+// dead-lettered messages are never dead-lettered again
+const MetadataDeadLetter = "deadLetter"
+
+// DeadLetterConfig sends the messages a queue gives up on to another queue
+// of the domain
+type DeadLetterConfig struct {
+	// Queue receives the dead-lettered messages
+	Queue string `yaml:"queue" json:"queue"`
+
+	// RoutingKey tags the messages in the dead-letter queue, several queues
+	// sharing one can tell theirs apart (default: the queue name)
+	RoutingKey string `yaml:"routingKey,omitempty" json:"routingKey,omitempty"`
+
+	// Reasons selects the reasons dead-lettered, all of them when empty
+	Reasons []DeadLetterReason `yaml:"reasons,omitempty" json:"reasons,omitempty"`
+}
+
+// Validate checks the dead-letter config of queueName
+func (d *DeadLetterConfig) Validate(queueName string) error {
+	if d.Queue == "" {
+		return fmt.Errorf("%w: dead-letter queue is required", ErrInvalidDeadLetter)
+	}
+	if d.Queue == queueName {
+		return fmt.Errorf("%w: a queue can't dead-letter to itself", ErrInvalidDeadLetter)
+	}
+	for _, reason := range d.Reasons {
+		if !slices.Contains(deadLetterReasons, reason) {
+			return fmt.Errorf("%w: unknown reason %q", ErrInvalidDeadLetter, reason)
+		}
+	}
+	return nil
+}
+
+// Accepts tells whether the messages given up on for reason are dead-lettered
+func (d *DeadLetterConfig) Accepts(reason DeadLetterReason) bool {
+	return d != nil && (len(d.Reasons) == 0 || slices.Contains(d.Reasons, reason))
+}
+
+// DeadLetterInfo describes why and where from a message was dead-lettered
+type DeadLetterInfo struct {
+	Reason     DeadLetterReason `json:"reason"`
+	Queue      string           `json:"queue"`
+	RoutingKey string           `json:"routingKey"`
+	Error      string           `json:"error,omitempty"`
+	Attempts   int              `json:"attempts,omitempty"`
+	Time       time.Time        `json:"time"`
+}
+
+// Copy builds the message published to the dead-letter queue, the payload
+// and ID of message with the info in its headers and metadata. The copy
+// shares the payload of message.
+func (i DeadLetterInfo) Copy(message *Message) *Message {
+	headers := maps.Clone(message.Headers)
+	if headers == nil {
+		headers = make(map[string]string, 6)
+	}
+	headers[HeaderDeadLetterReason] = string(i.Reason)
+	headers[HeaderDeadLetterQueue] = i.Queue
+	headers[HeaderDeadLetterRoutingKey] = i.RoutingKey
+	headers[HeaderDeadLetterTime] = i.Time.UTC().Format(time.RFC3339Nano)
+	if i.Error != "" {
+		headers[HeaderDeadLetterError] = i.Error
+	}
+	if i.Attempts > 0 {
+		headers[HeaderDeadLetterAttempts] = strconv.Itoa(i.Attempts)
+	}
+
+	return &Message{
+		ID:        message.ID,
+		Topic:     message.Topic,
+		Payload:   message.Payload,
+		Headers:   headers,
+		Metadata:  map[string]any{MetadataDeadLetter: i},
+		Timestamp: message.Timestamp,
+	}
+}
+
+// DeadLettered tells whether the message comes from a dead-letter queue
+func (m *Message) DeadLettered() bool {
+	_, ok := m.Metadata[MetadataDeadLetter]
+	return ok
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterConfigValidate(t *testing.T) {
+	assert.NoError(t, (&DeadLetterConfig{Queue: "orders-dlq"}).Validate("orders"))
+	assert.NoError(t, (&DeadLetterConfig{Queue: "orders-dlq", Reasons: []DeadLetterReason{DeadLetterExpired}}).Validate("orders"))
+
+	for _, config := range []*DeadLetterConfig{
+		{},
+		{Queue: "orders"},
+		{Queue: "orders-dlq", Reasons: []DeadLetterReason{"lost"}},
+	} {
+		assert.ErrorIs(t, config.Validate("orders"), ErrInvalidDeadLetter)
+	}
+}
+
+func TestDeadLetterConfigAccepts(t *testing.T) {
+	var none *DeadLetterConfig
+	assert.False(t, none.Accepts(DeadLetterRejected))
+
+	all := &DeadLetterConfig{Queue: "dlq"}
+	assert.True(t, all.Accepts(DeadLetterSchemaInvalid))
+
+	some := &DeadLetterConfig{Queue: "dlq", Reasons: []DeadLetterReason{DeadLetterRejected}}
+	assert.True(t, some.Accepts(DeadLetterRejected))
+	assert.False(t, some.Accepts(DeadLetterExpired))
+}
+
+func TestDeadLetterInfoCopy(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	message := &Message{
+		ID:       "order-1",
+		Payload:  []byte(`{"id":1}`),
+		Headers:  map[string]string{"X-Request-ID": "req-1"},
+		Metadata: map[string]any{"queue": "orders"},
+	}
+	info := DeadLetterInfo{
+		Reason:     DeadLetterExhaustedRetries,
+		Queue:      "orders",
+		RoutingKey: "billing",
+		Error:      "timeout",
+		Attempts:   4,
+		Time:       at,
+	}
+
+	copied := info.Copy(message)
+	assert.Equal(t, "order-1", copied.ID)
+	assert.Equal(t, message.Payload, copied.Payload)
+	assert.Equal(t, map[string]string{
+		"X-Request-ID":             "req-1",
+		HeaderDeadLetterReason:     "exhausted-retries",
+		HeaderDeadLetterQueue:      "orders",
+		HeaderDeadLetterRoutingKey: "billing",
+		HeaderDeadLetterError:      "timeout",
+		HeaderDeadLetterAttempts:   "4",
+		HeaderDeadLetterTime:       "2024-03-01T12:00:00Z",
+	}, copied.Headers)
+	assert.Equal(t, info, copied.Metadata[MetadataDeadLetter])
+	assert.True(t, copied.DeadLettered())
+
+	assert.NotContains(t, message.Headers, HeaderDeadLetterReason, "the original message is left alone")
+	assert.False(t, message.DeadLettered())
+}
+
+func TestChannelQueueDeadLetters(t *testing.T) {
+	queue := &Queue{Name: "orders", DomainName: "shop", Config: QueueConfig{
+		RetryEnabled: true,
+		RetryConfig:  &RetryConfig{MaxRetries: 1, InitialDelay: time.Hour},
+	}}
+	cq := NewChannelQueue(context.Background(), nil, queue, 10, &sliceProvider{})
+	defer cq.Stop()
+
+	type deadLetter struct {
+		id       string
+		reason   DeadLetterReason
+		attempts int
+	}
+	var letters []deadLetter
+	cq.SetDeadLetterHandler(func(msg *Message, reason DeadLetterReason, err error, attempts int) {
+		letters = append(letters, deadLetter{msg.ID, reason, attempts})
+	})
+	handler := func(*Message) error { return nil }
+
+	// rejections skip the retries
+	cq.handleDeliveryError(&Message{ID: "1"}, handler, errors.Join(ErrMessageRejected, errors.New("unknown customer")))
+	require.Len(t, letters, 1)
+	assert.Equal(t, deadLetter{"1", DeadLetterRejected, 1}, letters[0])
+
+	// the other failures once the retries are exhausted
+	failed := &Message{ID: "2"}
+	cq.handleDeliveryError(failed, handler, errors.New("timeout"))
+	assert.Len(t, letters, 1, "retried first")
+	cq.handleDeliveryError(failed, handler, errors.New("timeout"))
+	require.Len(t, letters, 2)
+	assert.Equal(t, deadLetter{"2", DeadLetterExhaustedRetries, 2}, letters[1])
+}
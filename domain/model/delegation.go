@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidDelegation = errors.New("invalid delegation")
+
+const (
+	// DefaultDelegationTTL is the lifetime of a delegated token asking for none
+	DefaultDelegationTTL = 15 * time.Minute
+
+	// MaxDelegationTTL bounds the lifetime of a delegated token
+	MaxDelegationTTL = time.Hour
+)
+
+// DelegationRequest asks a service account for a token granting part of its
+// permissions, handed to downstream workers instead of the account secret
+type DelegationRequest struct {
+	// Subject names the worker the token is handed to, for audit
+	Subject string `json:"subject,omitempty"`
+
+	// Permissions granted by the token, each one held by the account
+	Permissions []string `json:"permissions"`
+
+	// TTL is the lifetime of the token, DefaultDelegationTTL when empty
+	TTL string `json:"ttl,omitempty"`
+}
+
+// Validate checks the request against the account delegating and returns
+// the lifetime of the token
+func (r DelegationRequest) Validate(parent *ServiceAccount) (time.Duration, error) {
+	if len(r.Permissions) == 0 {
+		return 0, fmt.Errorf("%w: at least one permission is required", ErrInvalidDelegation)
+	}
+	for _, permission := range r.Permissions {
+		if !parent.HasPermission(permission) {
+			return 0, fmt.Errorf("%w: %s is not granted to the service", ErrInvalidDelegation, permission)
+		}
+	}
+
+	if r.TTL == "" {
+		return DefaultDelegationTTL, nil
+	}
+	ttl, err := time.ParseDuration(r.TTL)
+	if err != nil || ttl <= 0 || ttl > MaxDelegationTTL {
+		return 0, fmt.Errorf("%w: ttl must be a duration up to %s: %q", ErrInvalidDelegation, MaxDelegationTTL, r.TTL)
+	}
+	return ttl, nil
+}
+
+// Delegation is what a delegated token grants, on behalf of a service account
+type Delegation struct {
+	ID          string    `json:"id"`
+	ServiceID   string    `json:"serviceId"`
+	Subject     string    `json:"subject,omitempty"`
+	Permissions []string  `json:"permissions"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// Scoped is the account restricted to the delegated permissions it still
+// holds, so revoking a permission of the account revokes it from its tokens
+func (s *ServiceAccount) Scoped(permissions []string) *ServiceAccount {
+	scoped := *s
+	scoped.Permissions = nil
+	for _, permission := range permissions {
+		if s.HasPermission(permission) {
+			scoped.Permissions = append(scoped.Permissions, permission)
+		}
+	}
+	return &scoped
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegationRequestValidate(t *testing.T) {
+	parent := &ServiceAccount{Permissions: []string{"publish:*", "consume:orders"}}
+
+	ttl, err := DelegationRequest{Permissions: []string{"publish:orders", "consume:orders"}}.Validate(parent)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDelegationTTL, ttl)
+
+	ttl, err = DelegationRequest{Permissions: []string{"publish:*"}, TTL: "30m"}.Validate(parent)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, ttl)
+
+	for _, request := range []DelegationRequest{
+		{},
+		{Permissions: []string{"consume:payments"}},
+		{Permissions: []string{"*"}},
+		{Permissions: []string{"publish:orders"}, TTL: "2h"},
+		{Permissions: []string{"publish:orders"}, TTL: "soon"},
+	} {
+		_, err := request.Validate(parent)
+		assert.ErrorIs(t, err, ErrInvalidDelegation, "%+v", request)
+	}
+}
+
+func TestServiceAccountScoped(t *testing.T) {
+	parent := &ServiceAccount{ID: "billing", Permissions: []string{"publish:*", "consume:orders"}}
+
+	scoped := parent.Scoped([]string{"publish:invoices", "consume:payments"})
+	assert.Equal(t, "billing", scoped.ID)
+	assert.Equal(t, []string{"publish:invoices"}, scoped.Permissions, "permissions the account lost are dropped")
+	assert.True(t, scoped.HasPermission("publish:invoices"))
+	assert.False(t, scoped.HasPermission("publish:orders"))
+	assert.Equal(t, []string{"publish:*", "consume:orders"}, parent.Permissions)
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFailureSampleSize is the number of failures kept per queue
+	DefaultFailureSampleSize = 50
+
+	// MaxFailurePayloadBytes caps the captured payload of each failure
+	MaxFailurePayloadBytes = 4096
+)
+
+// DeliveryFailure is a captured handler error with the failing payload
+type DeliveryFailure struct {
+	MessageID        string    `json:"messageId"`
+	Payload          []byte    `json:"payload"`
+	PayloadTruncated bool      `json:"payloadTruncated,omitempty"`
+	PayloadSize      int       `json:"payloadSize"`
+	Error            string    `json:"error"`
+	RetryCount       int       `json:"retryCount"`
+	FailedAt         time.Time `json:"failedAt"`
+}
+
+// FailureSampler keeps the most recent delivery failures in a ring buffer
+type FailureSampler struct {
+	samples []DeliveryFailure
+	next    int
+	full    bool
+	total   int64
+	mu      sync.Mutex
+}
+
+func NewFailureSampler(size int) *FailureSampler {
+	if size <= 0 {
+		size = DefaultFailureSampleSize
+	}
+	return &FailureSampler{
+		samples: make([]DeliveryFailure, size),
+	}
+}
+
+// Record captures a failure, overwriting the oldest sample when full
+func (s *FailureSampler) Record(msg *Message, err error, retryCount int) {
+	failure := DeliveryFailure{
+		MessageID:   msg.ID,
+		PayloadSize: len(msg.Payload),
+		RetryCount:  retryCount,
+		FailedAt:    time.Now(),
+	}
+	if err != nil {
+		failure.Error = err.Error()
+	}
+
+	payload := msg.Payload
+	if len(payload) > MaxFailurePayloadBytes {
+		payload = payload[:MaxFailurePayloadBytes]
+		failure.PayloadTruncated = true
+	}
+	failure.Payload = append([]byte(nil), payload...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = failure
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+	s.total++
+}
+
+// Samples returns the captured failures, newest first
+func (s *FailureSampler) Samples() []DeliveryFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newestFirst()
+}
+
+func (s *FailureSampler) newestFirst() []DeliveryFailure {
+	count := s.next
+	if s.full {
+		count = len(s.samples)
+	}
+
+	result := make([]DeliveryFailure, 0, count)
+	for i := 1; i <= count; i++ {
+		idx := (s.next - i + len(s.samples)) % len(s.samples)
+		result = append(result, s.samples[idx])
+	}
+	return result
+}
+
+// Purge drops the failures matching, the total is left as is
+func (s *FailureSampler) Purge(match func(DeliveryFailure) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.newestFirst()
+	purged := 0
+	clear(s.samples)
+	s.next, s.full = 0, false
+	for i := len(kept) - 1; i >= 0; i-- {
+		if match(kept[i]) {
+			purged++
+			continue
+		}
+		s.samples[s.next] = kept[i]
+		s.next++
+	}
+	return purged
+}
+
+// Total returns the number of failures recorded since creation
+func (s *FailureSampler) Total() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
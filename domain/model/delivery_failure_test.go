@@ -0,0 +1,76 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureSampler(t *testing.T) {
+	sampler := NewFailureSampler(3)
+	assert.Empty(t, sampler.Samples())
+
+	for i := 1; i <= 5; i++ {
+		sampler.Record(&Message{
+			ID:      fmt.Sprintf("msg-%d", i),
+			Payload: []byte(`{"n":1}`),
+		}, errors.New("handler crashed"), 0)
+	}
+
+	samples := sampler.Samples()
+	require.Len(t, samples, 3)
+	assert.Equal(t, "msg-5", samples[0].MessageID)
+	assert.Equal(t, "msg-3", samples[2].MessageID)
+	assert.Equal(t, "handler crashed", samples[0].Error)
+	assert.Equal(t, int64(5), sampler.Total())
+}
+
+func TestFailureSamplerPurge(t *testing.T) {
+	sampler := NewFailureSampler(3)
+	for i := 1; i <= 4; i++ {
+		sampler.Record(&Message{ID: fmt.Sprintf("msg-%d", i)}, nil, 0)
+	}
+
+	purged := sampler.Purge(func(failure DeliveryFailure) bool { return failure.MessageID == "msg-3" })
+	assert.Equal(t, 1, purged)
+
+	samples := sampler.Samples()
+	require.Len(t, samples, 2)
+	assert.Equal(t, "msg-4", samples[0].MessageID)
+	assert.Equal(t, "msg-2", samples[1].MessageID)
+
+	sampler.Record(&Message{ID: "msg-5"}, nil, 0)
+	assert.Equal(t, "msg-5", sampler.Samples()[0].MessageID)
+	assert.Equal(t, int64(5), sampler.Total())
+}
+
+func TestFailureSamplerTruncatesPayload(t *testing.T) {
+	sampler := NewFailureSampler(1)
+	payload := bytes.Repeat([]byte("x"), MaxFailurePayloadBytes+10)
+
+	sampler.Record(&Message{ID: "big", Payload: payload}, errors.New("too big"), 2)
+
+	sample := sampler.Samples()[0]
+	assert.True(t, sample.PayloadTruncated)
+	assert.Len(t, sample.Payload, MaxFailurePayloadBytes)
+	assert.Equal(t, len(payload), sample.PayloadSize)
+	assert.Equal(t, 2, sample.RetryCount)
+}
+
+func TestChannelQueueSamplesDeliveryErrors(t *testing.T) {
+	cq := NewChannelQueue(context.Background(), nil, &Queue{Name: "orders", DomainName: "shop"}, 10, nil)
+	defer cq.Stop()
+
+	cq.handleDeliveryError(&Message{ID: "msg-1", Payload: []byte("boom")}, nil, errors.New("consumer panic"))
+
+	failures, total := cq.GetDeliveryFailures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "consumer panic", failures[0].Error)
+	assert.Equal(t, []byte("boom"), failures[0].Payload)
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// Resources created by a reconciler carry its name under LabelManagedBy,
+// only those are deleted when they leave the manifests
+const (
+	LabelManagedBy  = "managed-by"
+	ManagedByGitOps = "gitops"
+)
+
+// DesiredState is the broker content described by a set of manifests
+type DesiredState struct {
+	Domains         []DesiredDomain
+	ServiceAccounts []DesiredServiceAccount
+}
+
+// DesiredDomain fully describes a domain: queues and rules missing from it
+// are extraneous
+type DesiredDomain struct {
+	Name                string
+	Schema              *Schema
+	AllowedRouteSources []string
+	Labels              map[string]string
+	Queues              []DesiredQueue
+	Routes              []*RoutingRule
+}
+
+type DesiredQueue struct {
+	Name   string
+	Config QueueConfig
+}
+
+type DesiredServiceAccount struct {
+	ID              string
+	Name            string
+	Permissions     []string
+	IPWhitelist     []string
+	Domains         []string
+	Enabled         bool
+	TimestampWindow string
+}
+
+// Drift kinds
+const (
+	DriftKindDomain         = "domain"
+	DriftKindQueue          = "queue"
+	DriftKindRoute          = "route"
+	DriftKindServiceAccount = "serviceAccount"
+)
+
+// Drift actions, a conflict needs an operator as it can't be applied in place
+const (
+	DriftCreate   = "create"
+	DriftUpdate   = "update"
+	DriftDelete   = "delete"
+	DriftConflict = "conflict"
+)
+
+// Drift is a difference between the manifests and the broker
+type Drift struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"` // shop, shop/orders, shop/orders->invoices or a service account ID
+	Action  string `json:"action"`
+	Detail  string `json:"detail,omitempty"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReconcileReport is the outcome of a reconciliation pass
+type ReconcileReport struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	DryRun     bool      `json:"dryRun"`
+	InSync     bool      `json:"inSync"` // the broker matches the manifests once the pass is over
+	Drift      []Drift   `json:"drift"`
+	Error      string    `json:"error,omitempty"` // manifests that couldn't be loaded
+}
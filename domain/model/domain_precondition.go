@@ -0,0 +1,37 @@
+package model
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDomainVersionMismatch reports a store that found the domain at another
+// version than the one its request expected
+var ErrDomainVersionMismatch = errors.New("domain version mismatch")
+
+// DomainPrecondition is the version a request expects a domain to be at when
+// it stores or deletes it. The repository compares it under its lock and
+// moves it to the version it assigns, so the later stores of the same request
+// expect their own writes rather than the original version
+type DomainPrecondition struct {
+	Domain  string
+	Version uint64
+}
+
+type domainPreconditionKey struct{}
+
+// WithDomainPrecondition returns a context whose domain writes require the
+// domain to be at the given version
+func WithDomainPrecondition(ctx context.Context, domain string, version uint64) context.Context {
+	return context.WithValue(ctx, domainPreconditionKey{}, &DomainPrecondition{Domain: domain, Version: version})
+}
+
+// DomainPreconditionFor returns the precondition of the context on a domain,
+// nil when writes to it are unconditional
+func DomainPreconditionFor(ctx context.Context, domain string) *DomainPrecondition {
+	precondition, ok := ctx.Value(domainPreconditionKey{}).(*DomainPrecondition)
+	if !ok || precondition.Domain != domain {
+		return nil
+	}
+	return precondition
+}
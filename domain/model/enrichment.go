@@ -0,0 +1,203 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Enrichment sources, the server-side values a rule injects
+const (
+	EnrichReceivedAt = "receivedAt" // when the server received the message, RFC 3339
+	EnrichPrincipal  = "principal"  // user or service account that published it
+	EnrichClientIP   = "clientIP"   // address the publish came from
+	EnrichSequence   = "sequence"   // publish sequence number of the queue
+)
+
+// Enrichment targets
+const (
+	EnrichMetadata = "metadata"
+	EnrichPayload  = "payload"
+)
+
+// Publisher is who published a message and from where, as authenticated
+// by the inbound adapter
+type Publisher struct {
+	Principal string
+	ClientIP  string
+}
+
+// SetPublisher records who published the message. Producers can't set it
+// through headers or metadata, so enrichment can trust it.
+func (m *Message) SetPublisher(publisher Publisher) {
+	m.publisher = &publisher
+}
+
+// Publisher returns who published the message, empty when the adapter
+// didn't say
+func (m *Message) Publisher() Publisher {
+	if m.publisher == nil {
+		return Publisher{}
+	}
+	return *m.publisher
+}
+
+// ServiceID is the service account that published the message, empty for
+// users and unknown publishers
+func (p Publisher) ServiceID() string {
+	id, _ := strings.CutPrefix(p.Principal, "service:")
+	if id == p.Principal {
+		return ""
+	}
+	return id
+}
+
+// EnrichmentRule injects a server-side value into the messages published to
+// a queue. Values the producer set under the same field are overwritten.
+type EnrichmentRule struct {
+	// Source is receivedAt, principal, clientIP or sequence
+	Source string `yaml:"source" json:"source"`
+
+	// Target is metadata (default) or payload
+	Target string `yaml:"target,omitempty" json:"target,omitempty"`
+
+	// Field receives the value, the name of the source by default. Dotted
+	// paths reach nested payload objects (meta.receivedAt).
+	Field string `yaml:"field,omitempty" json:"field,omitempty"`
+}
+
+// field is where the rule writes its value
+func (r EnrichmentRule) field() string {
+	if r.Field == "" {
+		return r.Source
+	}
+	return r.Field
+}
+
+// ValidateEnrichment checks the rules of a queue
+func ValidateEnrichment(rules []EnrichmentRule) error {
+	fields := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		switch rule.Source {
+		case EnrichReceivedAt, EnrichPrincipal, EnrichClientIP, EnrichSequence:
+		default:
+			return fmt.Errorf("%w: unknown source %q", ErrInvalidEnrichment, rule.Source)
+		}
+		switch rule.Target {
+		case "", EnrichMetadata, EnrichPayload:
+		default:
+			return fmt.Errorf("%w: %s: target must be %s or %s", ErrInvalidEnrichment, rule.Source, EnrichMetadata, EnrichPayload)
+		}
+
+		field := rule.field()
+		if strings.Contains(field, "..") || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") {
+			return fmt.Errorf("%w: invalid field %q", ErrInvalidEnrichment, field)
+		}
+		key := rule.Target + ":" + field
+		if fields[key] {
+			return fmt.Errorf("%w: field %q written twice", ErrInvalidEnrichment, field)
+		}
+		fields[key] = true
+	}
+	return nil
+}
+
+// EnrichesPayload reports whether a rule writes into the payload
+func EnrichesPayload(rules []EnrichmentRule) bool {
+	for _, rule := range rules {
+		if rule.Target == EnrichPayload {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesSequence reports whether a rule injects the sequence number, which
+// is only drawn for the queues that need it
+func UsesSequence(rules []EnrichmentRule) bool {
+	for _, rule := range rules {
+		if rule.Source == EnrichSequence {
+			return true
+		}
+	}
+	return false
+}
+
+// Enrich applies the rules to a message received at receivedAt, sequence
+// being its publish sequence number. Rules whose value is unknown, such as
+// the principal of an anonymous publish, are skipped.
+func (m *Message) Enrich(rules []EnrichmentRule, receivedAt time.Time, sequence uint64) error {
+	publisher := m.Publisher()
+
+	var payload map[string]json.RawMessage
+	if EnrichesPayload(rules) {
+		if err := json.Unmarshal(m.Payload, &payload); err != nil || payload == nil {
+			return ErrEnrichmentPayload
+		}
+	}
+
+	for _, rule := range rules {
+		var value any
+		switch rule.Source {
+		case EnrichReceivedAt:
+			value = receivedAt.UTC().Format(time.RFC3339Nano)
+		case EnrichPrincipal:
+			value = publisher.Principal
+		case EnrichClientIP:
+			value = publisher.ClientIP
+		case EnrichSequence:
+			value = sequence
+		}
+		if value == "" {
+			continue
+		}
+
+		if rule.Target != EnrichPayload {
+			m.WritableMetadata()[rule.field()] = value
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err := setPayloadField(payload, strings.Split(rule.field(), "."), encoded); err != nil {
+			return err
+		}
+	}
+
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		m.Payload = encoded
+		m.sharedPayload = false
+	}
+	return nil
+}
+
+// setPayloadField sets a dotted path of a JSON object, creating the
+// missing objects along the way
+func setPayloadField(object map[string]json.RawMessage, path []string, value json.RawMessage) error {
+	if len(path) == 1 {
+		object[path[0]] = value
+		return nil
+	}
+
+	nested := make(map[string]json.RawMessage)
+	if existing, ok := object[path[0]]; ok {
+		if json.Unmarshal(existing, &nested) != nil || nested == nil {
+			return fmt.Errorf("%w: %s is not an object", ErrEnrichmentPayload, path[0])
+		}
+	}
+	if err := setPayloadField(nested, path[1:], value); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(nested)
+	if err != nil {
+		return err
+	}
+	object[path[0]] = encoded
+	return nil
+}
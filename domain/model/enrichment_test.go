@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEnrichment(t *testing.T) {
+	assert.NoError(t, ValidateEnrichment(nil))
+	assert.NoError(t, ValidateEnrichment([]EnrichmentRule{
+		{Source: EnrichReceivedAt},
+		{Source: EnrichReceivedAt, Target: EnrichPayload, Field: "meta.receivedAt"},
+		{Source: EnrichSequence, Target: EnrichMetadata, Field: "seq"},
+	}))
+
+	for _, rules := range [][]EnrichmentRule{
+		{{Source: "geo"}},
+		{{Source: EnrichPrincipal, Target: "headers"}},
+		{{Source: EnrichClientIP, Target: EnrichPayload, Field: "a..b"}},
+		{{Source: EnrichPrincipal, Field: "by"}, {Source: EnrichClientIP, Field: "by"}},
+	} {
+		assert.ErrorIs(t, ValidateEnrichment(rules), ErrInvalidEnrichment, "%+v", rules)
+	}
+}
+
+func TestMessageEnrich(t *testing.T) {
+	receivedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rules := []EnrichmentRule{
+		{Source: EnrichReceivedAt},
+		{Source: EnrichPrincipal, Target: EnrichPayload, Field: "audit.by"},
+		{Source: EnrichClientIP, Target: EnrichPayload},
+		{Source: EnrichSequence, Target: EnrichPayload, Field: "seq"},
+	}
+
+	message := &Message{Payload: []byte(`{"order":1,"seq":99,"audit":{"note":"x"}}`)}
+	message.SetPublisher(Publisher{Principal: "user:alice", ClientIP: "10.0.0.7"})
+	require.NoError(t, message.Enrich(rules, receivedAt, 42))
+
+	assert.Equal(t, "2026-03-01T12:00:00Z", message.Metadata["receivedAt"])
+	assert.JSONEq(t, `{"order":1,"seq":42,"clientIP":"10.0.0.7","audit":{"note":"x","by":"user:alice"}}`, string(message.Payload))
+
+	// An anonymous publish gets no principal
+	message = &Message{Payload: []byte(`{"order":2}`)}
+	require.NoError(t, message.Enrich(rules, receivedAt, 43))
+	assert.JSONEq(t, `{"order":2,"seq":43}`, string(message.Payload))
+
+	message = &Message{Payload: []byte(`["not","an","object"]`)}
+	assert.ErrorIs(t, message.Enrich(rules, receivedAt, 44), ErrEnrichmentPayload)
+	message = &Message{Payload: []byte(`{"audit":"flat"}`)}
+	message.SetPublisher(Publisher{Principal: "user:alice"})
+	assert.ErrorIs(t, message.Enrich(rules, receivedAt, 45), ErrEnrichmentPayload)
+
+	// Metadata only rules leave any payload alone
+	message = &Message{Payload: []byte("plain text")}
+	require.NoError(t, message.Enrich([]EnrichmentRule{{Source: EnrichSequence}}, receivedAt, 46))
+	assert.Equal(t, "plain text", string(message.Payload))
+	assert.Equal(t, uint64(46), message.Metadata["sequence"])
+}
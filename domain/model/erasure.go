@@ -0,0 +1,121 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Erasure modes
+const (
+	ErasureDelete = "delete" // remove the matching messages
+	ErasureShred  = "shred"  // overwrite their payload, the message stays as a tombstone
+)
+
+// Erasure job statuses
+const (
+	ErasureRunning   = "running"
+	ErasureCompleted = "completed"
+	ErasureFailed    = "failed"
+)
+
+// MetadataErasedAt marks the tombstones left by a shred erasure
+const MetadataErasedAt = "erasedAt"
+
+// ErasureTombstone replaces the payload of shredded messages
+var ErasureTombstone = []byte(`{"erased":true}`)
+
+// ErasureRequest purges the messages of a data subject, the messages whose
+// payload holds Value at Field
+type ErasureRequest struct {
+	// Field is the payload field identifying the subject, dotted paths
+	// reach nested objects (customer.id)
+	Field string `json:"field"`
+
+	// Value of the field, strings compare as is, other JSON values by
+	// their JSON text (42, true)
+	Value string `json:"value"`
+
+	// Mode is ErasureDelete (default) or ErasureShred
+	Mode string `json:"mode,omitempty"`
+
+	// Domains limits the scan, every domain when empty
+	Domains []string `json:"domains,omitempty"`
+}
+
+// Validate checks the request and applies the default mode
+func (r *ErasureRequest) Validate() error {
+	if r.Mode == "" {
+		r.Mode = ErasureDelete
+	}
+	switch {
+	case r.Field == "" || strings.Contains(r.Field, "..") ||
+		strings.HasPrefix(r.Field, ".") || strings.HasSuffix(r.Field, "."):
+		return fmt.Errorf("%w: invalid field %q", ErrInvalidErasure, r.Field)
+	case r.Value == "":
+		return fmt.Errorf("%w: value is required", ErrInvalidErasure)
+	case r.Mode != ErasureDelete && r.Mode != ErasureShred:
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidErasure, ErasureDelete, ErasureShred)
+	}
+	return nil
+}
+
+// Matches reports whether a JSON object payload holds the value at the field
+func (r *ErasureRequest) Matches(payload []byte) bool {
+	value, exists := payloadField(payload, r.Field)
+	return exists && value == r.Value
+}
+
+// payloadField reads a dotted field of a JSON object payload, strings as is
+// and other JSON values as their JSON text
+func payloadField(payload []byte, field string) (string, bool) {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(payload, &object) != nil {
+		return "", false
+	}
+
+	path := strings.Split(field, ".")
+	for _, key := range path[:len(path)-1] {
+		if json.Unmarshal(object[key], &object) != nil || object == nil {
+			return "", false
+		}
+	}
+
+	value, exists := object[path[len(path)-1]]
+	if !exists {
+		return "", false
+	}
+	var text string
+	if json.Unmarshal(value, &text) == nil {
+		return text, true
+	}
+	return string(value), true
+}
+
+// ErasureQueueReport details the erasure in one queue
+type ErasureQueueReport struct {
+	Domain         string   `json:"domain"`
+	Queue          string   `json:"queue"`
+	Scanned        int      `json:"scanned"`
+	Erased         int      `json:"erased"`
+	FailureSamples int      `json:"failureSamples,omitempty"` // delivery failure samples dropped
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// ErasureReport is the completion report of an erasure job. The value
+// searched isn't kept, the report must not hold the data it erased.
+type ErasureReport struct {
+	ID             string               `json:"id"`
+	Field          string               `json:"field"`
+	Mode           string               `json:"mode"`
+	Domains        []string             `json:"domains,omitempty"`
+	Status         string               `json:"status"`
+	StartedAt      time.Time            `json:"startedAt"`
+	CompletedAt    *time.Time           `json:"completedAt,omitempty"`
+	Scanned        int                  `json:"scanned"`
+	Erased         int                  `json:"erased"`
+	FailureSamples int                  `json:"failureSamples"`
+	Queues         []ErasureQueueReport `json:"queues"`
+	Error          string               `json:"error,omitempty"`
+}
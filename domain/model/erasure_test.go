@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErasureRequestMatches(t *testing.T) {
+	byID := &ErasureRequest{Field: "customer.id", Value: "42"}
+	assert.True(t, byID.Matches([]byte(`{"customer":{"id":42}}`)))
+	assert.True(t, byID.Matches([]byte(`{"customer":{"id":"42"}}`)))
+	assert.False(t, byID.Matches([]byte(`{"customer":{"id":420}}`)))
+	assert.False(t, byID.Matches([]byte(`{"customer":"42"}`)))
+	assert.False(t, byID.Matches([]byte(`plain 42`)))
+}
+
+func TestErasureRequestValidate(t *testing.T) {
+	request := &ErasureRequest{Field: "userId", Value: "u-1"}
+	assert.NoError(t, request.Validate())
+	assert.Equal(t, ErasureDelete, request.Mode)
+
+	assert.ErrorIs(t, (&ErasureRequest{Field: "a..b", Value: "x"}).Validate(), ErrInvalidErasure)
+	assert.ErrorIs(t, (&ErasureRequest{Field: "userId", Value: "x", Mode: "wipe"}).Validate(), ErrInvalidErasure)
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Kinds of the failures reported to the error trackers
+const (
+	ErrorKindPanic    = "panic"    // recovered from an API handler
+	ErrorKindDelivery = "delivery" // returned or raised by a subscriber
+	ErrorKindStorage  = "storage"  // a message that couldn't be stored
+)
+
+// ErrorReport describes an unexpected failure for the error trackers,
+// with the request or the queue it happened on
+type ErrorReport struct {
+	Kind      string    `json:"kind"`
+	RequestID string    `json:"requestId,omitempty"`
+	Listener  string    `json:"listener,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Domain    string    `json:"domain,omitempty"`
+	Queue     string    `json:"queue,omitempty"`
+	ServiceID string    `json:"serviceId,omitempty"`
+	MessageID string    `json:"messageId,omitempty"`
+	Message   string    `json:"message"` // the panic value or the error
+	Stack     string    `json:"-"`
+	Time      time.Time `json:"time"`
+}
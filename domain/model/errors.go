@@ -16,4 +16,110 @@ var (
 	ErrAccountRequestDatabaseCorrupted = errors.New("account request database file corrupted")
 	ErrUsernameAlreadyTaken            = errors.New("username is already taken")
 	ErrInvalidRequestedRole            = errors.New("invalid requested role")
+	ErrInvalidEmail                    = errors.New("invalid email address")
+
+	// Preferences related errors
+	ErrPreferencesNotFound          = errors.New("no preferences saved for this user")
+	ErrPreferencesDatabaseCorrupted = errors.New("preferences database file corrupted")
+	ErrInvalidPreferences           = errors.New("invalid preferences")
+
+	// Lookup related errors
+	ErrDomainNotFound = errors.New("domain not found")
+	ErrQueueNotFound  = errors.New("queue not found")
+
+	// Schema related errors
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// Search related errors
+	ErrSearchDisabled = errors.New("message search is not enabled")
+	ErrInvalidSearch  = errors.New("invalid search")
+
+	// Routing related errors
+	ErrCrossDomainRouteDenied = errors.New("destination domain does not accept routes from source domain")
+
+	// Consumer group related errors
+	ErrConsumerIDRequired  = errors.New("broadcast consumer groups require a consumer ID")
+	ErrConsumerNotInGroup  = errors.New("consumer is not a member of the group")
+	ErrImplicitGroupDenied = errors.New("queue denies implicit consumer groups, create the group first")
+	ErrInvalidGroupPolicy  = errors.New("invalid implicit group policy")
+
+	// Producer session related errors
+	ErrDuplicatePublish        = errors.New("message already published by this producer session")
+	ErrInvalidProducerSequence = errors.New("invalid producer sequence number")
+	ErrStaleProducerSequence   = errors.New("producer sequence number is behind the session")
+
+	// Label related errors
+	ErrInvalidLabelSelector = errors.New("invalid label selector")
+
+	// Stats related errors
+	ErrUnknownRanking   = errors.New("unknown ranking")
+	ErrNoScalingMetrics = errors.New("no metrics collected yet")
+
+	// Field encryption related errors
+	ErrEncryptedFieldsPayload  = errors.New("queue encrypts payload fields, the payload must be a JSON object")
+	ErrFieldEncryptionDisabled = errors.New("field encryption is not configured")
+	ErrNoEncryptedFields       = errors.New("queue does not declare encrypted fields")
+
+	// Circuit breaker related errors
+	ErrInvalidFallbackQueue = errors.New("invalid circuit breaker fallback queue")
+
+	// Partitioning related errors
+	ErrInvalidPartitions    = errors.New("invalid partitions")
+	ErrPartitionedBroadcast = errors.New("broadcast groups can't consume partitioned queues")
+
+	// Compaction related errors
+	ErrInvalidCompaction = errors.New("invalid compaction")
+
+	// Move related errors
+	ErrInvalidMove     = errors.New("invalid move")
+	ErrMessageNotFound = errors.New("message not found")
+	ErrMoveFailed      = errors.New("move failed, the message stays in its queue")
+
+	// Mirroring related errors
+	ErrInvalidMirror = errors.New("invalid mirror")
+
+	// Queue mode related errors
+	ErrInvalidQueueMode = errors.New("invalid queue mode")
+	ErrQueueReadOnly    = errors.New("queue is read-only, publishes are refused")
+	ErrQueueWriteOnly   = errors.New("queue is write-only, consumes are refused")
+
+	// Queue hook related errors
+	ErrInvalidQueueHooks = errors.New("invalid queue hooks")
+
+	// Read replica related errors
+	ErrInvalidReadReplica = errors.New("invalid read replica")
+
+	// Canary routing related errors
+	ErrInvalidCanary = errors.New("invalid canary split")
+
+	// Routing order related errors
+	ErrInvalidRuleOrder = errors.New("invalid routing rule order")
+
+	// Redaction related errors
+	ErrInvalidRedaction = errors.New("invalid redaction")
+
+	// Erasure related errors
+	ErrInvalidErasure  = errors.New("invalid erasure request")
+	ErrErasureNotFound = errors.New("erasure job not found")
+
+	// Digest related errors
+	ErrInvalidDigest = errors.New("invalid queue digest")
+
+	// Replication related errors
+	ErrStandbyReadOnly = errors.New("standby replica is read-only until promoted")
+	ErrNotStandby      = errors.New("not a standby replica")
+
+	// Fault injection related errors
+	ErrInvalidFault        = errors.New("invalid fault")
+	ErrInjectedFault       = errors.New("injected publish failure")
+	ErrInjectedCircuitOpen = errors.New("circuit breaker open (injected)")
+
+	// Enrichment related errors
+	ErrInvalidEnrichment = errors.New("invalid enrichment")
+	ErrEnrichmentPayload = errors.New("queue enriches the payload, the payload must be a JSON object")
+
+	// Queue blueprint related errors
+	ErrBlueprintNotFound = errors.New("queue blueprint not found")
+	ErrInvalidBlueprint  = errors.New("invalid queue blueprint")
+	ErrBlueprintReadOnly = errors.New("queue blueprint is defined in the configuration file")
 )
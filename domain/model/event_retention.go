@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEventsPerDomain is the number of system events kept per domain
+	DefaultEventsPerDomain = 50
+
+	// DefaultEventPageSize is the page size of the domain events without a limit
+	DefaultEventPageSize = 100
+
+	// MaxEventPageSize bounds the page size of the domain events
+	MaxEventPageSize = 1000
+)
+
+// EventRetention bounds the system events kept for each domain, so that a
+// busy domain doesn't evict the history of the others
+type EventRetention struct {
+	// PerDomain is the number of events kept per domain, the oldest going first
+	PerDomain int `yaml:"perDomain" json:"perDomain"`
+
+	// MaxAge drops the events older than it, 0 keeps them
+	MaxAge time.Duration `yaml:"maxAge" json:"maxAge"`
+}
+
+// WithDefaults fills the values left empty
+func (r EventRetention) WithDefaults() EventRetention {
+	if r.PerDomain <= 0 {
+		r.PerDomain = DefaultEventsPerDomain
+	}
+	return r
+}
+
+// Expired reports whether the event is past the max age
+func (r EventRetention) Expired(event SystemEvent, now time.Time) bool {
+	return r.MaxAge > 0 && now.Sub(event.Timestamp) > r.MaxAge
+}
+
+// ResourceDomain is the domain of an event resource, a domain name or a
+// domain.queue pair
+func ResourceDomain(resource string) string {
+	domain, _, _ := strings.Cut(resource, ".")
+	return domain
+}
+
+// EventPage is a page of the events of a domain, in the order they were
+// recorded or last updated. Next is the since of the following page.
+type EventPage struct {
+	Domain  string        `json:"domain"`
+	Events  []SystemEvent `json:"events"`
+	Next    uint64        `json:"next"`
+	HasMore bool          `json:"hasMore"`
+}
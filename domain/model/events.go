@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// EventKind identifies what happened in a DomainEvent
+type EventKind string
+
+const (
+	EventMessagePublished    EventKind = "message.published"
+	EventMessageConsumed     EventKind = "message.consumed"
+	EventMessageAcknowledged EventKind = "message.acknowledged"
+	EventMessageDiverted     EventKind = "message.diverted"
+	EventMessageMoved        EventKind = "message.moved"
+	EventMessageDeadLettered EventKind = "message.deadLettered"
+	EventGroupImplicit       EventKind = "group.implicit"
+	EventQueueCreated        EventKind = "queue.created"
+	EventQueueDeleted        EventKind = "queue.deleted"
+	EventQueueDrainProgress  EventKind = "queue.drainProgress"
+	EventDomainCreated       EventKind = "domain.created"
+	EventDomainDeleted       EventKind = "domain.deleted"
+	EventRoutingRuleCreated  EventKind = "routing.created"
+)
+
+// DomainEvent is emitted once by the service where it happens and fanned out
+// to every subsystem interested in it (stats, alerting, audit...)
+type DomainEvent struct {
+	Kind      EventKind
+	Domain    string
+	Queue     string
+	MessageID string         // message events only
+	GroupID   string         // consume events only
+	Data      map[string]any // kind specific details
+	Time      time.Time
+}
+
+// EventHandler receives the events of a subscription
+type EventHandler func(event DomainEvent)
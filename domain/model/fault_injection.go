@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultFaultTTL lifts injected faults nobody cleared
+	DefaultFaultTTL = 10 * time.Minute
+
+	// MaxFaultTTL bounds how long a fault can stay injected
+	MaxFaultTTL = 24 * time.Hour
+
+	// MaxFaultLatency bounds the consume latency a fault adds
+	MaxFaultLatency = time.Minute
+)
+
+// QueueFault is a failure injected into a queue for resilience testing
+type QueueFault struct {
+	Domain             string
+	Queue              string
+	PublishFailureRate float64       // share of publishes refused, from 0 to 1
+	ConsumeLatency     time.Duration // added before each consumed message
+	CircuitOpen        bool          // every publish is refused as by an open circuit breaker
+	ExpiresAt          time.Time
+}
+
+// Validate checks the fault bounds
+func (f QueueFault) Validate() error {
+	switch {
+	case f.PublishFailureRate < 0 || f.PublishFailureRate > 1:
+		return fmt.Errorf("%w: publish failure rate must be between 0 and 1", ErrInvalidFault)
+	case f.ConsumeLatency < 0 || f.ConsumeLatency > MaxFaultLatency:
+		return fmt.Errorf("%w: consume latency must be between 0 and %s", ErrInvalidFault, MaxFaultLatency)
+	case f.PublishFailureRate == 0 && f.ConsumeLatency == 0 && !f.CircuitOpen:
+		return fmt.Errorf("%w: no fault to inject", ErrInvalidFault)
+	}
+	return nil
+}
+
+// Expired reports whether the fault has lifted
+func (f QueueFault) Expired(now time.Time) bool {
+	return !f.ExpiresAt.IsZero() && !now.Before(f.ExpiresAt)
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueFaultValidate(t *testing.T) {
+	assert.NoError(t, QueueFault{PublishFailureRate: 1}.Validate())
+	assert.NoError(t, QueueFault{CircuitOpen: true}.Validate())
+	assert.ErrorIs(t, QueueFault{}.Validate(), ErrInvalidFault)
+	assert.ErrorIs(t, QueueFault{PublishFailureRate: 1.5}.Validate(), ErrInvalidFault)
+	assert.ErrorIs(t, QueueFault{ConsumeLatency: 2 * time.Minute}.Validate(), ErrInvalidFault)
+}
@@ -0,0 +1,74 @@
+package model
+
+import "encoding/json"
+
+// EncryptedFieldKey is the only key of an encrypted payload field:
+// {"$encrypted": {"key": "shop/orders", "nonce": "...", "data": "..."}}
+const EncryptedFieldKey = "$encrypted"
+
+// EncryptedField is the AES-256-GCM ciphertext of the JSON value of a field,
+// sealed with the key of the queue named by KeyID
+type EncryptedField struct {
+	KeyID string `json:"key"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// EncryptionKeyID names the key of a queue, routed copies keep the key of the
+// queue the message was first published to
+func EncryptionKeyID(domainName, queueName string) string {
+	return domainName + "/" + queueName
+}
+
+// IsEncryptedField reports whether a decoded payload value is an encrypted field
+func IsEncryptedField(value any) bool {
+	field, ok := value.(map[string]any)
+	if !ok || len(field) != 1 {
+		return false
+	}
+	_, ok = field[EncryptedFieldKey].(map[string]any)
+	return ok
+}
+
+// isEncryptedRaw is IsEncryptedField for a raw JSON value
+func isEncryptedRaw(raw json.RawMessage) bool {
+	var field map[string]json.RawMessage
+	if json.Unmarshal(raw, &field) != nil || len(field) != 1 {
+		return false
+	}
+	_, ok := field[EncryptedFieldKey]
+	return ok
+}
+
+// SealFields replaces the listed fields of a JSON object payload with the
+// result of seal. Fields absent or already encrypted are left as they are.
+func SealFields(payload []byte, fields []string, seal func(value []byte) (*EncryptedField, error)) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil || object == nil {
+		return nil, ErrEncryptedFieldsPayload
+	}
+
+	sealed := false
+	for _, name := range fields {
+		value, exists := object[name]
+		if !exists || isEncryptedRaw(value) {
+			continue
+		}
+
+		field, err := seal(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(map[string]*EncryptedField{EncryptedFieldKey: field})
+		if err != nil {
+			return nil, err
+		}
+		object[name] = encoded
+		sealed = true
+	}
+
+	if !sealed {
+		return payload, nil
+	}
+	return json.Marshal(object)
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GroupCleanupAction is what the stale group cleanup does with a group
+type GroupCleanupAction string
+
+const (
+	// GroupCleanupDelete drops the group along with its position
+	GroupCleanupDelete GroupCleanupAction = "delete"
+
+	// GroupCleanupArchive drops the group but keeps its position, the group
+	// resuming from it when it registers again
+	GroupCleanupArchive GroupCleanupAction = "archive"
+)
+
+const (
+	// DefaultGroupStaleAfter is the inactivity after which a group without
+	// a TTL of its own is cleaned up
+	DefaultGroupStaleAfter = 4 * time.Hour
+
+	// DefaultGroupCleanupInterval spaces the stale group cleanups
+	DefaultGroupCleanupInterval = 5 * time.Minute
+
+	// DefaultCleanupLookahead is how far ahead upcoming cleanups are listed
+	DefaultCleanupLookahead = 24 * time.Hour
+)
+
+var ErrInvalidCleanupAction = errors.New("invalid cleanup action")
+
+// ParseGroupCleanupAction validates a cleanup action, empty meaning the
+// action of the policy
+func ParseGroupCleanupAction(action string) (GroupCleanupAction, error) {
+	switch GroupCleanupAction(action) {
+	case "", GroupCleanupDelete, GroupCleanupArchive:
+		return GroupCleanupAction(action), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidCleanupAction, action)
+}
+
+// GroupCleanupPolicy decides when idle consumer groups are cleaned up and
+// what happens to their position. Groups override StaleAfter with their TTL
+// and Action with their CleanupAction.
+type GroupCleanupPolicy struct {
+	// StaleAfter is the inactivity after which a group is cleaned up
+	StaleAfter time.Duration `yaml:"staleAfter" json:"staleAfter"`
+
+	// Action is delete (default) or archive
+	Action GroupCleanupAction `yaml:"action" json:"action"`
+
+	// Interval spaces the cleanups
+	Interval time.Duration `yaml:"interval" json:"interval"`
+}
+
+// WithDefaults fills the values left empty
+func (p GroupCleanupPolicy) WithDefaults() GroupCleanupPolicy {
+	if p.StaleAfter <= 0 {
+		p.StaleAfter = DefaultGroupStaleAfter
+	}
+	if p.Action == "" {
+		p.Action = GroupCleanupDelete
+	}
+	if p.Interval <= 0 {
+		p.Interval = DefaultGroupCleanupInterval
+	}
+	return p
+}
+
+// ScheduledCleanup is when and how the policy cleans a group up
+type ScheduledCleanup struct {
+	Domain       string             `json:"domain"`
+	Queue        string             `json:"queue"`
+	GroupID      string             `json:"groupId"`
+	LastActivity time.Time          `json:"lastActivity"`
+	StaleAfter   time.Duration      `json:"staleAfterNs"`
+	CleanupAt    time.Time          `json:"cleanupAt"`
+	Action       GroupCleanupAction `json:"action"`
+	Position     int64              `json:"position"`
+}
+
+// ArchivedGroup is the position of a group the cleanup archived
+type ArchivedGroup struct {
+	Domain       string    `json:"domain"`
+	Queue        string    `json:"queue"`
+	GroupID      string    `json:"groupId"`
+	Position     int64     `json:"position"`
+	ConsumerIDs  []string  `json:"consumerIds"`
+	LastActivity time.Time `json:"lastActivity"`
+	ArchivedAt   time.Time `json:"archivedAt"`
+}
+
+// CleanupSchedule applies the policy, with the overrides of the group
+func (cg *ConsumerGroup) CleanupSchedule(policy GroupCleanupPolicy) ScheduledCleanup {
+	policy = policy.WithDefaults()
+
+	staleAfter := policy.StaleAfter
+	if cg.Ephemeral {
+		staleAfter = DefaultEphemeralGroupTTL
+	}
+	if cg.TTL > 0 {
+		staleAfter = cg.TTL
+	}
+	action := policy.Action
+	if cg.CleanupAction != "" {
+		action = cg.CleanupAction
+	}
+	if cg.Ephemeral {
+		action = GroupCleanupDelete
+	}
+
+	return ScheduledCleanup{
+		Domain:       cg.DomainName,
+		Queue:        cg.QueueName,
+		GroupID:      cg.GroupID,
+		LastActivity: cg.LastActivity,
+		StaleAfter:   staleAfter,
+		CleanupAt:    cg.LastActivity.Add(staleAfter),
+		Action:       action,
+		Position:     cg.Position,
+	}
+}
+
+// Archive records the position of the group for when it registers again
+func (cg *ConsumerGroup) Archive(now time.Time) *ArchivedGroup {
+	return &ArchivedGroup{
+		Domain:       cg.DomainName,
+		Queue:        cg.QueueName,
+		GroupID:      cg.GroupID,
+		Position:     cg.Position,
+		ConsumerIDs:  append([]string{}, cg.ConsumerIDs...),
+		LastActivity: cg.LastActivity,
+		ArchivedAt:   now,
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupCleanupAction(t *testing.T) {
+	for _, action := range []string{"", "delete", "archive"} {
+		parsed, err := ParseGroupCleanupAction(action)
+		require.NoError(t, err)
+		assert.Equal(t, GroupCleanupAction(action), parsed)
+	}
+
+	_, err := ParseGroupCleanupAction("forget")
+	assert.ErrorIs(t, err, ErrInvalidCleanupAction)
+}
+
+func TestConsumerGroupCleanupSchedule(t *testing.T) {
+	lastActivity := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	group := &ConsumerGroup{DomainName: "shop", QueueName: "orders", GroupID: "reports", LastActivity: lastActivity, Position: 42}
+
+	schedule := group.CleanupSchedule(GroupCleanupPolicy{})
+	assert.Equal(t, lastActivity.Add(DefaultGroupStaleAfter), schedule.CleanupAt, "policy defaults apply")
+	assert.Equal(t, GroupCleanupDelete, schedule.Action)
+	assert.Equal(t, int64(42), schedule.Position)
+
+	policy := GroupCleanupPolicy{StaleAfter: time.Hour, Action: GroupCleanupArchive}
+	schedule = group.CleanupSchedule(policy)
+	assert.Equal(t, lastActivity.Add(time.Hour), schedule.CleanupAt)
+	assert.Equal(t, GroupCleanupArchive, schedule.Action)
+
+	// The group overrides the policy
+	group.TTL = 48 * time.Hour
+	group.CleanupAction = GroupCleanupDelete
+	schedule = group.CleanupSchedule(policy)
+	assert.Equal(t, 48*time.Hour, schedule.StaleAfter)
+	assert.Equal(t, lastActivity.Add(48*time.Hour), schedule.CleanupAt)
+	assert.Equal(t, GroupCleanupDelete, schedule.Action)
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidOffsets = errors.New("invalid group offsets")
+
+// Outcomes of the import of a group offset
+const (
+	OffsetImported = "imported"
+	OffsetSkipped  = "skipped"
+)
+
+// GroupOffset is the position of a consumer group, exported to move its
+// consumers to another instance. Message indexes differ between instances,
+// so the position is anchored on NextMessageID: the first message the group
+// hasn't consumed yet, empty when it had consumed everything.
+type GroupOffset struct {
+	GroupID       string             `json:"groupId"`
+	Partition     *int               `json:"partition,omitempty"`
+	Position      int64              `json:"position"` // index on the exporting instance
+	NextMessageID string             `json:"nextMessageId,omitempty"`
+	TTL           string             `json:"ttl,omitempty"`
+	DeliveryMode  GroupDeliveryMode  `json:"deliveryMode,omitempty"`
+	Priority      int                `json:"priority,omitempty"`
+	Prefetch      int                `json:"prefetch,omitempty"`
+	CleanupAction GroupCleanupAction `json:"cleanupAction,omitempty"`
+}
+
+// GroupOffsets are the offsets of the consumer groups of a queue
+type GroupOffsets struct {
+	Domain     string        `json:"domain"`
+	Queue      string        `json:"queue"`
+	ExportedAt time.Time     `json:"exportedAt"`
+	Groups     []GroupOffset `json:"groups"`
+}
+
+// OffsetImportResult reports what the import did with a group offset
+type OffsetImportResult struct {
+	GroupID   string `json:"groupId"`
+	Partition *int   `json:"partition,omitempty"`
+	Position  int64  `json:"position"` // index on the importing instance
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ParsedTTL is the TTL of the offset, 0 when it has none
+func (o GroupOffset) ParsedTTL() (time.Duration, error) {
+	if o.TTL == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(o.TTL)
+}
+
+// Validate checks every offset before any is imported
+func (o *GroupOffsets) Validate() error {
+	seen := make(map[string]bool, len(o.Groups))
+	for i, offset := range o.Groups {
+		if offset.GroupID == "" {
+			return fmt.Errorf("%w: groups[%d]: groupId is required", ErrInvalidOffsets, i)
+		}
+		key := offset.GroupID
+		if offset.Partition != nil {
+			if *offset.Partition < 0 || *offset.Partition >= MaxPartitions {
+				return fmt.Errorf("%w: %s: invalid partition %d", ErrInvalidOffsets, offset.GroupID, *offset.Partition)
+			}
+			key = fmt.Sprintf("%s/%d", key, *offset.Partition)
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: %s: offset given twice", ErrInvalidOffsets, key)
+		}
+		seen[key] = true
+
+		if ttl, err := offset.ParsedTTL(); err != nil || ttl < 0 {
+			return fmt.Errorf("%w: %s: invalid ttl %q", ErrInvalidOffsets, offset.GroupID, offset.TTL)
+		}
+		if _, err := ParseGroupDeliveryMode(string(offset.DeliveryMode)); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidOffsets, offset.GroupID, err)
+		}
+		if _, err := ParseGroupCleanupAction(string(offset.CleanupAction)); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidOffsets, offset.GroupID, err)
+		}
+		if offset.Prefetch < 0 {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidOffsets, offset.GroupID, ErrInvalidPrefetch)
+		}
+	}
+	return nil
+}
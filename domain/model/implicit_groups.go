@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ImplicitGroupPolicy decides whether consumers may read a queue through a
+// group nobody created, the group then springing up on its first consume
+type ImplicitGroupPolicy string
+
+const (
+	// ImplicitGroupsAllow creates unknown groups on their first consume,
+	// the default
+	ImplicitGroupsAllow ImplicitGroupPolicy = "allow"
+
+	// ImplicitGroupsDeny refuses consumes through groups not created first
+	ImplicitGroupsDeny ImplicitGroupPolicy = "deny"
+)
+
+// DefaultEphemeralGroupTTL is the inactivity after which an ephemeral group
+// without a TTL of its own expires
+const DefaultEphemeralGroupTTL = 5 * time.Minute
+
+// ParseImplicitGroupPolicy validates a policy, empty meaning allow
+func ParseImplicitGroupPolicy(policy string) (ImplicitGroupPolicy, error) {
+	switch ImplicitGroupPolicy(policy) {
+	case "", ImplicitGroupsAllow:
+		return ImplicitGroupsAllow, nil
+	case ImplicitGroupsDeny:
+		return ImplicitGroupsDeny, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidGroupPolicy, policy)
+	}
+}
+
+// Validate checks the policy is allow, deny or empty
+func (p ImplicitGroupPolicy) Validate() error {
+	_, err := ParseImplicitGroupPolicy(string(p))
+	return err
+}
+
+// Allows reports whether unknown groups are created on their first consume
+func (p ImplicitGroupPolicy) Allows() bool {
+	return p != ImplicitGroupsDeny
+}
+
+// Equal compares two policies, empty being allow
+func (p ImplicitGroupPolicy) Equal(other ImplicitGroupPolicy) bool {
+	return p.Allows() == other.Allows()
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImplicitGroupPolicy(t *testing.T) {
+	policy, err := ParseImplicitGroupPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, ImplicitGroupsAllow, policy)
+
+	policy, err = ParseImplicitGroupPolicy("deny")
+	require.NoError(t, err)
+	assert.False(t, policy.Allows())
+
+	_, err = ParseImplicitGroupPolicy("sometimes")
+	assert.ErrorIs(t, err, ErrInvalidGroupPolicy)
+
+	var unset ImplicitGroupPolicy
+	assert.True(t, unset.Equal(ImplicitGroupsAllow))
+	assert.False(t, unset.Equal(ImplicitGroupsDeny))
+}
+
+func TestEphemeralGroupCleanupSchedule(t *testing.T) {
+	lastActivity := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := GroupCleanupPolicy{Action: GroupCleanupArchive}
+
+	group := &ConsumerGroup{GroupID: "temp-1", LastActivity: lastActivity, Ephemeral: true}
+	schedule := group.CleanupSchedule(policy)
+	assert.Equal(t, lastActivity.Add(DefaultEphemeralGroupTTL), schedule.CleanupAt)
+	assert.Equal(t, GroupCleanupDelete, schedule.Action)
+
+	// a TTL of its own wins over the ephemeral default
+	group.TTL = time.Hour
+	assert.Equal(t, lastActivity.Add(time.Hour), group.CleanupSchedule(policy).CleanupAt)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IndexCompactionStats reports the background message index compaction
+type IndexCompactionStats struct {
+	Runs           int64                           `json:"runs"`
+	IndicesRemoved int64                           `json:"indicesRemoved"`
+	LastRun        time.Time                       `json:"lastRun"`
+	LastDurationMs float64                         `json:"lastDurationMs"`
+	Queues         map[string]QueueCompactionStats `json:"queues"` // keyed by domain.queue
+}
+
+// QueueCompactionStats is the compaction state of one queue
+type QueueCompactionStats struct {
+	IndicesRemoved  int64     `json:"indicesRemoved"`
+	PendingConsumes int       `json:"pendingConsumes"` // consumes since the queue was last compacted
+	SafePosition    int64     `json:"safePosition"`    // indices below it have been removed
+	Backlog         bool      `json:"backlog"`         // the budget ran out, the rest goes to the next run
+	LastRun         time.Time `json:"lastRun"`
+}
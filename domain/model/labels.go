@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseLabelSelector parses "key=value" or "key" expressions into a selector.
+// A key without value matches any resource carrying that label.
+func ParseLabelSelector(exprs []string) (map[string]string, error) {
+	selector := make(map[string]string)
+
+	for _, expr := range exprs {
+		for _, part := range strings.Split(expr, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			key, value, _ := strings.Cut(part, "=")
+			key = strings.TrimSpace(key)
+			if key == "" {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidLabelSelector, part)
+			}
+			selector[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return selector, nil
+}
+
+// MatchLabels reports whether labels satisfy every entry of the selector
+func MatchLabels(labels, selector map[string]string) bool {
+	for key, want := range selector {
+		got, ok := labels[key]
+		if !ok {
+			return false
+		}
+		if want != "" && got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeLabels returns the union of both label sets, override taking precedence
+func MergeLabels(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// EffectiveLabels returns the queue labels inherited from its domain
+func (q *Queue) EffectiveLabels(domain *Domain) map[string]string {
+	if domain == nil {
+		return MergeLabels(nil, q.Config.Labels)
+	}
+	return MergeLabels(domain.Labels, q.Config.Labels)
+}
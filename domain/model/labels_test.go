@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector([]string{"team=payments,env=prod", "critical"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "payments", "env": "prod", "critical": ""}, selector)
+
+	_, err = ParseLabelSelector([]string{"=prod"})
+	assert.ErrorIs(t, err, ErrInvalidLabelSelector)
+}
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "prod"}
+
+	assert.True(t, MatchLabels(labels, nil))
+	assert.True(t, MatchLabels(labels, map[string]string{"team": "payments"}))
+	assert.True(t, MatchLabels(labels, map[string]string{"env": ""}))
+	assert.False(t, MatchLabels(labels, map[string]string{"env": "staging"}))
+	assert.False(t, MatchLabels(labels, map[string]string{"region": ""}))
+}
+
+func TestEffectiveLabels(t *testing.T) {
+	domain := &Domain{Labels: map[string]string{"team": "payments", "env": "prod"}}
+	queue := &Queue{Config: QueueConfig{Labels: map[string]string{"env": "staging"}}}
+
+	assert.Equal(t, map[string]string{"team": "payments", "env": "staging"}, queue.EffectiveLabels(domain))
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"bytes"
+	"maps"
+	"sync"
+)
+
+// messagePool recycles the per-subscriber envelopes built on every fan-out
+var messagePool = sync.Pool{
+	New: func() any { return new(Message) },
+}
+
+// Envelope returns a pooled shallow copy of the message for one delivery.
+// Payload, headers and metadata are shared with the original: the envelope
+// copies them on write through WritablePayload and WritableMetadata.
+// Hand it back with ReleaseEnvelope once nothing references it anymore.
+func (m *Message) Envelope() *Message {
+	env := messagePool.Get().(*Message)
+	*env = *m
+	env.sharedPayload = true
+	env.sharedMetadata = true
+	return env
+}
+
+// ReleaseEnvelope returns an envelope to the pool
+func ReleaseEnvelope(env *Message) {
+	*env = Message{}
+	messagePool.Put(env)
+}
+
+// WritablePayload returns a payload the caller may modify in place,
+// copying it first when it is shared with another message
+func (m *Message) WritablePayload() []byte {
+	if m.sharedPayload {
+		m.Payload = bytes.Clone(m.Payload)
+		m.sharedPayload = false
+	}
+	return m.Payload
+}
+
+// WritableMetadata returns metadata the caller may modify,
+// copying it first when it is shared with another message
+func (m *Message) WritableMetadata() map[string]any {
+	switch {
+	case m.Metadata == nil:
+		m.Metadata = make(map[string]any)
+	case m.sharedMetadata:
+		m.Metadata = maps.Clone(m.Metadata)
+	}
+	m.sharedMetadata = false
+	return m.Metadata
+}
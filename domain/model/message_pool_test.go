@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageEnvelopeCopyOnWrite(t *testing.T) {
+	original := &Message{
+		ID:       "msg-1",
+		Payload:  []byte(`{"amount":10}`),
+		Metadata: map[string]any{"source": "api"},
+	}
+
+	env := original.Envelope()
+	assert.Equal(t, original.ID, env.ID)
+	assert.Same(t, &original.Payload[0], &env.Payload[0], "payload is shared until written")
+
+	env.WritablePayload()[2] = 'A'
+	env.WritableMetadata()["retry_info"] = 1
+
+	assert.Equal(t, `{"amount":10}`, string(original.Payload))
+	assert.NotContains(t, original.Metadata, "retry_info")
+	assert.Equal(t, 1, env.Metadata["retry_info"])
+
+	// once copied the envelope owns its data
+	payload := env.WritablePayload()
+	assert.Same(t, &payload[0], &env.Payload[0])
+
+	ReleaseEnvelope(env)
+	assert.Empty(t, env.ID)
+	assert.Nil(t, env.Payload)
+}
+
+func TestMessageWritableMetadataOnOwnedMessage(t *testing.T) {
+	msg := &Message{}
+	msg.WritableMetadata()["key"] = "value"
+	assert.Equal(t, "value", msg.Metadata["key"])
+}
+
+// sink keeps handlers from being optimised away
+var sink *Message
+
+// BenchmarkMessageFanOut compares handing every subscriber a shallow copy of
+// the message with handing it a pooled envelope over the shared payload
+func BenchmarkMessageFanOut(b *testing.B) {
+	msg := &Message{ID: "msg-1", Payload: make([]byte, 512), Headers: map[string]string{"a": "b"}}
+	handler := func(m *Message) error {
+		sink = m
+		return nil
+	}
+
+	for _, subscribers := range []int{1, 16, 128} {
+		b.Run(fmt.Sprintf("copy/%d", subscribers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for range subscribers {
+					msgCopy := *msg
+					handler(&msgCopy)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("envelope/%d", subscribers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for range subscribers {
+					env := msg.Envelope()
+					handler(env)
+					ReleaseEnvelope(env)
+				}
+			}
+		})
+	}
+}
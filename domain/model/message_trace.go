@@ -0,0 +1,60 @@
+package model
+
+import "strconv"
+
+// Metadata keys linking a routed copy to the message it was copied from
+const (
+	MetadataOriginalID = "originalId" // ID of the message first published
+	MetadataParentID   = "parentId"   // ID of the message of the previous hop
+)
+
+// RoutedMessageID derives the ID of the copy of a message made at the given
+// routing hop, so copies can be told apart from the original
+func RoutedMessageID(originalID string, hop int) string {
+	return originalID + "~" + strconv.Itoa(hop)
+}
+
+// OriginalMessageID returns the ID of the message first published, the
+// message's own ID unless it is a routed copy
+func OriginalMessageID(message *Message) string {
+	if original, ok := message.Metadata[MetadataOriginalID].(string); ok && original != "" {
+		return original
+	}
+	return message.ID
+}
+
+// RoutingHops returns how many routing hops made the message, 0 for a
+// message published directly
+func RoutingHops(message *Message) int {
+	return routingHops(message.Metadata)
+}
+
+// MessageTrace is a message and the copies routing made of it, each copy
+// holding the copies made from it in turn
+type MessageTrace struct {
+	Domain    string `json:"domain"`
+	Queue     string `json:"queue"`
+	MessageID string `json:"messageId"`
+	Hop       int    `json:"hop"`
+
+	// Rule is the routing rule that made the copy, empty for the root
+	Rule string `json:"rule,omitempty"`
+
+	// Stored is false once the message left its queue, its copies may
+	// still be there
+	Stored bool `json:"stored"`
+
+	Copies []*MessageTrace `json:"copies,omitempty"`
+}
+
+// Count returns how many messages of the trace are still stored
+func (t *MessageTrace) Count() int {
+	count := 0
+	if t.Stored {
+		count++
+	}
+	for _, child := range t.Copies {
+		count += child.Count()
+	}
+	return count
+}
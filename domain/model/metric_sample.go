@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrMetricsRejected is returned when a metrics backend refuses samples for
+// good, pushing them again would fail the same way
+var ErrMetricsRejected = errors.New("metrics rejected")
+
+const (
+	// DefaultRemoteWriteInterval spaces the pushes of the stats to a
+	// remote-write endpoint
+	DefaultRemoteWriteInterval = 30 * time.Second
+
+	// DefaultRemoteWriteTimeout bounds a push
+	DefaultRemoteWriteTimeout = 10 * time.Second
+)
+
+// MetricSample is a point of a time series pushed to a metrics backend,
+// Prometheus style: a metric name, its labels and a value at a time
+type MetricSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	Time   time.Time
+}
+
+// SeriesKey identifies the series of the sample, its name and sorted labels
+func (s MetricSample) SeriesKey() string {
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := s.Name
+	for _, name := range names {
+		key += "," + name + "=" + s.Labels[name]
+	}
+	return key
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"strings"
+)
+
+// MetadataMirroredFrom names the queue a shadow copy was mirrored from,
+// shadow copies are never mirrored again
+const MetadataMirroredFrom = "mirroredFrom"
+
+// Payload scrubbing actions
+const (
+	ScrubRemove = "remove" // drop the field
+	ScrubMask   = "mask"   // replace the value with "***"
+	ScrubHash   = "hash"   // replace the value with its SHA-256, equal values stay equal
+)
+
+// scrubMaskValue replaces masked values
+const scrubMaskValue = `"***"`
+
+// MirrorConfig copies a share of the messages published to a queue into a
+// shadow queue of the same domain, to try new consumers on real traffic
+type MirrorConfig struct {
+	// Queue is the shadow queue
+	Queue string `yaml:"queue" json:"queue"`
+
+	// Percent of the published messages copied, from 0 (excluded) to 100
+	Percent float64 `yaml:"percent" json:"percent"`
+
+	// Scrub rewrites payload fields of the copies, dotted paths reach nested
+	// objects (customer.email)
+	Scrub []ScrubRule `yaml:"scrub,omitempty" json:"scrub,omitempty"`
+
+	// Headers filters the headers of the copies, all are kept by default
+	Headers *HeaderPropagation `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// ScrubRule rewrites one payload field of shadow copies
+type ScrubRule struct {
+	Field  string `yaml:"field" json:"field"`
+	Action string `yaml:"action" json:"action"`
+}
+
+// Validate checks the mirror of sourceQueue
+func (m *MirrorConfig) Validate(sourceQueue string) error {
+	switch {
+	case m.Queue == "":
+		return fmt.Errorf("%w: shadow queue is required", ErrInvalidMirror)
+	case m.Queue == sourceQueue:
+		return fmt.Errorf("%w: a queue can't mirror to itself", ErrInvalidMirror)
+	case m.Percent <= 0 || m.Percent > 100:
+		return fmt.Errorf("%w: percent must be above 0 and at most 100", ErrInvalidMirror)
+	}
+
+	if err := validateScrubRules(m.Scrub); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMirror, err)
+	}
+	return nil
+}
+
+func validateScrubRules(rules []ScrubRule) error {
+	for _, rule := range rules {
+		if rule.Field == "" || strings.Contains(rule.Field, "..") ||
+			strings.HasPrefix(rule.Field, ".") || strings.HasSuffix(rule.Field, ".") {
+			return fmt.Errorf("invalid scrub field %q", rule.Field)
+		}
+		switch rule.Action {
+		case ScrubRemove, ScrubMask, ScrubHash:
+		default:
+			return fmt.Errorf("scrub action of %s must be %s, %s or %s",
+				rule.Field, ScrubRemove, ScrubMask, ScrubHash)
+		}
+	}
+	return nil
+}
+
+// Samples reports whether the message falls within the mirrored percentage.
+// The choice hashes the message ID, so it doesn't change when a publish is retried.
+func (m *MirrorConfig) Samples(messageID string) bool {
+	if m.Percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(messageID))
+	return float64(h.Sum32()%10000) < m.Percent*100
+}
+
+// ShadowCopy clones a message published to sourceQueue for the shadow queue.
+// It returns false when the payload can't be scrubbed, so that nothing
+// unscrubbed reaches the shadow queue.
+func (m *MirrorConfig) ShadowCopy(message *Message, sourceQueue string) (*Message, bool) {
+	payload := message.Payload
+	if len(m.Scrub) > 0 {
+		var err error
+		if payload, err = scrubPayload(payload, m.Scrub); err != nil {
+			return nil, false
+		}
+	}
+
+	shadow := &Message{
+		ID:        message.ID,
+		Topic:     message.Topic,
+		Payload:   payload,
+		Headers:   m.Headers.Apply(message.Headers),
+		Metadata:  make(map[string]any, len(message.Metadata)+1),
+		Timestamp: message.Timestamp,
+	}
+	maps.Copy(shadow.Metadata, message.Metadata)
+	shadow.Metadata[MetadataMirroredFrom] = sourceQueue
+	return shadow, true
+}
+
+// scrubPayload applies the rules to a JSON object payload, fields missing
+// from the payload are skipped
+func scrubPayload(payload []byte, rules []ScrubRule) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil || object == nil {
+		return nil, fmt.Errorf("scrubbed payloads must be JSON objects")
+	}
+
+	for _, rule := range rules {
+		if err := scrubField(object, strings.Split(rule.Field, "."), rule.Action); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(object)
+}
+
+func scrubField(object map[string]json.RawMessage, path []string, action string) error {
+	value, exists := object[path[0]]
+	if !exists {
+		return nil
+	}
+
+	if len(path) > 1 {
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(value, &nested) != nil || nested == nil {
+			return nil // not an object, nothing below to scrub
+		}
+		if err := scrubField(nested, path[1:], action); err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(nested)
+		if err != nil {
+			return err
+		}
+		object[path[0]] = encoded
+		return nil
+	}
+
+	if action == ScrubRemove {
+		delete(object, path[0])
+		return nil
+	}
+	object[path[0]] = scrubValue(value, action)
+	return nil
+}
+
+// scrubValue rewrites a JSON value for the mask and hash actions
+func scrubValue(value json.RawMessage, action string) json.RawMessage {
+	if action == ScrubHash {
+		sum := sha256.Sum256(value)
+		return json.RawMessage(`"` + hex.EncodeToString(sum[:]) + `"`)
+	}
+	return json.RawMessage(scrubMaskValue)
+}
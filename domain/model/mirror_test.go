@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorSamples(t *testing.T) {
+	mirror := &MirrorConfig{Queue: "shadow", Percent: 25}
+
+	sampled := 0
+	for i := range 10000 {
+		if mirror.Samples(fmt.Sprintf("msg-%d", i)) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 2500, sampled, 250)
+	assert.Equal(t, mirror.Samples("msg-42"), mirror.Samples("msg-42"), "a retried publish is sampled the same way")
+	assert.True(t, (&MirrorConfig{Queue: "shadow", Percent: 100}).Samples("any"))
+}
+
+func TestMirrorShadowCopy(t *testing.T) {
+	mirror := &MirrorConfig{
+		Queue:   "shadow",
+		Percent: 100,
+		Scrub: []ScrubRule{
+			{Field: "card", Action: ScrubRemove},
+			{Field: "customer.email", Action: ScrubMask},
+			{Field: "customer.id", Action: ScrubHash},
+			{Field: "missing.field", Action: ScrubMask},
+		},
+		Headers: &HeaderPropagation{Exclude: []string{"Authorization"}},
+	}
+	require.NoError(t, mirror.Validate("orders"))
+
+	message := &Message{
+		ID:       "1",
+		Payload:  []byte(`{"total":12.5,"card":"4111","customer":{"id":7,"email":"a@b.c"}}`),
+		Headers:  map[string]string{"Authorization": "Bearer x", "traceparent": "00-1"},
+		Metadata: map[string]any{"queue": "orders"},
+	}
+
+	shadow, ok := mirror.ShadowCopy(message, "orders")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"total":12.5,"customer":{"id":"7902699be42c8a8e46fbbb4501726517e86b22c56a189f7625a6da49081b2451","email":"***"}}`, string(shadow.Payload))
+	assert.Equal(t, map[string]string{"traceparent": "00-1"}, shadow.Headers)
+	assert.Equal(t, "orders", shadow.Metadata[MetadataMirroredFrom])
+	assert.NotContains(t, message.Metadata, MetadataMirroredFrom, "the source message stays untouched")
+	assert.Contains(t, string(message.Payload), "4111")
+
+	_, ok = mirror.ShadowCopy(&Message{ID: "2", Payload: []byte("plain text")}, "orders")
+	assert.False(t, ok, "payloads that can't be scrubbed are not mirrored")
+}
+
+func TestMirrorValidate(t *testing.T) {
+	assert.ErrorIs(t, (&MirrorConfig{Percent: 10}).Validate("orders"), ErrInvalidMirror)
+	assert.ErrorIs(t, (&MirrorConfig{Queue: "orders", Percent: 10}).Validate("orders"), ErrInvalidMirror)
+	assert.ErrorIs(t, (&MirrorConfig{Queue: "shadow"}).Validate("orders"), ErrInvalidMirror)
+	assert.ErrorIs(t, (&MirrorConfig{Queue: "shadow", Percent: 10, Scrub: []ScrubRule{{Field: "a..b", Action: ScrubMask}}}).Validate("orders"), ErrInvalidMirror)
+	assert.ErrorIs(t, (&MirrorConfig{Queue: "shadow", Percent: 10, Scrub: []ScrubRule{{Field: "a", Action: "redact"}}}).Validate("orders"), ErrInvalidMirror)
+}
@@ -2,6 +2,9 @@ package model
 
 import (
 	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -14,11 +17,36 @@ type Message struct {
 	Headers   map[string]string // Message headers
 	Metadata  map[string]any    // Metadata for routing and processing
 	Timestamp time.Time         // Message creation timestamp
+
+	// set on envelopes whose payload or metadata still belong to another message
+	sharedPayload  bool
+	sharedMetadata bool
+
+	// set by the inbound adapters, out of reach of the producer
+	publisher *Publisher
 }
 
-// MessageHandler is a callback function for processing messages
+// MessageHandler is a callback function for processing messages.
+// Subscribers receive a pooled envelope that is only valid until the handler
+// returns: copy what must outlive the call instead of keeping the pointer.
 type MessageHandler func(*Message) error
 
+// ClaimCheckSizeKey is the metadata entry holding the size of a payload moved
+// to the blob store, the stored message keeping no payload
+const ClaimCheckSizeKey = "claimCheckSize"
+
+// PayloadSize returns the size of the payload, counting one moved to the blob
+// store
+func (m *Message) PayloadSize() int {
+	switch size := m.Metadata[ClaimCheckSizeKey].(type) {
+	case int:
+		return size
+	case float64: // metadata read back from JSON
+		return int(size)
+	}
+	return len(m.Payload)
+}
+
 // Queue represents a message queue
 type Queue struct {
 	Name         string      // Queue name
@@ -51,6 +79,63 @@ type QueueConfig struct {
 	// CircuitBreakerEnabled enables the circuit breaker
 	CircuitBreakerEnabled bool                  `yaml:"circuitBreakerEnabled"`
 	CircuitBreakerConfig  *CircuitBreakerConfig `yaml:"circuitBreakerConfig,omitempty"`
+
+	// Labels are free-form key/value tags (team=payments, env=prod)
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// EncryptedFields lists the top-level payload fields encrypted with the
+	// queue key on publish, see EncryptedField
+	EncryptedFields []string `yaml:"encryptedFields,omitempty"`
+
+	// Mirror copies a share of the published messages into a shadow queue
+	Mirror *MirrorConfig `yaml:"mirror,omitempty"`
+
+	// Partitions spreads the messages over that many partitions, each with
+	// its own channel and workers, consumers of a group read disjoint
+	// partitions (0 or 1 = not partitioned)
+	Partitions int `yaml:"partitions,omitempty"`
+
+	// OrderingKey is the payload field hashed to pick the partition when the
+	// message has no X-Ordering-Key header, dotted paths reach nested objects
+	OrderingKey string `yaml:"orderingKey,omitempty"`
+
+	// Compaction keeps only the latest message per key
+	Compaction *CompactionConfig `yaml:"compaction,omitempty"`
+
+	// Enrichment injects server-side values into the published messages
+	Enrichment []EnrichmentRule `yaml:"enrichment,omitempty"`
+
+	// ReadReplica serves the scans of the queue away from its storage
+	ReadReplica *ReadReplicaConfig `yaml:"readReplica,omitempty"`
+
+	// Hooks report when the queue fills up, drains, or gains or loses its
+	// consumers
+	Hooks *QueueHooks `yaml:"hooks,omitempty"`
+
+	// DeadLetter sends the messages the queue gives up on to another queue,
+	// with the reason and the queue they come from
+	DeadLetter *DeadLetterConfig `yaml:"deadLetter,omitempty"`
+
+	// Mode makes the queue read-only or write-only, empty is read-write
+	Mode QueueMode `yaml:"mode,omitempty"`
+
+	// ImplicitGroups allows (default) or denies consuming through groups
+	// that weren't created first
+	ImplicitGroups ImplicitGroupPolicy `yaml:"implicitGroups,omitempty"`
+}
+
+// SameSettings reports whether two configs agree on everything but their
+// labels, mirror, enrichment, mode and implicit group policy, the only parts
+// of a queue config that can change after creation. WorkerCount is a server
+// side tuning and isn't compared either.
+func (c QueueConfig) SameSettings(other QueueConfig) bool {
+	c.Labels, other.Labels = nil, nil
+	c.Mirror, other.Mirror = nil, nil
+	c.Enrichment, other.Enrichment = nil, nil
+	c.Mode, other.Mode = "", ""
+	c.ImplicitGroups, other.ImplicitGroups = "", ""
+	c.WorkerCount, other.WorkerCount = 0, 0
+	return reflect.DeepEqual(c, other)
 }
 
 // CircuitBreakerConfig defines the circuit breaker configuration
@@ -59,6 +144,29 @@ type CircuitBreakerConfig struct {
 	MinimumRequests  int           `yaml:"minimumRequests"`
 	OpenTimeout      time.Duration `yaml:"openTimeout"`
 	SuccessThreshold int           `yaml:"successThreshold"`
+
+	// FallbackQueue receives the messages published while the circuit is
+	// open, instead of leaving them out of the queue
+	FallbackQueue string `yaml:"fallbackQueue,omitempty"`
+}
+
+// MetadataDivertedFrom names the queue whose open circuit diverted the
+// message to its fallback queue, diverted messages are never diverted again
+const MetadataDivertedFrom = "divertedFrom"
+
+// MetadataMovedFrom names the queue a message was moved from by hand
+const MetadataMovedFrom = "movedFrom"
+
+// Fallback returns the queue a message published to q goes to while the
+// circuit is open, empty when it has none
+func (q *Queue) Fallback(message *Message) string {
+	if q == nil || q.Config.CircuitBreakerConfig == nil {
+		return ""
+	}
+	if _, diverted := message.Metadata[MetadataDivertedFrom]; diverted {
+		return ""
+	}
+	return q.Config.CircuitBreakerConfig.FallbackQueue
 }
 
 // QueueHandler defines the operations for a concurrent queue implementation
@@ -81,8 +189,8 @@ type QueueHandler interface {
 	// For dual-channel system
 	AddConsumerGroup(groupID string, lastIndex int64) error
 	RemoveConsumerGroup(groupID string)
-	RequestMessages(groupID string, count int) error
-	ConsumeMessage(groupID string, timeout time.Duration) (*Message, error)
+	RequestMessages(ctx context.Context, groupID string, count int) error
+	ConsumeMessage(ctx context.Context, groupID string, timeout time.Duration) (*Message, error)
 }
 
 // RetryConfig defines the configuration for retrying failed messages
@@ -140,16 +248,29 @@ type Domain struct {
 	Name   string                             // Domain name
 	Schema *Schema                            // Validation schema
 	Queues map[string]*Queue                  // Map of queues by domainName
-	Routes map[string]map[string]*RoutingRule // Map of routing rules (sourceQueue -> destQueue -> rule)
+	Routes map[string]map[string]*RoutingRule // Map of routing rules (sourceQueue -> destination key -> rule)
 	System bool
+
+	// AllowedRouteSources lists the domains allowed to route messages
+	// into this domain ("*" accepts any domain)
+	AllowedRouteSources []string
+
+	// Labels are free-form key/value tags inherited by the domain's queues
+	Labels map[string]string
+
+	// Version is assigned by the repository each time the domain is stored,
+	// it never repeats, even across a delete and recreate
+	Version uint64
 }
 
 // DomainConfig contains the configuration of a domain
 type DomainConfig struct {
-	Name         string                 // Domain name
-	Schema       *Schema                // Validation schema
-	QueueConfigs map[string]QueueConfig // Queue configurations
-	RoutingRules []*RoutingRule         // Routing rules
+	Name                string                 // Domain name
+	Schema              *Schema                // Validation schema
+	QueueConfigs        map[string]QueueConfig // Queue configurations
+	RoutingRules        []*RoutingRule         // Routing rules
+	AllowedRouteSources []string               // Domains allowed to route into this one
+	Labels              map[string]string      // Free-form key/value tags
 }
 
 type SchemaInfo struct {
@@ -161,12 +282,14 @@ type SchemaInfo struct {
 // SystemEvent represents a system event
 type SystemEvent struct {
 	ID        string    `json:"id"`
-	Type      string    `json:"type"`      // "info", "warning", "error"
-	EventType string    `json:"eventType"` // "domain_active", "queue_capacity", "connection_lost"
-	Resource  string    `json:"resource"`  // Name of the affected resource
-	Data      any       `json:"data"`      // Additional data (e.g., capacity percentage)
-	Timestamp time.Time `json:"-"`         // For internal use
-	UnixTime  int64     `json:"timestamp"` // Unix timestamp for the client
+	Seq       uint64    `json:"seq"`              // bumped each time the event is recorded or updated
+	Domain    string    `json:"domain,omitempty"` // domain of the resource
+	Type      string    `json:"type"`             // "info", "warning", "error"
+	EventType string    `json:"eventType"`        // "domain_active", "queue_capacity", "connection_lost"
+	Resource  string    `json:"resource"`         // Name of the affected resource
+	Data      any       `json:"data"`             // Additional data (e.g., capacity percentage)
+	Timestamp time.Time `json:"-"`                // For internal use
+	UnixTime  int64     `json:"timestamp"`        // Unix timestamp for the client
 }
 
 // Schema defines the structure of messages for a domain
@@ -178,6 +301,19 @@ type Schema struct {
 	Validation func([]byte) error
 }
 
+// SameFields reports whether two schemas require the same fields,
+// a nil schema requiring none
+func (s *Schema) SameFields(other *Schema) bool {
+	var fields, otherFields map[string]FieldType
+	if s != nil {
+		fields = s.Fields
+	}
+	if other != nil {
+		otherFields = other.Fields
+	}
+	return maps.Equal(fields, otherFields)
+}
+
 // FieldType defines the type of a field in the schema
 type FieldType string
 
@@ -197,8 +333,58 @@ type RoutingRule struct {
 	// DestinationQueue is the target queue
 	DestinationQueue string
 
+	// DestinationDomain is the target domain for cross-domain rules
+	// (empty = same domain as the source queue)
+	DestinationDomain string
+
 	// Predicate is a function or object that determines if a message should be routed
 	Predicate any
+
+	// Headers controls which headers the routed copy keeps (nil = all)
+	Headers *HeaderPropagation
+
+	// Canary sends a share of the matching messages to another queue
+	// (nil = all go to DestinationQueue)
+	Canary *CanarySplit
+
+	// Enabled turns the rule off without removing it (nil = enabled)
+	Enabled *bool
+
+	// Priority orders the rules of a source queue, higher is evaluated first
+	Priority int
+
+	// StopOnMatch skips the rules evaluated after this one once it routed
+	// a message
+	StopOnMatch bool
+
+	stats routingCounters // lives and dies with the rule
+}
+
+// IsCrossDomain reports whether the rule routes outside of the given source domain
+func (r *RoutingRule) IsCrossDomain(sourceDomain string) bool {
+	return r.DestinationDomain != "" && r.DestinationDomain != sourceDomain
+}
+
+// DestinationKey returns the key used to store the rule in Domain.Routes.
+// Cross-domain destinations are prefixed with their domain ("domain:queue").
+func (r *RoutingRule) DestinationKey(sourceDomain string) string {
+	if r.IsCrossDomain(sourceDomain) {
+		return r.DestinationDomain + ":" + r.DestinationQueue
+	}
+	return r.DestinationQueue
+}
+
+// AcceptsRoutesFrom checks if the domain allows routing from the given source domain
+func (d *Domain) AcceptsRoutesFrom(sourceDomain string) bool {
+	if d.Name == sourceDomain {
+		return true
+	}
+	for _, allowed := range d.AllowedRouteSources {
+		if allowed == "*" || allowed == sourceDomain {
+			return true
+		}
+	}
+	return false
 }
 
 // PredicateFunc is a function that determines whether a message should be routed
@@ -211,6 +397,29 @@ type JSONPredicate struct {
 	Value any    `json:"value"` // Value to compare
 }
 
+// SameRouting reports whether two rules route the same messages the same
+// way: predicate, header policy and canary split
+func (r *RoutingRule) SameRouting(other *RoutingRule) bool {
+	return SamePredicate(r.Predicate, other.Predicate) &&
+		reflect.DeepEqual(r.Headers, other.Headers) &&
+		reflect.DeepEqual(r.Canary, other.Canary)
+}
+
+// SamePredicate compares routing predicates by their JSON form, a
+// JSONPredicate matching the map decoded from the same JSON
+func SamePredicate(a, b any) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	var aValue, bValue any
+	json.Unmarshal(aJSON, &aValue)
+	json.Unmarshal(bJSON, &bValue)
+	return reflect.DeepEqual(aValue, bValue)
+}
+
 // Allow checks if an operation is allowed
 func (cb *CircuitBreaker) Allow() bool {
 	cb.mu.RLock()
@@ -0,0 +1,46 @@
+package model
+
+// NotificationType identifies what a notification is about, each type is
+// enabled and templated on its own
+type NotificationType string
+
+const (
+	// NotificationAlert reports a queue reaching a capacity alert level
+	NotificationAlert NotificationType = "alert"
+
+	// NotificationAlertResolved reports a queue leaving a capacity alert
+	// level, back under it or moved to another level
+	NotificationAlertResolved NotificationType = "alert-resolved"
+
+	// NotificationAccountRequest tells the administrators about a new
+	// account request
+	NotificationAccountRequest NotificationType = "account-request"
+
+	// NotificationAccountReviewed tells the requester the outcome of an
+	// account request
+	NotificationAccountReviewed NotificationType = "account-reviewed"
+
+	// NotificationPasswordReset sends a user the temporary password set by
+	// an administrator
+	NotificationPasswordReset NotificationType = "password-reset"
+)
+
+// NotificationTypes lists the known notification types
+var NotificationTypes = []NotificationType{
+	NotificationAlert,
+	NotificationAlertResolved,
+	NotificationAccountRequest,
+	NotificationAccountReviewed,
+	NotificationPasswordReset,
+}
+
+// Notification is a message for people rather than consumers
+type Notification struct {
+	Type NotificationType
+
+	// To adds recipients to the ones configured for the type
+	To []string
+
+	// Data holds the fields the templates of the type refer to
+	Data map[string]any
+}
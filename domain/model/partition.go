@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HeaderOrderingKey carries the key hashed to pick the partition of a
+	// message, messages sharing a key keep their order
+	HeaderOrderingKey = "X-Ordering-Key"
+
+	// MetadataPartition is the partition a message was stored in
+	MetadataPartition = "partition"
+
+	// MaxPartitions bounds the partitions of a queue
+	MaxPartitions = 64
+
+	// partitionSeparator joins a queue name and a partition number into the
+	// name the partition is stored under, queue names can't contain it
+	partitionSeparator = "#"
+)
+
+// ValidatePartitions checks the partitioning of queueName
+func (c QueueConfig) ValidatePartitions(queueName string) error {
+	switch {
+	case c.Partitions < 0 || c.Partitions > MaxPartitions:
+		return fmt.Errorf("%w: partitions must be between 0 and %d", ErrInvalidPartitions, MaxPartitions)
+	case strings.Contains(queueName, partitionSeparator):
+		return fmt.Errorf("%w: queue names can't contain %q", ErrInvalidPartitions, partitionSeparator)
+	case c.OrderingKey != "" && c.Partitions < 2:
+		return fmt.Errorf("%w: an ordering key needs at least 2 partitions", ErrInvalidPartitions)
+	}
+	return nil
+}
+
+// Partitioned reports whether the messages of q are spread over partitions
+func (q *Queue) Partitioned() bool {
+	return q != nil && q.Config.Partitions > 1
+}
+
+// Partition returns the partition of a message published to q. Messages
+// sharing an ordering key, from the X-Ordering-Key header or the payload
+// field named by the queue, land in the same partition; the others are
+// spread by ID.
+func (q *Queue) Partition(message *Message) int {
+	key := message.Headers[HeaderOrderingKey]
+	if key == "" && q.Config.OrderingKey != "" {
+		if value, ok := payloadField(message.Payload, q.Config.OrderingKey); ok {
+			key = value
+		}
+	}
+	if key == "" {
+		key = message.ID
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(q.Config.Partitions))
+}
+
+// PartitionQueue returns the queue a partition of q is stored as, it shares
+// the settings of q but its own channel and workers
+func (q *Queue) PartitionQueue(partition int) *Queue {
+	config := q.Config
+	config.Partitions = 0
+	config.OrderingKey = ""
+	return &Queue{
+		Name:       PartitionQueueName(q.Name, partition),
+		DomainName: q.DomainName,
+		Config:     config,
+	}
+}
+
+// StorageQueues returns the names the messages of q are stored under, its
+// partitions when it has some
+func (q *Queue) StorageQueues() []string {
+	if !q.Partitioned() {
+		return []string{q.Name}
+	}
+	names := make([]string, q.Config.Partitions)
+	for partition := range names {
+		names[partition] = PartitionQueueName(q.Name, partition)
+	}
+	return names
+}
+
+// PartitionQueueName is the name partition of queueName is stored under
+func PartitionQueueName(queueName string, partition int) string {
+	return queueName + partitionSeparator + strconv.Itoa(partition)
+}
+
+// ParsePartitionQueueName splits the name of a partition, ok is false for
+// the name of a queue
+func ParsePartitionQueueName(name string) (queueName string, partition int, ok bool) {
+	queueName, rawPartition, found := strings.Cut(name, partitionSeparator)
+	if !found {
+		return name, 0, false
+	}
+	partition, err := strconv.Atoi(rawPartition)
+	if err != nil || partition < 0 {
+		return name, 0, false
+	}
+	return queueName, partition, true
+}
+
+// PartitionParent returns the queue a partition belongs to, queue names are
+// returned unchanged
+func PartitionParent(name string) string {
+	queueName, _, _ := ParsePartitionQueueName(name)
+	return queueName
+}
+
+// AssignPartitions returns the partitions consumerID reads among the
+// consumers of its group. Partitions are dealt in turn to the consumers
+// sorted by ID, so the consumers of a group read disjoint partitions; an
+// anonymous consumer reads them all and consumers beyond the partition
+// count read none.
+func AssignPartitions(partitions int, consumerIDs []string, consumerID string) []int {
+	consumers := slices.Clone(consumerIDs)
+	slices.Sort(consumers)
+	consumers = slices.Compact(consumers)
+
+	position := slices.Index(consumers, consumerID)
+	if consumerID == "" || position < 0 {
+		assigned := make([]int, partitions)
+		for p := range assigned {
+			assigned[p] = p
+		}
+		return assigned
+	}
+
+	var assigned []int
+	for p := position; p < partitions; p += len(consumers) {
+		assigned = append(assigned, p)
+	}
+	return assigned
+}
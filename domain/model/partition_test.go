@@ -0,0 +1,67 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueuePartition(t *testing.T) {
+	queue := &Queue{Name: "orders", Config: QueueConfig{Partitions: 8, OrderingKey: "customer.id"}}
+
+	byPayload := queue.Partition(&Message{ID: "1", Payload: []byte(`{"customer":{"id":"c-42"}}`)})
+	byHeader := queue.Partition(&Message{ID: "2", Headers: map[string]string{HeaderOrderingKey: "c-42"}})
+	assert.Equal(t, byPayload, byHeader, "messages of a key share a partition")
+
+	used := make(map[int]bool)
+	for i := range 200 {
+		partition := queue.Partition(&Message{ID: fmt.Sprintf("msg-%d", i), Payload: []byte(`{}`)})
+		require.True(t, partition >= 0 && partition < 8)
+		used[partition] = true
+	}
+	assert.Len(t, used, 8, "messages without key are spread by ID")
+}
+
+func TestPartitionQueueName(t *testing.T) {
+	name := PartitionQueueName("orders", 3)
+	queueName, partition, ok := ParsePartitionQueueName(name)
+	assert.True(t, ok)
+	assert.Equal(t, "orders", queueName)
+	assert.Equal(t, 3, partition)
+	assert.Equal(t, "orders", PartitionParent(name))
+
+	_, _, ok = ParsePartitionQueueName("orders")
+	assert.False(t, ok)
+	assert.Equal(t, "orders", PartitionParent("orders"))
+
+	partitionQueue := (&Queue{Name: "orders", DomainName: "shop", Config: QueueConfig{Partitions: 4, MaxSize: 10}}).PartitionQueue(3)
+	assert.Equal(t, name, partitionQueue.Name)
+	assert.Equal(t, 10, partitionQueue.Config.MaxSize)
+	assert.False(t, partitionQueue.Partitioned())
+}
+
+func TestAssignPartitions(t *testing.T) {
+	consumers := []string{"c3", "c1", "c2"}
+
+	var all []int
+	for _, consumer := range consumers {
+		all = append(all, AssignPartitions(8, consumers, consumer)...)
+	}
+	slices.Sort(all)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, all, "each partition has a single consumer")
+	assert.Equal(t, []int{0, 3, 6}, AssignPartitions(8, consumers, "c1"))
+
+	assert.Len(t, AssignPartitions(4, consumers, ""), 4, "anonymous consumers read every partition")
+	assert.Empty(t, AssignPartitions(2, consumers, "c3"), "consumers beyond the partition count idle")
+}
+
+func TestValidatePartitions(t *testing.T) {
+	assert.NoError(t, QueueConfig{}.ValidatePartitions("orders"))
+	assert.NoError(t, QueueConfig{Partitions: 4, OrderingKey: "customer"}.ValidatePartitions("orders"))
+	assert.ErrorIs(t, QueueConfig{Partitions: MaxPartitions + 1}.ValidatePartitions("orders"), ErrInvalidPartitions)
+	assert.ErrorIs(t, QueueConfig{Partitions: 4}.ValidatePartitions("orders#1"), ErrInvalidPartitions)
+	assert.ErrorIs(t, QueueConfig{OrderingKey: "customer"}.ValidatePartitions("orders"), ErrInvalidPartitions)
+}
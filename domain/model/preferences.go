@@ -0,0 +1,101 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxPinnedQueues bounds the queues a user pins
+	MaxPinnedQueues = 100
+
+	// MaxSavedSearches bounds the searches a user saves
+	MaxSavedSearches = 50
+)
+
+// StatsPeriods and StatsGranularities are the values GET /api/stats accepts
+var (
+	StatsPeriods       = []string{"1h", "6h", "12h", "24h"}
+	StatsGranularities = []string{"auto", "10s", "1m", "5m", "15m", "30m", "1h"}
+)
+
+// PinnedQueue is a queue a user keeps at hand in the UI
+type PinnedQueue struct {
+	Domain string `json:"domain"`
+	Queue  string `json:"queue"`
+}
+
+// SavedSearch is a message search kept under a name: the indexed field
+// values of Query, in Domain or in every domain when empty
+type SavedSearch struct {
+	Name   string            `json:"name"`
+	Domain string            `json:"domain,omitempty"`
+	Query  map[string]string `json:"query"`
+}
+
+// UserPreferences persists the UI workflow of a user server-side
+type UserPreferences struct {
+	Username           string        `json:"username"`
+	DefaultPeriod      string        `json:"defaultPeriod,omitempty"`
+	DefaultGranularity string        `json:"defaultGranularity,omitempty"`
+	PinnedQueues       []PinnedQueue `json:"pinnedQueues"`
+	SavedSearches      []SavedSearch `json:"savedSearches"`
+	UpdatedAt          time.Time     `json:"updatedAt"`
+}
+
+// NewUserPreferences returns the preferences of a user who saved none
+func NewUserPreferences(username string) *UserPreferences {
+	return &UserPreferences{
+		Username:      username,
+		PinnedQueues:  []PinnedQueue{},
+		SavedSearches: []SavedSearch{},
+	}
+}
+
+// Validate checks the stats defaults against the values the stats accept,
+// and the pinned queues and saved searches are complete and unique
+func (p *UserPreferences) Validate() error {
+	if p.DefaultPeriod != "" && !slices.Contains(StatsPeriods, p.DefaultPeriod) {
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidPreferences, p.DefaultPeriod)
+	}
+	if p.DefaultGranularity != "" && !slices.Contains(StatsGranularities, p.DefaultGranularity) {
+		return fmt.Errorf("%w: unknown granularity %q", ErrInvalidPreferences, p.DefaultGranularity)
+	}
+
+	if len(p.PinnedQueues) > MaxPinnedQueues {
+		return fmt.Errorf("%w: at most %d pinned queues", ErrInvalidPreferences, MaxPinnedQueues)
+	}
+	pinned := make(map[PinnedQueue]bool, len(p.PinnedQueues))
+	for _, queue := range p.PinnedQueues {
+		if queue.Domain == "" || queue.Queue == "" {
+			return fmt.Errorf("%w: a pinned queue needs a domain and a queue", ErrInvalidPreferences)
+		}
+		if pinned[queue] {
+			return fmt.Errorf("%w: %s/%s pinned twice", ErrInvalidPreferences, queue.Domain, queue.Queue)
+		}
+		pinned[queue] = true
+	}
+
+	if len(p.SavedSearches) > MaxSavedSearches {
+		return fmt.Errorf("%w: at most %d saved searches", ErrInvalidPreferences, MaxSavedSearches)
+	}
+	names := make(map[string]bool, len(p.SavedSearches))
+	for _, search := range p.SavedSearches {
+		name := strings.TrimSpace(search.Name)
+		if name == "" || len(search.Query) == 0 {
+			return fmt.Errorf("%w: a saved search needs a name and a query", ErrInvalidPreferences)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: saved search %q defined twice", ErrInvalidPreferences, name)
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// PreferencesDatabase is the stored form of the preferences of every user
+type PreferencesDatabase struct {
+	Preferences map[string]*UserPreferences `json:"preferences"` // by username
+}
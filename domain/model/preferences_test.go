@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPreferencesValidate(t *testing.T) {
+	preferences := NewUserPreferences("alice")
+	preferences.DefaultPeriod = "24h"
+	preferences.DefaultGranularity = "5m"
+	preferences.PinnedQueues = []PinnedQueue{{Domain: "shop", Queue: "orders"}}
+	preferences.SavedSearches = []SavedSearch{{Name: "vip", Domain: "shop", Query: map[string]string{"customerId": "42"}}}
+	assert.NoError(t, preferences.Validate())
+
+	invalid := []func(p *UserPreferences){
+		func(p *UserPreferences) { p.DefaultPeriod = "2d" },
+		func(p *UserPreferences) { p.DefaultGranularity = "2s" },
+		func(p *UserPreferences) { p.PinnedQueues = append(p.PinnedQueues, PinnedQueue{Domain: "shop"}) },
+		func(p *UserPreferences) { p.PinnedQueues = append(p.PinnedQueues, p.PinnedQueues[0]) },
+		func(p *UserPreferences) { p.SavedSearches = append(p.SavedSearches, SavedSearch{Name: " vip ", Query: map[string]string{"status": "paid"}}) },
+		func(p *UserPreferences) { p.SavedSearches = append(p.SavedSearches, SavedSearch{Name: "no query", Domain: "shop"}) },
+	}
+	for _, change := range invalid {
+		copied := *preferences
+		copied.PinnedQueues = append([]PinnedQueue{}, preferences.PinnedQueues...)
+		copied.SavedSearches = append([]SavedSearch{}, preferences.SavedSearches...)
+		change(&copied)
+		assert.ErrorIs(t, copied.Validate(), ErrInvalidPreferences)
+	}
+}
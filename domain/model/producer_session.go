@@ -0,0 +1,167 @@
+package model
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderProducerID and HeaderProducerSeq identify an idempotent publish
+	HeaderProducerID  = "X-Producer-ID"
+	HeaderProducerSeq = "X-Producer-Seq"
+
+	// ProducerSessionWindow is the number of recent sequences remembered per session
+	ProducerSessionWindow = 256
+
+	// ProducerSessionTTL is how long an idle session is kept
+	ProducerSessionTTL = 1 * time.Hour
+)
+
+// ProducerSession is the public view of a producer's progress on a queue
+type ProducerSession struct {
+	ProducerID string    `json:"producerId"`
+	LastSeq    uint64    `json:"lastSeq"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+type producerSession struct {
+	ProducerSession
+	recent map[uint64]string // seq -> message ID
+	order  []uint64
+}
+
+// ProducerSessions deduplicates retried publishes per producer and queue
+type ProducerSessions struct {
+	sessions map[string]*producerSession
+	window   int
+	ttl      time.Duration
+	mu       sync.Mutex
+}
+
+func NewProducerSessions(window int, ttl time.Duration) *ProducerSessions {
+	if window <= 0 {
+		window = ProducerSessionWindow
+	}
+	return &ProducerSessions{
+		sessions: make(map[string]*producerSession),
+		window:   window,
+		ttl:      ttl,
+	}
+}
+
+func producerSessionKey(domainName, queueName, producerID string) string {
+	return domainName + ":" + queueName + ":" + producerID
+}
+
+// ProducerSequence reads the producer headers of a message, ok is false
+// when the message is not part of a producer session
+func ProducerSequence(msg *Message) (producerID string, seq uint64, ok bool, err error) {
+	producerID = msg.Headers[HeaderProducerID]
+	rawSeq := msg.Headers[HeaderProducerSeq]
+	if producerID == "" && rawSeq == "" {
+		return "", 0, false, nil
+	}
+	if producerID == "" || rawSeq == "" {
+		return "", 0, false, ErrInvalidProducerSequence
+	}
+
+	seq, err = strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil || seq == 0 {
+		return "", 0, false, ErrInvalidProducerSequence
+	}
+	return producerID, seq, true, nil
+}
+
+// Accept registers seq for the producer. A sequence already seen returns
+// ErrDuplicatePublish with the ID of the message published the first time.
+func (p *ProducerSessions) Accept(domainName, queueName, producerID string, seq uint64, messageID string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.purgeLocked(now)
+
+	key := producerSessionKey(domainName, queueName, producerID)
+	session, exists := p.sessions[key]
+	if !exists {
+		session = &producerSession{
+			ProducerSession: ProducerSession{ProducerID: producerID},
+			recent:          make(map[uint64]string),
+		}
+		p.sessions[key] = session
+	}
+	session.LastSeen = now
+
+	if originalID, seen := session.recent[seq]; seen {
+		return originalID, ErrDuplicatePublish
+	}
+	if seq <= session.LastSeq {
+		return "", ErrStaleProducerSequence
+	}
+
+	session.LastSeq = seq
+	session.recent[seq] = messageID
+	session.order = append(session.order, seq)
+	if len(session.order) > p.window {
+		delete(session.recent, session.order[0])
+		session.order = session.order[1:]
+	}
+
+	return messageID, nil
+}
+
+// Forget drops a sequence whose publish failed so the producer can retry it
+func (p *ProducerSessions) Forget(domainName, queueName, producerID string, seq uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	session, exists := p.sessions[producerSessionKey(domainName, queueName, producerID)]
+	if !exists {
+		return
+	}
+	if _, seen := session.recent[seq]; !seen {
+		return
+	}
+
+	delete(session.recent, seq)
+	for i, s := range session.order {
+		if s == seq {
+			session.order = append(session.order[:i], session.order[i+1:]...)
+			break
+		}
+	}
+
+	// roll back so the retry is not rejected as stale
+	if session.LastSeq == seq {
+		session.LastSeq = 0
+		for _, s := range session.order {
+			session.LastSeq = max(session.LastSeq, s)
+		}
+	}
+}
+
+// Get returns the producer progress, used by producers resuming after a restart
+func (p *ProducerSessions) Get(domainName, queueName, producerID string) (ProducerSession, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.purgeLocked(time.Now())
+
+	session, exists := p.sessions[producerSessionKey(domainName, queueName, producerID)]
+	if !exists {
+		return ProducerSession{}, false
+	}
+	return session.ProducerSession, true
+}
+
+func (p *ProducerSessions) purgeLocked(now time.Time) {
+	if p.ttl <= 0 {
+		return
+	}
+	for key, session := range p.sessions {
+		if now.Sub(session.LastSeen) > p.ttl {
+			delete(p.sessions, key)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerSequence(t *testing.T) {
+	_, _, ok, err := ProducerSequence(&Message{})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	producerID, seq, ok, err := ProducerSequence(&Message{Headers: map[string]string{
+		HeaderProducerID:  "billing",
+		HeaderProducerSeq: "42",
+	}})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "billing", producerID)
+	assert.Equal(t, uint64(42), seq)
+
+	for _, headers := range []map[string]string{
+		{HeaderProducerID: "billing"},
+		{HeaderProducerSeq: "1"},
+		{HeaderProducerID: "billing", HeaderProducerSeq: "0"},
+		{HeaderProducerID: "billing", HeaderProducerSeq: "abc"},
+	} {
+		_, _, _, err := ProducerSequence(&Message{Headers: headers})
+		assert.ErrorIs(t, err, ErrInvalidProducerSequence, "%v", headers)
+	}
+}
+
+func TestProducerSessionsAccept(t *testing.T) {
+	sessions := NewProducerSessions(2, time.Hour)
+
+	id, err := sessions.Accept("orders", "incoming", "billing", 1, "msg-1")
+	require.NoError(t, err)
+	assert.Equal(t, "msg-1", id)
+
+	t.Run("retry returns the original message", func(t *testing.T) {
+		id, err := sessions.Accept("orders", "incoming", "billing", 1, "msg-1-retry")
+		assert.ErrorIs(t, err, ErrDuplicatePublish)
+		assert.Equal(t, "msg-1", id)
+	})
+
+	t.Run("sessions are scoped per queue", func(t *testing.T) {
+		_, err := sessions.Accept("orders", "archive", "billing", 1, "msg-a")
+		assert.NoError(t, err)
+	})
+
+	t.Run("sequences older than the window are stale", func(t *testing.T) {
+		_, err := sessions.Accept("orders", "incoming", "billing", 2, "msg-2")
+		require.NoError(t, err)
+		_, err = sessions.Accept("orders", "incoming", "billing", 3, "msg-3")
+		require.NoError(t, err)
+
+		_, err = sessions.Accept("orders", "incoming", "billing", 1, "msg-1-late")
+		assert.ErrorIs(t, err, ErrStaleProducerSequence)
+	})
+
+	t.Run("forgotten sequence can be retried", func(t *testing.T) {
+		_, err := sessions.Accept("orders", "incoming", "billing", 4, "msg-4")
+		require.NoError(t, err)
+		sessions.Forget("orders", "incoming", "billing", 4)
+
+		session, ok := sessions.Get("orders", "incoming", "billing")
+		require.True(t, ok)
+		assert.Equal(t, uint64(3), session.LastSeq)
+
+		id, err := sessions.Accept("orders", "incoming", "billing", 4, "msg-4-retry")
+		assert.NoError(t, err)
+		assert.Equal(t, "msg-4-retry", id)
+	})
+}
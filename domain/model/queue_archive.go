@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// QueueArchiveVersion is the current format version of queue archives
+const QueueArchiveVersion = 1
+
+// QueueArchive is a portable snapshot of a single queue,
+// used for debugging and data migration between queues or instances
+type QueueArchive struct {
+	Version   int                `json:"version"`
+	Domain    string             `json:"domain"`
+	Queue     string             `json:"queue"`
+	Config    QueueConfig        `json:"config"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Count     int                `json:"count"`
+	Messages  []*ArchivedMessage `json:"messages"`
+}
+
+// ArchivedMessage is the serializable form of a Message inside an archive
+type ArchivedMessage struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic,omitempty"`
+	Payload   []byte            `json:"payload"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Metadata  map[string]any    `json:"metadata,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// QueueRestoreResult summarizes a restore operation
+type QueueRestoreResult struct {
+	Domain       string `json:"domain"`
+	Queue        string `json:"queue"`
+	Restored     int    `json:"restored"`
+	Skipped      int    `json:"skipped"`
+	QueueCreated bool   `json:"queueCreated"`
+}
+
+// NewArchivedMessage converts a message to its archived form.
+// Metadata entries that cannot be serialized (e.g. retry handlers) are dropped.
+func NewArchivedMessage(msg *Message) *ArchivedMessage {
+	archived := &ArchivedMessage{
+		ID:        msg.ID,
+		Topic:     msg.Topic,
+		Payload:   msg.Payload,
+		Timestamp: msg.Timestamp,
+	}
+
+	if len(msg.Headers) > 0 {
+		archived.Headers = make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			archived.Headers[k] = v
+		}
+	}
+
+	for k, v := range msg.Metadata {
+		if _, err := json.Marshal(v); err != nil {
+			continue
+		}
+		if archived.Metadata == nil {
+			archived.Metadata = make(map[string]any)
+		}
+		archived.Metadata[k] = v
+	}
+
+	return archived
+}
+
+// ToMessage converts an archived message back to a Message
+func (a *ArchivedMessage) ToMessage() *Message {
+	msg := &Message{
+		ID:        a.ID,
+		Topic:     a.Topic,
+		Payload:   a.Payload,
+		Headers:   make(map[string]string, len(a.Headers)),
+		Metadata:  make(map[string]any, len(a.Metadata)),
+		Timestamp: a.Timestamp,
+	}
+
+	for k, v := range a.Headers {
+		msg.Headers[k] = v
+	}
+	for k, v := range a.Metadata {
+		msg.Metadata[k] = v
+	}
+
+	return msg
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+)
+
+const (
+	// DefaultDigestBucketSize is the number of messages a digest bucket covers
+	DefaultDigestBucketSize = 1000
+
+	// MaxDigestBucketSize bounds the bucket size a digest may be asked for
+	MaxDigestBucketSize = 100000
+)
+
+// DigestBucket fingerprints a run of consecutive stored messages
+type DigestBucket struct {
+	First    int    `json:"first"` // offset of its first message in the partition
+	Count    int    `json:"count"`
+	FirstID  string `json:"firstId"`
+	LastID   string `json:"lastId"`
+	Checksum string `json:"checksum"`
+}
+
+// PartitionDigest fingerprints the messages stored in a partition, in order
+type PartitionDigest struct {
+	StorageQueue string         `json:"storageQueue"`
+	Messages     int            `json:"messages"`
+	Checksum     string         `json:"checksum"` // over the bucket checksums
+	Buckets      []DigestBucket `json:"buckets"`
+}
+
+// QueueDigest fingerprints the messages stored in a queue, two copies of a
+// queue holding the same messages in the same order have equal digests.
+// Buckets narrow a divergence down to the messages to compare.
+type QueueDigest struct {
+	Domain     string            `json:"domain"`
+	Queue      string            `json:"queue"`
+	BucketSize int               `json:"bucketSize"`
+	ComputedAt time.Time         `json:"computedAt"`
+	Partitions []PartitionDigest `json:"partitions"`
+}
+
+// DigestDivergence is a bucket differing between two digests of a queue,
+// an empty checksum means the side has no such bucket
+type DigestDivergence struct {
+	StorageQueue   string `json:"storageQueue"`
+	First          int    `json:"first"`
+	LocalCount     int    `json:"localCount"`
+	RemoteCount    int    `json:"remoteCount"`
+	LocalChecksum  string `json:"localChecksum,omitempty"`
+	RemoteChecksum string `json:"remoteChecksum,omitempty"`
+	LocalFirstID   string `json:"localFirstId,omitempty"`
+	RemoteFirstID  string `json:"remoteFirstId,omitempty"`
+}
+
+// NewPartitionDigest fingerprints the messages of a partition, in the order
+// they are stored
+func NewPartitionDigest(storageQueue string, messages []*Message, bucketSize int) PartitionDigest {
+	digest := PartitionDigest{
+		StorageQueue: storageQueue,
+		Messages:     len(messages),
+		Buckets:      make([]DigestBucket, 0, (len(messages)+bucketSize-1)/bucketSize),
+	}
+
+	total := sha256.New()
+	for first := 0; first < len(messages); first += bucketSize {
+		run := messages[first:min(first+bucketSize, len(messages))]
+		bucket := sha256.New()
+		for _, message := range run {
+			writeDigestField(bucket, message.ID)
+			writeDigestField(bucket, string(message.Payload))
+		}
+		checksum := hex.EncodeToString(bucket.Sum(nil))
+		total.Write([]byte(checksum))
+
+		digest.Buckets = append(digest.Buckets, DigestBucket{
+			First:    first,
+			Count:    len(run),
+			FirstID:  run[0].ID,
+			LastID:   run[len(run)-1].ID,
+			Checksum: checksum,
+		})
+	}
+	digest.Checksum = hex.EncodeToString(total.Sum(nil))
+	return digest
+}
+
+// writeDigestField hashes a length-prefixed field, so that moving bytes
+// between fields changes the checksum
+func writeDigestField(h hash.Hash, field string) {
+	fmt.Fprintf(h, "%d:", len(field))
+	h.Write([]byte(field))
+}
+
+// Diverges lists the buckets differing from a remote digest of the same
+// queue, both must be computed with the same bucket size
+func (d *QueueDigest) Diverges(remote *QueueDigest) ([]DigestDivergence, error) {
+	if remote.BucketSize != d.BucketSize {
+		return nil, fmt.Errorf("%w: bucket size %d, expected %d", ErrInvalidDigest, remote.BucketSize, d.BucketSize)
+	}
+
+	remotePartitions := make(map[string]PartitionDigest, len(remote.Partitions))
+	for _, partition := range remote.Partitions {
+		remotePartitions[partition.StorageQueue] = partition
+	}
+
+	divergences := make([]DigestDivergence, 0)
+	seen := make(map[string]bool, len(d.Partitions))
+	for _, local := range d.Partitions {
+		seen[local.StorageQueue] = true
+		divergences = append(divergences, local.diverges(remotePartitions[local.StorageQueue])...)
+	}
+	for _, partition := range remote.Partitions {
+		if !seen[partition.StorageQueue] {
+			divergences = append(divergences, PartitionDigest{StorageQueue: partition.StorageQueue}.diverges(partition)...)
+		}
+	}
+	return divergences, nil
+}
+
+// diverges compares the buckets of two digests of a partition
+func (p PartitionDigest) diverges(remote PartitionDigest) []DigestDivergence {
+	if p.Checksum != "" && p.Checksum == remote.Checksum {
+		return nil
+	}
+
+	var divergences []DigestDivergence
+	for i := range max(len(p.Buckets), len(remote.Buckets)) {
+		var local, other DigestBucket
+		if i < len(p.Buckets) {
+			local = p.Buckets[i]
+		}
+		if i < len(remote.Buckets) {
+			other = remote.Buckets[i]
+		}
+		if local.Checksum == other.Checksum {
+			continue
+		}
+		divergences = append(divergences, DigestDivergence{
+			StorageQueue:   p.StorageQueue,
+			First:          max(local.First, other.First),
+			LocalCount:     local.Count,
+			RemoteCount:    other.Count,
+			LocalChecksum:  local.Checksum,
+			RemoteChecksum: other.Checksum,
+			LocalFirstID:   local.FirstID,
+			RemoteFirstID:  other.FirstID,
+		})
+	}
+	return divergences
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digestMessages(n int) []*Message {
+	messages := make([]*Message, n)
+	for i := range messages {
+		messages[i] = &Message{ID: fmt.Sprintf("msg-%d", i), Payload: []byte(fmt.Sprintf(`{"n":%d}`, i))}
+	}
+	return messages
+}
+
+func TestPartitionDigest(t *testing.T) {
+	digest := NewPartitionDigest("orders", digestMessages(5), 2)
+	assert.Equal(t, 5, digest.Messages)
+	require.Len(t, digest.Buckets, 3)
+	assert.Equal(t, DigestBucket{First: 4, Count: 1, FirstID: "msg-4", LastID: "msg-4", Checksum: digest.Buckets[2].Checksum}, digest.Buckets[2])
+
+	assert.Equal(t, digest, NewPartitionDigest("orders", digestMessages(5), 2))
+
+	// moving bytes from the ID to the payload changes the checksum
+	a := NewPartitionDigest("orders", []*Message{{ID: "ab", Payload: []byte("c")}}, 2)
+	b := NewPartitionDigest("orders", []*Message{{ID: "a", Payload: []byte("bc")}}, 2)
+	assert.NotEqual(t, a.Checksum, b.Checksum)
+}
+
+func TestQueueDigestDiverges(t *testing.T) {
+	messages := digestMessages(6)
+	local := &QueueDigest{BucketSize: 2, Partitions: []PartitionDigest{
+		NewPartitionDigest("orders", messages, 2),
+	}}
+
+	same := &QueueDigest{BucketSize: 2, Partitions: []PartitionDigest{
+		NewPartitionDigest("orders", digestMessages(6), 2),
+	}}
+	divergences, err := local.Diverges(same)
+	require.NoError(t, err)
+	assert.Empty(t, divergences)
+
+	// the remote lost msg-3 and a partition is unknown locally
+	lost := append(append([]*Message{}, messages[:3]...), messages[4:]...)
+	remote := &QueueDigest{BucketSize: 2, Partitions: []PartitionDigest{
+		NewPartitionDigest("orders", lost, 2),
+		NewPartitionDigest("orders-extra", digestMessages(1), 2),
+	}}
+	divergences, err = local.Diverges(remote)
+	require.NoError(t, err)
+	require.Len(t, divergences, 3)
+	assert.Equal(t, 2, divergences[0].First)
+	assert.Equal(t, "msg-2", divergences[0].LocalFirstID)
+	assert.Equal(t, 4, divergences[1].First)
+	assert.Equal(t, 2, divergences[1].LocalCount)
+	assert.Equal(t, 1, divergences[1].RemoteCount)
+	assert.Equal(t, "orders-extra", divergences[2].StorageQueue)
+	assert.Empty(t, divergences[2].LocalChecksum)
+
+	_, err = local.Diverges(&QueueDigest{BucketSize: 3})
+	assert.ErrorIs(t, err, ErrInvalidDigest)
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// DrainReport is the outcome of a queue drain, also reported while it runs
+// by EventQueueDrainProgress events
+type DrainReport struct {
+	Domain      string `json:"domain"`
+	Queue       string `json:"queue"`
+	Destination string `json:"destination"`
+
+	// Total is the number of messages stored when the drain started,
+	// messages published meanwhile are left in the queue
+	Total int `json:"total"`
+	Moved int `json:"moved"`
+
+	// Skipped counts the messages consumed or removed during the drain
+	Skipped int `json:"skipped"`
+
+	// Error stops the drain, the messages not moved yet stay in the queue
+	Error string `json:"error,omitempty"`
+
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
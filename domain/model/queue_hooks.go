@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// QueueHookEvent is a change of state of a queue reported by its hooks
+type QueueHookEvent string
+
+const (
+	// QueueHookFull fires when the queue reaches its MaxSize
+	QueueHookFull QueueHookEvent = "full"
+
+	// QueueHookEmpty fires when the queue drains to no message
+	QueueHookEmpty QueueHookEvent = "empty"
+
+	// QueueHookFirstConsumer fires when a consumer joins a queue that had none
+	QueueHookFirstConsumer QueueHookEvent = "firstConsumer"
+
+	// QueueHookLastConsumer fires when the last consumer of the queue leaves
+	QueueHookLastConsumer QueueHookEvent = "lastConsumer"
+)
+
+// MetadataControlEvent marks the messages published by queue hooks, with the
+// event they report
+const MetadataControlEvent = "controlEvent"
+
+var queueHookEvents = []QueueHookEvent{QueueHookFull, QueueHookEmpty, QueueHookFirstConsumer, QueueHookLastConsumer}
+
+// QueueHooks publish a control event when the queue fills up, drains empty,
+// or gets its first or loses its last consumer, to a queue of the same domain
+// and/or a webhook. Consumers are counted by consumer ID across the groups of
+// the queue.
+type QueueHooks struct {
+	// Events selects the events reported, all of them when empty
+	Events []QueueHookEvent `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Queue receives the control events as messages
+	Queue string `yaml:"queue,omitempty" json:"queue,omitempty"`
+
+	// Webhook receives the control events as JSON POSTs
+	Webhook string `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+}
+
+// Validate checks the hooks of queueName
+func (h *QueueHooks) Validate(queueName string) error {
+	if h.Queue == "" && h.Webhook == "" {
+		return fmt.Errorf("%w: a queue or a webhook is required", ErrInvalidQueueHooks)
+	}
+	if h.Queue != "" && h.Queue == queueName {
+		return fmt.Errorf("%w: a queue can't report to itself", ErrInvalidQueueHooks)
+	}
+	if h.Webhook != "" {
+		u, err := url.Parse(h.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook must be an http(s) url", ErrInvalidQueueHooks)
+		}
+	}
+	for _, event := range h.Events {
+		if !slices.Contains(queueHookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidQueueHooks, event)
+		}
+	}
+	return nil
+}
+
+// Reports tells whether the hooks report the event
+func (h *QueueHooks) Reports(event QueueHookEvent) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// QueueControlEvent is the body of the messages and webhook posts of hooks
+type QueueControlEvent struct {
+	Event        QueueHookEvent `json:"event"`
+	Domain       string         `json:"domain"`
+	Queue        string         `json:"queue"`
+	MessageCount int            `json:"messageCount"`
+	MaxSize      int            `json:"maxSize,omitempty"`
+	Consumers    int            `json:"consumers"`
+	Time         time.Time      `json:"time"`
+}
+
+// QueueHookState remembers what hooks last saw of a queue, so they fire on
+// changes only
+type QueueHookState struct {
+	observed  bool
+	full      bool
+	empty     bool
+	consumers int
+}
+
+// Observe records the state of the queue and returns the events it went
+// through since the previous observation. The first observation only sets
+// the state, a queue found empty at startup didn't drain.
+func (s *QueueHookState) Observe(messageCount, maxSize, consumers int) []QueueHookEvent {
+	full := maxSize > 0 && messageCount >= maxSize
+	empty := messageCount == 0
+
+	var events []QueueHookEvent
+	if s.observed {
+		if full && !s.full {
+			events = append(events, QueueHookFull)
+		}
+		if empty && !s.empty {
+			events = append(events, QueueHookEmpty)
+		}
+		if consumers > 0 && s.consumers == 0 {
+			events = append(events, QueueHookFirstConsumer)
+		}
+		if consumers == 0 && s.consumers > 0 {
+			events = append(events, QueueHookLastConsumer)
+		}
+	}
+
+	*s = QueueHookState{observed: true, full: full, empty: empty, consumers: consumers}
+	return events
+}
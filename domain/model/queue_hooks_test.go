@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueHooksValidate(t *testing.T) {
+	assert.NoError(t, (&QueueHooks{Queue: "control"}).Validate("orders"))
+	assert.NoError(t, (&QueueHooks{Webhook: "https://hooks.example.com/queues"}).Validate("orders"))
+	assert.NoError(t, (&QueueHooks{Webhook: "https://hooks.example.com/queues"}).Validate(""), "blueprints validate without a queue name")
+
+	for _, hooks := range []QueueHooks{
+		{},
+		{Queue: "orders"},
+		{Webhook: "ftp://hooks.example.com"},
+		{Queue: "control", Events: []QueueHookEvent{"drained"}},
+	} {
+		assert.ErrorIs(t, hooks.Validate("orders"), ErrInvalidQueueHooks, "%+v", hooks)
+	}
+
+	hooks := &QueueHooks{Queue: "control"}
+	assert.True(t, hooks.Reports(QueueHookLastConsumer))
+	hooks.Events = []QueueHookEvent{QueueHookFull}
+	assert.True(t, hooks.Reports(QueueHookFull))
+	assert.False(t, hooks.Reports(QueueHookEmpty))
+}
+
+func TestQueueHookStateObserve(t *testing.T) {
+	var state QueueHookState
+	assert.Empty(t, state.Observe(0, 10, 0), "the first observation sets the state")
+
+	assert.Empty(t, state.Observe(4, 10, 0))
+	assert.Equal(t, []QueueHookEvent{QueueHookFirstConsumer}, state.Observe(4, 10, 2))
+	assert.Equal(t, []QueueHookEvent{QueueHookFull}, state.Observe(10, 10, 2))
+	assert.Empty(t, state.Observe(12, 10, 3), "still full, still consumed")
+	assert.Equal(t, []QueueHookEvent{QueueHookEmpty, QueueHookLastConsumer}, state.Observe(0, 10, 0))
+	assert.Empty(t, state.Observe(5, 0, 0), "queues without a max size never fill up")
+}
@@ -0,0 +1,57 @@
+package model
+
+import "fmt"
+
+// QueueMode restricts what a queue accepts, to drain it down or to collect
+// data before anything processes it
+type QueueMode string
+
+const (
+	// QueueModeReadWrite accepts publishes and consumes, the default
+	QueueModeReadWrite QueueMode = "read-write"
+
+	// QueueModeReadOnly refuses new messages, the backlog is still consumed
+	QueueModeReadOnly QueueMode = "read-only"
+
+	// QueueModeWriteOnly accepts messages and refuses consumes
+	QueueModeWriteOnly QueueMode = "write-only"
+)
+
+// ParseQueueMode validates a queue mode, empty meaning read-write
+func ParseQueueMode(mode string) (QueueMode, error) {
+	switch QueueMode(mode) {
+	case "", QueueModeReadWrite:
+		return QueueModeReadWrite, nil
+	case QueueModeReadOnly, QueueModeWriteOnly:
+		return QueueMode(mode), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidQueueMode, mode)
+	}
+}
+
+// Validate checks the mode is one of the known modes or empty
+func (m QueueMode) Validate() error {
+	_, err := ParseQueueMode(string(m))
+	return err
+}
+
+// AcceptsPublishes reports whether new messages may be published
+func (m QueueMode) AcceptsPublishes() bool {
+	return m != QueueModeReadOnly
+}
+
+// AcceptsConsumes reports whether messages may be consumed
+func (m QueueMode) AcceptsConsumes() bool {
+	return m != QueueModeWriteOnly
+}
+
+// Equal compares two modes, empty being read-write
+func (m QueueMode) Equal(other QueueMode) bool {
+	normalize := func(mode QueueMode) QueueMode {
+		if mode == "" {
+			return QueueModeReadWrite
+		}
+		return mode
+	}
+	return normalize(m) == normalize(other)
+}
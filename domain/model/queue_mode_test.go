@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueueMode(t *testing.T) {
+	mode, err := ParseQueueMode("")
+	require.NoError(t, err)
+	assert.Equal(t, QueueModeReadWrite, mode)
+
+	mode, err = ParseQueueMode("read-only")
+	require.NoError(t, err)
+	assert.Equal(t, QueueModeReadOnly, mode)
+
+	_, err = ParseQueueMode("append-only")
+	assert.ErrorIs(t, err, ErrInvalidQueueMode)
+}
+
+func TestQueueModeAccepts(t *testing.T) {
+	var unset QueueMode
+	assert.True(t, unset.AcceptsPublishes())
+	assert.True(t, unset.AcceptsConsumes())
+
+	assert.False(t, QueueModeReadOnly.AcceptsPublishes())
+	assert.True(t, QueueModeReadOnly.AcceptsConsumes())
+
+	assert.True(t, QueueModeWriteOnly.AcceptsPublishes())
+	assert.False(t, QueueModeWriteOnly.AcceptsConsumes())
+
+	assert.True(t, unset.Equal(QueueModeReadWrite))
+	assert.False(t, unset.Equal(QueueModeWriteOnly))
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Read replica modes
+const (
+	// ReadReplicaLocal keeps a copy of the queue storage on the node, fed
+	// asynchronously, which scans read instead of the primary storage
+	ReadReplicaLocal = "local"
+
+	// ReadReplicaRemote sends the scans to another node holding a copy of
+	// the queue, usually a standby of this one
+	ReadReplicaRemote = "remote"
+)
+
+// ReadReplicaConfig designates where the scans of a queue (searches and
+// snapshot exports) are served from, so that they don't contend with the
+// publishers and consumers. Replicas lag a little behind the queue.
+type ReadReplicaConfig struct {
+	// Mode is local or remote
+	Mode string `yaml:"mode" json:"mode"`
+
+	// URL is the base URL of the node serving the scans of a remote replica
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// Validate checks the read replica of a queue
+func (c *ReadReplicaConfig) Validate() error {
+	switch c.Mode {
+	case ReadReplicaLocal:
+		if c.URL != "" {
+			return fmt.Errorf("%w: a local replica has no url", ErrInvalidReadReplica)
+		}
+	case ReadReplicaRemote:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: a remote replica needs the http(s) url of its node", ErrInvalidReadReplica)
+		}
+	default:
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidReadReplica, ReadReplicaLocal, ReadReplicaRemote)
+	}
+	return nil
+}
+
+// Local reports whether the scans are served from a copy on this node
+func (c *ReadReplicaConfig) Local() bool {
+	return c != nil && c.Mode == ReadReplicaLocal
+}
+
+// Remote reports whether the scans are sent to another node
+func (c *ReadReplicaConfig) Remote() bool {
+	return c != nil && c.Mode == ReadReplicaRemote
+}
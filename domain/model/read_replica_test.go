@@ -0,0 +1,21 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplicaConfigValidate(t *testing.T) {
+	assert.NoError(t, (&ReadReplicaConfig{Mode: ReadReplicaLocal}).Validate())
+	assert.NoError(t, (&ReadReplicaConfig{Mode: ReadReplicaRemote, URL: "https://standby:8080"}).Validate())
+
+	assert.ErrorIs(t, (&ReadReplicaConfig{}).Validate(), ErrInvalidReadReplica)
+	assert.ErrorIs(t, (&ReadReplicaConfig{Mode: ReadReplicaLocal, URL: "https://standby:8080"}).Validate(), ErrInvalidReadReplica)
+	assert.ErrorIs(t, (&ReadReplicaConfig{Mode: ReadReplicaRemote}).Validate(), ErrInvalidReadReplica)
+	assert.ErrorIs(t, (&ReadReplicaConfig{Mode: ReadReplicaRemote, URL: "standby:8080"}).Validate(), ErrInvalidReadReplica)
+
+	var none *ReadReplicaConfig
+	assert.False(t, none.Local())
+	assert.False(t, none.Remote())
+}
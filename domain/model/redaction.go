@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RedactedPayload replaces the payloads that can't be redacted field by field
+var RedactedPayload = []byte(`{"redacted":true}`)
+
+// Redaction hides payload fields wherever messages show up in operational
+// tooling: logs, delivery failure samples and, optionally, WebSocket frames.
+// Consumers keep receiving the payloads as published.
+type Redaction struct {
+	// Rules rewrite payload fields, dotted paths reach nested objects
+	Rules []ScrubRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	// WebSocket also redacts the frames of WebSocket subscriptions, which the
+	// web UI watches. Consumers subscribing over WebSocket get them redacted too.
+	WebSocket bool `yaml:"webSocket,omitempty" json:"webSocket,omitempty"`
+}
+
+// Enabled reports whether any rule is set
+func (r *Redaction) Enabled() bool {
+	return r != nil && len(r.Rules) > 0
+}
+
+// Validate checks the rules
+func (r *Redaction) Validate() error {
+	if err := validateScrubRules(r.Rules); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRedaction, err)
+	}
+	return nil
+}
+
+// Payload returns the payload with the rules applied. Payloads that aren't
+// JSON objects are replaced by RedactedPayload, as nothing tells where the
+// sensitive parts are. The payload is returned as is without rules.
+func (r *Redaction) Payload(payload []byte) []byte {
+	if !r.Enabled() {
+		return payload
+	}
+	redacted, err := scrubPayload(payload, r.Rules)
+	if err != nil {
+		return RedactedPayload
+	}
+	return redacted
+}
+
+// Field redacts the value of a named field, such as a log attribute, when a
+// rule targets that top-level field. Removed fields are masked, the name
+// still shows where a value was.
+func (r *Redaction) Field(name string, value any) (string, bool) {
+	if !r.Enabled() {
+		return "", false
+	}
+	for _, rule := range r.Rules {
+		if strings.Contains(rule.Field, ".") || !strings.EqualFold(rule.Field, name) {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil || rule.Action != ScrubHash {
+			return "***", true
+		}
+		var hashed string
+		_ = json.Unmarshal(scrubValue(encoded, ScrubHash), &hashed)
+		return hashed, true
+	}
+	return "", false
+}
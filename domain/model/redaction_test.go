@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactionPayload(t *testing.T) {
+	redaction := &Redaction{Rules: []ScrubRule{
+		{Field: "customer.email", Action: ScrubMask},
+		{Field: "ssn", Action: ScrubRemove},
+	}}
+
+	assert.JSONEq(t, `{"total":3,"customer":{"email":"***"}}`,
+		string(redaction.Payload([]byte(`{"total":3,"ssn":"123","customer":{"email":"a@b.c"}}`))))
+	assert.Equal(t, RedactedPayload, redaction.Payload([]byte("plain text")))
+	assert.Equal(t, RedactedPayload, redaction.Payload([]byte(`{"ssn":"12`)), "truncated payloads are withheld")
+
+	var disabled *Redaction
+	assert.Equal(t, []byte("plain text"), disabled.Payload([]byte("plain text")))
+}
+
+func TestRedactionField(t *testing.T) {
+	redaction := &Redaction{Rules: []ScrubRule{
+		{Field: "email", Action: ScrubRemove},
+		{Field: "userId", Action: ScrubHash},
+		{Field: "customer.name", Action: ScrubMask},
+	}}
+
+	value, ok := redaction.Field("Email", "a@b.c")
+	assert.True(t, ok)
+	assert.Equal(t, "***", value)
+
+	value, ok = redaction.Field("userId", 42)
+	assert.True(t, ok)
+	assert.Len(t, value, 64)
+
+	_, ok = redaction.Field("name", "Ada")
+	assert.False(t, ok, "nested rules only apply to payloads")
+
+	assert.ErrorIs(t, (&Redaction{Rules: []ScrubRule{{Field: "email", Action: "blank"}}}).Validate(), ErrInvalidRedaction)
+}
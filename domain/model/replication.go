@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+const (
+	// DefaultReplicationBatchSize is the number of messages a standby pulls at once
+	DefaultReplicationBatchSize = 500
+
+	// MaxReplicationBatchSize bounds the messages a primary hands at once
+	MaxReplicationBatchSize = 5000
+
+	// DefaultReplicationInterval is the wait between the pulls of a caught up standby
+	DefaultReplicationInterval = 5 * time.Second
+)
+
+// ReplicatedDomain is the metadata of a domain a standby copies from its primary
+type ReplicatedDomain struct {
+	Name                string                 `json:"name"`
+	Fields              map[string]FieldType   `json:"fields,omitempty"` // schema fields
+	AllowedRouteSources []string               `json:"allowedRouteSources,omitempty"`
+	Labels              map[string]string      `json:"labels,omitempty"`
+	Queues              map[string]QueueConfig `json:"queues"`
+}
+
+// NewReplicatedDomain describes a domain for its standby copies
+func NewReplicatedDomain(domain *Domain) ReplicatedDomain {
+	replicated := ReplicatedDomain{
+		Name:                domain.Name,
+		AllowedRouteSources: domain.AllowedRouteSources,
+		Labels:              domain.Labels,
+		Queues:              make(map[string]QueueConfig, len(domain.Queues)),
+	}
+	if domain.Schema != nil {
+		replicated.Fields = domain.Schema.Fields
+	}
+	for name, queue := range domain.Queues {
+		replicated.Queues[name] = queue.Config
+	}
+	return replicated
+}
+
+// ReplicationBatch is a run of messages stored in a queue of the primary,
+// Next is the index to ask for the following ones
+type ReplicationBatch struct {
+	Messages []*ArchivedMessage `json:"messages"`
+	Next     int64              `json:"next"`
+}
+
+// Replica roles
+const (
+	ReplicaRolePrimary = "primary"
+	ReplicaRoleStandby = "standby"
+)
+
+// ReplicatedQueue is the progress of a standby on a queue of its primary
+type ReplicatedQueue struct {
+	Domain       string    `json:"domain"`
+	StorageQueue string    `json:"storageQueue"`
+	Next         int64     `json:"next"`   // next index pulled from the primary
+	Pulled       int64     `json:"pulled"` // messages copied since startup
+	LastPull     time.Time `json:"lastPull"`
+}
+
+// ReplicationStatus reports the role of the node and, for a standby, how
+// far it is behind its primary
+type ReplicationStatus struct {
+	Role       string            `json:"role"`
+	Primary    string            `json:"primary,omitempty"`
+	LastSync   *time.Time        `json:"lastSync,omitempty"` // last pass reaching the end of every queue
+	LastError  string            `json:"lastError,omitempty"`
+	PromotedAt *time.Time        `json:"promotedAt,omitempty"`
+	Queues     []ReplicatedQueue `json:"queues"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// CanarySplit sends a share of the messages matching a routing rule to an
+// alternate queue instead of the destination, to roll a new consumer out
+// gradually. The alternate queue is in the destination domain of the rule.
+type CanarySplit struct {
+	// Queue is the alternate destination
+	Queue string `yaml:"queue" json:"queue"`
+
+	// Percent of the matching messages sent to Queue, from 0 to 100
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+// Validate checks the split of a rule routing to destinationQueue
+func (c *CanarySplit) Validate(destinationQueue string) error {
+	switch {
+	case c.Queue == "":
+		return fmt.Errorf("%w: canary queue is required", ErrInvalidCanary)
+	case c.Queue == destinationQueue:
+		return fmt.Errorf("%w: the canary queue must differ from the destination", ErrInvalidCanary)
+	case c.Percent < 0 || c.Percent > 100:
+		return fmt.Errorf("%w: percent must be between 0 and 100", ErrInvalidCanary)
+	}
+	return nil
+}
+
+// Picks reports whether the message goes to the canary queue. The choice
+// hashes the message ID, so a retried publish lands on the same side.
+func (c *CanarySplit) Picks(messageID string) bool {
+	if c == nil || c.Percent <= 0 {
+		return false
+	}
+	if c.Percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	// salted with the queue so the split doesn't follow mirror sampling
+	h.Write([]byte(c.Queue))
+	h.Write([]byte(messageID))
+	return float64(h.Sum32()%10000) < c.Percent*100
+}
+
+// Target returns the queue the message is routed to
+func (r *RoutingRule) Target(messageID string) (queue string, canary bool) {
+	if r.Canary.Picks(messageID) {
+		return r.Canary.Queue, true
+	}
+	return r.DestinationQueue, false
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanarySplitValidate(t *testing.T) {
+	assert.NoError(t, (&CanarySplit{Queue: "orders-v2", Percent: 10}).Validate("orders"))
+	assert.ErrorIs(t, (&CanarySplit{Percent: 10}).Validate("orders"), ErrInvalidCanary)
+	assert.ErrorIs(t, (&CanarySplit{Queue: "orders", Percent: 10}).Validate("orders"), ErrInvalidCanary)
+	assert.ErrorIs(t, (&CanarySplit{Queue: "orders-v2", Percent: 120}).Validate("orders"), ErrInvalidCanary)
+	assert.ErrorIs(t, (&CanarySplit{Queue: "orders-v2", Percent: -1}).Validate("orders"), ErrInvalidCanary)
+}
+
+func TestRoutingRuleCanarySplit(t *testing.T) {
+	rule := &RoutingRule{
+		SourceQueue:      "incoming",
+		DestinationQueue: "orders",
+		Canary:           &CanarySplit{Queue: "orders-v2", Percent: 20},
+	}
+
+	for i := range 10000 {
+		id := fmt.Sprintf("msg-%d", i)
+		queue, canary := rule.Target(id)
+		again, _ := rule.Target(id)
+		assert.Equal(t, queue, again, "a message always lands on the same side")
+		if canary {
+			assert.Equal(t, "orders-v2", queue)
+		} else {
+			assert.Equal(t, "orders", queue)
+		}
+		rule.RecordRouted(canary)
+	}
+
+	stats := rule.Stats("shop")
+	assert.Equal(t, int64(10000), stats.Routed)
+	assert.Equal(t, stats.Routed, stats.ToDestination+stats.Canary.Routed)
+	assert.InDelta(t, 20, stats.Canary.ObservedPercent, 2)
+
+	plain := &RoutingRule{SourceQueue: "incoming", DestinationQueue: "orders"}
+	queue, canary := plain.Target("msg-1")
+	assert.Equal(t, "orders", queue)
+	assert.False(t, canary)
+	assert.Nil(t, plain.Stats("shop").Canary)
+
+	rule.Canary.Percent = 0
+	_, canary = rule.Target("msg-1")
+	assert.False(t, canary)
+	rule.Canary.Percent = 100
+	_, canary = rule.Target("msg-1")
+	assert.True(t, canary)
+}
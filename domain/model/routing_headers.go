@@ -0,0 +1,97 @@
+package model
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Metadata keys recording the last routing hop of a message
+const (
+	MetadataSourceQueue = "sourceQueue"
+	MetadataRoutingRule = "routingRule"
+	MetadataRoutingHops = "routingHops"
+)
+
+// HeaderPropagation controls the headers a routed copy of a message carries.
+// A rule without one copies every header of the source message.
+type HeaderPropagation struct {
+	// Include lists the source headers kept, empty keeps them all
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+
+	// Exclude lists the source headers dropped, applied after Include
+	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+
+	// Set adds or overrides headers on the routed copy
+	Set map[string]string `yaml:"set,omitempty" json:"set,omitempty"`
+}
+
+// Apply returns the headers of a routed copy, never sharing the source map.
+// Header names are matched case-insensitively.
+func (p *HeaderPropagation) Apply(headers map[string]string) map[string]string {
+	if p == nil {
+		return maps.Clone(headers)
+	}
+
+	result := make(map[string]string, len(headers)+len(p.Set))
+	for name, value := range headers {
+		if len(p.Include) > 0 && !containsFold(p.Include, name) {
+			continue
+		}
+		if containsFold(p.Exclude, name) {
+			continue
+		}
+		result[name] = value
+	}
+	maps.Copy(result, p.Set)
+	return result
+}
+
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+}
+
+// ID identifies the rule within its source domain as "source->destination"
+func (r *RoutingRule) ID(sourceDomain string) string {
+	return r.SourceQueue + "->" + r.DestinationKey(sourceDomain)
+}
+
+// RoutedCopy clones the message for one routing hop. The copy gets an ID
+// derived from the original message and the hop, headers follow the rule
+// propagation policy and the metadata records where the copy came from; the
+// copy shares neither map with the source, the payload bytes are shared.
+func (r *RoutingRule) RoutedCopy(message *Message, sourceDomain string) *Message {
+	original := OriginalMessageID(message)
+	hop := routingHops(message.Metadata) + 1
+
+	routed := &Message{
+		ID:        RoutedMessageID(original, hop),
+		Topic:     message.Topic,
+		Payload:   message.Payload,
+		Headers:   r.Headers.Apply(message.Headers),
+		Metadata:  make(map[string]any, len(message.Metadata)+5),
+		Timestamp: message.Timestamp,
+	}
+	maps.Copy(routed.Metadata, message.Metadata)
+
+	routed.Metadata[MetadataSourceQueue] = r.SourceQueue
+	routed.Metadata[MetadataRoutingRule] = r.ID(sourceDomain)
+	routed.Metadata[MetadataRoutingHops] = hop
+	routed.Metadata[MetadataOriginalID] = original
+	routed.Metadata[MetadataParentID] = message.ID
+	return routed
+}
+
+// routingHops reads the hop count, which turns into a float64 once the
+// metadata went through JSON
+func routingHops(metadata map[string]any) int {
+	switch hops := metadata[MetadataRoutingHops].(type) {
+	case int:
+		return hops
+	case float64:
+		return int(hops)
+	}
+	return 0
+}
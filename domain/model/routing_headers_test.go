@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderPropagationApply(t *testing.T) {
+	headers := map[string]string{
+		"traceparent":   "00-abc-01",
+		"X-Tenant":      "acme",
+		"Authorization": "Bearer secret",
+	}
+
+	var all *HeaderPropagation
+	copied := all.Apply(headers)
+	assert.Equal(t, headers, copied)
+	copied["X-Tenant"] = "other"
+	assert.Equal(t, "acme", headers["X-Tenant"], "the copy never shares the source map")
+
+	policy := &HeaderPropagation{
+		Exclude: []string{"authorization"},
+		Set:     map[string]string{"X-Pipeline": "billing"},
+	}
+	assert.Equal(t, map[string]string{
+		"traceparent": "00-abc-01",
+		"X-Tenant":    "acme",
+		"X-Pipeline":  "billing",
+	}, policy.Apply(headers))
+
+	policy = &HeaderPropagation{Include: []string{"TraceParent"}}
+	assert.Equal(t, map[string]string{"traceparent": "00-abc-01"}, policy.Apply(headers))
+}
+
+func TestRoutingRuleRoutedCopy(t *testing.T) {
+	rule := &RoutingRule{SourceQueue: "orders", DestinationQueue: "invoices"}
+	source := &Message{
+		ID:       "msg-1",
+		Payload:  []byte(`{"total":10}`),
+		Headers:  map[string]string{"traceparent": "00-abc-01"},
+		Metadata: map[string]any{"domain": "shop", "queue": "orders"},
+	}
+
+	routed := rule.RoutedCopy(source, "shop")
+	assert.Equal(t, "msg-1~1", routed.ID)
+	assert.Equal(t, "msg-1", routed.Metadata[MetadataOriginalID])
+	assert.Equal(t, "msg-1", routed.Metadata[MetadataParentID])
+	assert.Equal(t, "00-abc-01", routed.Headers["traceparent"])
+	assert.Equal(t, "orders", routed.Metadata[MetadataSourceQueue])
+	assert.Equal(t, "orders->invoices", routed.Metadata[MetadataRoutingRule])
+	assert.Equal(t, 1, routed.Metadata[MetadataRoutingHops])
+
+	// publishing the copy rewrites its metadata, not the source's
+	routed.Metadata["queue"] = "invoices"
+	assert.Equal(t, "orders", source.Metadata["queue"])
+	assert.NotContains(t, source.Metadata, MetadataRoutingHops)
+
+	// hops keep counting across rules, also once decoded from JSON
+	next := &RoutingRule{SourceQueue: "invoices", DestinationQueue: "archive", DestinationDomain: "audit"}
+	routed.Metadata[MetadataRoutingHops] = float64(1)
+	hop := next.RoutedCopy(routed, "shop")
+	assert.Equal(t, 2, hop.Metadata[MetadataRoutingHops])
+	assert.Equal(t, "msg-1~2", hop.ID)
+	assert.Equal(t, "msg-1", hop.Metadata[MetadataOriginalID])
+	assert.Equal(t, "msg-1~1", hop.Metadata[MetadataParentID])
+	assert.Equal(t, "invoices->audit:archive", hop.Metadata[MetadataRoutingRule])
+}
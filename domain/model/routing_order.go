@@ -0,0 +1,88 @@
+package model
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// RoutingRuleUpdate changes how a rule is evaluated without replacing it,
+// so the rule keeps its stats. Nil fields are left as they are.
+type RoutingRuleUpdate struct {
+	Enabled     *bool `json:"enabled,omitempty"`
+	Priority    *int  `json:"priority,omitempty"`
+	StopOnMatch *bool `json:"stopOnMatch,omitempty"`
+}
+
+// IsEnabled reports whether the rule routes messages
+func (r *RoutingRule) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Apply sets the fields of the update on the rule
+func (r *RoutingRule) Apply(update RoutingRuleUpdate) {
+	if update.Enabled != nil {
+		enabled := *update.Enabled
+		r.Enabled = &enabled
+	}
+	if update.Priority != nil {
+		r.Priority = *update.Priority
+	}
+	if update.StopOnMatch != nil {
+		r.StopOnMatch = *update.StopOnMatch
+	}
+}
+
+// SameEvaluation reports whether both rules are enabled, ordered and
+// stopping alike
+func (r *RoutingRule) SameEvaluation(other *RoutingRule) bool {
+	return r.IsEnabled() == other.IsEnabled() &&
+		r.Priority == other.Priority &&
+		r.StopOnMatch == other.StopOnMatch
+}
+
+// EvaluationUpdate is the update giving another rule the evaluation
+// settings of this one
+func (r *RoutingRule) EvaluationUpdate() RoutingRuleUpdate {
+	enabled, priority, stop := r.IsEnabled(), r.Priority, r.StopOnMatch
+	return RoutingRuleUpdate{Enabled: &enabled, Priority: &priority, StopOnMatch: &stop}
+}
+
+// AppendRulesInOrder appends the rules of a source queue in evaluation
+// order: highest priority first, then by destination, so rules of equal
+// priority always run in the same order
+func AppendRulesInOrder(dst []*RoutingRule, rules map[string]*RoutingRule) []*RoutingRule {
+	start := len(dst)
+	for _, rule := range rules {
+		dst = append(dst, rule)
+	}
+	slices.SortFunc(dst[start:], func(a, b *RoutingRule) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(b.Priority, a.Priority)
+		}
+		if a.DestinationDomain != b.DestinationDomain {
+			return cmp.Compare(a.DestinationDomain, b.DestinationDomain)
+		}
+		return cmp.Compare(a.DestinationQueue, b.DestinationQueue)
+	})
+	return dst
+}
+
+// ValidateRuleOrder checks that order lists every destination key of the
+// rules exactly once
+func ValidateRuleOrder(rules map[string]*RoutingRule, order []string) error {
+	if len(order) != len(rules) {
+		return fmt.Errorf("%w: %d rules listed, the queue has %d", ErrInvalidRuleOrder, len(order), len(rules))
+	}
+	seen := make(map[string]bool, len(order))
+	for _, key := range order {
+		if _, exists := rules[key]; !exists {
+			return fmt.Errorf("%w: unknown destination %q", ErrInvalidRuleOrder, key)
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: destination %q listed twice", ErrInvalidRuleOrder, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendRulesInOrder(t *testing.T) {
+	rules := map[string]*RoutingRule{
+		"billing":      {SourceQueue: "orders", DestinationQueue: "billing"},
+		"audit":        {SourceQueue: "orders", DestinationQueue: "audit"},
+		"vip":          {SourceQueue: "orders", DestinationQueue: "vip", Priority: 10},
+		"audit:events": {SourceQueue: "orders", DestinationQueue: "events", DestinationDomain: "audit", Priority: -1},
+	}
+
+	var destinations []string
+	for _, rule := range AppendRulesInOrder(nil, rules) {
+		destinations = append(destinations, rule.DestinationQueue)
+	}
+	assert.Equal(t, []string{"vip", "audit", "billing", "events"}, destinations)
+
+	assert.NoError(t, ValidateRuleOrder(rules, []string{"audit:events", "vip", "billing", "audit"}))
+	assert.ErrorIs(t, ValidateRuleOrder(rules, []string{"vip", "billing", "audit"}), ErrInvalidRuleOrder)
+	assert.ErrorIs(t, ValidateRuleOrder(rules, []string{"vip", "vip", "billing", "audit"}), ErrInvalidRuleOrder)
+	assert.ErrorIs(t, ValidateRuleOrder(rules, []string{"vip", "events", "billing", "audit"}), ErrInvalidRuleOrder)
+}
+
+func TestRoutingRuleApply(t *testing.T) {
+	rule := &RoutingRule{SourceQueue: "orders", DestinationQueue: "billing"}
+	assert.True(t, rule.IsEnabled())
+
+	disabled, priority := false, 5
+	rule.Apply(RoutingRuleUpdate{Enabled: &disabled, Priority: &priority})
+	assert.False(t, rule.IsEnabled())
+	assert.Equal(t, 5, rule.Priority)
+	assert.False(t, rule.StopOnMatch, "fields left out keep their value")
+
+	other := &RoutingRule{SourceQueue: "orders", DestinationQueue: "billing"}
+	assert.False(t, other.SameEvaluation(rule))
+	other.Apply(rule.EvaluationUpdate())
+	assert.True(t, other.SameEvaluation(rule))
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type routingCounters struct {
+	matched     atomic.Int64
+	lastMatch   atomic.Int64 // unix nanoseconds, 0 before the first match
+	routed      atomic.Int64
+	destination atomic.Int64
+	canary      atomic.Int64
+}
+
+// RecordMatch counts a message the predicate of the rule matched, routed
+// or not
+func (r *RoutingRule) RecordMatch(now time.Time) {
+	r.stats.matched.Add(1)
+	r.stats.lastMatch.Store(now.UnixNano())
+}
+
+// RecordRouted counts a message the rule routed, to its destination or canary
+func (r *RoutingRule) RecordRouted(canary bool) {
+	r.stats.routed.Add(1)
+	if canary {
+		r.stats.canary.Add(1)
+	} else {
+		r.stats.destination.Add(1)
+	}
+}
+
+// RoutingRuleStats is the traffic of a rule since it was created or the
+// broker started, rules that never match being candidates for cleanup
+type RoutingRuleStats struct {
+	Rule          string `json:"rule"` // see RoutingRule.ID
+	SourceQueue   string `json:"sourceQueue"`
+	Destination   string `json:"destination"`
+	Routed        int64  `json:"routed"`
+	ToDestination int64  `json:"toDestination"`
+
+	// Matched counts the messages the predicate matched, Routed falling
+	// behind when cross-domain hops are refused
+	Matched   int64      `json:"matched"`
+	LastMatch *time.Time `json:"lastMatch,omitempty"` // nil for rules that never matched
+
+	// Canary is the split of the rule, nil for rules without one
+	Canary *RoutingCanaryStats `json:"canary,omitempty"`
+}
+
+// RoutingCanaryStats compares the configured split to the observed one
+type RoutingCanaryStats struct {
+	Queue           string  `json:"queue"`
+	Percent         float64 `json:"percent"`
+	Routed          int64   `json:"routed"`
+	ObservedPercent float64 `json:"observedPercent"`
+}
+
+// Stats reports the matches of the rule and the split it applied so far
+func (r *RoutingRule) Stats(sourceDomain string) RoutingRuleStats {
+	stats := RoutingRuleStats{
+		Rule:          r.ID(sourceDomain),
+		SourceQueue:   r.SourceQueue,
+		Destination:   r.DestinationKey(sourceDomain),
+		Routed:        r.stats.routed.Load(),
+		ToDestination: r.stats.destination.Load(),
+		Matched:       r.stats.matched.Load(),
+	}
+	if lastMatch := r.stats.lastMatch.Load(); lastMatch != 0 {
+		at := time.Unix(0, lastMatch)
+		stats.LastMatch = &at
+	}
+	if r.Canary != nil {
+		canary := &RoutingCanaryStats{
+			Queue:   r.Canary.Queue,
+			Percent: r.Canary.Percent,
+			Routed:  r.stats.canary.Load(),
+		}
+		if stats.Routed > 0 {
+			canary.ObservedPercent = float64(canary.Routed) * 100 / float64(stats.Routed)
+		}
+		stats.Canary = canary
+	}
+	return stats
+}
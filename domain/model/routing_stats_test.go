@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingRuleMatchStats(t *testing.T) {
+	rule := &RoutingRule{SourceQueue: "orders", DestinationQueue: "audit", DestinationDomain: "compliance"}
+
+	stats := rule.Stats("shop")
+	assert.Equal(t, "orders->compliance:audit", stats.Rule)
+	assert.Zero(t, stats.Matched)
+	assert.Nil(t, stats.LastMatch, "a rule that never matched has no last match")
+
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	rule.RecordMatch(first)
+	rule.RecordRouted(false)
+	rule.RecordMatch(first.Add(time.Minute)) // refused by the destination domain
+
+	stats = rule.Stats("shop")
+	assert.Equal(t, int64(2), stats.Matched)
+	assert.Equal(t, int64(1), stats.Routed)
+	require.NotNil(t, stats.LastMatch)
+	assert.True(t, first.Add(time.Minute).Equal(*stats.LastMatch))
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultDrainTime is the time a scaling hint gives consumers to clear a backlog
+const DefaultDrainTime = time.Minute
+
+// scalingTolerance is the fraction of a consumer ignored when rounding up
+const scalingTolerance = 0.01
+
+// Reasons behind a recommended consumer count
+const (
+	ScalingReasonIdle        = "idle"         // nothing stored nor published
+	ScalingReasonThroughput  = "throughput"   // sized on the observed rate per consumer
+	ScalingReasonNoConsumers = "no-consumers" // work waits and nobody consumes
+	ScalingReasonStalled     = "stalled"      // consumers are connected but nothing is consumed
+)
+
+// ScalingPolicy tunes the consumer count a scaling hint recommends
+type ScalingPolicy struct {
+	DrainTime    time.Duration // time the backlog should be cleared in, DefaultDrainTime when 0
+	MinConsumers int
+	MaxConsumers int // 0 for no limit
+}
+
+// ScalingHint is the consumer count an autoscaler should run for a queue,
+// or for one of its consumer groups, with the rates it was computed from
+type ScalingHint struct {
+	Domain           string  `json:"domain"`
+	Queue            string  `json:"queue"`
+	GroupID          string  `json:"groupId,omitempty"`
+	Backlog          int     `json:"backlog"`        // messages waiting
+	ArrivalRate      float64 `json:"arrivalRate"`    // messages/s published
+	ProcessingRate   float64 `json:"processingRate"` // messages/s consumed
+	Consumers        int     `json:"consumers"`      // consumers currently registered
+	RatePerConsumer  float64 `json:"ratePerConsumer"`
+	DesiredConsumers int     `json:"desiredConsumers"`
+	Reason           string  `json:"reason"`
+}
+
+// Recommend sets DesiredConsumers to the count keeping up with arrivals while
+// clearing the backlog within the drain time, bounded by the policy
+func (h *ScalingHint) Recommend(policy ScalingPolicy) {
+	drainTime := policy.DrainTime
+	if drainTime <= 0 {
+		drainTime = DefaultDrainTime
+	}
+
+	h.RatePerConsumer = 0
+	if h.Consumers > 0 {
+		h.RatePerConsumer = h.ProcessingRate / float64(h.Consumers)
+	}
+	needed := h.ArrivalRate + float64(h.Backlog)/drainTime.Seconds()
+
+	switch {
+	case h.Backlog == 0 && h.ArrivalRate == 0:
+		h.DesiredConsumers, h.Reason = 0, ScalingReasonIdle
+	case h.RatePerConsumer > 0:
+		// measured rates are noisy, 2 consumers keeping up must not give 3
+		h.DesiredConsumers = int(math.Ceil(needed/h.RatePerConsumer - scalingTolerance))
+		h.Reason = ScalingReasonThroughput
+	case h.Consumers == 0:
+		h.DesiredConsumers, h.Reason = 1, ScalingReasonNoConsumers
+	default:
+		// more consumers won't help consumers that don't consume
+		h.DesiredConsumers, h.Reason = h.Consumers, ScalingReasonStalled
+	}
+
+	h.DesiredConsumers = max(h.DesiredConsumers, policy.MinConsumers)
+	if policy.MaxConsumers > 0 {
+		h.DesiredConsumers = min(h.DesiredConsumers, policy.MaxConsumers)
+	}
+}
+
+// QueueBacklog is the depth of a queue and the backlog of its consumer groups
+type QueueBacklog struct {
+	Domain string
+	Queue  string
+	Depth  int            // messages stored
+	Groups map[string]int // group ID -> messages waiting for the group ack
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalingHintRecommend(t *testing.T) {
+	tests := []struct {
+		name    string
+		hint    ScalingHint
+		policy  ScalingPolicy
+		desired int
+		reason  string
+	}{
+		{"idle scales to zero", ScalingHint{Consumers: 3}, ScalingPolicy{}, 0, ScalingReasonIdle},
+		{"idle keeps the minimum", ScalingHint{Consumers: 3}, ScalingPolicy{MinConsumers: 1}, 1, ScalingReasonIdle},
+		{
+			"keeping up holds the count",
+			ScalingHint{ArrivalRate: 20, ProcessingRate: 20, Consumers: 2},
+			ScalingPolicy{}, 2, ScalingReasonThroughput,
+		},
+		{
+			"backlog drained within the drain time",
+			ScalingHint{Backlog: 600, ArrivalRate: 20, ProcessingRate: 20, Consumers: 2},
+			ScalingPolicy{DrainTime: 15 * time.Second}, 6, ScalingReasonThroughput,
+		},
+		{
+			"bounded by the maximum",
+			ScalingHint{Backlog: 6000, ArrivalRate: 20, ProcessingRate: 20, Consumers: 2},
+			ScalingPolicy{MaxConsumers: 5}, 5, ScalingReasonThroughput,
+		},
+		{"work without consumers", ScalingHint{Backlog: 10}, ScalingPolicy{}, 1, ScalingReasonNoConsumers},
+		{"stalled consumers", ScalingHint{Backlog: 10, Consumers: 4}, ScalingPolicy{}, 4, ScalingReasonStalled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.hint.Recommend(tt.policy)
+			assert.Equal(t, tt.desired, tt.hint.DesiredConsumers)
+			assert.Equal(t, tt.reason, tt.hint.Reason)
+		})
+	}
+}
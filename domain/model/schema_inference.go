@@ -0,0 +1,230 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+const (
+	// DefaultInferenceSample is the number of recent messages sampled to infer a schema
+	DefaultInferenceSample = 100
+
+	// MaxInferenceSample caps the sample
+	MaxInferenceSample = 10000
+
+	// jsonSchemaDialect is the JSON Schema version of the proposals
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// InferredSchema is a schema proposed from the payloads of a queue
+type InferredSchema struct {
+	// Sampled is the number of JSON object payloads inferred from
+	Sampled int `json:"sampled"`
+
+	// Skipped counts the payloads that aren't JSON objects
+	Skipped int `json:"skipped"`
+
+	// Fields describes every top-level field seen
+	Fields map[string]*InferredField `json:"fields"`
+
+	// Proposed is a domain schema requiring the fields held by every sample
+	// with a single type, ready for the domain schema
+	Proposed map[string]FieldType `json:"proposed"`
+}
+
+// InferredField describes a field as seen across the samples
+type InferredField struct {
+	// Types lists the types seen, more than one hints at an inconsistency
+	Types []FieldType `json:"types"`
+
+	// Seen is the number of samples holding the field
+	Seen int `json:"seen"`
+
+	// Required is set when every sample holds the field, never null
+	Required bool `json:"required"`
+
+	Nullable bool `json:"nullable,omitempty"`
+
+	// Encrypted is set when the field was sealed in some samples, its type
+	// can't be seen there
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	// Properties of object fields, Items of array fields
+	Properties map[string]*InferredField `json:"properties,omitempty"`
+	Items      *InferredField            `json:"items,omitempty"`
+}
+
+// fieldStats accumulates the values of one field
+type fieldStats struct {
+	seen       int
+	nulls      int
+	encrypted  int
+	objects    int
+	itemCount  int
+	types      map[FieldType]bool
+	properties map[string]*fieldStats
+	items      *fieldStats
+}
+
+func newFieldStats() *fieldStats {
+	return &fieldStats{types: make(map[FieldType]bool)}
+}
+
+func (f *fieldStats) add(value any) {
+	f.seen++
+
+	switch v := value.(type) {
+	case nil:
+		f.nulls++
+	case string:
+		f.types[StringType] = true
+	case float64:
+		f.types[NumberType] = true
+	case bool:
+		f.types[BooleanType] = true
+	case map[string]any:
+		if IsEncryptedField(v) {
+			f.encrypted++
+			return
+		}
+		f.types[ObjectType] = true
+		f.objects++
+		f.addProperties(v)
+	case []any:
+		f.types[ArrayType] = true
+		if f.items == nil {
+			f.items = newFieldStats()
+		}
+		for _, item := range v {
+			f.itemCount++
+			f.items.add(item)
+		}
+	}
+}
+
+func (f *fieldStats) addProperties(object map[string]any) {
+	if f.properties == nil {
+		f.properties = make(map[string]*fieldStats)
+	}
+	for name, value := range object {
+		stats, exists := f.properties[name]
+		if !exists {
+			stats = newFieldStats()
+			f.properties[name] = stats
+		}
+		stats.add(value)
+	}
+}
+
+// field builds the description of a field held by parents objects
+func (f *fieldStats) field(parents int) *InferredField {
+	field := &InferredField{
+		Types:     make([]FieldType, 0, len(f.types)),
+		Seen:      f.seen,
+		Required:  f.seen == parents && f.nulls == 0,
+		Nullable:  f.nulls > 0,
+		Encrypted: f.encrypted > 0,
+	}
+	for fieldType := range f.types {
+		field.Types = append(field.Types, fieldType)
+	}
+	slices.Sort(field.Types)
+
+	if len(f.properties) > 0 {
+		field.Properties = make(map[string]*InferredField, len(f.properties))
+		for name, stats := range f.properties {
+			field.Properties[name] = stats.field(f.objects)
+		}
+	}
+	if f.items != nil && f.itemCount > 0 {
+		field.Items = f.items.field(f.itemCount)
+		field.Items.Required = false
+	}
+	return field
+}
+
+// InferSchema proposes a schema from sample payloads
+func InferSchema(payloads [][]byte) *InferredSchema {
+	root := newFieldStats()
+	schema := &InferredSchema{}
+
+	for _, payload := range payloads {
+		var object map[string]any
+		if json.Unmarshal(payload, &object) != nil || object == nil {
+			schema.Skipped++
+			continue
+		}
+		schema.Sampled++
+		root.addProperties(object)
+	}
+
+	schema.Fields = make(map[string]*InferredField, len(root.properties))
+	schema.Proposed = make(map[string]FieldType)
+	for name, stats := range root.properties {
+		field := stats.field(schema.Sampled)
+		schema.Fields[name] = field
+		if field.Required && len(field.Types) == 1 {
+			schema.Proposed[name] = field.Types[0]
+		}
+	}
+	return schema
+}
+
+// JSONSchema renders the inferred schema as a JSON Schema document
+func (s *InferredSchema) JSONSchema() map[string]any {
+	document := objectJSONSchema(s.Fields)
+	document["$schema"] = jsonSchemaDialect
+	return document
+}
+
+func objectJSONSchema(fields map[string]*InferredField) map[string]any {
+	properties := make(map[string]any, len(fields))
+	required := []string{}
+	for name, field := range fields {
+		properties[name] = field.jsonSchema()
+		if field.Required {
+			required = append(required, name)
+		}
+	}
+	slices.Sort(required)
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// jsonSchema describes the field, encrypted fields take any type
+func (f *InferredField) jsonSchema() map[string]any {
+	if f.Encrypted {
+		return map[string]any{"description": "encrypted field"}
+	}
+
+	var document map[string]any
+	if len(f.Properties) > 0 {
+		document = objectJSONSchema(f.Properties)
+	} else {
+		document = make(map[string]any)
+	}
+
+	types := make([]string, 0, len(f.Types)+1)
+	for _, fieldType := range f.Types {
+		types = append(types, string(fieldType))
+	}
+	if f.Nullable {
+		types = append(types, "null")
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		document["type"] = types[0]
+	default:
+		document["type"] = types
+	}
+
+	if f.Items != nil {
+		document["items"] = f.Items.jsonSchema()
+	}
+	return document
+}
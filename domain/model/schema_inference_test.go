@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	schema := InferSchema([][]byte{
+		[]byte(`{"id":1,"customer":{"email":"a@b.c"},"tags":["x"],"note":null,"card":{"$encrypted":{"kid":"k"}}}`),
+		[]byte(`{"id":2,"customer":{"email":"d@e.f","vip":true},"tags":[],"note":"late","card":{"$encrypted":{"kid":"k"}}}`),
+		[]byte(`{"id":"3","customer":{"email":"g@h.i"},"tags":["y","z"]}`),
+		[]byte(`not json`),
+	})
+
+	assert.Equal(t, 3, schema.Sampled)
+	assert.Equal(t, 1, schema.Skipped)
+
+	id := schema.Fields["id"]
+	assert.Equal(t, []FieldType{NumberType, StringType}, id.Types, "mixed types are reported")
+	assert.True(t, id.Required)
+
+	assert.True(t, schema.Fields["customer"].Required)
+	assert.True(t, schema.Fields["customer"].Properties["email"].Required)
+	assert.False(t, schema.Fields["customer"].Properties["vip"].Required)
+	assert.Equal(t, []FieldType{StringType}, schema.Fields["tags"].Items.Types)
+
+	note := schema.Fields["note"]
+	assert.True(t, note.Nullable)
+	assert.False(t, note.Required)
+	assert.True(t, schema.Fields["card"].Encrypted)
+
+	assert.Equal(t, map[string]FieldType{"customer": ObjectType, "tags": ArrayType}, schema.Proposed)
+}
+
+func TestInferredJSONSchema(t *testing.T) {
+	document := InferSchema([][]byte{
+		[]byte(`{"id":1,"customer":{"email":"a@b.c"},"note":null}`),
+		[]byte(`{"id":2,"customer":{"email":"d@e.f"},"note":"late"}`),
+	}).JSONSchema()
+
+	assert.Equal(t, jsonSchemaDialect, document["$schema"])
+	assert.Equal(t, []string{"customer", "id"}, document["required"])
+
+	properties := document["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "number"}, properties["id"])
+	assert.Equal(t, []string{"string", "null"}, properties["note"].(map[string]any)["type"])
+
+	customer := properties["customer"].(map[string]any)
+	require.Equal(t, "object", customer["type"])
+	assert.Equal(t, []string{"email"}, customer["required"])
+}
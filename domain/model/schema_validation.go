@@ -0,0 +1,198 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRejectionSampleSize is the number of schema rejections kept per queue
+const DefaultRejectionSampleSize = 50
+
+// SchemaViolation is a payload field not matching the domain schema. Got is
+// "missing" for absent fields, Message holds the error of custom validations.
+type SchemaViolation struct {
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Got      string `json:"got,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (v SchemaViolation) String() string {
+	if v.Message != "" {
+		return v.Message
+	}
+	if v.Field == "" {
+		return fmt.Sprintf("payload: expected %s, got %s", v.Expected, v.Got)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", v.Field, v.Expected, v.Got)
+}
+
+// SchemaValidationError lists every violation of a rejected payload, it
+// matches ErrInvalidMessage
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	details := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		details[i] = violation.String()
+	}
+	return ErrInvalidMessage.Error() + ": " + strings.Join(details, "; ")
+}
+
+func (e *SchemaValidationError) Unwrap() error {
+	return ErrInvalidMessage
+}
+
+// Validate checks a payload against the schema, a custom validation
+// replacing the field checks
+func (s *Schema) Validate(payload []byte) error {
+	if s == nil {
+		return nil
+	}
+	if s.Validation != nil {
+		if err := s.Validation(payload); err != nil {
+			return &SchemaValidationError{Violations: []SchemaViolation{{Message: err.Error()}}}
+		}
+		return nil
+	}
+	if len(s.Fields) == 0 {
+		return nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return &SchemaValidationError{Violations: []SchemaViolation{{Expected: string(ObjectType), Got: "invalid JSON"}}}
+	}
+	object, ok := decoded.(map[string]any)
+	if !ok {
+		return &SchemaValidationError{Violations: []SchemaViolation{{Expected: string(ObjectType), Got: jsonTypeName(decoded)}}}
+	}
+
+	fieldNames := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		fieldNames = append(fieldNames, name)
+	}
+	slices.Sort(fieldNames)
+
+	var violations []SchemaViolation
+	for _, fieldName := range fieldNames {
+		fieldType := s.Fields[fieldName]
+		value, exists := object[fieldName]
+		if !exists {
+			violations = append(violations, SchemaViolation{Field: fieldName, Expected: string(fieldType), Got: "missing"})
+			continue
+		}
+		if IsEncryptedField(value) {
+			// sealed by the queue it was first published to
+			continue
+		}
+		if got := jsonTypeName(value); got != string(fieldType) {
+			violations = append(violations, SchemaViolation{Field: fieldName, Expected: string(fieldType), Got: got})
+		}
+	}
+	if len(violations) > 0 {
+		return &SchemaValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// jsonTypeName names the JSON type of a decoded value
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return string(StringType)
+	case float64:
+		return string(NumberType)
+	case bool:
+		return string(BooleanType)
+	case map[string]any:
+		return string(ObjectType)
+	case []any:
+		return string(ArrayType)
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// SchemaRejection is a publish refused by the domain schema
+type SchemaRejection struct {
+	MessageID  string            `json:"messageId"`
+	Violations []SchemaViolation `json:"violations"`
+	RejectedAt time.Time         `json:"rejectedAt"`
+}
+
+// schemaRejectionLog keeps the most recent rejections of a queue in a ring buffer
+type schemaRejectionLog struct {
+	samples []SchemaRejection
+	next    int
+	full    bool
+	total   int64
+}
+
+// SchemaRejections counts the schema rejections of each queue and keeps a
+// sample of the latest ones, for producers to see what they got wrong
+type SchemaRejections struct {
+	size   int
+	mu     sync.Mutex
+	queues map[string]*schemaRejectionLog // "domain/queue" -> log
+}
+
+func NewSchemaRejections(size int) *SchemaRejections {
+	if size <= 0 {
+		size = DefaultRejectionSampleSize
+	}
+	return &SchemaRejections{
+		size:   size,
+		queues: make(map[string]*schemaRejectionLog),
+	}
+}
+
+// Record counts a rejection, overwriting the oldest sample of the queue when full
+func (r *SchemaRejections) Record(domainName, queueName string, rejection SchemaRejection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := domainName + "/" + queueName
+	log, exists := r.queues[key]
+	if !exists {
+		log = &schemaRejectionLog{samples: make([]SchemaRejection, r.size)}
+		r.queues[key] = log
+	}
+
+	log.samples[log.next] = rejection
+	log.next = (log.next + 1) % len(log.samples)
+	if log.next == 0 {
+		log.full = true
+	}
+	log.total++
+}
+
+// Samples returns the sampled rejections of a queue, newest first, and the
+// number of rejections since startup
+func (r *SchemaRejections) Samples(domainName, queueName string) ([]SchemaRejection, int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log, exists := r.queues[domainName+"/"+queueName]
+	if !exists {
+		return []SchemaRejection{}, 0
+	}
+
+	count := log.next
+	if log.full {
+		count = len(log.samples)
+	}
+	result := make([]SchemaRejection, 0, count)
+	for i := 1; i <= count; i++ {
+		result = append(result, log.samples[(log.next-i+len(log.samples))%len(log.samples)])
+	}
+	return result, log.total
+}
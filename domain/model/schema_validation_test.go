@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := &Schema{Fields: map[string]FieldType{
+		"id":    StringType,
+		"total": NumberType,
+		"paid":  BooleanType,
+		"items": ArrayType,
+	}}
+
+	assert.NoError(t, schema.Validate([]byte(`{"id":"o-1","total":3,"paid":true,"items":[],"extra":1}`)))
+
+	err := schema.Validate([]byte(`{"id":7,"total":null,"items":{}}`))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+	var validationErr *SchemaValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []SchemaViolation{
+		{Field: "id", Expected: "string", Got: "number"},
+		{Field: "items", Expected: "array", Got: "object"},
+		{Field: "paid", Expected: "boolean", Got: "missing"},
+		{Field: "total", Expected: "number", Got: "null"},
+	}, validationErr.Violations)
+	assert.Equal(t, "invalid message: id: expected string, got number; items: expected array, got object; paid: expected boolean, got missing; total: expected number, got null", err.Error())
+
+	err = schema.Validate([]byte(`not json`))
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []SchemaViolation{{Expected: "object", Got: "invalid JSON"}}, validationErr.Violations)
+
+	// encrypted fields are sealed, their type can't be checked
+	assert.NoError(t, (&Schema{Fields: map[string]FieldType{"card": StringType}}).Validate(
+		[]byte(fmt.Sprintf(`{"card":{%q:{"v":"AAAA"}}}`, EncryptedFieldKey))))
+}
+
+func TestSchemaValidateCustom(t *testing.T) {
+	schema := &Schema{Validation: func([]byte) error { return errors.New("total must be positive") }}
+
+	err := schema.Validate([]byte(`{}`))
+	var validationErr *SchemaValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []SchemaViolation{{Message: "total must be positive"}}, validationErr.Violations)
+
+	var none *Schema
+	assert.NoError(t, none.Validate([]byte(`anything`)))
+}
+
+func TestSchemaRejectionsKeepsLatest(t *testing.T) {
+	rejections := NewSchemaRejections(2)
+	for _, id := range []string{"1", "2", "3"} {
+		rejections.Record("shop", "orders", SchemaRejection{MessageID: id})
+	}
+
+	samples, total := rejections.Samples("shop", "orders")
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []SchemaRejection{{MessageID: "3"}, {MessageID: "2"}}, samples)
+
+	samples, total = rejections.Samples("shop", "payments")
+	assert.Empty(t, samples)
+	assert.Zero(t, total)
+}
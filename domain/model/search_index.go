@@ -0,0 +1,256 @@
+package model
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSearchLimit is the number of messages a search returns by default
+	DefaultSearchLimit = 100
+
+	// MaxSearchLimit caps the messages a search returns
+	MaxSearchLimit = 1000
+
+	// searchPostingOverhead roughly accounts for the maps holding a posting
+	searchPostingOverhead = 96
+)
+
+// SearchHit locates a message matching a search
+type SearchHit struct {
+	MessageID    string
+	Queue        string
+	StorageQueue string    // partition holding the message
+	Timestamp    time.Time // publish time, orders the results
+}
+
+// searchTerm is an indexed field value of a message
+type searchTerm struct {
+	field string
+	value string
+}
+
+// indexedMessage is a message of the index and its terms
+type indexedMessage struct {
+	hit   SearchHit
+	terms []searchTerm
+}
+
+// domainSearchIndex holds the postings of a domain
+type domainSearchIndex struct {
+	postings map[string]map[string]map[string]SearchHit // field -> value -> message key -> hit
+	messages map[string]indexedMessage                  // message key -> indexed terms
+	bytes    int64
+}
+
+// SearchIndex is an inverted index over selected schema fields, mapping
+// their values to the messages holding them. Only string, number and
+// boolean values are indexed. The index only narrows searches down, the
+// stored messages stay the reference: entries of consumed or deleted
+// messages are dropped when found missing.
+type SearchIndex struct {
+	fields       []string            // indexed in every domain
+	domainFields map[string][]string // indexed in a single domain
+
+	mu      sync.RWMutex
+	domains map[string]*domainSearchIndex
+}
+
+func NewSearchIndex(fields []string, domainFields map[string][]string) *SearchIndex {
+	return &SearchIndex{
+		fields:       fields,
+		domainFields: domainFields,
+		domains:      make(map[string]*domainSearchIndex),
+	}
+}
+
+// Fields returns the fields indexed in a domain, when its schema declares them
+func (x *SearchIndex) Fields(domainName string, schema *Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	var fields []string
+	for _, field := range slices.Concat(x.fields, x.domainFields[domainName]) {
+		if _, declared := schema.Fields[field]; declared && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Started reports whether the domain was indexed and marks it as such, the
+// messages stored before are indexed by whoever gets false
+func (x *SearchIndex) Started(domainName string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if _, exists := x.domains[domainName]; exists {
+		return true
+	}
+	x.domains[domainName] = &domainSearchIndex{
+		postings: make(map[string]map[string]map[string]SearchHit),
+		messages: make(map[string]indexedMessage),
+	}
+	return false
+}
+
+// Add indexes the fields of a message, indexing it again replaces its terms
+func (x *SearchIndex) Add(domainName string, fields []string, hit SearchHit, payload map[string]any) {
+	var terms []searchTerm
+	for _, field := range fields {
+		if value, ok := searchValue(payload[field]); ok {
+			terms = append(terms, searchTerm{field: field, value: value})
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	index, exists := x.domains[domainName]
+	if !exists {
+		return
+	}
+	key := hit.StorageQueue + "/" + hit.MessageID
+	index.remove(key)
+	if len(terms) == 0 {
+		return
+	}
+
+	index.messages[key] = indexedMessage{hit: hit, terms: terms}
+	for _, term := range terms {
+		values, exists := index.postings[term.field]
+		if !exists {
+			values = make(map[string]map[string]SearchHit)
+			index.postings[term.field] = values
+		}
+		hits, exists := values[term.value]
+		if !exists {
+			hits = make(map[string]SearchHit)
+			values[term.value] = hits
+		}
+		hits[key] = hit
+		index.bytes += term.size(hit)
+	}
+}
+
+// Remove drops a message from the index
+func (x *SearchIndex) Remove(domainName string, hit SearchHit) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if index, exists := x.domains[domainName]; exists {
+		index.remove(hit.StorageQueue + "/" + hit.MessageID)
+	}
+}
+
+// remove drops the terms of a message, mu must be held
+func (index *domainSearchIndex) remove(key string) {
+	indexed, exists := index.messages[key]
+	if !exists {
+		return
+	}
+	delete(index.messages, key)
+
+	for _, term := range indexed.terms {
+		hits := index.postings[term.field][term.value]
+		index.bytes -= term.size(indexed.hit)
+		delete(hits, key)
+		if len(hits) == 0 {
+			delete(index.postings[term.field], term.value)
+		}
+	}
+}
+
+// DropDomain forgets a deleted domain, it is indexed again if recreated
+func (x *SearchIndex) DropDomain(domainName string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.domains, domainName)
+}
+
+// Search returns the messages of a domain holding every queried value
+func (x *SearchIndex) Search(domainName string, query map[string]string) []SearchHit {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	index, exists := x.domains[domainName]
+	if !exists || len(query) == 0 {
+		return nil
+	}
+
+	// intersect from the smallest posting list
+	var smallest map[string]SearchHit
+	for field, value := range query {
+		hits := index.postings[field][value]
+		if len(hits) == 0 {
+			return nil
+		}
+		if smallest == nil || len(hits) < len(smallest) {
+			smallest = hits
+		}
+	}
+
+	var result []SearchHit
+	for key, hit := range smallest {
+		matches := true
+		for field, value := range query {
+			if _, found := index.postings[field][value][key]; !found {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, hit)
+		}
+	}
+	return result
+}
+
+// Entries returns the messages indexed per domain, for pruning
+func (x *SearchIndex) Entries() map[string][]SearchHit {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	entries := make(map[string][]SearchHit, len(x.domains))
+	for domainName, index := range x.domains {
+		for _, indexed := range index.messages {
+			entries[domainName] = append(entries[domainName], indexed.hit)
+		}
+	}
+	return entries
+}
+
+// Size returns the number of messages indexed in a domain and an estimate
+// of the memory their postings take
+func (x *SearchIndex) Size(domainName string) (messages int, bytes int64) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	index, exists := x.domains[domainName]
+	if !exists {
+		return 0, 0
+	}
+	return len(index.messages), index.bytes
+}
+
+// size estimates the memory taken by a posting
+func (t searchTerm) size(hit SearchHit) int64 {
+	return int64(len(t.field) + len(t.value) + 2*len(hit.MessageID) + len(hit.Queue) + 2*len(hit.StorageQueue) + searchPostingOverhead)
+}
+
+// searchValue returns the indexed form of a payload value, numbers the way
+// they're queried (12, not 12.0)
+func searchValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchIndexFieldsFollowSchema(t *testing.T) {
+	index := NewSearchIndex([]string{"customerId"}, map[string][]string{"shop": {"status", "customerId"}})
+	schema := &Schema{Fields: map[string]FieldType{"customerId": StringType, "status": StringType}}
+
+	assert.Equal(t, []string{"customerId", "status"}, index.Fields("shop", schema))
+	assert.Equal(t, []string{"customerId"}, index.Fields("billing", schema))
+	assert.Empty(t, index.Fields("shop", &Schema{Fields: map[string]FieldType{"total": NumberType}}))
+	assert.Empty(t, index.Fields("shop", nil))
+}
+
+func TestSearchIndexAddSearchRemove(t *testing.T) {
+	index := NewSearchIndex(nil, nil)
+	fields := []string{"customerId", "total", "paid"}
+
+	// domains not started aren't indexed
+	index.Add("shop", fields, SearchHit{MessageID: "0", Queue: "orders", StorageQueue: "orders"}, map[string]any{"customerId": "c-1"})
+	assert.False(t, index.Started("shop"))
+	assert.True(t, index.Started("shop"))
+
+	first := SearchHit{MessageID: "1", Queue: "orders", StorageQueue: "orders"}
+	second := SearchHit{MessageID: "2", Queue: "orders", StorageQueue: "orders"}
+	index.Add("shop", fields, first, map[string]any{"customerId": "c-1", "total": 12.0, "paid": true})
+	index.Add("shop", fields, second, map[string]any{"customerId": "c-1", "total": 7.5, "paid": false, "note": "x"})
+	index.Add("shop", fields, SearchHit{MessageID: "3", StorageQueue: "orders"}, map[string]any{"customerId": map[string]any{}})
+
+	assert.ElementsMatch(t, []SearchHit{first, second}, index.Search("shop", map[string]string{"customerId": "c-1"}))
+	assert.Equal(t, []SearchHit{first}, index.Search("shop", map[string]string{"customerId": "c-1", "total": "12"}))
+	assert.Equal(t, []SearchHit{second}, index.Search("shop", map[string]string{"paid": "false"}))
+	assert.Empty(t, index.Search("shop", map[string]string{"customerId": "c-2"}))
+	assert.Empty(t, index.Search("billing", map[string]string{"customerId": "c-1"}))
+
+	messages, bytes := index.Size("shop")
+	assert.Equal(t, 2, messages)
+	assert.Positive(t, bytes)
+
+	// indexing again replaces the terms
+	index.Add("shop", fields, first, map[string]any{"customerId": "c-2"})
+	assert.Equal(t, []SearchHit{second}, index.Search("shop", map[string]string{"customerId": "c-1"}))
+	assert.Equal(t, []SearchHit{first}, index.Search("shop", map[string]string{"customerId": "c-2"}))
+
+	index.Remove("shop", first)
+	index.Remove("shop", second)
+	messages, bytes = index.Size("shop")
+	assert.Zero(t, messages)
+	assert.Zero(t, bytes)
+	assert.Empty(t, index.Entries()["shop"])
+
+	index.DropDomain("shop")
+	assert.False(t, index.Started("shop"))
+}
@@ -1,35 +1,126 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
 
+var (
+	ErrInvalidServiceLimits = errors.New("invalid service limits")
+	ErrInvalidServiceScope  = errors.New("invalid service domain scope")
+	ErrInvalidServiceWindow = errors.New("invalid service timestamp window")
+)
+
+// ServiceLimits isolate a service account from the others on a shared
+// broker. Zero falls back to the per-service defaults of the configuration.
+type ServiceLimits struct {
+	// MaxInFlight bounds the HMAC requests of the account served at once
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+
+	// MaxConnections bounds the streaming (WebSocket) connections the
+	// account holds open
+	MaxConnections int `json:"maxConnections,omitempty"`
+}
+
+// Validate rejects negative limits
+func (l ServiceLimits) Validate() error {
+	if l.MaxInFlight < 0 || l.MaxConnections < 0 {
+		return fmt.Errorf("%w: limits can't be negative", ErrInvalidServiceLimits)
+	}
+	return nil
+}
+
+// MaxServiceTimestampWindow caps the timestamp window of a service account,
+// past it a captured request could be replayed for too long
+const MaxServiceTimestampWindow = 15 * time.Minute
+
+// ValidateTimestampWindow accepts an empty window, the server default, or
+// a positive duration up to MaxServiceTimestampWindow
+func ValidateTimestampWindow(window string) error {
+	if window == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(window); err != nil || d <= 0 || d > MaxServiceTimestampWindow {
+		return fmt.Errorf("%w: must be a positive duration up to %s: %q", ErrInvalidServiceWindow, MaxServiceTimestampWindow, window)
+	}
+	return nil
+}
+
+// ValidateServiceScope rejects domain scopes naming no domain or every one
+func ValidateServiceScope(domains []string) error {
+	for _, domain := range domains {
+		if strings.TrimSpace(domain) == "" || domain == "*" {
+			return fmt.Errorf("%w: %q is not a domain name", ErrInvalidServiceScope, domain)
+		}
+	}
+	return nil
+}
+
 // represents a service account for HMAC authentication
 type ServiceAccount struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Secret      string    `json:"-"`
-	IsDisclosed bool      `json:"isDisclosed"`
-	Permissions []string  `json:"permissions"`
-	IPWhitelist []string  `json:"ipWhitelist,omitempty"`
-	CreatedAt   time.Time `json:"createdAt"`
-	LastUsed    time.Time `json:"lastUsed"`
-	Enabled     bool      `json:"enabled"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Secret      string        `json:"-"`
+	IsDisclosed bool          `json:"isDisclosed"`
+	Permissions []string      `json:"permissions"`
+	IPWhitelist []string      `json:"ipWhitelist,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	LastUsed    time.Time     `json:"lastUsed"`
+	Enabled     bool          `json:"enabled"`
+	Version     uint64        `json:"version"`             // bumped by the repository on every write
+	ManagedBy   string        `json:"managedBy,omitempty"` // reconciler owning the account, see LabelManagedBy
+	Limits      ServiceLimits `json:"limits"`
+
+	// Domains restricts the account to the named domains whatever its
+	// permissions grant, empty leaving it global
+	Domains []string `json:"domains,omitempty"`
+
+	// TimestampWindow is the clock skew allowed between the signed
+	// timestamps of the account and the server, the server default when empty
+	TimestampWindow string `json:"timestampWindow,omitempty"`
 }
 
-// checks if service has specific permission
+// SkewWindow returns the timestamp window of the account, def when it sets none,
+// capped for accounts stored before the cap
+func (s *ServiceAccount) SkewWindow(def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s.TimestampWindow); err == nil && d > 0 {
+		return min(d, MaxServiceTimestampWindow)
+	}
+	return def
+}
+
+// InScope reports whether the account may act on a domain, "*" standing for
+// every domain and so only in scope of global accounts
+func (s *ServiceAccount) InScope(domain string) bool {
+	return len(s.Domains) == 0 || (domain != "*" && slices.Contains(s.Domains, domain))
+}
+
+// checks if service has specific permission, within the domain scope of the
+// service when it has one
 func (s *ServiceAccount) HasPermission(permission string) bool {
+	_, ok := s.MatchPermission(permission)
+	return ok
+}
+
+// MatchPermission returns the permission of the service granting the one
+// asked for, false when none does or the domain is out of scope
+func (s *ServiceAccount) MatchPermission(permission string) (string, bool) {
+	if _, domain, ok := strings.Cut(permission, ":"); ok && !s.InScope(domain) {
+		return "", false
+	}
 	for _, p := range s.Permissions {
 		if p == permission || p == "*" {
-			return true
+			return p, true
 		}
 
 		// Support wildcard patterns like "publish:*" (action wildcard)
 		if strings.HasSuffix(p, ":*") {
 			prefix := strings.TrimSuffix(p, "*")
 			if strings.HasPrefix(permission, prefix) {
-				return true
+				return p, true
 			}
 		}
 
@@ -37,24 +128,44 @@ func (s *ServiceAccount) HasPermission(permission string) bool {
 		if strings.HasPrefix(p, "*:") {
 			suffix := strings.TrimPrefix(p, "*:")
 			if strings.HasSuffix(permission, ":"+suffix) {
-				return true
+				return p, true
 			}
 		}
 	}
-	return false
+	return "", false
+}
+
+// AccessCheck tells whether a service account may send a request, as the
+// HMAC middleware would decide it
+type AccessCheck struct {
+	Allowed bool `json:"allowed"`
+
+	// Permission the request needs, empty when the route needs none
+	Permission string `json:"permission,omitempty"`
+
+	// MatchedBy is the permission of the service granting it
+	MatchedBy string `json:"matchedBy,omitempty"`
+
+	// Reason explains a refusal
+	Reason string `json:"reason,omitempty"`
 }
 
 // returns a view of the service account safe for API responses
 func (s *ServiceAccount) ToPublicView() *ServiceAccountView {
 	view := &ServiceAccountView{
-		ID:          s.ID,
-		Name:        s.Name,
-		IsDisclosed: s.IsDisclosed,
-		Permissions: s.Permissions,
-		IPWhitelist: s.IPWhitelist,
-		CreatedAt:   s.CreatedAt,
-		LastUsed:    s.LastUsed,
-		Enabled:     s.Enabled,
+		ID:              s.ID,
+		Name:            s.Name,
+		IsDisclosed:     s.IsDisclosed,
+		Permissions:     s.Permissions,
+		IPWhitelist:     s.IPWhitelist,
+		CreatedAt:       s.CreatedAt,
+		LastUsed:        s.LastUsed,
+		Enabled:         s.Enabled,
+		Version:         s.Version,
+		ManagedBy:       s.ManagedBy,
+		Limits:          s.Limits,
+		Domains:         s.Domains,
+		TimestampWindow: s.TimestampWindow,
 	}
 
 	// Mask secret if already disclosed
@@ -67,27 +178,68 @@ func (s *ServiceAccount) ToPublicView() *ServiceAccountView {
 
 // represents the public view of a service account
 type ServiceAccountView struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Secret      string    `json:"secret,omitempty"`
-	IsDisclosed bool      `json:"isDisclosed"`
-	Permissions []string  `json:"permissions"`
-	IPWhitelist []string  `json:"ipWhitelist,omitempty"`
-	CreatedAt   time.Time `json:"createdAt"`
-	LastUsed    time.Time `json:"lastUsed"`
-	Enabled     bool      `json:"enabled"`
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	Secret          string        `json:"secret,omitempty"`
+	IsDisclosed     bool          `json:"isDisclosed"`
+	Permissions     []string      `json:"permissions"`
+	IPWhitelist     []string      `json:"ipWhitelist,omitempty"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	LastUsed        time.Time     `json:"lastUsed"`
+	Enabled         bool          `json:"enabled"`
+	Version         uint64        `json:"version"`
+	ManagedBy       string        `json:"managedBy,omitempty"`
+	Limits          ServiceLimits `json:"limits"`
+	Domains         []string      `json:"domains,omitempty"`
+	TimestampWindow string        `json:"timestampWindow,omitempty"`
 }
 
 // represents a request to create a service account
 type ServiceAccountCreateRequest struct {
-	Name        string   `json:"name" validate:"required,min=3,max=50"`
-	Permissions []string `json:"permissions" validate:"required,min=1"`
-	IPWhitelist []string `json:"ipWhitelist,omitempty"`
+	Name            string        `json:"name" validate:"required,min=3,max=50"`
+	Permissions     []string      `json:"permissions" validate:"required,min=1"`
+	IPWhitelist     []string      `json:"ipWhitelist,omitempty"`
+	Limits          ServiceLimits `json:"limits"`
+	Domains         []string      `json:"domains,omitempty"`
+	TimestampWindow string        `json:"timestampWindow,omitempty"`
 }
 
 // represents a request to update service permissions
 type ServiceAccountUpdateRequest struct {
-	Permissions []string `json:"permissions" validate:"required,min=1"`
-	IPWhitelist []string `json:"ipWhitelist,omitempty"`
-	Enabled     *bool    `json:"enabled,omitempty"`
+	Permissions     []string       `json:"permissions" validate:"required,min=1"`
+	IPWhitelist     []string       `json:"ipWhitelist,omitempty"`
+	Enabled         *bool          `json:"enabled,omitempty"`
+	Limits          *ServiceLimits `json:"limits,omitempty"`
+	Domains         *[]string      `json:"domains,omitempty"`
+	TimestampWindow *string        `json:"timestampWindow,omitempty"`
+}
+
+// IPAllowed checks whether the IP of an "IP:port" address matches a whitelist
+// entry, "*" matching every address and a trailing "*" a prefix
+func IPAllowed(remoteAddr string, whitelist []string) bool {
+	// Extract IP from "IP:port" format
+	clientIP := remoteAddr
+	if idx := strings.LastIndex(remoteAddr, ":"); idx != -1 {
+		clientIP = remoteAddr[:idx]
+	}
+
+	// Remove brackets from IPv6 addresses
+	clientIP = strings.Trim(clientIP, "[]")
+
+	for _, allowedIP := range whitelist {
+		// Simple IP matching (could be enhanced with CIDR support)
+		if clientIP == allowedIP || allowedIP == "*" {
+			return true
+		}
+
+		// Basic wildcard support
+		if strings.HasSuffix(allowedIP, "*") {
+			prefix := strings.TrimSuffix(allowedIP, "*")
+			if strings.HasPrefix(clientIP, prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceAccountDomainScope(t *testing.T) {
+	global := &ServiceAccount{Permissions: []string{"*"}}
+	assert.True(t, global.HasPermission("publish:inventory"))
+	assert.True(t, global.HasPermission("replicate:*"))
+
+	scoped := &ServiceAccount{Permissions: []string{"*", "consume:*"}, Domains: []string{"orders"}}
+	assert.True(t, scoped.HasPermission("publish:orders"))
+	assert.False(t, scoped.HasPermission("publish:inventory"))
+	assert.False(t, scoped.HasPermission("replicate:*"))
+
+	// delegated tokens keep the scope of their account
+	assert.False(t, scoped.Scoped([]string{"consume:*"}).HasPermission("consume:inventory"))
+
+	assert.NoError(t, ValidateServiceScope([]string{"orders"}))
+	assert.ErrorIs(t, ValidateServiceScope([]string{" "}), ErrInvalidServiceScope)
+}
+
+func TestServiceAccountTimestampWindow(t *testing.T) {
+	assert.NoError(t, ValidateTimestampWindow(""))
+	assert.NoError(t, ValidateTimestampWindow("15m"))
+	assert.ErrorIs(t, ValidateTimestampWindow("0s"), ErrInvalidServiceWindow)
+	assert.ErrorIs(t, ValidateTimestampWindow("8760h"), ErrInvalidServiceWindow)
+
+	assert.Equal(t, 5*time.Minute, (&ServiceAccount{}).SkewWindow(5*time.Minute))
+	assert.Equal(t, 2*time.Minute, (&ServiceAccount{TimestampWindow: "2m"}).SkewWindow(5*time.Minute))
+
+	// accounts stored before the cap don't escape it
+	assert.Equal(t, MaxServiceTimestampWindow, (&ServiceAccount{TimestampWindow: "8760h"}).SkewWindow(5*time.Minute))
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// usageBuckets are the minutes of traffic kept per service account, the
+// longest rolling window
+const usageBuckets = 60
+
+// UsageWindows are the rolling windows reported for each account
+var UsageWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// UsageCounters count messages and their payload bytes
+type UsageCounters struct {
+	Messages int64 `json:"messages"`
+	Bytes    int64 `json:"bytes"`
+}
+
+func (c *UsageCounters) add(other UsageCounters) {
+	c.Messages += other.Messages
+	c.Bytes += other.Bytes
+}
+
+// UsageWindow is the traffic of an account over the last Window
+type UsageWindow struct {
+	Window    string        `json:"window"`
+	Published UsageCounters `json:"published"`
+	Consumed  UsageCounters `json:"consumed"`
+}
+
+// ServiceUsage is the traffic of a service account: rolling windows for
+// quotas, totals since startup for chargeback
+type ServiceUsage struct {
+	ServiceID      string        `json:"serviceId"`
+	Windows        []UsageWindow `json:"windows"`
+	TotalPublished UsageCounters `json:"totalPublished"`
+	TotalConsumed  UsageCounters `json:"totalConsumed"`
+	Since          time.Time     `json:"since"`
+	LastActivity   time.Time     `json:"lastActivity"`
+}
+
+// Window returns the usage over one of UsageWindows, false for others
+func (u ServiceUsage) Window(window time.Duration) (UsageWindow, bool) {
+	for _, w := range u.Windows {
+		if w.Window == window.String() {
+			return w, true
+		}
+	}
+	return UsageWindow{}, false
+}
+
+type usageBucket struct {
+	minute    int64 // unix minute the counters belong to
+	published UsageCounters
+	consumed  UsageCounters
+}
+
+type serviceUsage struct {
+	mu             sync.Mutex
+	buckets        [usageBuckets]usageBucket
+	totalPublished UsageCounters
+	totalConsumed  UsageCounters
+	since          time.Time
+	lastActivity   time.Time
+}
+
+// ServiceUsageTracker counts what each service account publishes and
+// consumes, per minute for the last hour and in total since startup
+type ServiceUsageTracker struct {
+	mu       sync.RWMutex
+	services map[string]*serviceUsage
+}
+
+func NewServiceUsageTracker() *ServiceUsageTracker {
+	return &ServiceUsageTracker{services: make(map[string]*serviceUsage)}
+}
+
+// RecordPublished counts a message published by the account
+func (t *ServiceUsageTracker) RecordPublished(serviceID string, bytes int, now time.Time) {
+	t.record(serviceID, UsageCounters{Messages: 1, Bytes: int64(bytes)}, UsageCounters{}, now)
+}
+
+// RecordConsumed counts a message consumed by the account
+func (t *ServiceUsageTracker) RecordConsumed(serviceID string, bytes int, now time.Time) {
+	t.record(serviceID, UsageCounters{}, UsageCounters{Messages: 1, Bytes: int64(bytes)}, now)
+}
+
+func (t *ServiceUsageTracker) record(serviceID string, published, consumed UsageCounters, now time.Time) {
+	if serviceID == "" {
+		return
+	}
+
+	t.mu.RLock()
+	usage, exists := t.services[serviceID]
+	t.mu.RUnlock()
+	if !exists {
+		t.mu.Lock()
+		if usage, exists = t.services[serviceID]; !exists {
+			usage = &serviceUsage{since: now}
+			t.services[serviceID] = usage
+		}
+		t.mu.Unlock()
+	}
+
+	minute := now.Unix() / 60
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+
+	bucket := &usage.buckets[minute%usageBuckets]
+	if bucket.minute != minute {
+		*bucket = usageBucket{minute: minute}
+	}
+	bucket.published.add(published)
+	bucket.consumed.add(consumed)
+	usage.totalPublished.add(published)
+	usage.totalConsumed.add(consumed)
+	usage.lastActivity = now
+}
+
+// Usage returns the traffic of an account, zero when it had none since
+// startup
+func (t *ServiceUsageTracker) Usage(serviceID string, now time.Time) ServiceUsage {
+	t.mu.RLock()
+	usage, exists := t.services[serviceID]
+	t.mu.RUnlock()
+	if !exists {
+		usage = &serviceUsage{}
+	}
+	return usage.snapshot(serviceID, now)
+}
+
+// All returns the traffic of every account seen since startup, by ID
+func (t *ServiceUsageTracker) All(now time.Time) []ServiceUsage {
+	t.mu.RLock()
+	ids := make([]string, 0, len(t.services))
+	for id := range t.services {
+		ids = append(ids, id)
+	}
+	t.mu.RUnlock()
+	sort.Strings(ids)
+
+	usages := make([]ServiceUsage, 0, len(ids))
+	for _, id := range ids {
+		usages = append(usages, t.Usage(id, now))
+	}
+	return usages
+}
+
+func (u *serviceUsage) snapshot(serviceID string, now time.Time) ServiceUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	snapshot := ServiceUsage{
+		ServiceID:      serviceID,
+		Windows:        make([]UsageWindow, len(UsageWindows)),
+		TotalPublished: u.totalPublished,
+		TotalConsumed:  u.totalConsumed,
+		Since:          u.since,
+		LastActivity:   u.lastActivity,
+	}
+	current := now.Unix() / 60
+	for i, window := range UsageWindows {
+		w := UsageWindow{Window: window.String()}
+		minutes := int64(window / time.Minute)
+		for _, bucket := range u.buckets {
+			// the current minute counts as the first of each window
+			if age := current - bucket.minute; age >= 0 && age < minutes {
+				w.Published.add(bucket.published)
+				w.Consumed.add(bucket.consumed)
+			}
+		}
+		snapshot.Windows[i] = w
+	}
+	return snapshot
+}
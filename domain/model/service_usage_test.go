@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceUsageWindows(t *testing.T) {
+	tracker := NewServiceUsageTracker()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.RecordPublished("billing", 100, start)
+	tracker.RecordPublished("billing", 50, start.Add(10*time.Second))
+	tracker.RecordConsumed("billing", 30, start.Add(7*time.Minute))
+	tracker.RecordPublished("", 10, start) // anonymous traffic isn't counted
+
+	now := start.Add(7*time.Minute + 30*time.Second)
+	usage := tracker.Usage("billing", now)
+	assert.Equal(t, "billing", usage.ServiceID)
+	assert.Equal(t, start, usage.Since)
+	assert.Equal(t, start.Add(7*time.Minute), usage.LastActivity)
+	assert.Equal(t, UsageCounters{Messages: 2, Bytes: 150}, usage.TotalPublished)
+	assert.Equal(t, UsageCounters{Messages: 1, Bytes: 30}, usage.TotalConsumed)
+
+	oneMinute, ok := usage.Window(time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, UsageCounters{}, oneMinute.Published)
+	assert.Equal(t, UsageCounters{Messages: 1, Bytes: 30}, oneMinute.Consumed)
+
+	fiveMinutes, _ := usage.Window(5 * time.Minute)
+	assert.Equal(t, UsageCounters{}, fiveMinutes.Published)
+
+	fifteenMinutes, _ := usage.Window(15 * time.Minute)
+	assert.Equal(t, UsageCounters{Messages: 2, Bytes: 150}, fifteenMinutes.Published)
+
+	// An hour later the windows are empty, the totals remain
+	usage = tracker.Usage("billing", start.Add(2*time.Hour))
+	hour, _ := usage.Window(time.Hour)
+	assert.Equal(t, UsageCounters{}, hour.Published)
+	assert.Equal(t, UsageCounters{}, hour.Consumed)
+	assert.Equal(t, int64(2), usage.TotalPublished.Messages)
+
+	_, ok = usage.Window(2 * time.Minute)
+	assert.False(t, ok)
+}
+
+func TestServiceUsageBucketReuse(t *testing.T) {
+	tracker := NewServiceUsageTracker()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// The bucket of a minute is reused an hour later without its old counts
+	tracker.RecordPublished("billing", 10, start)
+	tracker.RecordPublished("billing", 20, start.Add(time.Hour))
+
+	usage := tracker.Usage("billing", start.Add(time.Hour))
+	hour, _ := usage.Window(time.Hour)
+	assert.Equal(t, UsageCounters{Messages: 1, Bytes: 20}, hour.Published)
+	assert.Equal(t, UsageCounters{Messages: 2, Bytes: 30}, usage.TotalPublished)
+}
+
+func TestServiceUsageAll(t *testing.T) {
+	tracker := NewServiceUsageTracker()
+	now := time.Now()
+	tracker.RecordConsumed("orders", 1, now)
+	tracker.RecordPublished("billing", 1, now)
+
+	all := tracker.All(now)
+	require.Len(t, all, 2)
+	assert.Equal(t, "billing", all[0].ServiceID)
+	assert.Equal(t, "orders", all[1].ServiceID)
+
+	unknown := tracker.Usage("nope", now)
+	assert.Zero(t, unknown.TotalPublished)
+	assert.Len(t, unknown.Windows, len(UsageWindows))
+	assert.Len(t, tracker.All(now), 2, "looking up an account doesn't create it")
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHandlerTimeout bounds the time a subscriber handles a message
+	DefaultHandlerTimeout = 5 * time.Second
+
+	// DefaultQuarantineAfter is the number of consecutive timeouts or panics
+	// putting a subscriber aside
+	DefaultQuarantineAfter = 3
+
+	// DefaultQuarantineFor is how long a subscriber is put aside
+	DefaultQuarantineFor = time.Minute
+)
+
+var (
+	ErrHandlerTimeout        = errors.New("subscriber handler timed out")
+	ErrHandlerPanic          = errors.New("subscriber handler panicked")
+	ErrSubscriberBusy        = errors.New("subscriber still handling a timed out message")
+	ErrSubscriberQuarantined = errors.New("subscriber quarantined")
+)
+
+// SubscriberPolicy bounds what a subscriber may cost its queue
+type SubscriberPolicy struct {
+	// Timeout is the longest a handler is waited for
+	Timeout time.Duration `yaml:"timeout"`
+
+	// QuarantineAfter consecutive timeouts or panics skip the subscriber
+	// for QuarantineFor
+	QuarantineAfter int           `yaml:"quarantineAfter"`
+	QuarantineFor   time.Duration `yaml:"quarantineFor"`
+}
+
+// withDefaults fills the values left empty
+func (p SubscriberPolicy) withDefaults() SubscriberPolicy {
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultHandlerTimeout
+	}
+	if p.QuarantineAfter <= 0 {
+		p.QuarantineAfter = DefaultQuarantineAfter
+	}
+	if p.QuarantineFor <= 0 {
+		p.QuarantineFor = DefaultQuarantineFor
+	}
+	return p
+}
+
+// SubscriberStats reports how a subscriber handles its messages
+type SubscriberStats struct {
+	ID               string        `json:"id"`
+	Domain           string        `json:"domain"`
+	Queue            string        `json:"queue"`
+	Delivered        int64         `json:"delivered"`
+	Errors           int64         `json:"errors"`
+	Timeouts         int64         `json:"timeouts"`
+	Panics           int64         `json:"panics"`
+	Skipped          int64         `json:"skipped"` // busy or quarantined
+	AvgLatency       time.Duration `json:"avgLatencyNs"`
+	MaxLatency       time.Duration `json:"maxLatencyNs"`
+	LastLatency      time.Duration `json:"lastLatencyNs"`
+	QuarantinedUntil *time.Time    `json:"quarantinedUntil,omitempty"`
+}
+
+// subscriberState tracks a subscriber between deliveries
+type subscriberState struct {
+	stats        SubscriberStats
+	totalLatency time.Duration
+	completed    int64
+	consecutive  int  // timeouts and panics in a row
+	busy         bool // a timed out call is still running
+}
+
+// SubscriberGuard calls subscriber handlers so that a slow or panicking one
+// can't hold its queue: panics are recovered, handlers taking longer than
+// the timeout are given up on, and subscribers failing that way again and
+// again are skipped for a while. A timed out handler keeps running, the
+// subscriber gets no other message until it returns.
+type SubscriberGuard struct {
+	policy SubscriberPolicy
+	logger Logger
+
+	mu          sync.Mutex
+	subscribers map[string]*subscriberState
+}
+
+func NewSubscriberGuard(policy SubscriberPolicy, logger Logger) *SubscriberGuard {
+	return &SubscriberGuard{
+		policy:      policy.withDefaults(),
+		logger:      logger,
+		subscribers: make(map[string]*subscriberState),
+	}
+}
+
+// Deliver hands a message to a subscriber, within the policy
+func (g *SubscriberGuard) Deliver(id, domainName, queueName string, handler MessageHandler, msg *Message) error {
+	g.mu.Lock()
+	state, exists := g.subscribers[id]
+	if !exists {
+		state = &subscriberState{stats: SubscriberStats{ID: id, Domain: domainName, Queue: queueName}}
+		g.subscribers[id] = state
+	}
+	now := time.Now()
+	if until := state.stats.QuarantinedUntil; until != nil {
+		if now.Before(*until) {
+			state.stats.Skipped++
+			g.mu.Unlock()
+			return ErrSubscriberQuarantined
+		}
+		state.stats.QuarantinedUntil = nil
+	}
+	if state.busy {
+		state.stats.Skipped++
+		g.mu.Unlock()
+		return ErrSubscriberBusy
+	}
+	state.busy = true
+	timeout := g.policy.Timeout
+	g.mu.Unlock()
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+			}
+			g.mu.Lock()
+			state.busy = false
+			g.mu.Unlock()
+		}()
+		result <- handler(msg)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-timer.C:
+		err = ErrHandlerTimeout
+	}
+	g.record(state, time.Since(now), err)
+	return err
+}
+
+// record updates the stats of a delivery and quarantines the subscriber
+// once it failed too often in a row
+func (g *SubscriberGuard) record(state *subscriberState, latency time.Duration, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats := &state.stats
+	stats.Delivered++
+	stats.LastLatency = latency
+	stats.MaxLatency = max(stats.MaxLatency, latency)
+	state.totalLatency += latency
+	state.completed++
+	stats.AvgLatency = state.totalLatency / time.Duration(state.completed)
+
+	switch {
+	case err == nil:
+		state.consecutive = 0
+		return
+	case errors.Is(err, ErrHandlerTimeout):
+		stats.Timeouts++
+	case errors.Is(err, ErrHandlerPanic):
+		stats.Panics++
+	default:
+		// the subscriber is alive and answering, only slow or crashing ones
+		// are put aside
+		stats.Errors++
+		state.consecutive = 0
+		return
+	}
+
+	state.consecutive++
+	if state.consecutive >= g.policy.QuarantineAfter {
+		until := time.Now().Add(g.policy.QuarantineFor)
+		stats.QuarantinedUntil = &until
+		state.consecutive = 0
+		if g.logger != nil {
+			g.logger.Warn("Subscriber quarantined",
+				"subscriber", stats.ID,
+				"queue", stats.Domain+"."+stats.Queue,
+				"until", until,
+				"ERROR", err)
+		}
+	}
+}
+
+// Forget drops the stats of a removed subscriber
+func (g *SubscriberGuard) Forget(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.subscribers, id)
+}
+
+// Stats returns the stats of the subscribers of a queue
+func (g *SubscriberGuard) Stats(domainName, queueName string) []SubscriberStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make([]SubscriberStats, 0)
+	for _, state := range g.subscribers {
+		if state.stats.Domain == domainName && state.stats.Queue == queueName {
+			stats := state.stats
+			if until := stats.QuarantinedUntil; until != nil {
+				copied := *until
+				stats.QuarantinedUntil = &copied
+			}
+			result = append(result, stats)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberGuardRecoversPanics(t *testing.T) {
+	guard := NewSubscriberGuard(SubscriberPolicy{QuarantineAfter: 2}, nil)
+	panicking := func(*Message) error { panic("boom") }
+
+	err := guard.Deliver("sub-1", "shop", "orders", panicking, &Message{ID: "msg-1"})
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+
+	err = guard.Deliver("sub-1", "shop", "orders", panicking, &Message{ID: "msg-2"})
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+
+	// quarantined after two panics in a row
+	called := false
+	err = guard.Deliver("sub-1", "shop", "orders", func(*Message) error {
+		called = true
+		return nil
+	}, &Message{ID: "msg-3"})
+	assert.ErrorIs(t, err, ErrSubscriberQuarantined)
+	assert.False(t, called)
+
+	stats := guard.Stats("shop", "orders")
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Panics)
+	assert.Equal(t, int64(1), stats[0].Skipped)
+	assert.NotNil(t, stats[0].QuarantinedUntil)
+}
+
+func TestSubscriberGuardTimesOutSlowHandlers(t *testing.T) {
+	guard := NewSubscriberGuard(SubscriberPolicy{Timeout: 20 * time.Millisecond}, nil)
+	release := make(chan struct{})
+
+	start := time.Now()
+	err := guard.Deliver("sub-1", "shop", "orders", func(*Message) error {
+		<-release
+		return nil
+	}, &Message{ID: "msg-1"})
+	assert.ErrorIs(t, err, ErrHandlerTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the timed out handler still runs, the subscriber is skipped meanwhile
+	err = guard.Deliver("sub-1", "shop", "orders", func(*Message) error { return nil }, &Message{ID: "msg-2"})
+	assert.ErrorIs(t, err, ErrSubscriberBusy)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return guard.Deliver("sub-1", "shop", "orders", func(*Message) error { return nil }, &Message{ID: "msg-3"}) == nil
+	}, time.Second, 5*time.Millisecond)
+
+	stats := guard.Stats("shop", "orders")
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(1), stats[0].Timeouts)
+	assert.GreaterOrEqual(t, stats[0].MaxLatency, 20*time.Millisecond)
+	assert.Nil(t, stats[0].QuarantinedUntil)
+}
+
+func TestSubscriberGuardErrorsDontQuarantine(t *testing.T) {
+	guard := NewSubscriberGuard(SubscriberPolicy{QuarantineAfter: 1}, nil)
+	failing := func(*Message) error { return errors.New("rejected") }
+
+	for range 3 {
+		err := guard.Deliver("sub-1", "shop", "orders", failing, &Message{})
+		assert.EqualError(t, err, "rejected")
+	}
+
+	stats := guard.Stats("shop", "orders")
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(3), stats[0].Delivered)
+	assert.Equal(t, int64(3), stats[0].Errors)
+	assert.Nil(t, stats[0].QuarantinedUntil)
+
+	guard.Forget("sub-1")
+	assert.Empty(t, guard.Stats("shop", "orders"))
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultResumeWindow is how long the subscription of a client gone away
+	// is kept for it to reconnect
+	DefaultResumeWindow = 30 * time.Second
+
+	// DefaultResumeBuffer bounds the messages kept for such a subscription
+	DefaultResumeBuffer = 1000
+)
+
+// ErrSubscriptionNotResumable is returned when resuming a subscription that
+// expired, is still attached or belongs to another queue
+var ErrSubscriptionNotResumable = errors.New("subscription can't be resumed")
+
+// SubscriptionRetention keeps the subscriptions of the clients that went
+// away for Window, their messages buffered up to Buffer, the oldest going
+// first, so that a client reconnecting in time resumes where it stopped.
+// A zero Window drops subscriptions with their connection.
+type SubscriptionRetention struct {
+	Window time.Duration
+	Buffer int
+}
+
+func (r SubscriptionRetention) withDefaults() SubscriptionRetention {
+	if r.Window > 0 && r.Buffer <= 0 {
+		r.Buffer = DefaultResumeBuffer
+	}
+	return r
+}
+
+// Enabled reports whether subscriptions outlive their connection
+func (r SubscriptionRetention) Enabled() bool {
+	return r.Window > 0
+}
+
+// NewSubscriptionRetention fills in the buffer of a retention
+func NewSubscriptionRetention(window time.Duration, buffer int) SubscriptionRetention {
+	return SubscriptionRetention{Window: window, Buffer: buffer}.withDefaults()
+}
@@ -21,6 +21,7 @@ type User struct {
 	LastLogin      time.Time `json:"lastLogin"`
 	LastValidLogin time.Time `json:"lastValidLogin"`
 	Enabled        bool      `json:"enabled"`
+	Email          string    `json:"email,omitempty"`
 }
 
 type UserDatabase struct {
@@ -35,6 +36,7 @@ type UserResponse struct {
 	CreatedAt time.Time `json:"createdAt"`
 	LastLogin time.Time `json:"lastLogin"`
 	Enabled   bool      `json:"enabled"`
+	Email     string    `json:"email,omitempty"`
 }
 
 func (u *User) ToResponse() *UserResponse {
@@ -45,5 +47,6 @@ func (u *User) ToResponse() *UserResponse {
 		CreatedAt: u.CreatedAt,
 		LastLogin: u.LastLogin,
 		Enabled:   u.Enabled,
+		Email:     u.Email,
 	}
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// WaitBuckets are the upper bounds, in seconds, of the buckets of a
+// WaitHistogram, from the few milliseconds of a queue kept up with to the
+// hour of a stalled one
+var WaitBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600}
+
+// WaitBucket counts the waits up to a bound, cumulative like Prometheus
+// buckets
+type WaitBucket struct {
+	Le    float64 `json:"le"` // seconds
+	Count int64   `json:"count"`
+}
+
+// WaitHistogram spreads the time messages waited in a queue over the
+// WaitBuckets. Waits beyond the last bound only add to Count.
+type WaitHistogram struct {
+	Count   int64        `json:"count"`
+	Sum     float64      `json:"sum"` // seconds
+	Buckets []WaitBucket `json:"buckets"`
+}
+
+func NewWaitHistogram() *WaitHistogram {
+	h := &WaitHistogram{Buckets: make([]WaitBucket, len(WaitBuckets))}
+	for i, le := range WaitBuckets {
+		h.Buckets[i].Le = le
+	}
+	return h
+}
+
+// Observe adds a wait, negative ones counting as none
+func (h *WaitHistogram) Observe(wait time.Duration) {
+	seconds := max(wait.Seconds(), 0)
+	h.Count++
+	h.Sum += seconds
+	for i := range h.Buckets {
+		if seconds <= h.Buckets[i].Le {
+			h.Buckets[i].Count++
+		}
+	}
+}
+
+// Quantile estimates the wait under which the q fraction of the messages
+// were, as the bound of the bucket it falls in. It is +Inf past the last
+// bound and 0 without waits.
+func (h *WaitHistogram) Quantile(q float64) float64 {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.Count)))
+	for _, bucket := range h.Buckets {
+		if bucket.Count >= rank {
+			return bucket.Le
+		}
+	}
+	return math.Inf(1)
+}
+
+// Mean is the average wait in seconds
+func (h *WaitHistogram) Mean() float64 {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+func (h *WaitHistogram) Clone() *WaitHistogram {
+	if h == nil {
+		return nil
+	}
+	clone := *h
+	clone.Buckets = append([]WaitBucket(nil), h.Buckets...)
+	return &clone
+}
+
+// QueueWaits are the wait histograms of a queue: Delivery until the first
+// delivery of its messages, Ack until they are acknowledged by every
+// consumer group
+type QueueWaits struct {
+	Domain   string         `json:"domain"`
+	Queue    string         `json:"queue"`
+	Delivery *WaitHistogram `json:"delivery"`
+	Ack      *WaitHistogram `json:"ack"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitHistogram(t *testing.T) {
+	h := NewWaitHistogram()
+	assert.Zero(t, h.Quantile(0.5), "no waits")
+
+	for range 8 {
+		h.Observe(20 * time.Millisecond)
+	}
+	h.Observe(2 * time.Second)
+	h.Observe(-time.Second)
+
+	assert.Equal(t, int64(10), h.Count)
+	assert.InDelta(t, 2.16, h.Sum, 1e-9)
+	assert.InDelta(t, 0.216, h.Mean(), 1e-9)
+	assert.Equal(t, WaitBucket{Le: 0.005, Count: 1}, h.Buckets[0], "negative waits count as none")
+	assert.Equal(t, WaitBucket{Le: 0.05, Count: 9}, h.Buckets[2])
+	assert.Equal(t, WaitBucket{Le: 5, Count: 10}, h.Buckets[6], "cumulative")
+
+	assert.Equal(t, 0.05, h.Quantile(0.5))
+	assert.Equal(t, 0.05, h.Quantile(0.9))
+	assert.Equal(t, 5.0, h.Quantile(0.99))
+
+	h.Observe(2 * time.Hour)
+	assert.True(t, math.IsInf(h.Quantile(1), 1), "past the last bound")
+
+	clone := h.Clone()
+	clone.Observe(time.Millisecond)
+	assert.Equal(t, int64(1), h.Buckets[0].Count, "clones don't share buckets")
+}
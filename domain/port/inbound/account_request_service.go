@@ -11,6 +11,7 @@ type CreateAccountRequestOptions struct {
 	Username      string         `json:"username"`
 	Password      string         `json:"password"`
 	RequestedRole model.UserRole `json:"requestedRole"`
+	Email         string         `json:"email,omitempty"`
 }
 
 // ReviewAccountRequestOptions contains options for reviewing an account request
@@ -17,6 +17,7 @@ type AuthService interface {
 	BootstrapAdmin() (*model.User, string, error) // user, plainPassword, error
 	GenerateToken(user *model.User, issuedAt time.Time) (string, error)
 	UpdatePassword(user *model.User, old, new string) error
+	ResetPassword(userID, resetBy string) (*model.User, string, error) // user, temporary password unless emailed, error
 }
 
 type UpdateUserRequest struct {
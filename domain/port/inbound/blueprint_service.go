@@ -0,0 +1,18 @@
+package inbound
+
+import "github.com/ajkula/GoRTMS/domain/model"
+
+// BlueprintService keeps the queue blueprints, the ones of the
+// configuration file and the ones managed through the API
+type BlueprintService interface {
+	// ListBlueprints returns the blueprints sorted by name
+	ListBlueprints() []*model.QueueBlueprint
+
+	GetBlueprint(name string) (*model.QueueBlueprint, error)
+
+	// SaveBlueprint creates or replaces a blueprint, queues created from
+	// the previous version keep their config
+	SaveBlueprint(blueprint *model.QueueBlueprint) error
+
+	DeleteBlueprint(name string) error
+}
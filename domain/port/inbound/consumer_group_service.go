@@ -12,9 +12,24 @@ type ConsumerGroupService interface {
 	ListAllGroups(ctx context.Context) ([]*model.ConsumerGroup, error)
 	GetGroupDetails(ctx context.Context, domainName, queueName, groupID string) (*model.ConsumerGroup, error)
 	CreateConsumerGroup(ctx context.Context, domainName, queueName, groupID string, ttl time.Duration) error
+	// CreateEphemeralConsumerGroup creates a group deleted once idle for its TTL, DefaultEphemeralGroupTTL when 0
+	CreateEphemeralConsumerGroup(ctx context.Context, domainName, queueName, groupID string, ttl time.Duration) error
 	DeleteConsumerGroup(ctx context.Context, domainName, queueName, groupID string) error
 	UpdateConsumerGroupTTL(ctx context.Context, domainName, queueName, groupID string, ttl time.Duration) error
+	UpdateConsumerGroupDeliveryMode(ctx context.Context, domainName, queueName, groupID string, mode model.GroupDeliveryMode) error
+	UpdateConsumerGroupPriority(ctx context.Context, domainName, queueName, groupID string, priority int) error
+	UpdateConsumerGroupPrefetch(ctx context.Context, domainName, queueName, groupID string, prefetch int) error
+	UpdateConsumerGroupCleanupAction(ctx context.Context, domainName, queueName, groupID string, action model.GroupCleanupAction) error
+	// UpcomingCleanups lists the groups the stale group cleanup removes within the given time
+	UpcomingCleanups(ctx context.Context, within time.Duration) ([]model.ScheduledCleanup, error)
+	// ArchivedGroups lists the positions kept by archiving cleanups
+	ArchivedGroups(ctx context.Context) ([]*model.ArchivedGroup, error)
+	// ExportOffsets and ImportOffsets move the groups of a queue between instances
+	ExportOffsets(ctx context.Context, domainName, queueName string) (*model.GroupOffsets, error)
+	ImportOffsets(ctx context.Context, domainName, queueName string, offsets *model.GroupOffsets) ([]model.OffsetImportResult, error)
 	GetPendingMessages(ctx context.Context, domainName, queueName, groupID string) ([]*model.Message, error)
+	// Heartbeat signals the liveness of a group polling between deliveries
+	Heartbeat(ctx context.Context, domainName, queueName, groupID, consumerID string) error
 	// RegisterConsumer(...) error
 	// RemoveConsumer(...) error
 }
@@ -0,0 +1,20 @@
+package inbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// ErasureService purges the messages of a data subject across queues
+type ErasureService interface {
+	// StartErasure validates the request and runs the erasure in the
+	// background, the returned report is the job as started
+	StartErasure(ctx context.Context, request model.ErasureRequest) (*model.ErasureReport, error)
+
+	// GetErasure returns the report of a job, complete once its status leaves running
+	GetErasure(id string) (*model.ErasureReport, error)
+
+	// ListErasures returns the reports of the jobs run since startup, oldest first
+	ListErasures() []*model.ErasureReport
+}
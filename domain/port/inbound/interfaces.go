@@ -13,6 +13,7 @@ type ConsumeOptions struct {
 	ConsumerID  string
 	Timeout     time.Duration
 	MaxCount    int
+	ServiceID   string // service account consuming, counted in its usage
 }
 
 // MessageService defines operations for messages
@@ -48,6 +49,12 @@ type DomainService interface {
 
 	// ListDomains lists all domains
 	ListDomains(ctx context.Context) ([]*model.Domain, error)
+
+	// UpdateAllowedRouteSources sets the domains allowed to route into a domain
+	UpdateAllowedRouteSources(ctx context.Context, name string, sources []string) error
+
+	// UpdateDomainLabels replaces the labels of a domain
+	UpdateDomainLabels(ctx context.Context, name string, labels map[string]string) error
 }
 
 // QueueService defines operations for queues
@@ -58,6 +65,21 @@ type QueueService interface {
 	// GetQueue retrieves an existing queue
 	GetQueue(ctx context.Context, domainName, queueName string) (*model.Queue, error)
 
+	// UpdateQueueLabels replaces the labels of a queue
+	UpdateQueueLabels(ctx context.Context, domainName, queueName string, labels map[string]string) error
+
+	// UpdateQueueMirror replaces the shadow queue mirror of a queue, nil stops mirroring
+	UpdateQueueMirror(ctx context.Context, domainName, queueName string, mirror *model.MirrorConfig) error
+
+	// UpdateQueueEnrichment replaces the enrichment rules of a queue, nil stops enriching
+	UpdateQueueEnrichment(ctx context.Context, domainName, queueName string, rules []model.EnrichmentRule) error
+
+	// UpdateQueueMode makes a queue read-only, write-only or read-write again
+	UpdateQueueMode(ctx context.Context, domainName, queueName string, mode model.QueueMode) error
+
+	// UpdateQueueImplicitGroups allows or denies consumes through groups not created first
+	UpdateQueueImplicitGroups(ctx context.Context, domainName, queueName string, policy model.ImplicitGroupPolicy) error
+
 	// DeleteQueue deletes a queue
 	DeleteQueue(ctx context.Context, domainName, queueName string) error
 
@@ -84,4 +106,15 @@ type RoutingService interface {
 
 	// ListRoutingRules lists all routing rules for a domain
 	ListRoutingRules(ctx context.Context, domainName string) ([]*model.RoutingRule, error)
+
+	// UpdateRoutingRule enables, disables or reprioritizes a rule in place
+	UpdateRoutingRule(ctx context.Context, domainName, sourceQueue, destKey string, update model.RoutingRuleUpdate) (*model.RoutingRule, error)
+
+	// ReorderRoutingRules sets the evaluation order of the rules of a
+	// source queue, order listing every destination key first to last
+	ReorderRoutingRules(ctx context.Context, domainName, sourceQueue string, order []string) error
+
+	// GetRoutingRuleStats reports how many messages each rule of a domain
+	// routed, and the observed split of canary rules
+	GetRoutingRuleStats(ctx context.Context, domainName string) ([]model.RoutingRuleStats, error)
 }
@@ -0,0 +1,16 @@
+package inbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// ReconcilerService converges the broker to a set of declarative manifests
+type ReconcilerService interface {
+	// Reconcile compares the manifests with the broker, applying the drift unless dryRun is set
+	Reconcile(ctx context.Context, dryRun bool) (*model.ReconcileReport, error)
+
+	// LastReport returns the outcome of the last applied pass, nil if none ran yet
+	LastReport() *model.ReconcileReport
+}
@@ -21,7 +21,10 @@ type DomainResourceInfo struct {
 	QueueCount      int                          `json:"queueCount"`
 	MessageCount    int                          `json:"messageCount"`
 	QueueStats      map[string]QueueResourceInfo `json:"queueStats"`
-	EstimatedMemory int64                        `json:"estimatedMemory"` // rough estimate
+	EstimatedMemory int64                        `json:"estimatedMemory"` // rough estimate, search index included
+	// Message search index
+	IndexedMessages  int   `json:"indexedMessages,omitempty"`
+	SearchIndexBytes int64 `json:"searchIndexBytes,omitempty"` // rough estimate
 }
 
 // QueueResourceInfo holds stats per queue
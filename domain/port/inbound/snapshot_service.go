@@ -0,0 +1,17 @@
+package inbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// SnapshotService defines snapshot and restore operations for single queues
+type SnapshotService interface {
+	// SnapshotQueue builds an archive of all messages currently stored in a queue
+	SnapshotQueue(ctx context.Context, domainName, queueName string) (*model.QueueArchive, error)
+
+	// RestoreQueue loads an archive into the given queue,
+	// creating it from the archived config if it does not exist yet
+	RestoreQueue(ctx context.Context, domainName, queueName string, archive *model.QueueArchive) (*model.QueueRestoreResult, error)
+}
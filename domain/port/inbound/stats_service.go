@@ -2,6 +2,8 @@ package inbound
 
 import (
 	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
 )
 
 // StatsService defines operations for system statistics
@@ -18,6 +20,29 @@ type StatsService interface {
 	// GetStatsWithAggregation returns stats with time-based aggregation
 	GetStatsWithAggregation(ctx context.Context, period, granularity string) (any, error)
 
+	// GetStatsByLabel groups queue totals by the value of a label
+	GetStatsByLabel(ctx context.Context, labelKey string) (any, error)
+
+	// GetTopN returns the first entries of a triage ranking
+	// (slow-consumers, hot-queues or large-queues)
+	GetTopN(ctx context.Context, ranking string, limit int) (any, error)
+
+	// GetScalingHint recommends a consumer count for a queue, or for one of
+	// its consumer groups when groupID is set
+	GetScalingHint(ctx context.Context, domainName, queueName, groupID string, policy model.ScalingPolicy) (*model.ScalingHint, error)
+
+	// GetBacklogs returns the depth of every queue and the backlog of its
+	// consumer groups, as last collected
+	GetBacklogs(ctx context.Context) ([]model.QueueBacklog, error)
+
+	// GetQueueWaits returns, per queue, the histograms of the time messages
+	// waited for their first delivery and for the ack of every group
+	GetQueueWaits(ctx context.Context) ([]model.QueueWaits, error)
+
+	// GetDomainEvents pages through the system events of a domain recorded
+	// or updated after the since sequence
+	GetDomainEvents(ctx context.Context, domainName string, since uint64, limit int) (*model.EventPage, error)
+
 	// Specialized methods for different event types
 	RecordDomainCreated(name string)
 	RecordDomainDeleted(name string)
@@ -0,0 +1,13 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// ErrorReporter forwards unexpected failures to an error tracker
+type ErrorReporter interface {
+	// Report queues a failure for delivery, it never blocks the caller
+	Report(ctx context.Context, report model.ErrorReport)
+}
@@ -0,0 +1,9 @@
+package outbound
+
+import "github.com/ajkula/GoRTMS/domain/model"
+
+// HookPoster delivers the control events of queue hooks to webhooks
+type HookPoster interface {
+	// Post queues an event for delivery to url, it never blocks the caller
+	Post(url string, event model.QueueControlEvent)
+}
@@ -45,12 +45,14 @@ type MessageRepository interface {
 		domainName, queueName string,
 	)
 
-	// Cleanup old message index references
+	// Cleanup old message index references below minPosition, removing at
+	// most budget entries (no limit when budget <= 0); more reports leftovers
 	CleanupMessageIndices(
 		ctx context.Context,
 		domainName, queueName string,
 		minPosition int64,
-	)
+		budget int,
+	) (removed int, more bool)
 
 	// Get the number of messages in a queue
 	GetQueueMessageCount(domainName, queueName string) int
@@ -131,6 +133,42 @@ type ConsumerGroupRepository interface {
 	UpdateLastActivity(ctx context.Context, domainName, queueName, groupID string) error
 }
 
+// EventBus fans domain events out to independent subscribers
+type EventBus interface {
+	// Publish never blocks the caller, slow subscribers lose events instead
+	Publish(event model.DomainEvent)
+
+	// Subscribe registers a handler for the given kinds, all kinds when none given
+	Subscribe(name string, handler model.EventHandler, kinds ...model.EventKind) (unsubscribe func())
+
+	// Close stops every subscriber once its pending events are handled
+	Close()
+}
+
+// BlobStore keeps large payloads outside of the queues (claim-check)
+type BlobStore interface {
+	// Put stores data under a key, replacing any previous content
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get retrieves the data stored under a key
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes a key, missing keys are not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// ClusterPlacement tells which node owns each queue
+type ClusterPlacement interface {
+	// Self returns the node running this process
+	Self() model.ClusterNode
+
+	// Nodes returns the nodes of the cluster, Self included
+	Nodes() []model.ClusterNode
+
+	// Owner returns the node holding a queue
+	Owner(domainName, queueName string) model.ClusterNode
+}
+
 // machine uuid
 type MachineIDService interface {
 	GetMachineID() (string, error)
@@ -0,0 +1,14 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// ManifestSource loads the desired state of the broker
+type ManifestSource interface {
+	// Load reads every manifest, failing on any invalid one so that a
+	// half-read source never looks like deleted resources
+	Load(ctx context.Context) (*model.DesiredState, error)
+}
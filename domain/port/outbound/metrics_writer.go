@@ -0,0 +1,12 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// MetricsWriter pushes metric samples to a metrics backend
+type MetricsWriter interface {
+	Write(ctx context.Context, samples []model.MetricSample) error
+}
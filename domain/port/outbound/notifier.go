@@ -0,0 +1,17 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// Notifier delivers notifications to people, by email
+type Notifier interface {
+	// Enabled reports whether notifications of the type are sent
+	Enabled(kind model.NotificationType) bool
+
+	// Notify queues a notification for delivery, disabled types are
+	// dropped without error
+	Notify(ctx context.Context, notification model.Notification) error
+}
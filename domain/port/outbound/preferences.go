@@ -0,0 +1,19 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// defines storage operations for user preferences
+type PreferencesRepository interface {
+	// retrieves the preferences of a user
+	Get(ctx context.Context, username string) (*model.UserPreferences, error)
+
+	// saves the preferences of a user, replacing the previous ones
+	Store(ctx context.Context, preferences *model.UserPreferences) error
+
+	// removes the preferences of a user
+	Delete(ctx context.Context, username string) error
+}
@@ -0,0 +1,27 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// MessageReader is the read side of a message storage, a MessageRepository
+// or a read replica of it. Indexes are the ones of the primary storage.
+type MessageReader interface {
+	GetMessage(ctx context.Context, domainName, queueName, messageID string) (*model.Message, error)
+	GetMessagesAfterIndex(ctx context.Context, domainName, queueName string, startIndex int64, limit int) ([]*model.Message, error)
+	GetQueueMessageCount(domainName, queueName string) int
+}
+
+// ReadReplicas keeps local copies of storage queues, updated asynchronously,
+// for the scans that shouldn't contend with the publish and consume paths
+type ReadReplicas interface {
+	// ReplicaReader returns the replica of a storage queue, which starts
+	// being copied on first use; ok is false until the copy has caught up
+	ReplicaReader(domainName, queueName string) (reader MessageReader, ok bool)
+
+	// DropReplicas forgets the replicas of a queue and its partitions, or
+	// of every queue of the domain when queueName is empty
+	DropReplicas(domainName, queueName string)
+}
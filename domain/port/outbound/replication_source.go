@@ -0,0 +1,18 @@
+package outbound
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// ReplicationSource reads the metadata and the messages of a primary, for
+// its standby
+type ReplicationSource interface {
+	// Domains returns the domains of the primary and their queues
+	Domains(ctx context.Context) ([]model.ReplicatedDomain, error)
+
+	// Messages returns the messages stored in a queue (a partition for
+	// partitioned queues) from index after on, at most limit of them
+	Messages(ctx context.Context, domainName, storageQueue string, after int64, limit int) (*model.ReplicationBatch, error)
+}
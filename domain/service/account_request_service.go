@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"net/mail"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,7 @@ type accountRequestService struct {
 	crypto         outbound.CryptoService
 	messageService inbound.MessageService
 	authService    inbound.AuthService
+	notifier       outbound.Notifier // optional
 	logger         outbound.Logger
 }
 
@@ -28,6 +30,7 @@ func NewAccountRequestService(
 	crypto outbound.CryptoService,
 	messageService inbound.MessageService,
 	authService inbound.AuthService,
+	notifier outbound.Notifier,
 	logger outbound.Logger,
 ) inbound.AccountRequestService {
 	return &accountRequestService{
@@ -36,6 +39,7 @@ func NewAccountRequestService(
 		crypto:         crypto,
 		messageService: messageService,
 		authService:    authService,
+		notifier:       notifier,
 		logger:         logger,
 	}
 }
@@ -53,6 +57,11 @@ func (s *accountRequestService) CreateAccountRequest(ctx context.Context, option
 	if options.RequestedRole != model.RoleUser && options.RequestedRole != model.RoleAdmin {
 		return nil, model.ErrInvalidRequestedRole
 	}
+	if options.Email != "" {
+		if address, err := mail.ParseAddress(options.Email); err != nil || address.Name != "" {
+			return nil, model.ErrInvalidEmail
+		}
+	}
 
 	// check username availability
 	if err := s.CheckUsernameAvailability(ctx, options.Username); err != nil {
@@ -75,6 +84,7 @@ func (s *accountRequestService) CreateAccountRequest(ctx context.Context, option
 		CreatedAt:     time.Now(),
 		PasswordHash:  passwordHash,
 		Salt:          salt,
+		Email:         options.Email,
 	}
 
 	if err := s.repo.Store(ctx, request); err != nil {
@@ -88,6 +98,15 @@ func (s *accountRequestService) CreateAccountRequest(ctx context.Context, option
 		// noop
 	}
 
+	s.notify(ctx, model.Notification{
+		Type: model.NotificationAccountRequest,
+		Data: map[string]any{
+			"RequestID": request.ID,
+			"Username":  request.Username,
+			"Role":      request.RequestedRole,
+		},
+	})
+
 	s.logger.Info("Account request created successfully", "requestID", request.ID, "username", request.Username)
 	return request, nil
 }
@@ -147,9 +166,36 @@ func (s *accountRequestService) ReviewAccountRequest(ctx context.Context, reques
 		return nil, err
 	}
 
+	if request.Email != "" {
+		data := map[string]any{
+			"Username":   request.Username,
+			"Status":     request.Status,
+			"Reason":     request.RejectReason,
+			"ReviewedBy": request.ReviewedBy,
+		}
+		if request.ApprovedRole != nil {
+			data["Role"] = *request.ApprovedRole
+		}
+		s.notify(ctx, model.Notification{
+			Type: model.NotificationAccountReviewed,
+			To:   []string{request.Email},
+			Data: data,
+		})
+	}
+
 	return request, nil
 }
 
+// notify hands a notification to the notifier, when one is configured
+func (s *accountRequestService) notify(ctx context.Context, notification model.Notification) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(ctx, notification); err != nil {
+		s.logger.Warn("Notification not sent", "type", notification.Type, "error", err)
+	}
+}
+
 func (s *accountRequestService) DeleteAccountRequest(ctx context.Context, requestID string) error {
 	s.logger.Info("Deleting account request", "requestID", requestID)
 	return s.repo.Delete(ctx, requestID)
@@ -259,6 +305,7 @@ func (s *accountRequestService) createUserFromRequest(ctx context.Context, reque
 		}
 	}
 
+	user.Email = request.Email
 	db.Users[user.Username] = user
 	return s.userRepo.Save(db)
 }
@@ -165,6 +165,10 @@ func (m *mockAuthService) BootstrapAdmin() (*model.User, string, error) {
 	return nil, "", nil
 }
 
+func (m *mockAuthService) ResetPassword(userID, resetBy string) (*model.User, string, error) {
+	return nil, "", nil
+}
+
 func (m *mockAuthService) GenerateToken(user *model.User, issuedAt time.Time) (string, error) {
 	return "token", nil
 }
@@ -408,6 +412,76 @@ func TestAccountRequestService_ReviewAccountRequest(t *testing.T) {
 	})
 }
 
+// recordingNotifier keeps the notifications instead of sending them
+type recordingNotifier struct {
+	disabled      map[model.NotificationType]bool
+	notifications []model.Notification
+}
+
+func (n *recordingNotifier) Enabled(kind model.NotificationType) bool {
+	return !n.disabled[kind]
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	if n.Enabled(notification.Type) {
+		n.notifications = append(n.notifications, notification)
+	}
+	return nil
+}
+
+func TestAccountRequestService_Notifications(t *testing.T) {
+	service := createTestService()
+	notifier := &recordingNotifier{}
+	service.notifier = notifier
+	ctx := context.Background()
+
+	_, err := service.CreateAccountRequest(ctx, &inbound.CreateAccountRequestOptions{
+		Username:      "mallory",
+		Password:      "password123",
+		RequestedRole: model.RoleUser,
+		Email:         "not an address",
+	})
+	if err != model.ErrInvalidEmail {
+		t.Fatalf("Expected %v, got %v", model.ErrInvalidEmail, err)
+	}
+
+	request, err := service.CreateAccountRequest(ctx, &inbound.CreateAccountRequestOptions{
+		Username:      "alice",
+		Password:      "password123",
+		RequestedRole: model.RoleAdmin,
+		Email:         "alice@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test request: %v", err)
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].Type != model.NotificationAccountRequest {
+		t.Fatalf("Expected the administrators to be notified, got %+v", notifier.notifications)
+	}
+	if len(notifier.notifications[0].To) != 0 {
+		t.Errorf("Expected the configured recipients only, got %v", notifier.notifications[0].To)
+	}
+
+	_, err = service.ReviewAccountRequest(ctx, request.ID, &inbound.ReviewAccountRequestOptions{
+		Approve:      false,
+		RejectReason: "Use a user account",
+		ReviewedBy:   "admin",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error during review, got %v", err)
+	}
+
+	reviewed := notifier.notifications[len(notifier.notifications)-1]
+	if reviewed.Type != model.NotificationAccountReviewed {
+		t.Fatalf("Expected the requester to be notified, got %s", reviewed.Type)
+	}
+	if len(reviewed.To) != 1 || reviewed.To[0] != "alice@example.com" {
+		t.Errorf("Expected the notification to go to the requester, got %v", reviewed.To)
+	}
+	if reviewed.Data["Status"] != model.AccountRequestRejected || reviewed.Data["Reason"] != "Use a user account" {
+		t.Errorf("Unexpected notification data: %v", reviewed.Data)
+	}
+}
+
 func TestAccountRequestService_CheckUsernameAvailability(t *testing.T) {
 	t.Run("available username", func(t *testing.T) {
 		service := createTestService()
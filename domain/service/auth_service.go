@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -36,6 +37,7 @@ type authService struct {
 	jwtSecret    string
 	jwtExpiry    time.Duration
 	userDatabase *model.UserDatabase
+	notifier     outbound.Notifier // optional
 }
 
 func NewAuthService(
@@ -44,6 +46,7 @@ func NewAuthService(
 	logger outbound.Logger,
 	jwtSecret string,
 	jwtExpiryMinutes int,
+	notifier outbound.Notifier,
 ) inbound.AuthService {
 	return &authService{
 		userRepo:  userRepo,
@@ -51,6 +54,7 @@ func NewAuthService(
 		logger:    logger,
 		jwtSecret: jwtSecret,
 		jwtExpiry: time.Duration(jwtExpiryMinutes) * time.Minute,
+		notifier:  notifier,
 	}
 }
 
@@ -98,6 +102,53 @@ func (s *authService) UpdatePassword(user *model.User, old, new string) error {
 	return nil
 }
 
+// ResetPassword replaces the password of a user with a temporary one and
+// revokes its tokens. The temporary password is emailed when the user has
+// an address and password-reset notifications are enabled, otherwise it is
+// returned for the administrator to hand over.
+func (s *authService) ResetPassword(userID, resetBy string) (*model.User, string, error) {
+	if err := s.loadDatabase(); err != nil {
+		return nil, "", err
+	}
+
+	var user *model.User
+	for _, u := range s.userDatabase.Users {
+		if u.ID == userID {
+			user = u
+			break
+		}
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	password := s.generateSecurePassword()
+	user.PasswordHash = s.crypto.HashPassword(password, user.Salt)
+	user.LastValidLogin = time.Now().Truncate(time.Second)
+	if err := s.saveDatabase(); err != nil {
+		return nil, "", err
+	}
+	s.logger.Info("Password reset", "username", user.Username, "resetBy", resetBy)
+
+	if user.Email == "" || s.notifier == nil || !s.notifier.Enabled(model.NotificationPasswordReset) {
+		return user, password, nil
+	}
+	err := s.notifier.Notify(context.Background(), model.Notification{
+		Type: model.NotificationPasswordReset,
+		To:   []string{user.Email},
+		Data: map[string]any{
+			"Username": user.Username,
+			"Password": password,
+			"ResetBy":  resetBy,
+		},
+	})
+	if err != nil {
+		s.logger.Warn("Password reset not notified", "username", user.Username, "error", err)
+		return user, password, nil
+	}
+	return user, "", nil
+}
+
 func (s *authService) ValidateToken(tokenString string) (*model.User, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
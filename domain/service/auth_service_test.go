@@ -413,3 +413,41 @@ func TestAuthService_LastValidLogin_Migration(t *testing.T) {
 	assert.False(t, testDB.Users["testuser"].LastValidLogin.IsZero())
 	assert.Equal(t, testDB.Users["testuser"].CreatedAt, testDB.Users["testuser"].LastValidLogin)
 }
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	service, userRepo, crypto, logger := setupAuthService()
+	testDB := createTestDatabase()
+	issuedAt := testDB.Users["testuser"].LastValidLogin
+
+	userRepo.On("Load").Return(testDB, nil)
+	userRepo.On("Save", mock.Anything).Return(nil)
+	crypto.On("HashPassword", mock.Anything, mock.Anything).Return("temporary-hash")
+	logger.On("Info", mock.Anything, mock.Anything).Return()
+
+	// without an email the administrator hands the password over
+	user, password, err := service.ResetPassword("test-id", "admin")
+	assert.NoError(t, err)
+	assert.Len(t, password, 16)
+	assert.Equal(t, "temporary-hash", user.PasswordHash)
+	assert.False(t, user.LastValidLogin.Before(issuedAt.Truncate(time.Second)), "older tokens are revoked")
+
+	notifier := &recordingNotifier{}
+	service.notifier = notifier
+	user.Email = "testuser@example.com"
+
+	_, password, err = service.ResetPassword("test-id", "admin")
+	assert.NoError(t, err)
+	assert.Empty(t, password, "the password is only emailed")
+	assert.Len(t, notifier.notifications, 1)
+	assert.Equal(t, model.NotificationPasswordReset, notifier.notifications[0].Type)
+	assert.Equal(t, []string{"testuser@example.com"}, notifier.notifications[0].To)
+	assert.Len(t, notifier.notifications[0].Data["Password"], 16)
+
+	notifier.disabled = map[model.NotificationType]bool{model.NotificationPasswordReset: true}
+	_, password, err = service.ResetPassword("test-id", "admin")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, password)
+
+	_, _, err = service.ResetPassword("unknown", "admin")
+	assert.Equal(t, ErrUserNotFound, err)
+}
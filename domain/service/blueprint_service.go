@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+var _ inbound.BlueprintService = (*BlueprintServiceImpl)(nil)
+
+// BlueprintServiceImpl keeps the blueprints in memory: the ones of the
+// configuration file are loaded at startup, the ones of the API last until
+// the server stops
+type BlueprintServiceImpl struct {
+	logger outbound.Logger
+
+	mu         sync.RWMutex
+	blueprints map[string]*model.QueueBlueprint
+}
+
+// NewBlueprintService loads the blueprints of the configuration file,
+// already validated along with it
+func NewBlueprintService(logger outbound.Logger, defined []model.QueueBlueprint) *BlueprintServiceImpl {
+	s := &BlueprintServiceImpl{
+		logger:     logger,
+		blueprints: make(map[string]*model.QueueBlueprint, len(defined)),
+	}
+	now := time.Now()
+	for _, blueprint := range defined {
+		blueprint.FromConfig = true
+		blueprint.UpdatedAt = now
+		s.blueprints[blueprint.Name] = &blueprint
+	}
+	return s
+}
+
+func (s *BlueprintServiceImpl) ListBlueprints() []*model.QueueBlueprint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blueprints := make([]*model.QueueBlueprint, 0, len(s.blueprints))
+	for _, blueprint := range s.blueprints {
+		blueprints = append(blueprints, blueprint)
+	}
+	slices.SortFunc(blueprints, func(a, b *model.QueueBlueprint) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return blueprints
+}
+
+func (s *BlueprintServiceImpl) GetBlueprint(name string) (*model.QueueBlueprint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blueprint, ok := s.blueprints[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", model.ErrBlueprintNotFound, name)
+	}
+	return blueprint, nil
+}
+
+// SaveBlueprint stores a copy of the blueprint, the stored ones are never
+// modified so the ones handed out can be read without locking
+func (s *BlueprintServiceImpl) SaveBlueprint(blueprint *model.QueueBlueprint) error {
+	if err := blueprint.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.blueprints[blueprint.Name]; ok && existing.FromConfig {
+		return fmt.Errorf("%w: %s", model.ErrBlueprintReadOnly, blueprint.Name)
+	}
+	stored := &model.QueueBlueprint{
+		Name:        blueprint.Name,
+		Description: blueprint.Description,
+		Config:      blueprint.QueueConfig(),
+		UpdatedAt:   time.Now(),
+	}
+	s.blueprints[stored.Name] = stored
+	s.logger.Info("Queue blueprint saved", "blueprint", stored.Name)
+	return nil
+}
+
+func (s *BlueprintServiceImpl) DeleteBlueprint(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blueprint, ok := s.blueprints[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", model.ErrBlueprintNotFound, name)
+	}
+	if blueprint.FromConfig {
+		return fmt.Errorf("%w: %s", model.ErrBlueprintReadOnly, name)
+	}
+	delete(s.blueprints, name)
+	s.logger.Info("Queue blueprint deleted", "blueprint", name)
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopSubscriptions struct{}
+
+func (noopSubscriptions) RegisterSubscription(string, string, model.MessageHandler) (string, error) {
+	return "", nil
+}
+func (noopSubscriptions) UnregisterSubscription(string) error                    { return nil }
+func (noopSubscriptions) NotifySubscribers(string, string, *model.Message) error { return nil }
+
+type recordingBus struct {
+	events []model.DomainEvent
+}
+
+func (b *recordingBus) Publish(event model.DomainEvent) { b.events = append(b.events, event) }
+func (b *recordingBus) Subscribe(string, model.EventHandler, ...model.EventKind) func() {
+	return func() {}
+}
+func (b *recordingBus) Close() {}
+
+// newBareMessageService builds a message service without its background tasks
+func newBareMessageService(
+	ctx context.Context,
+	domainRepo *mockDomainRepository,
+	messageRepo *mockMessageRepository,
+	queueService inbound.QueueService,
+	bus *recordingBus,
+) *MessageServiceImpl {
+	return &MessageServiceImpl{
+		eventEmitter:     eventEmitter{eventBus: bus},
+		rootCtx:          ctx,
+		logger:           &mockLogger{},
+		domainRepo:       domainRepo,
+		messageRepo:      messageRepo,
+		subscriptionReg:  noopSubscriptions{},
+		queueService:     queueService,
+		faults:           newFaultInjector(),
+		producerSessions: model.NewProducerSessions(model.ProducerSessionWindow, model.ProducerSessionTTL),
+		schemaRejections: model.NewSchemaRejections(model.DefaultRejectionSampleSize),
+	}
+}
+
+func TestPublishDivertsToFallbackQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop", Config: model.QueueConfig{
+				CircuitBreakerConfig: &model.CircuitBreakerConfig{FallbackQueue: "orders-fallback"},
+			}},
+			"orders-fallback": {Name: "orders-fallback", DomainName: "shop"},
+			"payments":        {Name: "payments", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, bus)
+
+	for _, queueName := range []string{"orders", "payments"} {
+		require.NoError(t, svc.InjectFault(ctx, model.QueueFault{Domain: "shop", Queue: queueName, CircuitOpen: true}))
+	}
+
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "1", Payload: []byte(`{"total":10}`)}))
+	assert.Empty(t, messageRepo.messages["shop:orders"])
+	diverted := messageRepo.messages["shop:orders-fallback"]
+	require.Len(t, diverted, 1)
+	assert.Equal(t, "orders", diverted[0].Metadata[model.MetadataDivertedFrom])
+
+	require.NotEmpty(t, bus.events)
+	last := bus.events[len(bus.events)-1]
+	assert.Equal(t, model.EventMessageDiverted, last.Kind)
+	assert.Equal(t, "orders", last.Queue)
+	assert.Equal(t, "orders-fallback", last.Data["fallback"])
+
+	// without fallback the open circuit still refuses the publish
+	err := svc.PublishMessage("shop", "payments", &model.Message{ID: "2", Payload: []byte(`{"total":20}`)})
+	assert.ErrorIs(t, err, model.ErrInjectedCircuitOpen)
+}
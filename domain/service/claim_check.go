@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+const (
+	claimCheckKey     = "claimCheck"
+	claimCheckSizeKey = model.ClaimCheckSizeKey
+)
+
+// claimCheck moves payloads above a threshold out of the message store,
+// messages keep only a reference until they are handed to a consumer
+type claimCheck struct {
+	store     outbound.BlobStore
+	threshold int
+}
+
+func claimCheckBlobKey(domainName, queueName, messageID string) string {
+	return domainName + "/" + queueName + "/" + messageID
+}
+
+// offload returns the message to store: the original one when small enough,
+// otherwise a copy without payload pointing to the blob
+func (c *claimCheck) offload(ctx context.Context, domainName, queueName string, message *model.Message) (*model.Message, error) {
+	if c == nil || len(message.Payload) <= c.threshold {
+		return message, nil
+	}
+
+	key := claimCheckBlobKey(domainName, queueName, message.ID)
+	if err := c.store.Put(ctx, key, message.Payload); err != nil {
+		return nil, fmt.Errorf("failed to store large payload: %w", err)
+	}
+
+	stripped := *message
+	stripped.Payload = nil
+	stripped.Metadata = maps.Clone(message.Metadata)
+	stripped.Metadata[claimCheckKey] = key
+	stripped.Metadata[claimCheckSizeKey] = len(message.Payload)
+
+	return &stripped, nil
+}
+
+// resolve returns a copy carrying the payload back from the blob store
+func (c *claimCheck) resolve(ctx context.Context, message *model.Message) (*model.Message, error) {
+	if c == nil || message == nil {
+		return message, nil
+	}
+
+	key, ok := message.Metadata[claimCheckKey].(string)
+	if !ok {
+		return message, nil
+	}
+
+	payload, err := c.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load large payload: %w", err)
+	}
+
+	resolved := *message
+	resolved.Payload = payload
+	return &resolved, nil
+}
+
+// release drops the blob once the message is gone from its queue
+func (c *claimCheck) release(ctx context.Context, message *model.Message) error {
+	if c == nil || message == nil {
+		return nil
+	}
+
+	key, ok := message.Metadata[claimCheckKey].(string)
+	if !ok {
+		return nil
+	}
+	return c.store.Delete(ctx, key)
+}
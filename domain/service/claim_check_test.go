@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBlobStore struct {
+	blobs map[string][]byte
+}
+
+func (m *mockBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *mockBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return data, nil
+}
+
+func (m *mockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
+
+func TestClaimCheck(t *testing.T) {
+	ctx := context.Background()
+	store := &mockBlobStore{blobs: make(map[string][]byte)}
+	cc := &claimCheck{store: store, threshold: 8}
+
+	t.Run("small payload stays inline", func(t *testing.T) {
+		msg := &model.Message{ID: "small", Payload: []byte("tiny")}
+
+		stored, err := cc.offload(ctx, "orders", "incoming", msg)
+		require.NoError(t, err)
+		assert.Same(t, msg, stored)
+		assert.Empty(t, store.blobs)
+	})
+
+	t.Run("large payload round trip", func(t *testing.T) {
+		msg := &model.Message{
+			ID:       "large",
+			Payload:  []byte(`{"data":"well above threshold"}`),
+			Metadata: map[string]any{"domain": "orders"},
+		}
+
+		stored, err := cc.offload(ctx, "orders", "incoming", msg)
+		require.NoError(t, err)
+		assert.Nil(t, stored.Payload)
+		assert.Equal(t, "orders/incoming/large", stored.Metadata[claimCheckKey])
+		assert.Equal(t, len(msg.Payload), stored.PayloadSize(), "sizes count the offloaded payload")
+		assert.NotContains(t, msg.Metadata, claimCheckKey, "original message must stay untouched")
+		assert.NotNil(t, msg.Payload)
+
+		resolved, err := cc.resolve(ctx, stored)
+		require.NoError(t, err)
+		assert.Equal(t, msg.Payload, resolved.Payload)
+		assert.Nil(t, stored.Payload, "stored message must stay stripped")
+
+		require.NoError(t, cc.release(ctx, stored))
+		assert.Empty(t, store.blobs)
+	})
+
+	t.Run("disabled claim check is a no-op", func(t *testing.T) {
+		var disabled *claimCheck
+		msg := &model.Message{ID: "any", Payload: []byte("whatever size it has")}
+
+		stored, err := disabled.offload(ctx, "orders", "incoming", msg)
+		require.NoError(t, err)
+		assert.Same(t, msg, stored)
+
+		resolved, err := disabled.resolve(ctx, msg)
+		require.NoError(t, err)
+		assert.Same(t, msg, resolved)
+		assert.NoError(t, disabled.release(ctx, msg))
+	})
+}
+
+// orderedBlobStore logs the blob operations in the order they happen. Reads
+// are slow, leaving time to whatever runs concurrently to delete the blob.
+type orderedBlobStore struct {
+	mockBlobStore
+	mu  sync.Mutex
+	ops []string
+}
+
+func (s *orderedBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mockBlobStore.Put(ctx, key, data)
+}
+
+func (s *orderedBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	time.Sleep(50 * time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, "get")
+	return s.mockBlobStore.Get(ctx, key)
+}
+
+func (s *orderedBlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, "delete")
+	return s.mockBlobStore.Delete(ctx, key)
+}
+
+func (s *orderedBlobStore) operations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ops)
+}
+
+// positionGroupStore starts every group at the head of the queue
+type positionGroupStore struct {
+	groupStore
+}
+
+func (r *positionGroupStore) GetPosition(ctx context.Context, domainName, queueName, groupID string) (int64, error) {
+	return 0, nil
+}
+
+func (r *positionGroupStore) UpdateLastActivity(ctx context.Context, domainName, queueName, groupID string) error {
+	return nil
+}
+
+func TestConsumeResolvesLargePayloadBeforeRelease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the queue is stored once the queue service reads messages through svc
+	domainRepo := &mockDomainRepository{}
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	messageRepo := &mockMessageRepository{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+	queueService.(*QueueServiceImpl).SetMessageService(svc)
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "shop",
+		Queues: map[string]*model.Queue{"orders": {Name: "orders", DomainName: "shop"}},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	store := &orderedBlobStore{mockBlobStore: mockBlobStore{blobs: make(map[string][]byte)}}
+	svc.SetBlobStore(store, 8)
+	groups := &positionGroupStore{groupStore{groups: map[string]*model.ConsumerGroup{}}}
+	require.NoError(t, groups.RegisterConsumer(ctx, "shop", "orders", "billing", ""))
+	messageRepo.GetOrCreateAckMatrix("shop", "orders").RegisterGroup("billing")
+	svc.consumerGroupRepo = groups
+	svc.compactor = newIndexCompactor(&mockLogger{}, svc.messageRepo, groups)
+
+	payload := []byte(`{"data":"well above threshold"}`)
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "large", Payload: payload}))
+
+	// the only group acknowledges the message in full, which drops its blob
+	message, err := svc.ConsumeMessageWithGroup(ctx, "shop", "orders", "billing", &inbound.ConsumeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, message)
+	assert.Equal(t, payload, message.Payload)
+
+	assert.Eventually(t, func() bool {
+		return slices.Contains(store.operations(), "delete")
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"get", "delete"}, store.operations())
+}
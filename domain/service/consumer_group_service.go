@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -20,6 +23,9 @@ type ConsumerGroupServiceImpl struct {
 	logger            outbound.Logger
 	consumerGroupRepo outbound.ConsumerGroupRepository
 	messageRepo       outbound.MessageRepository
+
+	policyMu      sync.RWMutex
+	cleanupPolicy model.GroupCleanupPolicy
 }
 
 func NewConsumerGroupService(
@@ -33,6 +39,7 @@ func NewConsumerGroupService(
 		logger:            logger,
 		consumerGroupRepo: consumerGroupRepo,
 		messageRepo:       messageRepo,
+		cleanupPolicy:     model.GroupCleanupPolicy{}.WithDefaults(),
 	}
 
 	// Start the clean interval task
@@ -87,29 +94,55 @@ func (s *ConsumerGroupServiceImpl) CreateConsumerGroup(
 		return err
 	}
 
-	// Set TTL if provided
+	// Set TTL if provided, group details are copies
 	if ttl > 0 {
-		if repo, ok := s.consumerGroupRepo.(interface {
-			GetGroupDetails(
-				ctx context.Context,
-				domainName, queueName, groupID string,
-			) (*model.ConsumerGroup, error)
-		}); ok {
-			group, err := repo.GetGroupDetails(ctx, domainName, queueName, groupID)
-			if err != nil {
-				return err
-			}
-			group.SetTTL(ttl)
-		}
+		return s.consumerGroupRepo.SetGroupTTL(ctx, domainName, queueName, groupID, ttl)
 	}
 
 	return nil
 }
 
+// CreateEphemeralConsumerGroup creates a group the cleanup deletes once idle
+// for its TTL, or DefaultEphemeralGroupTTL, never archiving its position
+func (s *ConsumerGroupServiceImpl) CreateEphemeralConsumerGroup(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	ttl time.Duration,
+) error {
+	repo, ok := s.consumerGroupRepo.(interface {
+		SetGroupEphemeral(ctx context.Context, domainName, queueName, groupID string) error
+	})
+	if !ok {
+		return errors.New("ephemeral groups not supported by consumer group repository")
+	}
+
+	if err := s.CreateConsumerGroup(ctx, domainName, queueName, groupID, ttl); err != nil {
+		return err
+	}
+	return repo.SetGroupEphemeral(ctx, domainName, queueName, groupID)
+}
+
 func (s *ConsumerGroupServiceImpl) DeleteConsumerGroup(
 	ctx context.Context,
 	domainName, queueName, groupID string,
 ) error {
+	// the group keeps a position per partition of a partitioned queue
+	if _, _, isPartition := model.ParsePartitionQueueName(queueName); !isPartition {
+		for partition := range model.MaxPartitions {
+			partitionQueue := model.PartitionQueueName(queueName, partition)
+			groups, err := s.consumerGroupRepo.ListGroups(ctx, domainName, partitionQueue)
+			if err != nil || !slices.Contains(groups, groupID) {
+				continue
+			}
+			if err := s.DeleteConsumerGroup(ctx, domainName, partitionQueue, groupID); err != nil {
+				s.logger.Warn("Partition group not deleted",
+					"queue", partitionQueue,
+					"group", groupID,
+					"ERROR", err)
+			}
+		}
+	}
+
 	// Clean AckMatrix
 	ackMatrix := s.messageRepo.GetOrCreateAckMatrix(domainName, queueName)
 	if ackMatrix != nil {
@@ -134,6 +167,123 @@ func (s *ConsumerGroupServiceImpl) UpdateConsumerGroupTTL(
 	return s.consumerGroupRepo.SetGroupTTL(ctx, domainName, queueName, groupID, ttl)
 }
 
+func (s *ConsumerGroupServiceImpl) UpdateConsumerGroupDeliveryMode(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	mode model.GroupDeliveryMode,
+) error {
+	if repo, ok := s.consumerGroupRepo.(interface {
+		SetGroupDeliveryMode(ctx context.Context, domainName, queueName, groupID string, mode model.GroupDeliveryMode) error
+	}); ok {
+		return repo.SetGroupDeliveryMode(ctx, domainName, queueName, groupID, mode)
+	}
+
+	return errors.New("delivery modes not supported by consumer group repository")
+}
+
+func (s *ConsumerGroupServiceImpl) UpdateConsumerGroupPriority(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	priority int,
+) error {
+	if repo, ok := s.consumerGroupRepo.(interface {
+		SetGroupPriority(ctx context.Context, domainName, queueName, groupID string, priority int) error
+	}); ok {
+		return repo.SetGroupPriority(ctx, domainName, queueName, groupID, priority)
+	}
+
+	return errors.New("priorities not supported by consumer group repository")
+}
+
+func (s *ConsumerGroupServiceImpl) UpdateConsumerGroupPrefetch(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	prefetch int,
+) error {
+	if prefetch < 0 {
+		return model.ErrInvalidPrefetch
+	}
+
+	if repo, ok := s.consumerGroupRepo.(interface {
+		SetGroupPrefetch(ctx context.Context, domainName, queueName, groupID string, prefetch int) error
+	}); ok {
+		return repo.SetGroupPrefetch(ctx, domainName, queueName, groupID, prefetch)
+	}
+
+	return errors.New("prefetch not supported by consumer group repository")
+}
+
+func (s *ConsumerGroupServiceImpl) UpdateConsumerGroupCleanupAction(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	action model.GroupCleanupAction,
+) error {
+	if _, err := model.ParseGroupCleanupAction(string(action)); err != nil {
+		return err
+	}
+
+	if repo, ok := s.consumerGroupRepo.(interface {
+		SetGroupCleanupAction(ctx context.Context, domainName, queueName, groupID string, action model.GroupCleanupAction) error
+	}); ok {
+		return repo.SetGroupCleanupAction(ctx, domainName, queueName, groupID, action)
+	}
+
+	return errors.New("cleanup actions not supported by consumer group repository")
+}
+
+// SetCleanupPolicy replaces the stale group cleanup policy, taking effect
+// from the next cleanup
+func (s *ConsumerGroupServiceImpl) SetCleanupPolicy(policy model.GroupCleanupPolicy) {
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+	s.cleanupPolicy = policy.WithDefaults()
+}
+
+// CleanupPolicy returns the stale group cleanup policy
+func (s *ConsumerGroupServiceImpl) CleanupPolicy() model.GroupCleanupPolicy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.cleanupPolicy
+}
+
+// UpcomingCleanups lists the groups the cleanup removes within the given
+// time, soonest first, so their owners can send a heartbeat or raise the TTL
+func (s *ConsumerGroupServiceImpl) UpcomingCleanups(ctx context.Context, within time.Duration) ([]model.ScheduledCleanup, error) {
+	if within <= 0 {
+		within = model.DefaultCleanupLookahead
+	}
+
+	groups, err := s.ListAllGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := s.CleanupPolicy()
+	horizon := time.Now().Add(within)
+	cleanups := make([]model.ScheduledCleanup, 0)
+	for _, group := range groups {
+		if schedule := group.CleanupSchedule(policy); !schedule.CleanupAt.After(horizon) {
+			cleanups = append(cleanups, schedule)
+		}
+	}
+	sort.Slice(cleanups, func(i, j int) bool {
+		return cleanups[i].CleanupAt.Before(cleanups[j].CleanupAt)
+	})
+
+	return cleanups, nil
+}
+
+// ArchivedGroups lists the positions kept by archiving cleanups
+func (s *ConsumerGroupServiceImpl) ArchivedGroups(ctx context.Context) ([]*model.ArchivedGroup, error) {
+	if repo, ok := s.consumerGroupRepo.(interface {
+		ArchivedGroups(ctx context.Context) ([]*model.ArchivedGroup, error)
+	}); ok {
+		return repo.ArchivedGroups(ctx)
+	}
+
+	return []*model.ArchivedGroup{}, nil
+}
+
 func (s *ConsumerGroupServiceImpl) CleanupStaleGroups(
 	ctx context.Context,
 	olderThan time.Duration,
@@ -168,6 +318,21 @@ func (s *ConsumerGroupServiceImpl) UpdateLastActivity(
 	return s.consumerGroupRepo.UpdateLastActivity(ctx, domainName, queueName, groupID)
 }
 
+// Heartbeat keeps a group that polls infrequently from being cleaned up as
+// stale, consumerID also marking that consumer as seen
+func (s *ConsumerGroupServiceImpl) Heartbeat(
+	ctx context.Context,
+	domainName, queueName, groupID, consumerID string,
+) error {
+	if repo, ok := s.consumerGroupRepo.(interface {
+		Heartbeat(ctx context.Context, domainName, queueName, groupID, consumerID string) error
+	}); ok {
+		return repo.Heartbeat(ctx, domainName, queueName, groupID, consumerID)
+	}
+
+	return s.consumerGroupRepo.UpdateLastActivity(ctx, domainName, queueName, groupID)
+}
+
 func (s *ConsumerGroupServiceImpl) GetPendingMessages(ctx context.Context, domainName, queueName, groupID string) ([]*model.Message, error) {
 	s.logger.Debug("Getting pending messages for group " + domainName + "." + queueName + "." + groupID)
 
@@ -213,24 +378,36 @@ func (s *ConsumerGroupServiceImpl) GetPendingMessages(ctx context.Context, domai
 	return messages, nil
 }
 
+// cleanupStaleGroups applies the cleanup policy, falling back to deleting
+// groups for repositories that can't archive them
+func (s *ConsumerGroupServiceImpl) cleanupStaleGroups(ctx context.Context) error {
+	policy := s.CleanupPolicy()
+	if repo, ok := s.consumerGroupRepo.(interface {
+		CleanupGroups(ctx context.Context, policy model.GroupCleanupPolicy) error
+	}); ok {
+		return repo.CleanupGroups(ctx, policy)
+	}
+	return s.CleanupStaleGroups(ctx, policy.StaleAfter)
+}
+
 func (s *ConsumerGroupServiceImpl) startCleanupTask(ctx context.Context) {
 	go func() {
-		// Cleanup every 5 minutes
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
+		timer := time.NewTimer(s.CleanupPolicy().Interval)
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-timer.C:
 				s.logger.Debug("Starting cleanup of stale consumer groups...")
-				if err := s.CleanupStaleGroups(ctx, 4*time.Hour); err != nil {
+				if err := s.cleanupStaleGroups(ctx); err != nil {
 					s.logger.Error("Error cleaning up stale consumer groups",
 						"ERROR", err)
 				} else {
 					s.logger.Debug("Cleanup of stale consumer groups completed successfully")
 				}
+				timer.Reset(s.CleanupPolicy().Interval)
 			}
 		}
 	}()
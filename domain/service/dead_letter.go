@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// deadLetterer is the message service as seen by the channel queues
+type deadLetterer interface {
+	DeadLetter(domainName, queueName string, message *model.Message, reason model.DeadLetterReason, cause error, attempts int) bool
+}
+
+var _ deadLetterer = (*MessageServiceImpl)(nil)
+
+// DeadLetter publishes a message a queue gives up on to the dead-letter
+// queue of its config, with the reason and the original queue in its
+// headers. It reports whether the message was dead-lettered: queues without
+// a dead-letter queue, or one not taking the reason, drop the message as
+// before, and dead-lettered messages are never dead-lettered again.
+func (s *MessageServiceImpl) DeadLetter(
+	domainName, queueName string,
+	message *model.Message,
+	reason model.DeadLetterReason,
+	cause error,
+	attempts int,
+) bool {
+	if message.DeadLettered() {
+		s.logger.Warn("Dead-lettered message given up on again, dropped",
+			"queue", domainName+"."+queueName,
+			"message", message.ID,
+			"reason", reason)
+		return false
+	}
+
+	// partitions dead-letter as the queue they belong to
+	queueName = model.PartitionParent(queueName)
+
+	domain, err := s.domainRepo.GetDomain(s.rootCtx, domainName)
+	if err != nil || domain == nil {
+		return false
+	}
+	queue, exists := domain.Queues[queueName]
+	if !exists || !queue.Config.DeadLetter.Accepts(reason) {
+		return false
+	}
+	config := queue.Config.DeadLetter
+
+	resolved, err := s.claimCheck.resolve(s.rootCtx, message)
+	if err != nil {
+		s.logger.Error("Message not dead-lettered, its payload is unavailable",
+			"queue", domainName+"."+queueName,
+			"message", message.ID,
+			"ERROR", err)
+		return false
+	}
+
+	info := model.DeadLetterInfo{
+		Reason:     reason,
+		Queue:      queueName,
+		RoutingKey: config.RoutingKey,
+		Attempts:   attempts,
+		Time:       time.Now(),
+	}
+	if info.RoutingKey == "" {
+		info.RoutingKey = queueName
+	}
+	if cause != nil {
+		info.Error = cause.Error()
+	}
+
+	// the schema may be what the message failed
+	if err := s.publish(domainName, config.Queue, info.Copy(resolved), false); err != nil {
+		s.logger.Error("Message not dead-lettered",
+			"queue", domainName+"."+queueName,
+			"deadLetterQueue", domainName+"."+config.Queue,
+			"message", message.ID,
+			"reason", reason,
+			"ERROR", err)
+		return false
+	}
+
+	s.logger.Warn("Message dead-lettered",
+		"queue", domainName+"."+queueName,
+		"deadLetterQueue", domainName+"."+config.Queue,
+		"message", message.ID,
+		"reason", reason)
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessageDeadLettered,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: message.ID,
+		Data: map[string]any{
+			"deadLetterQueue": config.Queue,
+			"reason":          string(reason),
+		},
+	})
+	return true
+}
+
+// deadLetterExpired dead-letters a consumed message that outlived the TTL
+// of its queue, the TTL being only enforced for the queues dead-lettering
+// expired messages. It reports whether the message was dead-lettered,
+// otherwise it goes to the consumer.
+func (s *MessageServiceImpl) deadLetterExpired(ctx context.Context, domainName, queueName string, message *model.Message) bool {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return false
+	}
+	queue, exists := domain.Queues[queueName]
+	if !exists || queue.Config.TTL <= 0 || !queue.Config.DeadLetter.Accepts(model.DeadLetterExpired) ||
+		message.Timestamp.IsZero() || time.Since(message.Timestamp) <= queue.Config.TTL {
+		return false
+	}
+	cause := fmt.Errorf("older than the %s ttl of the queue", queue.Config.TTL)
+	return s.DeadLetter(domainName, queueName, message, model.DeadLetterExpired, cause, 0)
+}
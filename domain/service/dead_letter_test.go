@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Schema: &model.Schema{Fields: map[string]model.FieldType{
+			"id": model.StringType,
+		}},
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop", Config: model.QueueConfig{
+				DeadLetter: &model.DeadLetterConfig{Queue: "dlq", RoutingKey: "billing"},
+			}},
+			"refunds": {Name: "refunds", DomainName: "shop", Config: model.QueueConfig{
+				DeadLetter: &model.DeadLetterConfig{Queue: "dlq", Reasons: []model.DeadLetterReason{model.DeadLetterRejected}},
+			}},
+			"payments": {Name: "payments", DomainName: "shop"},
+			"dlq":      {Name: "dlq", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, bus)
+
+	// a delivered message the queue gave up on
+	failed := &model.Message{ID: "order-1", Payload: []byte(`{"id":"o-1"}`), Headers: map[string]string{"X-Request-ID": "req-1"}}
+	require.True(t, svc.DeadLetter("shop", "orders", failed, model.DeadLetterExhaustedRetries, errors.New("timeout"), 3))
+
+	stored := messageRepo.messages["shop:dlq"]
+	require.Len(t, stored, 1)
+	assert.Equal(t, "order-1", stored[0].ID)
+	assert.Equal(t, "req-1", stored[0].Headers["X-Request-ID"])
+	assert.Equal(t, "exhausted-retries", stored[0].Headers[model.HeaderDeadLetterReason])
+	assert.Equal(t, "orders", stored[0].Headers[model.HeaderDeadLetterQueue])
+	assert.Equal(t, "billing", stored[0].Headers[model.HeaderDeadLetterRoutingKey])
+	assert.Equal(t, "timeout", stored[0].Headers[model.HeaderDeadLetterError])
+	assert.Equal(t, "3", stored[0].Headers[model.HeaderDeadLetterAttempts])
+	info, ok := stored[0].Metadata[model.MetadataDeadLetter].(model.DeadLetterInfo)
+	require.True(t, ok)
+	assert.Equal(t, model.DeadLetterExhaustedRetries, info.Reason)
+
+	last := bus.events[len(bus.events)-1]
+	assert.Equal(t, model.EventMessageDeadLettered, last.Kind)
+	assert.Equal(t, "orders", last.Queue)
+	assert.Equal(t, "dlq", last.Data["deadLetterQueue"])
+
+	// dropped as before: no dead-letter queue, reason not taken, dead-lettered already
+	assert.False(t, svc.DeadLetter("shop", "payments", failed, model.DeadLetterRejected, nil, 1))
+	assert.False(t, svc.DeadLetter("shop", "refunds", failed, model.DeadLetterExhaustedRetries, nil, 1))
+	assert.False(t, svc.DeadLetter("shop", "orders", stored[0], model.DeadLetterRejected, nil, 1))
+	assert.Len(t, messageRepo.messages["shop:dlq"], 1)
+
+	// the producer still gets the schema error, the dead-letter queue the message
+	err := svc.PublishMessage("shop", "orders", &model.Message{ID: "bad-1", Payload: []byte(`{"id":1}`)})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+	stored = messageRepo.messages["shop:dlq"]
+	require.Len(t, stored, 2)
+	assert.Equal(t, "bad-1", stored[1].ID)
+	assert.Equal(t, "schema-invalid", stored[1].Headers[model.HeaderDeadLetterReason])
+	assert.Contains(t, stored[1].Headers[model.HeaderDeadLetterError], "id")
+	assert.Empty(t, messageRepo.messages["shop:orders"])
+}
+
+func TestDeadLetterExpired(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop", Config: model.QueueConfig{
+				TTL:        time.Minute,
+				DeadLetter: &model.DeadLetterConfig{Queue: "dlq", Reasons: []model.DeadLetterReason{model.DeadLetterExpired}},
+			}},
+			"payments": {Name: "payments", DomainName: "shop", Config: model.QueueConfig{TTL: time.Minute}},
+			"dlq":      {Name: "dlq", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+	messageRepo := &mockMessageRepository{}
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+
+	old := &model.Message{ID: "old", Payload: []byte(`{}`), Timestamp: time.Now().Add(-2 * time.Minute)}
+	fresh := &model.Message{ID: "fresh", Payload: []byte(`{}`), Timestamp: time.Now()}
+
+	assert.False(t, svc.deadLetterExpired(ctx, "shop", "orders", fresh))
+	assert.False(t, svc.deadLetterExpired(ctx, "shop", "payments", old), "the TTL is only enforced with a dead-letter queue")
+	require.True(t, svc.deadLetterExpired(ctx, "shop", "orders", old))
+
+	stored := messageRepo.messages["shop:dlq"]
+	require.Len(t, stored, 1)
+	assert.Equal(t, "expired", stored[0].Headers[model.HeaderDeadLetterReason])
+	assert.Contains(t, stored[0].Headers[model.HeaderDeadLetterError], "1m0s ttl")
+}
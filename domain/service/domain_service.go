@@ -15,6 +15,8 @@ var (
 )
 
 type DomainServiceImpl struct {
+	eventEmitter
+
 	domainRepo   outbound.DomainRepository
 	queueService inbound.QueueService
 	rootCtx      context.Context
@@ -41,10 +43,12 @@ func (s *DomainServiceImpl) CreateDomain(ctx context.Context, config *model.Doma
 	}
 
 	domain := &model.Domain{
-		Name:   config.Name,
-		Schema: config.Schema,
-		Queues: make(map[string]*model.Queue),
-		Routes: make(map[string]map[string]*model.RoutingRule),
+		Name:                config.Name,
+		Schema:              config.Schema,
+		Queues:              make(map[string]*model.Queue),
+		Routes:              make(map[string]map[string]*model.RoutingRule),
+		AllowedRouteSources: config.AllowedRouteSources,
+		Labels:              config.Labels,
 	}
 
 	// If set create initial queues
@@ -65,11 +69,17 @@ func (s *DomainServiceImpl) CreateDomain(ctx context.Context, config *model.Doma
 			if domain.Routes[rule.SourceQueue] == nil {
 				domain.Routes[rule.SourceQueue] = make(map[string]*model.RoutingRule)
 			}
-			domain.Routes[rule.SourceQueue][rule.DestinationQueue] = rule
+			domain.Routes[rule.SourceQueue][rule.DestinationKey(config.Name)] = rule
 		}
 	}
 
-	return s.domainRepo.StoreDomain(ctx, domain)
+	if err := s.domainRepo.StoreDomain(ctx, domain); err != nil {
+		return err
+	}
+
+	s.emit(model.DomainEvent{Kind: model.EventDomainCreated, Domain: config.Name})
+
+	return nil
 }
 
 func (s *DomainServiceImpl) GetDomain(ctx context.Context, name string) (*model.Domain, error) {
@@ -85,15 +95,85 @@ func (s *DomainServiceImpl) DeleteDomain(ctx context.Context, name string) error
 		return ErrDomainNotFound
 	}
 
+	// the repository may refuse the delete, so the queues stop only after it
+	if err := s.domainRepo.DeleteDomain(ctx, name); err != nil {
+		return err
+	}
+
 	s.queueService.StopDomainQueues(ctx, name)
 
-	return s.domainRepo.DeleteDomain(ctx, name)
+	s.emit(model.DomainEvent{Kind: model.EventDomainDeleted, Domain: name})
+
+	return nil
 }
 
 func (s *DomainServiceImpl) ListDomains(ctx context.Context) ([]*model.Domain, error) {
 	return s.domainRepo.ListDomains(ctx)
 }
 
+// domainPageSize is the page size of the background walks over every domain
+const domainPageSize = 256
+
+// forEachDomain walks the domains a page at a time when the repository pages
+// them, so background walks neither hold a lock nor build a listing of every
+// domain; other repositories are walked from ListDomains
+func forEachDomain(ctx context.Context, repo outbound.DomainRepository, fn func(*model.Domain)) error {
+	pager, ok := repo.(interface {
+		ListDomainsPage(ctx context.Context, cursor string, limit int) ([]*model.Domain, string, error)
+	})
+	if !ok {
+		domains, err := repo.ListDomains(ctx)
+		if err != nil {
+			return err
+		}
+		for _, domain := range domains {
+			fn(domain)
+		}
+		return nil
+	}
+
+	cursor := ""
+	for {
+		domains, next, err := pager.ListDomainsPage(ctx, cursor, domainPageSize)
+		if err != nil {
+			return err
+		}
+		for _, domain := range domains {
+			fn(domain)
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (s *DomainServiceImpl) UpdateAllowedRouteSources(ctx context.Context, name string, sources []string) error {
+	log.Printf("Updating allowed route sources for domain %s: %v", name, sources)
+
+	domain, err := s.domainRepo.GetDomain(ctx, name)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	domain.AllowedRouteSources = sources
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+func (s *DomainServiceImpl) UpdateDomainLabels(ctx context.Context, name string, labels map[string]string) error {
+	log.Printf("Updating labels for domain %s: %v", name, labels)
+
+	domain, err := s.domainRepo.GetDomain(ctx, name)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	domain.Labels = labels
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
 func (s *DomainServiceImpl) ListSystemDomains(ctx context.Context) ([]*model.Domain, error) {
 	return s.domainRepo.SystemDomains(ctx)
 }
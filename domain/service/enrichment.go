@@ -0,0 +1,35 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// publishSequences numbers the publishes of the queues enriched with a
+// sequence. Numbers increase per queue, with gaps when a publish fails
+// after drawing one, and start over when the server restarts.
+type publishSequences struct {
+	counters sync.Map // "domain/queue" -> *atomic.Uint64
+}
+
+func (p *publishSequences) next(domainName, queueName string) uint64 {
+	key := domainName + "/" + queueName
+	counter, ok := p.counters.Load(key)
+	if !ok {
+		counter, _ = p.counters.LoadOrStore(key, new(atomic.Uint64))
+	}
+	return counter.(*atomic.Uint64).Add(1)
+}
+
+// enrich applies the enrichment rules of the queue to a message being
+// published
+func (s *MessageServiceImpl) enrich(domainName, queueName string, rules []model.EnrichmentRule, message *model.Message) error {
+	var sequence uint64
+	if model.UsesSequence(rules) {
+		sequence = s.sequences.next(domainName, queueName)
+	}
+	return message.Enrich(rules, time.Now(), sequence)
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishSequences(t *testing.T) {
+	var sequences publishSequences
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	drawn := make(map[uint64]bool)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := sequences.next("shop", "orders")
+			mu.Lock()
+			drawn[n] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, drawn, 50, "numbers are never handed out twice")
+	assert.Equal(t, uint64(51), sequences.next("shop", "orders"))
+	assert.Equal(t, uint64(1), sequences.next("shop", "refunds"), "each queue has its own sequence")
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/google/uuid"
+)
+
+// messageReplacer is implemented by message repositories able to rewrite a
+// stored message in place, which shred erasures need
+type messageReplacer interface {
+	ReplaceMessage(ctx context.Context, domainName, queueName string, message *model.Message) error
+}
+
+// failurePurger is implemented by channel queues sampling delivery failures
+type failurePurger interface {
+	PurgeDeliveryFailures(match func(model.DeliveryFailure) bool) int
+}
+
+var _ inbound.ErasureService = (*ErasureServiceImpl)(nil)
+
+type ErasureServiceImpl struct {
+	rootCtx      context.Context
+	logger       outbound.Logger
+	domainRepo   outbound.DomainRepository
+	messageRepo  outbound.MessageRepository
+	queueService inbound.QueueService
+	claimCheck   *claimCheck
+
+	mu   sync.Mutex
+	jobs []*model.ErasureReport
+}
+
+func NewErasureService(
+	rootCtx context.Context,
+	logger outbound.Logger,
+	domainRepo outbound.DomainRepository,
+	messageRepo outbound.MessageRepository,
+	queueService inbound.QueueService,
+) *ErasureServiceImpl {
+	return &ErasureServiceImpl{
+		rootCtx:      rootCtx,
+		logger:       logger,
+		domainRepo:   domainRepo,
+		messageRepo:  messageRepo,
+		queueService: queueService,
+	}
+}
+
+// SetBlobStore lets erasures match and drop the payloads offloaded by claim-check
+func (s *ErasureServiceImpl) SetBlobStore(store outbound.BlobStore) {
+	s.claimCheck = &claimCheck{store: store}
+}
+
+func (s *ErasureServiceImpl) StartErasure(ctx context.Context, request model.ErasureRequest) (*model.ErasureReport, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if request.Mode == model.ErasureShred {
+		if _, ok := s.messageRepo.(messageReplacer); !ok {
+			return nil, fmt.Errorf("%w: the storage can't rewrite messages, use %s", model.ErrInvalidErasure, model.ErasureDelete)
+		}
+	}
+	for _, domainName := range request.Domains {
+		if domain, err := s.domainRepo.GetDomain(ctx, domainName); err != nil || domain == nil {
+			return nil, ErrDomainNotFound
+		}
+	}
+
+	job := &model.ErasureReport{
+		ID:        uuid.New().String(),
+		Field:     request.Field,
+		Mode:      request.Mode,
+		Domains:   request.Domains,
+		Status:    model.ErasureRunning,
+		StartedAt: time.Now(),
+		Queues:    []model.ErasureQueueReport{},
+	}
+
+	s.mu.Lock()
+	s.jobs = append(s.jobs, job)
+	started := copyErasureReport(job)
+	s.mu.Unlock()
+
+	s.logger.Info("Erasure started", "id", job.ID, "field", request.Field, "mode", request.Mode)
+	go s.run(job, request)
+
+	return started, nil
+}
+
+func (s *ErasureServiceImpl) GetErasure(id string) (*model.ErasureReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.ID == id {
+			return copyErasureReport(job), nil
+		}
+	}
+	return nil, model.ErrErasureNotFound
+}
+
+func (s *ErasureServiceImpl) ListErasures() []*model.ErasureReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := make([]*model.ErasureReport, len(s.jobs))
+	for i, job := range s.jobs {
+		reports[i] = copyErasureReport(job)
+	}
+	return reports
+}
+
+// run scans every queue of the requested domains, one queue at a time so
+// that the report shows the progress
+func (s *ErasureServiceImpl) run(job *model.ErasureReport, request model.ErasureRequest) {
+	ctx := s.rootCtx
+
+	domainNames := slices.Clone(request.Domains)
+	if len(domainNames) == 0 {
+		domains, err := s.domainRepo.ListDomains(ctx)
+		if err != nil {
+			s.finish(job, err)
+			return
+		}
+		for _, domain := range domains {
+			domainNames = append(domainNames, domain.Name)
+		}
+	}
+	slices.Sort(domainNames)
+
+	for _, domainName := range domainNames {
+		domain, err := s.domainRepo.GetDomain(ctx, domainName)
+		if err != nil || domain == nil {
+			continue // deleted since the request
+		}
+		queueNames := make([]string, 0, len(domain.Queues))
+		for name := range domain.Queues {
+			queueNames = append(queueNames, name)
+		}
+		slices.Sort(queueNames)
+
+		for _, queueName := range queueNames {
+			if ctx.Err() != nil {
+				s.finish(job, ctx.Err())
+				return
+			}
+			report := s.eraseQueue(ctx, domainName, domain.Queues[queueName], request)
+
+			s.mu.Lock()
+			job.Queues = append(job.Queues, report)
+			job.Scanned += report.Scanned
+			job.Erased += report.Erased
+			job.FailureSamples += report.FailureSamples
+			s.mu.Unlock()
+		}
+	}
+	s.finish(job, nil)
+}
+
+func (s *ErasureServiceImpl) eraseQueue(
+	ctx context.Context,
+	domainName string,
+	queue *model.Queue,
+	request model.ErasureRequest,
+) model.ErasureQueueReport {
+	report := model.ErasureQueueReport{Domain: domainName, Queue: queue.Name}
+	addError := func(err error) {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	// sealed values can't be compared, the subject may still be in there
+	topField, _, _ := strings.Cut(request.Field, ".")
+	if slices.Contains(queue.Config.EncryptedFields, topField) {
+		report.Errors = append(report.Errors, "field "+topField+" is encrypted in this queue, its messages can't be matched")
+	}
+
+	// the messages of a partitioned queue are stored in its partitions
+	for _, storageQueue := range queue.StorageQueues() {
+		count := s.messageRepo.GetQueueMessageCount(domainName, storageQueue)
+		if count > 0 {
+			messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, storageQueue, 0, count)
+			if err != nil {
+				addError(err)
+			}
+			for _, message := range messages {
+				report.Scanned++
+
+				resolved, err := s.claimCheck.resolve(ctx, message)
+				if err != nil {
+					addError(err)
+					continue
+				}
+				if !request.Matches(resolved.Payload) {
+					continue
+				}
+
+				if err := s.erase(ctx, domainName, storageQueue, message, request.Mode); err != nil {
+					addError(err)
+					continue
+				}
+				report.Erased++
+			}
+		}
+
+		// failure samples hold payload copies, the truncated ones can't be parsed
+		// and go when they merely contain the value
+		if channelQueue, err := s.queueService.GetChannelQueue(ctx, domainName, storageQueue); err == nil {
+			if purger, ok := channelQueue.(failurePurger); ok {
+				report.FailureSamples += purger.PurgeDeliveryFailures(func(failure model.DeliveryFailure) bool {
+					if failure.PayloadTruncated {
+						return bytes.Contains(failure.Payload, []byte(request.Value))
+					}
+					return request.Matches(failure.Payload)
+				})
+			}
+		}
+	}
+
+	return report
+}
+
+// erase deletes the message, or rewrites it as a tombstone, then drops its
+// offloaded payload
+func (s *ErasureServiceImpl) erase(ctx context.Context, domainName, queueName string, message *model.Message, mode string) error {
+	if mode == model.ErasureShred {
+		tombstone := *message
+		tombstone.Payload = model.ErasureTombstone
+		tombstone.Metadata = maps.Clone(message.Metadata)
+		if tombstone.Metadata == nil {
+			tombstone.Metadata = make(map[string]any)
+		}
+		delete(tombstone.Metadata, claimCheckKey)
+		delete(tombstone.Metadata, claimCheckSizeKey)
+		tombstone.Metadata[model.MetadataErasedAt] = time.Now().UTC()
+
+		if err := s.messageRepo.(messageReplacer).ReplaceMessage(ctx, domainName, queueName, &tombstone); err != nil {
+			return err
+		}
+	} else if err := s.messageRepo.DeleteMessage(ctx, domainName, queueName, message.ID); err != nil {
+		return err
+	}
+	return s.claimCheck.release(ctx, message)
+}
+
+func (s *ErasureServiceImpl) finish(job *model.ErasureReport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = model.ErasureCompleted
+	if err != nil {
+		job.Status = model.ErasureFailed
+		job.Error = err.Error()
+	}
+
+	s.logger.Info("Erasure finished",
+		"id", job.ID,
+		"status", job.Status,
+		"scanned", job.Scanned,
+		"erased", job.Erased,
+		"failureSamples", job.FailureSamples)
+}
+
+func copyErasureReport(job *model.ErasureReport) *model.ErasureReport {
+	report := *job
+	report.Queues = slices.Clone(job.Queues)
+	return &report
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupErasureService(t *testing.T) (*ErasureServiceImpl, *mockMessageRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders":   {Name: "orders", DomainName: "shop"},
+			"payments": {Name: "payments", DomainName: "shop", Config: model.QueueConfig{EncryptedFields: []string{"customer"}}},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	t.Cleanup(queueService.Cleanup)
+
+	for _, message := range []*model.Message{
+		{ID: "1", Payload: []byte(`{"customer":{"id":"u-42"},"total":10}`)},
+		{ID: "2", Payload: []byte(`{"customer":{"id":"u-7"},"total":20}`)},
+		{ID: "3", Payload: []byte(`{"customer":{"id":"u-42"},"total":30}`)},
+	} {
+		messageRepo.StoreMessage(ctx, "shop", "orders", message)
+	}
+
+	return NewErasureService(ctx, &mockLogger{}, domainRepo, messageRepo, queueService), messageRepo
+}
+
+func waitErasure(t *testing.T, svc *ErasureServiceImpl, id string) *model.ErasureReport {
+	var report *model.ErasureReport
+	require.Eventually(t, func() bool {
+		report, _ = svc.GetErasure(id)
+		return report.Status != model.ErasureRunning
+	}, time.Second, 5*time.Millisecond)
+	return report
+}
+
+func TestErasureDelete(t *testing.T) {
+	svc, messageRepo := setupErasureService(t)
+
+	started, err := svc.StartErasure(context.Background(), model.ErasureRequest{Field: "customer.id", Value: "u-42"})
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasureDelete, started.Mode)
+
+	report := waitErasure(t, svc, started.ID)
+	assert.Equal(t, model.ErasureCompleted, report.Status)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, 2, report.Erased)
+	require.Len(t, report.Queues, 2)
+	assert.Contains(t, report.Queues[1].Errors[0], "encrypted", "sealed fields are reported, not silently skipped")
+
+	remaining := messageRepo.messages["shop:orders"]
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "2", remaining[0].ID)
+}
+
+func TestErasureShred(t *testing.T) {
+	svc, messageRepo := setupErasureService(t)
+
+	started, err := svc.StartErasure(context.Background(), model.ErasureRequest{Field: "customer.id", Value: "u-42", Mode: model.ErasureShred})
+	require.NoError(t, err)
+	report := waitErasure(t, svc, started.ID)
+	assert.Equal(t, 2, report.Erased)
+
+	messages := messageRepo.messages["shop:orders"]
+	require.Len(t, messages, 3, "shredded messages stay as tombstones")
+	assert.Equal(t, model.ErasureTombstone, messages[0].Payload)
+	assert.Contains(t, messages[0].Metadata, model.MetadataErasedAt)
+	assert.JSONEq(t, `{"customer":{"id":"u-7"},"total":20}`, string(messages[1].Payload))
+}
+
+func TestErasureRejectsInvalidRequests(t *testing.T) {
+	svc, _ := setupErasureService(t)
+
+	_, err := svc.StartErasure(context.Background(), model.ErasureRequest{Field: "userId"})
+	assert.ErrorIs(t, err, model.ErrInvalidErasure)
+
+	_, err = svc.StartErasure(context.Background(), model.ErasureRequest{Field: "userId", Value: "u-1", Domains: []string{"missing"}})
+	assert.ErrorIs(t, err, ErrDomainNotFound)
+
+	_, err = svc.GetErasure("unknown")
+	assert.ErrorIs(t, err, model.ErrErasureNotFound)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// errorReporting is embedded by the services forwarding their failures to
+// an error tracker, reporting is a no-op until a reporter is set
+type errorReporting struct {
+	errorReporter outbound.ErrorReporter
+}
+
+// SetErrorReporter sets the tracker receiving the failures of the service
+func (e *errorReporting) SetErrorReporter(reporter outbound.ErrorReporter) {
+	e.errorReporter = reporter
+}
+
+// reportError forwards a failure on a queue, with the message it happened
+// on when there is one
+func (e *errorReporting) reportError(kind, domainName, queueName string, msg *model.Message, err error) {
+	if e.errorReporter == nil {
+		return
+	}
+	report := model.ErrorReport{
+		Kind:    kind,
+		Domain:  domainName,
+		Queue:   queueName,
+		Message: err.Error(),
+		Time:    time.Now(),
+	}
+	if msg != nil {
+		report.MessageID = msg.ID
+		report.ServiceID = msg.Publisher().ServiceID()
+	}
+	e.errorReporter.Report(context.Background(), report)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingReporter struct {
+	reports []model.ErrorReport
+}
+
+func (r *recordingReporter) Report(ctx context.Context, report model.ErrorReport) {
+	r.reports = append(r.reports, report)
+}
+
+func TestReportError(t *testing.T) {
+	var reporting errorReporting
+	msg := &model.Message{ID: "msg-1"}
+	msg.SetPublisher(model.Publisher{Principal: "service:billing"})
+
+	// no reporter, nothing to do
+	reporting.reportError(model.ErrorKindStorage, "shop", "orders", msg, errors.New("disk full"))
+
+	reporter := &recordingReporter{}
+	reporting.SetErrorReporter(reporter)
+	reporting.reportError(model.ErrorKindStorage, "shop", "orders", msg, errors.New("disk full"))
+	reporting.reportError(model.ErrorKindDelivery, "shop", "orders", nil, model.ErrHandlerTimeout)
+
+	require.Len(t, reporter.reports, 2)
+	assert.Equal(t, model.ErrorReport{
+		Kind:      model.ErrorKindStorage,
+		Domain:    "shop",
+		Queue:     "orders",
+		ServiceID: "billing",
+		MessageID: "msg-1",
+		Message:   "disk full",
+		Time:      reporter.reports[0].Time,
+	}, reporter.reports[0])
+	assert.Empty(t, reporter.reports[1].ServiceID)
+}
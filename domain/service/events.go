@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// eventEmitter is embedded by the services publishing domain events,
+// emitting is a no-op until a bus is set
+type eventEmitter struct {
+	eventBus outbound.EventBus
+}
+
+// SetEventBus sets the bus receiving the events of the service
+func (e *eventEmitter) SetEventBus(bus outbound.EventBus) {
+	e.eventBus = bus
+}
+
+func (e *eventEmitter) emit(event model.DomainEvent) {
+	if e.eventBus != nil {
+		e.eventBus.Publish(event)
+	}
+}
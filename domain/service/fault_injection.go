@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// faultInjector holds the faults injected into queues, so consumer teams
+// can check how their retries and backoff behave against GoRTMS
+type faultInjector struct {
+	mu     sync.RWMutex
+	faults map[string]model.QueueFault // "domain/queue" -> fault
+	random func() float64
+}
+
+func newFaultInjector() *faultInjector {
+	return &faultInjector{
+		faults: make(map[string]model.QueueFault),
+		random: rand.Float64,
+	}
+}
+
+// active returns the fault of a queue unless it has expired
+func (f *faultInjector) active(domainName, queueName string) (model.QueueFault, bool) {
+	if f == nil {
+		return model.QueueFault{}, false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	fault, exists := f.faults[domainName+"/"+queueName]
+	if !exists || fault.Expired(time.Now()) {
+		return model.QueueFault{}, false
+	}
+	return fault, true
+}
+
+// beforePublish refuses the publish the fault of the queue selects
+func (f *faultInjector) beforePublish(domainName, queueName string) error {
+	fault, exists := f.active(domainName, queueName)
+	switch {
+	case !exists:
+		return nil
+	case fault.CircuitOpen:
+		return model.ErrInjectedCircuitOpen
+	case fault.PublishFailureRate > 0 && f.random() < fault.PublishFailureRate:
+		return model.ErrInjectedFault
+	}
+	return nil
+}
+
+// beforeConsume waits for the latency of the fault of the queue
+func (f *faultInjector) beforeConsume(ctx context.Context, domainName, queueName string) error {
+	fault, exists := f.active(domainName, queueName)
+	if !exists || fault.ConsumeLatency == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(fault.ConsumeLatency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InjectFault sets the fault of a queue, replacing the previous one
+func (s *MessageServiceImpl) InjectFault(ctx context.Context, fault model.QueueFault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.queueService.GetQueue(ctx, fault.Domain, fault.Queue); err != nil {
+		return err
+	}
+
+	s.faults.mu.Lock()
+	defer s.faults.mu.Unlock()
+	s.faults.faults[fault.Domain+"/"+fault.Queue] = fault
+
+	s.logger.Warn("Fault injected",
+		"domain", fault.Domain,
+		"queue", fault.Queue,
+		"publishFailureRate", fault.PublishFailureRate,
+		"consumeLatency", fault.ConsumeLatency,
+		"circuitOpen", fault.CircuitOpen,
+		"expiresAt", fault.ExpiresAt)
+	return nil
+}
+
+// ClearFault lifts the fault of a queue, reporting whether there was one
+func (s *MessageServiceImpl) ClearFault(domainName, queueName string) bool {
+	s.faults.mu.Lock()
+	defer s.faults.mu.Unlock()
+
+	key := domainName + "/" + queueName
+	fault, exists := s.faults.faults[key]
+	delete(s.faults.faults, key)
+	if exists {
+		s.logger.Info("Fault cleared", "domain", domainName, "queue", queueName)
+	}
+	return exists && !fault.Expired(time.Now())
+}
+
+// ListFaults returns the faults still active, expired ones being dropped
+func (s *MessageServiceImpl) ListFaults() []model.QueueFault {
+	s.faults.mu.Lock()
+	defer s.faults.mu.Unlock()
+
+	now := time.Now()
+	faults := make([]model.QueueFault, 0, len(s.faults.faults))
+	for key, fault := range s.faults.faults {
+		if fault.Expired(now) {
+			delete(s.faults.faults, key)
+			continue
+		}
+		faults = append(faults, fault)
+	}
+	sort.Slice(faults, func(i, j int) bool {
+		return faults[i].Domain+"/"+faults[i].Queue < faults[j].Domain+"/"+faults[j].Queue
+	})
+	return faults
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjector(t *testing.T) {
+	injector := newFaultInjector()
+	injector.random = func() float64 { return 0.3 }
+	inject := func(fault model.QueueFault) {
+		fault.Domain, fault.Queue = "shop", "orders"
+		injector.faults["shop/orders"] = fault
+	}
+
+	t.Run("queues without fault are untouched", func(t *testing.T) {
+		assert.NoError(t, injector.beforePublish("shop", "orders"))
+		assert.NoError(t, injector.beforeConsume(context.Background(), "shop", "orders"))
+
+		var disabled *faultInjector
+		assert.NoError(t, disabled.beforePublish("shop", "orders"))
+	})
+
+	t.Run("publish failures follow the rate", func(t *testing.T) {
+		inject(model.QueueFault{PublishFailureRate: 0.5})
+		assert.ErrorIs(t, injector.beforePublish("shop", "orders"), model.ErrInjectedFault)
+
+		inject(model.QueueFault{PublishFailureRate: 0.2})
+		assert.NoError(t, injector.beforePublish("shop", "orders"))
+		assert.NoError(t, injector.beforePublish("shop", "invoices"))
+	})
+
+	t.Run("open circuit refuses every publish", func(t *testing.T) {
+		inject(model.QueueFault{CircuitOpen: true})
+		assert.ErrorIs(t, injector.beforePublish("shop", "orders"), model.ErrInjectedCircuitOpen)
+	})
+
+	t.Run("consume latency", func(t *testing.T) {
+		inject(model.QueueFault{ConsumeLatency: 20 * time.Millisecond})
+		start := time.Now()
+		assert.NoError(t, injector.beforeConsume(context.Background(), "shop", "orders"))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+		inject(model.QueueFault{ConsumeLatency: time.Minute})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, injector.beforeConsume(ctx, "shop", "orders"), context.DeadlineExceeded)
+	})
+
+	t.Run("expired faults lift themselves", func(t *testing.T) {
+		inject(model.QueueFault{CircuitOpen: true, ExpiresAt: time.Now().Add(-time.Second)})
+		assert.NoError(t, injector.beforePublish("shop", "orders"))
+	})
+}
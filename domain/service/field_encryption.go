@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// fieldEncryption seals the sensitive payload fields of a queue with a key
+// derived from the master key, so the broker stores, routes and filters
+// messages without exposing them. Consumers decrypt with the queue key.
+type fieldEncryption struct {
+	crypto    outbound.CryptoService
+	masterKey [32]byte
+}
+
+// queueKey derives the key of a queue from the master key
+func (f *fieldEncryption) queueKey(keyID string) [32]byte {
+	mac := hmac.New(sha256.New, f.masterKey[:])
+	mac.Write([]byte("gortms-queue-key:" + keyID))
+
+	var key [32]byte
+	copy(key[:], mac.Sum(nil))
+	return key
+}
+
+// seal encrypts the fields the queue declares, in place
+func (f *fieldEncryption) seal(domainName, queueName string, fields []string, message *model.Message) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if f == nil {
+		return model.ErrFieldEncryptionDisabled
+	}
+
+	keyID := model.EncryptionKeyID(domainName, queueName)
+	key := f.queueKey(keyID)
+
+	payload, err := model.SealFields(message.Payload, fields, func(value []byte) (*model.EncryptedField, error) {
+		data, nonce, err := f.crypto.Encrypt(value, key)
+		if err != nil {
+			return nil, err
+		}
+		return &model.EncryptedField{KeyID: keyID, Nonce: nonce, Data: data}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	message.Payload = payload
+	return nil
+}
+
+// SetFieldEncryption enables the encryption of the fields queues declare
+// sensitive, with keys derived from masterKey
+func (s *MessageServiceImpl) SetFieldEncryption(crypto outbound.CryptoService, masterKey [32]byte) {
+	s.fieldEncryption = &fieldEncryption{crypto: crypto, masterKey: masterKey}
+}
+
+// QueueEncryptionKey returns the key sealing the encrypted fields of a queue,
+// to be handed to the consumers allowed to read them
+func (s *MessageServiceImpl) QueueEncryptionKey(ctx context.Context, domainName, queueName string) (string, [32]byte, error) {
+	queue, err := s.queueService.GetQueue(ctx, domainName, queueName)
+	if err != nil {
+		return "", [32]byte{}, err
+	}
+	if len(queue.Config.EncryptedFields) == 0 {
+		return "", [32]byte{}, model.ErrNoEncryptedFields
+	}
+	if s.fieldEncryption == nil {
+		return "", [32]byte{}, model.ErrFieldEncryptionDisabled
+	}
+
+	keyID := model.EncryptionKeyID(domainName, queueName)
+	return keyID, s.fieldEncryption.queueKey(keyID), nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldEncryption(t *testing.T) {
+	aes := crypto.NewAESCryptoService()
+	fe := &fieldEncryption{crypto: aes, masterKey: aes.DeriveKey("test")}
+
+	t.Run("sealed fields decrypt with the queue key", func(t *testing.T) {
+		msg := &model.Message{ID: "1", Payload: []byte(`{"type":"invoice","card":{"number":"4111"}}`)}
+		require.NoError(t, fe.seal("shop", "orders", []string{"card", "missing"}, msg))
+
+		var payload map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.JSONEq(t, `"invoice"`, string(payload["type"]), "other fields stay readable")
+		assert.NotContains(t, payload, "missing")
+
+		var card map[string]*model.EncryptedField
+		require.NoError(t, json.Unmarshal(payload["card"], &card))
+		field := card[model.EncryptedFieldKey]
+		require.NotNil(t, field)
+		assert.Equal(t, "shop/orders", field.KeyID)
+
+		plain, err := aes.Decrypt(field.Data, field.Nonce, fe.queueKey(field.KeyID))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"number":"4111"}`, string(plain))
+
+		sealed := msg.Payload
+		require.NoError(t, fe.seal("billing", "invoices", []string{"card"}, msg))
+		assert.Equal(t, sealed, msg.Payload, "a routed copy keeps the first seal")
+	})
+
+	t.Run("queues have distinct keys", func(t *testing.T) {
+		assert.NotEqual(t, fe.queueKey("shop/orders"), fe.queueKey("shop/invoices"))
+	})
+
+	t.Run("payload must be an object", func(t *testing.T) {
+		msg := &model.Message{ID: "2", Payload: []byte(`["card"]`)}
+		assert.ErrorIs(t, fe.seal("shop", "orders", []string{"card"}, msg), model.ErrEncryptedFieldsPayload)
+	})
+
+	t.Run("disabled encryption refuses sensitive queues", func(t *testing.T) {
+		var disabled *fieldEncryption
+		msg := &model.Message{ID: "3", Payload: []byte(`{"card":"4111"}`)}
+
+		assert.NoError(t, disabled.seal("shop", "orders", nil, msg))
+		assert.ErrorIs(t, disabled.seal("shop", "orders", []string{"card"}, msg), model.ErrFieldEncryptionDisabled)
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -350,13 +351,14 @@ func TestGetStats_WithTrends(t *testing.T) {
 		LastUpdated:     time.Now(),
 	}
 
-	// Set previous stats for trend calculation
-	metrics.previousStats = &StatsData{
+	// Set a previous snapshot for trend calculation, the metrics changed since
+	metrics.snapshot.Store(&statsSnapshot{stats: &StatsData{
 		Domains:  2,  // Was 2, now 1 → down 50%
 		Queues:   3,  // Was 3, now 1 → down 66.67%
 		Messages: 50, // Was 50, now 100 → up 100%
 		Routes:   1,  // Was 1, now 0 → down 100%
-	}
+	}})
+	metrics.version.Add(1)
 
 	service := &StatsServiceImpl{
 		domainRepo:  domainRepo,
@@ -444,3 +446,40 @@ func TestGetStats_TopQueuesOrdering(t *testing.T) {
 	assert.Equal(t, "queue1", stats.TopQueues[2]["name"])
 	assert.Equal(t, 50.0, stats.TopQueues[2]["usage"])
 }
+
+func TestGetStats_SharesSnapshotUntilMetricsChange(t *testing.T) {
+	ctx := context.Background()
+	logger := &mockLogger{}
+
+	domain := createTestDomain("test-domain", map[string]int{"queue1": 1000})
+	service := &StatsServiceImpl{
+		domainRepo:  &mockDomainRepository{domains: []*model.Domain{domain}},
+		messageRepo: &mockMessageRepository{},
+		metrics:     setupMetricsStore(logger),
+	}
+
+	first, err := service.GetStats(ctx)
+	require.NoError(t, err)
+
+	// concurrent dashboards get the same snapshot
+	var wg sync.WaitGroup
+	results := make([]any, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = service.GetStats(ctx)
+		}()
+	}
+	wg.Wait()
+	for _, result := range results {
+		assert.Same(t, first, result)
+	}
+
+	service.RecordEvent("queue_created", "info", "test-domain.queue1", nil)
+	second, err := service.GetStats(ctx)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+	assert.Empty(t, first.(*StatsData).RecentEvents, "published snapshots are never modified")
+	assert.Len(t, second.(*StatsData).RecentEvents, 1)
+}
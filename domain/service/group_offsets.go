@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// offsetQueue is a queue holding group positions: the queue itself, or one
+// of its partitions
+type offsetQueue struct {
+	name      string
+	partition *int
+}
+
+// offsetQueues lists the queue and those of its partitions having groups
+func (s *ConsumerGroupServiceImpl) offsetQueues(ctx context.Context, domainName, queueName string) []offsetQueue {
+	queues := []offsetQueue{{name: queueName}}
+	for partition := range model.MaxPartitions {
+		partitionQueue := model.PartitionQueueName(queueName, partition)
+		groups, err := s.consumerGroupRepo.ListGroups(ctx, domainName, partitionQueue)
+		if err != nil || len(groups) == 0 {
+			continue
+		}
+		queues = append(queues, offsetQueue{name: partitionQueue, partition: &partition})
+	}
+	return queues
+}
+
+// ExportOffsets returns the offsets and settings of the groups of a queue,
+// for ImportOffsets on another instance
+func (s *ConsumerGroupServiceImpl) ExportOffsets(ctx context.Context, domainName, queueName string) (*model.GroupOffsets, error) {
+	offsets := &model.GroupOffsets{
+		Domain:     domainName,
+		Queue:      queueName,
+		ExportedAt: time.Now(),
+		Groups:     []model.GroupOffset{},
+	}
+
+	for _, queue := range s.offsetQueues(ctx, domainName, queueName) {
+		groupIDs, err := s.consumerGroupRepo.ListGroups(ctx, domainName, queue.name)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(groupIDs)
+
+		for _, groupID := range groupIDs {
+			group, err := s.GetGroupDetails(ctx, domainName, queue.name, groupID)
+			if err != nil {
+				return nil, err
+			}
+
+			offset := model.GroupOffset{
+				GroupID:       groupID,
+				Partition:     queue.partition,
+				Position:      group.Position,
+				DeliveryMode:  group.DeliveryMode,
+				Priority:      group.Priority,
+				Prefetch:      group.Prefetch,
+				CleanupAction: group.CleanupAction,
+			}
+			if group.TTL > 0 {
+				offset.TTL = group.TTL.String()
+			}
+			next, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, queue.name, group.Position, 1)
+			if err == nil && len(next) > 0 {
+				offset.NextMessageID = next[0].ID
+			}
+			offsets.Groups = append(offsets.Groups, offset)
+		}
+	}
+
+	return offsets, nil
+}
+
+// ImportOffsets creates the exported groups on the queue, each positioned on
+// its next message so that its consumers neither skip nor reprocess any.
+// Groups that already exist, and groups whose next message isn't stored
+// here, are skipped and reported.
+func (s *ConsumerGroupServiceImpl) ImportOffsets(
+	ctx context.Context,
+	domainName, queueName string,
+	offsets *model.GroupOffsets,
+) ([]model.OffsetImportResult, error) {
+	if err := offsets.Validate(); err != nil {
+		return nil, err
+	}
+
+	results := make([]model.OffsetImportResult, 0, len(offsets.Groups))
+	for _, offset := range offsets.Groups {
+		result := model.OffsetImportResult{GroupID: offset.GroupID, Partition: offset.Partition, Status: model.OffsetSkipped}
+
+		storageQueue := queueName
+		if offset.Partition != nil {
+			storageQueue = model.PartitionQueueName(queueName, *offset.Partition)
+		}
+
+		existing, err := s.consumerGroupRepo.ListGroups(ctx, domainName, storageQueue)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(existing, offset.GroupID) {
+			result.Reason = "group already exists"
+			results = append(results, result)
+			continue
+		}
+
+		position, err := s.localPosition(ctx, domainName, storageQueue, offset.NextMessageID)
+		if err != nil {
+			result.Reason = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if err := s.createFromOffset(ctx, domainName, storageQueue, offset, position); err != nil {
+			return nil, fmt.Errorf("importing group %s: %w", offset.GroupID, err)
+		}
+		result.Position = position
+		result.Status = model.OffsetImported
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// localPosition is the index of the next message of a group on this
+// instance, the end of the queue for a group that had consumed everything
+func (s *ConsumerGroupServiceImpl) localPosition(ctx context.Context, domainName, queueName, nextMessageID string) (int64, error) {
+	if nextMessageID != "" {
+		index, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, queueName, nextMessageID)
+		if err != nil || index < 0 {
+			return 0, fmt.Errorf("next message %s not found", nextMessageID)
+		}
+		return index, nil
+	}
+
+	count := s.messageRepo.GetQueueMessageCount(domainName, queueName)
+	if count == 0 {
+		return 0, nil
+	}
+	messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, queueName, 0, count)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	last, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, queueName, messages[len(messages)-1].ID)
+	if err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+func (s *ConsumerGroupServiceImpl) createFromOffset(
+	ctx context.Context,
+	domainName, queueName string,
+	offset model.GroupOffset,
+	position int64,
+) error {
+	ttl, _ := offset.ParsedTTL()
+	if err := s.CreateConsumerGroup(ctx, domainName, queueName, offset.GroupID, ttl); err != nil {
+		return err
+	}
+	if position > 0 {
+		if err := s.consumerGroupRepo.StorePosition(ctx, domainName, queueName, offset.GroupID, position); err != nil {
+			return err
+		}
+	}
+
+	if offset.DeliveryMode != "" && offset.DeliveryMode != model.GroupDeliveryShared {
+		if err := s.UpdateConsumerGroupDeliveryMode(ctx, domainName, queueName, offset.GroupID, offset.DeliveryMode); err != nil {
+			return err
+		}
+	}
+	if offset.Priority != 0 {
+		if err := s.UpdateConsumerGroupPriority(ctx, domainName, queueName, offset.GroupID, offset.Priority); err != nil {
+			return err
+		}
+	}
+	if offset.Prefetch != 0 {
+		if err := s.UpdateConsumerGroupPrefetch(ctx, domainName, queueName, offset.GroupID, offset.Prefetch); err != nil {
+			return err
+		}
+	}
+	if offset.CleanupAction != "" {
+		return s.UpdateConsumerGroupCleanupAction(ctx, domainName, queueName, offset.GroupID, offset.CleanupAction)
+	}
+	return nil
+}
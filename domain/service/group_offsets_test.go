@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// groupStore keeps the groups of a single queue
+type groupStore struct {
+	outbound.ConsumerGroupRepository
+	groups map[string]*model.ConsumerGroup
+}
+
+func (r *groupStore) ListGroups(ctx context.Context, domainName, queueName string) ([]string, error) {
+	ids := make([]string, 0)
+	for id, group := range r.groups {
+		if group.QueueName == queueName {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *groupStore) RegisterConsumer(ctx context.Context, domainName, queueName, groupID, consumerID string) error {
+	if r.groups == nil {
+		r.groups = make(map[string]*model.ConsumerGroup)
+	}
+	if _, exists := r.groups[groupID]; !exists {
+		r.groups[groupID] = &model.ConsumerGroup{DomainName: domainName, QueueName: queueName, GroupID: groupID}
+	}
+	return nil
+}
+
+func (r *groupStore) StorePosition(ctx context.Context, domainName, queueName, groupID string, position int64) error {
+	r.groups[groupID].UpdatePosition(position)
+	return nil
+}
+
+func (r *groupStore) GetGroupDetails(ctx context.Context, domainName, queueName, groupID string) (*model.ConsumerGroup, error) {
+	group, exists := r.groups[groupID]
+	if !exists {
+		return nil, errors.New("consumer group not found")
+	}
+	return group, nil
+}
+
+func (r *groupStore) SetGroupPriority(ctx context.Context, domainName, queueName, groupID string, priority int) error {
+	r.groups[groupID].Priority = priority
+	return nil
+}
+
+func storeMessages(t *testing.T, repo *mockMessageRepository, ids ...string) {
+	for _, id := range ids {
+		require.NoError(t, repo.StoreMessage(context.Background(), "shop", "orders", &model.Message{ID: id}))
+	}
+}
+
+func TestExportImportOffsets(t *testing.T) {
+	ctx := context.Background()
+
+	sourceMessages := &mockMessageRepository{}
+	storeMessages(t, sourceMessages, "m1", "m2", "m3", "m4")
+	sourceGroups := &groupStore{}
+	source := &ConsumerGroupServiceImpl{logger: &mockLogger{}, consumerGroupRepo: sourceGroups, messageRepo: sourceMessages}
+
+	require.NoError(t, source.CreateConsumerGroup(ctx, "shop", "orders", "billing", 0))
+	require.NoError(t, sourceGroups.StorePosition(ctx, "shop", "orders", "billing", 2))
+	require.NoError(t, source.UpdateConsumerGroupPriority(ctx, "shop", "orders", "billing", 7))
+	require.NoError(t, source.CreateConsumerGroup(ctx, "shop", "orders", "audit", 0))
+	require.NoError(t, sourceGroups.StorePosition(ctx, "shop", "orders", "audit", 4))
+	require.NoError(t, source.CreateConsumerGroup(ctx, "shop", "orders", "search", 0))
+
+	offsets, err := source.ExportOffsets(ctx, "shop", "orders")
+	require.NoError(t, err)
+	require.Len(t, offsets.Groups, 3)
+	assert.Equal(t, "audit", offsets.Groups[0].GroupID)
+	assert.Empty(t, offsets.Groups[0].NextMessageID, "audit consumed everything")
+	assert.Equal(t, "m3", offsets.Groups[1].NextMessageID)
+	assert.Equal(t, 7, offsets.Groups[1].Priority)
+
+	// The target stores the same messages at other indexes, and has its own search group
+	targetMessages := &mockMessageRepository{}
+	storeMessages(t, targetMessages, "m0", "m1", "m2", "m3", "m4")
+	targetGroups := &groupStore{}
+	target := &ConsumerGroupServiceImpl{logger: &mockLogger{}, consumerGroupRepo: targetGroups, messageRepo: targetMessages}
+	require.NoError(t, target.CreateConsumerGroup(ctx, "shop", "orders", "search", 0))
+
+	results, err := target.ImportOffsets(ctx, "shop", "orders", offsets)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, model.OffsetImported, results[0].Status)
+	assert.Equal(t, int64(5), targetGroups.groups["audit"].Position, "caught up groups start at the end")
+	assert.Equal(t, model.OffsetImported, results[1].Status)
+	assert.Equal(t, int64(3), targetGroups.groups["billing"].Position, "positioned on m3")
+	assert.Equal(t, 7, targetGroups.groups["billing"].Priority)
+	assert.Equal(t, model.OffsetSkipped, results[2].Status)
+	assert.Equal(t, "group already exists", results[2].Reason)
+
+	// A next message missing here can't be positioned on
+	offsets.Groups = []model.GroupOffset{{GroupID: "late", NextMessageID: "m9"}}
+	results, err = target.ImportOffsets(ctx, "shop", "orders", offsets)
+	require.NoError(t, err)
+	assert.Equal(t, model.OffsetSkipped, results[0].Status)
+	assert.NotContains(t, targetGroups.groups, "late")
+
+	offsets.Groups = []model.GroupOffset{{GroupID: "bad", DeliveryMode: "fanout"}}
+	_, err = target.ImportOffsets(ctx, "shop", "orders", offsets)
+	assert.ErrorIs(t, err, model.ErrInvalidOffsets)
+}
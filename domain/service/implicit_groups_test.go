@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImplicitGroups(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "shop",
+		Queues: map[string]*model.Queue{"orders": {Name: "orders", DomainName: "shop"}},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, &mockMessageRepository{}, queueService, bus)
+	groups := &groupStore{groups: map[string]*model.ConsumerGroup{}}
+	svc.consumerGroupRepo = groups
+
+	queue := func() *model.Queue {
+		domain, err := domainRepo.GetDomain(ctx, "shop")
+		require.NoError(t, err)
+		return domain.Queues["orders"]
+	}
+
+	// an unknown group springs up and is reported
+	require.NoError(t, svc.implicitGroup(ctx, "shop", queue(), "billing"))
+	require.Len(t, bus.events, 1)
+	assert.Equal(t, model.EventGroupImplicit, bus.events[0].Kind)
+	assert.Equal(t, "billing", bus.events[0].GroupID)
+
+	// a created group is not
+	require.NoError(t, groups.RegisterConsumer(ctx, "shop", "orders", "audit", ""))
+	require.NoError(t, svc.implicitGroup(ctx, "shop", queue(), "audit"))
+	assert.Len(t, bus.events, 1)
+
+	require.NoError(t, queueService.UpdateQueueImplicitGroups(ctx, "shop", "orders", model.ImplicitGroupsDeny))
+	assert.ErrorIs(t, svc.implicitGroup(ctx, "shop", queue(), "search"), model.ErrImplicitGroupDenied)
+	assert.NoError(t, svc.implicitGroup(ctx, "shop", queue(), "audit"))
+
+	assert.ErrorIs(t, queueService.UpdateQueueImplicitGroups(ctx, "shop", "orders", "sometimes"), model.ErrInvalidGroupPolicy)
+}
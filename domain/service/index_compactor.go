@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+const (
+	// IndexCompactionInterval is how often the compactor looks for due queues
+	IndexCompactionInterval = time.Second
+
+	// IndexCompactionThreshold is the number of consumes making a queue due
+	IndexCompactionThreshold = 100
+
+	// IndexCompactionBudget caps the indices removed per queue and run, a
+	// large backlog is spread over several runs instead of one long lock
+	IndexCompactionBudget = 10000
+
+	// indices kept below the slowest consumer group
+	indexSafetyMargin = 10
+)
+
+type queueCompaction struct {
+	domainName string
+	queueName  string
+	consumes   int
+	stats      model.QueueCompactionStats
+}
+
+// indexCompactor removes consumed message indices in the background so the
+// consume path only bumps a counter
+type indexCompactor struct {
+	logger            outbound.Logger
+	messageRepo       outbound.MessageRepository
+	consumerGroupRepo outbound.ConsumerGroupRepository
+	threshold         int
+	budget            int
+
+	mu     sync.Mutex
+	queues map[string]*queueCompaction
+	stats  model.IndexCompactionStats
+}
+
+func newIndexCompactor(
+	logger outbound.Logger,
+	messageRepo outbound.MessageRepository,
+	consumerGroupRepo outbound.ConsumerGroupRepository,
+) *indexCompactor {
+	return &indexCompactor{
+		logger:            logger,
+		messageRepo:       messageRepo,
+		consumerGroupRepo: consumerGroupRepo,
+		threshold:         IndexCompactionThreshold,
+		budget:            IndexCompactionBudget,
+		queues:            make(map[string]*queueCompaction),
+	}
+}
+
+// markConsumed counts a consume towards the queue's next compaction
+func (c *indexCompactor) markConsumed(domainName, queueName string) {
+	key := domainName + "." + queueName
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, exists := c.queues[key]
+	if !exists {
+		queue = &queueCompaction{domainName: domainName, queueName: queueName}
+		c.queues[key] = queue
+	}
+	queue.consumes++
+}
+
+// forget drops the compaction state of a deleted queue and its partitions,
+// or of every queue of the domain when queueName is empty
+func (c *indexCompactor) forget(domainName, queueName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, queue := range c.queues {
+		if queue.domainName == domainName &&
+			(queueName == "" || model.PartitionParent(queue.queueName) == queueName) {
+			delete(c.queues, key)
+		}
+	}
+}
+
+func (c *indexCompactor) start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(IndexCompactionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// runOnce compacts the queues past the threshold or left with a backlog
+func (c *indexCompactor) runOnce(ctx context.Context) {
+	c.mu.Lock()
+	var due []*queueCompaction
+	for _, queue := range c.queues {
+		if queue.consumes >= c.threshold || queue.stats.Backlog {
+			due = append(due, queue)
+			queue.consumes = 0
+		}
+	}
+	c.mu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	start := time.Now()
+	var removedTotal int64
+	for _, queue := range due {
+		safePosition := c.safePosition(ctx, queue.domainName, queue.queueName)
+		if safePosition <= 0 {
+			c.mu.Lock()
+			queue.stats.Backlog = false
+			c.mu.Unlock()
+			continue
+		}
+
+		removed, more := c.messageRepo.CleanupMessageIndices(ctx, queue.domainName, queue.queueName, safePosition, c.budget)
+		removedTotal += int64(removed)
+
+		c.mu.Lock()
+		queue.stats.IndicesRemoved += int64(removed)
+		queue.stats.SafePosition = safePosition
+		queue.stats.Backlog = more
+		queue.stats.LastRun = time.Now()
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.stats.Runs++
+	c.stats.IndicesRemoved += removedTotal
+	c.stats.LastRun = start
+	c.stats.LastDurationMs = float64(time.Since(start).Microseconds()) / 1000
+	c.mu.Unlock()
+
+	c.logger.Debug("Index compaction finished",
+		"queues", len(due),
+		"removed", removedTotal,
+		"duration", time.Since(start).String())
+}
+
+// safePosition is the lowest group position minus a margin, 0 when nothing can go
+func (c *indexCompactor) safePosition(ctx context.Context, domainName, queueName string) int64 {
+	groups, err := c.consumerGroupRepo.ListGroups(ctx, domainName, queueName)
+	if err != nil || len(groups) == 0 {
+		return 0
+	}
+
+	minPosition := int64(math.MaxInt64)
+	for _, groupID := range groups {
+		pos, err := c.consumerGroupRepo.GetPosition(ctx, domainName, queueName, groupID)
+		if err == nil && pos < minPosition && pos > 0 {
+			minPosition = pos
+		}
+	}
+	if minPosition == int64(math.MaxInt64) {
+		return 0
+	}
+	return max(minPosition-indexSafetyMargin, 0)
+}
+
+// Stats returns a copy of the compaction counters
+func (c *indexCompactor) Stats() model.IndexCompactionStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Queues = make(map[string]model.QueueCompactionStats, len(c.queues))
+	for key, queue := range c.queues {
+		queueStats := queue.stats
+		queueStats.PendingConsumes = queue.consumes
+		stats.Queues[key] = queueStats
+	}
+	return stats
+}
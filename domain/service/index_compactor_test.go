@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedPositionGroupRepo serves one group sitting at a fixed position
+type fixedPositionGroupRepo struct {
+	outbound.ConsumerGroupRepository
+	position int64
+}
+
+func (r *fixedPositionGroupRepo) ListGroups(ctx context.Context, domainName, queueName string) ([]string, error) {
+	return []string{"workers"}, nil
+}
+
+func (r *fixedPositionGroupRepo) GetPosition(ctx context.Context, domainName, queueName, groupID string) (int64, error) {
+	return r.position, nil
+}
+
+// budgetedIndexRepo simulates a sequential index cleanup honouring the budget
+type budgetedIndexRepo struct {
+	mockMessageRepository
+	floor int64
+	calls int
+}
+
+func (r *budgetedIndexRepo) CleanupMessageIndices(ctx context.Context, domainName, queueName string, minPosition int64, budget int) (int, bool) {
+	r.calls++
+	end := min(minPosition, r.floor+int64(budget))
+	removed := int(end - r.floor)
+	r.floor = end
+	return removed, end < minPosition
+}
+
+func TestIndexCompactorBudgetedRuns(t *testing.T) {
+	ctx := context.Background()
+	repo := &budgetedIndexRepo{}
+	compactor := newIndexCompactor(&mockLogger{}, repo, &fixedPositionGroupRepo{position: 260})
+	compactor.threshold = 3
+	compactor.budget = 100
+
+	// below the threshold the queue is left alone
+	compactor.markConsumed("shop", "orders")
+	compactor.markConsumed("shop", "orders")
+	compactor.runOnce(ctx)
+	assert.Equal(t, 0, repo.calls)
+
+	compactor.markConsumed("shop", "orders")
+	compactor.runOnce(ctx)
+	require.Equal(t, 1, repo.calls)
+
+	stats := compactor.Stats()
+	queue := stats.Queues["shop.orders"]
+	assert.Equal(t, int64(100), queue.IndicesRemoved)
+	assert.Equal(t, int64(250), queue.SafePosition, "keeps a margin below the slowest group")
+	assert.True(t, queue.Backlog)
+
+	// the backlog is worked off on the next runs without new consumes
+	compactor.runOnce(ctx)
+	compactor.runOnce(ctx)
+	compactor.runOnce(ctx)
+	assert.Equal(t, 3, repo.calls)
+
+	stats = compactor.Stats()
+	assert.Equal(t, int64(3), stats.Runs)
+	assert.Equal(t, int64(250), stats.IndicesRemoved)
+	assert.False(t, stats.Queues["shop.orders"].Backlog)
+	assert.Equal(t, 0, stats.Queues["shop.orders"].PendingConsumes)
+}
+
+func TestIndexCompactorForgetsDeletedQueues(t *testing.T) {
+	s := &MessageServiceImpl{
+		compactor: newIndexCompactor(&mockLogger{}, &budgetedIndexRepo{}, &fixedPositionGroupRepo{}),
+	}
+
+	for _, queue := range []string{"orders", "orders#0", "orders#1", "payments"} {
+		s.compactor.markConsumed("shop", queue)
+	}
+	s.compactor.markConsumed("billing", "invoices")
+
+	s.HandleEvent(model.DomainEvent{Kind: model.EventQueueDeleted, Domain: "shop", Queue: "orders"})
+	queues := s.GetIndexCompactionStats().Queues
+	assert.Len(t, queues, 2, "the queue and its partitions are forgotten")
+	assert.Contains(t, queues, "shop.payments")
+
+	s.HandleEvent(model.DomainEvent{Kind: model.EventDomainDeleted, Domain: "shop"})
+	queues = s.GetIndexCompactionStats().Queues
+	assert.Len(t, queues, 1)
+	assert.Contains(t, queues, "billing.invoices")
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// keyedMessage locates the latest message of a compaction key
+type keyedMessage struct {
+	storageQueue string
+	messageID    string
+}
+
+// keyCompaction remembers the latest message of each key in the compacted
+// queues. A queue is indexed from its stored messages the first time it is
+// published to, which also drops the duplicates left by a restart.
+type keyCompaction struct {
+	mu     sync.Mutex                         // serializes the stores of compacted queues
+	latest map[string]map[string]keyedMessage // "domain/queue" -> key -> latest
+}
+
+// replace records the latest message of a key and returns the message it
+// supersedes, mu must be held
+func (c *keyCompaction) replace(queueKey, key string, latest keyedMessage) (keyedMessage, bool) {
+	previous, exists := c.latest[queueKey][key]
+	c.latest[queueKey][key] = latest
+	return previous, exists && previous != latest
+}
+
+// indexed reports whether the queue was indexed and marks it as such, mu
+// must be held
+func (c *keyCompaction) indexed(queueKey string) bool {
+	if c.latest == nil {
+		c.latest = make(map[string]map[string]keyedMessage)
+	}
+	if _, exists := c.latest[queueKey]; exists {
+		return true
+	}
+	c.latest[queueKey] = make(map[string]keyedMessage)
+	return false
+}
+
+// storeMessage stores a published message. Compacted queues store one
+// message at a time, so the latest message of a key is the last stored, and
+// drop the messages it supersedes.
+func (s *MessageServiceImpl) storeMessage(
+	domainName string,
+	queue *model.Queue,
+	storageQueue string,
+	message, stored *model.Message,
+) error {
+	if queue.Config.Compaction == nil {
+		return s.messageRepo.StoreMessage(s.rootCtx, domainName, storageQueue, stored)
+	}
+
+	s.keyCompaction.mu.Lock()
+	defer s.keyCompaction.mu.Unlock()
+
+	if err := s.messageRepo.StoreMessage(s.rootCtx, domainName, storageQueue, stored); err != nil {
+		return err
+	}
+	s.compactByKey(domainName, queue, storageQueue, message)
+	return nil
+}
+
+// compactByKey removes the messages a stored message supersedes, mu must
+// be held
+func (s *MessageServiceImpl) compactByKey(
+	domainName string,
+	queue *model.Queue,
+	storageQueue string,
+	message *model.Message,
+) {
+	queueKey := domainName + "/" + queue.Name
+	if !s.keyCompaction.indexed(queueKey) {
+		s.indexCompactedQueue(domainName, queue)
+	}
+
+	key := queue.CompactionKey(message)
+	if key == "" {
+		return
+	}
+
+	previous, superseded := s.keyCompaction.replace(queueKey, key, keyedMessage{storageQueue: storageQueue, messageID: message.ID})
+	if superseded {
+		s.removeSuperseded(domainName, previous)
+	}
+}
+
+// indexCompactedQueue records the latest stored message of each key and
+// removes the older ones, mu must be held
+func (s *MessageServiceImpl) indexCompactedQueue(domainName string, queue *model.Queue) {
+	queueKey := domainName + "/" + queue.Name
+
+	for _, storageQueue := range queue.StorageQueues() {
+		count := s.messageRepo.GetQueueMessageCount(domainName, storageQueue)
+		if count == 0 {
+			continue
+		}
+		messages, err := s.messageRepo.GetMessagesAfterIndex(s.rootCtx, domainName, storageQueue, 0, count)
+		if err != nil {
+			s.logger.Warn("Compacted queue not indexed",
+				"queue", domainName+"."+storageQueue,
+				"ERROR", err)
+			continue
+		}
+
+		for _, stored := range messages {
+			resolved, err := s.claimCheck.resolve(s.rootCtx, stored)
+			if err != nil {
+				continue
+			}
+			key := queue.CompactionKey(resolved)
+			if key == "" {
+				continue
+			}
+			previous, superseded := s.keyCompaction.replace(queueKey, key, keyedMessage{storageQueue: storageQueue, messageID: stored.ID})
+			if superseded {
+				s.removeSuperseded(domainName, previous)
+			}
+		}
+	}
+}
+
+// removeSuperseded deletes a message replaced by a newer one of its key, it
+// may have been consumed in the meantime
+func (s *MessageServiceImpl) removeSuperseded(domainName string, superseded keyedMessage) {
+	ctx := context.Background()
+
+	stored, err := s.messageRepo.GetMessage(ctx, domainName, superseded.storageQueue, superseded.messageID)
+	if err != nil || stored == nil {
+		return
+	}
+	if err := s.messageRepo.DeleteMessage(ctx, domainName, superseded.storageQueue, superseded.messageID); err != nil {
+		return
+	}
+	if err := s.claimCheck.release(ctx, stored); err != nil {
+		s.logger.Warn("Large payload not released",
+			"message", superseded.messageID,
+			"ERROR", err)
+	}
+
+	s.logger.Debug("Message compacted",
+		"queue", domainName+"."+superseded.storageQueue,
+		"message", superseded.messageID)
+}
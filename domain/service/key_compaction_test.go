@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishCompactsByKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "iot",
+		Queues: map[string]*model.Queue{
+			"status": {Name: "status", DomainName: "iot", Config: model.QueueConfig{
+				Compaction: &model.CompactionConfig{Key: "device"},
+			}},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	// left by a previous run
+	messageRepo.StoreMessage(ctx, "iot", "status", &model.Message{ID: "old-1", Payload: []byte(`{"device":"d-1","status":"down"}`)})
+	messageRepo.StoreMessage(ctx, "iot", "status", &model.Message{ID: "old-2", Payload: []byte(`{"device":"d-1","status":"up"}`)})
+	messageRepo.StoreMessage(ctx, "iot", "status", &model.Message{ID: "old-3", Payload: []byte(`{"device":"d-3","status":"up"}`)})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+
+	publish := func(id, payload string, headers map[string]string) {
+		require.NoError(t, svc.PublishMessage("iot", "status", &model.Message{ID: id, Payload: []byte(payload), Headers: headers}))
+	}
+	publish("1", `{"device":"d-2","status":"up"}`, nil)
+	publish("2", `{"device":"d-1","status":"degraded"}`, nil)
+	publish("3", `{"device":"d-2","status":"down"}`, nil)
+	publish("4", `{"status":"up"}`, nil)
+	publish("5", `{"status":"down"}`, map[string]string{model.HeaderCompactionKey: "d-3"})
+
+	var ids []string
+	for _, message := range messageRepo.messages["iot:status"] {
+		ids = append(ids, message.ID)
+	}
+	assert.ElementsMatch(t, []string{"2", "3", "4", "5"}, ids, "only the latest message of each key is kept")
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// MoveMessage takes a message out of a queue and publishes it to another
+// queue of the domain, keeping its ID, headers and payload. The message is
+// removed from the source first, a failed publish puts it back, so it ends
+// up in exactly one of the queues.
+func (s *MessageServiceImpl) MoveMessage(
+	ctx context.Context,
+	domainName, queueName, messageID, destinationQueue string,
+) (*model.Message, error) {
+	if destinationQueue == "" {
+		return nil, fmt.Errorf("%w: destination queue is required", model.ErrInvalidMove)
+	}
+	if destinationQueue == queueName {
+		return nil, fmt.Errorf("%w: the message is already in %s", model.ErrInvalidMove, queueName)
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	source, exists := domain.Queues[queueName]
+	if !exists {
+		return nil, ErrQueueNotFound
+	}
+	if _, exists := domain.Queues[destinationQueue]; !exists {
+		return nil, fmt.Errorf("%w: destination queue %s not found", model.ErrInvalidMove, destinationQueue)
+	}
+
+	// a concurrent move of the same message must find it gone
+	s.moveMu.Lock()
+	defer s.moveMu.Unlock()
+
+	storageQueue, stored := s.findStoredMessage(ctx, domainName, source, messageID)
+	if stored == nil {
+		return nil, model.ErrMessageNotFound
+	}
+
+	resolved, err := s.claimCheck.resolve(ctx, stored)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrMoveFailed, err)
+	}
+	moved := &model.Message{
+		ID:        stored.ID,
+		Topic:     stored.Topic,
+		Payload:   resolved.Payload,
+		Headers:   maps.Clone(stored.Headers),
+		Metadata:  maps.Clone(stored.Metadata),
+		Timestamp: stored.Timestamp,
+	}
+	if moved.Metadata == nil {
+		moved.Metadata = make(map[string]any)
+	}
+	// set again by the destination
+	delete(moved.Metadata, claimCheckKey)
+	delete(moved.Metadata, claimCheckSizeKey)
+	delete(moved.Metadata, model.MetadataPartition)
+	// replayed out of a dead-letter queue, the message can be dead-lettered again
+	delete(moved.Metadata, model.MetadataDeadLetter)
+	moved.Metadata[model.MetadataMovedFrom] = queueName
+
+	if err := s.messageRepo.DeleteMessage(ctx, domainName, storageQueue, messageID); err != nil {
+		return nil, model.ErrMessageNotFound
+	}
+
+	if err := s.publishMessage(domainName, destinationQueue, moved); err != nil {
+		if restoreErr := s.messageRepo.StoreMessage(ctx, domainName, storageQueue, stored); restoreErr != nil {
+			s.logger.Error("Moved message lost, it couldn't be put back",
+				"queue", domainName+"."+queueName,
+				"message", messageID,
+				"ERROR", restoreErr)
+		}
+		return nil, fmt.Errorf("%w: %s: %v", model.ErrMoveFailed, destinationQueue, err)
+	}
+
+	// the source copy of a large payload isn't needed anymore
+	if err := s.claimCheck.release(ctx, stored); err != nil {
+		s.logger.Warn("Large payload not released",
+			"message", messageID,
+			"ERROR", err)
+	}
+
+	s.logger.Info("Message moved",
+		"message", messageID,
+		"source", domainName+"."+queueName,
+		"destination", domainName+"."+destinationQueue)
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessageMoved,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: messageID,
+		Data:      map[string]any{"destination": destinationQueue},
+	})
+	return moved, nil
+}
+
+// findStoredMessage looks for a message in the queue, or in its partitions
+func (s *MessageServiceImpl) findStoredMessage(
+	ctx context.Context,
+	domainName string,
+	queue *model.Queue,
+	messageID string,
+) (string, *model.Message) {
+	for _, storageQueue := range queue.StorageQueues() {
+		if message, err := s.messageRepo.GetMessage(ctx, domainName, storageQueue, messageID); err == nil && message != nil {
+			return storageQueue, message
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"triage": {Name: "triage", DomainName: "shop"},
+			"retry":  {Name: "retry", DomainName: "shop"},
+			// refuses publishes without field encryption
+			"vault": {Name: "vault", DomainName: "shop", Config: model.QueueConfig{EncryptedFields: []string{"card"}}},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, bus)
+
+	require.NoError(t, svc.PublishMessage("shop", "triage", &model.Message{
+		ID:      "order-1",
+		Payload: []byte(`{"card":"4111"}`),
+		Headers: map[string]string{"X-Request-ID": "req-1"},
+	}))
+
+	// a failed publish leaves the message where it was
+	_, err := svc.MoveMessage(ctx, "shop", "triage", "order-1", "vault")
+	assert.ErrorIs(t, err, model.ErrMoveFailed)
+	require.Len(t, messageRepo.messages["shop:triage"], 1)
+
+	moved, err := svc.MoveMessage(ctx, "shop", "triage", "order-1", "retry")
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", moved.ID)
+	assert.Empty(t, messageRepo.messages["shop:triage"])
+
+	stored := messageRepo.messages["shop:retry"]
+	require.Len(t, stored, 1)
+	assert.Equal(t, "order-1", stored[0].ID)
+	assert.Equal(t, "req-1", stored[0].Headers["X-Request-ID"])
+	assert.Equal(t, "triage", stored[0].Metadata[model.MetadataMovedFrom])
+	assert.Equal(t, "retry", stored[0].Metadata["queue"])
+
+	last := bus.events[len(bus.events)-1]
+	assert.Equal(t, model.EventMessageMoved, last.Kind)
+	assert.Equal(t, "retry", last.Data["destination"])
+
+	_, err = svc.MoveMessage(ctx, "shop", "triage", "order-1", "retry")
+	assert.ErrorIs(t, err, model.ErrMessageNotFound)
+	_, err = svc.MoveMessage(ctx, "shop", "retry", "order-1", "retry")
+	assert.ErrorIs(t, err, model.ErrInvalidMove)
+	_, err = svc.MoveMessage(ctx, "shop", "retry", "order-1", "missing")
+	assert.ErrorIs(t, err, model.ErrInvalidMove)
+}
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -16,15 +16,29 @@ import (
 )
 
 var (
-	ErrDomainNotFound     = errors.New("domain not found")
-	ErrQueueNotFound      = errors.New("queue not found")
-	ErrInvalidMessage     = errors.New("invalid message")
+	ErrDomainNotFound     = model.ErrDomainNotFound
+	ErrQueueNotFound      = model.ErrQueueNotFound
+	ErrInvalidMessage     = model.ErrInvalidMessage
 	ErrSubscriptionFailed = errors.New("subscription failed")
 )
 
 var _ model.MessageProvider = (*MessageServiceImpl)(nil)
 
+// publishMetadataEntries sizes the metadata of published messages: domain,
+// queue and partition
+const publishMetadataEntries = 3
+
+// consumerStatsRecorder is implemented by group repositories tracking per-consumer deliveries
+type consumerStatsRecorder interface {
+	RecordConsumerDelivery(ctx context.Context, domainName, queueName, groupID, consumerID string) error
+	RecordConsumerAck(ctx context.Context, domainName, queueName, groupID, consumerID string) error
+}
+
 type MessageServiceImpl struct {
+	eventEmitter
+	errorReporting
+	readReplicas
+
 	rootCtx           context.Context
 	logger            outbound.Logger
 	domainRepo        outbound.DomainRepository
@@ -32,11 +46,19 @@ type MessageServiceImpl struct {
 	consumerGroupRepo outbound.ConsumerGroupRepository
 	subscriptionReg   outbound.SubscriptionRegistry
 	queueService      inbound.QueueService
-	statsService      inbound.StatsService
-
-	// Periodic clean counter
-	messageCountSinceLastCleanup int
-	cleanupMu                    sync.Mutex
+	claimCheck        *claimCheck
+	fieldEncryption   *fieldEncryption
+	faults            *faultInjector
+	producerSessions  *model.ProducerSessions
+	compactor         *indexCompactor
+	partitionTurn     atomic.Uint64 // rotates the first partition consumed
+	moveMu            sync.Mutex    // serializes message moves
+	keyCompaction     keyCompaction
+	sequences         publishSequences
+	schemaRejections  *model.SchemaRejections
+	searchIndex       *model.SearchIndex
+	standby           *StandbyServiceImpl // refuses publishes and consumes until promoted
+	serviceUsage      *model.ServiceUsageTracker
 }
 
 func NewMessageService(
@@ -47,7 +69,6 @@ func NewMessageService(
 	consumerGroupRepo outbound.ConsumerGroupRepository,
 	subscriptionReg outbound.SubscriptionRegistry,
 	queueService inbound.QueueService,
-	statsService ...inbound.StatsService,
 ) inbound.MessageService {
 	impl := &MessageServiceImpl{
 		rootCtx:           rootCtx,
@@ -57,145 +78,572 @@ func NewMessageService(
 		consumerGroupRepo: consumerGroupRepo,
 		subscriptionReg:   subscriptionReg,
 		queueService:      queueService,
-	}
-
-	if len(statsService) > 0 {
-		impl.statsService = statsService[0]
+		producerSessions:  model.NewProducerSessions(model.ProducerSessionWindow, model.ProducerSessionTTL),
+		compactor:         newIndexCompactor(logger, messageRepo, consumerGroupRepo),
+		faults:            newFaultInjector(),
+		schemaRejections:  model.NewSchemaRejections(model.DefaultRejectionSampleSize),
+		serviceUsage:      model.NewServiceUsageTracker(),
 	}
 
 	// Start clean tasks
 	impl.startCleanupTasks(rootCtx)
+	impl.compactor.start(rootCtx)
 
 	return impl
 }
 
+// SetBlobStore enables claim-check storage for payloads larger than thresholdBytes
+func (s *MessageServiceImpl) SetBlobStore(store outbound.BlobStore, thresholdBytes int) {
+	s.claimCheck = &claimCheck{store: store, threshold: thresholdBytes}
+}
+
+// PublishMessage publishes once per producer sequence when the message
+// carries producer headers. A retried sequence returns ErrDuplicatePublish
+// and message.ID is set back to the ID stored the first time.
 func (s *MessageServiceImpl) PublishMessage(
 	domainName, queueName string,
 	message *model.Message,
+) error {
+	if s.readOnlyOnStandby(domainName) {
+		return model.ErrStandbyReadOnly
+	}
+	if err := s.faults.beforePublish(domainName, queueName); err != nil {
+		// an injected open circuit diverts as a real one does
+		if errors.Is(err, model.ErrInjectedCircuitOpen) {
+			if fallback := s.fallbackQueue(domainName, queueName, message); fallback != "" {
+				return s.divert(domainName, queueName, fallback, message)
+			}
+		}
+		return err
+	}
+
+	producerID, seq, inSession, err := model.ProducerSequence(message)
+	if err != nil {
+		return err
+	}
+	if !inSession {
+		if err := s.publishMessage(domainName, queueName, message); err != nil {
+			return err
+		}
+		s.recordPublished(message)
+		return nil
+	}
+
+	originalID, err := s.producerSessions.Accept(domainName, queueName, producerID, seq, message.ID)
+	if errors.Is(err, model.ErrDuplicatePublish) {
+		s.logger.Debug("Duplicate publish ignored",
+			"producer", producerID,
+			"seq", seq,
+			"message", originalID)
+		message.ID = originalID
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.publishMessage(domainName, queueName, message); err != nil {
+		// a stored message keeps its sequence, a retry would store it twice
+		var routed *routedCopyError
+		if !errors.As(err, &routed) {
+			s.producerSessions.Forget(domainName, queueName, producerID, seq)
+		}
+		return err
+	}
+	s.recordPublished(message)
+	return nil
+}
+
+// recordPublished counts a message in the usage of the service account
+// that published it
+func (s *MessageServiceImpl) recordPublished(message *model.Message) {
+	if serviceID := message.Publisher().ServiceID(); serviceID != "" {
+		s.serviceUsage.RecordPublished(serviceID, len(message.Payload), time.Now())
+	}
+}
+
+// GetServiceUsage returns what a service account published and consumed
+func (s *MessageServiceImpl) GetServiceUsage(serviceID string) model.ServiceUsage {
+	return s.serviceUsage.Usage(serviceID, time.Now())
+}
+
+// ListServiceUsage returns the traffic of every service account seen since
+// startup
+func (s *MessageServiceImpl) ListServiceUsage() []model.ServiceUsage {
+	return s.serviceUsage.All(time.Now())
+}
+
+// GetIndexCompactionStats reports the background index compaction
+func (s *MessageServiceImpl) GetIndexCompactionStats() model.IndexCompactionStats {
+	return s.compactor.Stats()
+}
+
+// HandleEvent forgets the index compaction of deleted queues
+func (s *MessageServiceImpl) HandleEvent(event model.DomainEvent) {
+	switch event.Kind {
+	case model.EventQueueDeleted:
+		s.compactor.forget(event.Domain, event.Queue)
+	case model.EventDomainDeleted:
+		s.compactor.forget(event.Domain, "")
+	}
+}
+
+// GetProducerSession returns the last sequence accepted for a producer
+func (s *MessageServiceImpl) GetProducerSession(domainName, queueName, producerID string) (model.ProducerSession, bool) {
+	return s.producerSessions.Get(domainName, queueName, producerID)
+}
+
+// GetSchemaRejections returns the sampled schema rejections of a queue,
+// newest first, and the number of rejections since startup
+func (s *MessageServiceImpl) GetSchemaRejections(domainName, queueName string) ([]model.SchemaRejection, int64) {
+	return s.schemaRejections.Samples(domainName, queueName)
+}
+
+func (s *MessageServiceImpl) publishMessage(
+	domainName, queueName string,
+	message *model.Message,
+) error {
+	return s.publish(domainName, queueName, message, true)
+}
+
+// publish stores and delivers a message, validate is only false for the
+// dead-letter copies of messages that may not match the schema
+func (s *MessageServiceImpl) publish(
+	domainName, queueName string,
+	message *model.Message,
+	validate bool,
 ) error {
 	domain, err := s.domainRepo.GetDomain(s.rootCtx, domainName)
 	if err != nil {
 		return ErrDomainNotFound
 	}
 
-	channelQueue, err := s.queueService.GetChannelQueue(s.rootCtx, domainName, queueName)
+	// Read-only queues are draining down, they take no new message
+	queue := domain.Queues[queueName]
+	if queue != nil && !queue.Config.Mode.AcceptsPublishes() {
+		return fmt.Errorf("%w: %s.%s", model.ErrQueueReadOnly, domainName, queueName)
+	}
+
+	// Partitioned queues store and deliver each message through its partition
+	storageQueue, partition := queueName, 0
+	if queue.Partitioned() {
+		partition = queue.Partition(message)
+		storageQueue = model.PartitionQueueName(queueName, partition)
+	}
+
+	channelQueue, err := s.queueService.GetChannelQueue(s.rootCtx, domainName, storageQueue)
 	if err != nil {
 		return ErrQueueNotFound
 	}
 
-	// Validate schema for message
-	if domain.Schema != nil && domain.Schema.Validation != nil {
-		if err := domain.Schema.Validation(message.Payload); err != nil {
-			return ErrInvalidMessage
-		}
-	} else if domain.Schema != nil && len(domain.Schema.Fields) > 0 {
-		// if no custom func, use field validation
-		var payload map[string]interface{}
-		if err := json.Unmarshal(message.Payload, &payload); err != nil {
-			return ErrInvalidMessage
+	// An open circuit would leave the message out of the queue
+	if fallback := queue.Fallback(message); fallback != "" {
+		if breaker, ok := channelQueue.(interface{ CircuitOpen() bool }); ok && breaker.CircuitOpen() {
+			return s.divert(domainName, queueName, fallback, message)
 		}
+	}
 
-		for fieldName, fieldType := range domain.Schema.Fields {
-			fieldValue, exists := payload[fieldName]
-			if !exists {
-				return ErrInvalidMessage
+	// Validate schema for message, rejections are kept for the producer to
+	// look at and dead-lettered when the queue asks for them
+	if validate {
+		if err := domain.Schema.Validate(message.Payload); err != nil {
+			var validationErr *model.SchemaValidationError
+			if errors.As(err, &validationErr) {
+				s.schemaRejections.Record(domainName, queueName, model.SchemaRejection{
+					MessageID:  message.ID,
+					Violations: validationErr.Violations,
+					RejectedAt: time.Now(),
+				})
+				s.DeadLetter(domainName, queueName, message, model.DeadLetterSchemaInvalid, err, 0)
 			}
+			return err
+		}
+	}
 
-			// Simplified type validation
-			switch fieldType {
-			case model.StringType:
-				if _, ok := fieldValue.(string); !ok {
-					return ErrInvalidMessage
-				}
-			case model.NumberType:
-				if _, ok := fieldValue.(float64); !ok {
-					return ErrInvalidMessage
-				}
-			case model.BooleanType:
-				if _, ok := fieldValue.(bool); !ok {
-					return ErrInvalidMessage
-				}
-			}
+	// Server-side values are injected after validation, so schemas don't
+	// have to declare them, and before sealing, so they can be sealed too
+	if queue != nil && len(queue.Config.Enrichment) > 0 {
+		if err := s.enrich(domainName, queueName, queue.Config.Enrichment, message); err != nil {
+			return err
 		}
 	}
 
-	// Add metadata
+	// Sensitive fields are sealed before the message is stored, routed or
+	// pushed to subscribers, predicates only see the other fields
+	if queue != nil {
+		if err := s.fieldEncryption.seal(domainName, queueName, queue.Config.EncryptedFields, message); err != nil {
+			return err
+		}
+	}
+
+	// Add metadata, sized once for the entries set here
 	if message.Metadata == nil {
-		message.Metadata = make(map[string]interface{})
+		message.Metadata = make(map[string]any, publishMetadataEntries)
 	}
 	message.Metadata["domain"] = domainName
 	message.Metadata["queue"] = queueName
+	if queue.Partitioned() {
+		message.Metadata[model.MetadataPartition] = partition
+	}
 
 	if message.Timestamp.IsZero() {
 		message.Timestamp = time.Now()
 	}
 
-	// Send to repository
-	if err := s.messageRepo.StoreMessage(s.rootCtx, domainName, queueName, message); err != nil {
+	// Large payloads are kept aside, routing and subscribers still see the full message
+	stored, err := s.claimCheck.offload(s.rootCtx, domainName, queueName, message)
+	if err != nil {
+		s.reportError(model.ErrorKindStorage, domainName, queueName, message, err)
 		return err
 	}
 
-	// Collect statistics
-	if s.statsService != nil {
-		s.statsService.TrackMessagePublished(domainName, queueName)
+	// Send to repository
+	if err := s.storeMessage(domainName, queue, storageQueue, message, stored); err != nil {
+		s.reportError(model.ErrorKindStorage, domainName, queueName, message, err)
+		s.claimCheck.release(s.rootCtx, stored)
+		return err
 	}
+	s.indexMessage(domain, queueName, storageQueue, message)
+
+	// Let stats, alerting... know about it
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessagePublished,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: message.ID,
+	})
 
 	// Enqueue message in chan queue
-	_ = channelQueue.Enqueue(s.rootCtx, message)
-
-	// Notify websockets
-	_ = s.subscriptionReg.NotifySubscribers(domainName, queueName, message)
-
-	// Apply routing rules
-	if routes, exists := domain.Routes[queueName]; exists {
-		for destQueue, rule := range routes {
-			// Convert predicate to correct type
-			var match bool
-
-			switch pred := rule.Predicate.(type) {
-			case model.PredicateFunc:
-				// Use function directly
-				match = pred(message)
-			case model.JSONPredicate:
-				// Evaluate JSON predicate
-				match = s.evaluateJSONPredicate(pred, message)
-			case map[string]any:
-				// Convert map to JSONPredicate
-				jsonPred := model.JSONPredicate{
-					Type:  fmt.Sprintf("%v", pred["type"]),
-					Field: fmt.Sprintf("%v", pred["field"]),
-					Value: pred["value"],
-				}
-				match = s.evaluateJSONPredicate(jsonPred, message)
-			default:
-				s.logger.Warn("Unknown predicate type", "predicate", rule.Predicate)
+	_ = channelQueue.Enqueue(s.rootCtx, stored)
+
+	// Notify websockets, write-only queues keep their messages for later
+	if queue == nil || queue.Config.Mode.AcceptsConsumes() {
+		_ = s.subscriptionReg.NotifySubscribers(domainName, queueName, message)
+	}
+
+	// Shadow copies never fail the publish they come from
+	if queue != nil && queue.Config.Mirror != nil {
+		s.mirror(domainName, queueName, queue.Config.Mirror, message)
+	}
+
+	// Apply routing rules, most domains have none
+	if len(domain.Routes) == 0 {
+		return nil
+	}
+	routes, exists := domain.Routes[queueName]
+	if !exists {
+		return nil
+	}
+
+	// rules run by priority, most queues have a handful of them
+	var buf [8]*model.RoutingRule
+	for _, rule := range model.AppendRulesInOrder(buf[:0], routes) {
+		if !rule.IsEnabled() {
+			continue
+		}
+
+		// Convert predicate to correct type
+		var match bool
+
+		switch pred := rule.Predicate.(type) {
+		case model.PredicateFunc:
+			// Use function directly
+			match = pred(message)
+		case model.JSONPredicate:
+			// Evaluate JSON predicate
+			match = s.evaluateJSONPredicate(pred, message)
+		case map[string]any:
+			// Convert map to JSONPredicate
+			jsonPred := model.JSONPredicate{
+				Type:  fmt.Sprintf("%v", pred["type"]),
+				Field: fmt.Sprintf("%v", pred["field"]),
+				Value: pred["value"],
 			}
+			match = s.evaluateJSONPredicate(jsonPred, message)
+		default:
+			s.logger.Warn("Unknown predicate type", "predicate", rule.Predicate)
+		}
 
-			if match {
-				// push a copy to queue
-				destMsg := *message
-				if err := s.PublishMessage(domainName, destQueue, &destMsg); err != nil {
-					return err
-				}
+		if !match {
+			continue
+		}
+		rule.RecordMatch(time.Now())
+
+		// canary rules send a share of the messages to another queue
+		target, canary := rule.Target(message.ID)
+
+		if rule.IsCrossDomain(domainName) {
+			// Other domains may change their grants or queues at any time,
+			// so a failed cross-domain hop must not fail the original publish
+			if err := s.routeCrossDomain(domainName, rule, target, message); err != nil {
+				s.logger.Warn("Cross-domain routing skipped",
+					"source", domainName+"."+queueName,
+					"destination", rule.DestinationDomain+"."+target,
+					"ERROR", err)
+			} else {
+				rule.RecordRouted(canary)
 			}
+		} else {
+			// push a copy to queue
+			if err := s.publishMessage(domainName, target, rule.RoutedCopy(message, domainName)); err != nil {
+				return &routedCopyError{err: err}
+			}
+			rule.RecordRouted(canary)
+		}
+
+		if rule.StopOnMatch {
+			break
 		}
-	} else {
-		s.logger.Debug("No routes found for queue", "queue", queueName)
 	}
 
 	return nil
 }
 
+// routedCopyError reports a routed copy that failed after the original
+// message was stored
+type routedCopyError struct {
+	err error
+}
+
+func (e *routedCopyError) Error() string { return e.err.Error() }
+func (e *routedCopyError) Unwrap() error { return e.err }
+
+// publishes a copy of the message into the target queue of another domain
+// after re-checking its grant
+func (s *MessageServiceImpl) routeCrossDomain(
+	sourceDomain string,
+	rule *model.RoutingRule,
+	target string,
+	message *model.Message,
+) error {
+	destDomain, err := s.domainRepo.GetDomain(s.rootCtx, rule.DestinationDomain)
+	if err != nil || destDomain == nil {
+		return ErrDomainNotFound
+	}
+	if !destDomain.AcceptsRoutesFrom(sourceDomain) {
+		return model.ErrCrossDomainRouteDenied
+	}
+
+	destMsg := rule.RoutedCopy(message, sourceDomain)
+	destMsg.Metadata["sourceDomain"] = sourceDomain
+
+	return s.publishMessage(rule.DestinationDomain, target, destMsg)
+}
+
+// mirror publishes the shadow copy of a sampled message, copies are not mirrored again
+func (s *MessageServiceImpl) mirror(domainName, queueName string, mirror *model.MirrorConfig, message *model.Message) {
+	if _, mirrored := message.Metadata[model.MetadataMirroredFrom]; mirrored || !mirror.Samples(message.ID) {
+		return
+	}
+
+	shadow, ok := mirror.ShadowCopy(message, queueName)
+	if !ok {
+		s.logger.Warn("Mirror skipped, the payload can't be scrubbed",
+			"source", domainName+"."+queueName,
+			"message", message.ID)
+		return
+	}
+
+	if err := s.publishMessage(domainName, mirror.Queue, shadow); err != nil {
+		s.logger.Warn("Mirror skipped",
+			"source", domainName+"."+queueName,
+			"shadow", domainName+"."+mirror.Queue,
+			"ERROR", err)
+	}
+}
+
+// fallbackQueue returns the fallback of the queue for the message
+func (s *MessageServiceImpl) fallbackQueue(domainName, queueName string, message *model.Message) string {
+	domain, err := s.domainRepo.GetDomain(s.rootCtx, domainName)
+	if err != nil || domain == nil {
+		return ""
+	}
+	return domain.Queues[queueName].Fallback(message)
+}
+
+// divert publishes to the fallback queue a message refused by an open circuit
+func (s *MessageServiceImpl) divert(domainName, queueName, fallback string, message *model.Message) error {
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]any)
+	}
+	message.Metadata[model.MetadataDivertedFrom] = queueName
+
+	if err := s.publishMessage(domainName, fallback, message); err != nil {
+		return fmt.Errorf("circuit open on %s, fallback queue %s: %w", queueName, fallback, err)
+	}
+
+	s.logger.Debug("Message diverted, circuit open",
+		"queue", domainName+"."+queueName,
+		"fallback", fallback,
+		"message", message.ID)
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessageDiverted,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: message.ID,
+		Data:      map[string]any{"fallback": fallback},
+	})
+	return nil
+}
+
 func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 	ctx context.Context,
 	domainName, queueName, groupID string,
 	options *inbound.ConsumeOptions,
 ) (*model.Message, error) {
-	now := time.Now()
 	if options == nil {
 		options = &inbound.ConsumeOptions{}
 	}
 
+	message, err := s.consumeMessage(ctx, domainName, queueName, groupID, options)
+	for message != nil && s.deadLetterExpired(ctx, domainName, queueName, message) {
+		message, err = s.consumeMessage(ctx, domainName, queueName, groupID, options)
+	}
+	if message != nil && options.ServiceID != "" {
+		s.serviceUsage.RecordConsumed(options.ServiceID, len(message.Payload), time.Now())
+	}
+	return message, err
+}
+
+func (s *MessageServiceImpl) consumeMessage(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	options *inbound.ConsumeOptions,
+) (*model.Message, error) {
+	if s.readOnlyOnStandby(domainName) {
+		return nil, model.ErrStandbyReadOnly
+	}
+	if err := s.faults.beforeConsume(ctx, domainName, queueName); err != nil {
+		return nil, err
+	}
+
+	if domain, err := s.domainRepo.GetDomain(ctx, domainName); err == nil && domain != nil {
+		queue := domain.Queues[queueName]
+		if queue != nil && !queue.Config.Mode.AcceptsConsumes() {
+			return nil, fmt.Errorf("%w: %s.%s", model.ErrQueueWriteOnly, domainName, queueName)
+		}
+		if queue != nil {
+			if err := s.implicitGroup(ctx, domainName, queue, groupID); err != nil {
+				return nil, err
+			}
+		}
+		if queue.Partitioned() {
+			return s.consumePartitions(ctx, domainName, queue, groupID, options)
+		}
+	}
+	return s.consumeQueue(ctx, domainName, queueName, groupID, options)
+}
+
+// implicitGroup refuses a group nobody created on a queue denying implicit
+// groups, on the others it reports the first consume of such a group
+func (s *MessageServiceImpl) implicitGroup(ctx context.Context, domainName string, queue *model.Queue, groupID string) error {
+	if s.getGroupDetails(ctx, domainName, queue.Name, groupID) != nil {
+		return nil
+	}
+	if !queue.Config.ImplicitGroups.Allows() {
+		return fmt.Errorf("%w: %s", model.ErrImplicitGroupDenied, groupID)
+	}
+
+	if queue.Partitioned() {
+		// the partitioned queue keeps no group state of its own, the
+		// repository remembers the group was seen
+		if err := s.consumerGroupRepo.RegisterConsumer(ctx, domainName, queue.Name, groupID, ""); err != nil {
+			return err
+		}
+	} else {
+		channelQueue, err := s.queueService.GetChannelQueue(ctx, domainName, queue.Name)
+		if err != nil {
+			return nil
+		}
+		if chQueue, ok := channelQueue.(*model.ChannelQueue); !ok || chQueue.HasConsumerGroup(groupID) {
+			return nil
+		}
+	}
+
+	s.logger.Debug("Consumer group created implicitly",
+		"queue", domainName+"."+queue.Name,
+		"group", groupID)
+	s.emit(model.DomainEvent{
+		Kind:    model.EventGroupImplicit,
+		Domain:  domainName,
+		Queue:   queue.Name,
+		GroupID: groupID,
+	})
+	return nil
+}
+
+// consumePartitions reads the next message of the partitions assigned to the
+// consumer, the partitions of a queue are dealt among the consumers of the
+// group so each one reads its own
+func (s *MessageServiceImpl) consumePartitions(
+	ctx context.Context,
+	domainName string,
+	queue *model.Queue,
+	groupID string,
+	options *inbound.ConsumeOptions,
+) (*model.Message, error) {
+	group := s.getGroupDetails(ctx, domainName, queue.Name, groupID)
+	if group != nil && group.DeliveryMode == model.GroupDeliveryBroadcast {
+		return nil, model.ErrPartitionedBroadcast
+	}
+
+	var consumerIDs []string
+	if options.ConsumerID != "" {
+		if err := s.consumerGroupRepo.RegisterConsumer(ctx, domainName, queue.Name, groupID, options.ConsumerID); err != nil {
+			return nil, err
+		}
+		if group = s.getGroupDetails(ctx, domainName, queue.Name, groupID); group != nil {
+			consumerIDs = group.ConsumerIDs
+		}
+	}
+
+	timeout := 1 * time.Second
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+
+	assigned := model.AssignPartitions(queue.Config.Partitions, consumerIDs, options.ConsumerID)
+	if len(assigned) == 0 {
+		// more consumers than partitions, this one idles
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(timeout):
+			return nil, nil
+		}
+	}
+
+	// each partition gets its share of the wait, starting from a different
+	// one every call so a busy partition doesn't starve the others
+	partitionOptions := *options
+	partitionOptions.Timeout = max(timeout/time.Duration(len(assigned)), 10*time.Millisecond)
+	start := int(s.partitionTurn.Add(1))
+	for i := range assigned {
+		partition := model.PartitionQueueName(queue.Name, assigned[(start+i)%len(assigned)])
+
+		// the group keeps a position per partition
+		if err := s.consumerGroupRepo.RegisterConsumer(ctx, domainName, partition, groupID, ""); err != nil {
+			return nil, err
+		}
+		message, err := s.consumeQueue(ctx, domainName, partition, groupID, &partitionOptions)
+		if err != nil || message != nil {
+			return message, err
+		}
+	}
+	return nil, nil
+}
+
+// consumeQueue reads the next message of a queue or partition for the group
+func (s *MessageServiceImpl) consumeQueue(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	options *inbound.ConsumeOptions,
+) (*model.Message, error) {
+	now := time.Now()
+
+	// activity, consumer stats and events are those of the partitioned queue
+	reportedQueue := model.PartitionParent(queueName)
+
 	channelQueue, err := s.queueService.GetChannelQueue(ctx, domainName, queueName)
 	if err != nil {
 		return nil, err
@@ -220,8 +668,32 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 		_ = s.consumerGroupRepo.RegisterConsumer(ctx, domainName, queueName, groupID, options.ConsumerID)
 	}
 
+	// Broadcast groups hand every message to each of their consumers
+	mode := model.GroupDeliveryShared
+	group := s.getGroupDetails(ctx, domainName, queueName, groupID)
+	if group != nil && group.DeliveryMode == model.GroupDeliveryBroadcast {
+		mode = model.GroupDeliveryBroadcast
+	}
+	chQueue.SetGroupDeliveryMode(groupID, mode)
+	if group != nil {
+		chQueue.SetGroupPriority(groupID, group.Priority)
+		chQueue.SetGroupPrefetch(groupID, group.Prefetch)
+	}
+
+	if mode == model.GroupDeliveryBroadcast {
+		if options.ConsumerID == "" {
+			return nil, model.ErrConsumerIDRequired
+		}
+		chQueue.SyncGroupConsumers(groupID, group.ConsumerIDs)
+		return s.consumeBroadcast(ctx, chQueue, domainName, queueName, groupID, options)
+	}
+
 	// Check group chan for messages
-	message, err := chQueue.ConsumeMessage(groupID, 10*time.Millisecond)
+	message, err := chQueue.ConsumeMessage(ctx, groupID, 10*time.Millisecond)
+	if ctx.Err() != nil {
+		// the consumer left or its deadline passed
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		s.logger.Error("ConsumeMessageWithGroup chQueue.ConsumeMessage",
 			"duration", time.Since(now).String(),
@@ -230,12 +702,15 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 	}
 
 	if message == nil {
-		maxCount := 5
+		// If no messages send command, the queue caps it to the group credit
+		maxCount := model.DefaultGroupPrefetch
+		if group != nil {
+			maxCount = group.EffectivePrefetch()
+		}
 		if options.MaxCount > 0 {
 			maxCount = options.MaxCount
 		}
-		// If no messages send command
-		channelQueue.RequestMessages(groupID, maxCount)
+		channelQueue.RequestMessages(ctx, groupID, maxCount)
 
 		timeout := 1 * time.Second
 		if options != nil && options.Timeout > 0 {
@@ -243,7 +718,10 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 		}
 
 		// [CHECK] Waits for a message with full timeout duration = not working
-		message, err = chQueue.ConsumeMessage(groupID, timeout)
+		message, err = chQueue.ConsumeMessage(ctx, groupID, timeout)
+		if message == nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil {
 			s.logger.Error("ConsumeMessageWithGroup chQueue.ConsumeMessage",
 				"duration", time.Since(now).String(),
@@ -254,17 +732,44 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 	}
 
 	// msg found -> auto ack update Pos
+	delivered := message
 	if message != nil {
+		// the message left the group channel, its position is stored even
+		// if the consumer leaves now
+		ctx = context.WithoutCancel(ctx)
+
+		// load a large payload before the ack below can release its blob
+		delivered, err = s.claimCheck.resolve(ctx, message)
+		if err != nil {
+			s.logger.Error("ConsumeMessageWithGroup resolving large payload",
+				"message", message.ID,
+				"ERROR", err)
+			return nil, err
+		}
+
 		if repo, ok := s.consumerGroupRepo.(interface {
 			UpdateLastActivity(ctx context.Context, domainName, queueName, groupID string) error
 		}); ok {
-			if err = repo.UpdateLastActivity(ctx, domainName, queueName, groupID); err != nil {
+			if err = repo.UpdateLastActivity(ctx, domainName, reportedQueue, groupID); err != nil {
 				s.logger.Error("ConsumeMessageWithGroup updating last activity",
 					"duration", time.Since(now).String(),
 					"ERROR", err)
 			}
 		}
 
+		consumerID := ""
+		if options != nil {
+			consumerID = options.ConsumerID
+		}
+		recorder, tracksConsumers := s.consumerGroupRepo.(consumerStatsRecorder)
+		if tracksConsumers && consumerID != "" {
+			if err := recorder.RecordConsumerDelivery(ctx, domainName, reportedQueue, groupID, consumerID); err != nil {
+				s.logger.Warn("ConsumeMessageWithGroup recording delivery",
+					"consumer", consumerID,
+					"ERROR", err)
+			}
+		}
+
 		index, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, queueName, message.ID)
 		if err != nil {
 			s.logger.Error("ConsumeMessageWithGroup s.messageRepo.GetIndexByMessageID",
@@ -277,6 +782,7 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 				s.logger.Error("ConsumeMessageWithGroup StorePosition",
 					"duration", time.Since(now).String(),
 					"ERROR", err)
+				s.reportError(model.ErrorKindStorage, domainName, queueName, message, err)
 				return nil, err
 			}
 
@@ -287,6 +793,7 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 		// Elevate post treatment to asynchronous execution with new dedicated ctx
 		bgCtx := context.Background()
 		msgCopy := *message // Copy used to avoid race conditions
+		waited := time.Since(message.Timestamp)
 
 		go func(
 			ctx context.Context,
@@ -299,10 +806,17 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 				s.logger.Error("ConsumeMessageWithGroup AcknowledgeMessage",
 					"duration", time.Since(now).String(),
 					"ERROR", err)
+			} else if tracksConsumers && consumerID != "" {
+				recorder.RecordConsumerAck(ctx, domainName, reportedQueue, groupID, consumerID)
 			}
 
 			// delete if fully ack
 			if fullyAcked {
+				if err := s.claimCheck.release(ctx, &msgCopy); err != nil {
+					s.logger.Warn("Large payload not released",
+						"message", message.ID,
+						"ERROR", err)
+				}
 				if err := s.messageRepo.DeleteMessage(ctx, domainName, queueName, message.ID); err != nil {
 					// Ignore "message not found" error
 					if err.Error() == "message not found" {
@@ -317,40 +831,21 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 			}
 
 			// statistics
-			if s.statsService != nil {
-				s.statsService.TrackMessageConsumed(domainName, queueName)
+			s.emit(model.DomainEvent{
+				Kind:      model.EventMessageConsumed,
+				Domain:    domainName,
+				Queue:     reportedQueue,
+				MessageID: messageID,
+				GroupID:   groupID,
+				Data:      map[string]any{"waited": waited},
+			})
+			if fullyAcked {
+				s.emitAcknowledged(domainName, reportedQueue, &msgCopy)
 			}
 
-			// thread-safe counter increase
-			s.cleanupMu.Lock()
-			s.messageCountSinceLastCleanup++
-			shouldCleanup := s.messageCountSinceLastCleanup >= 100
-			if shouldCleanup {
-				s.messageCountSinceLastCleanup = 0
-			}
-			s.cleanupMu.Unlock()
-
-			// Clean indexs
-			if shouldCleanup {
-				// Find minimal pos cross group
-				minPosition := int64(math.MaxInt64)
-				groups, err := s.consumerGroupRepo.ListGroups(ctx, domainName, queueName)
-				if err == nil && len(groups) > 0 {
-					for _, gID := range groups {
-						pos, err := s.consumerGroupRepo.GetPosition(ctx, domainName, queueName, gID)
-						if err == nil && pos < minPosition && pos > 0 {
-							minPosition = pos
-						}
-					}
+			// indices are compacted in the background
+			s.compactor.markConsumed(domainName, queueName)
 
-					if minPosition < int64(math.MaxInt64) {
-						safePosition := minPosition - 10 // Keep a secutiry margin
-						if safePosition > 0 {
-							s.messageRepo.CleanupMessageIndices(ctx, domainName, queueName, safePosition)
-						}
-					}
-				}
-			}
 			s.logger.Debug("ConsumeMessageWithGroup Post Treatment Finished",
 				"duration", time.Since(now).String())
 		}(bgCtx, domainName, queueName, groupID, msgCopy.ID, now)
@@ -359,7 +854,148 @@ func (s *MessageServiceImpl) ConsumeMessageWithGroup(
 	s.logger.Debug("ConsumeMessageWithGroup Finished",
 		"duration", time.Since(now).String())
 
-	return message, nil
+	return delivered, nil
+}
+
+// reads the group through the repository when it exposes details
+func (s *MessageServiceImpl) getGroupDetails(ctx context.Context, domainName, queueName, groupID string) *model.ConsumerGroup {
+	repo, ok := s.consumerGroupRepo.(interface {
+		GetGroupDetails(ctx context.Context, domainName, queueName, groupID string) (*model.ConsumerGroup, error)
+	})
+	if !ok {
+		return nil
+	}
+
+	group, err := repo.GetGroupDetails(ctx, domainName, queueName, groupID)
+	if err != nil {
+		return nil
+	}
+	return group
+}
+
+// consumeBroadcast delivers the next message from the consumer's own cursor.
+// The group only acknowledges messages once its slowest consumer got them.
+func (s *MessageServiceImpl) consumeBroadcast(
+	ctx context.Context,
+	chQueue *model.ChannelQueue,
+	domainName, queueName, groupID string,
+	options *inbound.ConsumeOptions,
+) (*model.Message, error) {
+	consumerID := options.ConsumerID
+
+	timeout := 1 * time.Second
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+
+	message, err := chQueue.ConsumeBroadcast(ctx, groupID, consumerID, timeout)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	// load a large payload before the release below can drop its blob
+	delivered, err := s.claimCheck.resolve(ctx, message)
+	if err != nil {
+		s.logger.Error("consumeBroadcast resolving large payload", "message", message.ID, "ERROR", err)
+		chQueue.ReleaseBroadcastMessage(groupID, consumerID, message.ID)
+		return nil, err
+	}
+
+	// without its position the cursor can't move past the message, which
+	// is left for a later consume rather than handed out twice
+	index, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, queueName, message.ID)
+	if err != nil {
+		s.logger.Error("consumeBroadcast GetIndexByMessageID", "ERROR", err)
+		chQueue.ReleaseBroadcastMessage(groupID, consumerID, message.ID)
+		return nil, err
+	}
+
+	if err := s.consumerGroupRepo.UpdateLastActivity(ctx, domainName, queueName, groupID); err != nil {
+		s.logger.Error("consumeBroadcast updating last activity", "ERROR", err)
+	}
+
+	recorder, tracksConsumers := s.consumerGroupRepo.(consumerStatsRecorder)
+	if tracksConsumers {
+		recorder.RecordConsumerDelivery(ctx, domainName, queueName, groupID, consumerID)
+	}
+
+	oldMin, newMin := chQueue.AdvanceConsumerCursor(groupID, consumerID, message.ID, index+1)
+	if tracksConsumers {
+		recorder.RecordConsumerAck(ctx, domainName, queueName, groupID, consumerID)
+	}
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessageConsumed,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: message.ID,
+		GroupID:   groupID,
+		Data:      map[string]any{"waited": time.Since(message.Timestamp)},
+	})
+
+	if newMin > oldMin {
+		if err := s.consumerGroupRepo.StorePosition(ctx, domainName, queueName, groupID, newMin); err != nil {
+			s.logger.Error("consumeBroadcast StorePosition", "ERROR", err)
+			return delivered, nil
+		}
+		go s.releaseBroadcastMessages(context.Background(), domainName, queueName, groupID, oldMin, newMin)
+	}
+
+	return delivered, nil
+}
+
+// acknowledges, for the group, the messages every broadcast consumer has read
+func (s *MessageServiceImpl) releaseBroadcastMessages(
+	ctx context.Context,
+	domainName, queueName, groupID string,
+	fromIndex, toIndex int64,
+) {
+	messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, queueName, fromIndex, int(toIndex-fromIndex))
+	if err != nil {
+		s.logger.Error("releaseBroadcastMessages GetMessagesAfterIndex", "ERROR", err)
+		return
+	}
+
+	for _, msg := range messages {
+		index, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, queueName, msg.ID)
+		if err != nil || index >= toIndex {
+			continue
+		}
+
+		fullyAcked, err := s.messageRepo.AcknowledgeMessage(ctx, domainName, queueName, groupID, msg.ID)
+		if err != nil {
+			s.logger.Error("releaseBroadcastMessages AcknowledgeMessage",
+				"message", msg.ID,
+				"ERROR", err)
+			continue
+		}
+
+		if fullyAcked {
+			if err := s.claimCheck.release(ctx, msg); err != nil {
+				s.logger.Warn("releaseBroadcastMessages release payload",
+					"message", msg.ID,
+					"ERROR", err)
+			}
+			if err := s.messageRepo.DeleteMessage(ctx, domainName, queueName, msg.ID); err != nil {
+				s.logger.Warn("releaseBroadcastMessages DeleteMessage",
+					"message", msg.ID,
+					"ERROR", err)
+			}
+			s.emitAcknowledged(domainName, queueName, msg)
+		}
+	}
+}
+
+// emitAcknowledged reports a message acknowledged by every consumer group,
+// with the time it waited for it
+func (s *MessageServiceImpl) emitAcknowledged(domainName, queueName string, message *model.Message) {
+	s.emit(model.DomainEvent{
+		Kind:      model.EventMessageAcknowledged,
+		Domain:    domainName,
+		Queue:     queueName,
+		MessageID: message.ID,
+		Data:      map[string]any{"waited": time.Since(message.Timestamp)},
+	})
 }
 
 func (s *MessageServiceImpl) GetMessagesAfterIndex(
@@ -371,6 +1007,46 @@ func (s *MessageServiceImpl) GetMessagesAfterIndex(
 	return s.messageRepo.GetMessagesAfterIndex(ctx, domainName, queueName, startIndex, limit)
 }
 
+// SampleMessages returns up to count of the most recent messages stored in
+// a queue, oldest first, with their offloaded payloads loaded back
+func (s *MessageServiceImpl) SampleMessages(
+	ctx context.Context,
+	domainName, queueName string,
+	count int,
+) ([]*model.Message, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	if _, exists := domain.Queues[queueName]; !exists {
+		return nil, ErrQueueNotFound
+	}
+
+	stored := s.messageRepo.GetQueueMessageCount(domainName, queueName)
+	if stored == 0 || count <= 0 {
+		return []*model.Message{}, nil
+	}
+
+	messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, queueName, 0, stored)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > count {
+		messages = messages[len(messages)-count:]
+	}
+
+	samples := make([]*model.Message, 0, len(messages))
+	for _, message := range messages {
+		resolved, err := s.claimCheck.resolve(ctx, message)
+		if err != nil {
+			s.logger.Warn("SampleMessages skipped a message", "id", message.ID, "ERROR", err)
+			continue
+		}
+		samples = append(samples, resolved)
+	}
+	return samples, nil
+}
+
 func (s *MessageServiceImpl) SubscribeToQueue(
 	domainName, queueName string,
 	handler model.MessageHandler,
@@ -399,6 +1075,26 @@ func (s *MessageServiceImpl) UnsubscribeFromQueue(
 	return s.subscriptionReg.UnregisterSubscription(subscriptionID)
 }
 
+// GetSubscriberStats reports the handler latency, failures and quarantine
+// of the subscribers of a queue
+func (s *MessageServiceImpl) GetSubscriberStats(ctx context.Context, domainName, queueName string) ([]model.SubscriberStats, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	if _, exists := domain.Queues[queueName]; !exists {
+		return nil, ErrQueueNotFound
+	}
+
+	registry, ok := s.subscriptionReg.(interface {
+		SubscriberStats(domainName, queueName string) []model.SubscriberStats
+	})
+	if !ok {
+		return []model.SubscriberStats{}, nil
+	}
+	return registry.SubscriberStats(domainName, queueName), nil
+}
+
 func (s *MessageServiceImpl) evaluateJSONPredicate(predicate model.JSONPredicate, message *model.Message) bool {
 	var payload map[string]interface{}
 	if err := json.Unmarshal(message.Payload, &payload); err != nil {
@@ -463,13 +1159,7 @@ func (s *MessageServiceImpl) startCleanupTasks(ctx context.Context) {
 			case <-ticker.C:
 				inactivityMu.Lock()
 
-				domains, err := s.domainRepo.ListDomains(ctx)
-				if err != nil {
-					inactivityMu.Unlock()
-					continue
-				}
-
-				for _, domain := range domains {
+				forEachDomain(ctx, s.domainRepo, func(domain *model.Domain) {
 					if _, exists := queueInactivity[domain.Name]; !exists {
 						queueInactivity[domain.Name] = make(map[string]*QueueInactivity)
 					}
@@ -502,6 +1192,7 @@ func (s *MessageServiceImpl) startCleanupTasks(ctx context.Context) {
 
 							messages, _ := s.messageRepo.GetMessagesAfterIndex(ctx, domain.Name, queueName, 0, 1000)
 							for _, msg := range messages {
+								_ = s.claimCheck.release(ctx, msg)
 								_ = s.messageRepo.DeleteMessage(ctx, domain.Name, queueName, msg.ID)
 							}
 
@@ -511,7 +1202,7 @@ func (s *MessageServiceImpl) startCleanupTasks(ctx context.Context) {
 							inactivityInfo.checked = true
 						}
 					}
-				}
+				})
 
 				inactivityMu.Unlock()
 			}
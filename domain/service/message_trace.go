@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// maxTraceHops bounds the trace of messages caught in a routing loop
+const maxTraceHops = 32
+
+// TraceMessage returns the tree of the copies routing made of a message,
+// following the routing rules from its queue. Copies are found by their
+// derived IDs, so the trace shows the copies still stored, and the ones
+// consumed since when copies were made from them.
+func (s *MessageServiceImpl) TraceMessage(
+	ctx context.Context,
+	domainName, queueName, messageID string,
+) (*model.MessageTrace, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return nil, ErrQueueNotFound
+	}
+
+	root := &model.MessageTrace{Domain: domainName, Queue: queueName, MessageID: messageID}
+	original := messageID
+	if _, stored := s.findStoredMessage(ctx, domainName, queue, messageID); stored != nil {
+		root.Stored = true
+		root.Hop = model.RoutingHops(stored)
+		original = model.OriginalMessageID(stored)
+	}
+
+	s.traceCopies(ctx, domain, root, original, make(map[string]bool))
+	if root.Count() == 0 {
+		return nil, model.ErrMessageNotFound
+	}
+	return root, nil
+}
+
+// traceCopies adds to the node the copies the routing rules of its queue
+// made of it. A queue holds a single copy per hop, the visited ones are
+// skipped so that routing loops don't blow the trace up.
+func (s *MessageServiceImpl) traceCopies(
+	ctx context.Context,
+	domain *model.Domain,
+	node *model.MessageTrace,
+	original string,
+	visited map[string]bool,
+) {
+	if node.Hop >= maxTraceHops {
+		return
+	}
+	copyID := model.RoutedMessageID(original, node.Hop+1)
+
+	for _, rule := range model.AppendRulesInOrder(nil, domain.Routes[node.Queue]) {
+		destination := domain
+		if rule.IsCrossDomain(domain.Name) {
+			other, err := s.domainRepo.GetDomain(ctx, rule.DestinationDomain)
+			if err != nil || other == nil {
+				continue
+			}
+			destination = other
+		}
+
+		// canary rules may have sent the copy to either queue
+		targets := []string{rule.DestinationQueue}
+		if rule.Canary != nil {
+			targets = append(targets, rule.Canary.Queue)
+		}
+		for _, target := range targets {
+			queue, exists := destination.Queues[target]
+			key := destination.Name + ":" + target + ":" + copyID
+			if !exists || visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			child := &model.MessageTrace{
+				Domain:    destination.Name,
+				Queue:     target,
+				MessageID: copyID,
+				Hop:       node.Hop + 1,
+				Rule:      rule.ID(domain.Name),
+			}
+			// another path of the same length may have copied a message
+			// with the same ID into the queue
+			if _, stored := s.findStoredMessage(ctx, destination.Name, queue, copyID); stored != nil &&
+				stored.Metadata[model.MetadataParentID] == node.MessageID &&
+				stored.Metadata[model.MetadataSourceQueue] == node.Queue {
+				child.Stored = true
+			}
+
+			s.traceCopies(ctx, destination, child, original, visited)
+			if child.Count() > 0 {
+				node.Copies = append(node.Copies, child)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders":  {Name: "orders", DomainName: "shop"},
+			"billing": {Name: "billing", DomainName: "shop"},
+			"audit":   {Name: "audit", DomainName: "shop"},
+			"archive": {Name: "archive", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+	routing := NewRoutingService(domainRepo, ctx)
+
+	all := model.JSONPredicate{Type: "contains", Field: "total", Value: ""}
+	for _, rule := range []*model.RoutingRule{
+		{SourceQueue: "orders", DestinationQueue: "billing", Predicate: all},
+		{SourceQueue: "orders", DestinationQueue: "audit", Predicate: all},
+		{SourceQueue: "billing", DestinationQueue: "archive", Predicate: all},
+	} {
+		require.NoError(t, routing.AddRoutingRule(ctx, "shop", rule))
+	}
+
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "o-1", Payload: []byte(`{"total":"10"}`)}))
+
+	// copies get their own IDs and point at their parent
+	archived := messageRepo.messages["shop:archive"]
+	require.Len(t, archived, 1)
+	assert.Equal(t, "o-1~2", archived[0].ID)
+	assert.Equal(t, "o-1", archived[0].Metadata[model.MetadataOriginalID])
+	assert.Equal(t, "o-1~1", archived[0].Metadata[model.MetadataParentID])
+
+	trace, err := svc.TraceMessage(ctx, "shop", "orders", "o-1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, trace.Count())
+	require.Len(t, trace.Copies, 2)
+
+	// a consumed copy stays in the tree while copies made of it are stored
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "billing", "o-1~1"))
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "audit", "o-1~1"))
+	trace, err = svc.TraceMessage(ctx, "shop", "orders", "o-1")
+	require.NoError(t, err)
+	require.Len(t, trace.Copies, 1)
+	billing := trace.Copies[0]
+	assert.Equal(t, "billing", billing.Queue)
+	assert.False(t, billing.Stored)
+	require.Len(t, billing.Copies, 1)
+	assert.Equal(t, model.MessageTrace{
+		Domain: "shop", Queue: "archive", MessageID: "o-1~2", Hop: 2, Rule: "billing->archive", Stored: true,
+	}, *billing.Copies[0])
+
+	// tracing a copy shows the copies made from it
+	trace, err = svc.TraceMessage(ctx, "shop", "archive", "o-1~2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, trace.Hop)
+	assert.Empty(t, trace.Copies)
+
+	_, err = svc.TraceMessage(ctx, "shop", "orders", "unknown")
+	assert.ErrorIs(t, err, model.ErrMessageNotFound)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// MetricSource hands the metric samples collected after a time
+type MetricSource interface {
+	MetricSamples(since time.Time) []model.MetricSample
+}
+
+// MetricSamples returns the message rates collected after since, and the
+// queue snapshots updated after it
+func (s *StatsServiceImpl) MetricSamples(since time.Time) []model.MetricSample {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+
+	var samples []model.MetricSample
+	for _, rate := range s.metrics.messageRates {
+		at := time.Unix(rate.Timestamp, 0)
+		if !at.After(since) {
+			continue
+		}
+		samples = append(samples,
+			model.MetricSample{Name: "gortms_publish_rate", Value: rate.Published, Time: at},
+			model.MetricSample{Name: "gortms_consume_rate", Value: rate.Consumed, Time: at},
+		)
+	}
+
+	for _, snapshot := range s.metrics.queueSnapshots {
+		if !snapshot.LastUpdated.After(since) {
+			continue
+		}
+		labels := map[string]string{"domain": snapshot.Domain, "queue": snapshot.Queue}
+		at := snapshot.LastUpdated
+		samples = append(samples,
+			model.MetricSample{Name: "gortms_queue_messages", Labels: labels, Value: float64(snapshot.RepositoryCount), Time: at},
+			model.MetricSample{Name: "gortms_queue_buffer_usage_percent", Labels: labels, Value: snapshot.BufferUsage, Time: at},
+			model.MetricSample{Name: "gortms_queue_publish_rate", Labels: labels, Value: snapshot.PublishRate, Time: at},
+			model.MetricSample{Name: "gortms_queue_consume_rate", Labels: labels, Value: snapshot.ConsumeRate, Time: at},
+			model.MetricSample{Name: "gortms_queue_bytes", Labels: labels, Value: float64(snapshot.Bytes), Time: at},
+			model.MetricSample{Name: "gortms_queue_diverted_total", Labels: labels, Value: float64(snapshot.Diverted), Time: at},
+			model.MetricSample{Name: "gortms_queue_implicit_groups_total", Labels: labels, Value: float64(snapshot.ImplicitGroups), Time: at},
+		)
+		samples = appendWaitSamples(samples, "gortms_queue_delivery_wait_seconds", labels, snapshot.DeliveryWait, at)
+		samples = appendWaitSamples(samples, "gortms_queue_ack_wait_seconds", labels, snapshot.AckWait, at)
+	}
+	return samples
+}
+
+// appendWaitSamples appends a wait histogram as Prometheus histogram
+// series: the _bucket series by le bound, then _sum and _count
+func appendWaitSamples(
+	samples []model.MetricSample,
+	name string,
+	labels map[string]string,
+	histogram *model.WaitHistogram,
+	at time.Time,
+) []model.MetricSample {
+	if histogram == nil {
+		return samples
+	}
+	bucket := func(le string, count int64) model.MetricSample {
+		bucketLabels := make(map[string]string, len(labels)+1)
+		maps.Copy(bucketLabels, labels)
+		bucketLabels["le"] = le
+		return model.MetricSample{Name: name + "_bucket", Labels: bucketLabels, Value: float64(count), Time: at}
+	}
+	for _, b := range histogram.Buckets {
+		samples = append(samples, bucket(strconv.FormatFloat(b.Le, 'g', -1, 64), b.Count))
+	}
+	return append(samples,
+		bucket("+Inf", histogram.Count),
+		model.MetricSample{Name: name + "_sum", Labels: labels, Value: histogram.Sum, Time: at},
+		model.MetricSample{Name: name + "_count", Labels: labels, Value: float64(histogram.Count), Time: at},
+	)
+}
+
+// MetricsExportServiceImpl pushes the stats to a metrics backend, for
+// installs that can't be scraped. Each push carries the samples collected
+// since the last successful one, so a backend down for a while gets the
+// history it missed, within the points the stats keep.
+type MetricsExportServiceImpl struct {
+	logger outbound.Logger
+	source MetricSource
+	writer outbound.MetricsWriter
+
+	pushed time.Time // time of the newest sample pushed
+}
+
+func NewMetricsExportService(logger outbound.Logger, source MetricSource, writer outbound.MetricsWriter) *MetricsExportServiceImpl {
+	return &MetricsExportServiceImpl{
+		logger: logger,
+		source: source,
+		writer: writer,
+	}
+}
+
+// Run pushes the new samples each interval, until ctx is done
+func (s *MetricsExportServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = model.DefaultRemoteWriteInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Push(ctx); err != nil {
+			s.logger.Warn("Metrics push failed, retrying at the next interval", "ERROR", err)
+		}
+	}
+}
+
+// Push writes the samples collected since the last successful push
+func (s *MetricsExportServiceImpl) Push(ctx context.Context) error {
+	samples := s.source.MetricSamples(s.pushed)
+	if len(samples) == 0 {
+		return nil
+	}
+	err := s.writer.Write(ctx, samples)
+	if err != nil && !errors.Is(err, model.ErrMetricsRejected) {
+		return err
+	}
+
+	// rejected samples are dropped, the next push starts after them
+	for _, sample := range samples {
+		if sample.Time.After(s.pushed) {
+			s.pushed = sample.Time
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.logger.Debug("Metrics pushed", "samples", len(samples), "until", s.pushed)
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMetricsWriter struct {
+	pushes [][]model.MetricSample
+	err    error
+}
+
+func (w *recordingMetricsWriter) Write(ctx context.Context, samples []model.MetricSample) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.pushes = append(w.pushes, samples)
+	return nil
+}
+
+func TestStatsMetricSamples(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	stats := &StatsServiceImpl{metrics: &MetricsStore{
+		messageRates: []MessageRate{
+			{Timestamp: t0.Unix(), Published: 1, Consumed: 2},
+			{Timestamp: t0.Add(5 * time.Second).Unix(), Published: 3, Consumed: 4},
+		},
+		queueSnapshots: map[string]*QueueSnapshot{
+			"shop:orders": {Domain: "shop", Queue: "orders", RepositoryCount: 7, LastUpdated: t0.Add(5 * time.Second)},
+		},
+	}}
+
+	samples := stats.MetricSamples(time.Time{})
+	assert.Len(t, samples, 4+7)
+
+	samples = stats.MetricSamples(t0)
+	names := make(map[string]float64)
+	for _, sample := range samples {
+		names[sample.Name] = sample.Value
+		assert.Equal(t, t0.Add(5*time.Second), sample.Time)
+	}
+	assert.Equal(t, 3.0, names["gortms_publish_rate"], "only the points after since")
+	assert.Equal(t, 7.0, names["gortms_queue_messages"])
+
+	assert.Empty(t, stats.MetricSamples(t0.Add(5*time.Second)))
+}
+
+func TestMetricsExportPush(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0)
+	stats := &StatsServiceImpl{metrics: &MetricsStore{
+		messageRates:   []MessageRate{{Timestamp: t0.Unix(), Published: 1}},
+		queueSnapshots: map[string]*QueueSnapshot{},
+	}}
+	writer := &recordingMetricsWriter{}
+	export := NewMetricsExportService(&mockLogger{}, stats, writer)
+	ctx := context.Background()
+
+	require.NoError(t, export.Push(ctx))
+	require.Len(t, writer.pushes, 1)
+	assert.Len(t, writer.pushes[0], 2)
+
+	require.NoError(t, export.Push(ctx))
+	assert.Len(t, writer.pushes, 1, "nothing new to push")
+
+	// a backend down keeps the samples for the next push
+	stats.metrics.messageRates = append(stats.metrics.messageRates, MessageRate{Timestamp: t0.Add(5 * time.Second).Unix()})
+	writer.err = errors.New("connection refused")
+	assert.Error(t, export.Push(ctx))
+	stats.metrics.messageRates = append(stats.metrics.messageRates, MessageRate{Timestamp: t0.Add(10 * time.Second).Unix()})
+	writer.err = nil
+	require.NoError(t, export.Push(ctx))
+	require.Len(t, writer.pushes, 2)
+	assert.Len(t, writer.pushes[1], 4, "the missed points are pushed too")
+
+	// rejected samples are dropped
+	stats.metrics.messageRates = append(stats.metrics.messageRates, MessageRate{Timestamp: t0.Add(15 * time.Second).Unix()})
+	writer.err = fmt.Errorf("%w: 400 Bad Request", model.ErrMetricsRejected)
+	assert.ErrorIs(t, export.Push(ctx), model.ErrMetricsRejected)
+	writer.err = nil
+	require.NoError(t, export.Push(ctx))
+	assert.Len(t, writer.pushes, 2)
+}
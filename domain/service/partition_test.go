@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishStoresMessagesInPartitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop", Config: model.QueueConfig{Partitions: 4, OrderingKey: "customer"}},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, bus)
+
+	for i := range 6 {
+		require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{
+			ID:      fmt.Sprintf("%d", i),
+			Payload: []byte(`{"customer":"c-42"}`),
+		}))
+	}
+
+	assert.Empty(t, messageRepo.messages["shop:orders"])
+	partition := model.PartitionQueueName("orders", 0)
+	for p := range 4 {
+		if len(messageRepo.messages["shop:"+model.PartitionQueueName("orders", p)]) > 0 {
+			partition = model.PartitionQueueName("orders", p)
+		}
+	}
+	stored := messageRepo.messages["shop:"+partition]
+	require.Len(t, stored, 6, "messages of a customer share a partition")
+	_, p, _ := model.ParsePartitionQueueName(partition)
+	assert.Equal(t, p, stored[0].Metadata[model.MetadataPartition])
+
+	// events name the queue, not the partition
+	for _, event := range bus.events {
+		assert.Equal(t, "orders", event.Queue)
+	}
+
+	_, err := queueService.GetChannelQueue(ctx, "shop", model.PartitionQueueName("orders", 4))
+	assert.ErrorIs(t, err, ErrQueueNotFound)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerSessionKeepsStoredMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders":  {Name: "orders", DomainName: "shop"},
+			"billing": {Name: "billing", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+	routing := NewRoutingService(domainRepo, ctx)
+
+	require.NoError(t, routing.AddRoutingRule(ctx, "shop", &model.RoutingRule{
+		SourceQueue: "orders", DestinationQueue: "billing",
+		Predicate: model.JSONPredicate{Type: "contains", Field: "tier", Value: ""},
+	}))
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "billing", model.QueueModeReadOnly))
+
+	publish := func(id, seq string) error {
+		return svc.PublishMessage("shop", "orders", &model.Message{
+			ID:      id,
+			Payload: []byte(`{"tier":"standard"}`),
+			Headers: map[string]string{model.HeaderProducerID: "checkout", model.HeaderProducerSeq: seq},
+		})
+	}
+
+	// the routed copy fails once the original is stored
+	assert.ErrorIs(t, publish("order-1", "1"), model.ErrQueueReadOnly)
+	require.Len(t, messageRepo.messages["shop:orders"], 1)
+
+	// so the retry is a duplicate, not a second copy
+	assert.ErrorIs(t, publish("order-1-retry", "1"), model.ErrDuplicatePublish)
+	assert.Len(t, messageRepo.messages["shop:orders"], 1)
+
+	// a publish refused before storing can be retried
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "billing", model.QueueModeReadWrite))
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "orders", model.QueueModeReadOnly))
+	assert.ErrorIs(t, publish("order-2", "2"), model.ErrQueueReadOnly)
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "orders", model.QueueModeReadWrite))
+	require.NoError(t, publish("order-2", "2"))
+	assert.Len(t, messageRepo.messages["shop:orders"], 2)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// GetQueueDigest fingerprints the messages stored in a queue, partition by
+// partition, so that two copies of it can be compared. Stored payloads are
+// hashed as stored, claim-check references included.
+func (s *MessageServiceImpl) GetQueueDigest(ctx context.Context, domainName, queueName string, bucketSize int) (*model.QueueDigest, error) {
+	if bucketSize == 0 {
+		bucketSize = model.DefaultDigestBucketSize
+	}
+	if bucketSize < 0 || bucketSize > model.MaxDigestBucketSize {
+		return nil, fmt.Errorf("%w: bucket size must be between 1 and %d", model.ErrInvalidDigest, model.MaxDigestBucketSize)
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return nil, ErrQueueNotFound
+	}
+
+	digest := &model.QueueDigest{
+		Domain:     domainName,
+		Queue:      queueName,
+		BucketSize: bucketSize,
+		ComputedAt: time.Now(),
+	}
+	for _, storageQueue := range queue.StorageQueues() {
+		var messages []*model.Message
+		if count := s.messageRepo.GetQueueMessageCount(domainName, storageQueue); count > 0 {
+			messages, err = s.messageRepo.GetMessagesAfterIndex(ctx, domainName, storageQueue, 0, count)
+			if err != nil {
+				return nil, err
+			}
+		}
+		digest.Partitions = append(digest.Partitions, model.NewPartitionDigest(storageQueue, messages, bucketSize))
+	}
+	return digest, nil
+}
+
+// CompareQueueDigest lists where a queue diverges from a digest of another
+// copy of it, computed with GetQueueDigest on the other node
+func (s *MessageServiceImpl) CompareQueueDigest(ctx context.Context, domainName, queueName string, remote *model.QueueDigest) ([]model.DigestDivergence, error) {
+	if remote == nil || remote.BucketSize <= 0 {
+		return nil, fmt.Errorf("%w: bucket size is required", model.ErrInvalidDigest)
+	}
+	local, err := s.GetQueueDigest(ctx, domainName, queueName, remote.BucketSize)
+	if err != nil {
+		return nil, err
+	}
+	return local.Diverges(remote)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// drainProgressEvery is the number of messages moved between two progress
+// events of a drain
+const drainProgressEvery = 100
+
+// DrainQueue moves the messages stored in a queue to another queue of the
+// domain, oldest first and partition after partition, to decommission the
+// queue without losing its backlog. Each message is moved as by
+// MoveMessage. The drain stops at the first failed move or when ctx is
+// done, the report telling how far it went.
+func (s *MessageServiceImpl) DrainQueue(
+	ctx context.Context,
+	domainName, queueName, destinationQueue string,
+) (*model.DrainReport, error) {
+	if destinationQueue == "" {
+		return nil, fmt.Errorf("%w: destination queue is required", model.ErrInvalidMove)
+	}
+	if destinationQueue == queueName {
+		return nil, fmt.Errorf("%w: a queue can't be drained into itself", model.ErrInvalidMove)
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	source, exists := domain.Queues[queueName]
+	if !exists {
+		return nil, ErrQueueNotFound
+	}
+	if _, exists := domain.Queues[destinationQueue]; !exists {
+		return nil, fmt.Errorf("%w: destination queue %s not found", model.ErrInvalidMove, destinationQueue)
+	}
+
+	report := &model.DrainReport{
+		Domain:      domainName,
+		Queue:       queueName,
+		Destination: destinationQueue,
+		StartedAt:   time.Now(),
+	}
+
+	// the backlog is taken when the drain starts
+	var backlog []*model.Message
+	for _, storageQueue := range source.StorageQueues() {
+		count := s.messageRepo.GetQueueMessageCount(domainName, storageQueue)
+		if count == 0 {
+			continue
+		}
+		messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, storageQueue, 0, count)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrMoveFailed, err)
+		}
+		backlog = append(backlog, messages...)
+	}
+	report.Total = len(backlog)
+
+	s.logger.Info("Queue drain started",
+		"source", domainName+"."+queueName,
+		"destination", domainName+"."+destinationQueue,
+		"messages", report.Total)
+	s.emitDrainProgress(report)
+
+	for _, message := range backlog {
+		if err := ctx.Err(); err != nil {
+			return s.finishDrain(report, err)
+		}
+
+		_, err := s.MoveMessage(ctx, domainName, queueName, message.ID, destinationQueue)
+		switch {
+		case errors.Is(err, model.ErrMessageNotFound):
+			report.Skipped++
+		case err != nil:
+			return s.finishDrain(report, err)
+		default:
+			report.Moved++
+		}
+
+		if done := report.Moved + report.Skipped; done%drainProgressEvery == 0 && done < report.Total {
+			s.emitDrainProgress(report)
+		}
+	}
+	return s.finishDrain(report, nil)
+}
+
+// finishDrain reports the end of a drain, err being what stopped it
+func (s *MessageServiceImpl) finishDrain(report *model.DrainReport, err error) (*model.DrainReport, error) {
+	report.FinishedAt = time.Now()
+	if err != nil {
+		report.Error = err.Error()
+		s.logger.Error("Queue drain stopped",
+			"source", report.Domain+"."+report.Queue,
+			"destination", report.Domain+"."+report.Destination,
+			"moved", report.Moved,
+			"total", report.Total,
+			"ERROR", err)
+	} else {
+		s.logger.Info("Queue drained",
+			"source", report.Domain+"."+report.Queue,
+			"destination", report.Domain+"."+report.Destination,
+			"moved", report.Moved,
+			"skipped", report.Skipped)
+	}
+	s.emitDrainProgress(report)
+	return report, err
+}
+
+func (s *MessageServiceImpl) emitDrainProgress(report *model.DrainReport) {
+	data := map[string]any{
+		"destination": report.Destination,
+		"total":       report.Total,
+		"moved":       report.Moved,
+		"skipped":     report.Skipped,
+		"done":        !report.FinishedAt.IsZero(),
+	}
+	if report.Error != "" {
+		data["error"] = report.Error
+	}
+	s.emit(model.DomainEvent{
+		Kind:   model.EventQueueDrainProgress,
+		Domain: report.Domain,
+		Queue:  report.Queue,
+		Data:   data,
+	})
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"legacy": {Name: "legacy", DomainName: "shop"},
+			"parked": {Name: "parked", DomainName: "shop"},
+			"orders": {Name: "orders", DomainName: "shop"},
+			// refuses publishes without field encryption
+			"vault": {Name: "vault", DomainName: "shop", Config: model.QueueConfig{EncryptedFields: []string{"card"}}},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	bus := &recordingBus{}
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, bus)
+
+	for i := range 250 {
+		require.NoError(t, svc.PublishMessage("shop", "legacy", &model.Message{
+			ID:      fmt.Sprintf("order-%03d", i),
+			Payload: []byte(`{"card":"4111"}`),
+		}))
+	}
+
+	for i := range 3 {
+		require.NoError(t, svc.PublishMessage("shop", "parked", &model.Message{
+			ID:      fmt.Sprintf("parked-%d", i),
+			Payload: []byte(`{"card":"4111"}`),
+		}))
+	}
+
+	// a failed move stops the drain, the backlog stays
+	report, err := svc.DrainQueue(ctx, "shop", "parked", "vault")
+	assert.ErrorIs(t, err, model.ErrMoveFailed)
+	require.NotNil(t, report)
+	assert.Equal(t, 3, report.Total)
+	assert.Zero(t, report.Moved)
+	assert.NotEmpty(t, report.Error)
+	assert.Len(t, messageRepo.messages["shop:parked"], 3)
+
+	bus.events = nil
+	report, err = svc.DrainQueue(ctx, "shop", "legacy", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, 250, report.Total)
+	assert.Equal(t, 250, report.Moved)
+	assert.Empty(t, report.Error)
+	assert.False(t, report.FinishedAt.IsZero())
+
+	assert.Empty(t, messageRepo.messages["shop:legacy"])
+	drained := messageRepo.messages["shop:orders"]
+	require.Len(t, drained, 250)
+	for i, message := range drained {
+		assert.Equal(t, fmt.Sprintf("order-%03d", i), message.ID, "drained in order")
+		assert.Equal(t, "legacy", message.Metadata[model.MetadataMovedFrom])
+	}
+
+	var progress []map[string]any
+	for _, event := range bus.events {
+		if event.Kind == model.EventQueueDrainProgress {
+			assert.Equal(t, "legacy", event.Queue)
+			progress = append(progress, event.Data)
+		}
+	}
+	require.Len(t, progress, 4, "start, every 100 messages and end")
+	assert.Equal(t, 0, progress[0]["moved"])
+	assert.Equal(t, 100, progress[1]["moved"])
+	assert.Equal(t, 200, progress[2]["moved"])
+	assert.Equal(t, 250, progress[3]["moved"])
+	assert.Equal(t, true, progress[3]["done"])
+
+	_, err = svc.DrainQueue(ctx, "shop", "legacy", "legacy")
+	assert.ErrorIs(t, err, model.ErrInvalidMove)
+	_, err = svc.DrainQueue(ctx, "shop", "legacy", "missing")
+	assert.ErrorIs(t, err, model.ErrInvalidMove)
+	_, err = svc.DrainQueue(ctx, "shop", "missing", "orders")
+	assert.ErrorIs(t, err, ErrQueueNotFound)
+}
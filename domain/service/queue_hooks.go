@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// DefaultQueueHookInterval spaces the checks catching what no event
+// reports: consumers joining or leaving, acknowledged or expired messages
+const DefaultQueueHookInterval = time.Second
+
+// QueueHookServiceImpl fires the hooks of the queues. Publishes and consumes
+// trigger a check of their queue, the other changes are caught by a
+// periodic check of every queue with hooks.
+type QueueHookServiceImpl struct {
+	logger            outbound.Logger
+	domainRepo        outbound.DomainRepository
+	messageRepo       outbound.MessageRepository
+	consumerGroupRepo outbound.ConsumerGroupRepository
+	messageService    inbound.MessageService
+	poster            outbound.HookPoster // nil when webhooks aren't delivered
+
+	states map[string]map[string]*model.QueueHookState // domain -> queue -> last seen state
+	mu     sync.Mutex
+}
+
+func NewQueueHookService(
+	logger outbound.Logger,
+	domainRepo outbound.DomainRepository,
+	messageRepo outbound.MessageRepository,
+	consumerGroupRepo outbound.ConsumerGroupRepository,
+	messageService inbound.MessageService,
+	poster outbound.HookPoster,
+) *QueueHookServiceImpl {
+	return &QueueHookServiceImpl{
+		logger:            logger,
+		domainRepo:        domainRepo,
+		messageRepo:       messageRepo,
+		consumerGroupRepo: consumerGroupRepo,
+		messageService:    messageService,
+		poster:            poster,
+		states:            make(map[string]map[string]*model.QueueHookState),
+	}
+}
+
+// HandleEvent checks the queue of a message event and forgets deleted queues
+func (s *QueueHookServiceImpl) HandleEvent(event model.DomainEvent) {
+	switch event.Kind {
+	case model.EventMessagePublished, model.EventMessageConsumed:
+		domain, err := s.domainRepo.GetDomain(context.Background(), event.Domain)
+		if err != nil || domain == nil {
+			return
+		}
+		if queue := domain.Queues[model.PartitionParent(event.Queue)]; queue != nil {
+			s.check(context.Background(), domain.Name, queue)
+		}
+	case model.EventQueueDeleted:
+		s.mu.Lock()
+		delete(s.states[event.Domain], event.Queue)
+		s.mu.Unlock()
+	case model.EventDomainDeleted:
+		s.mu.Lock()
+		delete(s.states, event.Domain)
+		s.mu.Unlock()
+	}
+}
+
+// Run checks every queue with hooks each interval, until ctx is done
+func (s *QueueHookServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultQueueHookInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.CheckAll(ctx); err != nil {
+			s.logger.Warn("Queue hook check failed", "ERROR", err)
+		}
+	}
+}
+
+// CheckAll checks every queue with hooks
+func (s *QueueHookServiceImpl) CheckAll(ctx context.Context) error {
+	return forEachDomain(ctx, s.domainRepo, func(domain *model.Domain) {
+		for _, queue := range domain.Queues {
+			s.check(ctx, domain.Name, queue)
+		}
+	})
+}
+
+// check observes the queue and fires the hooks of the changes it went through
+func (s *QueueHookServiceImpl) check(ctx context.Context, domainName string, queue *model.Queue) {
+	hooks := queue.Config.Hooks
+	if hooks == nil {
+		return
+	}
+
+	// reading and observing under the lock keeps the observations of a
+	// queue in order when an event and the periodic check race
+	s.mu.Lock()
+	count, consumers := s.messageCount(domainName, queue), s.consumerCount(ctx, domainName, queue)
+	if s.states[domainName] == nil {
+		s.states[domainName] = make(map[string]*model.QueueHookState)
+	}
+	state, exists := s.states[domainName][queue.Name]
+	if !exists {
+		state = &model.QueueHookState{}
+		s.states[domainName][queue.Name] = state
+	}
+	events := state.Observe(count, queue.Config.MaxSize, consumers)
+	s.mu.Unlock()
+
+	for _, event := range events {
+		if !hooks.Reports(event) {
+			continue
+		}
+		s.fire(hooks, model.QueueControlEvent{
+			Event:        event,
+			Domain:       domainName,
+			Queue:        queue.Name,
+			MessageCount: count,
+			MaxSize:      queue.Config.MaxSize,
+			Consumers:    consumers,
+			Time:         time.Now(),
+		})
+	}
+}
+
+func (s *QueueHookServiceImpl) messageCount(domainName string, queue *model.Queue) int {
+	count := 0
+	for _, storageQueue := range queue.StorageQueues() {
+		count += s.messageRepo.GetQueueMessageCount(domainName, storageQueue)
+	}
+	return count
+}
+
+// consumerCount counts the distinct consumer IDs of the groups of the queue,
+// through the repository when it exposes group details
+func (s *QueueHookServiceImpl) consumerCount(ctx context.Context, domainName string, queue *model.Queue) int {
+	repo, ok := s.consumerGroupRepo.(interface {
+		GetGroupDetails(ctx context.Context, domainName, queueName, groupID string) (*model.ConsumerGroup, error)
+	})
+	if !ok {
+		return 0
+	}
+
+	consumers := make(map[string]bool)
+	for _, storageQueue := range queue.StorageQueues() {
+		groupIDs, err := s.consumerGroupRepo.ListGroups(ctx, domainName, storageQueue)
+		if err != nil {
+			continue
+		}
+		for _, groupID := range groupIDs {
+			group, err := repo.GetGroupDetails(ctx, domainName, storageQueue, groupID)
+			if err != nil || group == nil {
+				continue
+			}
+			for _, consumerID := range group.ConsumerIDs {
+				consumers[groupID+"/"+consumerID] = true
+			}
+		}
+	}
+	return len(consumers)
+}
+
+// fire publishes the event to the hook queue and posts it to the webhook
+func (s *QueueHookServiceImpl) fire(hooks *model.QueueHooks, event model.QueueControlEvent) {
+	s.logger.Info("Queue hook fired",
+		"queue", event.Domain+"."+event.Queue,
+		"event", event.Event,
+		"messages", event.MessageCount,
+		"consumers", event.Consumers)
+
+	if hooks.Webhook != "" && s.poster != nil {
+		s.poster.Post(hooks.Webhook, event)
+	}
+	if hooks.Queue == "" {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	message := &model.Message{
+		ID:        fmt.Sprintf("hook-%s-%s-%d", event.Queue, event.Event, event.Time.UnixNano()),
+		Payload:   payload,
+		Headers:   map[string]string{"Content-Type": "application/json"},
+		Metadata:  map[string]any{model.MetadataControlEvent: string(event.Event), "source": event.Queue},
+		Timestamp: event.Time,
+	}
+	if err := s.messageService.PublishMessage(event.Domain, hooks.Queue, message); err != nil {
+		s.logger.Warn("Queue hook not published",
+			"queue", event.Domain+"."+event.Queue,
+			"hookQueue", hooks.Queue,
+			"event", event.Event,
+			"ERROR", err)
+	}
+}
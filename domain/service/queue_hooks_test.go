@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hookPublisher struct {
+	inbound.MessageService
+	published []*model.Message
+}
+
+func (p *hookPublisher) PublishMessage(domainName, queueName string, message *model.Message) error {
+	p.published = append(p.published, message)
+	return nil
+}
+
+type hookPosts struct {
+	events []model.QueueControlEvent
+}
+
+func (p *hookPosts) Post(url string, event model.QueueControlEvent) {
+	p.events = append(p.events, event)
+}
+
+func TestQueueHooksFire(t *testing.T) {
+	ctx := context.Background()
+
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop", Config: model.QueueConfig{
+				MaxSize: 2,
+				Hooks:   &model.QueueHooks{Queue: "control", Webhook: "http://hooks.local/orders"},
+			}},
+			"payments": {Name: "payments", DomainName: "shop", Config: model.QueueConfig{
+				Hooks: &model.QueueHooks{Queue: "control", Events: []model.QueueHookEvent{model.QueueHookEmpty}},
+			}},
+			"control": {Name: "control", DomainName: "shop"},
+		},
+	})
+	messageRepo := &mockMessageRepository{}
+	groups := &groupStore{groups: map[string]*model.ConsumerGroup{}}
+	publisher := &hookPublisher{}
+	posts := &hookPosts{}
+	svc := NewQueueHookService(&mockLogger{}, domainRepo, messageRepo, groups, publisher, posts)
+
+	fired := func() []model.QueueHookEvent {
+		var events []model.QueueHookEvent
+		for _, message := range publisher.published {
+			var event model.QueueControlEvent
+			require.NoError(t, json.Unmarshal(message.Payload, &event))
+			events = append(events, event.Event)
+		}
+		publisher.published = nil
+		return events
+	}
+
+	require.NoError(t, svc.CheckAll(ctx))
+	assert.Empty(t, fired(), "the first check only records the state")
+
+	storeMessages(t, messageRepo, "m1", "m2")
+	svc.HandleEvent(model.DomainEvent{Kind: model.EventMessagePublished, Domain: "shop", Queue: "orders"})
+	assert.Equal(t, []model.QueueHookEvent{model.QueueHookFull}, fired())
+	require.Len(t, posts.events, 1)
+	assert.Equal(t, 2, posts.events[0].MessageCount)
+	assert.Equal(t, "orders", posts.events[0].Queue)
+
+	groups.groups["billing"] = &model.ConsumerGroup{QueueName: "orders", GroupID: "billing", ConsumerIDs: []string{"worker-1"}}
+	require.NoError(t, svc.CheckAll(ctx))
+	assert.Equal(t, []model.QueueHookEvent{model.QueueHookFirstConsumer}, fired())
+
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "orders", "m1"))
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "orders", "m2"))
+	groups.groups["billing"].ConsumerIDs = nil
+	require.NoError(t, svc.CheckAll(ctx))
+	assert.ElementsMatch(t, []model.QueueHookEvent{model.QueueHookEmpty, model.QueueHookLastConsumer}, fired())
+
+	require.NoError(t, svc.CheckAll(ctx))
+	assert.Empty(t, fired(), "hooks fire on changes only")
+
+	// payments reports its drains only
+	require.NoError(t, messageRepo.StoreMessage(ctx, "shop", "payments", &model.Message{ID: "p1"}))
+	require.NoError(t, svc.CheckAll(ctx))
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "payments", "p1"))
+	require.NoError(t, svc.CheckAll(ctx))
+	assert.Equal(t, []model.QueueHookEvent{model.QueueHookEmpty}, fired())
+}
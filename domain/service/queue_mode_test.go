@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueModes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "shop",
+		Queues: map[string]*model.Queue{"orders": {Name: "orders", DomainName: "shop"}},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "order-1", Payload: []byte(`{}`)}))
+
+	// a draining queue keeps its backlog and takes nothing new
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "orders", model.QueueModeReadOnly))
+	err := svc.PublishMessage("shop", "orders", &model.Message{ID: "order-2", Payload: []byte(`{}`)})
+	assert.ErrorIs(t, err, model.ErrQueueReadOnly)
+	assert.Len(t, messageRepo.messages["shop:orders"], 1)
+
+	// a collecting queue takes messages and hands none
+	require.NoError(t, queueService.UpdateQueueMode(ctx, "shop", "orders", model.QueueModeWriteOnly))
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "order-3", Payload: []byte(`{}`)}))
+	_, err = svc.ConsumeMessageWithGroup(ctx, "shop", "orders", "billing", &inbound.ConsumeOptions{})
+	assert.ErrorIs(t, err, model.ErrQueueWriteOnly)
+	assert.Len(t, messageRepo.messages["shop:orders"], 2)
+
+	assert.ErrorIs(t, queueService.UpdateQueueMode(ctx, "shop", "orders", "paused"), model.ErrInvalidQueueMode)
+}
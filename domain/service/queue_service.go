@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -17,12 +18,17 @@ var (
 )
 
 type QueueServiceImpl struct {
+	eventEmitter
+	errorReporting
+
 	rootCtx        context.Context
 	logger         outbound.Logger
 	domainRepo     outbound.DomainRepository
 	statsService   inbound.StatsService
 	channelQueues  map[string]map[string]*model.ChannelQueue // domainName -> queueName -> ChannelQueue
 	messageService model.MessageProvider
+	policy         *model.SubscriberPolicy // bounds the channel queue subscribers
+	initialized    chan struct{}           // closed once the stored queues are loaded
 	mu             sync.RWMutex
 }
 
@@ -38,6 +44,7 @@ func NewQueueService(
 		domainRepo:    domainRepo,
 		statsService:  statsService,
 		channelQueues: make(map[string]map[string]*model.ChannelQueue),
+		initialized:   make(chan struct{}),
 	}
 
 	// init existing queues
@@ -52,7 +59,22 @@ func (s *QueueServiceImpl) SetMessageService(messageService model.MessageProvide
 	s.messageService = messageService
 }
 
+// SetSubscriberPolicy bounds the time the channel queue subscribers take
+// and when they are quarantined
+func (s *QueueServiceImpl) SetSubscriberPolicy(policy model.SubscriberPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = &policy
+	for _, domainQueues := range s.channelQueues {
+		for _, cq := range domainQueues {
+			cq.SetSubscriberPolicy(policy)
+		}
+	}
+}
+
 func (s *QueueServiceImpl) initializeExistingQueues() {
+	defer close(s.initialized)
+
 	domains, err := s.domainRepo.ListDomains(s.rootCtx)
 	if err != nil {
 		log.Printf("Failed to list domains for queue initialization: %v", err)
@@ -78,7 +100,13 @@ func (s *QueueServiceImpl) GetChannelQueue(ctx context.Context, domainName, queu
 
 	queue, exists := domain.Queues[queueName]
 	if !exists {
-		return nil, ErrQueueNotFound
+		// partitions run as queues of their own
+		parentName, partition, ok := model.ParsePartitionQueueName(queueName)
+		parent := domain.Queues[parentName]
+		if !ok || !parent.Partitioned() || partition >= parent.Config.Partitions {
+			return nil, ErrQueueNotFound
+		}
+		queue = parent.PartitionQueue(partition)
 	}
 
 	return s.getOrCreateChannelQueue(domainName, queue)
@@ -112,6 +140,17 @@ func (s *QueueServiceImpl) getOrCreateChannelQueue(domainName string, queue *mod
 	}
 
 	cq := model.NewChannelQueue(s.rootCtx, s.logger, queue, bufferSize, s.messageService)
+	if s.policy != nil {
+		cq.SetSubscriberPolicy(*s.policy)
+	}
+	cq.SetFailureHandler(func(msg *model.Message, err error) {
+		s.reportError(model.ErrorKindDelivery, domainName, queue.Name, msg, err)
+	})
+	if letters, ok := s.messageService.(deadLetterer); ok {
+		cq.SetDeadLetterHandler(func(msg *model.Message, reason model.DeadLetterReason, err error, attempts int) {
+			letters.DeadLetter(domainName, queue.Name, msg, reason, err, attempts)
+		})
+	}
 	s.channelQueues[domainName][queue.Name] = cq
 
 	// start workers
@@ -139,6 +178,50 @@ func (s *QueueServiceImpl) getOrCreateChannelQueue(domainName string, queue *mod
 func (s *QueueServiceImpl) CreateQueue(ctx context.Context, domainName, queueName string, config *model.QueueConfig) error {
 	log.Printf("Creating queue: %s.%s", domainName, queueName)
 
+	// the shadow queue may be created afterwards, copies wait for it
+	if config.Mirror != nil {
+		if err := config.Mirror.Validate(queueName); err != nil {
+			return err
+		}
+	}
+	if cb := config.CircuitBreakerConfig; cb != nil && cb.FallbackQueue == queueName {
+		return fmt.Errorf("%w: a queue can't fall back to itself", model.ErrInvalidFallbackQueue)
+	}
+	if err := config.ValidatePartitions(queueName); err != nil {
+		return err
+	}
+	if config.Compaction != nil {
+		if err := config.Compaction.Validate(*config); err != nil {
+			return err
+		}
+	}
+	if err := model.ValidateEnrichment(config.Enrichment); err != nil {
+		return err
+	}
+	if config.ReadReplica != nil {
+		if err := config.ReadReplica.Validate(); err != nil {
+			return err
+		}
+	}
+	// like shadow queues, the hook queue may be created afterwards
+	if config.Hooks != nil {
+		if err := config.Hooks.Validate(queueName); err != nil {
+			return err
+		}
+	}
+	// and so may the dead-letter queue, messages are dropped until it exists
+	if config.DeadLetter != nil {
+		if err := config.DeadLetter.Validate(queueName); err != nil {
+			return err
+		}
+	}
+	if err := config.Mode.Validate(); err != nil {
+		return err
+	}
+	if err := config.ImplicitGroups.Validate(); err != nil {
+		return err
+	}
+
 	domain, err := s.domainRepo.GetDomain(ctx, domainName)
 	if err != nil {
 		log.Printf("Error getting domain %s: %v", domainName, err)
@@ -175,12 +258,16 @@ func (s *QueueServiceImpl) CreateQueue(ctx context.Context, domainName, queueNam
 
 	s.getOrCreateChannelQueue(domainName, queue)
 
+	s.emit(model.DomainEvent{
+		Kind:   model.EventQueueCreated,
+		Domain: domainName,
+		Queue:  queueName,
+	})
+
 	return nil
 }
 
 func (s *QueueServiceImpl) GetQueue(ctx context.Context, domainName, queueName string) (*model.Queue, error) {
-	log.Printf("Getting queue: %s.%s", domainName, queueName)
-
 	domain, err := s.domainRepo.GetDomain(ctx, domainName)
 	if err != nil {
 		return nil, ErrDomainNotFound
@@ -198,6 +285,121 @@ func (s *QueueServiceImpl) GetQueue(ctx context.Context, domainName, queueName s
 	return queue, nil
 }
 
+func (s *QueueServiceImpl) UpdateQueueLabels(ctx context.Context, domainName, queueName string, labels map[string]string) error {
+	log.Printf("Updating labels for queue %s.%s: %v", domainName, queueName, labels)
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return ErrQueueNotFound
+	}
+
+	queue.Config.Labels = labels
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+// UpdateQueueMirror replaces the mirror of a queue, nil stops mirroring
+func (s *QueueServiceImpl) UpdateQueueMirror(ctx context.Context, domainName, queueName string, mirror *model.MirrorConfig) error {
+	log.Printf("Updating mirror for queue %s.%s: %+v", domainName, queueName, mirror)
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return ErrQueueNotFound
+	}
+
+	if mirror != nil {
+		if err := mirror.Validate(queueName); err != nil {
+			return err
+		}
+		if _, exists := domain.Queues[mirror.Queue]; !exists {
+			return fmt.Errorf("%w: shadow queue %s not found", model.ErrInvalidMirror, mirror.Queue)
+		}
+	}
+
+	queue.Config.Mirror = mirror
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+func (s *QueueServiceImpl) UpdateQueueEnrichment(ctx context.Context, domainName, queueName string, rules []model.EnrichmentRule) error {
+	log.Printf("Updating enrichment for queue %s.%s: %+v", domainName, queueName, rules)
+
+	if err := model.ValidateEnrichment(rules); err != nil {
+		return err
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return ErrQueueNotFound
+	}
+
+	queue.Config.Enrichment = rules
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+// UpdateQueueMode makes a queue read-only, write-only or read-write again
+func (s *QueueServiceImpl) UpdateQueueMode(ctx context.Context, domainName, queueName string, mode model.QueueMode) error {
+	log.Printf("Updating mode for queue %s.%s: %s", domainName, queueName, mode)
+
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return ErrQueueNotFound
+	}
+
+	queue.Config.Mode = mode
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+// UpdateQueueImplicitGroups allows or denies consumes through groups not
+// created first
+func (s *QueueServiceImpl) UpdateQueueImplicitGroups(ctx context.Context, domainName, queueName string, policy model.ImplicitGroupPolicy) error {
+	log.Printf("Updating implicit groups for queue %s.%s: %s", domainName, queueName, policy)
+
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil {
+		return ErrDomainNotFound
+	}
+
+	queue, exists := domain.Queues[queueName]
+	if !exists {
+		return ErrQueueNotFound
+	}
+
+	queue.Config.ImplicitGroups = policy
+
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
 func (s *QueueServiceImpl) DeleteQueue(ctx context.Context, domainName, queueName string) error {
 	log.Printf("Deleting queue: %s.%s", domainName, queueName)
 
@@ -210,25 +412,10 @@ func (s *QueueServiceImpl) DeleteQueue(ctx context.Context, domainName, queueNam
 		return ErrQueueNotFound
 	}
 
-	// Stop ChannelQueue if it exists
-	s.mu.Lock()
-	if domainQueues, exists := s.channelQueues[domainName]; exists {
-		if channelQueue, exists := domainQueues[queueName]; exists {
-			// Release the mutex during the potentially long operation
-			s.mu.Unlock()
-			log.Printf("Stopping queue: %s.%s", domainName, queueName)
-			channelQueue.Stop()
-			log.Printf("Queue stopped: %s.%s", domainName, queueName)
-
-			// Reacquire it to update the map
-			s.mu.Lock()
-			delete(domainQueues, queueName)
-			if len(domainQueues) == 0 {
-				delete(s.channelQueues, domainName)
-			}
-		}
+	channelQueueNames := []string{queueName}
+	for partition := range domain.Queues[queueName].Config.Partitions {
+		channelQueueNames = append(channelQueueNames, model.PartitionQueueName(queueName, partition))
 	}
-	s.mu.Unlock()
 
 	// Delete queue
 	delete(domain.Queues, queueName)
@@ -250,7 +437,44 @@ func (s *QueueServiceImpl) DeleteQueue(ctx context.Context, domainName, queueNam
 	}
 
 	// update domain
-	return s.domainRepo.StoreDomain(ctx, domain)
+	if err := s.domainRepo.StoreDomain(ctx, domain); err != nil {
+		return err
+	}
+
+	// Stop ChannelQueue if it exists, and those of its partitions, once the
+	// repository took the delete
+	for _, name := range channelQueueNames {
+		s.stopChannelQueue(domainName, name)
+	}
+
+	s.emit(model.DomainEvent{
+		Kind:   model.EventQueueDeleted,
+		Domain: domainName,
+		Queue:  queueName,
+	})
+
+	return nil
+}
+
+func (s *QueueServiceImpl) stopChannelQueue(domainName, queueName string) {
+	s.mu.Lock()
+	if domainQueues, exists := s.channelQueues[domainName]; exists {
+		if channelQueue, exists := domainQueues[queueName]; exists {
+			// Release the mutex during the potentially long operation
+			s.mu.Unlock()
+			log.Printf("Stopping queue: %s.%s", domainName, queueName)
+			channelQueue.Stop()
+			log.Printf("Queue stopped: %s.%s", domainName, queueName)
+
+			// Reacquire it to update the map
+			s.mu.Lock()
+			delete(domainQueues, queueName)
+			if len(domainQueues) == 0 {
+				delete(s.channelQueues, domainName)
+			}
+		}
+	}
+	s.mu.Unlock()
 }
 
 func (s *QueueServiceImpl) StopDomainQueues(ctx context.Context, domainName string) error {
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// readReplicas is embedded by the services scanning queues, the scans of a
+// queue with a local read replica read it once it has caught up
+type readReplicas struct {
+	replicas outbound.ReadReplicas
+}
+
+// SetReadReplicas sets the local read replicas of the message storage
+func (r *readReplicas) SetReadReplicas(replicas outbound.ReadReplicas) {
+	r.replicas = replicas
+}
+
+// scanReader returns where to scan a storage queue of queue from: its local
+// read replica when it has one, caught up, and primary otherwise
+func (r *readReplicas) scanReader(primary outbound.MessageRepository, domainName string, queue *model.Queue, storageQueue string) (reader outbound.MessageReader, replica bool) {
+	if r.replicas == nil || queue == nil || !queue.Config.ReadReplica.Local() {
+		return primary, false
+	}
+	if reader, ok := r.replicas.ReplicaReader(domainName, storageQueue); ok {
+		return reader, true
+	}
+	return primary, false
+}
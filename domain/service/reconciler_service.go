@@ -0,0 +1,561 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// manifest edits come in bursts (editors, git checkouts), wait for them to settle
+const reconcileSettleDelay = 500 * time.Millisecond
+
+// plannedChange is a drift along with the call resolving it,
+// apply is nil for drift that must not be applied
+type plannedChange struct {
+	drift model.Drift
+	apply func(ctx context.Context) error
+}
+
+// ReconcilerServiceImpl converges the broker to the manifests of a source.
+// Domains and service accounts it creates are marked as managed, only those
+// are deleted once they leave the manifests, and only when pruning is enabled.
+type ReconcilerServiceImpl struct {
+	source         outbound.ManifestSource
+	domainService  inbound.DomainService
+	queueService   inbound.QueueService
+	routingService inbound.RoutingService
+	serviceRepo    outbound.ServiceRepository
+	logger         outbound.Logger
+	prune          bool
+
+	running    sync.Mutex // one pass at a time
+	mu         sync.RWMutex
+	lastReport *model.ReconcileReport
+}
+
+func NewReconcilerService(
+	source outbound.ManifestSource,
+	domainService inbound.DomainService,
+	queueService inbound.QueueService,
+	routingService inbound.RoutingService,
+	serviceRepo outbound.ServiceRepository,
+	logger outbound.Logger,
+	prune bool,
+) *ReconcilerServiceImpl {
+	return &ReconcilerServiceImpl{
+		source:         source,
+		domainService:  domainService,
+		queueService:   queueService,
+		routingService: routingService,
+		serviceRepo:    serviceRepo,
+		logger:         logger,
+		prune:          prune,
+	}
+}
+
+// Run reconciles at startup, then every interval and whenever the manifests change
+func (s *ReconcilerServiceImpl) Run(ctx context.Context, interval time.Duration, changes <-chan outbound.FileChangeEvent) {
+	s.reconcileAndLog(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileAndLog(ctx)
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			settle = time.After(reconcileSettleDelay)
+		case <-settle:
+			settle = nil
+			s.reconcileAndLog(ctx)
+		}
+	}
+}
+
+func (s *ReconcilerServiceImpl) reconcileAndLog(ctx context.Context) {
+	report, err := s.Reconcile(ctx, false)
+	if err != nil {
+		s.logger.Error("Reconciliation failed", "error", err)
+		return
+	}
+	if len(report.Drift) > 0 {
+		s.logger.Info("Manifests reconciled",
+			"drift", len(report.Drift),
+			"inSync", report.InSync,
+			"durationMs", report.DurationMs)
+	}
+}
+
+// Reconcile compares the manifests with the broker and, unless dryRun is set,
+// applies the differences. Manifests that can't be loaded change nothing.
+func (s *ReconcilerServiceImpl) Reconcile(ctx context.Context, dryRun bool) (*model.ReconcileReport, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	report := &model.ReconcileReport{
+		StartedAt: time.Now(),
+		DryRun:    dryRun,
+		Drift:     []model.Drift{},
+	}
+
+	state, err := s.source.Load(ctx)
+	if err == nil {
+		var changes []plannedChange
+		changes, err = s.plan(ctx, state)
+
+		report.InSync = true
+		for _, change := range changes {
+			if !dryRun && change.apply != nil {
+				if applyErr := change.apply(ctx); applyErr != nil {
+					change.drift.Error = applyErr.Error()
+					s.logger.Warn("Failed to apply drift",
+						"kind", change.drift.Kind,
+						"name", change.drift.Name,
+						"action", change.drift.Action,
+						"error", applyErr)
+				} else {
+					change.drift.Applied = true
+				}
+			}
+			report.InSync = report.InSync && change.drift.Applied
+			report.Drift = append(report.Drift, change.drift)
+		}
+	}
+	if err != nil {
+		report.Error = err.Error()
+		report.InSync = false
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	// a dry run reflects what would happen, not the broker state
+	if !dryRun {
+		s.mu.Lock()
+		s.lastReport = report
+		s.mu.Unlock()
+	}
+
+	return report, err
+}
+
+// LastReport returns the report of the last applied pass, nil before the first one
+func (s *ReconcilerServiceImpl) LastReport() *model.ReconcileReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastReport
+}
+
+// plan lists the drift in the order it can be applied: domains and queues
+// first, then routes between them, then deletions and service accounts
+func (s *ReconcilerServiceImpl) plan(ctx context.Context, state *model.DesiredState) ([]plannedChange, error) {
+	domains, err := s.domainService.ListDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	existing := make(map[string]*model.Domain, len(domains))
+	for _, domain := range domains {
+		existing[domain.Name] = domain
+	}
+
+	var domainChanges, queueChanges, routeChanges, queueDeletes, domainDeletes []plannedChange
+	desiredNames := make(map[string]bool, len(state.Domains))
+
+	for _, desired := range state.Domains {
+		desiredNames[desired.Name] = true
+		current := existing[desired.Name]
+
+		domainChanges = append(domainChanges, s.planDomain(desired, current)...)
+		queueChanges = append(queueChanges, s.planQueues(desired, current)...)
+		routeChanges = append(routeChanges, s.planRoutes(desired, current)...)
+		if current != nil {
+			queueDeletes = append(queueDeletes, s.planQueueDeletes(desired, current)...)
+		}
+	}
+
+	for _, domain := range domains {
+		if desiredNames[domain.Name] || domain.Labels[model.LabelManagedBy] != model.ManagedByGitOps {
+			continue
+		}
+		name := domain.Name
+		domainDeletes = append(domainDeletes, s.deletion(model.DriftKindDomain, name, func(ctx context.Context) error {
+			return s.domainService.DeleteDomain(ctx, name)
+		}))
+	}
+	sortChanges(domainDeletes)
+
+	accountChanges, err := s.planServiceAccounts(ctx, state.ServiceAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Concat(domainChanges, queueChanges, routeChanges, queueDeletes, domainDeletes, accountChanges), nil
+}
+
+func (s *ReconcilerServiceImpl) planDomain(desired model.DesiredDomain, current *model.Domain) []plannedChange {
+	labels := maps.Clone(desired.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[model.LabelManagedBy] = model.ManagedByGitOps
+
+	if current == nil {
+		config := &model.DomainConfig{
+			Name:                desired.Name,
+			Schema:              desired.Schema,
+			AllowedRouteSources: desired.AllowedRouteSources,
+			Labels:              labels,
+		}
+		return []plannedChange{{
+			drift: model.Drift{Kind: model.DriftKindDomain, Name: desired.Name, Action: model.DriftCreate},
+			apply: func(ctx context.Context) error {
+				return s.domainService.CreateDomain(ctx, config)
+			},
+		}}
+	}
+
+	if !current.Schema.SameFields(desired.Schema) {
+		return []plannedChange{{
+			drift: model.Drift{
+				Kind:   model.DriftKindDomain,
+				Name:   desired.Name,
+				Action: model.DriftConflict,
+				Detail: "schema differs, the domain must be recreated",
+			},
+		}}
+	}
+
+	var changes []plannedChange
+	if !slices.Equal(current.AllowedRouteSources, desired.AllowedRouteSources) {
+		sources := desired.AllowedRouteSources
+		changes = append(changes, plannedChange{
+			drift: model.Drift{
+				Kind:   model.DriftKindDomain,
+				Name:   desired.Name,
+				Action: model.DriftUpdate,
+				Detail: "allowed route sources",
+			},
+			apply: func(ctx context.Context) error {
+				return s.domainService.UpdateAllowedRouteSources(ctx, desired.Name, sources)
+			},
+		})
+	}
+	if !maps.Equal(current.Labels, labels) {
+		changes = append(changes, plannedChange{
+			drift: model.Drift{
+				Kind:   model.DriftKindDomain,
+				Name:   desired.Name,
+				Action: model.DriftUpdate,
+				Detail: "labels",
+			},
+			apply: func(ctx context.Context) error {
+				return s.domainService.UpdateDomainLabels(ctx, desired.Name, labels)
+			},
+		})
+	}
+	return changes
+}
+
+func (s *ReconcilerServiceImpl) planQueues(desired model.DesiredDomain, current *model.Domain) []plannedChange {
+	var changes []plannedChange
+	for _, queue := range desired.Queues {
+		name := desired.Name + "/" + queue.Name
+		config := queue.Config
+
+		var existing *model.Queue
+		if current != nil {
+			existing = current.Queues[queue.Name]
+		}
+
+		switch {
+		case existing == nil:
+			changes = append(changes, plannedChange{
+				drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftCreate},
+				apply: func(ctx context.Context) error {
+					return s.queueService.CreateQueue(ctx, desired.Name, queue.Name, &config)
+				},
+			})
+		case !existing.Config.SameSettings(config):
+			changes = append(changes, plannedChange{
+				drift: model.Drift{
+					Kind:   model.DriftKindQueue,
+					Name:   name,
+					Action: model.DriftConflict,
+					Detail: "queue settings differ, the queue must be recreated",
+				},
+			})
+		default:
+			if !maps.Equal(existing.Config.Labels, config.Labels) {
+				changes = append(changes, plannedChange{
+					drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftUpdate, Detail: "labels"},
+					apply: func(ctx context.Context) error {
+						return s.queueService.UpdateQueueLabels(ctx, desired.Name, queue.Name, config.Labels)
+					},
+				})
+			}
+			if !reflect.DeepEqual(existing.Config.Mirror, config.Mirror) {
+				changes = append(changes, plannedChange{
+					drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftUpdate, Detail: "mirror"},
+					apply: func(ctx context.Context) error {
+						return s.queueService.UpdateQueueMirror(ctx, desired.Name, queue.Name, config.Mirror)
+					},
+				})
+			}
+			if !reflect.DeepEqual(existing.Config.Enrichment, config.Enrichment) {
+				changes = append(changes, plannedChange{
+					drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftUpdate, Detail: "enrichment"},
+					apply: func(ctx context.Context) error {
+						return s.queueService.UpdateQueueEnrichment(ctx, desired.Name, queue.Name, config.Enrichment)
+					},
+				})
+			}
+			if !existing.Config.Mode.Equal(config.Mode) {
+				changes = append(changes, plannedChange{
+					drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftUpdate, Detail: "mode"},
+					apply: func(ctx context.Context) error {
+						return s.queueService.UpdateQueueMode(ctx, desired.Name, queue.Name, config.Mode)
+					},
+				})
+			}
+			if !existing.Config.ImplicitGroups.Equal(config.ImplicitGroups) {
+				changes = append(changes, plannedChange{
+					drift: model.Drift{Kind: model.DriftKindQueue, Name: name, Action: model.DriftUpdate, Detail: "implicitGroups"},
+					apply: func(ctx context.Context) error {
+						return s.queueService.UpdateQueueImplicitGroups(ctx, desired.Name, queue.Name, config.ImplicitGroups)
+					},
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// planRoutes compares rules by source queue and destination key,
+// a rule whose predicate, header policy or canary split changed is replaced,
+// one only enabled, ordered or stopping differently is updated in place
+func (s *ReconcilerServiceImpl) planRoutes(desired model.DesiredDomain, current *model.Domain) []plannedChange {
+	var changes []plannedChange
+	wanted := make(map[string]bool, len(desired.Routes))
+
+	for _, rule := range desired.Routes {
+		key := rule.DestinationKey(desired.Name)
+		wanted[rule.SourceQueue+"\x00"+key] = true
+		name := desired.Name + "/" + rule.ID(desired.Name)
+
+		var existing *model.RoutingRule
+		if current != nil {
+			existing = current.Routes[rule.SourceQueue][key]
+		}
+
+		switch {
+		case existing == nil:
+			changes = append(changes, plannedChange{
+				drift: model.Drift{Kind: model.DriftKindRoute, Name: name, Action: model.DriftCreate},
+				apply: func(ctx context.Context) error {
+					return s.routingService.AddRoutingRule(ctx, desired.Name, rule)
+				},
+			})
+		case !existing.SameRouting(rule):
+			changes = append(changes, plannedChange{
+				drift: model.Drift{
+					Kind:   model.DriftKindRoute,
+					Name:   name,
+					Action: model.DriftUpdate,
+					Detail: "predicate, header policy or canary split",
+				},
+				apply: func(ctx context.Context) error {
+					if err := s.routingService.RemoveRoutingRule(ctx, desired.Name, rule.SourceQueue, key); err != nil {
+						return err
+					}
+					return s.routingService.AddRoutingRule(ctx, desired.Name, rule)
+				},
+			})
+		case !existing.SameEvaluation(rule):
+			changes = append(changes, plannedChange{
+				drift: model.Drift{
+					Kind:   model.DriftKindRoute,
+					Name:   name,
+					Action: model.DriftUpdate,
+					Detail: "enabled, priority or stop on match",
+				},
+				apply: func(ctx context.Context) error {
+					_, err := s.routingService.UpdateRoutingRule(ctx, desired.Name, rule.SourceQueue, key, rule.EvaluationUpdate())
+					return err
+				},
+			})
+		}
+	}
+
+	if current == nil {
+		return changes
+	}
+
+	var deletes []plannedChange
+	for source, rules := range current.Routes {
+		for key, rule := range rules {
+			if wanted[source+"\x00"+key] {
+				continue
+			}
+			deletes = append(deletes, s.deletion(model.DriftKindRoute, desired.Name+"/"+rule.ID(desired.Name),
+				func(ctx context.Context) error {
+					return s.routingService.RemoveRoutingRule(ctx, desired.Name, source, key)
+				}))
+		}
+	}
+	sortChanges(deletes)
+
+	return append(deletes, changes...)
+}
+
+func (s *ReconcilerServiceImpl) planQueueDeletes(desired model.DesiredDomain, current *model.Domain) []plannedChange {
+	wanted := make(map[string]bool, len(desired.Queues))
+	for _, queue := range desired.Queues {
+		wanted[queue.Name] = true
+	}
+
+	var deletes []plannedChange
+	for queueName := range current.Queues {
+		if wanted[queueName] {
+			continue
+		}
+		deletes = append(deletes, s.deletion(model.DriftKindQueue, desired.Name+"/"+queueName,
+			func(ctx context.Context) error {
+				return s.queueService.DeleteQueue(ctx, desired.Name, queueName)
+			}))
+	}
+	sortChanges(deletes)
+	return deletes
+}
+
+// planServiceAccounts creates the accounts with a generated secret, to be
+// rotated through the API to be disclosed
+func (s *ReconcilerServiceImpl) planServiceAccounts(ctx context.Context, desired []model.DesiredServiceAccount) ([]plannedChange, error) {
+	accounts, err := s.serviceRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	existing := make(map[string]*model.ServiceAccount, len(accounts))
+	for _, account := range accounts {
+		existing[account.ID] = account
+	}
+
+	var changes []plannedChange
+	wanted := make(map[string]bool, len(desired))
+
+	for _, account := range desired {
+		wanted[account.ID] = true
+		current := existing[account.ID]
+
+		if current == nil {
+			changes = append(changes, plannedChange{
+				drift: model.Drift{Kind: model.DriftKindServiceAccount, Name: account.ID, Action: model.DriftCreate},
+				apply: func(ctx context.Context) error {
+					secret, err := generateServiceSecret()
+					if err != nil {
+						return err
+					}
+					return s.serviceRepo.Create(ctx, &model.ServiceAccount{
+						ID:              account.ID,
+						Name:            account.Name,
+						Secret:          secret,
+						Permissions:     account.Permissions,
+						IPWhitelist:     account.IPWhitelist,
+						Domains:         account.Domains,
+						CreatedAt:       time.Now(),
+						Enabled:         account.Enabled,
+						ManagedBy:       model.ManagedByGitOps,
+						TimestampWindow: account.TimestampWindow,
+					})
+				},
+			})
+			continue
+		}
+
+		if current.Name == account.Name &&
+			slices.Equal(current.Permissions, account.Permissions) &&
+			slices.Equal(current.IPWhitelist, account.IPWhitelist) &&
+			slices.Equal(current.Domains, account.Domains) &&
+			current.TimestampWindow == account.TimestampWindow &&
+			current.Enabled == account.Enabled &&
+			current.ManagedBy == model.ManagedByGitOps {
+			continue
+		}
+
+		changes = append(changes, plannedChange{
+			drift: model.Drift{Kind: model.DriftKindServiceAccount, Name: account.ID, Action: model.DriftUpdate},
+			apply: func(ctx context.Context) error {
+				updated := *current
+				updated.Name = account.Name
+				updated.Permissions = account.Permissions
+				updated.IPWhitelist = account.IPWhitelist
+				updated.Domains = account.Domains
+				updated.TimestampWindow = account.TimestampWindow
+				updated.Enabled = account.Enabled
+				updated.ManagedBy = model.ManagedByGitOps
+				return s.serviceRepo.Update(ctx, &updated)
+			},
+		})
+	}
+
+	var deletes []plannedChange
+	for _, account := range accounts {
+		if wanted[account.ID] || account.ManagedBy != model.ManagedByGitOps {
+			continue
+		}
+		id := account.ID
+		deletes = append(deletes, s.deletion(model.DriftKindServiceAccount, id, func(ctx context.Context) error {
+			return s.serviceRepo.Delete(ctx, id)
+		}))
+	}
+	sortChanges(deletes)
+
+	return append(changes, deletes...), nil
+}
+
+// deletion plans the removal of a resource missing from the manifests,
+// reported but left in place unless pruning is enabled
+func (s *ReconcilerServiceImpl) deletion(kind, name string, apply func(ctx context.Context) error) plannedChange {
+	change := plannedChange{
+		drift: model.Drift{Kind: kind, Name: name, Action: model.DriftDelete},
+		apply: apply,
+	}
+	if !s.prune {
+		change.drift.Detail = "not in manifests, pruning disabled"
+		change.apply = nil
+	}
+	return change
+}
+
+// sortChanges orders changes built from map iterations
+func sortChanges(changes []plannedChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].drift.Name < changes[j].drift.Name
+	})
+}
+
+// generateServiceSecret matches the 32 random bytes, hex encoded, of accounts
+// created through the API
+func generateServiceSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
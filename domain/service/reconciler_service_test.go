@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManifestSource struct {
+	state *model.DesiredState
+	err   error
+}
+
+func (f *fakeManifestSource) Load(ctx context.Context) (*model.DesiredState, error) {
+	return f.state, f.err
+}
+
+// keyedDomainRepository replaces domains on store, as the real repositories
+// do, and is safe for concurrent use
+type keyedDomainRepository struct {
+	mockDomainRepository
+	mu sync.Mutex
+}
+
+func (r *keyedDomainRepository) StoreDomain(ctx context.Context, domain *model.Domain) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mockDomainRepository.DeleteDomain(ctx, domain.Name)
+	return r.mockDomainRepository.StoreDomain(ctx, domain)
+}
+
+func (r *keyedDomainRepository) GetDomain(ctx context.Context, name string) (*model.Domain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mockDomainRepository.GetDomain(ctx, name)
+}
+
+func (r *keyedDomainRepository) ListDomains(ctx context.Context) ([]*model.Domain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.domains), nil
+}
+
+func (r *keyedDomainRepository) DeleteDomain(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mockDomainRepository.DeleteDomain(ctx, name)
+}
+
+type fakeServiceRepository struct {
+	accounts map[string]*model.ServiceAccount
+}
+
+func (f *fakeServiceRepository) GetByID(ctx context.Context, id string) (*model.ServiceAccount, error) {
+	if account, ok := f.accounts[id]; ok {
+		return account, nil
+	}
+	return nil, errors.New("service not found")
+}
+
+func (f *fakeServiceRepository) Create(ctx context.Context, account *model.ServiceAccount) error {
+	f.accounts[account.ID] = account
+	return nil
+}
+
+func (f *fakeServiceRepository) Update(ctx context.Context, account *model.ServiceAccount) error {
+	f.accounts[account.ID] = account
+	return nil
+}
+
+func (f *fakeServiceRepository) Delete(ctx context.Context, id string) error {
+	delete(f.accounts, id)
+	return nil
+}
+
+func (f *fakeServiceRepository) List(ctx context.Context) ([]*model.ServiceAccount, error) {
+	accounts := make([]*model.ServiceAccount, 0, len(f.accounts))
+	for _, account := range f.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (f *fakeServiceRepository) UpdateLastUsed(ctx context.Context, id string) error { return nil }
+
+type reconcilerFixture struct {
+	source        *fakeManifestSource
+	services      *fakeServiceRepository
+	domainService *DomainServiceImpl
+	reconciler    *ReconcilerServiceImpl
+}
+
+func setupReconciler(t *testing.T, prune bool) *reconcilerFixture {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	domainRepo := &keyedDomainRepository{}
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	t.Cleanup(queueService.Cleanup)
+	waitQueueInit(t, queueService)
+	domainService := NewDomainService(domainRepo, queueService, ctx).(*DomainServiceImpl)
+
+	f := &reconcilerFixture{
+		source:        &fakeManifestSource{state: &model.DesiredState{}},
+		services:      &fakeServiceRepository{accounts: make(map[string]*model.ServiceAccount)},
+		domainService: domainService,
+	}
+	f.reconciler = NewReconcilerService(
+		f.source,
+		domainService,
+		queueService,
+		NewRoutingService(domainRepo, ctx),
+		f.services,
+		&mockLogger{},
+		prune,
+	)
+	return f
+}
+
+func shopManifest() model.DesiredDomain {
+	return model.DesiredDomain{
+		Name:   "shop",
+		Schema: &model.Schema{Fields: map[string]model.FieldType{"id": model.StringType}},
+		Labels: map[string]string{"team": "payments"},
+		Queues: []model.DesiredQueue{
+			{Name: "orders", Config: model.QueueConfig{IsPersistent: true, MaxSize: 100, TTL: time.Hour}},
+			{Name: "invoices", Config: model.QueueConfig{MaxSize: 10}},
+		},
+		Routes: []*model.RoutingRule{{
+			SourceQueue:      "orders",
+			DestinationQueue: "invoices",
+			Predicate:        model.JSONPredicate{Type: "eq", Field: "type", Value: "invoice"},
+		}},
+	}
+}
+
+func driftActions(report *model.ReconcileReport) map[string]string {
+	actions := make(map[string]string, len(report.Drift))
+	for _, drift := range report.Drift {
+		actions[drift.Kind+" "+drift.Name] = drift.Action
+	}
+	return actions
+}
+
+func TestReconcileCreatesThenConverges(t *testing.T) {
+	f := setupReconciler(t, false)
+	ctx := context.Background()
+
+	f.source.state = &model.DesiredState{
+		Domains: []model.DesiredDomain{shopManifest()},
+		ServiceAccounts: []model.DesiredServiceAccount{
+			{ID: "billing", Name: "Billing", Permissions: []string{"publish:shop"}, Enabled: true},
+		},
+	}
+
+	report, err := f.reconciler.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.InSync)
+	assert.Equal(t, map[string]string{
+		"domain shop":                 model.DriftCreate,
+		"queue shop/orders":           model.DriftCreate,
+		"queue shop/invoices":         model.DriftCreate,
+		"route shop/orders->invoices": model.DriftCreate,
+		"serviceAccount billing":      model.DriftCreate,
+	}, driftActions(report))
+	assert.Same(t, report, f.reconciler.LastReport())
+
+	domain, err := f.domainService.GetDomain(ctx, "shop")
+	require.NoError(t, err)
+	assert.Equal(t, model.ManagedByGitOps, domain.Labels[model.LabelManagedBy])
+	assert.Len(t, domain.Queues, 2)
+	assert.NotNil(t, domain.Routes["orders"]["invoices"])
+
+	account := f.services.accounts["billing"]
+	require.NotNil(t, account)
+	assert.Equal(t, model.ManagedByGitOps, account.ManagedBy)
+	assert.Len(t, account.Secret, 64)
+
+	report, err = f.reconciler.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.InSync)
+	assert.Empty(t, report.Drift, "a second pass finds nothing to do")
+}
+
+func TestReconcileUpdatesAndConflicts(t *testing.T) {
+	f := setupReconciler(t, false)
+	ctx := context.Background()
+
+	f.source.state = &model.DesiredState{Domains: []model.DesiredDomain{shopManifest()}}
+	_, err := f.reconciler.Reconcile(ctx, false)
+	require.NoError(t, err)
+
+	shop := shopManifest()
+	shop.Labels = map[string]string{"team": "billing"}
+	shop.Queues[0].Config.MaxSize = 500
+	shop.Routes[0].Predicate = model.JSONPredicate{Type: "eq", Field: "type", Value: "refund"}
+	f.source.state = &model.DesiredState{Domains: []model.DesiredDomain{shop}}
+
+	report, err := f.reconciler.Reconcile(ctx, false)
+	require.NoError(t, err)
+	assert.False(t, report.InSync, "conflicts are left to an operator")
+	assert.Equal(t, map[string]string{
+		"domain shop":                 model.DriftUpdate,
+		"queue shop/orders":           model.DriftConflict,
+		"route shop/orders->invoices": model.DriftUpdate,
+	}, driftActions(report))
+
+	domain, _ := f.domainService.GetDomain(ctx, "shop")
+	assert.Equal(t, "billing", domain.Labels["team"])
+	assert.Equal(t, 100, domain.Queues["orders"].Config.MaxSize)
+	assert.True(t, model.SamePredicate(shop.Routes[0].Predicate, domain.Routes["orders"]["invoices"].Predicate))
+}
+
+func TestReconcilePrune(t *testing.T) {
+	ctx := context.Background()
+
+	for _, prune := range []bool{false, true} {
+		f := setupReconciler(t, prune)
+
+		require.NoError(t, f.domainService.CreateDomain(ctx, &model.DomainConfig{Name: "manual"}))
+		f.source.state = &model.DesiredState{
+			Domains: []model.DesiredDomain{shopManifest(), {Name: "legacy"}},
+			ServiceAccounts: []model.DesiredServiceAccount{
+				{ID: "billing", Name: "Billing", Permissions: []string{"publish:shop"}, Enabled: true},
+			},
+		}
+		_, err := f.reconciler.Reconcile(ctx, false)
+		require.NoError(t, err)
+
+		shop := shopManifest()
+		shop.Queues = shop.Queues[:1]
+		shop.Routes = nil
+		f.source.state = &model.DesiredState{Domains: []model.DesiredDomain{shop}}
+
+		report, err := f.reconciler.Reconcile(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"route shop/orders->invoices": model.DriftDelete,
+			"queue shop/invoices":         model.DriftDelete,
+			"domain legacy":               model.DriftDelete,
+			"serviceAccount billing":      model.DriftDelete,
+		}, driftActions(report), "unmanaged resources are never deleted")
+		assert.Equal(t, prune, report.InSync)
+
+		_, err = f.domainService.GetDomain(ctx, "manual")
+		assert.NoError(t, err)
+		legacy, _ := f.domainService.GetDomain(ctx, "legacy")
+		assert.Equal(t, prune, legacy == nil)
+		_, kept := f.services.accounts["billing"]
+		assert.Equal(t, !prune, kept)
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	f := setupReconciler(t, true)
+	ctx := context.Background()
+
+	f.source.state = &model.DesiredState{Domains: []model.DesiredDomain{shopManifest()}}
+	report, err := f.reconciler.Reconcile(ctx, true)
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.False(t, report.InSync)
+	assert.Len(t, report.Drift, 4)
+	for _, drift := range report.Drift {
+		assert.False(t, drift.Applied)
+	}
+
+	domain, _ := f.domainService.GetDomain(ctx, "shop")
+	assert.Nil(t, domain, "a dry run changes nothing")
+	assert.Nil(t, f.reconciler.LastReport())
+}
+
+func TestReconcileInvalidManifests(t *testing.T) {
+	f := setupReconciler(t, true)
+	ctx := context.Background()
+
+	f.source.state = &model.DesiredState{Domains: []model.DesiredDomain{shopManifest()}}
+	_, err := f.reconciler.Reconcile(ctx, false)
+	require.NoError(t, err)
+
+	f.source.state, f.source.err = nil, errors.New("manifests.yaml: bad indentation")
+	report, err := f.reconciler.Reconcile(ctx, false)
+	require.Error(t, err)
+	assert.Equal(t, "manifests.yaml: bad indentation", report.Error)
+	assert.False(t, report.InSync)
+	assert.Empty(t, report.Drift)
+
+	_, err = f.domainService.GetDomain(ctx, "shop")
+	assert.NoError(t, err, "nothing is pruned when the manifests can't be read")
+}
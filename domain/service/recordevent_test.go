@@ -350,3 +350,70 @@ func TestRecordRoutingRuleCreated(t *testing.T) {
 		assert.Equal(t, "dest-queue", data["destination"])
 	})
 }
+
+func TestDomainEventRetention(t *testing.T) {
+	ctx := context.Background()
+	service := NewStatsService(ctx, &mockLogger{}, &mockDomainRepository{}, &mockMessageRepository{}).(*StatsServiceImpl)
+	defer service.Cleanup()
+	service.SetEventRetention(model.EventRetention{PerDomain: 10})
+
+	t.Run("a busy domain doesn't evict the others", func(t *testing.T) {
+		service.RecordQueueCreated("quiet", "orders")
+		for i := range 30 {
+			service.RecordEvent("test_event", "info", "busy.queue", i)
+		}
+
+		quiet, err := service.GetDomainEvents(ctx, "quiet", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, quiet.Events, 1)
+		assert.Equal(t, "queue_created", quiet.Events[0].EventType)
+
+		busy, err := service.GetDomainEvents(ctx, "busy", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, busy.Events, 10)
+		assert.Equal(t, 20, busy.Events[0].Data)
+	})
+
+	t.Run("pages follow the since sequence", func(t *testing.T) {
+		first, err := service.GetDomainEvents(ctx, "busy", 0, 4)
+		require.NoError(t, err)
+		require.Len(t, first.Events, 4)
+		assert.True(t, first.HasMore)
+
+		second, err := service.GetDomainEvents(ctx, "busy", first.Next, 100)
+		require.NoError(t, err)
+		assert.Len(t, second.Events, 6)
+		assert.False(t, second.HasMore)
+		assert.Greater(t, second.Events[0].Seq, first.Next)
+
+		// an updated event comes back after the last page
+		service.RecordQueueCapacity("busy", "queue", 95)
+		service.FlushEvents()
+		service.RecordQueueCapacity("busy", "queue", 97)
+		service.FlushEvents()
+		third, err := service.GetDomainEvents(ctx, "busy", second.Next, 100)
+		require.NoError(t, err)
+		require.Len(t, third.Events, 1)
+		assert.Equal(t, 97.0, third.Events[0].Data)
+
+		none, err := service.GetDomainEvents(ctx, "busy", third.Next, 100)
+		require.NoError(t, err)
+		assert.Empty(t, none.Events)
+		assert.Equal(t, third.Next, none.Next)
+	})
+
+	t.Run("events past the max age expire", func(t *testing.T) {
+		service.SetEventRetention(model.EventRetention{PerDomain: 10, MaxAge: time.Hour})
+		service.metrics.mu.Lock()
+		for i := range service.metrics.systemEvents {
+			if service.metrics.systemEvents[i].Domain == "quiet" {
+				service.metrics.systemEvents[i].Timestamp = time.Now().Add(-2 * time.Hour)
+			}
+		}
+		service.metrics.mu.Unlock()
+
+		quiet, err := service.GetDomainEvents(ctx, "quiet", 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, quiet.Events)
+	})
+}
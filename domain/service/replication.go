@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// SetStandby makes the node refuse publishes and consumes while the standby
+// follows its primary
+func (s *MessageServiceImpl) SetStandby(standby *StandbyServiceImpl) {
+	s.standby = standby
+}
+
+// readOnlyOnStandby reports whether a domain copied from the primary is
+// read-only, system domains stay local to each node
+func (s *MessageServiceImpl) readOnlyOnStandby(domainName string) bool {
+	if !s.standby.IsStandby() {
+		return false
+	}
+	domain, err := s.domainRepo.GetDomain(s.rootCtx, domainName)
+	return err != nil || !domain.System
+}
+
+// ReplicatedDomains describes the domains and queues standby replicas copy,
+// system domains are left out as each node creates its own
+func (s *MessageServiceImpl) ReplicatedDomains(ctx context.Context) ([]model.ReplicatedDomain, error) {
+	domains, err := s.domainRepo.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replicated := make([]model.ReplicatedDomain, 0, len(domains))
+	for _, domain := range domains {
+		if !domain.System {
+			replicated = append(replicated, model.NewReplicatedDomain(domain))
+		}
+	}
+	slices.SortFunc(replicated, func(a, b model.ReplicatedDomain) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return replicated, nil
+}
+
+// ReplicationBatch returns the messages stored in a queue, or a partition of
+// it, from index after on. Offloaded payloads are resolved, the standby gets
+// the messages as published.
+func (s *MessageServiceImpl) ReplicationBatch(ctx context.Context, domainName, storageQueue string, after int64, limit int) (*model.ReplicationBatch, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain.System {
+		return nil, ErrDomainNotFound
+	}
+	if !storedInDomain(domain, storageQueue) {
+		return nil, ErrQueueNotFound
+	}
+	if limit <= 0 {
+		limit = model.DefaultReplicationBatchSize
+	}
+	limit = min(limit, model.MaxReplicationBatchSize)
+	after = max(after, 0)
+
+	messages, err := s.messageRepo.GetMessagesAfterIndex(ctx, domainName, storageQueue, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &model.ReplicationBatch{
+		Messages: make([]*model.ArchivedMessage, 0, len(messages)),
+		Next:     after,
+	}
+	for _, stored := range messages {
+		resolved, err := s.claimCheck.resolve(ctx, stored)
+		if err != nil {
+			return nil, err
+		}
+		batch.Messages = append(batch.Messages, model.NewArchivedMessage(resolved))
+	}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		index, err := s.messageRepo.GetIndexByMessageID(ctx, domainName, storageQueue, last.ID)
+		if err != nil {
+			return nil, err
+		}
+		batch.Next = index + 1
+	}
+	return batch, nil
+}
+
+// storedInDomain reports whether messages of the domain are stored under
+// storageQueue, a queue or one of its partitions
+func storedInDomain(domain *model.Domain, storageQueue string) bool {
+	for _, queue := range domain.Queues {
+		if slices.Contains(queue.StorageQueues(), storageQueue) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/ajkula/GoRTMS/domain/port/inbound"
 	"github.com/ajkula/GoRTMS/domain/port/outbound"
 )
@@ -15,6 +16,7 @@ type ResourceMonitorServiceImpl struct {
 	domainRepo      outbound.DomainRepository
 	messageRepo     outbound.MessageRepository
 	queueService    inbound.QueueService
+	searchIndex     *model.SearchIndex
 	statsHistory    []*inbound.ResourceStats
 	lastStats       *inbound.ResourceStats
 	maxHistorySize  int
@@ -49,6 +51,13 @@ func NewResourceMonitorService(
 	return svc
 }
 
+// SetSearchIndex accounts the message search index in the domain stats
+func (s *ResourceMonitorServiceImpl) SetSearchIndex(index *model.SearchIndex) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searchIndex = index
+}
+
 func (s *ResourceMonitorServiceImpl) startCollection(ctx context.Context) {
 	ticker := time.NewTicker(s.collectInterval)
 	defer ticker.Stop()
@@ -83,6 +92,10 @@ func (s *ResourceMonitorServiceImpl) collectStats(ctx context.Context) {
 		DomainStats: make(map[string]inbound.DomainResourceInfo),
 	}
 
+	s.mu.RLock()
+	searchIndex := s.searchIndex
+	s.mu.RUnlock()
+
 	// by domain
 	domains, err := s.domainRepo.ListDomains(ctx)
 	if err == nil {
@@ -109,6 +122,11 @@ func (s *ResourceMonitorServiceImpl) collectStats(ctx context.Context) {
 				domainInfo.QueueStats[queueName] = queueInfo
 			}
 
+			if searchIndex != nil {
+				domainInfo.IndexedMessages, domainInfo.SearchIndexBytes = searchIndex.Size(domain.Name)
+				domainInfo.EstimatedMemory += domainInfo.SearchIndexBytes
+			}
+
 			stats.DomainStats[domain.Name] = domainInfo
 		}
 	}
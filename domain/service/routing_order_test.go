@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishRoutesByPriority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Queues: map[string]*model.Queue{
+			"orders":  {Name: "orders", DomainName: "shop"},
+			"vip":     {Name: "vip", DomainName: "shop"},
+			"billing": {Name: "billing", DomainName: "shop"},
+			"audit":   {Name: "audit", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	waitQueueInit(t, queueService)
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+	routing := NewRoutingService(domainRepo, ctx)
+
+	vip := model.JSONPredicate{Type: "eq", Field: "tier", Value: "vip"}
+	all := model.JSONPredicate{Type: "contains", Field: "tier", Value: ""}
+	require.NoError(t, routing.AddRoutingRule(ctx, "shop", &model.RoutingRule{
+		SourceQueue: "orders", DestinationQueue: "vip", Predicate: vip, Priority: 10, StopOnMatch: true,
+	}))
+	require.NoError(t, routing.AddRoutingRule(ctx, "shop", &model.RoutingRule{
+		SourceQueue: "orders", DestinationQueue: "billing", Predicate: all,
+	}))
+	require.NoError(t, routing.AddRoutingRule(ctx, "shop", &model.RoutingRule{
+		SourceQueue: "orders", DestinationQueue: "audit", Predicate: all,
+	}))
+
+	publish := func(id, tier string) {
+		require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{
+			ID: id, Payload: []byte(`{"tier":"` + tier + `"}`),
+		}))
+	}
+
+	publish("1", "vip")
+	publish("2", "standard")
+	assert.Len(t, messageRepo.messages["shop:vip"], 1)
+	assert.Len(t, messageRepo.messages["shop:billing"], 1, "the vip rule stops the evaluation")
+	assert.Len(t, messageRepo.messages["shop:audit"], 1)
+
+	disabled := false
+	_, err := routing.UpdateRoutingRule(ctx, "shop", "orders", "audit", model.RoutingRuleUpdate{Enabled: &disabled})
+	require.NoError(t, err)
+	publish("3", "standard")
+	assert.Len(t, messageRepo.messages["shop:audit"], 1, "disabled rules don't route")
+	assert.Len(t, messageRepo.messages["shop:billing"], 2)
+
+	stats, err := routing.GetRoutingRuleStats(ctx, "shop")
+	require.NoError(t, err)
+	matched := make(map[string]int64)
+	for _, rule := range stats {
+		matched[rule.Destination] = rule.Matched
+	}
+	assert.Equal(t, map[string]int64{"vip": 1, "billing": 2, "audit": 1}, matched)
+
+	// billing moves ahead of vip, vip messages now reach billing too
+	require.NoError(t, routing.ReorderRoutingRules(ctx, "shop", "orders", []string{"billing", "vip", "audit"}))
+	publish("4", "vip")
+	assert.Len(t, messageRepo.messages["shop:vip"], 2)
+	assert.Len(t, messageRepo.messages["shop:billing"], 3)
+
+	err = routing.ReorderRoutingRules(ctx, "shop", "orders", []string{"billing"})
+	assert.ErrorIs(t, err, model.ErrInvalidRuleOrder)
+	_, err = routing.UpdateRoutingRule(ctx, "shop", "orders", "missing", model.RoutingRuleUpdate{})
+	assert.ErrorIs(t, err, ErrRoutingRuleNotFound)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/ajkula/GoRTMS/domain/model"
 	"github.com/ajkula/GoRTMS/domain/port/inbound"
@@ -16,6 +18,8 @@ var (
 )
 
 type RoutingServiceImpl struct {
+	eventEmitter
+
 	domainRepo outbound.DomainRepository
 	rootCtx    context.Context
 }
@@ -41,8 +45,30 @@ func (s *RoutingServiceImpl) AddRoutingRule(ctx context.Context, domainName stri
 	if _, exists := domain.Queues[rule.SourceQueue]; !exists {
 		return ErrQueueNotFound
 	}
-	if _, exists := domain.Queues[rule.DestinationQueue]; !exists {
-		return ErrQueueNotFound
+
+	if rule.IsCrossDomain(domainName) {
+		// Cross-domain rules need an explicit grant from the destination domain
+		destDomain, err := s.domainRepo.GetDomain(ctx, rule.DestinationDomain)
+		if err != nil || destDomain == nil {
+			return ErrDomainNotFound
+		}
+		if _, exists := destDomain.Queues[rule.DestinationQueue]; !exists {
+			return ErrQueueNotFound
+		}
+		if !destDomain.AcceptsRoutesFrom(domainName) {
+			return model.ErrCrossDomainRouteDenied
+		}
+		if err := validateCanary(rule, destDomain); err != nil {
+			return err
+		}
+	} else {
+		if _, exists := domain.Queues[rule.DestinationQueue]; !exists {
+			return ErrQueueNotFound
+		}
+		if err := validateCanary(rule, domain); err != nil {
+			return err
+		}
+		rule.DestinationDomain = ""
 	}
 
 	if domain.Routes == nil {
@@ -54,13 +80,40 @@ func (s *RoutingServiceImpl) AddRoutingRule(ctx context.Context, domainName stri
 		domain.Routes[rule.SourceQueue] = make(map[string]*model.RoutingRule)
 	}
 
-	if _, exists := domain.Routes[rule.SourceQueue][rule.DestinationQueue]; exists {
+	destKey := rule.DestinationKey(domainName)
+	if _, exists := domain.Routes[rule.SourceQueue][destKey]; exists {
 		return ErrRoutingRuleAlreadyExists
 	}
 
-	domain.Routes[rule.SourceQueue][rule.DestinationQueue] = rule
+	domain.Routes[rule.SourceQueue][destKey] = rule
 
-	return s.domainRepo.StoreDomain(ctx, domain)
+	if err := s.domainRepo.StoreDomain(ctx, domain); err != nil {
+		return err
+	}
+
+	s.emit(model.DomainEvent{
+		Kind:   model.EventRoutingRuleCreated,
+		Domain: domainName,
+		Queue:  rule.SourceQueue,
+		Data:   map[string]any{"destination": destKey},
+	})
+
+	return nil
+}
+
+// validateCanary checks the canary split of a rule, whose alternate queue
+// lives next to its destination
+func validateCanary(rule *model.RoutingRule, destDomain *model.Domain) error {
+	if rule.Canary == nil {
+		return nil
+	}
+	if err := rule.Canary.Validate(rule.DestinationQueue); err != nil {
+		return err
+	}
+	if _, exists := destDomain.Queues[rule.Canary.Queue]; !exists {
+		return ErrQueueNotFound
+	}
+	return nil
 }
 
 func (s *RoutingServiceImpl) RemoveRoutingRule(ctx context.Context, domainName string, sourceQueue, destQueue string) error {
@@ -106,6 +159,71 @@ func (s *RoutingServiceImpl) ListRoutingRules(ctx context.Context, domainName st
 	return rules, nil
 }
 
+// UpdateRoutingRule changes how a rule is evaluated, keeping its stats
+func (s *RoutingServiceImpl) UpdateRoutingRule(
+	ctx context.Context,
+	domainName, sourceQueue, destKey string,
+	update model.RoutingRuleUpdate,
+) (*model.RoutingRule, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+
+	rule := domain.Routes[sourceQueue][destKey]
+	if rule == nil {
+		return nil, ErrRoutingRuleNotFound
+	}
+	rule.Apply(update)
+
+	if err := s.domainRepo.StoreDomain(ctx, domain); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// ReorderRoutingRules gives the rules of a source queue decreasing
+// priorities, the first of order being evaluated first
+func (s *RoutingServiceImpl) ReorderRoutingRules(ctx context.Context, domainName, sourceQueue string, order []string) error {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return ErrDomainNotFound
+	}
+
+	rules := domain.Routes[sourceQueue]
+	if len(rules) == 0 {
+		return ErrRoutingRuleNotFound
+	}
+	if err := model.ValidateRuleOrder(rules, order); err != nil {
+		return err
+	}
+
+	for i, key := range order {
+		rules[key].Priority = len(order) - i
+	}
+	return s.domainRepo.StoreDomain(ctx, domain)
+}
+
+// GetRoutingRuleStats reports the traffic of the rules of a domain, ordered
+// by rule ID
+func (s *RoutingServiceImpl) GetRoutingRuleStats(ctx context.Context, domainName string) ([]model.RoutingRuleStats, error) {
+	domain, err := s.domainRepo.GetDomain(ctx, domainName)
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+
+	stats := make([]model.RoutingRuleStats, 0)
+	for _, sourceRules := range domain.Routes {
+		for _, rule := range sourceRules {
+			stats = append(stats, rule.Stats(domainName))
+		}
+	}
+	slices.SortFunc(stats, func(a, b model.RoutingRuleStats) int {
+		return strings.Compare(a.Rule, b.Rule)
+	})
+	return stats, nil
+}
+
 func (s *RoutingServiceImpl) Cleanup() {
 	log.Println("Cleaning up routing service resources...")
 	// noop
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRoutingRuleCrossDomain(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "orders",
+		Queues: map[string]*model.Queue{"incoming": {Name: "incoming", DomainName: "orders"}},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "audit",
+		Queues: map[string]*model.Queue{"events": {Name: "events", DomainName: "audit"}},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	svc := NewRoutingService(domainRepo, ctx)
+	rule := &model.RoutingRule{
+		SourceQueue:       "incoming",
+		DestinationQueue:  "events",
+		DestinationDomain: "audit",
+	}
+
+	t.Run("denied without grant", func(t *testing.T) {
+		err := svc.AddRoutingRule(ctx, "orders", rule)
+		assert.ErrorIs(t, err, model.ErrCrossDomainRouteDenied)
+	})
+
+	t.Run("allowed once destination grants source", func(t *testing.T) {
+		audit, _ := domainRepo.GetDomain(ctx, "audit")
+		audit.AllowedRouteSources = []string{"orders"}
+
+		require.NoError(t, svc.AddRoutingRule(ctx, "orders", rule))
+
+		orders, _ := domainRepo.GetDomain(ctx, "orders")
+		assert.Contains(t, orders.Routes["incoming"], "audit:events")
+	})
+
+	t.Run("unknown destination domain", func(t *testing.T) {
+		err := svc.AddRoutingRule(ctx, "orders", &model.RoutingRule{
+			SourceQueue:       "incoming",
+			DestinationQueue:  "events",
+			DestinationDomain: "missing",
+		})
+		assert.ErrorIs(t, err, ErrDomainNotFound)
+	})
+}
+
+func TestAddRoutingRuleCanary(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "orders",
+		Queues: map[string]*model.Queue{
+			"incoming":  {Name: "incoming", DomainName: "orders"},
+			"billing":   {Name: "billing", DomainName: "orders"},
+			"billing-2": {Name: "billing-2", DomainName: "orders"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+	svc := NewRoutingService(domainRepo, ctx)
+
+	err := svc.AddRoutingRule(ctx, "orders", &model.RoutingRule{
+		SourceQueue:      "incoming",
+		DestinationQueue: "billing",
+		Canary:           &model.CanarySplit{Queue: "billing", Percent: 10},
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidCanary)
+
+	err = svc.AddRoutingRule(ctx, "orders", &model.RoutingRule{
+		SourceQueue:      "incoming",
+		DestinationQueue: "billing",
+		Canary:           &model.CanarySplit{Queue: "missing", Percent: 10},
+	})
+	assert.ErrorIs(t, err, ErrQueueNotFound)
+
+	rule := &model.RoutingRule{
+		SourceQueue:      "incoming",
+		DestinationQueue: "billing",
+		Canary:           &model.CanarySplit{Queue: "billing-2", Percent: 100},
+	}
+	require.NoError(t, svc.AddRoutingRule(ctx, "orders", rule))
+	rule.RecordRouted(true)
+
+	stats, err := svc.GetRoutingRuleStats(ctx, "orders")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(1), stats[0].Routed)
+	assert.Equal(t, float64(100), stats[0].Canary.ObservedPercent)
+
+	_, err = svc.GetRoutingRuleStats(ctx, "missing")
+	assert.ErrorIs(t, err, ErrDomainNotFound)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishRecordsSchemaRejections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "shop",
+		Schema: &model.Schema{Fields: map[string]model.FieldType{
+			"id":    model.StringType,
+			"total": model.NumberType,
+		}},
+		Queues: map[string]*model.Queue{
+			"orders": {Name: "orders", DomainName: "shop"},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+
+	err := svc.PublishMessage("shop", "orders", &model.Message{ID: "bad-1", Payload: []byte(`{"total":"12"}`)})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+	var validationErr *model.SchemaValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []model.SchemaViolation{
+		{Field: "id", Expected: "string", Got: "missing"},
+		{Field: "total", Expected: "number", Got: "string"},
+	}, validationErr.Violations)
+
+	require.NoError(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "ok", Payload: []byte(`{"id":"o-1","total":12}`)}))
+	assert.Error(t, svc.PublishMessage("shop", "orders", &model.Message{ID: "bad-2", Payload: []byte(`[1]`)}))
+
+	rejections, total := svc.GetSchemaRejections("shop", "orders")
+	assert.Equal(t, int64(2), total)
+	require.Len(t, rejections, 2)
+	assert.Equal(t, "bad-2", rejections[0].MessageID)
+	assert.Equal(t, []model.SchemaViolation{{Expected: "object", Got: "array"}}, rejections[0].Violations)
+	assert.Equal(t, "bad-1", rejections[1].MessageID)
+
+	_, total = svc.GetSchemaRejections("shop", "payments")
+	assert.Zero(t, total)
+}
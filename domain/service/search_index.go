@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// searchPruneInterval is how often the index drops the messages no longer stored
+const searchPruneInterval = 5 * time.Minute
+
+// SetSearchIndex indexes the published messages for SearchMessages and
+// prunes the index until ctx is done
+func (s *MessageServiceImpl) SetSearchIndex(ctx context.Context, index *model.SearchIndex) {
+	s.searchIndex = index
+
+	go func() {
+		ticker := time.NewTicker(searchPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.pruneSearchIndex(ctx)
+			}
+		}
+	}()
+}
+
+// indexMessage adds a stored message to the search index, the messages
+// stored before the domain was first indexed are indexed with it
+func (s *MessageServiceImpl) indexMessage(domain *model.Domain, queueName, storageQueue string, message *model.Message) {
+	if s.searchIndex == nil {
+		return
+	}
+	fields := s.searchIndex.Fields(domain.Name, domain.Schema)
+	if len(fields) == 0 {
+		return
+	}
+	if !s.searchIndex.Started(domain.Name) {
+		s.indexStoredMessages(domain, fields)
+	}
+	s.addToSearchIndex(domain.Name, fields, model.SearchHit{
+		MessageID:    message.ID,
+		Queue:        queueName,
+		StorageQueue: storageQueue,
+		Timestamp:    message.Timestamp,
+	}, message.Payload)
+}
+
+// indexStoredMessages indexes the messages already stored in a domain
+func (s *MessageServiceImpl) indexStoredMessages(domain *model.Domain, fields []string) {
+	for queueName, queue := range domain.Queues {
+		for _, storageQueue := range queue.StorageQueues() {
+			reader, _ := s.scanReader(s.messageRepo, domain.Name, queue, storageQueue)
+			count := reader.GetQueueMessageCount(domain.Name, storageQueue)
+			if count == 0 {
+				continue
+			}
+			messages, err := reader.GetMessagesAfterIndex(s.rootCtx, domain.Name, storageQueue, 0, count)
+			if err != nil {
+				s.logger.Warn("Queue not indexed for search",
+					"queue", domain.Name+"."+storageQueue,
+					"ERROR", err)
+				continue
+			}
+			for _, stored := range messages {
+				resolved, err := s.claimCheck.resolve(s.rootCtx, stored)
+				if err != nil {
+					continue
+				}
+				s.addToSearchIndex(domain.Name, fields, model.SearchHit{
+					MessageID:    stored.ID,
+					Queue:        queueName,
+					StorageQueue: storageQueue,
+					Timestamp:    stored.Timestamp,
+				}, resolved.Payload)
+			}
+		}
+	}
+}
+
+// addToSearchIndex indexes the fields of a JSON object payload
+func (s *MessageServiceImpl) addToSearchIndex(domainName string, fields []string, hit model.SearchHit, payload []byte) {
+	var object map[string]any
+	if json.Unmarshal(payload, &object) != nil {
+		return
+	}
+	s.searchIndex.Add(domainName, fields, hit, object)
+}
+
+// SearchMessages returns the stored messages holding every queried field
+// value, in every domain indexing the fields when domainName is empty. The
+// oldest messages come first.
+func (s *MessageServiceImpl) SearchMessages(ctx context.Context, domainName string, query map[string]string, limit int) ([]*model.Message, error) {
+	if s.searchIndex == nil {
+		return nil, model.ErrSearchDisabled
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("%w: no field queried", model.ErrInvalidSearch)
+	}
+	if limit <= 0 {
+		limit = model.DefaultSearchLimit
+	}
+	limit = min(limit, model.MaxSearchLimit)
+
+	var domains []*model.Domain
+	if domainName != "" {
+		domain, err := s.domainRepo.GetDomain(ctx, domainName)
+		if err != nil {
+			return nil, ErrDomainNotFound
+		}
+		domains = append(domains, domain)
+	} else {
+		all, err := s.domainRepo.ListDomains(ctx)
+		if err != nil {
+			return nil, err
+		}
+		domains = all
+	}
+
+	type domainHit struct {
+		domainName string
+		queue      *model.Queue
+		model.SearchHit
+	}
+	var hits []domainHit
+	searched := false
+	for _, domain := range domains {
+		fields := s.searchIndex.Fields(domain.Name, domain.Schema)
+		indexed := true
+		for field := range query {
+			indexed = indexed && slices.Contains(fields, field)
+		}
+		if !indexed {
+			continue
+		}
+		searched = true
+
+		if !s.searchIndex.Started(domain.Name) {
+			s.indexStoredMessages(domain, fields)
+		}
+		for _, hit := range s.searchIndex.Search(domain.Name, query) {
+			hits = append(hits, domainHit{domainName: domain.Name, queue: domain.Queues[hit.Queue], SearchHit: hit})
+		}
+	}
+	if !searched {
+		return nil, fmt.Errorf("%w: fields not indexed", model.ErrInvalidSearch)
+	}
+
+	// only the messages returned are read from storage
+	slices.SortFunc(hits, func(a, b domainHit) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	results := make([]*model.Message, 0, min(limit, len(hits)))
+	for _, hit := range hits {
+		if len(results) == limit {
+			break
+		}
+		reader, replica := s.scanReader(s.messageRepo, hit.domainName, hit.queue, hit.StorageQueue)
+		stored, err := reader.GetMessage(ctx, hit.domainName, hit.StorageQueue, hit.MessageID)
+		if replica && (err != nil || stored == nil) {
+			// published after the replica was last fed
+			stored, err = s.messageRepo.GetMessage(ctx, hit.domainName, hit.StorageQueue, hit.MessageID)
+		}
+		if err != nil || stored == nil {
+			// consumed or deleted since it was indexed
+			s.searchIndex.Remove(hit.domainName, hit.SearchHit)
+			continue
+		}
+		resolved, err := s.claimCheck.resolve(ctx, stored)
+		if err != nil {
+			continue
+		}
+		results = append(results, resolved)
+	}
+	return results, nil
+}
+
+// pruneSearchIndex drops the messages consumed or deleted since they were indexed
+func (s *MessageServiceImpl) pruneSearchIndex(ctx context.Context) {
+	pruned := 0
+	for domainName, hits := range s.searchIndex.Entries() {
+		if _, err := s.domainRepo.GetDomain(ctx, domainName); err != nil {
+			s.searchIndex.DropDomain(domainName)
+			continue
+		}
+		for _, hit := range hits {
+			if stored, err := s.messageRepo.GetMessage(ctx, domainName, hit.StorageQueue, hit.MessageID); err != nil || stored == nil {
+				s.searchIndex.Remove(domainName, hit)
+				pruned++
+			}
+		}
+	}
+	if pruned > 0 {
+		s.logger.Debug("Search index pruned", "messages", pruned)
+	}
+}
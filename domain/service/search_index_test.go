@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	schema := &model.Schema{Fields: map[string]model.FieldType{
+		"customerId": model.StringType,
+		"status":     model.StringType,
+	}}
+	for _, name := range []string{"shop", "billing"} {
+		domainRepo.StoreDomain(ctx, &model.Domain{
+			Name:   name,
+			Schema: schema,
+			Queues: map[string]*model.Queue{
+				"orders": {Name: "orders", DomainName: name},
+			},
+			Routes: make(map[string]map[string]*model.RoutingRule),
+		})
+	}
+
+	// stored before the index, indexed on first use
+	messageRepo.StoreMessage(ctx, "shop", "orders", &model.Message{
+		ID:        "early",
+		Payload:   []byte(`{"customerId":"c-1","status":"new"}`),
+		Metadata:  map[string]any{"domain": "shop", "queue": "orders"},
+		Timestamp: time.Now().Add(-time.Hour),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	defer queueService.Cleanup()
+	svc := newBareMessageService(ctx, domainRepo, messageRepo, queueService, &recordingBus{})
+
+	_, err := svc.SearchMessages(ctx, "shop", map[string]string{"customerId": "c-1"}, 0)
+	assert.ErrorIs(t, err, model.ErrSearchDisabled)
+
+	svc.SetSearchIndex(ctx, model.NewSearchIndex([]string{"customerId"}, map[string][]string{"shop": {"status"}}))
+
+	for _, publish := range []struct{ domain, id, payload string }{
+		{"shop", "paid", `{"customerId":"c-1","status":"paid"}`},
+		{"shop", "other", `{"customerId":"c-2","status":"paid"}`},
+		{"billing", "invoice", `{"customerId":"c-1","status":"paid"}`},
+	} {
+		require.NoError(t, svc.PublishMessage(publish.domain, "orders", &model.Message{ID: publish.id, Payload: []byte(publish.payload)}))
+	}
+
+	ids := func(messages []*model.Message) []string {
+		var result []string
+		for _, message := range messages {
+			result = append(result, message.ID)
+		}
+		return result
+	}
+
+	messages, err := svc.SearchMessages(ctx, "shop", map[string]string{"customerId": "c-1"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"early", "paid"}, ids(messages))
+
+	messages, err = svc.SearchMessages(ctx, "shop", map[string]string{"customerId": "c-1", "status": "paid"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"paid"}, ids(messages))
+
+	// every domain indexing the field
+	messages, err = svc.SearchMessages(ctx, "", map[string]string{"customerId": "c-1"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"early", "paid"}, ids(messages))
+
+	// status is only indexed in shop
+	messages, err = svc.SearchMessages(ctx, "", map[string]string{"status": "paid"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"paid", "other"}, ids(messages))
+
+	_, err = svc.SearchMessages(ctx, "billing", map[string]string{"status": "paid"}, 0)
+	assert.ErrorIs(t, err, model.ErrInvalidSearch)
+	_, err = svc.SearchMessages(ctx, "shop", nil, 0)
+	assert.ErrorIs(t, err, model.ErrInvalidSearch)
+
+	// deleted messages are dropped from the index
+	require.NoError(t, messageRepo.DeleteMessage(ctx, "shop", "orders", "paid"))
+	messages, err = svc.SearchMessages(ctx, "shop", map[string]string{"customerId": "c-1"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"early"}, ids(messages))
+	indexed, _ := svc.searchIndex.Size("shop")
+	assert.Equal(t, 2, indexed)
+}
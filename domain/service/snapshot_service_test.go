@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSnapshotService(t *testing.T) (*SnapshotServiceImpl, *mockMessageRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name: "orders",
+		Queues: map[string]*model.Queue{
+			"incoming": {
+				Name:       "incoming",
+				DomainName: "orders",
+				Config:     model.QueueConfig{MaxSize: 50, TTL: time.Minute},
+			},
+		},
+		Routes: make(map[string]map[string]*model.RoutingRule),
+	})
+
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	t.Cleanup(queueService.Cleanup)
+	waitQueueInit(t, queueService)
+
+	svc := NewSnapshotService(&mockLogger{}, domainRepo, messageRepo, queueService).(*SnapshotServiceImpl)
+	return svc, messageRepo
+}
+
+func TestSnapshotQueue(t *testing.T) {
+	svc, messageRepo := setupSnapshotService(t)
+	ctx := context.Background()
+
+	messageRepo.StoreMessage(ctx, "orders", "incoming", &model.Message{
+		ID:        "msg-1",
+		Payload:   []byte(`{"amount":10}`),
+		Headers:   map[string]string{"Content-Type": "application/json"},
+		Metadata:  map[string]any{"retry_info": &model.MessageWithRetry{}, "source": "api"},
+		Timestamp: time.Now(),
+	})
+	messageRepo.StoreMessage(ctx, "orders", "incoming", &model.Message{
+		ID:      "msg-2",
+		Payload: []byte(`{"amount":20}`),
+	})
+
+	archive, err := svc.SnapshotQueue(ctx, "orders", "incoming")
+	require.NoError(t, err)
+
+	assert.Equal(t, model.QueueArchiveVersion, archive.Version)
+	assert.Equal(t, 2, archive.Count)
+	assert.Equal(t, 50, archive.Config.MaxSize)
+	assert.Equal(t, "msg-1", archive.Messages[0].ID)
+	assert.Equal(t, "application/json", archive.Messages[0].Headers["Content-Type"])
+
+	// Non-serializable metadata is dropped, the rest survives a JSON round trip
+	_, hasRetry := archive.Messages[0].Metadata["retry_info"]
+	assert.False(t, hasRetry)
+	assert.Equal(t, "api", archive.Messages[0].Metadata["source"])
+
+	_, err = json.Marshal(archive)
+	assert.NoError(t, err)
+
+	_, err = svc.SnapshotQueue(ctx, "orders", "missing")
+	assert.ErrorIs(t, err, ErrQueueNotFound)
+}
+
+func TestRestoreQueue(t *testing.T) {
+	svc, messageRepo := setupSnapshotService(t)
+	ctx := context.Background()
+
+	archive := &model.QueueArchive{
+		Version: model.QueueArchiveVersion,
+		Domain:  "orders",
+		Queue:   "incoming",
+		Config:  model.QueueConfig{MaxSize: 20},
+		Messages: []*model.ArchivedMessage{
+			{ID: "msg-1", Payload: []byte(`{"amount":10}`)},
+			{ID: "msg-2", Payload: []byte(`{"amount":20}`)},
+			{ID: ""},
+		},
+	}
+
+	t.Run("creates missing target queue", func(t *testing.T) {
+		result, err := svc.RestoreQueue(ctx, "orders", "replay", archive)
+		require.NoError(t, err)
+
+		assert.True(t, result.QueueCreated)
+		assert.Equal(t, 2, result.Restored)
+		assert.Equal(t, 1, result.Skipped)
+		assert.Equal(t, 2, messageRepo.GetQueueMessageCount("orders", "replay"))
+
+		restored, _ := messageRepo.GetMessage(ctx, "orders", "replay", "msg-1")
+		require.NotNil(t, restored)
+		assert.Equal(t, "replay", restored.Metadata["queue"])
+	})
+
+	t.Run("restoring twice does not duplicate", func(t *testing.T) {
+		result, err := svc.RestoreQueue(ctx, "orders", "replay", archive)
+		require.NoError(t, err)
+
+		assert.False(t, result.QueueCreated)
+		assert.Equal(t, 0, result.Restored)
+		assert.Equal(t, 3, result.Skipped)
+		assert.Equal(t, 2, messageRepo.GetQueueMessageCount("orders", "replay"))
+	})
+
+	t.Run("rejects unknown version", func(t *testing.T) {
+		_, err := svc.RestoreQueue(ctx, "orders", "replay", &model.QueueArchive{Version: 99})
+		assert.ErrorIs(t, err, ErrUnsupportedArchiveVersion)
+	})
+
+	t.Run("rejects unknown domain", func(t *testing.T) {
+		_, err := svc.RestoreQueue(ctx, "unknown", "replay", archive)
+		assert.ErrorIs(t, err, ErrDomainNotFound)
+	})
+}
+
+func TestSnapshotRoundTripOffloadedPayload(t *testing.T) {
+	svc, messageRepo := setupSnapshotService(t)
+	ctx := context.Background()
+
+	store := &mockBlobStore{blobs: make(map[string][]byte)}
+	svc.SetBlobStore(store, 8)
+	cc := &claimCheck{store: store, threshold: 8}
+
+	payload := []byte(`{"amount":10,"note":"large enough"}`)
+	stored, err := cc.offload(ctx, "orders", "incoming", &model.Message{
+		ID: "msg-1", Payload: payload, Metadata: map[string]any{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, messageRepo.StoreMessage(ctx, "orders", "incoming", stored))
+
+	archive, err := svc.SnapshotQueue(ctx, "orders", "incoming")
+	require.NoError(t, err)
+	require.Len(t, archive.Messages, 1)
+	assert.Equal(t, payload, archive.Messages[0].Payload, "the archive holds the payload")
+	assert.NotContains(t, archive.Messages[0].Metadata, claimCheckKey)
+
+	// the original is consumed and its blob released
+	require.NoError(t, cc.release(ctx, stored))
+
+	result, err := svc.RestoreQueue(ctx, "orders", "replay", archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Restored)
+
+	restored, err := messageRepo.GetMessage(ctx, "orders", "replay", "msg-1")
+	require.NoError(t, err)
+	assert.Nil(t, restored.Payload, "offloaded again")
+	resolved, err := cc.resolve(ctx, restored)
+	require.NoError(t, err)
+	assert.Equal(t, payload, resolved.Payload)
+
+	// archives that only point to a released blob are skipped
+	archive.Messages[0].Payload = nil
+	archive.Messages[0].Metadata = map[string]any{claimCheckKey: "orders/incoming/msg-1"}
+	result, err = svc.RestoreQueue(ctx, "orders", "other", archive)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Restored)
+	assert.Equal(t, 1, result.Skipped)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// StandbyServiceImpl keeps a warm copy of a primary until it is promoted:
+// it pulls the domains, queues and messages of the primary, and the node
+// refuses publishes and consumes meanwhile. Messages are stored as they
+// were on the primary, without routing, mirroring or delivery. Messages
+// consumed on the primary once copied stay on the standby, consumers of a
+// promoted standby may get them again.
+type StandbyServiceImpl struct {
+	source        outbound.ReplicationSource
+	primary       string
+	domainService inbound.DomainService
+	queueService  inbound.QueueService
+	messageRepo   outbound.MessageRepository
+	logger        outbound.Logger
+	batchSize     int
+
+	standby  atomic.Bool
+	promoted chan struct{} // closed on promotion, stops the pulls
+
+	running    sync.Mutex // one pass at a time
+	mu         sync.RWMutex
+	queues     map[string]*model.ReplicatedQueue // domain/storage queue -> progress
+	lastSync   *time.Time
+	lastError  string
+	promotedAt *time.Time
+}
+
+func NewStandbyService(
+	source outbound.ReplicationSource,
+	primary string,
+	domainService inbound.DomainService,
+	queueService inbound.QueueService,
+	messageRepo outbound.MessageRepository,
+	logger outbound.Logger,
+	batchSize int,
+) *StandbyServiceImpl {
+	if batchSize <= 0 {
+		batchSize = model.DefaultReplicationBatchSize
+	}
+	s := &StandbyServiceImpl{
+		source:        source,
+		primary:       primary,
+		domainService: domainService,
+		queueService:  queueService,
+		messageRepo:   messageRepo,
+		logger:        logger,
+		batchSize:     batchSize,
+		promoted:      make(chan struct{}),
+		queues:        make(map[string]*model.ReplicatedQueue),
+	}
+	s.standby.Store(true)
+	return s
+}
+
+// IsStandby reports whether the node still follows its primary
+func (s *StandbyServiceImpl) IsStandby() bool {
+	return s != nil && s.standby.Load()
+}
+
+// Run pulls from the primary every interval, until promoted or ctx is done
+func (s *StandbyServiceImpl) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = model.DefaultReplicationInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sync(ctx); err != nil && s.IsStandby() {
+			s.logger.Warn("Standby replication failed", "primary", s.primary, "ERROR", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.promoted:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync copies what the primary got since the last pass: new domains and
+// queues first, then the messages of every queue
+func (s *StandbyServiceImpl) Sync(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	err := s.sync(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastError = err.Error()
+		return err
+	}
+	now := time.Now()
+	s.lastSync = &now
+	s.lastError = ""
+	return nil
+}
+
+func (s *StandbyServiceImpl) sync(ctx context.Context) error {
+	domains, err := s.source.Domains(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, replicated := range domains {
+		if err := s.syncDomain(ctx, replicated); err != nil {
+			return err
+		}
+	}
+
+	for _, replicated := range domains {
+		domain, err := s.domainService.GetDomain(ctx, replicated.Name)
+		if err != nil {
+			return err
+		}
+		queueNames := make([]string, 0, len(domain.Queues))
+		for name := range domain.Queues {
+			queueNames = append(queueNames, name)
+		}
+		slices.Sort(queueNames)
+
+		for _, queueName := range queueNames {
+			for _, storageQueue := range domain.Queues[queueName].StorageQueues() {
+				if err := s.pull(ctx, domain.Name, queueName, storageQueue); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// syncDomain creates a domain of the primary, or the queues it lacks
+func (s *StandbyServiceImpl) syncDomain(ctx context.Context, replicated model.ReplicatedDomain) error {
+	domain, err := s.domainService.GetDomain(ctx, replicated.Name)
+	if err != nil || domain == nil {
+		config := &model.DomainConfig{
+			Name:                replicated.Name,
+			QueueConfigs:        replicated.Queues,
+			AllowedRouteSources: replicated.AllowedRouteSources,
+			Labels:              replicated.Labels,
+		}
+		if len(replicated.Fields) > 0 {
+			config.Schema = &model.Schema{Fields: replicated.Fields}
+		}
+		if err := s.domainService.CreateDomain(ctx, config); err != nil {
+			return err
+		}
+		s.logger.Info("Domain replicated from primary", "domain", replicated.Name)
+		return nil
+	}
+
+	for queueName, queueConfig := range replicated.Queues {
+		if _, exists := domain.Queues[queueName]; exists {
+			continue
+		}
+		if err := s.queueService.CreateQueue(ctx, replicated.Name, queueName, &queueConfig); err != nil {
+			return err
+		}
+		s.logger.Info("Queue replicated from primary", "domain", replicated.Name, "queue", queueName)
+	}
+	return nil
+}
+
+// pull copies the messages of a queue, or a partition, batch after batch
+// until it caught up with the primary
+func (s *StandbyServiceImpl) pull(ctx context.Context, domainName, queueName, storageQueue string) error {
+	key := domainName + "/" + storageQueue
+	s.mu.Lock()
+	progress, exists := s.queues[key]
+	if !exists {
+		progress = &model.ReplicatedQueue{Domain: domainName, StorageQueue: storageQueue}
+		s.queues[key] = progress
+	}
+	next := progress.Next
+	s.mu.Unlock()
+
+	for s.IsStandby() {
+		batch, err := s.source.Messages(ctx, domainName, storageQueue, next, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		copied := int64(0)
+		for _, archived := range batch.Messages {
+			if archived == nil || archived.ID == "" {
+				continue
+			}
+			// a pass interrupted midway pulls its last batch again
+			if existing, err := s.messageRepo.GetMessage(ctx, domainName, storageQueue, archived.ID); err == nil && existing != nil {
+				continue
+			}
+			message := archived.ToMessage()
+			message.Metadata["domain"] = domainName
+			message.Metadata["queue"] = queueName
+			if err := s.messageRepo.StoreMessage(ctx, domainName, storageQueue, message); err != nil {
+				return err
+			}
+			copied++
+		}
+
+		s.mu.Lock()
+		progress.Next = max(batch.Next, next)
+		progress.Pulled += copied
+		progress.LastPull = time.Now()
+		s.mu.Unlock()
+
+		if len(batch.Messages) < s.batchSize || batch.Next <= next {
+			return nil
+		}
+		next = batch.Next
+	}
+	return nil
+}
+
+// Promote stops following the primary and opens the node to publishes and
+// consumes, the primary must be stopped or fenced first
+func (s *StandbyServiceImpl) Promote() error {
+	if !s.standby.CompareAndSwap(true, false) {
+		return model.ErrNotStandby
+	}
+	close(s.promoted)
+
+	// let a pass in progress end
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	now := time.Now()
+	s.mu.Lock()
+	s.promotedAt = &now
+	s.mu.Unlock()
+
+	s.logger.Warn("Standby promoted, the node no longer follows its primary", "primary", s.primary)
+	return nil
+}
+
+// Status reports the role of the node and its progress on each queue
+func (s *StandbyServiceImpl) Status() model.ReplicationStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := model.ReplicationStatus{
+		Role:       model.ReplicaRoleStandby,
+		Primary:    s.primary,
+		LastSync:   s.lastSync,
+		LastError:  s.lastError,
+		PromotedAt: s.promotedAt,
+		Queues:     make([]model.ReplicatedQueue, 0, len(s.queues)),
+	}
+	if !s.IsStandby() {
+		status.Role = model.ReplicaRolePrimary
+	}
+	for _, progress := range s.queues {
+		status.Queues = append(status.Queues, *progress)
+	}
+	sort.Slice(status.Queues, func(i, j int) bool {
+		a, b := status.Queues[i], status.Queues[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.StorageQueue < b.StorageQueue
+	})
+	return status
+}
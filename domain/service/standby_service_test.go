@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReplicationSource serves a fixed primary from memory
+type fakeReplicationSource struct {
+	domains  []model.ReplicatedDomain
+	messages map[string][]*model.ArchivedMessage // key: "domain/queue"
+}
+
+func (f *fakeReplicationSource) Domains(ctx context.Context) ([]model.ReplicatedDomain, error) {
+	return f.domains, nil
+}
+
+func (f *fakeReplicationSource) Messages(ctx context.Context, domainName, storageQueue string, after int64, limit int) (*model.ReplicationBatch, error) {
+	messages := f.messages[domainName+"/"+storageQueue]
+	start := min(int(after), len(messages))
+	end := min(start+limit, len(messages))
+	return &model.ReplicationBatch{Messages: messages[start:end], Next: int64(end)}, nil
+}
+
+func setupStandbyService(t *testing.T, source *fakeReplicationSource) (*StandbyServiceImpl, *mockMessageRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+	queueService := NewQueueService(ctx, &mockLogger{}, domainRepo, nil)
+	t.Cleanup(queueService.Cleanup)
+	waitQueueInit(t, queueService)
+	domainService := NewDomainService(domainRepo, queueService, ctx)
+
+	svc := NewStandbyService(source, "http://primary:8080", domainService, queueService, messageRepo, &mockLogger{}, 2)
+	return svc, messageRepo
+}
+
+func TestStandbySync(t *testing.T) {
+	source := &fakeReplicationSource{
+		domains: []model.ReplicatedDomain{{
+			Name:   "orders",
+			Queues: map[string]model.QueueConfig{"incoming": {MaxSize: 50}},
+		}},
+		messages: map[string][]*model.ArchivedMessage{
+			"orders/incoming": {
+				{ID: "msg-1", Payload: []byte(`{"n":1}`)},
+				{ID: "msg-2", Payload: []byte(`{"n":2}`)},
+				{ID: "msg-3", Payload: []byte(`{"n":3}`)},
+			},
+		},
+	}
+	svc, messageRepo := setupStandbyService(t, source)
+	ctx := context.Background()
+
+	require.NoError(t, svc.Sync(ctx))
+	stored := messageRepo.messages["orders:incoming"]
+	require.Len(t, stored, 3)
+	assert.Equal(t, "msg-3", stored[2].ID)
+	assert.Equal(t, "incoming", stored[0].Metadata["queue"])
+
+	// New messages of the primary are pulled from where the last pass stopped
+	source.messages["orders/incoming"] = append(source.messages["orders/incoming"],
+		&model.ArchivedMessage{ID: "msg-4", Payload: []byte(`{"n":4}`)})
+	require.NoError(t, svc.Sync(ctx))
+	assert.Len(t, messageRepo.messages["orders:incoming"], 4)
+
+	status := svc.Status()
+	assert.Equal(t, model.ReplicaRoleStandby, status.Role)
+	require.Len(t, status.Queues, 1)
+	assert.Equal(t, int64(4), status.Queues[0].Next)
+	assert.Equal(t, int64(4), status.Queues[0].Pulled)
+	assert.NotNil(t, status.LastSync)
+}
+
+func TestStandbyPromote(t *testing.T) {
+	svc, _ := setupStandbyService(t, &fakeReplicationSource{})
+
+	assert.True(t, svc.IsStandby())
+	require.NoError(t, svc.Promote())
+	assert.False(t, svc.IsStandby())
+
+	status := svc.Status()
+	assert.Equal(t, model.ReplicaRolePrimary, status.Role)
+	assert.NotNil(t, status.PromotedAt)
+
+	assert.ErrorIs(t, svc.Promote(), model.ErrNotStandby)
+
+	// A nil standby is a node that never followed a primary
+	var none *StandbyServiceImpl
+	assert.False(t, none.IsStandby())
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatsByLabel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	domainRepo := &mockDomainRepository{}
+	messageRepo := &mockMessageRepository{}
+
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "payments",
+		Labels: map[string]string{"team": "payments"},
+		Queues: map[string]*model.Queue{
+			"charges": {Name: "charges", DomainName: "payments"},
+			"refunds": {Name: "refunds", DomainName: "payments", Config: model.QueueConfig{
+				Labels: map[string]string{"team": "support"},
+			}},
+		},
+	})
+	domainRepo.StoreDomain(ctx, &model.Domain{
+		Name:   "misc",
+		Queues: map[string]*model.Queue{"jobs": {Name: "jobs", DomainName: "misc"}},
+	})
+
+	messageRepo.StoreMessage(ctx, "payments", "charges", &model.Message{ID: "m1"})
+	messageRepo.StoreMessage(ctx, "payments", "charges", &model.Message{ID: "m2"})
+	messageRepo.StoreMessage(ctx, "payments", "refunds", &model.Message{ID: "m3"})
+
+	svc := NewStatsService(ctx, &mockLogger{}, domainRepo, messageRepo)
+
+	result, err := svc.GetStatsByLabel(ctx, "team")
+	require.NoError(t, err)
+
+	groups := result.(map[string]any)["groups"].([]*LabelGroup)
+	require.Len(t, groups, 3)
+
+	// sorted by value, unlabeled queues first
+	assert.Equal(t, "", groups[0].Value)
+	assert.Equal(t, []string{"misc"}, groups[0].Domains)
+
+	assert.Equal(t, "payments", groups[1].Value)
+	assert.Equal(t, 1, groups[1].QueueCount)
+	assert.Equal(t, 2, groups[1].MessageCount)
+
+	assert.Equal(t, "support", groups[2].Value)
+	assert.Equal(t, 1, groups[2].MessageCount)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// GetScalingHint recommends a consumer count from the collected rates. A group
+// is sized on its own backlog, its processing rate being the publish rate
+// minus the growth of that backlog.
+func (s *StatsServiceImpl) GetScalingHint(ctx context.Context, domainName, queueName, groupID string, policy model.ScalingPolicy) (*model.ScalingHint, error) {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+
+	snapshot, exists := s.metrics.queueSnapshots[domainName+":"+queueName]
+	if !exists {
+		return nil, fmt.Errorf("%w for queue %s/%s", model.ErrNoScalingMetrics, domainName, queueName)
+	}
+
+	hint := &model.ScalingHint{
+		Domain:         domainName,
+		Queue:          queueName,
+		GroupID:        groupID,
+		Backlog:        snapshot.RepositoryCount,
+		ArrivalRate:    snapshot.PublishRate,
+		ProcessingRate: snapshot.ConsumeRate,
+	}
+
+	if groupID != "" {
+		lag, exists := s.metrics.groupLags[fmt.Sprintf("%s:%s:%s", domainName, queueName, groupID)]
+		if !exists || len(lag.samples) == 0 {
+			return nil, fmt.Errorf("%w for consumer group %s", model.ErrNoScalingMetrics, groupID)
+		}
+		growth, _ := lag.growth()
+		hint.Backlog = lag.samples[len(lag.samples)-1].backlog
+		hint.Consumers = lag.consumers
+		hint.ProcessingRate = max(snapshot.PublishRate-growth, 0)
+	} else {
+		for _, lag := range s.metrics.groupLags {
+			if lag.domain == domainName && lag.queue == queueName {
+				hint.Consumers += lag.consumers
+			}
+		}
+	}
+
+	hint.Recommend(policy)
+	return hint, nil
+}
+
+// GetBacklogs returns the queue depths of the last collection, sorted by
+// domain and queue. Group backlogs are as fresh as the last lag sample.
+func (s *StatsServiceImpl) GetBacklogs(ctx context.Context) ([]model.QueueBacklog, error) {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+
+	backlogs := make([]model.QueueBacklog, 0, len(s.metrics.queueSnapshots))
+	index := make(map[string]int, len(s.metrics.queueSnapshots))
+	for key, snapshot := range s.metrics.queueSnapshots {
+		index[key] = len(backlogs)
+		backlogs = append(backlogs, model.QueueBacklog{
+			Domain: snapshot.Domain,
+			Queue:  snapshot.Queue,
+			Depth:  snapshot.RepositoryCount,
+			Groups: make(map[string]int),
+		})
+	}
+
+	for _, lag := range s.metrics.groupLags {
+		i, exists := index[lag.domain+":"+lag.queue]
+		if !exists || len(lag.samples) == 0 {
+			continue
+		}
+		backlogs[i].Groups[lag.groupID] = lag.samples[len(lag.samples)-1].backlog
+	}
+
+	sort.Slice(backlogs, func(i, j int) bool {
+		if backlogs[i].Domain != backlogs[j].Domain {
+			return backlogs[i].Domain < backlogs[j].Domain
+		}
+		return backlogs[i].Queue < backlogs[j].Queue
+	})
+	return backlogs, nil
+}
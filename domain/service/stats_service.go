@@ -1,11 +1,14 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"math/rand"
+	"slices"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
@@ -16,7 +19,18 @@ import (
 const (
 	maxPoints     int           = 60 * 60 * 24
 	ratesInterval time.Duration = 1 * time.Second
-	maxEvents     int           = 50
+	maxEvents     int           = 50 // recent events of the dashboard, all domains
+)
+
+const (
+	// maxCollectInterval caps the backoff of the collection on large brokers
+	maxCollectInterval = 30 * time.Second
+
+	// queuesPerCollectStep adds a collection interval per step of queues
+	queuesPerCollectStep = 500
+
+	// snapshotWorkers bounds the queues read concurrently by a collection
+	snapshotWorkers = 8
 )
 
 type StatsData struct {
@@ -40,6 +54,14 @@ type StatsData struct {
 	RecentEvents []map[string]any `json:"recentEvents"`
 }
 
+// LabelGroup aggregates the queues sharing the same value for a label
+type LabelGroup struct {
+	Value        string   `json:"value"` // empty for queues without the label
+	QueueCount   int      `json:"queueCount"`
+	MessageCount int      `json:"messageCount"`
+	Domains      []string `json:"domains"`
+}
+
 type Trend struct {
 	Direction string  `json:"direction"` // "up" / "down"
 	Value     float64 `json:"value"`     // %
@@ -63,6 +85,26 @@ type QueueSnapshot struct {
 	RepositoryCount int       `json:"repositoryCount"`
 	LastUpdated     time.Time `json:"lastUpdated"`
 
+	// Per-queue throughput and footprint
+	PublishRate float64 `json:"publishRate"`
+	ConsumeRate float64 `json:"consumeRate"`
+	Bytes       int64   `json:"bytes"`
+
+	// Diverted counts the messages sent to the circuit breaker fallback queue
+	Diverted int64 `json:"diverted"`
+
+	// ImplicitGroups counts the groups created by a consume instead of
+	// explicitly
+	ImplicitGroups int64 `json:"implicitGroups"`
+
+	// Time the messages waited for their first delivery and for the ack of
+	// every group, nil until one is delivered
+	DeliveryWait *model.WaitHistogram `json:"deliveryWait,omitempty"`
+	AckWait      *model.WaitHistogram `json:"ackWait,omitempty"`
+
+	publishWindow rateWindow
+	consumeWindow rateWindow
+
 	// Alert state
 	AlertLevel string    `json:"alertLevel,omitempty"` // "", "warning", "critical"
 	AlertSince time.Time `json:"alertSince,omitempty"`
@@ -74,14 +116,28 @@ type MetricsStore struct {
 	messageRates   []MessageRate
 	queueSnapshots map[string]*QueueSnapshot // "domain:queue" -> snapshot
 
-	// Previous state to calculate trends
-	previousStats *StatsData
+	// Last GetStats result, and the number of changes to the metrics it
+	// doesn't show yet
+	snapshot atomic.Pointer[statsSnapshot]
+	version  atomic.Uint64
 
 	// Timestamp of the last collection
 	lastCollected time.Time
 
-	// Recent system events
-	systemEvents []model.SystemEvent
+	// Recent system events, in the order they were recorded, kept per
+	// domain by the retention
+	systemEvents   []model.SystemEvent
+	eventSeq       uint64 // last sequence handed to an event
+	eventRetention model.EventRetention
+
+	// Backlog samples per consumer group ("domain:queue:group")
+	groupLags map[string]*groupLag
+
+	// Collections since the last consumer backlog sample
+	collectionsSinceLagSample int
+
+	// Queues seen by the last collection, spacing the next one
+	trackedQueues int
 
 	// Root context
 	rootCtx context.Context
@@ -98,10 +154,18 @@ type StatsServiceImpl struct {
 	metrics                      *MetricsStore
 	publishCountSinceLastCollect int
 	consumeCountSinceLastCollect int
+	publishedByQueue             map[string]int // "domain:queue" -> count since last collect
+	consumedByQueue              map[string]int
+	divertedByQueue              map[string]int64 // "domain:queue" -> count since startup
+	implicitGroupsByQueue        map[string]int64 // "domain:queue" -> count since startup
 	countMu                      sync.Mutex
+	waits                        map[string]*queueWaits // "domain:queue" -> wait histograms
+	waitMu                       sync.Mutex
+	consumerGroupRepo            outbound.ConsumerGroupRepository
+	notifier                     outbound.Notifier // capacity alerts, optional
 	eventChan                    chan eventMessage
 
-	// Metrics collection interval
+	// Metrics collection interval of a small broker, see nextCollectInterval
 	collectInterval time.Duration
 
 	// Channel to stop automatic collection
@@ -109,6 +173,7 @@ type StatsServiceImpl struct {
 }
 
 type eventMessage struct {
+	domain    string
 	eventType string
 	severity  string
 	resource  string
@@ -153,7 +218,7 @@ func (s *StatsServiceImpl) eventProcessor() {
 				continue
 			}
 		}
-		s.RecordEvent(event.eventType, event.severity, event.resource, event.data)
+		s.recordEvent(event.domain, event.eventType, event.severity, event.resource, event.data)
 	}
 }
 
@@ -161,30 +226,93 @@ func (s *StatsServiceImpl) TrackMessagePublished(domainName, queueName string) {
 	s.countMu.Lock()
 	defer s.countMu.Unlock()
 	s.publishCountSinceLastCollect++
+	if s.publishedByQueue == nil {
+		s.publishedByQueue = make(map[string]int)
+	}
+	s.publishedByQueue[domainName+":"+queueName]++
 }
 
 func (s *StatsServiceImpl) TrackMessageConsumed(domainName, queueName string) {
 	s.countMu.Lock()
 	defer s.countMu.Unlock()
 	s.consumeCountSinceLastCollect++
+	if s.consumedByQueue == nil {
+		s.consumedByQueue = make(map[string]int)
+	}
+	s.consumedByQueue[domainName+":"+queueName]++
+}
+
+// TrackMessageDiverted counts a message diverted to the fallback queue of
+// the queue by an open circuit
+func (s *StatsServiceImpl) TrackMessageDiverted(domainName, queueName string) {
+	s.countMu.Lock()
+	defer s.countMu.Unlock()
+	if s.divertedByQueue == nil {
+		s.divertedByQueue = make(map[string]int64)
+	}
+	s.divertedByQueue[domainName+":"+queueName]++
+}
+
+func (s *StatsServiceImpl) divertedCount(key string) int64 {
+	s.countMu.Lock()
+	defer s.countMu.Unlock()
+	return s.divertedByQueue[key]
+}
+
+// TrackImplicitGroup counts a consumer group the first consume of the
+// queue created implicitly
+func (s *StatsServiceImpl) TrackImplicitGroup(domainName, queueName string) {
+	s.countMu.Lock()
+	defer s.countMu.Unlock()
+	if s.implicitGroupsByQueue == nil {
+		s.implicitGroupsByQueue = make(map[string]int64)
+	}
+	s.implicitGroupsByQueue[domainName+":"+queueName]++
+}
+
+func (s *StatsServiceImpl) implicitGroupCount(key string) int64 {
+	s.countMu.Lock()
+	defer s.countMu.Unlock()
+	return s.implicitGroupsByQueue[key]
 }
 
 func (s *StatsServiceImpl) startMetricsCollection() {
-	ticker := time.NewTicker(s.collectInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(s.collectInterval)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			s.collectMetrics()
+			timer.Reset(s.nextCollectInterval())
 		case <-s.stopCollect:
 			return
 		}
 	}
 }
 
+// nextCollectInterval backs the collection off as queues grow, rates being
+// computed over the elapsed time they stay exact, only coarser
+func (s *StatsServiceImpl) nextCollectInterval() time.Duration {
+	s.metrics.mu.RLock()
+	queues := s.metrics.trackedQueues
+	s.metrics.mu.RUnlock()
+	return adaptiveCollectInterval(s.collectInterval, queues)
+}
+
+// adaptiveCollectInterval adds a base interval per queuesPerCollectStep queues,
+// up to maxCollectInterval
+func adaptiveCollectInterval(base time.Duration, queues int) time.Duration {
+	interval := base * time.Duration(1+queues/queuesPerCollectStep)
+	if interval > maxCollectInterval {
+		return max(base, maxCollectInterval)
+	}
+	return interval
+}
+
 func (s *StatsServiceImpl) collectMetrics() {
 	s.metrics.mu.Lock()
+	s.metrics.version.Add(1)
 
 	now := time.Now()
 	elapsed := now.Sub(s.metrics.lastCollected).Seconds()
@@ -208,17 +336,25 @@ func (s *StatsServiceImpl) collectMetrics() {
 
 	s.publishCountSinceLastCollect = 0
 	s.consumeCountSinceLastCollect = 0
+	published, consumed := s.publishedByQueue, s.consumedByQueue
+	s.publishedByQueue, s.consumedByQueue = nil, nil
 
 	s.metrics.lastCollected = now
 
 	s.metrics.mu.Unlock()
 
-	s.updateQueueSnapshots()
+	s.updateQueueSnapshots(published, consumed, elapsed)
 }
 
+// RecordEvent records an event of the domain of the resource
 func (s *StatsServiceImpl) RecordEvent(eventType, eventSeverity, resource string, data any) {
+	s.recordEvent(model.ResourceDomain(resource), eventType, eventSeverity, resource, data)
+}
+
+func (s *StatsServiceImpl) recordEvent(domainName, eventType, eventSeverity, resource string, data any) {
 	s.metrics.mu.Lock()
 	defer s.metrics.mu.Unlock()
+	s.metrics.version.Add(1)
 
 	now := time.Now()
 
@@ -249,6 +385,7 @@ func (s *StatsServiceImpl) RecordEvent(eventType, eventSeverity, resource string
 					// Generate a new ID so the frontend detects it as a new event
 					newID := fmt.Sprintf("event-%d-%d", now.UnixNano(), rand.Intn(10000))
 					s.metrics.systemEvents[i].ID = newID
+					s.metrics.systemEvents[i].Seq = s.metrics.nextEventSeq()
 					s.metrics.systemEvents[i].Data = data
 					s.metrics.systemEvents[i].Timestamp = now
 					s.metrics.systemEvents[i].UnixTime = now.Unix()
@@ -269,6 +406,7 @@ func (s *StatsServiceImpl) RecordEvent(eventType, eventSeverity, resource string
 
 				if hasChanged {
 					// Real change → update timestamp
+					s.metrics.systemEvents[i].Seq = s.metrics.nextEventSeq()
 					s.metrics.systemEvents[i].Data = data
 					s.metrics.systemEvents[i].Type = eventSeverity
 					s.metrics.systemEvents[i].Timestamp = now
@@ -285,6 +423,8 @@ func (s *StatsServiceImpl) RecordEvent(eventType, eventSeverity, resource string
 
 	event := model.SystemEvent{
 		ID:        id,
+		Seq:       s.metrics.nextEventSeq(),
+		Domain:    domainName,
 		Type:      eventSeverity,
 		EventType: eventType,
 		Resource:  resource,
@@ -294,39 +434,174 @@ func (s *StatsServiceImpl) RecordEvent(eventType, eventSeverity, resource string
 	}
 
 	s.metrics.systemEvents = append(s.metrics.systemEvents, event)
+	s.metrics.pruneEvents(domainName, now)
+}
+
+// nextEventSeq hands out the sequence of a recorded or updated event, the
+// metrics lock must be held
+func (m *MetricsStore) nextEventSeq() uint64 {
+	m.eventSeq++
+	return m.eventSeq
+}
+
+// pruneEvents drops the oldest events of the domain past its retention and
+// the expired events of every domain, the metrics lock must be held
+func (m *MetricsStore) pruneEvents(domainName string, now time.Time) {
+	retention := m.eventRetention.WithDefaults()
+
+	count := 0
+	for _, event := range m.systemEvents {
+		if event.Domain == domainName {
+			count++
+		}
+	}
+	excess := count - retention.PerDomain
 
-	// limit
-	if len(s.metrics.systemEvents) > maxEvents {
-		s.metrics.systemEvents = s.metrics.systemEvents[len(s.metrics.systemEvents)-50:]
+	kept := m.systemEvents[:0]
+	for _, event := range m.systemEvents {
+		if event.Domain == domainName && excess > 0 {
+			excess--
+			continue
+		}
+		if retention.Expired(event, now) {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	clear(m.systemEvents[len(kept):])
+	m.systemEvents = kept
+}
+
+// SetEventRetention bounds the events kept per domain, from the next
+// recorded event
+func (s *StatsServiceImpl) SetEventRetention(retention model.EventRetention) {
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	s.metrics.eventRetention = retention
+}
+
+// GetDomainEvents returns the events of a domain recorded or updated after
+// the since sequence, oldest first
+func (s *StatsServiceImpl) GetDomainEvents(ctx context.Context, domainName string, since uint64, limit int) (*model.EventPage, error) {
+	if limit <= 0 {
+		limit = model.DefaultEventPageSize
+	}
+	limit = min(limit, model.MaxEventPageSize)
+
+	s.metrics.mu.RLock()
+	retention := s.metrics.eventRetention.WithDefaults()
+	now := time.Now()
+	events := make([]model.SystemEvent, 0)
+	for _, event := range s.metrics.systemEvents {
+		if event.Domain == domainName && event.Seq > since && !retention.Expired(event, now) {
+			events = append(events, event)
+		}
+	}
+	s.metrics.mu.RUnlock()
+
+	// updated events keep their place but get a new sequence
+	slices.SortFunc(events, func(a, b model.SystemEvent) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	page := &model.EventPage{Domain: domainName, Events: events, Next: since}
+	if len(events) > limit {
+		page.Events, page.HasMore = events[:limit], true
+	}
+	if len(page.Events) > 0 {
+		page.Next = page.Events[len(page.Events)-1].Seq
+	}
+	return page, nil
+}
+
+// HandleEvent feeds the metrics from the event bus
+func (s *StatsServiceImpl) HandleEvent(event model.DomainEvent) {
+	switch event.Kind {
+	case model.EventMessagePublished:
+		s.TrackMessagePublished(event.Domain, event.Queue)
+	case model.EventMessageConsumed:
+		s.TrackMessageConsumed(event.Domain, event.Queue)
+		if waited, ok := event.Data["waited"].(time.Duration); ok {
+			s.RecordMessageDelivered(event.Domain, event.Queue, event.MessageID, waited)
+		}
+	case model.EventMessageAcknowledged:
+		if waited, ok := event.Data["waited"].(time.Duration); ok {
+			s.RecordMessageAcknowledged(event.Domain, event.Queue, event.MessageID, waited)
+		}
+	case model.EventMessageDiverted:
+		s.TrackMessageDiverted(event.Domain, event.Queue)
+	case model.EventGroupImplicit:
+		s.TrackImplicitGroup(event.Domain, event.Queue)
+	case model.EventMessageDeadLettered:
+		dlq, _ := event.Data["deadLetterQueue"].(string)
+		reason, _ := event.Data["reason"].(string)
+		s.RecordMessageDeadLettered(event.Domain, event.Queue, dlq, reason)
+	case model.EventDomainCreated:
+		s.RecordDomainCreated(event.Domain)
+	case model.EventDomainDeleted:
+		s.RecordDomainDeleted(event.Domain)
+	case model.EventQueueCreated:
+		s.RecordQueueCreated(event.Domain, event.Queue)
+	case model.EventQueueDeleted:
+		s.RecordQueueDeleted(event.Domain, event.Queue)
+	case model.EventQueueDrainProgress:
+		s.RecordQueueDrainProgress(event.Domain, event.Queue, event.Data)
+	case model.EventRoutingRuleCreated:
+		dest, _ := event.Data["destination"].(string)
+		s.RecordRoutingRuleCreated(event.Domain, event.Queue, dest)
 	}
 }
 
 func (s *StatsServiceImpl) RecordDomainActive(name string, queueCount int) {
-	s.RecordEvent("domain_active", "info", name, map[string]any{
+	s.recordEvent(name, "domain_active", "info", name, map[string]any{
 		"queueCount": queueCount,
 	})
 }
 
 func (s *StatsServiceImpl) RecordDomainCreated(name string) {
-	s.RecordEvent("domain_created", "info", name, nil)
+	s.recordEvent(name, "domain_created", "info", name, nil)
 }
 
 func (s *StatsServiceImpl) RecordDomainDeleted(name string) {
-	s.RecordEvent("domain_deleted", "info", name, nil)
+	s.recordEvent(name, "domain_deleted", "info", name, nil)
+	s.dropWaits(name, "")
 }
 
 func (s *StatsServiceImpl) RecordQueueCreated(domain, queue string) {
 	resource := fmt.Sprintf("%s.%s", domain, queue)
-	s.RecordEvent("queue_created", "info", resource, nil)
+	s.recordEvent(domain, "queue_created", "info", resource, nil)
 }
 
 func (s *StatsServiceImpl) RecordQueueDeleted(domain, queue string) {
 	resource := fmt.Sprintf("%s.%s", domain, queue)
-	s.RecordEvent("queue_deleted", "info", resource, nil)
+	s.recordEvent(domain, "queue_deleted", "info", resource, nil)
+
+	s.countMu.Lock()
+	delete(s.divertedByQueue, domain+":"+queue)
+	delete(s.implicitGroupsByQueue, domain+":"+queue)
+	s.countMu.Unlock()
+	s.dropWaits(domain, queue)
+}
+
+func (s *StatsServiceImpl) RecordMessageDeadLettered(domain, queue, deadLetterQueue, reason string) {
+	resource := fmt.Sprintf("%s.%s", domain, queue)
+	s.recordEvent(domain, "message_dead_lettered", "warning", resource, map[string]string{
+		"deadLetterQueue": deadLetterQueue,
+		"reason":          reason,
+	})
+}
+
+func (s *StatsServiceImpl) RecordQueueDrainProgress(domain, queue string, progress map[string]any) {
+	resource := fmt.Sprintf("%s.%s", domain, queue)
+	severity := "info"
+	if _, failed := progress["error"]; failed {
+		severity = "error"
+	}
+	s.recordEvent(domain, "queue_drain", severity, resource, progress)
 }
 
 func (s *StatsServiceImpl) RecordRoutingRuleCreated(domain, source, dest string) {
-	s.RecordEvent("routing_rule_created", "info", domain, map[string]string{
+	s.recordEvent(domain, "routing_rule_created", "info", domain, map[string]string{
 		"source":      source,
 		"destination": dest,
 	})
@@ -340,12 +615,62 @@ func (s *StatsServiceImpl) RecordQueueCapacity(domain, queue string, usage float
 	}
 
 	select {
-	case s.eventChan <- eventMessage{eventType: "queue_capacity", severity: severity, resource: resource, data: usage}:
+	case s.eventChan <- eventMessage{domain: domain, eventType: "queue_capacity", severity: severity, resource: resource, data: usage}:
 	default:
 		s.metrics.logger.Warn("queue_capacity chan full skipping", "time", time.Now().Local())
 	}
 }
 
+// SetNotifier emails the queue capacity alerts
+func (s *StatsServiceImpl) SetNotifier(notifier outbound.Notifier) {
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	s.notifier = notifier
+}
+
+// notifyAlert reports a queue entering or changing its alert level,
+// called with the metrics lock held
+func (s *StatsServiceImpl) notifyAlert(domainName, queueName, level string, usage float64, since time.Time) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(context.Background(), model.Notification{
+		Type: model.NotificationAlert,
+		Data: map[string]any{
+			"Domain":   domainName,
+			"Queue":    queueName,
+			"Severity": level,
+			"Usage":    usage,
+			"Since":    since,
+		},
+	})
+	if err != nil {
+		s.metrics.logger.Warn("Alert not notified", "queue", domainName+"."+queueName, "ERROR", err)
+	}
+}
+
+// notifyAlertResolved reports a queue leaving an alert level, called with
+// the metrics lock held
+func (s *StatsServiceImpl) notifyAlertResolved(domainName, queueName, level string, usage float64, since, resolvedAt time.Time) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.Notify(context.Background(), model.Notification{
+		Type: model.NotificationAlertResolved,
+		Data: map[string]any{
+			"Domain":     domainName,
+			"Queue":      queueName,
+			"Severity":   level,
+			"Usage":      usage,
+			"Since":      since,
+			"ResolvedAt": resolvedAt,
+		},
+	})
+	if err != nil {
+		s.metrics.logger.Warn("Alert resolution not notified", "queue", domainName+"."+queueName, "ERROR", err)
+	}
+}
+
 // This one is for tests
 func (s *StatsServiceImpl) FlushEvents() {
 	done := make(chan struct{})
@@ -358,97 +683,218 @@ func (s *StatsServiceImpl) FlushEvents() {
 
 func (s *StatsServiceImpl) RecordConnectionLost(domain, queue, consumerId string) {
 	resource := fmt.Sprintf("%s.%s", domain, queue)
-	s.RecordEvent("connection_lost", "error", resource, map[string]string{
+	s.recordEvent(domain, "connection_lost", "error", resource, map[string]string{
 		"consumerId": consumerId,
 	})
 }
 
-func (s *StatsServiceImpl) updateQueueSnapshots() {
-	s.metrics.mu.Lock()
-	defer s.metrics.mu.Unlock()
+// queueReading is what a collection reads of a queue outside the metrics lock
+type queueReading struct {
+	domain, queue string
+	capacity      int
+	count         int
+	bytes         int64
+	diverted      int64
+	implicit      int64
+	deliveryWait  *model.WaitHistogram
+	ackWait       *model.WaitHistogram
+}
 
+// readQueues reads every queue with a bounded worker pool, the per-queue
+// reads dominating a collection on brokers with thousands of queues
+func (s *StatsServiceImpl) readQueues(ctx context.Context) ([]*queueReading, error) {
+	readings := make([]*queueReading, 0)
+	err := forEachDomain(ctx, s.domainRepo, func(domain *model.Domain) {
+		for queueName, queue := range domain.Queues {
+			// buffer config
+			capacity := queue.Config.MaxSize
+			if capacity <= 0 {
+				capacity = 1000
+			}
+			readings = append(readings, &queueReading{domain: domain.Name, queue: queueName, capacity: capacity})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sizer, _ := s.messageRepo.(interface {
+		GetQueueMessageBytes(domainName, queueName string) int64
+	})
+
+	jobs := make(chan *queueReading)
+	var wg sync.WaitGroup
+	for range min(snapshotWorkers, len(readings)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for reading := range jobs {
+				reading.count = s.messageRepo.GetQueueMessageCount(reading.domain, reading.queue)
+				if sizer != nil {
+					reading.bytes = sizer.GetQueueMessageBytes(reading.domain, reading.queue)
+				}
+				reading.diverted = s.divertedCount(reading.domain + ":" + reading.queue)
+				reading.implicit = s.implicitGroupCount(reading.domain + ":" + reading.queue)
+				reading.deliveryWait, reading.ackWait = s.waitHistograms(reading.domain + ":" + reading.queue)
+			}
+		}()
+	}
+	for _, reading := range readings {
+		jobs <- reading
+	}
+	close(jobs)
+	wg.Wait()
+
+	return readings, nil
+}
+
+// updateQueueSnapshots refreshes the queue snapshots with the publish and
+// consume counts per queue collected over the last elapsed seconds
+func (s *StatsServiceImpl) updateQueueSnapshots(published, consumed map[string]int, elapsed float64) {
 	ctx, cancel := context.WithTimeout(s.metrics.rootCtx, 5*time.Second)
 	defer cancel()
 
-	domains, err := s.domainRepo.ListDomains(ctx)
+	readings, err := s.readQueues(ctx)
 	if err != nil {
 		s.metrics.logger.Error("Error fetching domains for snapshots", "ERROR", err)
 		return
 	}
 
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	s.metrics.version.Add(1)
+
 	now := time.Now()
 
 	// mark all snapshots as "viewed"
-	seen := make(map[string]bool)
+	seen := make(map[string]bool, len(readings))
 
 	// TODO: use queueService to access ChannelQueues (if required)
-	for _, domain := range domains {
-		for queueName, queue := range domain.Queues {
-			key := fmt.Sprintf("%s:%s", domain.Name, queueName)
-			seen[key] = true
-
-			// buffer config
-			bufferCapacity := queue.Config.MaxSize
-			if bufferCapacity <= 0 {
-				bufferCapacity = 1000
-			}
-
-			// Stats
-			repoCount := s.messageRepo.GetQueueMessageCount(domain.Name, queueName)
-			bufferSize := repoCount // TODO: remplace with GetBufferStats()
-			usage := float64(bufferSize) / float64(bufferCapacity) * 100
-
-			// get/create snapshot
-			snapshot, exists := s.metrics.queueSnapshots[key]
-			if !exists {
-				snapshot = &QueueSnapshot{
-					Domain: domain.Name,
-					Queue:  queueName,
-				}
-				s.metrics.queueSnapshots[key] = snapshot
+	for _, reading := range readings {
+		key := fmt.Sprintf("%s:%s", reading.domain, reading.queue)
+		seen[key] = true
+
+		// Stats
+		bufferCapacity := reading.capacity
+		repoCount := reading.count
+		bufferSize := repoCount // TODO: remplace with GetBufferStats()
+		usage := float64(bufferSize) / float64(bufferCapacity) * 100
+
+		// get/create snapshot
+		snapshot, exists := s.metrics.queueSnapshots[key]
+		if !exists {
+			snapshot = &QueueSnapshot{
+				Domain: reading.domain,
+				Queue:  reading.queue,
 			}
+			s.metrics.queueSnapshots[key] = snapshot
+		}
 
-			snapshot.BufferSize = bufferSize
-			snapshot.BufferCapacity = bufferCapacity
-			snapshot.BufferUsage = usage
-			snapshot.RepositoryCount = repoCount
-			snapshot.LastUpdated = now
-
-			// Alerts management
-			previousLevel := snapshot.AlertLevel
-			newLevel := ""
+		snapshot.BufferSize = bufferSize
+		snapshot.BufferCapacity = bufferCapacity
+		snapshot.BufferUsage = usage
+		snapshot.RepositoryCount = repoCount
+		snapshot.LastUpdated = now
+
+		snapshot.publishWindow.add(published[key], elapsed)
+		snapshot.consumeWindow.add(consumed[key], elapsed)
+		snapshot.PublishRate = snapshot.publishWindow.rate()
+		snapshot.ConsumeRate = snapshot.consumeWindow.rate()
+		snapshot.Diverted = reading.diverted
+		snapshot.ImplicitGroups = reading.implicit
+		snapshot.Bytes = reading.bytes
+		snapshot.DeliveryWait = reading.deliveryWait
+		snapshot.AckWait = reading.ackWait
+
+		// Alerts management
+		previousLevel := snapshot.AlertLevel
+		newLevel := ""
+
+		if usage >= 90 {
+			newLevel = "critical"
+		} else if usage >= 75 {
+			newLevel = "warning"
+		}
 
-			if usage >= 90 {
-				newLevel = "critical"
-			} else if usage >= 75 {
-				newLevel = "warning"
+		// if state change
+		if newLevel != previousLevel {
+			// the previous level is resolved, even when replaced by another
+			if previousLevel != "" {
+				s.notifyAlertResolved(reading.domain, reading.queue, previousLevel, usage, snapshot.AlertSince, now)
 			}
-
-			// if state change
-			if newLevel != previousLevel {
-				if newLevel != "" {
-					// new alert
-					snapshot.AlertLevel = newLevel
-					snapshot.AlertSince = now
-					snapshot.AlertID = fmt.Sprintf("alert-%s-%d", key, now.UnixNano())
-
-					s.RecordQueueCapacity(domain.Name, queueName, usage)
-				} else {
-					// Alert resolved
-					snapshot.AlertLevel = ""
-					snapshot.AlertSince = time.Time{}
-					snapshot.AlertID = ""
-				}
+			if newLevel != "" {
+				// new alert
+				snapshot.AlertLevel = newLevel
+				snapshot.AlertSince = now
+				snapshot.AlertID = fmt.Sprintf("alert-%s-%d", key, now.UnixNano())
+
+				s.RecordQueueCapacity(reading.domain, reading.queue, usage)
+				s.notifyAlert(reading.domain, reading.queue, newLevel, usage, now)
+			} else {
+				// Alert resolved
+				snapshot.AlertLevel = ""
+				snapshot.AlertSince = time.Time{}
+				snapshot.AlertID = ""
 			}
 		}
 	}
 
+	s.metrics.trackedQueues = len(readings)
+
 	// clean obsolete
 	for key := range s.metrics.queueSnapshots {
 		if !seen[key] {
 			delete(s.metrics.queueSnapshots, key)
 		}
 	}
+	// consumer backlogs move slower than rates, sample them less often
+	s.metrics.collectionsSinceLagSample++
+	if s.metrics.collectionsSinceLagSample >= lagSampleEvery || s.metrics.groupLags == nil {
+		s.metrics.collectionsSinceLagSample = 0
+		s.sampleGroupLags(ctx, now)
+	}
+}
+
+// GetStatsByLabel groups queues by the value of a label, domain labels
+// being inherited by queues that don't override them
+func (s *StatsServiceImpl) GetStatsByLabel(ctx context.Context, labelKey string) (any, error) {
+	domains, err := s.domainRepo.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*LabelGroup)
+	for _, domain := range domains {
+		for queueName, queue := range domain.Queues {
+			value := queue.EffectiveLabels(domain)[labelKey]
+
+			group, exists := groups[value]
+			if !exists {
+				group = &LabelGroup{Value: value, Domains: make([]string, 0)}
+				groups[value] = group
+			}
+
+			group.QueueCount++
+			group.MessageCount += s.messageRepo.GetQueueMessageCount(domain.Name, queueName)
+			if !slices.Contains(group.Domains, domain.Name) {
+				group.Domains = append(group.Domains, domain.Name)
+			}
+		}
+	}
+
+	result := make([]*LabelGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group.Domains)
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Value < result[j].Value
+	})
+
+	return map[string]any{
+		"label":  labelKey,
+		"groups": result,
+	}, nil
 }
 
 func (s *StatsServiceImpl) GetStatsWithAggregation(ctx context.Context, period, granularity string) (any, error) {
@@ -611,17 +1057,47 @@ func (s *StatsServiceImpl) aggregateMessageRates(startTime time.Time, granularit
 	return aggregated
 }
 
+// statsSnapshot is a GetStats result, never modified once published so
+// that concurrent callers can share it
+type statsSnapshot struct {
+	version uint64 // metrics version it was built from
+	stats   *StatsData
+}
+
+// GetStats returns the published snapshot of the metrics, built again when
+// the metrics changed since. Dashboards polling concurrently share the
+// snapshot and only ever take the read lock of the metrics; the result must
+// not be modified.
 func (s *StatsServiceImpl) GetStats(ctx context.Context) (any, error) {
 	s.metrics.logger.Info("Getting system statistics")
 
-	domains, err := s.domainRepo.ListDomains(ctx)
+	version := s.metrics.version.Load()
+	published := s.metrics.snapshot.Load()
+	if published != nil && published.version == version {
+		return published.stats, nil
+	}
+
+	var previous *StatsData
+	if published != nil {
+		previous = published.stats
+	}
+	stats, err := s.buildStats(ctx, previous)
 	if err != nil {
 		return nil, err
 	}
 
-	s.metrics.mu.RLock()
-	previousStats := s.metrics.previousStats
-	s.metrics.mu.RUnlock()
+	// a concurrent call may have published a newer snapshot meanwhile
+	s.metrics.snapshot.CompareAndSwap(published, &statsSnapshot{version: version, stats: stats})
+	return stats, nil
+}
+
+// buildStats reads the metrics in a single pass under the read lock, the
+// trends comparing them with the previous snapshot
+func (s *StatsServiceImpl) buildStats(ctx context.Context, previous *StatsData) (*StatsData, error) {
+	domains, err := s.domainRepo.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	stats := &StatsData{
 		Domains:       len(domains),
@@ -657,6 +1133,7 @@ func (s *StatsServiceImpl) GetStats(ctx context.Context) (any, error) {
 			"messageCount": snapshot.BufferSize,
 			"maxSize":      snapshot.BufferCapacity,
 			"usage":        snapshot.BufferUsage,
+			"diverted":     snapshot.Diverted,
 		}
 		queueDataList = append(queueDataList, queueData)
 
@@ -670,6 +1147,23 @@ func (s *StatsServiceImpl) GetStats(ctx context.Context) (any, error) {
 			})
 		}
 	}
+
+	events := make([]map[string]any, 0, len(s.metrics.systemEvents))
+	for _, event := range s.metrics.systemEvents {
+		eventMap := map[string]any{
+			"id":        event.ID,
+			"type":      event.Type,
+			"eventType": event.EventType,
+			"resource":  event.Resource,
+			"timestamp": event.UnixTime,
+		}
+
+		if event.Data != nil {
+			eventMap["data"] = event.Data
+		}
+
+		events = append(events, eventMap)
+	}
 	s.metrics.mu.RUnlock()
 
 	// ActiveDomains for DomainPieChart
@@ -701,40 +1195,20 @@ func (s *StatsServiceImpl) GetStats(ctx context.Context) (any, error) {
 		stats.TopQueues = queueDataList
 	}
 
-	if previousStats != nil {
-		stats.DomainTrend = calculateTrend(previousStats.Domains, stats.Domains)
-		stats.QueueTrend = calculateTrend(previousStats.Queues, stats.Queues)
-		stats.MessageTrend = calculateTrend(previousStats.Messages, stats.Messages)
-		stats.RouteTrend = calculateTrend(previousStats.Routes, stats.Routes)
-	}
-
-	s.metrics.mu.Lock()
-	s.metrics.previousStats = stats
-	s.metrics.mu.Unlock()
-
-	s.metrics.mu.RLock()
-	events := make([]map[string]any, 0, len(s.metrics.systemEvents))
-	for _, event := range s.metrics.systemEvents {
-		eventMap := map[string]any{
-			"id":        event.ID,
-			"type":      event.Type,
-			"eventType": event.EventType,
-			"resource":  event.Resource,
-			"timestamp": event.UnixTime,
-		}
-
-		if event.Data != nil {
-			eventMap["data"] = event.Data
-		}
-
-		events = append(events, eventMap)
+	if previous != nil {
+		stats.DomainTrend = calculateTrend(previous.Domains, stats.Domains)
+		stats.QueueTrend = calculateTrend(previous.Queues, stats.Queues)
+		stats.MessageTrend = calculateTrend(previous.Messages, stats.Messages)
+		stats.RouteTrend = calculateTrend(previous.Routes, stats.Routes)
 	}
-	s.metrics.mu.RUnlock()
 
-	// recent first
+	// recent first, across domains
 	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
 		events[i], events[j] = events[j], events[i]
 	}
+	if len(events) > maxEvents {
+		events = events[:maxEvents]
+	}
 	stats.RecentEvents = events
 
 	return stats, nil
@@ -793,7 +1267,7 @@ func (s *StatsServiceImpl) Cleanup() {
 		s.metrics.mu.Lock()
 		s.metrics.messageRates = nil
 		s.metrics.systemEvents = nil
-		s.metrics.previousStats = nil
+		s.metrics.snapshot.Store(nil)
 		s.metrics.queueSnapshots = nil
 		s.metrics.mu.Unlock()
 
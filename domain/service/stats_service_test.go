@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +22,17 @@ func (m *mockLogger) Warn(msg string, keysAndValues ...interface{})  {}
 func (m *mockLogger) UpdateLevel(logLvl string)                      {}
 func (m *mockLogger) Shutdown()                                      {}
 
+// waitQueueInit waits for the queue service to load the stored queues, which
+// it does on a goroutine of its own reading the domains
+func waitQueueInit(t *testing.T, queueService inbound.QueueService) {
+	t.Helper()
+	select {
+	case <-queueService.(*QueueServiceImpl).initialized:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queue service didn't load the stored queues")
+	}
+}
+
 type mockDomainRepository struct {
 	domains []*model.Domain
 }
@@ -101,6 +114,18 @@ func (m *mockMessageRepository) DeleteMessage(ctx context.Context, domainName, q
 	return nil
 }
 
+func (m *mockMessageRepository) ReplaceMessage(ctx context.Context, domainName, queueName string, message *model.Message) error {
+	m.init()
+	key := domainName + ":" + queueName
+	for i, msg := range m.messages[key] {
+		if msg.ID == message.ID {
+			m.messages[key][i] = message
+			return nil
+		}
+	}
+	return nil
+}
+
 func (m *mockMessageRepository) GetMessagesAfterIndex(ctx context.Context, domainName, queueName string, startIndex int64, limit int) ([]*model.Message, error) {
 	m.init()
 	key := domainName + ":" + queueName
@@ -115,7 +140,8 @@ func (m *mockMessageRepository) GetMessagesAfterIndex(ctx context.Context, domai
 		end = len(msgs)
 	}
 
-	return msgs[startIndex:end], nil
+	// a copy, as the repositories return, deletes must not shift it
+	return append([]*model.Message(nil), msgs[startIndex:end]...), nil
 }
 
 func (m *mockMessageRepository) GetIndexByMessageID(ctx context.Context, domainName, queueName, messageID string) (int64, error) {
@@ -142,15 +168,16 @@ func (m *mockMessageRepository) GetOrCreateAckMatrix(domainName, queueName strin
 
 func (m *mockMessageRepository) AcknowledgeMessage(ctx context.Context, domainName, queueName, groupID, messageID string) (bool, error) {
 	matrix := m.GetOrCreateAckMatrix(domainName, queueName)
-	return matrix.Acknowledge(groupID, messageID), nil
+	return matrix.Acknowledge(messageID, groupID), nil
 }
 
 func (m *mockMessageRepository) ClearQueueIndices(ctx context.Context, domainName, queueName string) {
 	// Mock implementation - nothing to do
 }
 
-func (m *mockMessageRepository) CleanupMessageIndices(ctx context.Context, domainName, queueName string, minPosition int64) {
+func (m *mockMessageRepository) CleanupMessageIndices(ctx context.Context, domainName, queueName string, minPosition int64, budget int) (int, bool) {
 	// Mock implementation - nothing to do
+	return 0, false
 }
 
 func (m *mockMessageRepository) GetQueueMessageCount(domainName, queueName string) int {
@@ -596,3 +623,41 @@ func TestCalculateDomainMessageRate_SliceModification(t *testing.T) {
 
 	assert.Equal(t, 30.0, result, "Previous result should remain unchanged")
 }
+
+func TestAdaptiveCollectInterval(t *testing.T) {
+	assert.Equal(t, time.Second, adaptiveCollectInterval(time.Second, 0))
+	assert.Equal(t, time.Second, adaptiveCollectInterval(time.Second, queuesPerCollectStep-1))
+	assert.Equal(t, 3*time.Second, adaptiveCollectInterval(time.Second, 2*queuesPerCollectStep+10))
+	assert.Equal(t, maxCollectInterval, adaptiveCollectInterval(time.Second, 1_000_000))
+	assert.Equal(t, time.Minute, adaptiveCollectInterval(time.Minute, 0), "a base above the cap is kept")
+}
+
+func TestUpdateQueueSnapshotsManyQueues(t *testing.T) {
+	ctx := context.Background()
+	queues := make(map[string]int, 1200)
+	for i := range 1200 {
+		queues[fmt.Sprintf("q%d", i)] = 10
+	}
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, createTestDomain("big", queues))
+
+	messageRepo := &mockMessageRepository{}
+	for range 9 {
+		messageRepo.StoreMessage(ctx, "big", "q42", &model.Message{ID: "m"})
+	}
+
+	s := &StatsServiceImpl{
+		domainRepo:      domainRepo,
+		messageRepo:     messageRepo,
+		metrics:         setupMetricsStore(&mockLogger{}),
+		collectInterval: time.Second,
+		eventChan:       make(chan eventMessage, 10),
+	}
+	s.updateQueueSnapshots(map[string]int{"big:q7": 5}, nil, 1)
+
+	require.Len(t, s.metrics.queueSnapshots, 1200)
+	assert.Equal(t, 9, s.metrics.queueSnapshots["big:q42"].RepositoryCount)
+	assert.Equal(t, "critical", s.metrics.queueSnapshots["big:q42"].AlertLevel)
+	assert.InDelta(t, 5, s.metrics.queueSnapshots["big:q7"].PublishRate, 0.001)
+	assert.Equal(t, 3*time.Second, s.nextCollectInterval(), "collection backs off with the queue count")
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+)
+
+// Rankings served by GetTopN
+const (
+	RankingSlowConsumers = "slow-consumers" // consumer groups by backlog growth
+	RankingHotQueues     = "hot-queues"     // queues by publish rate
+	RankingLargeQueues   = "large-queues"   // queues by stored bytes
+)
+
+const (
+	// rateWindowSize is the number of collections per-queue rates are averaged on
+	rateWindowSize = 60
+
+	// lagSampleEvery spaces consumer backlog samples, counting them is linear
+	lagSampleEvery = 10
+
+	// lagHistorySize keeps a few minutes of backlog samples per group
+	lagHistorySize = 30
+
+	defaultTopN = 5
+	maxTopN     = 100
+)
+
+// QueueRanking is a queue entry of the hot and large queue rankings
+type QueueRanking struct {
+	Domain       string  `json:"domain"`
+	Queue        string  `json:"queue"`
+	PublishRate  float64 `json:"publishRate"` // messages/s over the last minute
+	ConsumeRate  float64 `json:"consumeRate"` // messages/s over the last minute
+	MessageCount int     `json:"messageCount"`
+	Bytes        int64   `json:"bytes"`
+	Diverted     int64   `json:"diverted"` // messages sent to the fallback queue
+}
+
+// ConsumerGroupRanking is an entry of the slow consumer ranking
+type ConsumerGroupRanking struct {
+	Domain    string  `json:"domain"`
+	Queue     string  `json:"queue"`
+	GroupID   string  `json:"groupId"`
+	Backlog   int     `json:"backlog"`   // messages waiting for the group ack
+	LagGrowth float64 `json:"lagGrowth"` // backlog change in messages/s, positive when falling behind
+	Window    float64 `json:"window"`    // seconds covered by the growth
+}
+
+// rateWindow sums the last samples of a counter to smooth per-queue rates
+type rateWindow struct {
+	counts  [rateWindowSize]int
+	seconds [rateWindowSize]float64
+	next    int
+}
+
+func (w *rateWindow) add(count int, elapsed float64) {
+	w.counts[w.next] = count
+	w.seconds[w.next] = elapsed
+	w.next = (w.next + 1) % rateWindowSize
+}
+
+func (w *rateWindow) rate() float64 {
+	total, seconds := 0, 0.0
+	for i := range rateWindowSize {
+		total += w.counts[i]
+		seconds += w.seconds[i]
+	}
+	if seconds == 0 {
+		return 0
+	}
+	return float64(total) / seconds
+}
+
+// lagSample is the backlog of a group at a point in time
+type lagSample struct {
+	at      time.Time
+	backlog int
+}
+
+// groupLag holds the recent backlog samples of a consumer group
+type groupLag struct {
+	domain, queue, groupID string
+	samples                []lagSample
+	consumers              int // registered at the last sample
+	seen                   bool
+}
+
+// growth returns the backlog change rate between the oldest and newest samples
+func (g *groupLag) growth() (perSecond, window float64) {
+	if len(g.samples) < 2 {
+		return 0, 0
+	}
+	first, last := g.samples[0], g.samples[len(g.samples)-1]
+	window = last.at.Sub(first.at).Seconds()
+	if window <= 0 {
+		return 0, 0
+	}
+	return float64(last.backlog-first.backlog) / window, window
+}
+
+// SetConsumerGroupRepository enables the slow consumer ranking
+func (s *StatsServiceImpl) SetConsumerGroupRepository(repo outbound.ConsumerGroupRepository) {
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	s.consumerGroupRepo = repo
+}
+
+// sampleGroupLags records the backlog of every consumer group,
+// called with the metrics lock held
+func (s *StatsServiceImpl) sampleGroupLags(ctx context.Context, now time.Time) {
+	lister, ok := s.consumerGroupRepo.(interface {
+		GetAllGroups(ctx context.Context) ([]*model.ConsumerGroup, error)
+	})
+	if !ok {
+		return
+	}
+
+	groups, err := lister.GetAllGroups(ctx)
+	if err != nil {
+		s.metrics.logger.Error("Error fetching consumer groups for lag samples", "ERROR", err)
+		return
+	}
+
+	if s.metrics.groupLags == nil {
+		s.metrics.groupLags = make(map[string]*groupLag)
+	}
+	for _, lag := range s.metrics.groupLags {
+		lag.seen = false
+	}
+
+	for _, group := range groups {
+		key := fmt.Sprintf("%s:%s:%s", group.DomainName, group.QueueName, group.GroupID)
+		lag, exists := s.metrics.groupLags[key]
+		if !exists {
+			lag = &groupLag{domain: group.DomainName, queue: group.QueueName, groupID: group.GroupID}
+			s.metrics.groupLags[key] = lag
+		}
+		lag.seen = true
+		lag.consumers = len(group.ConsumerIDs)
+		lag.samples = append(lag.samples, lagSample{at: now, backlog: group.MessageCount})
+		if len(lag.samples) > lagHistorySize {
+			lag.samples = lag.samples[len(lag.samples)-lagHistorySize:]
+		}
+	}
+
+	// forget deleted groups
+	for key, lag := range s.metrics.groupLags {
+		if !lag.seen {
+			delete(s.metrics.groupLags, key)
+		}
+	}
+}
+
+// GetTopN returns the first limit entries of a triage ranking
+func (s *StatsServiceImpl) GetTopN(ctx context.Context, ranking string, limit int) (any, error) {
+	if limit <= 0 {
+		limit = defaultTopN
+	}
+	limit = min(limit, maxTopN)
+
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+
+	switch ranking {
+	case RankingSlowConsumers:
+		return topN(s.slowConsumerRanking(), limit, func(a, b ConsumerGroupRanking) bool {
+			if a.LagGrowth != b.LagGrowth {
+				return a.LagGrowth > b.LagGrowth
+			}
+			if a.Backlog != b.Backlog {
+				return a.Backlog > b.Backlog
+			}
+			return a.Domain+":"+a.Queue+":"+a.GroupID < b.Domain+":"+b.Queue+":"+b.GroupID
+		}), nil
+
+	case RankingHotQueues:
+		return topN(s.queueRanking(), limit, func(a, b QueueRanking) bool {
+			if a.PublishRate != b.PublishRate {
+				return a.PublishRate > b.PublishRate
+			}
+			return a.Domain+":"+a.Queue < b.Domain+":"+b.Queue
+		}), nil
+
+	case RankingLargeQueues:
+		return topN(s.queueRanking(), limit, func(a, b QueueRanking) bool {
+			if a.Bytes != b.Bytes {
+				return a.Bytes > b.Bytes
+			}
+			return a.Domain+":"+a.Queue < b.Domain+":"+b.Queue
+		}), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", model.ErrUnknownRanking, ranking)
+}
+
+func (s *StatsServiceImpl) queueRanking() []QueueRanking {
+	entries := make([]QueueRanking, 0, len(s.metrics.queueSnapshots))
+	for _, snapshot := range s.metrics.queueSnapshots {
+		entries = append(entries, QueueRanking{
+			Domain:       snapshot.Domain,
+			Queue:        snapshot.Queue,
+			PublishRate:  snapshot.PublishRate,
+			ConsumeRate:  snapshot.ConsumeRate,
+			MessageCount: snapshot.RepositoryCount,
+			Bytes:        snapshot.Bytes,
+			Diverted:     snapshot.Diverted,
+		})
+	}
+	return entries
+}
+
+func (s *StatsServiceImpl) slowConsumerRanking() []ConsumerGroupRanking {
+	entries := make([]ConsumerGroupRanking, 0, len(s.metrics.groupLags))
+	for _, lag := range s.metrics.groupLags {
+		growth, window := lag.growth()
+		backlog := 0
+		if len(lag.samples) > 0 {
+			backlog = lag.samples[len(lag.samples)-1].backlog
+		}
+		entries = append(entries, ConsumerGroupRanking{
+			Domain:    lag.domain,
+			Queue:     lag.queue,
+			GroupID:   lag.groupID,
+			Backlog:   backlog,
+			LagGrowth: growth,
+			Window:    window,
+		})
+	}
+	return entries
+}
+
+// topN sorts the entries and keeps the first limit ones
+func topN[T any](entries []T, limit int, less func(a, b T) bool) []T {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i], entries[j])
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
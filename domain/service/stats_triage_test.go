@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sizedMessageRepository reports fixed byte counts per queue
+type sizedMessageRepository struct {
+	mockMessageRepository
+	bytes map[string]int64
+}
+
+func (r *sizedMessageRepository) GetQueueMessageBytes(domainName, queueName string) int64 {
+	return r.bytes[domainName+":"+queueName]
+}
+
+// groupLister serves a fixed list of consumer groups
+type groupLister struct {
+	outbound.ConsumerGroupRepository
+	groups []*model.ConsumerGroup
+}
+
+func (l *groupLister) GetAllGroups(ctx context.Context) ([]*model.ConsumerGroup, error) {
+	return l.groups, nil
+}
+
+func TestGetTopNQueues(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, createTestDomain("shop", map[string]int{"orders": 100, "audit": 100, "mails": 100}))
+
+	s := &StatsServiceImpl{
+		domainRepo: domainRepo,
+		messageRepo: &sizedMessageRepository{bytes: map[string]int64{
+			"shop:orders": 2048, "shop:audit": 1 << 20, "shop:mails": 0,
+		}},
+		metrics: setupMetricsStore(&mockLogger{}),
+	}
+	for range 2 {
+		s.HandleEvent(model.DomainEvent{Kind: model.EventMessageDiverted, Domain: "shop", Queue: "orders"})
+	}
+
+	s.updateQueueSnapshots(map[string]int{"shop:orders": 30, "shop:mails": 10}, map[string]int{"shop:orders": 20}, 1)
+	s.updateQueueSnapshots(map[string]int{"shop:orders": 10, "shop:mails": 10}, nil, 1)
+
+	hot, err := s.GetTopN(ctx, RankingHotQueues, 2)
+	require.NoError(t, err)
+	queues := hot.([]QueueRanking)
+	require.Len(t, queues, 2)
+	assert.Equal(t, "orders", queues[0].Queue)
+	assert.InDelta(t, 20, queues[0].PublishRate, 0.001, "rates are averaged over the window")
+	assert.InDelta(t, 10, queues[0].ConsumeRate, 0.001)
+	assert.Equal(t, int64(2), queues[0].Diverted, "diverted counts are cumulative")
+	assert.Equal(t, "mails", queues[1].Queue)
+
+	large, err := s.GetTopN(ctx, RankingLargeQueues, 0)
+	require.NoError(t, err)
+	queues = large.([]QueueRanking)
+	require.Len(t, queues, 3)
+	assert.Equal(t, []string{"audit", "orders", "mails"}, []string{queues[0].Queue, queues[1].Queue, queues[2].Queue})
+
+	_, err = s.GetTopN(ctx, "coldest", 5)
+	assert.True(t, errors.Is(err, model.ErrUnknownRanking))
+}
+
+func TestGetTopNSlowConsumers(t *testing.T) {
+	ctx := context.Background()
+	billing := &model.ConsumerGroup{DomainName: "shop", QueueName: "orders", GroupID: "billing", MessageCount: 10}
+	search := &model.ConsumerGroup{DomainName: "shop", QueueName: "orders", GroupID: "search", MessageCount: 500}
+
+	s := &StatsServiceImpl{metrics: setupMetricsStore(&mockLogger{})}
+	s.SetConsumerGroupRepository(&groupLister{groups: []*model.ConsumerGroup{billing, search}})
+
+	start := time.Now()
+	s.sampleGroupLags(ctx, start)
+	billing.MessageCount = 110 // falling behind
+	search.MessageCount = 400  // large backlog, but draining
+	s.sampleGroupLags(ctx, start.Add(10*time.Second))
+
+	top, err := s.GetTopN(ctx, RankingSlowConsumers, 5)
+	require.NoError(t, err)
+	groups := top.([]ConsumerGroupRanking)
+	require.Len(t, groups, 2)
+
+	assert.Equal(t, "billing", groups[0].GroupID)
+	assert.InDelta(t, 10, groups[0].LagGrowth, 0.001)
+	assert.Equal(t, 110, groups[0].Backlog)
+	assert.InDelta(t, 10, groups[0].Window, 0.001)
+	assert.Equal(t, "search", groups[1].GroupID)
+	assert.InDelta(t, -10, groups[1].LagGrowth, 0.001)
+
+	// deleted groups leave the ranking
+	s.SetConsumerGroupRepository(&groupLister{groups: []*model.ConsumerGroup{billing}})
+	s.sampleGroupLags(ctx, start.Add(20*time.Second))
+	top, _ = s.GetTopN(ctx, RankingSlowConsumers, 5)
+	assert.Len(t, top.([]ConsumerGroupRanking), 1)
+}
+
+func TestGetScalingHint(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, createTestDomain("shop", map[string]int{"orders": 100}))
+	billing := &model.ConsumerGroup{
+		DomainName: "shop", QueueName: "orders", GroupID: "billing",
+		ConsumerIDs: []string{"b1", "b2"}, MessageCount: 100,
+	}
+
+	s := &StatsServiceImpl{
+		domainRepo:  domainRepo,
+		messageRepo: &mockMessageRepository{},
+		metrics:     setupMetricsStore(&mockLogger{}),
+	}
+	s.SetConsumerGroupRepository(&groupLister{groups: []*model.ConsumerGroup{billing}})
+
+	_, err := s.GetScalingHint(ctx, "shop", "orders", "", model.ScalingPolicy{})
+	assert.ErrorIs(t, err, model.ErrNoScalingMetrics, "nothing collected yet")
+
+	start := time.Now()
+	s.sampleGroupLags(ctx, start)
+	s.updateQueueSnapshots(map[string]int{"shop:orders": 30}, map[string]int{"shop:orders": 20}, 1)
+	billing.MessageCount = 200 // 10 messages/s behind
+	s.sampleGroupLags(ctx, start.Add(10*time.Second))
+
+	hint, err := s.GetScalingHint(ctx, "shop", "orders", "", model.ScalingPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, hint.Consumers)
+	assert.InDelta(t, 30, hint.ArrivalRate, 0.001)
+	assert.InDelta(t, 20, hint.ProcessingRate, 0.001)
+
+	hint, err = s.GetScalingHint(ctx, "shop", "orders", "billing", model.ScalingPolicy{DrainTime: 20 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, 200, hint.Backlog)
+	assert.InDelta(t, 20, hint.ProcessingRate, 0.001, "publish rate minus backlog growth")
+	assert.InDelta(t, 10, hint.RatePerConsumer, 0.001)
+	assert.Equal(t, 4, hint.DesiredConsumers, "30 arriving plus 10 to drain, at 10 per consumer")
+
+	_, err = s.GetScalingHint(ctx, "shop", "orders", "search", model.ScalingPolicy{})
+	assert.ErrorIs(t, err, model.ErrNoScalingMetrics)
+}
+
+func TestGetBacklogs(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, createTestDomain("shop", map[string]int{"orders": 100, "mails": 100}))
+
+	s := &StatsServiceImpl{
+		domainRepo:  domainRepo,
+		messageRepo: &mockMessageRepository{},
+		metrics:     setupMetricsStore(&mockLogger{}),
+	}
+	s.SetConsumerGroupRepository(&groupLister{groups: []*model.ConsumerGroup{
+		{DomainName: "shop", QueueName: "orders", GroupID: "billing", MessageCount: 40},
+	}})
+	s.updateQueueSnapshots(nil, nil, 1)
+
+	backlogs, err := s.GetBacklogs(ctx)
+	require.NoError(t, err)
+	require.Len(t, backlogs, 2)
+	assert.Equal(t, "mails", backlogs[0].Queue)
+	assert.Empty(t, backlogs[0].Groups)
+	assert.Equal(t, "orders", backlogs[1].Queue)
+	assert.Equal(t, map[string]int{"billing": 40}, backlogs[1].Groups)
+}
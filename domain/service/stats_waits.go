@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// maxPendingDeliveries bounds the messages of a queue remembered as
+// delivered until every group acknowledges them. Past it, a message
+// delivered to several groups may count more than once in the delivery
+// waits.
+const maxPendingDeliveries = 10000
+
+// queueWaits holds the wait histograms of a queue
+type queueWaits struct {
+	delivery *model.WaitHistogram
+	ack      *model.WaitHistogram
+
+	// messages delivered but not yet acknowledged by every group, so that
+	// the deliveries to the other groups don't count
+	delivered map[string]struct{}
+}
+
+func (s *StatsServiceImpl) queueWaits(key string) *queueWaits {
+	if s.waits == nil {
+		s.waits = make(map[string]*queueWaits)
+	}
+	waits, exists := s.waits[key]
+	if !exists {
+		waits = &queueWaits{
+			delivery:  model.NewWaitHistogram(),
+			ack:       model.NewWaitHistogram(),
+			delivered: make(map[string]struct{}),
+		}
+		s.waits[key] = waits
+	}
+	return waits
+}
+
+// RecordMessageDelivered adds the time a message waited in its queue to the
+// delivery waits, on its first delivery only
+func (s *StatsServiceImpl) RecordMessageDelivered(domainName, queueName, messageID string, waited time.Duration) {
+	s.waitMu.Lock()
+	defer s.waitMu.Unlock()
+
+	waits := s.queueWaits(domainName + ":" + queueName)
+	if _, exists := waits.delivered[messageID]; exists {
+		return
+	}
+	waits.delivery.Observe(waited)
+	if len(waits.delivered) < maxPendingDeliveries {
+		waits.delivered[messageID] = struct{}{}
+	}
+}
+
+// RecordMessageAcknowledged adds the time a message waited until every
+// group acknowledged it to the ack waits
+func (s *StatsServiceImpl) RecordMessageAcknowledged(domainName, queueName, messageID string, waited time.Duration) {
+	s.waitMu.Lock()
+	defer s.waitMu.Unlock()
+
+	waits := s.queueWaits(domainName + ":" + queueName)
+	delete(waits.delivered, messageID)
+	waits.ack.Observe(waited)
+}
+
+// dropWaits forgets the waits of a deleted queue, or of every queue of a
+// deleted domain when queueName is empty
+func (s *StatsServiceImpl) dropWaits(domainName, queueName string) {
+	s.waitMu.Lock()
+	defer s.waitMu.Unlock()
+	if queueName != "" {
+		delete(s.waits, domainName+":"+queueName)
+		return
+	}
+	for key := range s.waits {
+		if strings.HasPrefix(key, domainName+":") {
+			delete(s.waits, key)
+		}
+	}
+}
+
+// waitHistograms copies the histograms of a queue, nil when none of its
+// messages were delivered yet
+func (s *StatsServiceImpl) waitHistograms(key string) (delivery, ack *model.WaitHistogram) {
+	s.waitMu.Lock()
+	defer s.waitMu.Unlock()
+	waits, exists := s.waits[key]
+	if !exists {
+		return nil, nil
+	}
+	return waits.delivery.Clone(), waits.ack.Clone()
+}
+
+// GetQueueWaits returns the wait histograms of the queues as last
+// collected, sorted by domain and queue
+func (s *StatsServiceImpl) GetQueueWaits(ctx context.Context) ([]model.QueueWaits, error) {
+	s.metrics.mu.RLock()
+	defer s.metrics.mu.RUnlock()
+
+	waits := make([]model.QueueWaits, 0, len(s.metrics.queueSnapshots))
+	for _, snapshot := range s.metrics.queueSnapshots {
+		entry := model.QueueWaits{
+			Domain:   snapshot.Domain,
+			Queue:    snapshot.Queue,
+			Delivery: snapshot.DeliveryWait,
+			Ack:      snapshot.AckWait,
+		}
+		if entry.Delivery == nil {
+			entry.Delivery, entry.Ack = model.NewWaitHistogram(), model.NewWaitHistogram()
+		}
+		waits = append(waits, entry)
+	}
+
+	sort.Slice(waits, func(i, j int) bool {
+		if waits[i].Domain != waits[j].Domain {
+			return waits[i].Domain < waits[j].Domain
+		}
+		return waits[i].Queue < waits[j].Queue
+	})
+	return waits, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueWaits(t *testing.T) {
+	ctx := context.Background()
+	domainRepo := &mockDomainRepository{}
+	domainRepo.StoreDomain(ctx, createTestDomain("shop", map[string]int{"orders": 100, "mails": 100}))
+
+	s := &StatsServiceImpl{
+		domainRepo:  domainRepo,
+		messageRepo: &mockMessageRepository{},
+		metrics:     setupMetricsStore(&mockLogger{}),
+	}
+
+	consumed := func(id, group string, waited time.Duration) model.DomainEvent {
+		return model.DomainEvent{
+			Kind: model.EventMessageConsumed, Domain: "shop", Queue: "orders",
+			MessageID: id, GroupID: group, Data: map[string]any{"waited": waited},
+		}
+	}
+	acked := func(id string, waited time.Duration) model.DomainEvent {
+		return model.DomainEvent{
+			Kind: model.EventMessageAcknowledged, Domain: "shop", Queue: "orders",
+			MessageID: id, Data: map[string]any{"waited": waited},
+		}
+	}
+
+	// m1 is delivered to two groups, only its first delivery counts
+	s.HandleEvent(consumed("m1", "billing", 20*time.Millisecond))
+	s.HandleEvent(consumed("m1", "shipping", 40*time.Second))
+	s.HandleEvent(acked("m1", 40*time.Second))
+	s.HandleEvent(consumed("m2", "billing", 2*time.Second))
+	s.HandleEvent(acked("m2", 2*time.Second))
+
+	s.updateQueueSnapshots(nil, nil, 1)
+	waits, err := s.GetQueueWaits(ctx)
+	require.NoError(t, err)
+	require.Len(t, waits, 2)
+
+	assert.Equal(t, "mails", waits[0].Queue)
+	assert.Zero(t, waits[0].Delivery.Count, "nothing delivered yet")
+
+	orders := waits[1]
+	assert.Equal(t, "orders", orders.Queue)
+	assert.Equal(t, int64(2), orders.Delivery.Count)
+	assert.InDelta(t, 2.02, orders.Delivery.Sum, 1e-9)
+	assert.Equal(t, int64(2), orders.Ack.Count)
+	assert.InDelta(t, 42, orders.Ack.Sum, 1e-9)
+	assert.Equal(t, 60.0, orders.Ack.Quantile(1))
+	assert.Empty(t, s.waits["shop:orders"].delivered, "forgotten once fully acked")
+
+	samples := make(map[string]float64)
+	for _, sample := range s.MetricSamples(time.Time{}) {
+		if sample.Labels["queue"] == "orders" {
+			samples[sample.Name+"{"+sample.Labels["le"]+"}"] = sample.Value
+		}
+	}
+	assert.Equal(t, 1.0, samples["gortms_queue_delivery_wait_seconds_bucket{0.05}"])
+	assert.Equal(t, 2.0, samples["gortms_queue_delivery_wait_seconds_bucket{5}"])
+	assert.Equal(t, 2.0, samples["gortms_queue_delivery_wait_seconds_bucket{+Inf}"])
+	assert.Equal(t, 2.0, samples["gortms_queue_ack_wait_seconds_count{}"])
+	assert.InDelta(t, 42, samples["gortms_queue_ack_wait_seconds_sum{}"], 1e-9)
+
+	s.RecordQueueDeleted("shop", "orders")
+	assert.NotContains(t, s.waits, "shop:orders")
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/ajkula/GoRTMS/domain/model"
+)
+
+// subscriptionKeeper is implemented by the registries keeping the
+// subscriptions of the clients gone away for them to resume
+type subscriptionKeeper interface {
+	DetachSubscription(subscriptionID string) error
+	ResumeSubscription(subscriptionID, domainName, queueName string, handler model.MessageHandler) (int64, error)
+}
+
+// DetachFromQueue ends the connection of a subscriber without ending its
+// subscription: its messages are kept for the retention window of the
+// registry, or the subscription is removed when it keeps none.
+func (s *MessageServiceImpl) DetachFromQueue(domainName, queueName, subscriptionID string) error {
+	keeper, ok := s.subscriptionReg.(subscriptionKeeper)
+	if !ok {
+		return s.subscriptionReg.UnregisterSubscription(subscriptionID)
+	}
+	return keeper.DetachSubscription(subscriptionID)
+}
+
+// ResumeSubscription hands a detached subscription to a new handler, the
+// messages published meanwhile first. missed counts the messages its full
+// buffer dropped.
+func (s *MessageServiceImpl) ResumeSubscription(
+	domainName, queueName, subscriptionID string,
+	handler model.MessageHandler,
+) (missed int64, err error) {
+	keeper, ok := s.subscriptionReg.(subscriptionKeeper)
+	if !ok {
+		return 0, model.ErrSubscriptionNotResumable
+	}
+	return keeper.ResumeSubscription(subscriptionID, domainName, queueName, handler)
+}
@@ -0,0 +1,192 @@
+// Package gortms embeds the broker in a Go application. The broker runs
+// in-process, without the HTTP, WebSocket and gRPC adapters, and is driven
+// through the services the server uses.
+//
+//	broker, err := gortms.New(context.Background(), gortms.Config{})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer broker.Close()
+//
+//	broker.Domains.CreateDomain(ctx, &model.DomainConfig{Name: "shop", ...})
+//	broker.Messages.PublishMessage("shop", "orders", &model.Message{...})
+package gortms
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	"github.com/ajkula/GoRTMS/adapter/outbound/crypto"
+	"github.com/ajkula/GoRTMS/adapter/outbound/eventbus"
+	"github.com/ajkula/GoRTMS/adapter/outbound/storage/memory"
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/ajkula/GoRTMS/domain/port/outbound"
+	"github.com/ajkula/GoRTMS/domain/service"
+)
+
+// Config chooses the adapters of an embedded broker, the zero value runs
+// entirely in memory without logs
+type Config struct {
+	// Logger receives the broker logs, discarded when nil
+	Logger outbound.Logger
+
+	// DomainRepository and MessageRepository default to the in-memory storage
+	DomainRepository  outbound.DomainRepository
+	MessageRepository outbound.MessageRepository
+
+	// ConsumerGroupRepository defaults to the in-memory storage, built on
+	// the message repository
+	ConsumerGroupRepository outbound.ConsumerGroupRepository
+
+	// EventBus defaults to an in-memory bus
+	EventBus outbound.EventBus
+
+	// BlobStore enables claim-check, payloads above ClaimCheckThreshold
+	// bytes are offloaded to it
+	BlobStore           outbound.BlobStore
+	ClaimCheckThreshold int
+
+	// FieldEncryptionKey seals the fields queues declare sensitive, queues
+	// with encrypted fields reject publishes without it
+	FieldEncryptionKey string
+}
+
+// Broker is an embedded GoRTMS, its services are those of the server
+type Broker struct {
+	Domains        inbound.DomainService
+	Queues         inbound.QueueService
+	Messages       inbound.MessageService
+	Routing        inbound.RoutingService
+	ConsumerGroups inbound.ConsumerGroupService
+	Stats          inbound.StatsService
+	Snapshots      inbound.SnapshotService
+	Erasures       inbound.ErasureService
+
+	eventBus  outbound.EventBus
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// New wires the domain services with the configured adapters. The broker
+// stops when ctx is done or Close is called.
+func New(ctx context.Context, cfg Config) (*Broker, error) {
+	if cfg.BlobStore != nil && cfg.ClaimCheckThreshold <= 0 {
+		return nil, errors.New("gortms: claim-check needs a positive threshold")
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	domainRepo := cfg.DomainRepository
+	if domainRepo == nil {
+		domainRepo = memory.NewDomainRepository(logger)
+	}
+	messageRepo := cfg.MessageRepository
+	if messageRepo == nil {
+		messageRepo = memory.NewMessageRepository(logger)
+	}
+	consumerGroupRepo := cfg.ConsumerGroupRepository
+	if consumerGroupRepo == nil {
+		consumerGroupRepo = memory.NewConsumerGroupRepository(logger, messageRepo)
+	}
+	eventBus := cfg.EventBus
+	if eventBus == nil {
+		eventBus = eventbus.NewMemoryEventBus(logger, eventbus.DefaultSubscriberBuffer)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	statsService := service.NewStatsService(ctx, logger, domainRepo, messageRepo)
+	queueService := service.NewQueueService(ctx, logger, domainRepo, statsService)
+	messageService := service.NewMessageService(
+		ctx,
+		logger,
+		domainRepo,
+		messageRepo,
+		consumerGroupRepo,
+		memory.NewSubscriptionRegistry(),
+		queueService,
+	)
+	if queueSvc, ok := queueService.(*service.QueueServiceImpl); ok {
+		queueSvc.SetMessageService(messageService)
+	}
+
+	erasureService := service.NewErasureService(ctx, logger, domainRepo, messageRepo, queueService)
+	snapshotService := service.NewSnapshotService(logger, domainRepo, messageRepo, queueService).(*service.SnapshotServiceImpl)
+	if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+		if cfg.BlobStore != nil {
+			msgSvc.SetBlobStore(cfg.BlobStore, cfg.ClaimCheckThreshold)
+			erasureService.SetBlobStore(cfg.BlobStore)
+			snapshotService.SetBlobStore(cfg.BlobStore, cfg.ClaimCheckThreshold)
+		}
+		if cfg.FieldEncryptionKey != "" {
+			msgSvc.SetFieldEncryption(crypto.NewAESCryptoService(), sha256.Sum256([]byte(cfg.FieldEncryptionKey)))
+		}
+	}
+
+	domainService := service.NewDomainService(domainRepo, queueService, ctx)
+	routingService := service.NewRoutingService(domainRepo, ctx)
+
+	for _, svc := range []any{messageService, queueService, domainService, routingService} {
+		if emitter, ok := svc.(interface{ SetEventBus(outbound.EventBus) }); ok {
+			emitter.SetEventBus(eventBus)
+		}
+	}
+	if msgSvc, ok := messageService.(*service.MessageServiceImpl); ok {
+		eventBus.Subscribe("messages", msgSvc.HandleEvent, model.EventQueueDeleted, model.EventDomainDeleted)
+	}
+	if statsSvc, ok := statsService.(*service.StatsServiceImpl); ok {
+		eventBus.Subscribe("stats", statsSvc.HandleEvent)
+		statsSvc.SetConsumerGroupRepository(consumerGroupRepo)
+	}
+
+	return &Broker{
+		Domains:        domainService,
+		Queues:         queueService,
+		Messages:       messageService,
+		Routing:        routingService,
+		ConsumerGroups: service.NewConsumerGroupService(ctx, logger, consumerGroupRepo, messageRepo),
+		Stats:          statsService,
+		Snapshots:      snapshotService,
+		Erasures:       erasureService,
+		eventBus:       eventBus,
+		cancel:         cancel,
+	}, nil
+}
+
+// Close stops the queues and the background tasks, in the server's
+// shutdown order
+func (b *Broker) Close() error {
+	b.closeOnce.Do(func() {
+		for _, svc := range []any{b.Messages, b.Queues} {
+			if cleanable, ok := svc.(interface{ Cleanup() }); ok {
+				cleanable.Cleanup()
+			}
+		}
+
+		// drain pending events before stopping their subscribers
+		b.eventBus.Close()
+
+		for _, svc := range []any{b.Stats, b.Routing, b.Domains} {
+			if cleanable, ok := svc.(interface{ Cleanup() }); ok {
+				cleanable.Cleanup()
+			}
+		}
+		b.cancel()
+	})
+	return nil
+}
+
+// nopLogger discards the logs of a broker embedded without a logger
+type nopLogger struct{}
+
+func (nopLogger) Error(string, ...any) {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) UpdateLevel(string)   {}
+func (nopLogger) Shutdown()            {}
@@ -0,0 +1,117 @@
+package gortms
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ajkula/GoRTMS/domain/model"
+	"github.com/ajkula/GoRTMS/domain/port/inbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedBroker(t *testing.T) {
+	ctx := context.Background()
+	broker, err := New(ctx, Config{})
+	require.NoError(t, err)
+	defer broker.Close()
+
+	require.NoError(t, broker.Domains.CreateDomain(ctx, &model.DomainConfig{Name: "shop"}))
+	require.NoError(t, broker.Queues.CreateQueue(ctx, "shop", "orders", &model.QueueConfig{}))
+
+	// the subscriber envelope is released once the handler returns
+	received := make(chan model.Message, 1)
+	_, err = broker.Messages.SubscribeToQueue("shop", "orders", func(message *model.Message) error {
+		received <- model.Message{ID: message.ID, Payload: slices.Clone(message.Payload)}
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Messages.PublishMessage("shop", "orders", &model.Message{
+		ID:        "order-1",
+		Payload:   []byte(`{"total":42}`),
+		Timestamp: time.Now(),
+	}))
+
+	select {
+	case message := <-received:
+		assert.Equal(t, "order-1", message.ID)
+		assert.JSONEq(t, `{"total":42}`, string(message.Payload))
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber did not receive the message")
+	}
+
+	require.NoError(t, broker.ConsumerGroups.CreateConsumerGroup(ctx, "shop", "orders", "billing", 0))
+	message, err := broker.Messages.ConsumeMessageWithGroup(ctx, "shop", "orders", "billing", &inbound.ConsumeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, message)
+	assert.Equal(t, "order-1", message.ID)
+
+	groups, err := broker.ConsumerGroups.ListConsumerGroups(ctx, "shop", "orders")
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+}
+
+func TestEmbeddedBrokerPartitionedQueue(t *testing.T) {
+	ctx := context.Background()
+	broker, err := New(ctx, Config{})
+	require.NoError(t, err)
+	defer broker.Close()
+
+	require.NoError(t, broker.Domains.CreateDomain(ctx, &model.DomainConfig{Name: "shop"}))
+	require.NoError(t, broker.Queues.CreateQueue(ctx, "shop", "orders", &model.QueueConfig{Partitions: 4}))
+	require.NoError(t, broker.ConsumerGroups.CreateConsumerGroup(ctx, "shop", "orders", "billing", 0))
+
+	// both consumers join the group before the messages arrive
+	consumers := []string{"worker-1", "worker-2"}
+	for _, consumerID := range consumers {
+		_, err := broker.Messages.ConsumeMessageWithGroup(ctx, "shop", "orders", "billing",
+			&inbound.ConsumeOptions{ConsumerID: consumerID, Timeout: 10 * time.Millisecond})
+		require.NoError(t, err)
+	}
+
+	for i := range 40 {
+		require.NoError(t, broker.Messages.PublishMessage("shop", "orders", &model.Message{
+			ID:      fmt.Sprintf("order-%d", i),
+			Payload: []byte(`{"total":42}`),
+			Headers: map[string]string{model.HeaderOrderingKey: fmt.Sprintf("customer-%d", i%8)},
+		}))
+	}
+
+	partitions := make(map[string]map[any]bool)
+	consumed := 0
+	for _, consumerID := range consumers {
+		partitions[consumerID] = make(map[any]bool)
+		for {
+			message, err := broker.Messages.ConsumeMessageWithGroup(ctx, "shop", "orders", "billing",
+				&inbound.ConsumeOptions{ConsumerID: consumerID, Timeout: 200 * time.Millisecond})
+			require.NoError(t, err)
+			if message == nil {
+				break
+			}
+			partitions[consumerID][message.Metadata[model.MetadataPartition]] = true
+			consumed++
+		}
+	}
+
+	assert.Equal(t, 40, consumed)
+	assert.Len(t, partitions["worker-1"], 2)
+	assert.Len(t, partitions["worker-2"], 2)
+	for partition := range partitions["worker-1"] {
+		assert.False(t, partitions["worker-2"][partition], "partition %v read by both consumers", partition)
+	}
+}
+
+func TestEmbeddedBrokerChecksClaimCheck(t *testing.T) {
+	_, err := New(context.Background(), Config{BlobStore: nopBlobStore{}})
+	assert.Error(t, err)
+}
+
+type nopBlobStore struct{}
+
+func (nopBlobStore) Put(context.Context, string, []byte) error   { return nil }
+func (nopBlobStore) Get(context.Context, string) ([]byte, error) { return nil, nil }
+func (nopBlobStore) Delete(context.Context, string) error        { return nil }
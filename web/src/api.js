@@ -219,7 +219,9 @@ const api = {
   createQueueMonitor(domainName, queueName, onMessage, onError) {
     try {
       const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-      const wsUrl = `${protocol}//${window.location.host}/api/ws/domains/${domainName}/queues/${queueName}`;
+      const token = authService.getToken();
+      const query = token ? `?token=${encodeURIComponent(token)}` : '';
+      const wsUrl = `${protocol}//${window.location.host}/api/ws/domains/${domainName}/queues/${queueName}${query}`;
 
       const socket = new WebSocket(wsUrl);
 
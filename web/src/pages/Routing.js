@@ -2,6 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { PlusCircle, ChevronRight, Trash2, Loader, AlertTriangle, Columns } from 'lucide-react';
 import api from '../api';
 import RoutingTester from '../components/RoutingTester';
+import { formatNumber, formatRelativeTime } from '../utils/utils';
 
 const Routing = () => {
   const [domains, setDomains] = useState([]);
@@ -369,6 +370,9 @@ const Routing = () => {
                     <span className="font-medium">{rule.SourceQueue}</span>
                     <ChevronRight className="h-5 w-5 mx-2 text-gray-400" />
                     <span className="font-medium">{rule.DestinationQueue}</span>
+                    {rule.Enabled === false && (
+                      <span className="ml-3 px-2 py-0.5 text-xs rounded-full bg-gray-100 text-gray-600">Disabled</span>
+                    )}
                   </div>
 
                   <button
@@ -401,6 +405,18 @@ const Routing = () => {
                     );
                   })()}
                 </div>
+
+                {rule.stats && (
+                  <div className="mt-2 flex flex-wrap gap-4 text-xs text-gray-500">
+                    <span>Matched: {formatNumber(rule.stats.matched)}</span>
+                    <span>Routed: {formatNumber(rule.stats.routed)}</span>
+                    {rule.stats.lastMatch ? (
+                      <span>Last match: {formatRelativeTime(Math.floor(new Date(rule.stats.lastMatch).getTime() / 1000))}</span>
+                    ) : (
+                      <span className="text-amber-600">Never matched</span>
+                    )}
+                  </div>
+                )}
               </li>
             ))}
           </ul>